@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/actions"
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/i18n"
+)
+
+var (
+	applyYes  bool
+	applyTool string
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <actions.json>",
+	Short: "Run the actions written by git explain --fix --dry-run or gh-wtfork --emit-actions",
+	Long: `Run a file of JSON actions, as written by 'git explain --fix --dry-run'
+and 'gh-wtfork --emit-actions'. Each action is an object:
+
+  {"tool": "git-explain", "repo": "/home/me/src/app", "action": "Push your
+   2 unpushed commit(s)", "command": "git push", "args": ["git", "push"],
+   "severity": "warning", "dir": "/home/me/src/app"}
+
+The file can be reviewed and edited first: drop the actions you don't
+want, or change their commands. Each action is shown and run in its dir
+once you answer y: its args as they are, without a shell, or, when it
+has none, its command with sh; a answers yes to the rest and q stops. Use
+--yes to run them all without asking. A failed command doesn't stop the
+ones after it.
+
+Use - to read the actions from stdin; answering then needs --yes. So
+does running where nobody can answer: when stdin isn't a terminal, with
+--no-input, or with GIT_THIS_BREAD_NO_INPUT or CI set, bread apply fails
+at once instead of waiting for answers.`,
+	Example: `  git explain ~/src --fix --dry-run > actions.json
+  bread apply actions.json
+  gh-wtfork --emit-actions | bread apply --yes -`,
+	Args: cobra.ExactArgs(1),
+	RunE: runApply,
+}
+
+func init() {
+	applyCmd.Flags().BoolVarP(&applyYes, "yes", "y", false, "Run every action without asking")
+	applyCmd.Flags().StringVar(&applyTool, "tool", "", "Only run the actions from this tool (git-explain, gh-wtfork)")
+	rootCmd.AddCommand(applyCmd)
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	var in io.Reader = os.Stdin
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	} else if !applyYes {
+		return errors.New("the actions come from stdin, so there is nowhere to answer from: use --yes or a file")
+	}
+	if !applyYes {
+		if err := cli.RequireInput("bread apply", i18n.T("Use --yes to run every action without asking")); err != nil {
+			return err
+		}
+	}
+
+	items, err := actions.Read(in)
+	if err != nil {
+		return err
+	}
+	if applyTool != "" {
+		var kept []actions.Action
+		for _, a := range items {
+			if a.Tool == applyTool {
+				kept = append(kept, a)
+			}
+		}
+		items = kept
+	}
+	if len(items) == 0 {
+		fmt.Println("No actions to run.")
+		return nil
+	}
+
+	res, err := actions.Apply(cmd.Context(), items, actions.ApplyOptions{Yes: applyYes})
+	return actions.Summarize(os.Stdout, res, err)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/config"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show and change settings in config.toml",
+	Long: `Show and change the settings shared by every tool, kept in
+~/.config/git-this-bread/config.toml (or $XDG_CONFIG_HOME/git-this-bread).
+
+Settings are named section.key, like explain.roots or llm.model. Lists
+are given comma separated. Each setting can be overridden with an
+environment variable named after it: GIT_THIS_BREAD_EXPLAIN_ROOTS,
+GIT_THIS_BREAD_LLM_MODEL, and so on. Flags override both.`,
+	Example: `  bread config set explain.roots ~/src,~/work
+  bread config set identity.default personal
+  bread config get llm.provider
+  bread config set wtfork.excludes ""   # back to the default`,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every setting with its current value",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		for _, s := range cfg.Settings() {
+			fmt.Printf("%s = %s\n", s.Name(), s)
+		}
+		return nil
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:               "get <setting>",
+	Short:             "Print the current value of a setting",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSettings,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		s, err := cfg.Lookup(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(s)
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:               "set <setting> <value>",
+	Short:             "Write a setting to config.toml (an empty value removes it)",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeSettings,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := config.Set(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Set %s = %s in %s\n", args[0], args[1], path)
+		return nil
+	},
+}
+
+// completeSettings completes the setting name argument
+func completeSettings(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var cfg config.Config
+	var names []string
+	for _, s := range cfg.Settings() {
+		names = append(names, s.Name())
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	configCmd.AddCommand(configListCmd, configGetCmd, configSetCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/doctor"
+)
+
+var doctorJSON bool
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that everything the tools need is in place",
+	Long: `Check everything the git-this-bread tools rely on, all at once, and list
+how to fix what isn't right:
+
+  • git, and a recent enough version
+  • git config user.email and github.user, used to find your work
+  • gh, and a version with multi-account support, for the GitHub tools
+  • config.toml, which must load
+  • every git-id profile: SSH key, email, forge and login
+  • that the GitHub API can be reached
+  • that the cache directory can be written to
+  • whether a Nerd Font is installed, for the icons
+
+✗ marks what stops a tool from working, ! what only limits it. The command
+fails when anything is marked ✗.`,
+	Example: `  bread doctor
+  bread doctor --json`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorJSON, "json", false, "Output as JSON")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	results := doctor.Run(cmd.Context(), doctor.Checks())
+
+	if doctorJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(results); err != nil {
+			return err
+		}
+	} else {
+		doctor.Write(os.Stdout, results)
+	}
+
+	if failed := doctor.Failed(results); failed > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
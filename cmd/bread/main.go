@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/cli/explain"
+	"github.com/jdevera/git-this-bread/internal/cli/ghas"
+	"github.com/jdevera/git-this-bread/internal/cli/gitas"
+	"github.com/jdevera/git-this-bread/internal/cli/id"
+	"github.com/jdevera/git-this-bread/internal/cli/wip"
+	"github.com/jdevera/git-this-bread/internal/cli/wtclone"
+	"github.com/jdevera/git-this-bread/internal/cli/wtfork"
+)
+
+// Set at build time with -ldflags "-X main.version=... -X main.commit=... -X main.date=..."
+var (
+	version = "dev"
+	commit  string
+	date    string
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "bread",
+	Short: "Git utilities for developers who knead to understand their repos",
+	Long: `bread (🍞 git-this-bread)
+
+Every git-this-bread tool in one command. Each subcommand is the tool of
+the same name, with the same flags:
+
+  bread explain   git-explain  repo status and advice
+  bread id        git-id       manage identity profiles
+  bread as        git-as       run git, gh, glab, ssh or terraform as an
+                               identity profile
+  bread gh-as     gh-as        run gh as an identity profile
+  bread wtfork    gh-wtfork    triage your GitHub forks
+  bread wtclone   gh-wtclone   reconcile local clones with GitHub
+  bread wip       git-wip      snapshot uncommitted work and stashes`,
+	Args: cobra.NoArgs,
+}
+
+func init() {
+	rootCmd.AddCommand(
+		cli.Rename(explain.Command(), "explain"),
+		cli.Rename(id.Command(), "id"),
+		gitas.DispatchCommand(),
+		cli.Rename(ghas.Command(), "gh-as"),
+		cli.Rename(wtfork.Command(), "wtfork"),
+		cli.Rename(wtclone.Command(), "wtclone"),
+		cli.Rename(wip.Command(), "wip"),
+	)
+}
+
+func main() {
+	if err := cli.Execute(rootCmd, cli.Build{Version: version, Commit: commit, Date: date}); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/identity"
+	"github.com/jdevera/git-this-bread/internal/selfupdate"
+)
+
+var (
+	selfCheck bool
+	selfAs    string
+)
+
+var selfCmd = &cobra.Command{
+	Use:   "self",
+	Short: "Manage the bread binary itself",
+}
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update bread to the latest GitHub release",
+	Long: `Check GitHub for a newer git-this-bread release and, if there is one,
+replace this bread binary with the one from the release archive. The
+archive is checked against the release checksums first.
+
+Only bread is replaced; the standalone tools installed next to it are
+left alone. Installs managed by a package manager (Homebrew, rpm) should
+be updated through it instead.
+
+Release checks are anonymous unless --as names a git-id profile, whose
+GitHub user's gh token is then used, which lifts the API rate limit.`,
+	Example: `  bread self update --check
+  bread self update
+  bread self update --as work`,
+	Args: cobra.NoArgs,
+	RunE: runSelfUpdate,
+}
+
+func init() {
+	selfUpdateCmd.Flags().BoolVar(&selfCheck, "check", false, "Only report whether an update is available")
+	selfUpdateCmd.Flags().StringVar(&selfAs, "as", "", "Query GitHub as identity profile (managed by git-id)")
+	_ = selfUpdateCmd.RegisterFlagCompletionFunc("as", cli.CompleteProfileFlag)
+	selfCmd.AddCommand(selfUpdateCmd)
+	rootCmd.AddCommand(selfCmd)
+}
+
+func runSelfUpdate(cmd *cobra.Command, args []string) error {
+	var token string
+	if selfAs != "" {
+		profile, err := identity.Get(selfAs)
+		if err != nil {
+			return err
+		}
+		if profile.GHUser == "" {
+			return fmt.Errorf("profile %q has no GitHub user configured", selfAs)
+		}
+		if token, err = identity.GHToken(profile.GHUser); err != nil {
+			return err
+		}
+	}
+
+	client := selfupdate.NewClient(token)
+	rel, err := client.Latest(cmd.Context())
+	if err != nil {
+		return err
+	}
+	current := version
+	newer, err := selfupdate.Newer(current, rel.Version())
+	if err != nil {
+		return fmt.Errorf("%w; can't tell whether %s is newer (install a release to self update)", err, rel.Tag)
+	}
+	if !newer {
+		fmt.Printf("bread %s is up to date\n", current)
+		return nil
+	}
+	fmt.Printf("bread %s is available (you have %s): %s\n", rel.Version(), current, rel.URL)
+	if selfCheck {
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	if strings.Contains(exe, "/Cellar/") {
+		return fmt.Errorf("%s is managed by Homebrew; run: brew upgrade git-this-bread", exe)
+	}
+	data, err := client.Fetch(cmd.Context(), rel, runtime.GOOS, runtime.GOARCH, "bread")
+	if err != nil {
+		return err
+	}
+	if err := selfupdate.Replace(exe, data); err != nil {
+		return fmt.Errorf("replacing %s: %w", exe, err)
+	}
+	fmt.Printf("Updated %s to %s\n", exe, rel.Version())
+	return nil
+}
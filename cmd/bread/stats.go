@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/config"
+	"github.com/jdevera/git-this-bread/internal/render"
+	"github.com/jdevera/git-this-bread/internal/stats"
+)
+
+var (
+	statsJSON  bool
+	statsSince string
+	statsReset bool
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show how often each command runs and how long it takes",
+	Long: `Show the local usage statistics: how many times each command ran, how
+many of those failed, how long they took, and which flags they were given.
+
+Nothing is recorded until you turn it on, and nothing is ever sent
+anywhere. Each run adds a line to ~/.local/state/git-this-bread/stats.jsonl
+(or $XDG_STATE_HOME/git-this-bread) with the command, the names of its
+flags, when it ran and how long it took; never arguments or flag values.`,
+	Example: `  bread config set stats.enabled true
+  bread stats
+  bread stats --since 30d
+  bread stats --reset`,
+	Args: cobra.NoArgs,
+	RunE: runStats,
+}
+
+func init() {
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "Output as JSON")
+	statsCmd.Flags().StringVar(&statsSince, "since", "", "Only count runs in this period, like 12h or 30d")
+	statsCmd.Flags().BoolVar(&statsReset, "reset", false, "Delete the recorded statistics")
+	statsCmd.MarkFlagsMutuallyExclusive("reset", "json")
+	statsCmd.MarkFlagsMutuallyExclusive("reset", "since")
+	rootCmd.AddCommand(statsCmd)
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	path, err := stats.Path()
+	if err != nil {
+		return err
+	}
+	if statsReset {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		fmt.Println("Statistics deleted.")
+		return nil
+	}
+
+	var since time.Time
+	if statsSince != "" {
+		period, err := parsePeriod(statsSince)
+		if err != nil {
+			return err
+		}
+		since = time.Now().Add(-period)
+	}
+
+	records, err := stats.Load(path)
+	if err != nil {
+		return err
+	}
+	summaries := stats.Summarize(records, since)
+	if statsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summaries)
+	}
+
+	if len(summaries) == 0 {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if !cfg.Stats.Enabled {
+			fmt.Println("Statistics are off. Turn them on with: bread config set stats.enabled true")
+		} else {
+			fmt.Println("No runs recorded yet.")
+		}
+		return nil
+	}
+
+	t := render.NewTable("Command", "Runs", "Failed", "Average", "Max", "Last run", "Top flags")
+	for i := range summaries {
+		s := &summaries[i]
+		t.AddRow(s.Command, strconv.Itoa(s.Runs), strconv.Itoa(s.Failed),
+			formatDuration(s.Average()), formatDuration(s.Max), s.Last.Format("2006-01-02"), topFlags(s.Flags, 3))
+	}
+	fmt.Println(t.String())
+	return nil
+}
+
+// parsePeriod parses a duration, also accepting a number of days like 30d
+func parsePeriod(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid --since %q: want a period like 12h or 30d", s)
+	}
+	return d, nil
+}
+
+// formatDuration rounds d to what is worth reading
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Minute:
+		return d.Round(time.Second).String()
+	case d >= time.Second:
+		return d.Round(100 * time.Millisecond).String()
+	default:
+		return d.Round(time.Millisecond).String()
+	}
+}
+
+// topFlags lists the n flags given in the most runs, with their counts
+func topFlags(flags map[string]int, n int) string {
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if flags[names[i]] != flags[names[j]] {
+			return flags[names[i]] > flags[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > n {
+		names = names[:n]
+	}
+	for i, name := range names {
+		names[i] = fmt.Sprintf("--%s (%d)", name, flags[name])
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/cli/wtclone"
+)
+
+// Set at build time with -ldflags "-X main.version=... -X main.commit=... -X main.date=..."
+var (
+	version = "dev"
+	commit  string
+	date    string
+)
+
+func main() {
+	if err := cli.Execute(wtclone.Command(), cli.Build{Version: version, Commit: commit, Date: date}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -14,22 +14,30 @@ BuildRequires:  golang >= 1.24
 
 %description
 A collection of git and GitHub CLI utilities: git-explain (repo status
-analyzer), git-as/git-id/gh-as (identity management), and gh-wtfork
-(fork analyzer).
+analyzer), git-as/git-id/gh-as (identity management), gh-wtfork
+(fork analyzer), git-wip (work-in-progress snapshots) and gh-wtclone
+(clone reconciliation), all of them also available as subcommands of bread.
 
 %prep
 %autosetup -n %{name}-%{version}
 
 %build
 LDFLAGS="-s -w -X main.version=%{version}"
-for cmd in git-explain git-id git-as gh-as gh-wtfork; do
+for cmd in git-explain git-id git-as gh-as gh-wtfork git-wip gh-wtclone bread; do
     GOFLAGS=-mod=vendor go build -ldflags "$LDFLAGS" -o "$cmd" "./cmd/$cmd"
+    "./$cmd" docs man --dir man
+    "./$cmd" completion --dir completions
 done
 
 %install
-for cmd in git-explain git-id git-as gh-as gh-wtfork; do
+for cmd in git-explain git-id git-as gh-as gh-wtfork git-wip gh-wtclone bread; do
     install -Dpm 0755 "$cmd" %{buildroot}%{_bindir}/"$cmd"
+    install -Dpm 0644 "completions/$cmd" %{buildroot}%{_datadir}/bash-completion/completions/"$cmd"
+    install -Dpm 0644 "completions/_$cmd" %{buildroot}%{_datadir}/zsh/site-functions/_"$cmd"
+    install -Dpm 0644 "completions/$cmd.fish" %{buildroot}%{_datadir}/fish/vendor_completions.d/"$cmd.fish"
 done
+install -d %{buildroot}%{_mandir}/man1
+install -pm 0644 man/*.1 %{buildroot}%{_mandir}/man1/
 
 %files
 %license LICENSE
@@ -38,6 +46,13 @@ done
 %{_bindir}/git-as
 %{_bindir}/gh-as
 %{_bindir}/gh-wtfork
+%{_bindir}/git-wip
+%{_bindir}/gh-wtclone
+%{_bindir}/bread
+%{_mandir}/man1/*.1*
+%{_datadir}/bash-completion/completions/*
+%{_datadir}/zsh/site-functions/_*
+%{_datadir}/fish/vendor_completions.d/*.fish
 
 %changelog
 * Sun Mar 22 2026 Jacobo de Vera <73069+jdevera@users.noreply.github.com> - @@VERSION@@-1
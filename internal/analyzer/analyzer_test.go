@@ -1,9 +1,16 @@
 package analyzer
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/go-git/go-git/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/testutil"
 )
 
 func TestParseShortstat(t *testing.T) {
@@ -246,6 +253,23 @@ func TestIsUserRemote(t *testing.T) {
 	}
 }
 
+func TestIsUserRemote_HostUsers(t *testing.T) {
+	SetTestConfig("test@example.com", "testuser")
+	defer ResetTestConfig()
+	AddHostUser("GitLab.Example.com", "jdoe")
+	AddHostUser("bitbucket.org", "jd")
+
+	assert.True(t, isUserRemote("git@gitlab.example.com:jdoe/repo.git"))
+	assert.True(t, isUserRemote("https://gitlab.example.com/JDoe/repo"))
+	assert.True(t, isUserRemote("ssh://git@bitbucket.org/jd/repo.git"))
+	assert.True(t, isUserRemote("git@github.com:testuser/repo.git"), "github.user still counts")
+	assert.False(t, isUserRemote("git@gitlab.example.com:team/repo.git"))
+	assert.False(t, isUserRemote("git@gitlab.com:jdoe/repo.git"), "jdoe is only registered on gitlab.example.com")
+
+	AssumeIdentity("other@example.com", "other")
+	assert.False(t, isUserRemote("git@gitlab.example.com:jdoe/repo.git"), "AssumeIdentity drops the accounts added before")
+}
+
 func TestIsUserCommit(t *testing.T) {
 	// isUserCommit requires a *object.Commit which is complex to construct
 	// without a real git repo. This is tested in integration tests instead.
@@ -256,3 +280,346 @@ func TestIsUserCommit(t *testing.T) {
 		// This is tested in integration_test.go with real commits
 	})
 }
+
+func TestParseLocalBranches(t *testing.T) {
+	output := "main|origin/main|[ahead 2, behind 1]|*\n" +
+		"feature|origin/feature|[gone]|\n" +
+		"local-only|||\n" +
+		"behind|origin/behind|[behind 4]|\n"
+
+	branches := parseLocalBranches(output)
+
+	assert.Equal(t, []BranchInfo{
+		{Name: "main", IsCurrent: true, Upstream: "origin/main", Ahead: 2, Behind: 1},
+		{Name: "feature", Upstream: "origin/feature", UpstreamGone: true},
+		{Name: "local-only"},
+		{Name: "behind", Upstream: "origin/behind", Behind: 4},
+	}, branches)
+
+	info := RepoInfo{LocalBranches: branches}
+	assert.Equal(t, []string{"feature"}, info.GoneBranches())
+}
+
+func TestUnpushed(t *testing.T) {
+	info := RepoInfo{
+		Ahead: 2,
+		LocalBranches: []BranchInfo{
+			{Name: "main", IsCurrent: true, Upstream: "origin/main", Ahead: 2},
+			{Name: "feature", Upstream: "origin/feature", Ahead: 3, Behind: 1},
+			{Name: "fix", Upstream: "origin/fix", Ahead: 2},
+			{Name: "gone", Upstream: "origin/gone", UpstreamGone: true},
+			{Name: "synced", Upstream: "origin/synced"},
+		},
+	}
+
+	others, commits := info.OtherUnpushed()
+	assert.Equal(t, []string{"feature", "fix"}, others)
+	assert.Equal(t, 5, commits)
+
+	branches, commits := info.Unpushed()
+	assert.Equal(t, 3, branches)
+	assert.Equal(t, 7, commits)
+
+	info.Ahead = 0
+	branches, commits = info.Unpushed()
+	assert.Equal(t, 2, branches)
+	assert.Equal(t, 5, commits)
+}
+
+func TestParseStashSubject(t *testing.T) {
+	tests := []struct {
+		subject string
+		branch  string
+		message string
+	}{
+		{"On feature/auth: WIP auth fix", "feature/auth", "WIP auth fix"},
+		{"WIP on main: 1a2b3c4 Add login form", "main", "WIP: 1a2b3c4 Add login form"},
+		{"On main: message: with colon", "main", "message: with colon"},
+		{"something else", "", "something else"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			branch, message := parseStashSubject(tt.subject)
+			assert.Equal(t, tt.branch, branch)
+			assert.Equal(t, tt.message, message)
+		})
+	}
+}
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		host  string
+		owner string
+		repo  string
+	}{
+		{"git@github.com:jdevera/git-this-bread.git", "github.com", "jdevera", "git-this-bread"},
+		{"https://github.com/jdevera/git-this-bread", "github.com", "jdevera", "git-this-bread"},
+		{"https://github.com/jdevera/git-this-bread.git/", "github.com", "jdevera", "git-this-bread"},
+		{"ssh://git@gitlab.example.com:2222/group/sub/project.git", "gitlab.example.com", "group/sub", "project"},
+		{"git://git.kernel.org/pub/scm/git/git.git", "git.kernel.org", "pub/scm/git", "git"},
+		{"gitea.local:team/tool", "gitea.local", "team", "tool"},
+		{"/srv/git/project.git", "", "/srv/git", "project"},
+		{"file:///srv/git/project.git", "", "/srv/git", "project"},
+		{"", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			host, owner, repo := ParseRemoteURL(tt.url)
+			assert.Equal(t, tt.host, host, "host")
+			assert.Equal(t, tt.owner, owner, "owner")
+			assert.Equal(t, tt.repo, repo, "repo")
+		})
+	}
+}
+
+func TestParseCountObjects(t *testing.T) {
+	output := "count: 12\nsize: 48\nin-pack: 3000\npacks: 2\nsize-pack: 1024\nprune-packable: 0\ngarbage: 0\nsize-garbage: 0\n"
+
+	assert.Equal(t, &DiskUsage{
+		LooseObjects:  12,
+		LooseBytes:    48 * 1024,
+		PackedObjects: 3000,
+		Packs:         2,
+		PackBytes:     1024 * 1024,
+	}, parseCountObjects(output))
+}
+
+func TestSortBySize(t *testing.T) {
+	repos := []RepoInfo{
+		{Name: "none"},
+		{Name: "small", DiskUsage: &DiskUsage{GitDirBytes: 10}},
+		{Name: "big", DiskUsage: &DiskUsage{GitDirBytes: 10, WorkTreeBytes: 100}},
+	}
+
+	SortBySize(repos)
+
+	var names []string
+	for _, r := range repos {
+		names = append(names, r.Name)
+	}
+	assert.Equal(t, []string{"big", "small", "none"}, names)
+}
+
+func TestClassify(t *testing.T) {
+	unreachable := false
+	tests := []struct {
+		name     string
+		info     RepoInfo
+		expected Category
+	}{
+		{"plain directory", RepoInfo{}, CategoryNotGit},
+		{"clone", RepoInfo{IsGitRepo: true}, CategoryUntouched},
+		{"own commits", RepoInfo{IsGitRepo: true, TotalUserCommits: 3}, CategoryActive},
+		{"own remote", RepoInfo{IsGitRepo: true, HasUserRemote: true}, CategoryActive},
+		{"dirty clone", RepoInfo{IsGitRepo: true, HasUncommittedChanges: true}, CategoryNeedsAttention},
+		{"unpushed", RepoInfo{IsGitRepo: true, TotalUserCommits: 3, Ahead: 1}, CategoryNeedsAttention},
+		{"unpushed elsewhere", RepoInfo{IsGitRepo: true, TotalUserCommits: 3, LocalBranches: []BranchInfo{{Name: "feature", Upstream: "origin/feature", Ahead: 2}}}, CategoryNeedsAttention},
+		{"stash", RepoInfo{IsGitRepo: true, StashCount: 1}, CategoryNeedsAttention},
+		{"error", RepoInfo{IsGitRepo: true, Error: "boom"}, CategoryNeedsAttention},
+		{"rebase", RepoInfo{IsGitRepo: true, Operation: &OperationDetails{State: OpRebase}}, CategoryNeedsAttention},
+		{"detached only", RepoInfo{IsGitRepo: true, Operation: &OperationDetails{DetachedHEAD: true}}, CategoryUntouched},
+		{
+			"unreachable remote",
+			RepoInfo{IsGitRepo: true, AllRemotes: []RemoteInfo{{Name: "origin", Reachable: &unreachable}}},
+			CategoryNeedsAttention,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Classify(&tt.info))
+		})
+	}
+}
+
+func TestGroupByCategory(t *testing.T) {
+	repos := []RepoInfo{
+		{Name: "b", IsGitRepo: true, TotalUserCommits: 1},
+		{Name: "c", IsGitRepo: true},
+		{Name: "a", IsGitRepo: true, TotalUserCommits: 1},
+	}
+
+	groups := GroupByCategory(repos)
+	require.Len(t, groups[CategoryActive], 2)
+	assert.Equal(t, "b", groups[CategoryActive][0].Name, "input order kept within a group")
+	assert.Equal(t, "a", groups[CategoryActive][1].Name)
+	require.Len(t, groups[CategoryUntouched], 1)
+	assert.Empty(t, groups[CategoryNeedsAttention])
+}
+
+func TestParsePruneDryRun(t *testing.T) {
+	output := "Pruning origin\nURL: git@github.com:user/repo.git\n" +
+		" * [would prune] origin/old-feature\n" +
+		" * [would prune] origin/fix/typo\n"
+
+	stale := parsePruneDryRun(output)
+	assert.Equal(t, []string{"origin/old-feature", "origin/fix/typo"}, stale)
+
+	branches := []BranchInfo{
+		{Name: "main", Upstream: "origin/main"},
+		{Name: "old-feature", Upstream: "origin/old-feature"},
+	}
+	markStaleUpstreams(branches, stale)
+	assert.False(t, branches[0].UpstreamGone)
+	assert.True(t, branches[1].UpstreamGone)
+}
+
+func TestGetDirtyDetails_FakeRunner(t *testing.T) {
+	fake := testutil.NewFakeGit().
+		On("status --porcelain", "M  staged.go\n M modified.go\nR  old.go -> new.go\n?? notes.txt\n").
+		On("diff --cached --shortstat", " 2 files changed, 7 insertions(+), 1 deletion(-)\n").
+		On("diff --shortstat", " 1 file changed, 3 insertions(+)\n")
+	defer SetGitRunner(fake)()
+
+	dirty, details := getDirtyDetails(context.Background(), "/repo")
+
+	require.True(t, dirty)
+	assert.Equal(t, []string{"staged.go", "new.go"}, details.StagedNames)
+	assert.Equal(t, []string{"modified.go"}, details.UnstagedNames)
+	assert.Equal(t, []string{"notes.txt"}, details.UntrackedNames)
+	assert.Equal(t, 7, details.StagedInsertions)
+	assert.Equal(t, 1, details.StagedDeletions)
+	assert.Equal(t, 3, details.UnstagedInsertions)
+}
+
+func TestGetDirtyDetails_FakeRunnerError(t *testing.T) {
+	defer SetGitRunner(testutil.NewFakeGit().Fail("status --porcelain", 128))()
+
+	dirty, details := getDirtyDetails(context.Background(), "/repo")
+
+	assert.False(t, dirty)
+	assert.Nil(t, details)
+}
+
+func TestListStashes_FakeRunner(t *testing.T) {
+	fake := testutil.NewFakeGit().
+		On("stash list --format=%gd|%gs|%ar", "stash@{0}|On main: tidy up|2 days ago\nstash@{1}|WIP on dev: abc123 Start|3 weeks ago\n").
+		On("stash show --name-only stash@{0}", "a.go\nb.go\n")
+	defer SetGitRunner(fake)()
+
+	stashes := ListStashes(context.Background(), "/repo")
+
+	assert.Equal(t, []StashInfo{
+		{Index: 0, Branch: "main", Message: "tidy up", Date: "2 days ago", Files: []string{"a.go", "b.go"}},
+		{Index: 1, Branch: "dev", Message: "WIP: abc123 Start", Date: "3 weeks ago"},
+	}, stashes)
+}
+
+func TestProbeRemote_FakeRunner(t *testing.T) {
+	tests := []struct {
+		name      string
+		fake      *testutil.FakeGit
+		reachable bool
+	}{
+		{"refs listed", testutil.NewFakeGit().On("ls-remote --exit-code -q origin", "abc\trefs/heads/main\n"), true},
+		{"empty remote", testutil.NewFakeGit().Fail("ls-remote --exit-code -q origin", 2), true},
+		{"connection failure", testutil.NewFakeGit().Fail("ls-remote --exit-code -q origin", 128), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer SetGitRunner(tt.fake)()
+			assert.Equal(t, tt.reachable, probeRemote(context.Background(), "/repo", "origin"))
+		})
+	}
+}
+
+func TestLoadGitConfig_FakeRunner(t *testing.T) {
+	ResetTestConfig()
+	defer ResetTestConfig()
+	defer SetGitRunner(testutil.NewFakeGit().On("config user.email", "me@example.com\n"))()
+
+	err := LoadGitConfig()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "github.user")
+	assert.Equal(t, "me@example.com", userEmail)
+}
+
+func TestRemoteOnlyBranches(t *testing.T) {
+	fake := testutil.NewFakeGit().
+		On("ls-remote --heads mine", "aaa\trefs/heads/main\nbbb\trefs/heads/laptop-work\nccc\trefs/heads/tracked\nddd\trefs/heads/new\n").
+		On("log -1 --format=%cs bbb --", "2026-01-05\n")
+	defer SetGitRunner(fake)()
+
+	local := []BranchInfo{
+		{Name: "main", Upstream: "mine/main"},
+		{Name: "renamed", Upstream: "mine/tracked"},
+	}
+	branches := remoteOnlyBranches(context.Background(), "", "mine", local)
+	assert.Equal(t, []RemoteBranch{
+		{Remote: "mine", Name: "laptop-work", Hash: "bbb", LastCommitDate: "2026-01-05"},
+		{Remote: "mine", Name: "new", Hash: "ddd"},
+	}, branches)
+}
+
+func TestGetMaintenance(t *testing.T) {
+	fake := testutil.NewFakeGit().
+		On("config --get gc.auto", "0\n").
+		On("rev-parse --show-toplevel", "/src/app\n").
+		On("config --global --get-all maintenance.repo", "/src/other\n/src/app\n")
+	defer SetGitRunner(fake)()
+
+	m := getMaintenance(context.Background(), "/src/app")
+
+	assert.Equal(t, &Maintenance{AutoGCDisabled: true, Registered: true}, m)
+	assert.False(t, m.Unmaintained())
+	assert.True(t, (&Maintenance{}).Unmaintained())
+}
+
+func TestBranchOverlaps(t *testing.T) {
+	fake := testutil.NewFakeGit().
+		On("diff --name-only main...feature/auth --", "auth.go\nlogin.go\n").
+		On("diff --name-only main...docs --", "README.md\n")
+	defer SetGitRunner(fake)()
+
+	branches := []BranchInfo{{Name: "main"}, {Name: "fix", IsCurrent: true}, {Name: "feature/auth"}, {Name: "docs"}}
+	dirty := &DirtyDetails{UnstagedNames: []string{"login.go", "main.go"}, UntrackedNames: []string{"login.go"}}
+	stashes := []StashInfo{{Index: 0, Files: []string{"README.md"}}}
+
+	overlaps := branchOverlaps(context.Background(), "/repo", "fix", "main", branches, dirty, stashes)
+
+	assert.Equal(t, []BranchOverlap{
+		{Source: WorkingTreeSource, Branch: "feature/auth", Files: []string{"login.go"}},
+		{Source: "stash@{0}", Branch: "docs", Files: []string{"README.md"}},
+	}, overlaps)
+	assert.Nil(t, branchOverlaps(context.Background(), "/repo", "fix", "", branches, dirty, stashes))
+}
+
+func TestMatchesAny(t *testing.T) {
+	patterns := []string{"vendor", "*-archive"}
+	assert.True(t, matchesAny("vendor", patterns))
+	assert.True(t, matchesAny("old-archive", patterns))
+	assert.False(t, matchesAny("vendored", patterns))
+	assert.False(t, matchesAny("vendor", nil))
+}
+
+func TestFindRepos(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"app", "lib", ".hidden", "old-archive"} {
+		_, err := git.PlainInit(filepath.Join(root, name), false)
+		require.NoError(t, err)
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(root, "notes"), 0o750))
+
+	assert.Equal(t, []string{filepath.Join(root, "app"), filepath.Join(root, "lib")},
+		FindRepos(root, []string{"*-archive"}))
+	assert.Equal(t, []string{filepath.Join(root, "lib")}, FindRepos(filepath.Join(root, "lib"), nil))
+	assert.Empty(t, FindRepos(filepath.Join(root, "notes"), nil))
+}
+
+func TestSubdirsOnly(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"acme-api", "acme-web", "acme-old", "blog", ".cache"} {
+		require.NoError(t, os.Mkdir(filepath.Join(root, name), 0o750))
+	}
+
+	assert.Equal(t, []string{filepath.Join(root, "acme-api"), filepath.Join(root, "acme-web")},
+		subdirs(root, []string{"*-old"}, []string{"acme-*"}))
+	assert.Equal(t, []string{filepath.Join(root, "acme-web"), filepath.Join(root, "blog")},
+		subdirs(root, nil, []string{"blog", "acme-web"}))
+	assert.Len(t, subdirs(root, nil, nil), 4)
+}
@@ -0,0 +1,56 @@
+package analyzer
+
+// Category groups repositories by what they need from the user
+type Category string
+
+const (
+	CategoryActive         Category = "active"          // Has your commits or remote, nothing pending
+	CategoryNeedsAttention Category = "needs-attention" // Local work at risk, or the repo is in a broken state
+	CategoryUntouched      Category = "untouched"       // A clone you never contributed to
+	CategoryNotGit         Category = "not-git"         // Plain directory
+)
+
+// Categories lists categories in display order
+var Categories = []Category{CategoryActive, CategoryNeedsAttention, CategoryUntouched, CategoryNotGit}
+
+// Classify returns the category a repository belongs in.
+// Anything with unfinished local work needs attention, regardless of
+// whether you've contributed to it.
+func Classify(info *RepoInfo) Category {
+	if !info.IsGitRepo {
+		return CategoryNotGit
+	}
+	if info.needsAttention() {
+		return CategoryNeedsAttention
+	}
+	if info.HasUserRemote || info.TotalUserCommits > 0 {
+		return CategoryActive
+	}
+	return CategoryUntouched
+}
+
+func (r *RepoInfo) needsAttention() bool {
+	if r.Error != "" || r.TimedOut {
+		return true
+	}
+	if _, unpushed := r.Unpushed(); r.HasUncommittedChanges || unpushed > 0 || r.StashCount > 0 {
+		return true
+	}
+	if op := r.Operation; op != nil && (op.State != OpNone || len(op.ConflictFiles) > 0) {
+		return true
+	}
+	if r.LFS != nil && r.LFS.AtRisk() {
+		return true
+	}
+	return len(r.UnreachableRemotes()) > 0
+}
+
+// GroupByCategory splits repos into categories, keeping their order within each group
+func GroupByCategory(repos []RepoInfo) map[Category][]*RepoInfo {
+	groups := make(map[Category][]*RepoInfo)
+	for i := range repos {
+		c := Classify(&repos[i])
+		groups[c] = append(groups[c], &repos[i])
+	}
+	return groups
+}
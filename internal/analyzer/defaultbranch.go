@@ -0,0 +1,110 @@
+package analyzer
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Where the default branch of a repository was found, for
+// RepoInfo.DefaultBranchSource
+const (
+	DefaultBranchFromConfig = "config"       // explain.defaultBranch in the repository's git config
+	DefaultBranchFromHead   = "remote-head"  // A remote's HEAD, as the last clone or fetch left it
+	DefaultBranchFromRemote = "remote-query" // Asked the remote, with ProbeRemotes
+	DefaultBranchFromName   = "name"         // The first local branch with one of the usual names
+)
+
+// DefaultBranchNames are the branch names tried, in order, when nothing
+// says which branch is the default
+var DefaultBranchNames = []string{"main", "master", "trunk", "develop"}
+
+// detectDefaultBranch finds the default branch of the repository and where
+// it came from: explain.defaultBranch in its git config, then the HEAD of
+// origin or another remote, then, with probe, the HEAD the reachable
+// remotes report, then the first of names, or DefaultBranchNames, that is
+// a local branch. It returns empty strings when none says.
+func detectDefaultBranch(ctx context.Context, repo *git.Repository, dir string, remotes []RemoteInfo, probe bool, names []string) (branch, source string) {
+	if cfg, err := repo.Config(); err == nil {
+		if name := strings.TrimSpace(cfg.Raw.Section("explain").Option("defaultBranch")); name != "" {
+			return name, DefaultBranchFromConfig
+		}
+	}
+
+	order := remoteOrder(remotes)
+	for _, remote := range order {
+		ref, err := repo.Reference(plumbing.NewRemoteReferenceName(remote, "HEAD"), true)
+		if err == nil {
+			return strings.TrimPrefix(ref.Name().Short(), remote+"/"), DefaultBranchFromHead
+		}
+	}
+
+	if probe {
+		for _, remote := range order {
+			if !reachable(remotes, remote) {
+				continue
+			}
+			if name := queryRemoteHead(ctx, dir, remote); name != "" {
+				return name, DefaultBranchFromRemote
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		names = DefaultBranchNames
+	}
+	for _, name := range names {
+		if _, err := repo.Reference(plumbing.NewBranchReferenceName(name), false); err == nil {
+			return name, DefaultBranchFromName
+		}
+	}
+	return "", ""
+}
+
+// reachable reports whether the remote called name answered the
+// reachability probe
+func reachable(remotes []RemoteInfo, name string) bool {
+	for _, r := range remotes {
+		if r.Name == name {
+			return r.Reachable != nil && *r.Reachable
+		}
+	}
+	return false
+}
+
+// remoteOrder returns the names of remotes, origin first
+func remoteOrder(remotes []RemoteInfo) []string {
+	names := []string{"origin"}
+	for _, r := range remotes {
+		if !slices.Contains(names, r.Name) {
+			names = append(names, r.Name)
+		}
+	}
+	return names
+}
+
+// queryRemoteHead asks remote which branch its HEAD points to, as
+// git ls-remote --symref reports it (network access)
+func queryRemoteHead(ctx context.Context, dir, remote string) string {
+	ctx, cancel := context.WithTimeout(ctx, remoteProbeTimeout)
+	defer cancel()
+
+	out, err := gitRunner.Run(ctx, dir, "ls-remote", "--symref", remote, "HEAD")
+	if err != nil {
+		return ""
+	}
+	// "ref: refs/heads/main\tHEAD"
+	for _, line := range strings.Split(out, "\n") {
+		target, ok := strings.CutPrefix(line, "ref: refs/heads/")
+		if !ok {
+			continue
+		}
+		if name, _, ok := strings.Cut(target, "\t"); ok {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/testutil"
+)
+
+func TestDetectDefaultBranch(t *testing.T) {
+	ctx := context.Background()
+	repo, err := git.PlainInit(t.TempDir(), false)
+	require.NoError(t, err)
+	hash := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+	setRef := func(ref *plumbing.Reference) {
+		require.NoError(t, repo.Storer.SetReference(ref))
+	}
+	detect := func(remotes []RemoteInfo, probe bool, names []string) (string, string) {
+		return detectDefaultBranch(ctx, repo, "", remotes, probe, names)
+	}
+
+	branch, source := detect(nil, false, nil)
+	assert.Empty(t, branch)
+	assert.Empty(t, source)
+
+	setRef(plumbing.NewHashReference(plumbing.NewBranchReferenceName("develop"), hash))
+	setRef(plumbing.NewHashReference(plumbing.NewBranchReferenceName("trunk"), hash))
+	branch, source = detect(nil, false, nil)
+	assert.Equal(t, "trunk", branch, "trunk comes before develop")
+	assert.Equal(t, DefaultBranchFromName, source)
+	branch, _ = detect(nil, false, []string{"develop"})
+	assert.Equal(t, "develop", branch, "explain.default_branches replaces the names")
+
+	reachable := true
+	remotes := []RemoteInfo{{Name: "upstream", Reachable: &reachable}}
+	defer SetGitRunner(testutil.NewFakeGit().
+		On("ls-remote --symref upstream HEAD", "ref: refs/heads/stable\tHEAD\n"+hash.String()+"\tHEAD\n"))()
+	branch, _ = detect(remotes, false, nil)
+	assert.Equal(t, "trunk", branch, "remotes are only asked with probe")
+	branch, source = detect(remotes, true, nil)
+	assert.Equal(t, "stable", branch)
+	assert.Equal(t, DefaultBranchFromRemote, source)
+
+	setRef(plumbing.NewHashReference(plumbing.NewRemoteReferenceName("upstream", "release"), hash))
+	setRef(plumbing.NewSymbolicReference(plumbing.NewRemoteReferenceName("upstream", "HEAD"),
+		plumbing.NewRemoteReferenceName("upstream", "release")))
+	branch, source = detect(remotes, true, nil)
+	assert.Equal(t, "release", branch)
+	assert.Equal(t, DefaultBranchFromHead, source)
+
+	cfg, err := repo.Config()
+	require.NoError(t, err)
+	cfg.Raw.Section("explain").SetOption("defaultbranch", "prod")
+	require.NoError(t, repo.SetConfig(cfg))
+	branch, source = detect(remotes, true, nil)
+	assert.Equal(t, "prod", branch)
+	assert.Equal(t, DefaultBranchFromConfig, source)
+}
+
+func TestQueryRemoteHead(t *testing.T) {
+	defer SetGitRunner(testutil.NewFakeGit().Fail("ls-remote --symref origin HEAD", 128))()
+	assert.Empty(t, queryRemoteHead(context.Background(), "", "origin"))
+}
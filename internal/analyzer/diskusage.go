@@ -0,0 +1,155 @@
+package analyzer
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Thresholds mirroring git's own gc.auto and gc.autoPackLimit defaults
+const (
+	looseObjectsGCThreshold = 6700
+	packsGCThreshold        = 50
+)
+
+// DiskUsage holds repository size and object storage statistics
+type DiskUsage struct {
+	GitDirBytes   int64 `json:"git_dir_bytes"`
+	WorkTreeBytes int64 `json:"work_tree_bytes"`
+	LooseObjects  int   `json:"loose_objects"`
+	LooseBytes    int64 `json:"loose_bytes"`
+	PackedObjects int   `json:"packed_objects"`
+	Packs         int   `json:"packs"`
+	PackBytes     int64 `json:"pack_bytes"`
+	GarbageBytes  int64 `json:"garbage_bytes,omitempty"`
+}
+
+// TotalBytes returns the combined size of the git dir and working tree
+func (d *DiskUsage) TotalBytes() int64 {
+	return d.GitDirBytes + d.WorkTreeBytes
+}
+
+// NeedsGC reports whether git would consider the repository due for garbage
+// collection, or it has garbage files left that gc cleans up
+func (d *DiskUsage) NeedsGC() bool {
+	return d.LooseObjects > looseObjectsGCThreshold || d.Packs > packsGCThreshold || d.GarbageBytes > 0
+}
+
+// Maintenance is how a repository is kept in shape: git's automatic gc and
+// background maintenance (git maintenance start)
+type Maintenance struct {
+	AutoGCDisabled bool   `json:"auto_gc_disabled,omitempty"` // gc.auto is 0
+	Strategy       string `json:"strategy,omitempty"`         // maintenance.strategy
+	Registered     bool   `json:"registered,omitempty"`       // Listed in the global maintenance.repo
+}
+
+// Unmaintained reports whether no background maintenance runs for the
+// repository
+func (m *Maintenance) Unmaintained() bool {
+	return m.Strategy == "" && !m.Registered
+}
+
+// getMaintenance reads the maintenance settings of the repository in dir
+func getMaintenance(ctx context.Context, dir string) *Maintenance {
+	m := &Maintenance{
+		AutoGCDisabled: strings.TrimSpace(runGit(ctx, dir, "config", "--get", "gc.auto")) == "0",
+		Strategy:       strings.TrimSpace(runGit(ctx, dir, "config", "--get", "maintenance.strategy")),
+	}
+	top := strings.TrimSpace(runGit(ctx, dir, "rev-parse", "--show-toplevel"))
+	for _, repo := range strings.Split(runGit(ctx, dir, "config", "--global", "--get-all", "maintenance.repo"), "\n") {
+		if repo = strings.TrimSpace(repo); repo != "" && top != "" && filepath.Clean(repo) == filepath.Clean(top) {
+			m.Registered = true
+		}
+	}
+	return m
+}
+
+// getDiskUsage collects object stats from `git count-objects -v` and sizes the
+// git dir and working tree on disk. Returns nil if the git dir can't be found.
+func getDiskUsage(ctx context.Context, dir string) *DiskUsage {
+	gitDir := strings.TrimSpace(runGit(ctx, dir, "rev-parse", "--absolute-git-dir"))
+	if gitDir == "" {
+		return nil
+	}
+
+	usage := parseCountObjects(runGit(ctx, dir, "count-objects", "-v"))
+	usage.GitDirBytes = dirSize(ctx, gitDir, "")
+	if strings.TrimSpace(runGit(ctx, dir, "rev-parse", "--is-bare-repository")) != "true" {
+		usage.WorkTreeBytes = dirSize(ctx, dir, gitDir)
+	}
+	return usage
+}
+
+// parseCountObjects parses `git count-objects -v` output (sizes are in KiB)
+func parseCountObjects(output string) *DiskUsage {
+	usage := &DiskUsage{}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "count":
+			usage.LooseObjects = int(n)
+		case "size":
+			usage.LooseBytes = n * 1024
+		case "in-pack":
+			usage.PackedObjects = int(n)
+		case "packs":
+			usage.Packs = int(n)
+		case "size-pack":
+			usage.PackBytes = n * 1024
+		case "size-garbage":
+			usage.GarbageBytes = n * 1024
+		}
+	}
+	return usage
+}
+
+// dirSize sums the sizes of regular files under root, skipping the skip
+// directory and any nested .git directories. Stops early if ctx is done.
+func dirSize(ctx context.Context, root, skip string) int64 {
+	var total int64
+	_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // unreadable entries are skipped, not fatal
+		}
+		if ctx.Err() != nil {
+			return fs.SkipAll
+		}
+		if d.IsDir() {
+			if p != root && (p == skip || d.Name() == ".git") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if fi, err := d.Info(); err == nil {
+				total += fi.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// SortBySize orders repos by total disk usage, largest first. Repos without
+// disk usage data sort last, keeping their relative order.
+func SortBySize(repos []RepoInfo) {
+	size := func(r *RepoInfo) int64 {
+		if r.DiskUsage == nil {
+			return -1
+		}
+		return r.DiskUsage.TotalBytes()
+	}
+	sort.SliceStable(repos, func(i, j int) bool {
+		return size(&repos[i]) > size(&repos[j])
+	})
+}
@@ -0,0 +1,140 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+)
+
+// Fingerprint captures cheap-to-read repository state used to decide whether
+// a previous analysis can be reused
+type Fingerprint struct {
+	Head        string `json:"head"`         // Symbolic ref and commit of HEAD
+	IndexMTime  int64  `json:"index_mtime"`  // Unix nanoseconds, 0 when there is no index
+	Refs        string `json:"refs"`         // Checksum of every ref and its target
+	ConfigMTime int64  `json:"config_mtime"` // Of the repository's config, for remote URLs, gc and maintenance settings
+	StashLog    string `json:"stash_log"`    // Size and mtime of the stash reflog, which dropping a stash other than the latest changes alone
+	Options     string `json:"options"`      // Analysis options the result was computed with
+}
+
+// computeFingerprint reads HEAD, the index, config and stash reflog mtimes
+// and all refs.
+// Returns nil when the directory is not a git repository.
+func computeFingerprint(ctx context.Context, dir string, opts Options) *Fingerprint {
+	out := runGit(ctx, dir, "rev-parse", "--path-format=absolute", "--absolute-git-dir", "--git-common-dir")
+	gitDir, commonDir, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	if gitDir == "" {
+		return nil
+	}
+	if commonDir == "" {
+		commonDir = gitDir
+	}
+
+	fp := &Fingerprint{
+		// Empty repos have no HEAD commit; the symbolic ref still distinguishes them
+		Head: strings.TrimSpace(runGit(ctx, dir, "symbolic-ref", "-q", "HEAD")) + "@" +
+			strings.TrimSpace(runGit(ctx, dir, "rev-parse", "-q", "--verify", "HEAD")),
+		Options: optionsKey(opts),
+	}
+	if fi, err := os.Stat(filepath.Join(gitDir, "index")); err == nil {
+		fp.IndexMTime = fi.ModTime().UnixNano()
+	}
+	if fi, err := os.Stat(filepath.Join(commonDir, "config")); err == nil {
+		fp.ConfigMTime = fi.ModTime().UnixNano()
+	}
+	if fi, err := os.Stat(filepath.Join(commonDir, "logs", "refs", "stash")); err == nil {
+		fp.StashLog = fmt.Sprintf("%d@%d", fi.Size(), fi.ModTime().UnixNano())
+	}
+	sum := sha256.Sum256([]byte(runGit(ctx, dir, "for-each-ref", "--format=%(refname) %(objectname)")))
+	fp.Refs = hex.EncodeToString(sum[:])
+	return fp
+}
+
+// optionsKey sums up the options a result depends on, so a cached one is
+// only reused with the same: labels and default branch names from the
+// config file, read-only remote patterns, exclusions
+func optionsKey(opts Options) string {
+	globs := make([]string, 0, len(opts.Labels))
+	for glob, label := range opts.Labels {
+		globs = append(globs, glob+"="+label)
+	}
+	slices.Sort(globs)
+	return fmt.Sprintf("verbose=%t max=%d disk=%t branches=%s readonly=%s labels=%s excludes=%s",
+		opts.Verbose, opts.MaxCommits, opts.DiskUsage,
+		strings.Join(opts.BranchNames, ","), strings.Join(opts.ReadOnly, ","),
+		strings.Join(globs, ","), strings.Join(opts.Excludes, ","))
+}
+
+// AnalyzeRepoCached analyzes the repository at path, reusing prev where the
+// repository hasn't moved on. When HEAD, refs, config and options match
+// prev's fingerprint, only the working tree status, the branches it
+// overlaps, in-progress operation and labels are refreshed; otherwise a
+// full AnalyzeRepo runs. Changed reports whether the result differs from
+// prev.
+//
+// Working tree edits don't touch the index, so dirty status is always
+// re-read. Disk usage is reused as-is until refs change. Options that need
+// the network (Fetch, ProbeRemotes) always force a full analysis, as do
+// partial results (timeouts, truncated walks).
+func AnalyzeRepoCached(path string, prev *RepoInfo, opts Options) (info RepoInfo, changed bool) {
+	ctx := context.Background()
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	fp := computeFingerprint(ctx, path, opts)
+	reuse := canReuse(prev, fp, opts)
+	debuglog.Cache("repo-analysis", path, reuse)
+	if !reuse {
+		info = AnalyzeRepo(path, opts)
+		info.Fingerprint = fp
+		return info, true
+	}
+
+	info = *prev
+	info.Fingerprint = fp
+	if repo, err := openRepo(path); err == nil {
+		info.Labels = getLabels(repo, info.Name, opts.Labels)
+	}
+	if !info.IsBare {
+		info.HasUncommittedChanges, info.DirtyDetails = getDirtyDetails(ctx, path)
+		info.Operation = DetectOperationState(ctx, path)
+		info.BranchOverlaps = branchOverlaps(ctx, path, info.CurrentBranch, info.DefaultBranch,
+			info.LocalBranches, info.DirtyDetails, info.Stashes)
+	}
+	info.TimedOut = ctx.Err() != nil
+
+	changed = fp.IndexMTime != prev.Fingerprint.IndexMTime ||
+		info.HasUncommittedChanges != prev.HasUncommittedChanges ||
+		!slices.Equal(info.Labels, prev.Labels) ||
+		!reflect.DeepEqual(info.DirtyDetails, prev.DirtyDetails) ||
+		!reflect.DeepEqual(info.BranchOverlaps, prev.BranchOverlaps) ||
+		!reflect.DeepEqual(info.Operation, prev.Operation)
+	return info, changed
+}
+
+// canReuse reports whether prev's expensive sections are still valid for fp
+func canReuse(prev *RepoInfo, fp *Fingerprint, opts Options) bool {
+	if prev == nil || prev.Fingerprint == nil || fp == nil {
+		return false
+	}
+	if opts.Fetch || opts.ProbeRemotes || prev.TimedOut || prev.Error != "" {
+		return false
+	}
+	if prev.CommitsTruncated || (prev.Commits != nil && prev.Commits.Truncated) {
+		return false
+	}
+	p := prev.Fingerprint
+	return p.Head == fp.Head && p.Refs == fp.Refs && p.ConfigMTime == fp.ConfigMTime &&
+		p.StashLog == fp.StashLog && p.Options == fp.Options
+}
@@ -3,8 +3,12 @@
 package analyzer
 
 import (
+	"context"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -44,6 +48,27 @@ func TestAnalyzeRepo_WithUserCommits(t *testing.T) {
 	assert.NotEmpty(t, info.LastCommitDate)
 }
 
+func TestAnalyzeRepo_WeeklyActivity(t *testing.T) {
+	repo := testutil.NewTestRepo(t)
+	SetTestConfig("test@example.com", "testuser")
+	defer ResetTestConfig()
+
+	now := time.Now()
+	for _, ago := range []time.Duration{2 * 365 * 24 * time.Hour, 22 * 24 * time.Hour, 0} {
+		repo.Git("commit", "--allow-empty", "-m", "work", "--date", now.Add(-ago).Format(time.RFC3339))
+	}
+	repo.WriteFile("file.txt", "content")
+	repo.CommitAs("Other commit", "other@example.com", "Other User")
+
+	info := AnalyzeRepo(repo.Path, Options{})
+
+	assert.Equal(t, 3, info.TotalUserCommits)
+	require.Len(t, info.Commits.Weekly, ActivityWeeks)
+	assert.Equal(t, 1, info.Commits.Weekly[ActivityWeeks-1], "this week")
+	assert.Equal(t, 1, info.Commits.Weekly[ActivityWeeks-4], "three weeks ago")
+	assert.Equal(t, 2, info.Commits.LastYear(), "the commit of two years ago is left out")
+}
+
 func TestAnalyzeRepo_WithMixedCommits(t *testing.T) {
 	repo := testutil.NewTestRepo(t)
 	SetTestConfig("test@example.com", "testuser")
@@ -278,9 +303,9 @@ func TestGetDirtyDetails(t *testing.T) {
 			setup: func(r *testutil.TestRepo) {
 				r.WriteFile("file.txt", "content")
 				r.Commit("Initial")
-				r.WriteFile("file.txt", "modified")      // unstaged
-				r.WriteFile("new.txt", "new")            // will stage
-				r.Stage("new.txt")                       // staged
+				r.WriteFile("file.txt", "modified")       // unstaged
+				r.WriteFile("new.txt", "new")             // will stage
+				r.Stage("new.txt")                        // staged
 				r.WriteFile("untracked.txt", "untracked") // untracked
 			},
 			expected: &DirtyDetails{
@@ -296,7 +321,7 @@ func TestGetDirtyDetails(t *testing.T) {
 			repo := testutil.NewTestRepo(t)
 			tt.setup(repo)
 
-			dirty, details := getDirtyDetails(repo.Path)
+			dirty, details := getDirtyDetails(context.Background(), repo.Path)
 
 			if tt.expected == nil {
 				assert.False(t, dirty)
@@ -335,3 +360,347 @@ func TestIsUserCommit_Integration(t *testing.T) {
 		assert.Equal(t, 0, info.TotalUserCommits)
 	})
 }
+
+func TestAnalyzeRepo_UpstreamDivergence(t *testing.T) {
+	SetTestConfig("test@example.com", "testuser")
+	defer ResetTestConfig()
+
+	upstream := testutil.NewTestRepo(t)
+	upstream.WriteFile("file.txt", "base")
+	upstream.Commit("Base")
+	upstream.Git("branch", "-M", "main")
+
+	repo := testutil.NewTestRepo(t)
+	repo.AddRemote("origin", "git@github.com:testuser/repo.git")
+	repo.AddRemote("upstream", upstream.Path)
+	repo.Git("fetch", "upstream")
+	repo.Git("checkout", "-B", "main", "upstream/main")
+
+	// One commit only in the fork, two only upstream
+	repo.WriteFile("mine.txt", "mine")
+	repo.Commit("Fork change")
+	upstream.WriteFile("file.txt", "upstream 1")
+	upstream.Commit("Upstream 1")
+	upstream.WriteFile("file.txt", "upstream 2")
+	upstream.Commit("Upstream 2")
+
+	t.Run("without fetch uses stale refs", func(t *testing.T) {
+		info := AnalyzeRepo(repo.Path, Options{})
+		require.True(t, info.IsFork)
+		assert.Equal(t, "upstream", info.UpstreamRemote)
+		assert.Equal(t, 1, info.UpstreamAhead)
+		assert.Equal(t, 0, info.UpstreamBehind)
+	})
+
+	t.Run("with fetch sees new upstream commits", func(t *testing.T) {
+		info := AnalyzeRepo(repo.Path, Options{Fetch: true})
+		assert.Equal(t, 1, info.UpstreamAhead)
+		assert.Equal(t, 2, info.UpstreamBehind)
+	})
+}
+
+func TestListStashes(t *testing.T) {
+	repo := testutil.NewTestRepo(t)
+	repo.WriteFile("file1.txt", "content1")
+	repo.Commit("Initial commit")
+	repo.Git("checkout", "-b", "feature/auth")
+
+	repo.WriteFile("file1.txt", "modified")
+	repo.WriteFile("file2.txt", "new")
+	repo.Stage("file2.txt")
+	repo.Stash()
+
+	stashes := ListStashes(context.Background(), repo.Path)
+
+	require.Len(t, stashes, 1)
+	assert.Equal(t, 0, stashes[0].Index)
+	assert.Equal(t, "feature/auth", stashes[0].Branch)
+	assert.Equal(t, "test stash", stashes[0].Message)
+	assert.ElementsMatch(t, []string{"file1.txt", "file2.txt"}, stashes[0].Files)
+}
+
+func TestAnalyzeRepo_MaxCommits(t *testing.T) {
+	repo := testutil.NewTestRepo(t)
+	SetTestConfig("test@example.com", "testuser")
+	defer ResetTestConfig()
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		repo.WriteFile(name+".txt", name)
+		repo.Commit("Add " + name)
+	}
+
+	info := AnalyzeRepo(repo.Path, Options{MaxCommits: 2})
+
+	assert.True(t, info.CommitsTruncated)
+	assert.True(t, info.Commits.Truncated)
+	assert.Equal(t, 2, info.TotalUserCommits)
+	assert.False(t, info.TimedOut)
+
+	info = AnalyzeRepo(repo.Path, Options{MaxCommits: 10})
+	assert.False(t, info.CommitsTruncated)
+	assert.Equal(t, 4, info.TotalUserCommits)
+}
+
+func TestAnalyzeRepo_MaxCommitsAheadBehind(t *testing.T) {
+	repo := testutil.NewTestRepo(t)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		repo.WriteFile(name+".txt", name)
+		repo.Commit("Add " + name)
+	}
+	branch := strings.TrimSpace(repo.Git("rev-parse", "--abbrev-ref", "HEAD"))
+	repo.Git("update-ref", "refs/remotes/origin/"+branch, "HEAD")
+	repo.Git("config", "branch."+branch+".remote", "origin")
+	repo.Git("config", "branch."+branch+".merge", "refs/heads/"+branch)
+	repo.WriteFile("e.txt", "e")
+	repo.Commit("Add e")
+
+	// A history longer than the budget shows no phantom commits behind
+	info := AnalyzeRepo(repo.Path, Options{MaxCommits: 2})
+	assert.Equal(t, 1, info.Ahead)
+	assert.Equal(t, 0, info.Behind)
+	assert.False(t, info.DivergenceTruncated, "the walk meets the remote before the budget runs out")
+
+	// Diverged: the remote moved on too
+	repo.Git("update-ref", "refs/remotes/origin/"+branch, repo.Git("commit-tree", "-p", "HEAD~1", "-m", "Upstream", "HEAD~1^{tree}")[:40])
+	info = AnalyzeRepo(repo.Path, Options{MaxCommits: 2})
+	assert.Equal(t, 1, info.Ahead)
+	assert.Equal(t, 1, info.Behind)
+	assert.False(t, info.DivergenceTruncated)
+
+	info = AnalyzeRepo(repo.Path, Options{MaxCommits: 1})
+	assert.Equal(t, 2, info.Ahead+info.Behind, "one past the budget")
+	assert.True(t, info.DivergenceTruncated)
+	assert.True(t, info.CommitsTruncated)
+}
+
+func TestDetectOperationState(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("clean repo", func(t *testing.T) {
+		repo := testutil.NewTestRepo(t)
+		repo.WriteFile("file.txt", "content")
+		repo.Commit("Initial")
+
+		assert.Nil(t, DetectOperationState(ctx, repo.Path))
+	})
+
+	t.Run("empty repo", func(t *testing.T) {
+		repo := testutil.NewTestRepo(t)
+
+		op := DetectOperationState(ctx, repo.Path)
+		require.NotNil(t, op)
+		assert.True(t, op.Empty)
+	})
+
+	t.Run("detached HEAD", func(t *testing.T) {
+		repo := testutil.NewTestRepo(t)
+		repo.WriteFile("file.txt", "content")
+		repo.Commit("Initial")
+		repo.Git("checkout", "--detach")
+
+		op := DetectOperationState(ctx, repo.Path)
+		require.NotNil(t, op)
+		assert.True(t, op.DetachedHEAD)
+	})
+
+	t.Run("merge with conflicts", func(t *testing.T) {
+		repo := testutil.NewTestRepo(t)
+		repo.WriteFile("file.txt", "base")
+		repo.Commit("Base")
+		repo.Git("checkout", "-b", "other")
+		repo.WriteFile("file.txt", "other")
+		repo.Commit("Other")
+		repo.Git("checkout", "-")
+		repo.WriteFile("file.txt", "mine")
+		repo.Commit("Mine")
+		_, err := repo.GitMayFail("merge", "other")
+		require.Error(t, err)
+
+		op := DetectOperationState(ctx, repo.Path)
+		require.NotNil(t, op)
+		assert.Equal(t, OpMerge, op.State)
+		assert.Equal(t, []string{"file.txt"}, op.ConflictFiles)
+	})
+}
+
+func TestAnalyzeRepo_PruneCandidates(t *testing.T) {
+	SetTestConfig("test@example.com", "testuser")
+	defer ResetTestConfig()
+
+	remote := testutil.NewTestRepo(t)
+	remote.WriteFile("file.txt", "base")
+	remote.Commit("Base")
+	remote.Git("branch", "-M", "main")
+	remote.Git("branch", "old-feature")
+
+	repo := testutil.NewTestRepo(t)
+	repo.AddRemote("origin", remote.Path)
+	repo.Git("fetch", "origin")
+	repo.Git("checkout", "-B", "main", "origin/main")
+	repo.Git("branch", "--track", "old-feature", "origin/old-feature")
+
+	remote.Git("branch", "-D", "old-feature")
+
+	t.Run("offline analysis does not see deletions", func(t *testing.T) {
+		info := AnalyzeRepo(repo.Path, Options{})
+		assert.Empty(t, info.StaleRemoteRefs)
+		assert.Empty(t, info.GoneBranches())
+	})
+
+	t.Run("probing finds stale refs and their branches", func(t *testing.T) {
+		info := AnalyzeRepo(repo.Path, Options{ProbeRemotes: true})
+		require.Len(t, info.AllRemotes, 1)
+		require.NotNil(t, info.AllRemotes[0].Reachable)
+		assert.True(t, *info.AllRemotes[0].Reachable)
+		assert.Equal(t, []string{"origin/old-feature"}, info.StaleRemoteRefs)
+		assert.Equal(t, []string{"old-feature"}, info.GoneBranches())
+	})
+}
+
+func TestAnalyzeRepoCached(t *testing.T) {
+	SetTestConfig("test@example.com", "testuser")
+	defer ResetTestConfig()
+
+	repo := testutil.NewTestRepo(t)
+	repo.WriteFile("file.txt", "one")
+	repo.Commit("First")
+
+	opts := Options{Verbose: true}
+	first, changed := AnalyzeRepoCached(repo.Path, nil, opts)
+	require.True(t, changed)
+	require.NotNil(t, first.Fingerprint)
+	assert.Equal(t, 1, first.TotalUserCommits)
+
+	t.Run("nothing changed", func(t *testing.T) {
+		again, changed := AnalyzeRepoCached(repo.Path, &first, opts)
+		assert.False(t, changed)
+		assert.Equal(t, first, again)
+	})
+
+	t.Run("working tree edit refreshes dirty state only", func(t *testing.T) {
+		repo.WriteFile("file.txt", "edited")
+		again, changed := AnalyzeRepoCached(repo.Path, &first, opts)
+		assert.True(t, changed)
+		assert.True(t, again.HasUncommittedChanges)
+		assert.Equal(t, first.Commits, again.Commits)
+	})
+
+	t.Run("new commit triggers full analysis", func(t *testing.T) {
+		repo.Commit("Second")
+		again, changed := AnalyzeRepoCached(repo.Path, &first, opts)
+		assert.True(t, changed)
+		assert.Equal(t, 2, again.TotalUserCommits)
+		assert.NotEqual(t, first.Fingerprint.Head, again.Fingerprint.Head)
+	})
+
+	t.Run("different options trigger full analysis", func(t *testing.T) {
+		_, changed := AnalyzeRepoCached(repo.Path, &first, Options{})
+		assert.True(t, changed)
+		for _, o := range []Options{
+			{Verbose: true, BranchNames: []string{"trunk"}},
+			{Verbose: true, ReadOnly: []string{"git.example.com"}},
+			{Verbose: true, Labels: map[string]string{"*": "work"}},
+			{Verbose: true, Excludes: []string{"vendor"}},
+		} {
+			assert.NotEqual(t, first.Fingerprint.Options, optionsKey(o))
+		}
+	})
+
+	t.Run("dropping an older stash triggers full analysis", func(t *testing.T) {
+		for _, content := range []string{"stash one", "stash two"} {
+			repo.WriteFile("file.txt", content)
+			repo.Git("stash", "push", "--quiet")
+		}
+		latest, _ := AnalyzeRepoCached(repo.Path, &first, opts)
+		require.Equal(t, 2, latest.StashCount)
+
+		repo.Git("stash", "drop", "--quiet", "stash@{1}")
+		again, changed := AnalyzeRepoCached(repo.Path, &latest, opts)
+		assert.True(t, changed)
+		assert.Equal(t, 1, again.StashCount)
+	})
+
+	t.Run("config edit triggers full analysis", func(t *testing.T) {
+		latest, _ := AnalyzeRepoCached(repo.Path, &first, opts)
+		// Within the same tick, the mtime wouldn't move
+		time.Sleep(10 * time.Millisecond)
+		repo.Git("remote", "add", "origin", "https://example.com/me/app.git")
+		again, changed := AnalyzeRepoCached(repo.Path, &latest, opts)
+		assert.True(t, changed)
+		assert.NotEqual(t, latest.Fingerprint.ConfigMTime, again.Fingerprint.ConfigMTime)
+		require.Len(t, again.AllRemotes, 1)
+	})
+}
+
+func TestAnalyzeRepoCached_BranchOverlaps(t *testing.T) {
+	repo := testutil.NewTestRepo(t)
+	repo.WriteFile("file.txt", "one")
+	repo.Commit("First")
+	repo.Git("branch", "-M", "main")
+	repo.Git("checkout", "-q", "-b", "feature")
+	repo.WriteFile("auth.go", "package auth")
+	repo.Commit("Add auth")
+	repo.Git("checkout", "-q", "main")
+	repo.Git("checkout", "-q", "-b", "fix")
+
+	first, _ := AnalyzeRepoCached(repo.Path, nil, Options{})
+	require.Equal(t, "main", first.DefaultBranch)
+	assert.Empty(t, first.BranchOverlaps)
+
+	// Editing what another branch changed is seen without a full analysis
+	repo.WriteFile("auth.go", "package auth // here by mistake")
+	again, changed := AnalyzeRepoCached(repo.Path, &first, Options{})
+	assert.True(t, changed)
+	assert.Equal(t, first.Fingerprint.Refs, again.Fingerprint.Refs, "reused")
+	assert.Equal(t, []BranchOverlap{{Source: WorkingTreeSource, Branch: "feature", Files: []string{"auth.go"}}}, again.BranchOverlaps)
+}
+
+func TestAnalyzeRepo_Layouts(t *testing.T) {
+	SetTestConfig("test@example.com", "testuser")
+	defer ResetTestConfig()
+
+	repo := testutil.NewTestRepo(t)
+	repo.WriteFile("file.txt", "content")
+	repo.Commit("Initial commit")
+	repo.Git("branch", "-M", "main")
+	dir := t.TempDir()
+
+	t.Run("bare", func(t *testing.T) {
+		bare := filepath.Join(dir, "bare.git")
+		repo.Git("clone", "--quiet", "--bare", repo.Path, bare)
+
+		info := AnalyzeRepo(bare, Options{DiskUsage: true})
+		require.True(t, info.IsGitRepo)
+		assert.True(t, info.IsBare)
+		assert.False(t, info.IsMirror)
+		assert.Equal(t, "main", info.CurrentBranch)
+		assert.Equal(t, 1, info.TotalUserCommits)
+		assert.False(t, info.HasUncommittedChanges)
+		require.NotNil(t, info.DiskUsage)
+		assert.Zero(t, info.DiskUsage.WorkTreeBytes)
+	})
+
+	t.Run("mirror", func(t *testing.T) {
+		mirror := filepath.Join(dir, "mirror.git")
+		repo.Git("clone", "--quiet", "--mirror", repo.Path, mirror)
+
+		info := AnalyzeRepo(mirror, Options{})
+		assert.True(t, info.IsBare)
+		assert.True(t, info.IsMirror)
+	})
+
+	t.Run("linked worktree", func(t *testing.T) {
+		wt := filepath.Join(dir, "wt")
+		repo.Git("worktree", "add", "--quiet", "-b", "feature", wt)
+
+		info := AnalyzeRepo(wt, Options{})
+		assert.False(t, info.IsBare)
+		assert.Equal(t, "feature", info.CurrentBranch)
+		assert.Equal(t, 1, info.TotalUserCommits)
+		main, err := filepath.EvalSymlinks(repo.Path)
+		require.NoError(t, err)
+		assert.Equal(t, main, info.WorktreeOf)
+
+		assert.Empty(t, AnalyzeRepo(repo.Path, Options{}).WorktreeOf, "the main worktree is not a linked one")
+	})
+}
@@ -0,0 +1,91 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxIntentBranches caps how many branches branchOverlaps diffs, so repos
+// with hundreds of branches don't pay a diff for each
+const maxIntentBranches = 30
+
+// BranchOverlap is uncommitted work, in the working tree or a stash, that
+// touches files another branch changed: a sign it belongs on that branch
+type BranchOverlap struct {
+	Source string   `json:"source"` // "working tree" or stash@{N}
+	Branch string   `json:"branch"` // The branch whose changes it touches
+	Files  []string `json:"files"`
+}
+
+// WorkingTreeSource is the BranchOverlap.Source of uncommitted changes
+const WorkingTreeSource = "working tree"
+
+// DirtyNames returns every file with staged, unstaged or untracked changes
+func (d *DirtyDetails) DirtyNames() []string {
+	var names []string
+	for _, list := range [][]string{d.StagedNames, d.UnstagedNames, d.UntrackedNames} {
+		for _, name := range list {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// branchOverlaps compares the dirty files and the files of each stash with
+// what every other local branch changed since it left defaultBranch
+func branchOverlaps(ctx context.Context, dir, current, defaultBranch string, branches []BranchInfo,
+	dirty *DirtyDetails, stashes []StashInfo) []BranchOverlap {
+	if defaultBranch == "" || (dirty == nil && len(stashes) == 0) {
+		return nil
+	}
+
+	var overlaps []BranchOverlap
+	diffed := 0
+	for _, b := range branches {
+		if b.Name == current || b.Name == defaultBranch {
+			continue
+		}
+		if diffed++; diffed > maxIntentBranches {
+			break
+		}
+		changed := branchFiles(ctx, dir, defaultBranch, b.Name)
+		if len(changed) == 0 {
+			continue
+		}
+		if dirty != nil {
+			if files := intersect(dirty.DirtyNames(), changed); len(files) > 0 {
+				overlaps = append(overlaps, BranchOverlap{Source: WorkingTreeSource, Branch: b.Name, Files: files})
+			}
+		}
+		for _, s := range stashes {
+			if files := intersect(s.Files, changed); len(files) > 0 {
+				overlaps = append(overlaps, BranchOverlap{Source: fmt.Sprintf("stash@{%d}", s.Index), Branch: b.Name, Files: files})
+			}
+		}
+	}
+	return overlaps
+}
+
+// branchFiles returns the files branch changed since it forked from base
+func branchFiles(ctx context.Context, dir, base, branch string) []string {
+	out := strings.TrimSpace(runGit(ctx, dir, "diff", "--name-only", base+"..."+branch, "--"))
+	if out == "" {
+		return nil
+	}
+	return strings.Split(out, "\n")
+}
+
+// intersect returns the elements of a that are also in b, in a's order
+func intersect(a, b []string) []string {
+	var both []string
+	for _, s := range a {
+		if slices.Contains(b, s) {
+			both = append(both, s)
+		}
+	}
+	return both
+}
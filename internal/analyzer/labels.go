@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// ParseLabels splits a list of labels, separated by commas or blanks
+func ParseLabels(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+// getLabels returns the labels of the repository named name: those of
+// explain.labels in its own git config, plus those of every rule (a glob of
+// repository names and its labels) matching its name. They come sorted,
+// once each.
+func getLabels(repo *git.Repository, name string, rules map[string]string) []string {
+	var labels []string
+	if cfg, err := repo.Config(); err == nil {
+		for _, value := range cfg.Raw.Section("explain").OptionAll("labels") {
+			labels = append(labels, ParseLabels(value)...)
+		}
+	}
+	for pattern, value := range rules {
+		if matchesAny(name, []string{pattern}) {
+			labels = append(labels, ParseLabels(value)...)
+		}
+	}
+	slices.Sort(labels)
+	return slices.Compact(labels)
+}
+
+// HasAnyLabel reports whether the repository has any of labels
+func (r *RepoInfo) HasAnyLabel(labels []string) bool {
+	for _, label := range labels {
+		if slices.Contains(r.Labels, label) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterByLabel keeps the repos with any of labels, in their order
+func FilterByLabel(repos []RepoInfo, labels []string) []RepoInfo {
+	var kept []RepoInfo
+	for i := range repos {
+		if repos[i].HasAnyLabel(labels) {
+			kept = append(kept, repos[i])
+		}
+	}
+	return kept
+}
+
+// GroupByLabel splits repos by label, keeping their order within each
+// group: a repository shows up under each of its labels, or under "" when
+// it has none. It also returns the labels found, sorted.
+func GroupByLabel(repos []RepoInfo) (map[string][]*RepoInfo, []string) {
+	groups := make(map[string][]*RepoInfo)
+	var labels []string
+	for i := range repos {
+		if len(repos[i].Labels) == 0 {
+			groups[""] = append(groups[""], &repos[i])
+			continue
+		}
+		for _, label := range repos[i].Labels {
+			if _, ok := groups[label]; !ok {
+				labels = append(labels, label)
+			}
+			groups[label] = append(groups[label], &repos[i])
+		}
+	}
+	slices.Sort(labels)
+	return groups, labels
+}
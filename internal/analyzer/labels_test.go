@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLabels(t *testing.T) {
+	assert.Equal(t, []string{"client-a", "backend", "go"}, ParseLabels(" client-a, backend go "))
+	assert.Empty(t, ParseLabels(""))
+}
+
+func TestGetLabels(t *testing.T) {
+	repo, err := git.PlainInit(t.TempDir(), false)
+	require.NoError(t, err)
+	assert.Empty(t, getLabels(repo, "acme-api", nil))
+
+	cfg, err := repo.Config()
+	require.NoError(t, err)
+	cfg.Raw.Section("explain").SetOption("labels", "client-a backend")
+	require.NoError(t, repo.SetConfig(cfg))
+
+	rules := map[string]string{"acme-*": "client-a,billing", "blog": "personal"}
+	assert.Equal(t, []string{"backend", "billing", "client-a"}, getLabels(repo, "acme-api", rules))
+	assert.Equal(t, []string{"backend", "client-a"}, getLabels(repo, "other", rules))
+}
+
+func TestFilterAndGroupByLabel(t *testing.T) {
+	repos := []RepoInfo{
+		{Name: "api", Labels: []string{"backend", "client-a"}},
+		{Name: "web", Labels: []string{"client-a"}},
+		{Name: "dotfiles"},
+	}
+
+	kept := FilterByLabel(repos, []string{"backend", "personal"})
+	require.Len(t, kept, 1)
+	assert.Equal(t, "api", kept[0].Name)
+	assert.Empty(t, FilterByLabel(repos, []string{"client-b"}))
+
+	groups, labels := GroupByLabel(repos)
+	assert.Equal(t, []string{"backend", "client-a"}, labels)
+	names := func(rs []*RepoInfo) []string {
+		var n []string
+		for _, r := range rs {
+			n = append(n, r.Name)
+		}
+		return n
+	}
+	assert.Equal(t, []string{"api"}, names(groups["backend"]))
+	assert.Equal(t, []string{"api", "web"}, names(groups["client-a"]))
+	assert.Equal(t, []string{"dotfiles"}, names(groups[""]))
+}
@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// openRepo opens the repository at path: a working tree, a linked worktree
+// (whose objects and refs live in its main repository) or a bare repository
+func openRepo(path string) (*git.Repository, error) {
+	return git.PlainOpenWithOptions(path, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
+}
+
+// getLayout fills in what kind of repository info is: bare, a mirror
+// clone (git clone --mirror, a bare repository that copies every ref of
+// its remote) or a linked worktree of another one
+func getLayout(ctx context.Context, repo *git.Repository, info *RepoInfo) {
+	// A worktree of a bare repository reads its core.bare, yet has a
+	// working tree of its own
+	if info.WorktreeOf = mainWorktree(ctx, info.Path); info.WorktreeOf != "" {
+		return
+	}
+	if cfg, err := repo.Config(); err == nil {
+		info.IsBare = cfg.Core.IsBare
+		for _, r := range cfg.Remotes {
+			if r.Mirror {
+				info.IsMirror = true
+			}
+		}
+	}
+}
+
+// mainWorktree returns the repository a linked worktree (git worktree add)
+// belongs to, or "" when path is not one. Linked worktrees and submodules
+// both have a .git file; only worktrees share the git dir of another.
+func mainWorktree(ctx context.Context, path string) string {
+	if fi, err := os.Stat(filepath.Join(path, ".git")); err != nil || fi.IsDir() {
+		return ""
+	}
+	common := strings.TrimSpace(runGit(ctx, path, "rev-parse", "--path-format=absolute", "--git-common-dir"))
+	gitDir := strings.TrimSpace(runGit(ctx, path, "rev-parse", "--absolute-git-dir"))
+	if common == "" || common == gitDir {
+		return ""
+	}
+	if filepath.Base(common) == ".git" {
+		return filepath.Dir(common)
+	}
+	return common // A bare main repository
+}
@@ -0,0 +1,65 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LFSInfo describes a repository that stores files in Git LFS
+type LFSInfo struct {
+	Files     int  `json:"files"`              // Tracked files stored in LFS
+	Installed bool `json:"installed"`          // git lfs runs here
+	Hooked    bool `json:"hooked,omitempty"`   // The pre-push hook uploads LFS objects on git push
+	Unpushed  int  `json:"unpushed,omitempty"` // LFS objects of unpushed commits not on the remote yet
+}
+
+// AtRisk reports whether LFS files can't be read here, or won't reach the
+// remote: without git lfs the working tree holds pointer files, and
+// without its pre-push hook git push sends commits whose LFS objects the
+// remote lacks
+func (l *LFSInfo) AtRisk() bool {
+	return !l.Installed || (l.Unpushed > 0 && !l.Hooked)
+}
+
+// getLFS returns how the repository in dir uses Git LFS, or nil when it
+// doesn't. Objects are only counted as unpushed for the current branch,
+// when it tracks remote and is ahead of it.
+func getLFS(ctx context.Context, dir, remote, branch string, ahead int) *LFSInfo {
+	files := runGit(ctx, dir, "ls-files", ":(attr:filter=lfs)")
+	if strings.TrimSpace(files) == "" {
+		return nil
+	}
+	lfs := &LFSInfo{Files: len(strings.Split(strings.TrimSpace(files), "\n"))}
+	if _, err := gitRunner.Run(ctx, dir, "lfs", "version"); err != nil {
+		return lfs
+	}
+	lfs.Installed = true
+	lfs.Hooked = hasLFSHook(ctx, dir)
+	if remote != "" && ahead > 0 {
+		for _, line := range strings.Split(runGit(ctx, dir, "lfs", "push", "--dry-run", remote, branch), "\n") {
+			if strings.HasPrefix(line, "push ") {
+				lfs.Unpushed++
+			}
+		}
+	}
+	return lfs
+}
+
+// hasLFSHook reports whether the pre-push hook of the repository in dir,
+// wherever core.hooksPath puts it, runs git lfs
+func hasLFSHook(ctx context.Context, dir string) bool {
+	hook := strings.TrimSpace(runGit(ctx, dir, "rev-parse", "--git-path", "hooks/pre-push"))
+	if hook == "" {
+		return false
+	}
+	if !filepath.IsAbs(hook) {
+		hook = filepath.Join(dir, hook)
+	}
+	script, err := os.ReadFile(hook) //nolint:gosec // path is the repository's own hook
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(script), "git lfs") || strings.Contains(string(script), "git-lfs")
+}
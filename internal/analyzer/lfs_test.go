@@ -0,0 +1,65 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/testutil"
+)
+
+func TestGetLFS(t *testing.T) {
+	dir := t.TempDir()
+	hooks := filepath.Join(dir, ".git", "hooks")
+	require.NoError(t, os.MkdirAll(hooks, 0o750))
+	lfsFiles := "assets/logo.psd\nvideo/intro.mp4\n"
+	dryRun := "push 4d7a2146 => assets/logo.psd\npush 9f86d081 => video/intro.mp4\n"
+
+	t.Run("no LFS files", func(t *testing.T) {
+		defer SetGitRunner(testutil.NewFakeGit().On("ls-files :(attr:filter=lfs)", ""))()
+		assert.Nil(t, getLFS(context.Background(), dir, "origin", "main", 2))
+	})
+
+	t.Run("git lfs not installed", func(t *testing.T) {
+		defer SetGitRunner(testutil.NewFakeGit().On("ls-files :(attr:filter=lfs)", lfsFiles))()
+		lfs := getLFS(context.Background(), dir, "origin", "main", 2)
+		assert.Equal(t, &LFSInfo{Files: 2}, lfs)
+		assert.True(t, lfs.AtRisk())
+	})
+
+	fake := func() *testutil.FakeGit {
+		return testutil.NewFakeGit().
+			On("ls-files :(attr:filter=lfs)", lfsFiles).
+			On("lfs version", "git-lfs/3.5.1\n").
+			On("rev-parse --git-path hooks/pre-push", ".git/hooks/pre-push\n").
+			On("lfs push --dry-run origin main", dryRun)
+	}
+
+	t.Run("unpushed objects without the hook", func(t *testing.T) {
+		defer SetGitRunner(fake())()
+		lfs := getLFS(context.Background(), dir, "origin", "main", 2)
+		assert.Equal(t, &LFSInfo{Files: 2, Installed: true, Unpushed: 2}, lfs)
+		assert.True(t, lfs.AtRisk())
+	})
+
+	t.Run("unpushed objects with the hook", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(hooks, "pre-push"),
+			[]byte("#!/bin/sh\ngit lfs pre-push \"$@\"\n"), 0o600))
+		defer SetGitRunner(fake())()
+		lfs := getLFS(context.Background(), dir, "origin", "main", 2)
+		assert.Equal(t, &LFSInfo{Files: 2, Installed: true, Hooked: true, Unpushed: 2}, lfs)
+		assert.False(t, lfs.AtRisk())
+	})
+
+	t.Run("nothing to push", func(t *testing.T) {
+		f := fake()
+		defer SetGitRunner(f)()
+		lfs := getLFS(context.Background(), dir, "origin", "main", 0)
+		assert.Zero(t, lfs.Unpushed)
+		assert.NotContains(t, f.Calls(), "lfs push --dry-run origin main")
+	})
+}
@@ -0,0 +1,84 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OperationState is a multi-step git operation left in progress in a repository
+type OperationState string
+
+const (
+	OpNone       OperationState = ""
+	OpMerge      OperationState = "merge"
+	OpRebase     OperationState = "rebase"
+	OpCherryPick OperationState = "cherry-pick"
+	OpRevert     OperationState = "revert"
+	OpBisect     OperationState = "bisect"
+)
+
+// OperationDetails describes states that need attention before normal work can continue
+type OperationDetails struct {
+	State         OperationState `json:"state,omitempty"`
+	ConflictFiles []string       `json:"conflict_files,omitempty"`
+	DetachedHEAD  bool           `json:"detached_head,omitempty"`
+	Empty         bool           `json:"empty,omitempty"` // No commits yet
+}
+
+// IsZero reports whether nothing noteworthy was detected
+func (o *OperationDetails) IsZero() bool {
+	return o.State == OpNone && len(o.ConflictFiles) == 0 && !o.DetachedHEAD && !o.Empty
+}
+
+// operationMarkers maps files or directories inside the git dir to the
+// operation whose presence they signal. Order matters: a rebase that stops
+// on a conflicting pick also leaves CHERRY_PICK_HEAD behind.
+var operationMarkers = []struct {
+	name  string
+	state OperationState
+}{
+	{"rebase-merge", OpRebase},
+	{"rebase-apply", OpRebase},
+	{"MERGE_HEAD", OpMerge},
+	{"CHERRY_PICK_HEAD", OpCherryPick},
+	{"REVERT_HEAD", OpRevert},
+	{"BISECT_LOG", OpBisect},
+}
+
+// DetectOperationState inspects the repository at dir for in-progress
+// operations, unresolved conflicts, a detached HEAD, or a lack of commits.
+// Returns nil when the repository is in a normal state.
+func DetectOperationState(ctx context.Context, dir string) *OperationDetails {
+	details := &OperationDetails{}
+
+	if gitDir := strings.TrimSpace(runGit(ctx, dir, "rev-parse", "--absolute-git-dir")); gitDir != "" {
+		details.State = detectOperation(gitDir)
+	}
+
+	if conflicts := runGit(ctx, dir, "diff", "--name-only", "--diff-filter=U"); conflicts != "" {
+		details.ConflictFiles = strings.Split(strings.TrimSpace(conflicts), "\n")
+	}
+
+	if runGit(ctx, dir, "rev-parse", "-q", "--verify", "HEAD") == "" {
+		details.Empty = true
+	} else if runGit(ctx, dir, "symbolic-ref", "-q", "HEAD") == "" {
+		details.DetachedHEAD = true
+	}
+
+	if details.IsZero() {
+		return nil
+	}
+	return details
+}
+
+// detectOperation returns the operation signaled by marker files in gitDir
+func detectOperation(gitDir string) OperationState {
+	for _, m := range operationMarkers {
+		if _, err := os.Stat(filepath.Join(gitDir, m.name)); err == nil {
+			return m.state
+		}
+	}
+	return OpNone
+}
@@ -0,0 +1,72 @@
+package analyzer
+
+import (
+	"path"
+	"strings"
+)
+
+// ReadOnlyHosts are globs of hosts whose https and git:// URLs are
+// read-only mirrors or anonymous clones: pushes go over ssh, or through
+// code review, if anywhere
+var ReadOnlyHosts = []string{"git.kernel.org", "*.googlesource.com", "git.savannah.gnu.org", "sourceware.org", "anongit.*"}
+
+// ReadOnlyMirrors are host/owner[/repo] globs of well-known mirrors on
+// forges, which take neither pushes nor pull requests, whatever the URL
+var ReadOnlyMirrors = []string{"github.com/mirror", "github.com/gcc-mirror", "github.com/llvm-mirror", "github.com/torvalds/linux"}
+
+// isReadOnlyRemote reports whether a remote can't be pushed to: its
+// pushurl is a placeholder, such as no_push, or the URL it pushes to is a
+// git:// one, matches one of patterns (host[/owner[/repo]] globs, as
+// explain.read_only_remotes has them) or ReadOnlyMirrors or, other than
+// over ssh, is on one of ReadOnlyHosts
+func isReadOnlyRemote(url, pushURL string, patterns []string) bool {
+	if pushURL != "" {
+		if !strings.ContainsAny(pushURL, ":/") {
+			return true
+		}
+		url = pushURL
+	}
+	lower := strings.ToLower(url)
+	if strings.HasPrefix(lower, "git://") {
+		return true
+	}
+	host, _, _ := ParseRemoteURL(lower)
+	if host == "" {
+		return false
+	}
+	for _, pattern := range append(patterns, ReadOnlyMirrors...) {
+		if MatchRemote(pattern, url) {
+			return true
+		}
+	}
+	if ssh := !strings.Contains(lower, "://") || strings.HasPrefix(lower, "ssh://"); ssh {
+		return false
+	}
+	for _, glob := range ReadOnlyHosts {
+		if ok, _ := path.Match(glob, host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadOnlyRemotes returns the names of remotes that can't be pushed to
+func (r *RepoInfo) ReadOnlyRemotes() []string {
+	var names []string
+	for _, remote := range r.AllRemotes {
+		if remote.ReadOnly {
+			names = append(names, remote.Name)
+		}
+	}
+	return names
+}
+
+// Remote returns the remote called name, or nil when there is none
+func (r *RepoInfo) Remote(name string) *RemoteInfo {
+	for i := range r.AllRemotes {
+		if r.AllRemotes[i].Name == name {
+			return &r.AllRemotes[i]
+		}
+	}
+	return nil
+}
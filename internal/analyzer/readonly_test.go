@@ -0,0 +1,49 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsReadOnlyRemote(t *testing.T) {
+	patterns := []string{"git.example.com/mirrors", "*.corp.example/*/vendored-*"}
+	tests := []struct {
+		url, pushURL string
+		want         bool
+	}{
+		{"https://git.kernel.org/pub/scm/git/git.git", "", true},
+		{"git://git.kernel.org/pub/scm/git/git.git", "", true},
+		{"ssh://git@git.kernel.org/pub/scm/git/git.git", "", false},
+		{"https://chromium.googlesource.com/chromium/src", "", true},
+		{"https://github.com/gcc-mirror/gcc", "", true},
+		{"git@github.com:torvalds/linux.git", "", true},
+		{"https://github.com/torvalds/subsurface", "", false},
+		{"https://github.com/jdevera/git-this-bread", "", false},
+		{"https://github.com/jdevera/git-this-bread", "no_push", true},
+		{"https://git.kernel.org/pub/scm/git/git.git", "git@github.com:me/git.git", false},
+		{"https://git.example.com/mirrors/tool.git", "", true},
+		{"git@git.example.com:team/tool.git", "", false},
+		{"git@git.eu.corp.example:infra/vendored-zlib.git", "", true},
+		{"git@git.eu.corp.example:infra/zlib.git", "", false},
+		{"/srv/git/project.git", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url+" "+tt.pushURL, func(t *testing.T) {
+			assert.Equal(t, tt.want, isReadOnlyRemote(tt.url, tt.pushURL, patterns))
+		})
+	}
+}
+
+func TestReadOnlyRemotes(t *testing.T) {
+	info := &RepoInfo{AllRemotes: []RemoteInfo{
+		{Name: "origin", ReadOnly: true},
+		{Name: "fork"},
+		{Name: "mirror", ReadOnly: true},
+	}}
+
+	assert.Equal(t, []string{"origin", "mirror"}, info.ReadOnlyRemotes())
+	assert.Equal(t, "fork", info.Remote("fork").Name)
+	assert.Nil(t, info.Remote("upstream"))
+}
@@ -0,0 +1,183 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// remoteProbeTimeout bounds each reachability check so dead hosts don't stall a run
+const remoteProbeTimeout = 10 * time.Second
+
+// ParseRemoteURL splits a remote URL into host, owner, and repository name.
+// Supports scp-like SSH (git@host:owner/repo.git), ssh://, git://, http(s)://
+// and file:// URLs. The owner keeps nested groups (e.g. GitLab subgroups).
+// Local paths yield an empty host.
+func ParseRemoteURL(raw string) (host, owner, repo string) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", "", ""
+	}
+
+	var path string
+	if u, err := url.Parse(raw); err == nil && u.Scheme != "" && strings.Contains(raw, "://") {
+		host = u.Hostname()
+		path = u.Path
+		if host != "" {
+			path = strings.TrimPrefix(path, "/")
+		}
+	} else if at := strings.Index(raw, ":"); at > 0 && !strings.Contains(raw[:at], "/") {
+		// scp-like syntax: [user@]host:path
+		host = raw[:at]
+		if i := strings.LastIndex(host, "@"); i != -1 {
+			host = host[i+1:]
+		}
+		path = raw[at+1:]
+	} else {
+		path = raw
+	}
+
+	path = strings.TrimSuffix(strings.TrimRight(path, "/"), ".git")
+	if i := strings.LastIndex(path, "/"); i != -1 {
+		return host, path[:i], path[i+1:]
+	}
+	return host, "", path
+}
+
+// MatchRemote reports whether pattern, a host[/owner[/repo]] glob,
+// matches the remote at raw, a URL, segment by segment and ignoring case
+func MatchRemote(pattern, raw string) bool {
+	host, owner, repo := ParseRemoteURL(strings.ToLower(raw))
+	if host == "" {
+		return false
+	}
+	segments := strings.Split(host+"/"+owner+"/"+repo, "/")
+	globs := strings.Split(strings.Trim(strings.ToLower(pattern), "/"), "/")
+	if len(globs) > len(segments) {
+		return false
+	}
+	for i, glob := range globs {
+		if ok, _ := path.Match(glob, segments[i]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// probeRemote reports whether the named remote answers `git ls-remote`.
+// An empty remote (exit code 2 under --exit-code) still counts as reachable.
+func probeRemote(ctx context.Context, dir, remote string) bool {
+	ctx, cancel := context.WithTimeout(ctx, remoteProbeTimeout)
+	defer cancel()
+
+	_, err := gitRunner.Run(ctx, dir, "ls-remote", "--exit-code", "-q", remote)
+	if err == nil {
+		return true
+	}
+	var exitErr interface{ ExitCode() int }
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == 2 && ctx.Err() == nil
+}
+
+// staleRemoteRefs lists remote-tracking refs of remote whose branch no longer
+// exists upstream, as reported by `git remote prune --dry-run` (network access)
+func staleRemoteRefs(ctx context.Context, dir, remote string) []string {
+	ctx, cancel := context.WithTimeout(ctx, remoteProbeTimeout)
+	defer cancel()
+	return parsePruneDryRun(runGit(ctx, dir, "remote", "prune", "--dry-run", remote))
+}
+
+// parsePruneDryRun extracts ref names from " * [would prune] origin/foo" lines
+func parsePruneDryRun(output string) []string {
+	var refs []string
+	for _, line := range strings.Split(output, "\n") {
+		if _, ref, ok := strings.Cut(line, "[would prune] "); ok {
+			refs = append(refs, strings.TrimSpace(ref))
+		}
+	}
+	return refs
+}
+
+// markStaleUpstreams flags local branches tracking a ref that is about to be pruned
+func markStaleUpstreams(branches []BranchInfo, stale []string) {
+	for i := range branches {
+		for _, ref := range stale {
+			if branches[i].Upstream == ref {
+				branches[i].UpstreamGone = true
+			}
+		}
+	}
+}
+
+// UnreachableRemotes returns the names of remotes that failed a reachability probe
+func (r *RepoInfo) UnreachableRemotes() []string {
+	var names []string
+	for _, remote := range r.AllRemotes {
+		if remote.Reachable != nil && !*remote.Reachable {
+			names = append(names, remote.Name)
+		}
+	}
+	return names
+}
+
+// RemoteBranch is a branch that exists on one of the user's remotes but
+// not in this clone, as for work pushed from another machine
+type RemoteBranch struct {
+	Remote         string `json:"remote"`
+	Name           string `json:"name"`
+	Hash           string `json:"hash"`
+	LastCommitDate string `json:"last_commit_date,omitempty"` // Empty when the commit was never fetched
+}
+
+// RemoteOnlyRemotes returns the remotes that have RemoteOnlyBranches, once
+// each, in order
+func (r *RepoInfo) RemoteOnlyRemotes() []string {
+	var names []string
+	for _, b := range r.RemoteOnlyBranches {
+		if len(names) == 0 || names[len(names)-1] != b.Remote {
+			names = append(names, b.Remote)
+		}
+	}
+	return names
+}
+
+// remoteOnlyBranches lists the branches of remote, from ls-remote (network
+// access), that no local branch has the name of or tracks
+func remoteOnlyBranches(ctx context.Context, dir, remote string, local []BranchInfo) []RemoteBranch {
+	ctx, cancel := context.WithTimeout(ctx, remoteProbeTimeout)
+	defer cancel()
+
+	known := map[string]bool{}
+	for _, b := range local {
+		known[b.Name] = true
+		if name, ok := strings.CutPrefix(b.Upstream, remote+"/"); ok {
+			known[name] = true
+		}
+	}
+
+	var branches []RemoteBranch
+	for _, b := range parseLsRemoteHeads(runGit(ctx, dir, "ls-remote", "--heads", remote)) {
+		if known[b.Name] {
+			continue
+		}
+		b.Remote = remote
+		// Known only if fetched before, as a remote-tracking ref or otherwise
+		b.LastCommitDate = strings.TrimSpace(runGit(ctx, dir, "log", "-1", "--format=%cs", b.Hash, "--"))
+		branches = append(branches, b)
+	}
+	return branches
+}
+
+// parseLsRemoteHeads parses "<hash>\trefs/heads/<name>" lines
+func parseLsRemoteHeads(output string) []RemoteBranch {
+	var branches []RemoteBranch
+	for _, line := range strings.Split(output, "\n") {
+		hash, ref, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if name, isHead := strings.CutPrefix(ref, "refs/heads/"); ok && isHead {
+			branches = append(branches, RemoteBranch{Name: name, Hash: hash})
+		}
+	}
+	return branches
+}
@@ -0,0 +1,56 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"os/exec"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+)
+
+// GitRunner executes git commands on behalf of the analyzer. The default
+// shells out to the git binary; tests can swap in an in-memory fake with
+// SetGitRunner to exercise parsing and error paths without real repositories.
+type GitRunner interface {
+	// Run executes git with args in dir (the current directory when empty)
+	// and returns stdout. A non-zero exit must yield an error that exposes
+	// ExitCode() int, as *exec.ExitError does.
+	Run(ctx context.Context, dir string, args ...string) (string, error)
+}
+
+// ExecRunner runs the git binary found on PATH
+type ExecRunner struct{}
+
+// Run implements GitRunner. Credential prompts are disabled so network
+// operations fail instead of blocking on input, and optional locks are
+// skipped so read-only commands like status never rewrite the index.
+func (ExecRunner) Run(ctx context.Context, dir string, args ...string) (string, error) {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	args = append([]string{"--no-optional-locks"}, args...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := debuglog.Output(cmd)
+	return string(out), err
+}
+
+var gitRunner GitRunner = ExecRunner{}
+
+// SetGitRunner replaces the runner used for all git commands and returns a
+// function that restores the previous one. Not safe to call while analysis
+// is running.
+func SetGitRunner(r GitRunner) (restore func()) {
+	prev := gitRunner
+	gitRunner = r
+	return func() { gitRunner = prev }
+}
+
+// runGit runs a git command in the given directory and returns stdout or empty string on error
+func runGit(ctx context.Context, dir string, args ...string) string {
+	out, err := gitRunner.Run(ctx, dir, args...)
+	if err != nil {
+		return ""
+	}
+	return out
+}
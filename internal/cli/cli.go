@@ -0,0 +1,93 @@
+// Package cli holds the cobra setup shared by the git-this-bread commands,
+// whether they run as their own binaries or as subcommands of bread
+package cli
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/jdevera/git-this-bread/internal/config"
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/i18n"
+	"github.com/jdevera/git-this-bread/internal/stats"
+)
+
+// Execute runs cmd as the root command of a binary built as build. It
+// gains version, completion and docs subcommands and, unless cmd passes its
+// flags through, a --debug flag that, like BREAD_DEBUG, logs external
+// commands, API requests and cache lookups to stderr, and a --no-input flag
+// that turns prompts off (see Interactive). With stats.enabled set, each run
+// is recorded for bread stats.
+func Execute(cmd *cobra.Command, build Build) error {
+	cmd.Version = build.withDefaults().String()
+	cmd.SetVersionTemplate("{{.Name}} {{.Version}}")
+	cmd.CompletionOptions.DisableDefaultCmd = true
+	cmd.AddCommand(versionCommand(), completionCommand(cmd), docsCommand(cmd))
+
+	var debug bool
+	if !cmd.DisableFlagParsing {
+		cmd.PersistentFlags().BoolVar(&debug, "debug", false,
+			"Log external commands, API requests and cache lookups to stderr (or set "+debuglog.Env+"=1)")
+		cmd.PersistentFlags().BoolVar(&noInput, "no-input", false,
+			"Never prompt: fail instead of asking (also when stdin isn't a terminal, or "+NoInputEnv+"=1 or CI is set)")
+	}
+	cobra.OnInitialize(func() {
+		if debug || debuglog.EnabledByEnv() {
+			debuglog.Enable(os.Stderr)
+		}
+		setLocale()
+	})
+
+	start := time.Now()
+	ran, err := cmd.ExecuteC()
+	record(ran, start, err)
+	return err
+}
+
+// record adds the run of cmd to the usage statistics, when stats.enabled
+// asks for them. Shell completion requests run on every tab and aren't
+// counted, and failing to record never fails the command.
+func record(cmd *cobra.Command, start time.Time, err error) {
+	if cmd == nil || cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd {
+		return
+	}
+	cfg, cfgErr := config.Load()
+	if cfgErr != nil || !cfg.Stats.Enabled {
+		return
+	}
+	path, pathErr := stats.Path()
+	if pathErr != nil {
+		return
+	}
+	r := stats.Record{Time: start, Command: cmd.CommandPath(), Duration: time.Since(start), Failed: err != nil}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		r.Flags = append(r.Flags, f.Name)
+	})
+	_ = stats.Append(path, &r)
+}
+
+// setLocale translates messages into the ui.locale setting or, without
+// one, the locale of the environment. A config file that doesn't load is
+// left for the command to report.
+func setLocale() {
+	var configured string
+	if cfg, err := config.Load(); err == nil {
+		configured = cfg.UI.Locale
+	}
+	_ = i18n.SetLocale(i18n.Detect(configured))
+}
+
+// Rename gives cmd a new name, keeping the argument synopsis of its Use
+// line, so "git-explain [directory]" becomes "explain [directory]"
+func Rename(cmd *cobra.Command, name string) *cobra.Command {
+	if _, args, ok := strings.Cut(cmd.Use, " "); ok {
+		cmd.Use = name + " " + args
+	} else {
+		cmd.Use = name
+	}
+	return cmd
+}
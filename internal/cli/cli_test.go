@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRename(t *testing.T) {
+	assert.Equal(t, "explain [directory]", Rename(&cobra.Command{Use: "git-explain [directory]"}, "explain").Use)
+	assert.Equal(t, "wtfork", Rename(&cobra.Command{Use: "gh-wtfork"}, "wtfork").Use)
+}
+
+// testRoot returns a root command with the completion and docs subcommands
+func testRoot() *cobra.Command {
+	root := &cobra.Command{Use: "git-tool", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(&cobra.Command{Use: "sub", Short: "A subcommand", Run: func(*cobra.Command, []string) {}})
+	root.AddCommand(completionCommand(root), docsCommand(root))
+	return root
+}
+
+func TestCompletionCommand_Dir(t *testing.T) {
+	dir := t.TempDir()
+	root := testRoot()
+	root.SetArgs([]string{"completion", "--dir", dir})
+	require.NoError(t, root.Execute())
+
+	for _, name := range []string{"git-tool", "_git-tool", "git-tool.fish", "git-tool.ps1"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err, name)
+		assert.Contains(t, string(data), "git-tool")
+	}
+}
+
+func TestCompletionCommand_NoShell(t *testing.T) {
+	root := testRoot()
+	root.SetArgs([]string{"completion"})
+	root.SilenceErrors, root.SilenceUsage = true, true
+	assert.ErrorContains(t, root.Execute(), "name a shell")
+
+	root.SetArgs([]string{"completion", "tcsh"})
+	assert.Error(t, root.Execute())
+}
+
+func TestDocsManCommand(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "0")
+	dir := t.TempDir()
+	root := testRoot()
+	root.SetArgs([]string{"docs", "man", "--dir", dir})
+	require.NoError(t, root.Execute())
+
+	data, err := os.ReadFile(filepath.Join(dir, "git-tool-sub.1"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "A subcommand")
+	assert.FileExists(t, filepath.Join(dir, "git-tool.1"))
+}
+
+func TestBuildString(t *testing.T) {
+	s := Build{Version: "1.2.3", Commit: "abc1234", Date: "2026-03-22T10:00:00Z"}.withDefaults().String()
+	assert.Contains(t, s, "1.2.3\ncommit: abc1234\nbuilt:  2026-03-22T10:00:00Z\ngo:     go")
+}
+
+func TestVersionCommand(t *testing.T) {
+	root := testRoot()
+	root.Version = Build{Version: "1.2.3"}.String()
+	root.AddCommand(versionCommand())
+	var out strings.Builder
+	root.SetOut(&out)
+	root.SetArgs([]string{"version"})
+	require.NoError(t, root.Execute())
+	assert.True(t, strings.HasPrefix(out.String(), "git-tool 1.2.3\n"), out.String())
+}
+
+func TestRequireInput(t *testing.T) {
+	terminal := true
+	orig := stdinIsTerminal
+	stdinIsTerminal = func() bool { return terminal }
+	t.Cleanup(func() { stdinIsTerminal = orig; noInput = false })
+	t.Setenv("CI", "")
+	t.Setenv(NoInputEnv, "")
+
+	assert.True(t, Interactive())
+	require.NoError(t, RequireInput("git-id add", "Use flags"))
+
+	tests := []struct {
+		name   string
+		setup  func(t *testing.T)
+		reason string
+	}{
+		{"no terminal", func(*testing.T) { terminal = false }, "stdin isn't a terminal"},
+		{"flag", func(*testing.T) { noInput = true }, "--no-input is set"},
+		{"env", func(t *testing.T) { t.Setenv(NoInputEnv, "1") }, NoInputEnv + " is set"},
+		{"ci", func(t *testing.T) { t.Setenv("CI", "true") }, "running in CI"},
+		{"ci any value", func(t *testing.T) { t.Setenv("CI", "woodpecker") }, "running in CI"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			terminal, noInput = true, false
+			t.Setenv("CI", "")
+			t.Setenv(NoInputEnv, "")
+			tt.setup(t)
+
+			assert.False(t, Interactive())
+			err := RequireInput("git-id add", "Use flags")
+			require.ErrorIs(t, err, ErrNoInput)
+			assert.Equal(t, "git-id add can't ask for input: "+tt.reason+"\nUse flags", err.Error())
+		})
+	}
+
+	terminal, noInput = true, false
+	t.Setenv("CI", "false")
+	assert.True(t, Interactive(), "CI=false doesn't count")
+}
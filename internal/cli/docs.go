@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+// shells are the shells completion scripts can be generated for
+var shells = []string{"bash", "zsh", "fish", "powershell"}
+
+// completionCommand replaces cobra's default completion command, which
+// only exists on commands with subcommands, with one that can also write
+// every script to a directory for packagers
+func completionCommand(root *cobra.Command) *cobra.Command {
+	var dir string
+	cmd := &cobra.Command{
+		Use:   "completion [bash|zsh|fish|powershell]",
+		Short: "Generate shell completion scripts",
+		Long: fmt.Sprintf(`Generate the completion script for a shell, completing commands, flags,
+identity profiles and directories.
+
+  source <(%[1]s completion bash)
+  %[1]s completion zsh > "${fpath[1]}/_%[1]s"
+  %[1]s completion fish > ~/.config/fish/completions/%[1]s.fish
+
+With --dir, the scripts are written to that directory under the names each
+shell looks for (%[1]s, _%[1]s, %[1]s.fish, %[1]s.ps1): all of them, or only
+the one for the shell given.`, root.Name()),
+		ValidArgs: shells,
+		Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dir == "" {
+				if len(args) == 0 {
+					return errors.New("name a shell, or use --dir to write every script")
+				}
+				return writeCompletion(root, args[0], os.Stdout)
+			}
+
+			targets := shells
+			if len(args) > 0 {
+				targets = args
+			}
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+			for _, shell := range targets {
+				path := filepath.Join(dir, completionFileName(root.Name(), shell))
+				if err := writeCompletionFile(root, shell, path); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dir, "dir", "", "Write the scripts to this directory instead of stdout")
+	return cmd
+}
+
+// completionFileName is the file name shell looks for completions of name in
+func completionFileName(name, shell string) string {
+	switch shell {
+	case "zsh":
+		return "_" + name
+	case "fish":
+		return name + ".fish"
+	case "powershell":
+		return name + ".ps1"
+	}
+	return name
+}
+
+func writeCompletionFile(root *cobra.Command, shell, path string) error {
+	f, err := os.Create(path) //nolint:gosec // path is the packager's chosen output directory
+	if err != nil {
+		return err
+	}
+	if err := writeCompletion(root, shell, f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+func writeCompletion(root *cobra.Command, shell string, w io.Writer) error {
+	switch shell {
+	case "bash":
+		return root.GenBashCompletionV2(w, true)
+	case "zsh":
+		return root.GenZshCompletion(w)
+	case "fish":
+		return root.GenFishCompletion(w, true)
+	case "powershell":
+		return root.GenPowerShellCompletionWithDesc(w)
+	}
+	return fmt.Errorf("unknown shell %q", shell)
+}
+
+// docsCommand generates reference documentation for root and its
+// subcommands
+func docsCommand(root *cobra.Command) *cobra.Command {
+	var dir string
+	man := &cobra.Command{
+		Use:   "man",
+		Short: "Write a man page for every command",
+		Long: `Write a section 1 man page for every command, named after the command
+path (git-id-set.1). Set SOURCE_DATE_EPOCH for reproducible dates.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+			root.DisableAutoGenTag = true
+			return doc.GenManTree(root, &doc.GenManHeader{
+				Section: "1",
+				Source:  "git-this-bread " + root.Version,
+				Manual:  "git-this-bread manual",
+			}, dir)
+		},
+	}
+	man.Flags().StringVar(&dir, "dir", ".", "Directory to write the pages to")
+
+	docs := &cobra.Command{
+		Use:   "docs",
+		Short: "Generate reference documentation",
+	}
+	docs.AddCommand(man)
+	return docs
+}
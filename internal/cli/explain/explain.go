@@ -0,0 +1,969 @@
+// Package explain implements the git-explain command, run on its own or as a
+// subcommand of bread
+package explain
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/invopop/jsonschema"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+
+	"github.com/jdevera/git-this-bread/internal/actions"
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/config"
+	"github.com/jdevera/git-this-bread/internal/forge"
+	"github.com/jdevera/git-this-bread/internal/i18n"
+	"github.com/jdevera/git-this-bread/internal/identity"
+	"github.com/jdevera/git-this-bread/internal/llmadvice"
+	"github.com/jdevera/git-this-bread/internal/notify"
+	"github.com/jdevera/git-this-bread/internal/render"
+)
+
+var (
+	verbose         bool
+	compact         bool
+	showAll         bool
+	useTable        bool
+	showLegend      bool
+	quiet           bool
+	showAdvice      bool
+	useJSON         bool
+	outputFormat    string
+	notifySinks     []string
+	showSchema      bool
+	llmAdvice       bool
+	llmProvider     string
+	llmInstructions string
+	llmModel        string
+	llmTemperature  float64
+	llmMaxTokens    int
+	llmBaseURL      string
+	llmHeaders      []string
+	noCache         bool
+	perRepo         bool
+	llmConcurrency  int
+	fetchUpstream   bool
+	maxCommits      int
+	repoTimeout     time.Duration
+	probeRemotes    bool
+	diskUsage       bool
+	activity        bool
+	sortBy          string
+	themeName       string
+	asciiIcons      bool
+	hyperlinks      string
+	noAlign         bool
+	noPager         bool
+	noStream        bool
+	llmRedact       bool
+	llmUsage        bool
+	llmChat         bool
+	suggestCommit   bool
+	llmDigest       bool
+	digestDays      int
+	excludes        []string
+	only            []string
+	labels          []string
+	byLabel         bool
+	fix             bool
+	dryRun          bool
+	fixYes          bool
+	maintenance     bool
+	record          bool
+	assumeIdentity  string
+
+	pruneTTL        time.Duration
+	pruneMaxEntries int
+	pruneMaxBytes   int64
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "git-explain [directory] [repo...]",
+	Short: "Check contribution status in git repositories",
+	Long: `git-explain (a 🍞 git-this-bread tool)
+
+Check your contribution status across git repositories.
+
+If DIRECTORY is a git repo, analyze it directly.
+Otherwise, analyze all immediate subdirectories.
+
+Without DIRECTORY, outside a git repo, the explain.roots directories from
+~/.config/git-this-bread/config.toml are analyzed instead of the current
+one, skipping the subdirectories that match explain.excludes:
+
+    bread config set explain.roots ~/src,~/work
+
+To re-check a few repos of a directory, name them (or glob them) after
+it, or with --only; a repo matches by its directory name:
+
+    git explain ~/src app 'acme-*'
+    git explain --only 'acme-*,blog'
+
+LABELS
+
+Label repos to give a large checkout more structure than directory
+names: in a repo's own git config, or for every repo whose name matches
+a glob in config.toml (labels separated by blanks or commas):
+
+    git -C ~/src/acme-api config explain.labels "client-a backend"
+    bread config set explain.labels 'acme-*: client-a'
+
+--label shows only the repos with any of the labels given, --by-label
+groups them by label instead of by category, and --json lists them:
+
+    git explain ~/src --label client-a
+    git explain ~/src --by-label
+
+Commits and remotes are yours when they match git config's user.email
+and github.user. --assume-identity <profile> (or --as) judges them by a
+git-id profile's email and ghuser instead, changing no config:
+
+    git explain --as work ~/src/acme
+
+When git config lacks them, the identity.default profile is used, and on
+a fresh machine without either, git-explain offers to set them up first.
+
+FIXING
+
+--fix runs the commands the advice suggests (git push, git gc, ...),
+asking before each one; --yes runs them all without asking. Where nobody
+can answer (stdin isn't a terminal, --no-input, or GIT_THIS_BREAD_NO_INPUT
+or CI set) --fix fails at once unless given --yes. With --dry-run it
+prints them as JSON actions instead, to review, edit and run later with
+'bread apply':
+
+    git explain ~/src --fix --dry-run > actions.json
+    bread apply actions.json
+
+--maintenance does the same with only the housekeeping: git gc for repos
+with many loose objects, re-enabling a gc.auto set to 0, and git
+maintenance start for repos without background maintenance:
+
+    git explain ~/src --maintenance --yes
+
+GITHUB ACTIONS
+
+--format gh-actions reports the repos with uncommitted work, unpushed
+commits, stashes, operations in progress or errors as annotations of the
+workflow run (errors for the repos blocked until fixed, warnings for the
+rest), with each one's advice in a collapsible group of the log. In a
+workflow it also adds a table of them to the job summary, so a nightly
+job over a build server's checkouts shows what needs attention:
+
+    git explain /srv/checkouts --format gh-actions
+
+NOTIFICATIONS
+
+--notify sends a summary of the repos needing attention, and why, when any
+do: to a webhook, as JSON that Slack and compatible chats show as is, or
+by email. Their settings are in the [notify] section of config.toml, so a
+cron job can ping you about dangling work instead of waiting for you to
+look:
+
+    bread config set notify.webhook https://hooks.slack.com/services/...
+    bread config set notify.smtp_server smtp.example.com:587
+    bread config set notify.to me@example.com
+    0 18 * * 1-5  git explain ~/src --notify webhook,email > /dev/null
+
+HISTORY
+
+--record appends each repo's uncommitted files, unpushed commits,
+stashes and commit totals to a local SQLite database (it needs the sqlite3
+command), and 'git explain history <repo>' shows how they changed:
+
+    git explain ~/src --record
+    git explain history ~/src/app --days 90
+
+--llm-digest asks the LLM for a short markdown digest of how the recorded
+repos under the directory changed over the last week (--digest-days), for
+a journal or a standup note:
+
+    git explain ~/src --llm-digest >> ~/journal/week-19.md
+
+LLM-POWERED ADVICE
+
+Enable intelligent, context-aware suggestions with --llm-advice.
+Requires an API key set in the environment:
+
+  OpenAI (default):
+    export OPENAI_API_KEY=sk-...
+    git explain --llm-advice --advice
+
+  Anthropic:
+    export ANTHROPIC_API_KEY=sk-ant-...
+    git explain --llm-advice --llm-provider anthropic --advice
+
+  Ollama (local, no API key):
+    export OLLAMA_HOST=localhost:11434   # optional
+    export OLLAMA_MODEL=llama3.2         # optional
+    git explain --llm-advice --llm-provider ollama --advice
+
+  Any OpenAI-compatible API (OpenRouter, vLLM, llama.cpp server, proxies):
+    export OPENAI_BASE_URL=https://openrouter.ai/api/v1
+    export OPENAI_MODEL=meta-llama/llama-3.1-8b-instruct   # optional
+    git explain --llm-advice --llm-header "X-Team: tools" --advice
+
+  Static (offline, canned advice for tests and demos):
+    export GIT_THIS_BREAD_LLM_PROVIDER=static   # or --llm-provider static
+    git explain --llm-advice --advice
+
+Rate limits and server errors are retried with backoff. List several
+providers (--llm-provider openai,anthropic) to fall back to the next
+one when the first is unavailable.
+
+Defaults for the provider, model, base URL, instructions, cache TTL and
+prompt templates can be set in the [llm] section of config.toml (or in
+llm.toml next to it); flags override them.
+
+Use --llm-chat to ask follow-up questions about the repos once the advice
+is shown, and --llm-usage to see the tokens spent this run and this month.
+
+--suggest-commit writes a commit message for the staged changes instead:
+
+    git commit -e -F <(git explain --suggest-commit)
+
+Advice is cached based on repo state. Use --no-cache to bypass, and
+'git explain llm-cache list|clear|prune' to manage the cache.
+If the API is unavailable, falls back to rule-based advice.`,
+	Args:              cobra.ArbitraryArgs,
+	ValidArgsFunction: completeArgs,
+	RunE:              runExplain,
+}
+
+var llmCacheCmd = &cobra.Command{
+	Use:   "llm-cache",
+	Short: "Inspect and clean up cached LLM advice",
+	Long: `Inspect and clean up cached LLM advice.
+
+Entries are dropped automatically after each LLM call once the cache grows
+past cache_max_entries (default 1000) or cache_max_bytes (default 10 MiB)
+from the LLM config, least recently used first. Entries older than cache_ttl are
+never served and are removed by prune.`,
+}
+
+var llmCacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cached advice, most recently used first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files, err := llmadvice.ListCache()
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			fmt.Println("LLM advice cache is empty.")
+			return nil
+		}
+
+		t := render.NewTable("Repos", "Model", "Created", "Last used", "Size")
+		var total int64
+		for i := range files {
+			f := &files[i]
+			total += f.Size
+			repos := strings.Join(f.Repos, "\n")
+			if repos == "" {
+				repos = "(unreadable)"
+			}
+			model := f.Provider
+			if f.Model != "" {
+				model += "/" + f.Model
+			}
+			t.AddRow(repos, model, formatTime(f.CreatedAt), formatTime(f.LastUsed), fmt.Sprintf("%d B", f.Size))
+		}
+		fmt.Println(t.String())
+		fmt.Printf("%d cached response(s), %d bytes\n", len(files), total)
+		return nil
+	},
+}
+
+var llmCacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached advice",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := llmadvice.ClearCache()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d cached response(s).\n", n)
+		return nil
+	},
+}
+
+var llmCachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired entries and trim the cache to its size limits",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		limits := cfg.LLM.Options().CacheLimits()
+		if cmd.Flags().Changed("ttl") {
+			limits.TTL = pruneTTL
+		}
+		if cmd.Flags().Changed("max-entries") {
+			limits.MaxEntries = pruneMaxEntries
+		}
+		if cmd.Flags().Changed("max-bytes") {
+			limits.MaxBytes = pruneMaxBytes
+		}
+
+		n, err := llmadvice.PruneCache(limits)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d cached response(s).\n", n)
+		return nil
+	},
+}
+
+// formatTime shows a cache timestamp, or "-" when it is unknown
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func init() {
+	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show detailed output (default for single repo)")
+	rootCmd.Flags().BoolVarP(&compact, "compact", "c", false, "Show compact one-line output (default for multi-repo)")
+	rootCmd.Flags().BoolVarP(&showAll, "all", "a", false, "Show all directories, even non-git ones")
+	rootCmd.Flags().StringSliceVar(&only, "only", nil, "Analyze only the subdirectories matching these globs (comma-separated or repeatable)")
+	rootCmd.Flags().StringSliceVar(&labels, "label", nil, "Show only the repos with any of these labels (comma-separated or repeatable)")
+	rootCmd.Flags().BoolVar(&byLabel, "by-label", false, "Group repos by label instead of by category")
+	rootCmd.Flags().StringArrayVar(&excludes, "exclude", nil, "Skip subdirectories matching this glob (repeatable; default explain.excludes from config.toml)")
+	rootCmd.Flags().BoolVarP(&useTable, "table", "t", false, "Show compact table view")
+	rootCmd.Flags().BoolVarP(&showLegend, "legend", "l", false, "Show legend explaining icons and colors")
+	rootCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Suppress progress bar")
+	rootCmd.Flags().BoolVar(&showAdvice, "advice", false, "Show actionable advice for each repo")
+	rootCmd.Flags().BoolVar(&useJSON, "json", false, "Output as JSON")
+	rootCmd.Flags().StringVar(&outputFormat, "format", "text", "Output format: text, gh-actions (annotations and a job summary for GitHub Actions)")
+	_ = rootCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(render.Formats, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.Flags().StringSliceVar(&notifySinks, "notify", nil, "Send a summary of the repos needing attention, if any: webhook, email (settings in [notify] of config.toml)")
+	_ = rootCmd.RegisterFlagCompletionFunc("notify", cobra.FixedCompletions(notify.Sinks, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.Flags().BoolVar(&showSchema, "schema", false, "Output JSON schema for the JSON output format and exit")
+	rootCmd.Flags().BoolVar(&llmAdvice, "llm-advice", false, "Enable LLM-powered advice (requires API key in env)")
+	rootCmd.Flags().StringVar(&llmProvider, "llm-provider", "openai", "LLM provider: openai, anthropic, ollama, static (offline); a comma-separated list falls back in order")
+	rootCmd.Flags().StringVar(&llmInstructions, "llm-instructions", "", "Custom instructions for the LLM (e.g., persona or style)")
+	rootCmd.Flags().StringVar(&llmModel, "llm-model", "", "LLM model name (default $OPENAI_MODEL/$ANTHROPIC_MODEL/$OLLAMA_MODEL or the provider default)")
+	rootCmd.Flags().Float64Var(&llmTemperature, "llm-temperature", 0.3, "LLM sampling temperature")
+	rootCmd.Flags().IntVar(&llmMaxTokens, "llm-max-tokens", 500, "Maximum tokens in the LLM response")
+	rootCmd.Flags().StringVar(&llmBaseURL, "llm-base-url", "", "OpenAI-compatible API or Ollama server URL (default $OPENAI_BASE_URL / $OLLAMA_HOST)")
+	rootCmd.Flags().StringArrayVar(&llmHeaders, "llm-header", nil, `Extra HTTP header for the LLM API, as "Name: value" (repeatable)`)
+	rootCmd.Flags().BoolVar(&llmRedact, "llm-redact", false, "Send only counts, dates and states to the LLM: no names, paths, URLs or messages")
+	rootCmd.Flags().BoolVar(&llmChat, "llm-chat", false, "After the advice, ask the LLM follow-up questions (implies --llm-advice)")
+	rootCmd.Flags().BoolVar(&suggestCommit, "suggest-commit", false, "Print an LLM-written commit message for the staged changes and exit")
+	rootCmd.Flags().BoolVar(&llmDigest, "llm-digest", false, "Print an LLM-written markdown digest of how the --record history changed and exit")
+	rootCmd.Flags().IntVar(&digestDays, "digest-days", 7, "How many days of history --llm-digest covers")
+	rootCmd.Flags().BoolVar(&llmUsage, "llm-usage", false, "Print LLM token usage and estimated cost for this run and month")
+	rootCmd.Flags().BoolVar(&noStream, "no-stream", false, "Wait for the full LLM response instead of showing advice as it arrives")
+	rootCmd.Flags().BoolVar(&noCache, "no-cache", false, "Bypass LLM advice cache")
+	rootCmd.Flags().BoolVar(&perRepo, "per-repo", false, "In multi-repo mode, analyze each repo individually with LLM")
+	rootCmd.Flags().IntVar(&llmConcurrency, "llm-concurrency", llmadvice.DefaultConcurrency, "LLM calls to run at once with --per-repo")
+	rootCmd.Flags().IntVar(&maxCommits, "max-commits", 0, "Stop counting commits after this many per walk (0 = unlimited)")
+	rootCmd.Flags().DurationVar(&repoTimeout, "timeout", 30*time.Second, "Per-repo analysis timeout (0 = none)")
+	rootCmd.Flags().BoolVar(&probeRemotes, "probe-remotes", false, "Check that each remote is reachable and list branches only on your remotes (uses the network)")
+	rootCmd.Flags().BoolVar(&diskUsage, "disk-usage", false, "Measure repository size and object stats")
+	rootCmd.Flags().BoolVar(&activity, "activity", false, "After the repos, sum up your commits of the last year by repo, week by week")
+	rootCmd.Flags().StringVar(&sortBy, "sort", "name", "Sort multi-repo output: name, size (implies --disk-usage)")
+	rootCmd.Flags().BoolVar(&fetchUpstream, "fetch", false, "Fetch the upstream remote of forks before comparing with it")
+	rootCmd.Flags().BoolVar(&noPager, "no-pager", false, "Don't pipe long output through $PAGER")
+	rootCmd.Flags().BoolVar(&noAlign, "no-align", false, "Don't line up columns in multi-repo compact output")
+	rootCmd.Flags().BoolVar(&asciiIcons, "ascii", false, "Draw icons and activity bars with ASCII characters, for terminals without a Nerd Font")
+	rootCmd.Flags().StringVar(&themeName, "theme", "", "Color theme: dark, light (default $GIT_THIS_BREAD_THEME, ui.theme from config.toml, or dark)")
+	rootCmd.Flags().StringVar(&hyperlinks, "hyperlinks", "", "Link repo names to their directories and remotes to their pages: auto, always, never (default ui.hyperlinks from config.toml, or auto)")
+	_ = rootCmd.RegisterFlagCompletionFunc("hyperlinks", cobra.FixedCompletions(render.HyperlinkModes, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.Flags().BoolVar(&fix, "fix", false, "Run the commands the advice suggests, asking before each one")
+	rootCmd.Flags().BoolVar(&maintenance, "maintenance", false, "Like --fix, but only run gc and git maintenance where repo health calls for it (implies --disk-usage)")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "With --fix or --maintenance, print the commands as JSON actions (for bread apply) instead of running them")
+	rootCmd.Flags().BoolVarP(&fixYes, "yes", "y", false, "With --fix or --maintenance, run every command without asking")
+	rootCmd.Flags().BoolVar(&record, "record", false, "Append each repo's metrics to the history database (see: git explain history)")
+	rootCmd.Flags().StringVar(&assumeIdentity, "assume-identity", "", "Judge commits and remotes as this identity profile's (managed by git-id) instead of git config's (alias: --as)")
+	_ = rootCmd.RegisterFlagCompletionFunc("assume-identity", cli.CompleteProfileFlag)
+	rootCmd.Flags().SetNormalizeFunc(func(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "as" {
+			name = "assume-identity"
+		}
+		return pflag.NormalizedName(name)
+	})
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "compact")
+	rootCmd.MarkFlagsMutuallyExclusive("llm-chat", "json")
+	rootCmd.MarkFlagsMutuallyExclusive("suggest-commit", "llm-chat", "json")
+	rootCmd.MarkFlagsMutuallyExclusive("llm-digest", "suggest-commit", "llm-chat", "json")
+	rootCmd.MarkFlagsMutuallyExclusive("fix", "llm-digest")
+	rootCmd.MarkFlagsMutuallyExclusive("maintenance", "llm-digest")
+	rootCmd.MarkFlagsMutuallyExclusive("fix", "json")
+	rootCmd.MarkFlagsMutuallyExclusive("fix", "llm-advice")
+	rootCmd.MarkFlagsMutuallyExclusive("fix", "llm-chat")
+	rootCmd.MarkFlagsMutuallyExclusive("fix", "suggest-commit")
+	rootCmd.MarkFlagsMutuallyExclusive("fix", "maintenance")
+	rootCmd.MarkFlagsMutuallyExclusive("maintenance", "json")
+	rootCmd.MarkFlagsMutuallyExclusive("maintenance", "llm-advice")
+	rootCmd.MarkFlagsMutuallyExclusive("maintenance", "llm-chat")
+	rootCmd.MarkFlagsMutuallyExclusive("maintenance", "suggest-commit")
+
+	llmCachePruneCmd.Flags().DurationVar(&pruneTTL, "ttl", 0, "Remove entries older than this (default cache_ttl from the LLM config)")
+	llmCachePruneCmd.Flags().IntVar(&pruneMaxEntries, "max-entries", 0, "Keep at most this many entries (default cache_max_entries or 1000)")
+	llmCachePruneCmd.Flags().Int64Var(&pruneMaxBytes, "max-bytes", 0, "Keep at most this many bytes (default cache_max_bytes or 10 MiB)")
+	llmCacheCmd.AddCommand(llmCacheListCmd, llmCacheClearCmd, llmCachePruneCmd)
+	rootCmd.AddCommand(llmCacheCmd)
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	if showSchema {
+		r := jsonschema.Reflector{}
+		schema := r.Reflect(&[]render.RepoJSON{})
+		out, _ := json.MarshalIndent(schema, "", "  ")
+		fmt.Println(string(out))
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := render.LoadTheme(themeName, cfg.UI.Theme); err != nil {
+		return err
+	}
+	render.SetASCII(asciiIcons)
+
+	if showLegend {
+		return render.PrintLegend()
+	}
+	if !cmd.Flags().Changed("hyperlinks") {
+		hyperlinks = cfg.UI.Hyperlinks
+	}
+	if err := render.SetHyperlinks(hyperlinks); err != nil {
+		return err
+	}
+
+	// Find out who you are before doing anything
+	if err := loadIdentity(&cfg); err != nil {
+		return err
+	}
+
+	dirs := []string{"."}
+	if len(args) > 0 {
+		dirs = args[:1]
+		only = append(only, repoPatterns(args[1:])...)
+	} else if len(cfg.Explain.Roots) > 0 && !analyzer.IsGitRepo(".") {
+		dirs = cfg.Explain.Roots
+	}
+
+	targets := make([]string, len(dirs))
+	for i, dir := range dirs {
+		if targets[i], err = checkDir(dir); err != nil {
+			return err
+		}
+	}
+	target := targets[0]
+
+	if sortBy != "name" && sortBy != "size" {
+		return fmt.Errorf("invalid --sort value %q: must be name or size", sortBy)
+	}
+	if !slices.Contains(render.Formats, outputFormat) {
+		return fmt.Errorf("invalid --format value %q: must be %s", outputFormat, strings.Join(render.Formats, ", "))
+	}
+	ghActions := outputFormat == "gh-actions"
+	if ghActions && (useJSON || useTable || llmAdvice || llmChat) {
+		return errors.New("--format gh-actions can't be combined with --json, --table or --llm-advice")
+	}
+	if ghActions {
+		quiet = true // The log is for the annotations
+	}
+	if err := cfg.Notify.Check(notifySinks); err != nil {
+		return err
+	}
+
+	isSingleRepo := len(targets) == 1 && analyzer.IsGitRepo(target)
+	if len(only) > 0 {
+		if isSingleRepo {
+			return fmt.Errorf("%s is a repository: name repos to pick from the directory that holds them", target)
+		}
+		if err := checkOnly(targets, only); err != nil {
+			return err
+		}
+	}
+	if isSingleRepo && (len(labels) > 0 || byLabel) {
+		return fmt.Errorf("%s is a repository: --label and --by-label pick repos from the directory that holds them", target)
+	}
+
+	// Determine verbose mode:
+	// - Single repo: verbose by default, unless --compact
+	// - Multi-repo: compact by default, unless --verbose
+	useVerbose := verbose || (isSingleRepo && !compact)
+
+	opts := analyzer.Options{
+		Verbose:      useVerbose || useJSON,
+		Fetch:        fetchUpstream,
+		MaxCommits:   maxCommits,
+		Timeout:      repoTimeout,
+		ProbeRemotes: probeRemotes,
+		DiskUsage:    diskUsage || sortBy == "size" || maintenance,
+		Excludes:     cfg.Explain.Excludes,
+		Only:         only,
+		Labels:       cfg.Explain.Labels,
+		BranchNames:  cfg.Explain.DefaultBranches,
+		ReadOnly:     cfg.Explain.ReadOnlyRemotes,
+	}
+	if cmd.Flags().Changed("exclude") {
+		opts.Excludes = excludes
+	}
+
+	if dryRun && !fix && !maintenance {
+		return errors.New("--dry-run needs --fix or --maintenance")
+	}
+	if fixYes && !fix && !maintenance {
+		return errors.New("--yes needs --fix or --maintenance")
+	}
+	if fix {
+		return runFix(cmd, targets, isSingleRepo, opts, render.ActionsFor)
+	}
+	if maintenance {
+		return runFix(cmd, targets, isSingleRepo, opts, render.MaintenanceActionsFor)
+	}
+
+	if llmChat {
+		if err := cli.RequireInput("--llm-chat", i18n.T("Use --llm-advice for the advice alone")); err != nil {
+			return err
+		}
+		if !render.IsTerminal() {
+			return errors.New("--llm-chat needs an interactive terminal")
+		}
+		// --llm-chat implies --llm-advice
+		llmAdvice = true
+	}
+	if suggestCommit {
+		if !isSingleRepo {
+			return fmt.Errorf("--suggest-commit needs a git repository: %s", target)
+		}
+		llmAdvice = true
+	}
+	if llmDigest {
+		llmAdvice = true
+	}
+
+	// Build LLM options if enabled
+	var llmOpts *llmadvice.Options
+	var usage llmadvice.Usage
+	if llmAdvice {
+		llmOpts, err = buildLLMOptions(cmd, &cfg.LLM)
+		if err != nil {
+			return err
+		}
+		llmOpts.OnUsage = usage.Add
+		defer func() { llmadvice.ReportUsage(os.Stderr, usage, llmUsage) }()
+		if !quiet && term.IsTerminal(int(os.Stderr.Fd())) { //nolint:gosec // Fd fits in int on all supported platforms
+			llmOpts.Progress = showLLMProgress
+		}
+		// --llm-advice implies --advice
+		showAdvice = true
+	}
+
+	if suggestCommit {
+		return runSuggestCommit(target, opts, llmOpts)
+	}
+	if llmDigest {
+		return runDigest(cmd, targets, llmOpts)
+	}
+
+	// Stream LLM advice when someone is watching; the pager would hold it back
+	stream := llmAdvice && !noStream && !useJSON && render.IsTerminal()
+	skipPager := noPager || useJSON || ghActions || stream || llmChat
+
+	if isSingleRepo {
+		// Single repo mode
+		repoInfo := analyzer.AnalyzeRepo(target, opts)
+		if record {
+			if err := recordRun(cmd, []analyzer.RepoInfo{repoInfo}); err != nil {
+				return err
+			}
+		}
+		err := render.Page(skipPager, func(w io.Writer) error {
+			if ghActions {
+				return writeGHActions(w, []analyzer.RepoInfo{repoInfo})
+			}
+			return render.WriteRepo(w, &repoInfo, render.Options{
+				Verbose:    useVerbose,
+				ShowAdvice: showAdvice,
+				UseJSON:    useJSON,
+				Stream:     stream,
+				LLMOpts:    llmOpts,
+			})
+		})
+		if err == nil {
+			err = sendNotification(cmd, &cfg.Notify, []analyzer.RepoInfo{repoInfo})
+		}
+		if err != nil || !llmChat {
+			return err
+		}
+		return runChat([]*analyzer.RepoInfo{&repoInfo}, llmOpts)
+	}
+
+	// Multi-repo mode
+	var repos []analyzer.RepoInfo
+	for _, t := range targets {
+		repos = append(repos, analyzer.AnalyzeDirectory(t, opts, !quiet)...)
+	}
+	if len(labels) > 0 {
+		repos = analyzer.FilterByLabel(repos, labels)
+	}
+	if sortBy == "size" {
+		analyzer.SortBySize(repos)
+	}
+	if record {
+		if err := recordRun(cmd, repos); err != nil {
+			return err
+		}
+	}
+
+	err = render.Page(skipPager, func(w io.Writer) error {
+		switch {
+		case useJSON:
+			return render.WriteJSONWithLLM(w, repos, llmOpts)
+		case ghActions:
+			return writeGHActions(w, repos)
+		case useTable:
+			return render.WriteTable(w, repos)
+		default:
+			return render.WriteRepos(w, repos, render.Options{
+				Verbose:    useVerbose,
+				ShowAdvice: showAdvice,
+				ShowAll:    showAll,
+				Aligned:    !noAlign,
+				Activity:   activity,
+				ByLabel:    byLabel,
+				Stream:     stream,
+				LLMOpts:    llmOpts,
+			})
+		}
+	})
+	if err == nil {
+		err = sendNotification(cmd, &cfg.Notify, repos)
+	}
+	if err != nil || !llmChat {
+		return err
+	}
+
+	var gitRepos []*analyzer.RepoInfo
+	for i := range repos {
+		if repos[i].IsGitRepo && repos[i].Error == "" {
+			gitRepos = append(gitRepos, &repos[i])
+		}
+	}
+	return runChat(gitRepos, llmOpts)
+}
+
+// writeGHActions writes repos as GitHub Actions workflow commands, and
+// adds their summary to the job's when run in a workflow
+func writeGHActions(w io.Writer, repos []analyzer.RepoInfo) error {
+	if err := render.WriteGHActions(w, repos); err != nil {
+		return err
+	}
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) //nolint:gosec // path is the runner's summary file
+	if err != nil {
+		return fmt.Errorf("writing the job summary: %w", err)
+	}
+	err = render.WriteGHSummary(f, repos)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// sendNotification sends the repos needing attention to the --notify
+// sinks, when any do
+func sendNotification(cmd *cobra.Command, cfg *notify.Config, repos []analyzer.RepoInfo) error {
+	if len(notifySinks) == 0 {
+		return nil
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	msg, ok := render.Notification(repos, host)
+	if !ok {
+		return nil
+	}
+	cmd.SilenceUsage = true // A sink failing is no fault of the command line
+	return cfg.Send(cmd.Context(), notifySinks, msg)
+}
+
+// useProfile makes the analysis judge ownership by a git-id profile: its
+// email for commits and its forge accounts (ghuser and the rest) for remotes
+func useProfile(name string) error {
+	p, err := identity.Get(name)
+	if err != nil {
+		return fmt.Errorf("%w\nUse 'git-id list' to see available profiles", err)
+	}
+	if p.Email == "" || p.GHUser == "" {
+		return fmt.Errorf("--assume-identity needs a profile with email and ghuser. Use: git-id set %s <email|ghuser> <value>", name)
+	}
+	analyzer.AssumeIdentity(p.Email, p.GHUser)
+	for _, a := range forge.Accounts(p) {
+		analyzer.AddHostUser(a.Host, a.User)
+	}
+	return nil
+}
+
+// repoPatterns turns the repo arguments into name globs, so that a repo
+// given as a path (as tab completion leaves it) matches by its name
+func repoPatterns(args []string) []string {
+	patterns := make([]string, len(args))
+	for i, arg := range args {
+		patterns[i] = filepath.Base(filepath.Clean(arg))
+	}
+	return patterns
+}
+
+// checkOnly makes sure every pattern picks some subdirectory of the
+// targets, so that a mistyped repo name is not silently left out
+func checkOnly(targets, patterns []string) error {
+	for _, pattern := range patterns {
+		found := false
+		for _, t := range targets {
+			matches, err := filepath.Glob(filepath.Join(t, pattern))
+			if err != nil {
+				return fmt.Errorf("invalid repo pattern %q: %w", pattern, err)
+			}
+			if len(matches) > 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("no directory in %s matches %q", strings.Join(targets, ", "), pattern)
+		}
+	}
+	return nil
+}
+
+// completeArgs completes the directory, then the names of its subdirectories
+func completeArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return cli.CompleteDir(cmd, args, toComplete)
+	}
+	entries, err := os.ReadDir(args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") && strings.HasPrefix(e.Name(), toComplete) {
+			names = append(names, e.Name())
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// checkDir returns the absolute path of dir, which must be a directory
+func checkDir(dir string) (string, error) {
+	target, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid directory: %w", err)
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return "", fmt.Errorf("cannot access directory: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("not a directory: %s", target)
+	}
+	return target, nil
+}
+
+// runFix turns the advice for the targets into actions with actionsFor,
+// then prints them (--dry-run) or runs them one confirmation at a time
+func runFix(cmd *cobra.Command, targets []string, isSingleRepo bool, opts analyzer.Options,
+	actionsFor func(*analyzer.RepoInfo) []actions.Action) error {
+	var repos []analyzer.RepoInfo
+	if isSingleRepo {
+		repos = []analyzer.RepoInfo{analyzer.AnalyzeRepo(targets[0], opts)}
+	} else {
+		for _, t := range targets {
+			repos = append(repos, analyzer.AnalyzeDirectory(t, opts, !quiet)...)
+		}
+		if len(labels) > 0 {
+			repos = analyzer.FilterByLabel(repos, labels)
+		}
+	}
+
+	var items []actions.Action
+	for i := range repos {
+		if repos[i].IsGitRepo && repos[i].Error == "" {
+			items = append(items, actionsFor(&repos[i])...)
+		}
+	}
+	if dryRun {
+		return actions.Write(cmd.OutOrStdout(), items)
+	}
+	if len(items) == 0 {
+		fmt.Println("Nothing to fix.")
+		return nil
+	}
+	if !fixYes {
+		if err := cli.RequireInput("git explain --fix", i18n.T("Use --yes to run every fix without asking, or --dry-run to print them")); err != nil {
+			return err
+		}
+	}
+	res, err := actions.Apply(cmd.Context(), items, actions.ApplyOptions{Yes: fixYes})
+	return actions.Summarize(os.Stdout, res, err)
+}
+
+// runChat answers follow-up questions about repos until the user enters an
+// empty line or closes stdin
+func runChat(repos []*analyzer.RepoInfo, llmOpts *llmadvice.Options) error {
+	if len(repos) == 0 {
+		return nil
+	}
+	chat, err := llmadvice.NewChat(repos, render.GetAdvice, *llmOpts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println("Ask a follow-up question (empty line or Ctrl-D to quit)")
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("> ")
+		if !scanner.Scan() {
+			fmt.Println()
+			return scanner.Err()
+		}
+		question := strings.TrimSpace(scanner.Text())
+		if question == "" || question == "exit" || question == "quit" {
+			return nil
+		}
+		if err := askChat(chat, question); err != nil {
+			fmt.Fprintf(os.Stderr, "LLM error: %v\n\n", err)
+		}
+	}
+}
+
+// runSuggestCommit prints a commit message for the staged changes in repo,
+// on its own so it can be piped into git commit -F -
+func runSuggestCommit(repo string, opts analyzer.Options, llmOpts *llmadvice.Options) error {
+	info := analyzer.AnalyzeRepo(repo, opts)
+	diff := analyzer.StagedDiff(context.Background(), repo)
+	message, err := llmadvice.SuggestCommitMessage(&info, diff, *llmOpts)
+	if err != nil {
+		return err
+	}
+	fmt.Println(message)
+	return nil
+}
+
+// askChat prints the answer to one question, as it arrives unless --no-stream
+func askChat(chat *llmadvice.Chat, question string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	shown := false
+	var onText func(string)
+	if !noStream {
+		onText = func(text string) {
+			shown = true
+			fmt.Print(text)
+		}
+	}
+	answer, err := chat.Ask(ctx, question, onText)
+	if err != nil {
+		return err
+	}
+	if !shown {
+		fmt.Print(strings.TrimSpace(answer))
+	}
+	fmt.Print("\n\n")
+	return nil
+}
+
+// showLLMProgress keeps a one-line count of per-repo LLM calls on stderr,
+// clearing it once every repo is done
+func showLLMProgress(done, total int) {
+	if done == total {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\r\033[K🧠 Asking the LLM... %d/%d repos", done, total)
+}
+
+// buildLLMOptions starts from the LLM config and applies the LLM flags the
+// user set
+func buildLLMOptions(cmd *cobra.Command, cfg *llmadvice.Config) (*llmadvice.Options, error) {
+	o := cfg.Options()
+	o.NoCache = noCache
+	o.PerRepo = perRepo
+
+	flags := cmd.Flags()
+	if flags.Changed("llm-provider") {
+		o.Provider, o.Fallback = llmadvice.ParseProviders(llmProvider)
+	}
+	if flags.Changed("llm-redact") {
+		o.Redact = llmRedact
+	}
+	if flags.Changed("llm-instructions") {
+		o.Instructions = llmInstructions
+	}
+	if flags.Changed("llm-model") {
+		o.Model = llmModel
+	}
+	if flags.Changed("llm-temperature") {
+		o.Temperature = &llmTemperature
+	}
+	if flags.Changed("llm-max-tokens") {
+		o.MaxTokens = llmMaxTokens
+	}
+	if flags.Changed("llm-base-url") {
+		o.BaseURL = llmBaseURL
+	}
+	if flags.Changed("llm-concurrency") {
+		o.Concurrency = llmConcurrency
+	}
+
+	headers, err := llmadvice.ParseHeaders(llmHeaders)
+	if err != nil {
+		return nil, err
+	}
+	if len(headers) > 0 && o.Headers == nil {
+		o.Headers = make(map[string]string, len(headers))
+	}
+	for k, v := range headers {
+		o.Headers[k] = v
+	}
+
+	// Advice, unlike commit messages, follows the locale of the messages
+	if i18n.Locale() != i18n.Default && !suggestCommit {
+		o.Instructions = strings.TrimSpace(o.Instructions + "\nWrite the advice text in " + i18n.Language() + ".")
+	}
+
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// Command returns the git-explain command
+func Command() *cobra.Command {
+	return rootCmd
+}
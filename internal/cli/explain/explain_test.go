@@ -0,0 +1,83 @@
+package explain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/config"
+	"github.com/jdevera/git-this-bread/testutil"
+)
+
+// setupHome points HOME and the config dir at temp dirs, with gitconfig as
+// the global git config
+func setupHome(t *testing.T, gitconfig string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".gitconfig"), []byte(gitconfig), 0o600))
+	t.Cleanup(analyzer.ResetTestConfig)
+	return home
+}
+
+func TestLoadIdentity_AssumeIdentity(t *testing.T) {
+	setupHome(t, `[user]
+	email = me@example.com
+[github]
+	user = me
+[identity "work"]
+	email = me@work.example
+	ghuser = me-work
+[identity "work.host.git.work.example"]
+	user = me-gitea
+`)
+	orig := assumeIdentity
+	assumeIdentity = "work"
+	t.Cleanup(func() { assumeIdentity = orig })
+	require.NoError(t, loadIdentity(&config.Config{}))
+
+	repo := testutil.NewTestRepo(t)
+	repo.WriteFile("a.txt", "a")
+	repo.CommitAs("work commit", "me@work.example", "Me")
+	repo.WriteFile("b.txt", "b")
+	repo.CommitAs("personal commit", "me@example.com", "Me")
+	repo.WriteFile("c.txt", "c")
+	repo.CommitAs("other work commit", "me@work.example", "Me")
+	repo.AddRemote("work", "git@github.com:me-work/tool.git")
+	repo.AddRemote("personal", "git@github.com:me/tool.git")
+	repo.AddRemote("gitea", "https://git.work.example/me-gitea/tool.git")
+
+	info := analyzer.AnalyzeRepo(repo.Path, analyzer.Options{})
+	require.NotNil(t, info.Commits)
+	assert.Equal(t, 2, info.Commits.UserTotal, "only the profile's email counts")
+	mine := map[string]bool{}
+	for _, r := range info.AllRemotes {
+		mine[r.Name] = r.IsMine
+	}
+	assert.Equal(t, map[string]bool{"work": true, "personal": false, "gitea": true}, mine,
+		"the profile's forge accounts own remotes, not github.user")
+}
+
+func TestLoadIdentity_AssumeIdentityErrors(t *testing.T) {
+	setupHome(t, `[identity "noforge"]
+	email = me@work.example
+`)
+	orig := assumeIdentity
+	t.Cleanup(func() { assumeIdentity = orig })
+
+	assumeIdentity = "noforge"
+	err := loadIdentity(&config.Config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--assume-identity needs a profile with email and ghuser")
+
+	assumeIdentity = "missing"
+	err = loadIdentity(&config.Config{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "git-id list")
+}
@@ -0,0 +1,135 @@
+package explain
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/history"
+	"github.com/jdevera/git-this-bread/internal/llmadvice"
+	"github.com/jdevera/git-this-bread/internal/render"
+)
+
+var historyDays int
+
+var historyCmd = &cobra.Command{
+	Use:   "history [repo]",
+	Short: "Show how a repo's metrics changed across --record runs",
+	Long: `Show the metrics git-explain --record stored for a repo, one row per run,
+and how each changed over the period: did the unpushed work shrink this
+month, or grow?
+
+REPO is a directory (default: the current one) or the name of a recorded
+repo. The history lives in a SQLite database under XDG_STATE_HOME, written
+with the sqlite3 command.`,
+	Example: `  git explain ~/src --record
+  git explain history ~/src/app
+  git explain history app --days 90`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo := "."
+		if len(args) > 0 {
+			repo = args[0]
+		}
+		if st, err := os.Stat(repo); err == nil && st.IsDir() {
+			if repo, err = filepath.Abs(repo); err != nil {
+				return err
+			}
+		}
+
+		db, err := history.Path()
+		if err != nil {
+			return err
+		}
+		since := time.Now().AddDate(0, 0, -historyDays)
+		entries, err := history.Load(cmd.Context(), db, repo, since)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Printf("No runs of %s recorded in the last %d days. Record them with: git explain --record\n", repo, historyDays)
+			return nil
+		}
+
+		t := render.NewTable("Date", "Uncommitted", "Unpushed", "Behind", "Stashes", "Your commits")
+		for _, e := range entries {
+			t.AddRow(e.Time.Local().Format("2006-01-02 15:04"),
+				strconv.Itoa(e.Dirty), strconv.Itoa(e.Ahead), strconv.Itoa(e.Behind),
+				strconv.Itoa(e.Stashes), strconv.Itoa(e.UserCommits))
+		}
+		fmt.Println(t.String())
+
+		fmt.Printf("Since %s:\n", entries[0].Time.Local().Format("2006-01-02"))
+		for _, c := range history.Trend(entries) {
+			fmt.Printf("  %-18s %d → %d  %s\n", c.Metric, c.From, c.To, trendWord(c.Delta()))
+		}
+		return nil
+	},
+}
+
+// trendWord describes a change in a metric
+func trendWord(delta int) string {
+	switch {
+	case delta > 0:
+		return fmt.Sprintf("(grew by %d)", delta)
+	case delta < 0:
+		return fmt.Sprintf("(shrank by %d)", -delta)
+	}
+	return "(unchanged)"
+}
+
+// runDigest prints an LLM digest of how the recorded repos under roots
+// changed over the last --digest-days
+func runDigest(cmd *cobra.Command, roots []string, llmOpts *llmadvice.Options) error {
+	db, err := history.Path()
+	if err != nil {
+		return err
+	}
+	since := time.Now().AddDate(0, 0, -digestDays)
+	var entries []history.Entry
+	for _, root := range roots {
+		under, err := history.LoadUnder(cmd.Context(), db, root, since)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, under...)
+	}
+	if len(entries) == 0 {
+		fmt.Printf("No runs under %s recorded in the last %d days. Record them with: git explain --record\n", strings.Join(roots, ", "), digestDays)
+		return nil
+	}
+
+	digest, err := llmadvice.Digest(entries, *llmOpts)
+	if err != nil {
+		return err
+	}
+	fmt.Println(digest)
+	return nil
+}
+
+// recordRun appends the metrics of the analyzed repos to the history
+func recordRun(cmd *cobra.Command, repos []analyzer.RepoInfo) error {
+	db, err := history.Path()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var entries []history.Entry
+	for i := range repos {
+		if repos[i].IsGitRepo && repos[i].Error == "" {
+			entries = append(entries, history.FromRepo(&repos[i], now))
+		}
+	}
+	return history.Append(cmd.Context(), db, entries)
+}
+
+func init() {
+	historyCmd.Flags().IntVar(&historyDays, "days", 30, "How many days back to show")
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,103 @@
+package explain
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/config"
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/forge"
+	"github.com/jdevera/git-this-bread/internal/i18n"
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+// loadIdentity finds out who "you" are for the analysis: the profile of
+// --assume-identity, git config, the identity.default profile when git
+// config lacks them, or, on a fresh machine, what the user answers
+func loadIdentity(cfg *config.Config) error {
+	if assumeIdentity != "" {
+		return useProfile(assumeIdentity)
+	}
+	err := analyzer.LoadGitConfig()
+	var missing *analyzer.MissingConfigError
+	if !errors.As(err, &missing) {
+		if err == nil {
+			// Remotes of any profile's forge accounts are yours too
+			for _, a := range forge.AllAccounts() {
+				analyzer.AddHostUser(a.Host, a.User)
+			}
+		}
+		return err
+	}
+	if name := cfg.Identity.Default; name != "" && useProfile(name) == nil {
+		return nil
+	}
+	if cli.RequireInput("git-explain setup", "") != nil {
+		return err
+	}
+	return onboard(os.Stdin, missing)
+}
+
+// onboard asks who the user is and either writes it to the global git
+// config or saves it as a git-id profile and makes that the default
+func onboard(in io.Reader, missing *analyzer.MissingConfigError) error {
+	reader := bufio.NewReader(in)
+	ask := func(prompt, def string) string {
+		if def != "" {
+			prompt = fmt.Sprintf("%s [%s]", prompt, def)
+		}
+		fmt.Print(prompt + ": ")
+		answer, _ := reader.ReadString('\n')
+		if answer = strings.TrimSpace(answer); answer != "" {
+			return answer
+		}
+		return def
+	}
+
+	fmt.Println(i18n.Sprintf("git-explain needs to know which commits and remotes are yours, and git config lacks %s.",
+		strings.Join(missing.Missing, ", ")))
+	fmt.Println()
+	fmt.Println(i18n.T("  1) Set them in your global git config"))
+	fmt.Println(i18n.T("  2) Save them as an identity profile (git-id) and make it the default"))
+	choice := ask(i18n.T("Choose"), "1")
+	if choice != "1" && choice != "2" {
+		return i18n.Errorf("no choice %q: run git-explain again to set up", choice)
+	}
+
+	email := ask(i18n.T("Email of your commits"), missing.Email)
+	ghUser := ask(i18n.T("GitHub user"), missing.GitHubUser)
+	if email == "" || ghUser == "" {
+		return i18n.Errorf("both the email and the GitHub user are needed")
+	}
+
+	if choice == "1" {
+		for _, kv := range [][2]string{{"user.email", email}, {"github.user", ghUser}} {
+			if err := debuglog.Run(exec.Command("git", "config", "--global", kv[0], kv[1])); err != nil {
+				return fmt.Errorf("git config --global %s: %w", kv[0], err)
+			}
+		}
+		fmt.Println(i18n.T("Saved user.email and github.user in your global git config."))
+	} else {
+		p := identity.Profile{Name: ask(i18n.T("Profile name"), "personal"), Email: email, GHUser: ghUser}
+		file, err := identity.Set(&p, identity.SetOptions{})
+		if err != nil {
+			return err
+		}
+		if _, err := config.Set("identity.default", p.Name); err != nil {
+			return err
+		}
+		fmt.Println(i18n.Sprintf("Saved profile %q to %s and made it the default. Add its SSH key with: git-id set %s sshkey <path>",
+			p.Name, file, p.Name))
+	}
+	fmt.Println()
+
+	analyzer.AssumeIdentity(email, ghUser)
+	return nil
+}
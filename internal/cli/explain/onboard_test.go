@@ -0,0 +1,103 @@
+package explain
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/config"
+	"github.com/jdevera/git-this-bread/internal/identity"
+	"github.com/jdevera/git-this-bread/testutil"
+)
+
+// globalGit returns key from the global git config
+func globalGit(t *testing.T, key string) string {
+	t.Helper()
+	out, err := exec.Command("git", "config", "--global", key).Output()
+	require.NoError(t, err, "git config --global %s", key)
+	return strings.TrimSpace(string(out))
+}
+
+// runOnboard answers onboard with script
+func runOnboard(script string, missing *analyzer.MissingConfigError) (string, error) {
+	var err error
+	out := testutil.CaptureStdout(func() {
+		err = onboard(strings.NewReader(script), missing)
+	})
+	return out, err
+}
+
+func TestOnboard_GlobalConfig(t *testing.T) {
+	setupHome(t, "")
+	out, err := runOnboard("1\nme@example.com\nme\n",
+		&analyzer.MissingConfigError{Missing: []string{"user.email", "github.user"}})
+	require.NoError(t, err)
+	assert.Contains(t, out, "lacks user.email, github.user")
+	assert.Contains(t, out, "Saved user.email and github.user")
+
+	assert.Equal(t, "me@example.com", globalGit(t, "user.email"))
+	assert.Equal(t, "me", globalGit(t, "github.user"))
+	require.NoError(t, analyzer.LoadGitConfig(), "git config has them now")
+}
+
+func TestOnboard_Defaults(t *testing.T) {
+	setupHome(t, "")
+	// Only github.user is asked for: the choice and the email keep their defaults
+	_, err := runOnboard("\n\nme\n",
+		&analyzer.MissingConfigError{Missing: []string{"github.user"}, Email: "me@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "me@example.com", globalGit(t, "user.email"))
+	assert.Equal(t, "me", globalGit(t, "github.user"))
+}
+
+func TestOnboard_Profile(t *testing.T) {
+	setupHome(t, "")
+	out, err := runOnboard("2\nme@work.example\nme-work\nwork\n",
+		&analyzer.MissingConfigError{Missing: []string{"user.email", "github.user"}})
+	require.NoError(t, err)
+	assert.Contains(t, out, `Saved profile "work"`)
+
+	p, err := identity.Get("work")
+	require.NoError(t, err)
+	assert.Equal(t, "me@work.example", p.Email)
+	assert.Equal(t, "me-work", p.GHUser)
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "work", cfg.Identity.Default)
+
+	_, err = exec.Command("git", "config", "--global", "user.email").Output()
+	assert.Error(t, err, "the global git config is left alone")
+
+	// The next run finds the default profile instead of asking again
+	analyzer.ResetTestConfig()
+	require.NoError(t, loadIdentity(&cfg))
+}
+
+func TestOnboard_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr string
+	}{
+		{"unknown choice", "3\n", `no choice "3"`},
+		{"no email", "1\n\nme\n", "both the email and the GitHub user are needed"},
+		{"no GitHub user", "2\nme@example.com\n\n", "both the email and the GitHub user are needed"},
+		{"input ends", "1\n", "both the email and the GitHub user are needed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupHome(t, "")
+			_, err := runOnboard(tt.script, &analyzer.MissingConfigError{Missing: []string{"user.email", "github.user"}})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+
+			_, gitErr := exec.Command("git", "config", "--global", "user.email").Output()
+			assert.Error(t, gitErr, "nothing is saved")
+		})
+	}
+}
@@ -0,0 +1,183 @@
+// Package ghas implements the gh-as command, run on its own or as a
+// subcommand of bread
+package ghas
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/identity"
+	"github.com/jdevera/git-this-bread/internal/paths"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "gh-as <profile> [gh args...]",
+	Short: "Run gh (GitHub CLI) commands with a specific identity profile",
+	Long: `gh-as (a git-this-bread tool)
+
+Run gh (GitHub CLI) commands with a specific identity profile.
+
+The profile must have 'ghuser' configured and authenticated.
+Use 'git-id' to manage profiles.
+
+Profiles can add flags to gh commands, and define aliases, that gh-as
+expands before running gh:
+
+    git-id set work gh.pr.list.flags "--repo acme/monorepo"
+    git-id set work gh.prs.alias "pr list --author @me"
+    gh-as work prs     # gh pr list --repo acme/monorepo --author @me`,
+	Example: `  gh-as personal pr list
+  gh-as work issue create
+  gh-as work prs --state merged
+  gh-as personal repo clone owner/repo`,
+	Args:               cobra.MinimumNArgs(1),
+	ValidArgsFunction:  cli.CompleteProfile,
+	DisableFlagParsing: true, // Pass all flags to gh
+	RunE:               run,
+}
+
+// Command returns the gh-as command
+func Command() *cobra.Command {
+	return rootCmd
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	// Check for help flags manually since we disabled flag parsing
+	if len(args) > 0 && (args[0] == "-h" || args[0] == "--help" || args[0] == "help") {
+		return cmd.Help()
+	}
+	if len(args) > 0 && (args[0] == "-v" || args[0] == "--version") {
+		cli.PrintVersion(cmd)
+		return nil
+	}
+
+	if len(args) < 1 {
+		return fmt.Errorf("missing profile argument")
+	}
+
+	profileName := args[0]
+	ghArgs := args[1:]
+
+	// Load the profile
+	profile, err := identity.Get(profileName)
+	if err != nil {
+		return fmt.Errorf("%w\nUse 'git-id list' to see available profiles", err)
+	}
+
+	if err := cli.CheckSession(); err != nil {
+		return err
+	}
+
+	// Find gh executable
+	ghPath, err := exec.LookPath("gh")
+	if err != nil {
+		return fmt.Errorf("gh not found in PATH")
+	}
+
+	ghArgs, configDir, err := Prepare(profile, ghArgs)
+	if err != nil {
+		return err
+	}
+
+	// Build environment with GH_CONFIG_DIR override
+	override := "GH_CONFIG_DIR=" + configDir
+	env := append(os.Environ(), override)
+
+	// Build args for exec
+	execArgs := append([]string{"gh"}, ghArgs...)
+	debuglog.Handoff(execArgs, override)
+
+	// Replace this process with gh
+	// Note: If this succeeds, it never returns. If it fails, we clean up.
+	if err := cli.Handoff(ghPath, execArgs, env); err != nil {
+		_ = os.RemoveAll(configDir)
+		return fmt.Errorf("failed to exec gh: %w", err)
+	}
+
+	return nil // unreachable
+}
+
+// Prepare readies gh to run as a profile: it expands the profile's aliases
+// and flags in args, and writes a gh config directory, for GH_CONFIG_DIR,
+// that selects its GitHub user. The profile must have a ghuser gh is
+// logged in as.
+func Prepare(profile *identity.Profile, args []string) (ghArgs []string, configDir string, err error) {
+	// Validate GHUser is set
+	if profile.GHUser == "" {
+		return nil, "", fmt.Errorf("profile '%s' has no GitHub user configured.\nUse: git-id set %s ghuser <username>", profile.Name, profile.Name)
+	}
+
+	// Validate user is authenticated
+	if err := identity.ValidateGHUser(profile.GHUser); err != nil {
+		return nil, "", err
+	}
+
+	ghArgs, err = profile.ExpandGHArgs(args)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Find the real gh config directory
+	realConfigDir := getGHConfigDir()
+
+	// Create temp directory for our modified config
+	// Note: This temp dir is intentionally not cleaned up once gh runs,
+	// because cli.Handoff replaces the process. The temp dir will be cleaned
+	// up by the OS eventually, or we could use a fixed location in the future.
+	tmpDir, err := os.MkdirTemp("", "gh-as-*")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+
+	// Symlink config.yml from real config dir if it exists
+	realConfig := filepath.Join(realConfigDir, "config.yml")
+	if _, err := os.Stat(realConfig); err == nil {
+		tmpConfig := filepath.Join(tmpDir, "config.yml")
+		if err := os.Symlink(realConfig, tmpConfig); err != nil {
+			_ = os.RemoveAll(tmpDir)
+			return nil, "", fmt.Errorf("failed to symlink config: %w", err)
+		}
+	}
+
+	// Write minimal hosts.yml that selects our user
+	hostsContent := fmt.Sprintf(`github.com:
+    git_protocol: ssh
+    users:
+        %s:
+    user: %s
+`, profile.GHUser, profile.GHUser)
+
+	hostsFile := filepath.Join(tmpDir, "hosts.yml")
+	if err := os.WriteFile(hostsFile, []byte(hostsContent), 0o600); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return nil, "", fmt.Errorf("failed to write hosts.yml: %w", err)
+	}
+	return ghArgs, tmpDir, nil
+}
+
+// getGHConfigDir returns the gh CLI config directory.
+func getGHConfigDir() string {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "gh")
+	}
+	if appData := os.Getenv("AppData"); runtime.GOOS == "windows" && appData != "" {
+		return filepath.Join(appData, "GitHub CLI")
+	}
+
+	home, err := paths.Home()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gh")
+}
@@ -0,0 +1,148 @@
+package gitas
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+// aliasName is the git alias that runs git-as: git as <profile> ...
+const aliasName = "alias.as"
+
+var (
+	aliasForce      bool
+	aliasSSHProfile string
+	unaliasSSH      bool
+)
+
+// aliasCommands returns new install-alias and uninstall-alias commands,
+// for git-as and for bread as each
+func aliasCommands() []*cobra.Command {
+	installAliasCmd := &cobra.Command{
+		Use:   "install-alias",
+		Short: "Make 'git as <profile> ...' run git-as from any repository",
+		Long: `Add an 'as' alias to your global git config, so that
+'git as personal push' works like 'git-as personal push'. The alias runs
+from the directory you are in, so relative paths keep working.
+
+With --ssh-command <profile>, also set core.sshCommand in the current
+repository to the profile's SSH key, so that plain git commands there
+use it without git-as. uninstall-alias reverts both.`,
+		Example: `  git-as install-alias
+  git as work push origin main
+  git-as install-alias --ssh-command work`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value := aliasValue(cmd.Root().Name())
+			current, _ := gitConfig("--global", "--get", aliasName)
+			switch {
+			case current == value:
+				fmt.Printf("%s is already set\n", aliasName)
+			case current != "" && !aliasForce:
+				return fmt.Errorf("%s is already set to %q. Use --force to replace it", aliasName, current)
+			default:
+				if _, err := gitConfig("--global", aliasName, value); err != nil {
+					return err
+				}
+				fmt.Printf("Set %s in your global git config. Try: git as <profile> status\n", aliasName)
+			}
+
+			if aliasSSHProfile == "" {
+				return nil
+			}
+			profile, err := identity.Get(aliasSSHProfile)
+			if err != nil {
+				return err
+			}
+			if err := identity.ValidateSSHKey(profile.SSHKey); err != nil {
+				return err
+			}
+			if _, err := gitConfig("--local", "core.sshCommand", profile.SSHCommand()); err != nil {
+				return fmt.Errorf("%w (--ssh-command needs a git repository)", err)
+			}
+			fmt.Printf("Set core.sshCommand of this repository to the SSH key of %s\n", profile.Name)
+			return nil
+		},
+	}
+
+	uninstallAliasCmd := &cobra.Command{
+		Use:   "uninstall-alias",
+		Short: "Remove the 'git as' alias that install-alias added",
+		Long: `Remove the 'as' alias from your global git config, when install-alias
+set it. With --ssh-command, also remove a core.sshCommand that
+install-alias set in the current repository.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			current, _ := gitConfig("--global", "--get", aliasName)
+			switch {
+			case current == "":
+				fmt.Printf("%s is not set\n", aliasName)
+			case !isOurAlias(current) && !aliasForce:
+				return fmt.Errorf("%s is %q, which install-alias didn't set. Use --force to remove it anyway", aliasName, current)
+			default:
+				if _, err := gitConfig("--global", "--unset", aliasName); err != nil {
+					return err
+				}
+				fmt.Printf("Removed %s from your global git config\n", aliasName)
+			}
+
+			if !unaliasSSH {
+				return nil
+			}
+			sshCommand, _ := gitConfig("--local", "--get", "core.sshCommand")
+			if sshCommand == "" {
+				return nil
+			}
+			if !strings.HasSuffix(sshCommand, " -o IdentitiesOnly=yes") && !aliasForce {
+				return fmt.Errorf("core.sshCommand is %q, which install-alias didn't set. Use --force to remove it anyway", sshCommand)
+			}
+			if _, err := gitConfig("--local", "--unset", "core.sshCommand"); err != nil {
+				return err
+			}
+			fmt.Println("Removed core.sshCommand from this repository")
+			return nil
+		},
+	}
+
+	installAliasCmd.Flags().BoolVar(&aliasForce, "force", false, "Replace an 'as' alias set to something else")
+	installAliasCmd.Flags().StringVar(&aliasSSHProfile, "ssh-command", "", "Also set core.sshCommand of the current repository to this profile's key")
+	_ = installAliasCmd.RegisterFlagCompletionFunc("ssh-command", cli.CompleteProfile)
+	uninstallAliasCmd.Flags().BoolVar(&aliasForce, "force", false, "Remove an 'as' alias, or core.sshCommand, install-alias didn't set")
+	uninstallAliasCmd.Flags().BoolVar(&unaliasSSH, "ssh-command", false, "Also remove core.sshCommand from the current repository")
+	return []*cobra.Command{installAliasCmd, uninstallAliasCmd}
+}
+
+// aliasValue returns the alias that runs git-as, or "bread as" when
+// installed from bread. Shell aliases run from the top of the repository,
+// so it goes back to where git was run first.
+func aliasValue(root string) string {
+	command := "git-as"
+	if root == "bread" {
+		command = "bread as"
+	}
+	return `!cd -- "${GIT_PREFIX:-.}" && ` + command
+}
+
+// isOurAlias reports whether an alias value is one aliasValue returns
+func isOurAlias(value string) bool {
+	return value == aliasValue("git-as") || value == aliasValue("bread")
+}
+
+// gitConfig runs git config with args and returns its trimmed output
+func gitConfig(args ...string) (string, error) {
+	out, err := debuglog.Output(exec.Command("git", append([]string{"config"}, args...)...))
+	if err != nil {
+		return "", fmt.Errorf("git config %s failed: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func init() {
+	rootCmd.AddCommand(aliasCommands()...)
+}
@@ -0,0 +1,148 @@
+package gitas
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/testutil"
+)
+
+// globalConfig gives the test a global git config of its own
+func globalConfig(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(home, ".gitconfig"))
+}
+
+// globalAlias returns the alias.as of the global git config
+func globalAlias(t *testing.T) string {
+	t.Helper()
+	out, _ := exec.Command("git", "config", "--global", "--get", aliasName).Output()
+	return strings.TrimSpace(string(out))
+}
+
+// runAlias runs the alias commands under a root named root with args
+func runAlias(root string, args ...string) (string, error) {
+	cmd := &cobra.Command{Use: root}
+	cmd.AddCommand(aliasCommands()...)
+	cmd.SetArgs(args)
+	cmd.SilenceUsage, cmd.SilenceErrors = true, true
+	var err error
+	out := testutil.CaptureStdout(func() { err = cmd.Execute() })
+	return out, err
+}
+
+func TestInstallAlias(t *testing.T) {
+	globalConfig(t)
+
+	out, err := runAlias("git-as", "install-alias")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Set alias.as in your global git config")
+	assert.Equal(t, aliasValue("git-as"), globalAlias(t))
+
+	out, err = runAlias("git-as", "install-alias")
+	require.NoError(t, err)
+	assert.Contains(t, out, "alias.as is already set")
+
+	_, err = runAlias("bread", "install-alias")
+	assert.ErrorContains(t, err, "Use --force to replace it", "bread's alias isn't git-as's")
+	assert.Equal(t, aliasValue("git-as"), globalAlias(t))
+
+	_, err = runAlias("bread", "install-alias", "--force")
+	require.NoError(t, err)
+	assert.Equal(t, `!cd -- "${GIT_PREFIX:-.}" && bread as`, globalAlias(t))
+}
+
+func TestInstallAlias_Foreign(t *testing.T) {
+	globalConfig(t)
+	require.NoError(t, exec.Command("git", "config", "--global", aliasName, "!echo mine").Run())
+
+	_, err := runAlias("git-as", "install-alias")
+	assert.ErrorContains(t, err, `alias.as is already set to "!echo mine"`)
+	assert.Equal(t, "!echo mine", globalAlias(t))
+
+	_, err = runAlias("git-as", "install-alias", "--force")
+	require.NoError(t, err)
+	assert.Equal(t, aliasValue("git-as"), globalAlias(t))
+}
+
+func TestUninstallAlias(t *testing.T) {
+	globalConfig(t)
+
+	out, err := runAlias("git-as", "uninstall-alias")
+	require.NoError(t, err)
+	assert.Contains(t, out, "alias.as is not set")
+
+	_, err = runAlias("bread", "install-alias")
+	require.NoError(t, err)
+	out, err = runAlias("git-as", "uninstall-alias")
+	require.NoError(t, err, "either tool's alias is ours")
+	assert.Contains(t, out, "Removed alias.as")
+	assert.Empty(t, globalAlias(t))
+
+	require.NoError(t, exec.Command("git", "config", "--global", aliasName, "!echo mine").Run())
+	_, err = runAlias("git-as", "uninstall-alias")
+	assert.ErrorContains(t, err, "which install-alias didn't set. Use --force to remove it anyway")
+	assert.Equal(t, "!echo mine", globalAlias(t))
+
+	_, err = runAlias("git-as", "uninstall-alias", "--force")
+	require.NoError(t, err)
+	assert.Empty(t, globalAlias(t))
+}
+
+func TestUninstallAlias_SSHCommand(t *testing.T) {
+	globalConfig(t)
+	repo := testutil.NewTestRepo(t)
+	t.Chdir(repo.Path)
+	sshCommand := func() string {
+		out, _ := repo.GitMayFail("config", "--local", "--get", "core.sshCommand")
+		return strings.TrimSpace(out)
+	}
+
+	repo.Git("config", "core.sshCommand", "ssh -i /keys/id_work -o IdentitiesOnly=yes")
+	_, err := runAlias("git-as", "uninstall-alias", "--ssh-command")
+	require.NoError(t, err)
+	assert.Empty(t, sshCommand(), "install-alias's core.sshCommand is removed")
+
+	repo.Git("config", "core.sshCommand", "ssh -o ProxyJump=bastion")
+	_, err = runAlias("git-as", "uninstall-alias", "--ssh-command")
+	assert.ErrorContains(t, err, "which install-alias didn't set")
+	assert.Equal(t, "ssh -o ProxyJump=bastion", sshCommand())
+
+	_, err = runAlias("git-as", "uninstall-alias", "--ssh-command", "--force")
+	require.NoError(t, err)
+	assert.Empty(t, sshCommand())
+}
+
+func TestIsOurAlias(t *testing.T) {
+	assert.True(t, isOurAlias(aliasValue("git-as")))
+	assert.True(t, isOurAlias(aliasValue("bread")))
+	assert.True(t, isOurAlias(`!cd -- "${GIT_PREFIX:-.}" && git-as`))
+	for _, value := range []string{"", "!git-as", "!echo mine", `!cd -- "${GIT_PREFIX:-.}" && git-as --force`} {
+		assert.False(t, isOurAlias(value), value)
+	}
+}
+
+func TestInstallAlias_SSHCommand(t *testing.T) {
+	setupProfiles(t)
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(os.Getenv("HOME"), ".gitconfig"))
+	repo := testutil.NewTestRepo(t)
+	t.Chdir(repo.Path)
+
+	_, err := runAlias("git-as", "install-alias", "--ssh-command", "work")
+	require.NoError(t, err)
+	got := strings.TrimSpace(repo.Git("config", "--local", "--get", "core.sshCommand"))
+	assert.True(t, strings.HasPrefix(got, "ssh -i "), got)
+	assert.True(t, strings.HasSuffix(got, " -o IdentitiesOnly=yes"), got)
+
+	_, err = runAlias("git-as", "install-alias", "--ssh-command", "nobody")
+	assert.Error(t, err)
+}
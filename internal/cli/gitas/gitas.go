@@ -0,0 +1,458 @@
+// Package gitas implements the git-as command, run on its own or as a
+// subcommand of bread
+package gitas
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/config"
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/i18n"
+	"github.com/jdevera/git-this-bread/internal/identity"
+	"github.com/jdevera/git-this-bread/internal/paths"
+	"github.com/jdevera/git-this-bread/internal/render"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "git-as <profile> [git args...]",
+	Short: "Run git commands with a specific identity profile",
+	Long: `git-as (a git-this-bread tool)
+
+Run git commands with a specific identity profile.
+
+The profile must have 'sshkey' and 'email' configured.
+Use 'git-id' to manage profiles.
+
+With --each <root> after the profile, the git command runs in every
+repository git-explain would look at for root (root itself, or the
+repositories directly under it, minus explain.excludes), one after the
+other, and a summary says where it failed.
+
+A GIT_SSH_COMMAND already in the environment, such as one going through a
+bastion, is kept: the profile's key is added to its ssh options. When it
+runs something other than ssh, git-as stops rather than guess; give
+--replace-ssh, before the profile, to use the profile's ssh command alone.
+
+Commits made with git-as carry the profile's trailers, such as a
+Signed-off-by or a client's billing code (git 2.32 or later):
+
+    git-id set client-a trailer "Billing-Code: ACME-42"
+    git-as client-a commit -m 'Fix bug'   # adds Billing-Code: ACME-42
+
+For a profile with privateemail set, git-as refuses to run unless its email
+is its GitHub noreply address, and refuses commits given an --author with
+any other email.
+
+A repository whose own user.email, from its local config or a file
+included for it, is another profile's is bound to that profile: git-as
+asks before running there as a different one, which is almost always a
+mistake. Give --force, before the profile, to run without asking.
+
+With --auto in place of the profile, the profile is the one the rules of
+'git-id rule' pick for the directory git runs in.`,
+	Example: `  git-as personal status
+  git-as --auto push
+  git-as personal --each ~/src/personal -- push
+  git-as --replace-ssh work fetch
+  git-as --force personal log --author=me
+  git-as work push origin main
+  git-as personal commit -m 'Fix bug'`,
+	Args:               cobra.MinimumNArgs(1),
+	ValidArgsFunction:  cli.CompleteProfile,
+	DisableFlagParsing: true, // Pass all flags to git
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return run(cmd, args, false)
+	},
+}
+
+// handoff is cli.Handoff, replaced in tests
+var handoff = cli.Handoff
+
+// Command returns the git-as command
+func Command() *cobra.Command {
+	return rootCmd
+}
+
+// DispatchCommand returns the git-as command for bread as: after the
+// profile, it takes the tool to run, git or any of tools, and gives the
+// tool the environment that makes it act as the profile. Arguments that
+// don't start with a tool are git's, as with git-as.
+func DispatchCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "as <profile> [tool] [args...]",
+		Short: "Run git, gh, glab, ssh or terraform with a specific identity profile",
+		Long: `bread as (a git-this-bread tool)
+
+Run an identity-sensitive tool with a specific identity profile, giving it
+the environment that makes it act as the profile:
+
+  git         the profile's SSH key, email, name and trailers, as git-as
+  gh          the profile's GitHub user, as gh-as, and its git identity
+  glab        the profile's GitLab host (gluser's gitlab.com, or its
+              gitlab forge), and its git identity
+  ssh         the profile's SSH key, and only it
+  terraform   the profile's git identity, for modules from git sources
+  tofu        the same, for OpenTofu
+
+The tool is inferred from the word after the profile, a name or a path to
+one of them. When it is none of them, the arguments are git's, so
+'bread as work push' runs git push, as git-as would.
+
+The profile must have what the tool needs configured.
+Use 'git-id' to manage profiles.
+
+As git-as, it asks before running git in a repository bound to another
+profile; --force, before the profile, runs without asking. --auto, in
+place of the profile, uses the one the rules of 'git-id rule' pick.`,
+		Example: `  bread as work push
+  bread as work gh pr list
+  bread as client-a glab mr list
+  bread as work ssh deploy@build.example.com
+  bread as work terraform init`,
+		Args:               cobra.MinimumNArgs(1),
+		ValidArgsFunction:  completeDispatch,
+		DisableFlagParsing: true, // Pass all flags to the tool
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(cmd, args, true)
+		},
+	}
+	cmd.AddCommand(aliasCommands()...)
+	return cmd
+}
+
+// completeDispatch completes the profile, then the tool to run, leaving
+// the tool's own arguments alone
+func completeDispatch(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return cli.CompleteProfile(cmd, args, toComplete)
+	case 1:
+		return toolNames(), cobra.ShellCompDirectiveDefault
+	default:
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+}
+
+// run runs git as the profile in args; with dispatch, for bread as, the
+// word after the profile may name another tool to run instead
+func run(cmd *cobra.Command, args []string, dispatch bool) error {
+	// Check for help flags manually since we disabled flag parsing
+	if len(args) > 0 && (args[0] == "-h" || args[0] == "--help" || args[0] == "help") {
+		return cmd.Help()
+	}
+	if len(args) > 0 && (args[0] == "-v" || args[0] == "--version") {
+		cli.PrintVersion(cmd)
+		return nil
+	}
+
+	replaceSSH, force, auto := false, false, false
+	for len(args) > 0 && (args[0] == "--replace-ssh" || args[0] == "--force" || args[0] == "--auto") {
+		switch args[0] {
+		case "--force":
+			force = true
+		case "--auto":
+			auto = true
+		default:
+			replaceSSH = true
+		}
+		args = args[1:]
+	}
+
+	if auto {
+		if len(args) > 0 && args[0] == "--each" {
+			return fmt.Errorf("--auto picks the profile of one directory, so it can't be used with --each")
+		}
+		name, err := ruleProfile(args)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		args = append([]string{name}, args...)
+	}
+	if len(args) < 1 {
+		return fmt.Errorf("missing profile argument")
+	}
+
+	profileName := args[0]
+	gitArgs := args[1:]
+	program, name := "git", "git"
+	if dispatch && len(gitArgs) > 0 {
+		if inferred := inferTool(gitArgs[0]); inferred != "" {
+			program, name, gitArgs = gitArgs[0], inferred, gitArgs[1:]
+		}
+	}
+	eachRoot := ""
+	if name == "git" && len(gitArgs) > 0 && gitArgs[0] == "--each" {
+		if len(gitArgs) < 2 {
+			return fmt.Errorf("--each needs a directory: git-as %s --each <root> -- <git args...>", profileName)
+		}
+		eachRoot, gitArgs = gitArgs[1], gitArgs[2:]
+		if len(gitArgs) > 0 && gitArgs[0] == "--" {
+			gitArgs = gitArgs[1:]
+		}
+		if len(gitArgs) == 0 {
+			return fmt.Errorf("--each needs a git command: git-as %s --each %s -- <git args...>", profileName, eachRoot)
+		}
+	}
+
+	// Load the profile
+	profile, err := identity.Get(profileName)
+	if err != nil {
+		return fmt.Errorf("%w\nUse 'git-id list' to see available profiles", err)
+	}
+
+	if err := cli.CheckSession(); err != nil {
+		return err
+	}
+	if name != "git" {
+		return runTool(profile, program, name, gitArgs, replaceSSH)
+	}
+
+	gitArgs, overrides, err := prepareGit(profile, gitArgs, replaceSSH)
+	if err != nil {
+		return err
+	}
+	env := append(os.Environ(), overrides...)
+	loadTheme()
+	if eachRoot == "" && !force {
+		if err := checkBinding(profile, gitDir(gitArgs)); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+	}
+
+	// Find git executable
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		return fmt.Errorf("git not found in PATH")
+	}
+
+	if eachRoot != "" {
+		cmd.SilenceUsage = true // Failures are the repositories', reported by runEach
+		return runEach(profile, eachRoot, gitPath, gitArgs, env, force)
+	}
+
+	// Build args for exec (argv[0] should be the command name)
+	execArgs := append([]string{"git"}, gitArgs...)
+	debuglog.Handoff(execArgs, overrides...)
+
+	// Replace this process with git
+	if err := handoff(gitPath, execArgs, env); err != nil {
+		return fmt.Errorf("failed to exec git: %w", err)
+	}
+
+	return nil // unreachable
+}
+
+// runTool hands off to program, the tool name, with the arguments and
+// environment that make it act as profile
+func runTool(profile *identity.Profile, program, name string, args []string, replaceSSH bool) error {
+	path, err := exec.LookPath(program)
+	if err != nil {
+		return fmt.Errorf("%s not found in PATH", program)
+	}
+	args, overrides, err := tools[name](profile, args, replaceSSH)
+	if err != nil {
+		return err
+	}
+	env := append(os.Environ(), overrides...)
+
+	execArgs := append([]string{name}, args...)
+	debuglog.Handoff(execArgs, overrides...)
+	if err := handoff(path, execArgs, env); err != nil {
+		return fmt.Errorf("failed to exec %s: %w", name, err)
+	}
+	return nil // unreachable
+}
+
+// ruleProfile returns the profile the rules of git-id pick for the
+// directory git runs in given args
+func ruleProfile(args []string) (string, error) {
+	dir, err := filepath.Abs(gitDir(args))
+	if err != nil {
+		return "", err
+	}
+	rule, err := identity.RuleFor(dir)
+	if err != nil {
+		return "", err
+	}
+	if rule == nil {
+		return "", fmt.Errorf("no rule picks a profile for %s\nUse 'git-id rule add <pattern> <profile>' to add one", dir)
+	}
+	return rule.Profile, nil
+}
+
+// loadTheme colors the warnings git-as prints with the theme of
+// $GIT_THIS_BREAD_THEME or ui.theme. A config file that doesn't load, or
+// names no theme there is, is left for the commands that need it to report.
+func loadTheme() {
+	if cfg, err := config.Load(); err == nil {
+		_ = render.LoadTheme("", cfg.UI.Theme)
+	}
+}
+
+// checkBinding asks before running git as profile in dir when the
+// repository there is bound to another profile
+func checkBinding(profile *identity.Profile, dir string) error {
+	bound, email := boundElsewhere(profile, dir)
+	if bound == "" {
+		return nil
+	}
+	warning := render.Style(render.RoleWarning).Bold(true)
+	fmt.Fprintln(os.Stderr, warning.Render(i18n.Sprintf("⚠ This repository is bound to profile %s (user.email %s), not %s", bound, email, profile.Name)))
+	if ok, err := askAnyway(profile); !ok {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("not run: this repository is bound to %s", bound)
+	}
+	return nil
+}
+
+// checkBindings asks once before running git as profile in repos when any
+// of them is bound to another profile, listing those
+func checkBindings(profile *identity.Profile, repos []string) error {
+	warning := render.Style(render.RoleWarning).Bold(true)
+	n := 0
+	for _, repo := range repos {
+		if bound, email := boundElsewhere(profile, repo); bound != "" {
+			if n == 0 {
+				fmt.Fprintln(os.Stderr, warning.Render(i18n.Sprintf("⚠ Repositories bound to another profile than %s:", profile.Name)))
+			}
+			fmt.Fprintf(os.Stderr, "  %s: %s (user.email %s)\n", repo, bound, email)
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	if ok, err := askAnyway(profile); !ok {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("not run: %d of %d repositories are bound to another profile", n, len(repos))
+	}
+	return nil
+}
+
+// boundElsewhere returns the profile the repository in dir is bound to,
+// and its email, when that isn't profile; both are "" otherwise
+func boundElsewhere(profile *identity.Profile, dir string) (bound, email string) {
+	bound, email = identity.Binding(dir)
+	if bound == "" || bound == profile.Name || strings.EqualFold(email, profile.Email) {
+		return "", ""
+	}
+	return bound, email
+}
+
+// askAnyway asks whether to run as profile all the same, failing when
+// nobody can answer
+func askAnyway(profile *identity.Profile) (bool, error) {
+	if err := cli.RequireInput("git-as",
+		i18n.Sprintf("Use: git-as --force %s ... to run as %s anyway", profile.Name, profile.Name)); err != nil {
+		return false, err
+	}
+	return confirm(i18n.Sprintf("Run git as %s anyway? [y/N] ", profile.Name)), nil
+}
+
+// confirm asks a yes/no question on stderr, leaving stdout to git, no
+// being the default
+func confirm(question string) bool {
+	fmt.Fprint(os.Stderr, question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// gitDir returns the directory git runs in given args: the last -C before
+// the subcommand, or the current one
+func gitDir(args []string) string {
+	dir := "."
+	for i := 0; i+1 < len(args) && strings.HasPrefix(args[i], "-"); i++ {
+		switch args[i] {
+		case "-C":
+			i++
+			if filepath.IsAbs(args[i]) {
+				dir = args[i]
+			} else {
+				dir = filepath.Join(dir, args[i])
+			}
+		case "-c", "--git-dir", "--work-tree", "--namespace", "--config-env":
+			i++ // Their value is the next argument
+		}
+	}
+	return dir
+}
+
+// keepSSHCommand merges the GIT_SSH_COMMAND of overrides into existing, the
+// one already in the environment, unless replace is set
+func keepSSHCommand(overrides []string, existing string, replace bool) error {
+	if existing == "" || replace {
+		return nil
+	}
+	for i, kv := range overrides {
+		ours, ok := strings.CutPrefix(kv, "GIT_SSH_COMMAND=")
+		if !ok {
+			continue
+		}
+		merged, ok := identity.MergeSSHCommand(existing, ours)
+		if !ok {
+			return fmt.Errorf("GIT_SSH_COMMAND is already set to %q, which doesn't run ssh, so the profile's key can't be added to it.\n"+
+				"Use: git-as --replace-ssh <profile> ... to replace it", existing)
+		}
+		overrides[i] = "GIT_SSH_COMMAND=" + merged
+	}
+	return nil
+}
+
+// runEach runs git with args and env in every repository under root, and
+// sums up how each went. Unless force is set, it asks first when any of
+// them is bound to another profile than profile.
+func runEach(profile *identity.Profile, root, gitPath string, args, env []string, force bool) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	repos := analyzer.FindRepos(paths.Expand(root), cfg.Explain.Excludes)
+	if len(repos) == 0 {
+		return fmt.Errorf("no git repositories in %s", root)
+	}
+	if !force {
+		if err := checkBindings(profile, repos); err != nil {
+			return err
+		}
+	}
+
+	failed := map[string]error{}
+	for _, repo := range repos {
+		fmt.Fprintf(os.Stderr, "==> %s\n", repo)
+		c := exec.Command(gitPath, args...) //nolint:gosec // the user's git command
+		c.Dir, c.Env = repo, env
+		c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := debuglog.Run(c); err != nil {
+			failed[repo] = err
+		}
+	}
+
+	fmt.Fprintln(os.Stderr)
+	for _, repo := range repos {
+		if err, ok := failed[repo]; ok {
+			fmt.Fprintf(os.Stderr, "✗ %s: %v\n", repo, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "✓ %s\n", repo)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("git %s failed in %d of %d repositories", strings.Join(args, " "), len(failed), len(repos))
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package gitas
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/identity"
+	"github.com/jdevera/git-this-bread/testutil"
+)
+
+// setupProfiles gives the test a HOME with the profiles personal, whose
+// email is the global one, and work
+func setupProfiles(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GIT_SSH_COMMAND", "")
+	key := filepath.Join(home, "id_test")
+	require.NoError(t, os.WriteFile(key, []byte("ssh key content"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".gitconfig"), []byte(`[user]
+	email = me@example.com
+[identity "personal"]
+	email = me@example.com
+	user = Me
+	sshkey = `+key+`
+[identity "work"]
+	email = me@work.example
+	user = Me
+	sshkey = `+key+`
+`), 0o600))
+}
+
+// fakeHandoff records the programs run instead of running them
+func fakeHandoff(t *testing.T) *[][]string {
+	t.Helper()
+	var calls [][]string
+	orig := handoff
+	handoff = func(_ string, argv, _ []string) error {
+		calls = append(calls, argv)
+		return nil
+	}
+	t.Cleanup(func() { handoff = orig })
+	return &calls
+}
+
+// boundRepo returns a repository bound to the work profile
+func boundRepo(t *testing.T) *testutil.TestRepo {
+	t.Helper()
+	repo := testutil.NewTestRepo(t)
+	repo.Git("config", "user.email", "me@work.example")
+	return repo
+}
+
+func TestRun_BoundRepo(t *testing.T) {
+	setupProfiles(t)
+	t.Setenv(cli.NoInputEnv, "1")
+	repo := boundRepo(t)
+
+	t.Run("asks before running as another profile", func(t *testing.T) {
+		calls := fakeHandoff(t)
+		err := run(Command(), []string{"personal", "-C", repo.Path, "status"}, false)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, cli.ErrNoInput)
+		assert.Contains(t, err.Error(), "git-as --force personal")
+		assert.Empty(t, *calls)
+	})
+
+	t.Run("--force runs without asking", func(t *testing.T) {
+		calls := fakeHandoff(t)
+		require.NoError(t, run(Command(), []string{"--force", "personal", "-C", repo.Path, "status"}, false))
+		assert.Equal(t, [][]string{{"git", "-C", repo.Path, "status"}}, *calls)
+	})
+
+	t.Run("the bound profile runs without asking", func(t *testing.T) {
+		calls := fakeHandoff(t)
+		require.NoError(t, run(Command(), []string{"work", "-C", repo.Path, "status"}, false))
+		assert.Len(t, *calls, 1)
+	})
+
+	t.Run("an unbound repository runs without asking", func(t *testing.T) {
+		calls := fakeHandoff(t)
+		other := testutil.NewTestRepo(t)
+		require.NoError(t, run(Command(), []string{"personal", "-C", other.Path, "status"}, false))
+		assert.Len(t, *calls, 1)
+	})
+}
+
+func TestRun_EachBoundRepo(t *testing.T) {
+	setupProfiles(t)
+	t.Setenv(cli.NoInputEnv, "1")
+	root := t.TempDir()
+	git := func(dir string, args ...string) error {
+		c := exec.Command("git", args...)
+		c.Dir = filepath.Join(root, dir)
+		return c.Run()
+	}
+	for _, name := range []string{"bound", "free"} {
+		require.NoError(t, os.Mkdir(filepath.Join(root, name), 0o750))
+		require.NoError(t, git(name, "init", "-q"))
+	}
+	require.NoError(t, git("bound", "config", "user.email", "me@work.example"))
+
+	err := run(Command(), []string{"personal", "--each", root, "--", "commit", "--allow-empty", "-m", "x"}, false)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, cli.ErrNoInput, "--each asks once before running anywhere")
+	for _, name := range []string{"bound", "free"} {
+		assert.Error(t, git(name, "rev-parse", "HEAD"), "%s has no commit", name)
+	}
+
+	require.NoError(t, run(Command(), []string{"--force", "personal", "--each", root, "--", "commit", "--allow-empty", "-m", "x"}, false))
+	for _, name := range []string{"bound", "free"} {
+		assert.NoError(t, git(name, "rev-parse", "HEAD"), "%s has a commit", name)
+	}
+}
+
+func TestCheckBindings(t *testing.T) {
+	setupProfiles(t)
+	t.Setenv(cli.NoInputEnv, "1")
+	free := testutil.NewTestRepo(t).Path
+	bound := boundRepo(t).Path
+	personal, err := identity.Get("personal")
+	require.NoError(t, err)
+	work, err := identity.Get("work")
+	require.NoError(t, err)
+
+	assert.NoError(t, checkBindings(personal, []string{free}), "nothing bound, nothing to ask")
+	assert.NoError(t, checkBindings(work, []string{free, bound}), "bound to the profile itself")
+
+	err = checkBindings(personal, []string{free, bound})
+	assert.ErrorIs(t, err, cli.ErrNoInput)
+	assert.Contains(t, err.Error(), "git-as --force personal")
+
+	assert.NoError(t, checkBinding(personal, free))
+	assert.ErrorIs(t, checkBinding(personal, bound), cli.ErrNoInput)
+}
+
+func TestGitDir(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no args", nil, "."},
+		{"no -C", []string{"status"}, "."},
+		{"-C", []string{"-C", "/src/repo", "status"}, "/src/repo"},
+		{"relative -C", []string{"-C", "repo", "status"}, "repo"},
+		{"-C sequence", []string{"-C", "/src", "-C", "repo", "status"}, "/src/repo"},
+		{"absolute -C resets", []string{"-C", "/src", "-C", "/other", "status"}, "/other"},
+		{"-c before -C", []string{"-c", "user.name=Me", "-C", "/src/repo", "commit"}, "/src/repo"},
+		{"-c value isn't a directory", []string{"-c", "-C", "status"}, "."},
+		{"--git-dir value skipped", []string{"--git-dir", "/x/.git", "-C", "/src", "log"}, "/src"},
+		{"-C after the subcommand is the subcommand's", []string{"status", "-C", "/src"}, "."},
+		{"-C without a value", []string{"-C"}, "."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, gitDir(tt.args))
+		})
+	}
+}
+
+func TestDispatchCommand(t *testing.T) {
+	dispatch := DispatchCommand()
+	assert.Equal(t, "as", dispatch.Name())
+	assert.Equal(t, "git-as", Command().Name(), "git-as keeps its own command")
+	assert.NotSame(t, Command(), dispatch)
+
+	names := func(cmd *cobra.Command) []string {
+		var names []string
+		for _, sub := range cmd.Commands() {
+			names = append(names, sub.Name())
+		}
+		return names
+	}
+	assert.Equal(t, []string{"install-alias", "uninstall-alias"}, names(dispatch))
+	assert.Equal(t, []string{"install-alias", "uninstall-alias"}, names(Command()))
+}
+
+func TestCompleteDispatch(t *testing.T) {
+	cmd := DispatchCommand()
+
+	got, _ := completeDispatch(cmd, []string{"work"}, "")
+	assert.Equal(t, toolNames(), got, "the tool comes after the profile")
+
+	for _, args := range [][]string{{"work", "gh"}, {"work", "gh", "pr"}, {"work", "push"}} {
+		got, directive := completeDispatch(cmd, args, "")
+		assert.Empty(t, got, args)
+		assert.Equal(t, cobra.ShellCompDirectiveDefault, directive, args)
+	}
+}
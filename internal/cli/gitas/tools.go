@@ -0,0 +1,133 @@
+package gitas
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/cli/ghas"
+	"github.com/jdevera/git-this-bread/internal/forge"
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+// tool readies a program to run as a profile: the arguments to give it, and
+// the environment overrides that make it act as the profile
+type tool func(p *identity.Profile, args []string, replaceSSH bool) (toolArgs, env []string, err error)
+
+// tools are the identity-sensitive programs bread as runs, by name
+var tools = map[string]tool{
+	"git":       prepareGit,
+	"gh":        prepareGH,
+	"glab":      prepareGLab,
+	"ssh":       prepareSSH,
+	"terraform": prepareTerraform,
+	"tofu":      prepareTerraform,
+}
+
+// toolNames returns the names of the tools, sorted
+func toolNames() []string {
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// inferTool returns the tool that command, a name or a path, runs, or ""
+// when it is none of them
+func inferTool(command string) string {
+	name := strings.ToLower(filepath.Base(strings.ReplaceAll(command, `\`, "/")))
+	name = strings.TrimSuffix(name, ".exe")
+	if _, ok := tools[name]; ok {
+		return name
+	}
+	return ""
+}
+
+// prepareGit checks the --author of a commit, adds the profile's trailers
+// to it, and sets the profile's SSH key, email and name, keeping a
+// GIT_SSH_COMMAND already set unless replaceSSH is
+func prepareGit(p *identity.Profile, args []string, replaceSSH bool) ([]string, []string, error) {
+	if err := p.CheckCommitAuthor(args); err != nil {
+		return nil, nil, err
+	}
+	args = p.AddTrailers(args)
+
+	// Build environment with identity overrides
+	env, err := p.GitEnv()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := keepSSHCommand(env, os.Getenv("GIT_SSH_COMMAND"), replaceSSH); err != nil {
+		return nil, nil, err
+	}
+	return args, env, nil
+}
+
+// prepareGH runs gh as the profile's GitHub user, as gh-as does, with its
+// git environment too when the profile has one, for gh repo clone and the
+// like
+func prepareGH(p *identity.Profile, args []string, replaceSSH bool) ([]string, []string, error) {
+	args, configDir, err := ghas.Prepare(p, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	env := []string{"GH_CONFIG_DIR=" + configDir}
+	if p.SSHKey != "" && p.Email != "" {
+		_, gitEnv, err := prepareGit(p, nil, replaceSSH)
+		if err != nil {
+			_ = os.RemoveAll(configDir)
+			return nil, nil, err
+		}
+		env = append(env, gitEnv...)
+	}
+	return args, env, nil
+}
+
+// prepareGLab points glab at the profile's GitLab host, whose token glab
+// keeps one of, and gives the git it runs the profile's identity
+func prepareGLab(p *identity.Profile, args []string, replaceSSH bool) ([]string, []string, error) {
+	host := ""
+	if spec, err := forge.ForProfile(p); err == nil && spec.Kind == forge.GitLab {
+		host = spec.Host
+	} else if p.GLUser != "" {
+		host = "gitlab.com"
+	}
+	if host == "" {
+		return nil, nil, fmt.Errorf("profile '%s' has no GitLab account.\nUse: git-id set %s gluser <username>, or git-id set %s forge gitlab:<host>", p.Name, p.Name, p.Name)
+	}
+	_, env, err := prepareGit(p, nil, replaceSSH)
+	if err != nil {
+		return nil, nil, err
+	}
+	return args, append(env, "GITLAB_HOST="+host), nil
+}
+
+// prepareSSH makes ssh log in with the profile's SSH key, and only with it
+func prepareSSH(p *identity.Profile, args []string, _ bool) ([]string, []string, error) {
+	if p.SSHKey == "" {
+		return nil, nil, fmt.Errorf("profile '%s' has no SSH key configured.\nUse: git-id set %s sshkey <path>", p.Name, p.Name)
+	}
+	if err := identity.ValidateSSHKey(p.SSHKey); err != nil {
+		return nil, nil, err
+	}
+	if err := p.CheckNotRevoked(); err != nil {
+		return nil, nil, err
+	}
+	sshArgs := append([]string{"-i", identity.ExpandPath(p.SSHKey), "-o", "IdentitiesOnly=yes"}, args...)
+	return sshArgs, nil, nil
+}
+
+// prepareTerraform gives terraform, or OpenTofu, the profile's git
+// environment, which the git it runs to fetch modules from git sources
+// uses
+func prepareTerraform(p *identity.Profile, args []string, replaceSSH bool) ([]string, []string, error) {
+	_, env, err := prepareGit(p, nil, replaceSSH)
+	if err != nil {
+		return nil, nil, err
+	}
+	return args, env, nil
+}
@@ -0,0 +1,183 @@
+package gitas
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+func TestInferTool(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"git", "git"},
+		{"gh", "gh"},
+		{"glab", "glab"},
+		{"ssh", "ssh"},
+		{"terraform", "terraform"},
+		{"tofu", "tofu"},
+		{"/usr/local/bin/terraform", "terraform"},
+		{`C:\Program Files\GitHub CLI\gh.exe`, "gh"},
+		{"SSH.EXE", "ssh"},
+		{"push", ""},
+		{"--each", ""},
+		{"gh-as", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			assert.Equal(t, tt.want, inferTool(tt.command))
+		})
+	}
+}
+
+func TestToolNames(t *testing.T) {
+	assert.Equal(t, []string{"gh", "git", "glab", "ssh", "terraform", "tofu"}, toolNames())
+	for _, name := range toolNames() {
+		assert.NotNil(t, tools[name], name)
+	}
+}
+
+// testProfile returns a profile with an SSH key that exists
+func testProfile(t *testing.T) *identity.Profile {
+	t.Helper()
+	key := filepath.Join(t.TempDir(), "id_work")
+	require.NoError(t, os.WriteFile(key, []byte("ssh key content"), 0o600))
+	return &identity.Profile{Name: "work", SSHKey: key, Email: "me@work.example", User: "Me"}
+}
+
+func TestPrepare(t *testing.T) {
+	t.Setenv("GIT_SSH_COMMAND", "")
+	p := testProfile(t)
+	p.Trailers = []string{"Billing-Code: ACME-42"}
+	sshCommand := "GIT_SSH_COMMAND=" + p.SSHCommand()
+
+	tests := []struct {
+		name     string
+		tool     string
+		args     []string
+		wantArgs []string
+		wantEnv  []string
+	}{
+		{
+			name:     "git adds trailers to commits",
+			tool:     "git",
+			args:     []string{"commit", "-m", "Fix"},
+			wantArgs: []string{"commit", "--trailer", "Billing-Code: ACME-42", "-m", "Fix"},
+			wantEnv:  []string{sshCommand, "GIT_AUTHOR_EMAIL=me@work.example"},
+		},
+		{
+			name:     "git leaves other commands alone",
+			tool:     "git",
+			args:     []string{"push"},
+			wantArgs: []string{"push"},
+			wantEnv:  []string{sshCommand, "GIT_COMMITTER_NAME=Me"},
+		},
+		{
+			name:     "ssh uses the key alone",
+			tool:     "ssh",
+			args:     []string{"deploy@build.example.com"},
+			wantArgs: []string{"-i", p.SSHKey, "-o", "IdentitiesOnly=yes", "deploy@build.example.com"},
+		},
+		{
+			name:     "terraform gets the git identity",
+			tool:     "terraform",
+			args:     []string{"init"},
+			wantArgs: []string{"init"},
+			wantEnv:  []string{sshCommand, "GIT_AUTHOR_EMAIL=me@work.example"},
+		},
+		{
+			name:     "tofu gets the git identity",
+			tool:     "tofu",
+			args:     []string{"init"},
+			wantArgs: []string{"init"},
+			wantEnv:  []string{sshCommand},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, env, err := tools[tt.tool](p, tt.args, false)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantArgs, args)
+			for _, kv := range tt.wantEnv {
+				assert.Contains(t, env, kv)
+			}
+			if tt.wantEnv == nil {
+				assert.Empty(t, env)
+			}
+		})
+	}
+}
+
+func TestPrepareGLab(t *testing.T) {
+	t.Setenv("GIT_SSH_COMMAND", "")
+	tests := []struct {
+		name     string
+		gluser   string
+		forge    string
+		wantHost string
+	}{
+		{"gluser", "me", "", "gitlab.com"},
+		{"gitlab forge", "", "gitlab:gitlab.work.example", "gitlab.work.example"},
+		{"forge over gluser", "me", "gitlab:gitlab.work.example", "gitlab.work.example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testProfile(t)
+			p.GLUser, p.Forge = tt.gluser, tt.forge
+			args, env, err := prepareGLab(p, []string{"mr", "list"}, false)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"mr", "list"}, args)
+			assert.Contains(t, env, "GITLAB_HOST="+tt.wantHost)
+			assert.Contains(t, env, "GIT_AUTHOR_EMAIL=me@work.example")
+		})
+	}
+}
+
+func TestPrepare_Errors(t *testing.T) {
+	t.Setenv("GIT_SSH_COMMAND", "")
+	tests := []struct {
+		name    string
+		tool    string
+		profile identity.Profile
+		wantErr string
+	}{
+		{"git without a key", "git", identity.Profile{Name: "work", Email: "me@work.example"}, "git-id set work sshkey"},
+		{"git without an email", "git", identity.Profile{Name: "work", SSHKey: "/nonexistent/key"}, "git-id set work email"},
+		{"gh without a GitHub user", "gh", identity.Profile{Name: "work"}, "git-id set work ghuser"},
+		{"glab without a GitLab account", "glab", identity.Profile{Name: "work", Forge: "github"}, "git-id set work gluser"},
+		{"ssh without a key", "ssh", identity.Profile{Name: "work"}, "git-id set work sshkey"},
+		{"ssh with a missing key", "ssh", identity.Profile{Name: "work", SSHKey: "/nonexistent/key"}, "SSH key not found"},
+		{"terraform without a key", "terraform", identity.Profile{Name: "work"}, "git-id set work sshkey"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := tools[tt.tool](&tt.profile, nil, false)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestPrepareGit_KeepsSSHCommand(t *testing.T) {
+	p := testProfile(t)
+
+	t.Setenv("GIT_SSH_COMMAND", "ssh -o ProxyJump=bastion")
+	_, env, err := prepareGit(p, nil, false)
+	require.NoError(t, err)
+	merged, _ := identity.MergeSSHCommand("ssh -o ProxyJump=bastion", p.SSHCommand())
+	assert.Contains(t, env, "GIT_SSH_COMMAND="+merged)
+
+	_, env, err = prepareGit(p, nil, true)
+	require.NoError(t, err)
+	assert.Contains(t, env, "GIT_SSH_COMMAND="+p.SSHCommand(), "--replace-ssh uses the profile's alone")
+
+	t.Setenv("GIT_SSH_COMMAND", "plink -batch")
+	_, _, err = prepareGit(p, nil, false)
+	assert.ErrorContains(t, err, "--replace-ssh")
+}
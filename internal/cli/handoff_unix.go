@@ -0,0 +1,11 @@
+//go:build !windows
+
+package cli
+
+import "syscall"
+
+// Handoff replaces this process with the program at path, run with argv
+// and env. It only returns when the program can't be started.
+func Handoff(path string, argv, env []string) error {
+	return syscall.Exec(path, argv, env)
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package cli
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// Handoff runs the program at path with argv and env, attached to this
+// process's stdin, stdout and stderr, then exits with its exit code.
+// Windows can't replace a process the way exec does elsewhere. It only
+// returns when the program can't be started.
+func Handoff(path string, argv, env []string) error {
+	cmd := &exec.Cmd{Path: path, Args: argv, Env: env, Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	err := cmd.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		return err
+	}
+	os.Exit(0)
+	return nil
+}
@@ -0,0 +1,76 @@
+package id
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+var (
+	applyUnset bool
+	applyForce bool
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <profile>",
+	Short: "Write a profile into the current repository's config",
+	Long: `Write a profile's email, commit name and SSH key into the local config of
+the current repository (user.email, user.name and core.sshCommand), so
+plain git commands there act as the profile without git-as. When the
+profile has no commit name, a user.name set by hand is kept.
+
+The profile must have what git-as needs: an SSH key and an email. The
+repository is then bound to it, and git-as asks before running there as
+another profile.
+
+A user.email or core.sshCommand set there by hand, rather than by a
+profile, is kept: apply refuses to replace it unless given --force.
+
+--unset removes the profile applied before. Given a profile, it refuses
+to remove another one.`,
+	Example: `  git-id apply work
+  git-id apply --force work
+  git-id apply --unset
+  git-id apply --unset work`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if applyUnset {
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	ValidArgsFunction: cli.CompleteProfile,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if applyUnset {
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+			removed, err := identity.Unapply(".", name)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Profile '%s' removed from this repository.\n", removed)
+			return nil
+		}
+
+		p, err := identity.Get(args[0])
+		if err != nil {
+			return err
+		}
+		if err := identity.Apply(".", p, applyForce); err != nil {
+			return err
+		}
+		fmt.Printf("Profile '%s' applied to this repository.\n", p.Name)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().BoolVar(&applyUnset, "unset", false, "Remove the profile applied before")
+	applyCmd.Flags().BoolVar(&applyForce, "force", false, "Replace a user.email or core.sshCommand set by hand")
+}
@@ -0,0 +1,139 @@
+package id
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/forge"
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+var currentCmd = &cobra.Command{
+	Use:   "current",
+	Short: "Show which profile is effective in this repository",
+	Long: `Show which profile each identity setting of the current repository
+belongs to: user.email, the SSH key of GIT_SSH_COMMAND or core.sshCommand,
+each remote, by its owner being one of the profile's forge accounts, and
+the rule that picks a profile for it (see 'git-id rule').
+
+When the settings belong to different profiles, such as work's email with
+personal's SSH key, it says so and exits with status 1, so scripts and
+prompts can tell. Settings no profile has are shown, but don't count.`,
+	Example: `  git-id current
+  git-id current || echo "mixed identities here"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, err := identity.List()
+		if err != nil {
+			return err
+		}
+		var profiles []*identity.Profile
+		for _, name := range names {
+			if p, err := identity.Get(name); err == nil {
+				profiles = append(profiles, p)
+			}
+		}
+
+		e := identity.EffectiveIn(".", profiles)
+		rows := currentRows(e, profiles)
+		rule, err := identity.RuleFor(".")
+		if err != nil {
+			return err
+		}
+		if rule != nil {
+			rows = append(rows, currentRow{"rule", rule.String(), rule.Profile})
+		}
+		var matched []string
+		for _, r := range rows {
+			if r.profile != "" && !slices.Contains(matched, r.profile) {
+				matched = append(matched, r.profile)
+			}
+		}
+
+		switch len(matched) {
+		case 0:
+			fmt.Println("Profile: (none matches)")
+		case 1:
+			fmt.Printf("Profile: %s\n", matched[0])
+		default:
+			fmt.Printf("Profile: ⚠ mixed (%s)\n", strings.Join(matched, ", "))
+		}
+		fmt.Println()
+		for _, r := range rows {
+			profile := r.profile
+			if profile == "" {
+				profile = "-"
+			}
+			fmt.Printf("  %-18s %-40s %s\n", r.setting, r.value, profile)
+		}
+
+		if len(matched) > 1 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("this repository's identity settings belong to different profiles: %s", strings.Join(matched, ", "))
+		}
+		return nil
+	},
+}
+
+// currentRow is an identity setting, its value and the profile it
+// belongs to, "" when none
+type currentRow struct {
+	setting, value, profile string
+}
+
+// currentRows lists the identity settings of e and their profiles, a
+// remote's being the one with an account that owns it
+func currentRows(e *identity.Effective, profiles []*identity.Profile) []currentRow {
+	email := e.Email
+	if email == "" {
+		email = "(not set)"
+	}
+	rows := []currentRow{{"user.email", email, e.EmailProfile}}
+
+	ssh := currentRow{setting: "core.sshCommand", value: e.SSHKey, profile: e.SSHProfile}
+	if e.SSHFromEnv {
+		ssh.setting = "GIT_SSH_COMMAND"
+	}
+	switch {
+	case e.SSHCommand == "":
+		ssh.value = "(not set)"
+	case e.SSHKey == "":
+		ssh.value = e.SSHCommand
+	}
+	rows = append(rows, ssh)
+
+	for _, r := range e.Remotes {
+		host, owner, repo := analyzer.ParseRemoteURL(r.URL)
+		row := currentRow{setting: "remote " + r.Name, value: r.URL}
+		if host != "" {
+			row.value = host + "/" + owner + "/" + repo
+		}
+		row.profile = remoteProfile(host, owner, profiles)
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// remoteProfile returns the first profile with an account that is owner
+// on host, or "" when none has
+func remoteProfile(host, owner string, profiles []*identity.Profile) string {
+	if host == "" || owner == "" {
+		return ""
+	}
+	for _, p := range profiles {
+		for _, a := range forge.Accounts(p) {
+			if strings.EqualFold(a.Host, host) && strings.EqualFold(a.User, owner) {
+				return p.Name
+			}
+		}
+	}
+	return ""
+}
+
+func init() {
+	rootCmd.AddCommand(currentCmd)
+}
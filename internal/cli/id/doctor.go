@@ -0,0 +1,58 @@
+package id
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/doctor"
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+var doctorContext string
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check every profile, or those of a context",
+	Long: `Check the profiles as 'bread doctor' does: that each has an SSH key and
+an email, a forge git-this-bread knows, and, with an account there, that
+it is logged in and holds the SSH key.
+
+--context checks only the profiles of one context, such as a client's,
+before a day of work for them. It fails when the context has none.`,
+	Example: `  git-id doctor
+  git-id doctor --context client-a`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		check := doctor.CheckProfiles
+		if doctorContext != "" {
+			check = doctor.CheckContext(doctorContext)
+		}
+		results := doctor.Run(cmd.Context(), []doctor.Check{check})
+		doctor.Write(os.Stdout, results)
+
+		if failed := doctor.Failed(results); failed > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d check(s) failed", failed)
+		}
+		return nil
+	},
+}
+
+// completeContext completes the context of a profile, among the ones
+// profiles are in
+func completeContext(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	contexts, err := identity.Contexts()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return contexts, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().StringVar(&doctorContext, "context", "", "Check only the profiles of this context")
+	_ = doctorCmd.RegisterFlagCompletionFunc("context", completeContext)
+}
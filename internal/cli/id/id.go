@@ -0,0 +1,798 @@
+// Package id implements the git-id command, run on its own or as a
+// subcommand of bread
+package id
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/config"
+	"github.com/jdevera/git-this-bread/internal/forge"
+	"github.com/jdevera/git-this-bread/internal/ghapi"
+	"github.com/jdevera/git-this-bread/internal/i18n"
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+var (
+	fileFlag     string
+	yesFlag      bool
+	detachedFlag bool
+	addFields    identity.Profile
+
+	showPublicKey bool
+	showUpload    bool
+	showTitle     string
+
+	listByContext bool
+	listContext   string
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "git-id",
+	Short: "Manage git identity profiles",
+	Long: `git-id (a git-this-bread tool)
+
+Manage git/GitHub identity profiles stored in git config.
+
+Profiles are stored as [identity.<name>] sections in your git config.
+Each profile can have:
+  - name:   Display name for git commits (optional, overrides user)
+  - sshkey: Path to SSH private key (required for git-as)
+  - email:  Git author/committer email (required for git-as)
+  - user:   Git author/committer name (optional)
+  - ghuser: GitHub username for gh-as, or the forge account (optional)
+  - forge:  Where ghuser lives: github (default), gitlab[:host] or
+            gitea:host (optional)
+  - gluser: Username on gitlab.com (optional)
+  - btuser: Username on bitbucket.org (optional)
+  - host.<host>.user: Username on any other forge host (optional)
+  - trailer: "Token: value" trailer git-as adds to commits (optional,
+             one per trailer)
+  - privateemail: true makes email have to be ghuser's GitHub noreply
+             address, and git-as refuse commits with any other (optional)
+  - context: Group the profile belongs to, such as work, personal or a
+             client's (optional)
+
+git-explain and git-wip count remotes owned by any of these usernames,
+on their host, as yours.
+
+The identity.default setting of config.toml (see 'bread config') names
+the profile that 'show' and gh-wtfork use when given none; 'list' marks
+it with a *.
+
+Examples:
+  git-id                    # List all profiles
+  git-id add personal       # Create a new profile interactively
+  git-id show personal      # Show profile details
+  git-id current            # Which profile this repo's settings belong to
+  git-id list --by-context  # List profiles by context
+  git-id doctor --context client-a   # Check every profile of a context
+  git-id set personal email me@example.com
+  git-id apply work         # Make plain git act as work in this repo
+  git-id use work --dir ~/work   # ...and in every repo under ~/work
+  git-id rule add '~/work/**' work   # Pick work for repos under ~/work
+  git-id remove personal    # Delete a profile
+  git-id revoke work        # Replace a compromised SSH key`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return listCmd.RunE(cmd, args)
+	},
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all identity profiles",
+	Long: `List the identity profiles, with their email and forge login.
+
+Profiles can be grouped into contexts, such as work, personal or one per
+client, with 'git-id set <profile> context <name>'. --by-context lists
+them under each context, and --context lists only one.`,
+	Example: `  git-id list --by-context
+  git-id list --context client-a`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, err := identity.List()
+		if err != nil {
+			return err
+		}
+
+		if len(names) == 0 {
+			fmt.Println("No identity profiles configured.")
+			fmt.Println("Use 'git-id add <name>' to create one.")
+			return nil
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		// Profiles by context, in the order git config lists them
+		var shown []string
+		profiles := make(map[string]*identity.Profile)
+		groups := make(map[string][]string)
+		for _, name := range names {
+			profile, err := identity.Get(name)
+			group := ""
+			if err == nil {
+				profiles[name] = profile
+				group = profile.Context
+			}
+			if listContext != "" && group != listContext {
+				continue
+			}
+			shown = append(shown, name)
+			groups[group] = append(groups[group], name)
+		}
+		if len(shown) == 0 {
+			fmt.Printf("No profiles in context %q.\n", listContext)
+			fmt.Printf("Use 'git-id set <profile> context %s' to add one.\n", listContext)
+			return nil
+		}
+
+		if !listByContext {
+			for _, name := range shown {
+				printListed(cmd.Context(), "", name, profiles[name], name == cfg.Identity.Default)
+			}
+			return nil
+		}
+		contexts := slices.Sorted(maps.Keys(groups))
+		if contexts[0] == "" {
+			contexts = append(contexts[1:], "")
+		}
+		for i, group := range contexts {
+			if i > 0 {
+				fmt.Println()
+			}
+			if group == "" {
+				fmt.Println("(no context):")
+			} else {
+				fmt.Printf("%s:\n", group)
+			}
+			for _, name := range groups[group] {
+				printListed(cmd.Context(), "  ", name, profiles[name], name == cfg.Identity.Default)
+			}
+		}
+		return nil
+	},
+}
+
+// printListed prints the line of a profile in list, marking the default
+// one with a *. A nil profile couldn't be read.
+func printListed(ctx context.Context, indent, name string, profile *identity.Profile, isDefault bool) {
+	marker := " "
+	if isDefault {
+		marker = "*"
+	}
+	if profile == nil {
+		fmt.Printf("%s%s %s (error reading)\n", indent, marker, name)
+		return
+	}
+
+	// Check forge auth status
+	var ghStatus string
+	if profile.GHUser == "" {
+		ghStatus = "(gh: not configured)"
+	} else if label, err := authStatus(ctx, profile); err == nil {
+		ghStatus = fmt.Sprintf("(%s: %s ✓)", label, profile.GHUser)
+	} else {
+		ghStatus = fmt.Sprintf("(%s: %s ⚠)", label, profile.GHUser)
+	}
+
+	fmt.Printf("%s%s %s: %s %s\n", indent, marker, name, profile.Email, ghStatus)
+}
+
+var showCmd = &cobra.Command{
+	Use:   "show [profile]",
+	Short: "Show profile details (default: identity.default from config.toml)",
+	Long: `Show the fields of a profile, checking its SSH key and forge login.
+
+On GitHub, it also checks that the SSH key is registered to ghuser, and
+not to another of your accounts, which pushes with it would act as.
+
+--public-key prints only the public half of the SSH key, from the .pub
+file next to it, ready to paste where a forge asks for it. --upload adds
+it to the SSH keys of the profile's GitHub account (ghuser) instead,
+unless it is there already; the gh token needs the admin:public_key scope.`,
+	Example: `  git-id show work
+  git-id show work --public-key | pbcopy
+  git-id show work --upload --title "work laptop"`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: cli.CompleteProfile,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		name := cfg.Identity.Default
+		if len(args) > 0 {
+			name = args[0]
+		}
+		if name == "" {
+			return fmt.Errorf("no profile given and no default set.\nUse: bread config set identity.default <profile>")
+		}
+
+		profile, err := identity.Get(name)
+		if err != nil {
+			return err
+		}
+		if showUpload {
+			return uploadPublicKey(cmd.Context(), profile)
+		}
+		if showPublicKey {
+			key, err := profilePublicKey(profile)
+			if err != nil {
+				return err
+			}
+			fmt.Println(key)
+			return nil
+		}
+
+		// Get source file
+		source, _ := identity.GetSourceFile(name)
+
+		fmt.Printf("Profile: %s\n", profile.Name)
+		if source != "" {
+			fmt.Printf("Source:  %s\n", source)
+		}
+		fmt.Println()
+
+		if profile.DisplayName != "" {
+			fmt.Printf("  name:   %s\n", profile.DisplayName)
+		} else {
+			fmt.Println("  name:   (not set)")
+		}
+
+		if profile.SSHKey != "" {
+			// Validate SSH key
+			sshStatus := "✓"
+			if err := identity.ValidateSSHKey(profile.SSHKey); err != nil {
+				sshStatus = "⚠ " + err.Error()
+			} else if err := profile.CheckNotRevoked(); errors.Is(err, identity.ErrKeyRevoked) {
+				sshStatus = "⚠ revoked"
+			}
+			fmt.Printf("  sshkey: %s %s\n", profile.SSHKey, sshStatus)
+		} else {
+			fmt.Println("  sshkey: (not set)")
+		}
+
+		if profile.Email != "" {
+			emailStatus := ""
+			if profile.PrivateEmail {
+				emailStatus = " ✓ private (noreply)"
+				if err := profile.CheckPrivateEmail(); err != nil {
+					emailStatus = " ⚠ privateemail set, but not the GitHub noreply address"
+				}
+			}
+			fmt.Printf("  email:  %s%s\n", profile.Email, emailStatus)
+		} else {
+			fmt.Println("  email:  (not set)")
+		}
+
+		if profile.User != "" {
+			fmt.Printf("  user:   %s\n", profile.User)
+		} else {
+			fmt.Println("  user:   (not set)")
+		}
+
+		var keyErr error
+		if profile.GHUser != "" {
+			ghStatus := "✓ authenticated"
+			if _, err := authStatus(cmd.Context(), profile); err != nil {
+				ghStatus = "⚠ " + err.Error()
+			} else if keyErr = checkKeyAccount(cmd.Context(), profile); keyErr == nil && isGitHubKey(profile) {
+				ghStatus += ", SSH key registered"
+			}
+			fmt.Printf("  ghuser: %s %s\n", profile.GHUser, ghStatus)
+		} else {
+			fmt.Println("  ghuser: (not set)")
+		}
+
+		if profile.Forge != "" {
+			fmt.Printf("  forge:  %s\n", profile.Forge)
+		} else {
+			fmt.Println("  forge:  github (default)")
+		}
+		if profile.GLUser != "" {
+			fmt.Printf("  gluser: %s\n", profile.GLUser)
+		}
+		if profile.BTUser != "" {
+			fmt.Printf("  btuser: %s\n", profile.BTUser)
+		}
+		for _, host := range slices.Sorted(maps.Keys(profile.Hosts)) {
+			fmt.Printf("  %s: %s\n", identity.HostKey(host), profile.Hosts[host])
+		}
+		for _, command := range slices.Sorted(maps.Keys(profile.GHFlags)) {
+			fmt.Printf("  %s: %s\n", identity.GHFlagsKey(command), profile.GHFlags[command])
+		}
+		for _, alias := range slices.Sorted(maps.Keys(profile.GHAliases)) {
+			fmt.Printf("  %s: %s\n", identity.GHAliasKey(alias), profile.GHAliases[alias])
+		}
+		for _, trailer := range profile.Trailers {
+			fmt.Printf("  trailer: %s\n", trailer)
+		}
+		if profile.Context != "" {
+			fmt.Printf("  context: %s\n", profile.Context)
+		}
+
+		if keyErr != nil {
+			printKeyWarning(profile, keyErr)
+		}
+		return nil
+	},
+}
+
+var addCmd = &cobra.Command{
+	Use:   "add <profile>",
+	Short: "Create a new identity profile, interactively or from flags",
+	Long: `Create a new identity profile, asking for each field.
+
+Give the fields as flags instead to create it without questions, as
+scripts must: with --sshkey and --email at least, nothing is asked.`,
+	Example: `  git-id add personal
+  git-id add work --sshkey ~/.ssh/id_work --email me@work.com --ghuser me-work`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		// Check if profile already exists
+		if _, err := identity.Get(name); err == nil {
+			return fmt.Errorf("profile %q already exists. Use 'git-id set' to modify it", name)
+		}
+
+		profile := addFields
+		profile.Name = name
+		if countFlags(cmd, "sshkey", "email", "name", "user", "ghuser", "forge", "gluser", "btuser") == 0 {
+			if err := cli.RequireInput("git-id add",
+				i18n.Sprintf("Give the fields as flags: git-id add %s --sshkey <path> --email <email>", name)); err != nil {
+				return err
+			}
+			if err := promptProfile(&profile); err != nil {
+				return err
+			}
+		} else if err := checkFields(&profile); err != nil {
+			return err
+		}
+
+		// Save the profile
+		opts := identity.SetOptions{
+			File:     fileFlag,
+			Yes:      yesFlag,
+			Detached: detachedFlag,
+		}
+		targetFile, err := identity.Set(&profile, opts)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\nProfile '%s' saved to %s\n", name, targetFile)
+
+		// Show warnings for forge auth if needed
+		if profile.GHUser != "" {
+			warnUnauthenticated(cmd.Context(), &profile)
+			warnKeyAccount(cmd.Context(), &profile)
+		}
+
+		return nil
+	},
+}
+
+// countFlags counts how many of the named flags were given
+func countFlags(cmd *cobra.Command, names ...string) int {
+	n := 0
+	for _, name := range names {
+		if cmd.Flags().Changed(name) {
+			n++
+		}
+	}
+	return n
+}
+
+// promptProfile asks for each field of p on stdin
+func promptProfile(p *identity.Profile) error {
+	reader := bufio.NewReader(os.Stdin)
+	ask := func(prompt string) string {
+		fmt.Print(prompt)
+		answer, _ := reader.ReadString('\n')
+		return strings.TrimSpace(answer)
+	}
+
+	fmt.Printf("Creating profile: %s\n\n", p.Name)
+
+	// SSH Key (required)
+	p.SSHKey = ask("SSH key path (required): ")
+	if p.SSHKey == "" {
+		return fmt.Errorf("SSH key path is required")
+	}
+	if err := identity.ValidateSSHKey(p.SSHKey); err != nil {
+		return err
+	}
+
+	// Email (required)
+	p.Email = ask("Email (required): ")
+	if p.Email == "" {
+		return fmt.Errorf("email is required")
+	}
+
+	// Optional fields
+	p.DisplayName = ask("Display name for commits (optional): ")
+	p.User = ask("User name (optional): ")
+	p.GHUser = ask("GitHub username (optional): ")
+	if p.GHUser != "" {
+		p.Forge = ask("Forge: github, gitlab[:host] or gitea:host (default github): ")
+		if _, err := forge.ParseSpec(p.Forge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFields validates a profile given with flags
+func checkFields(p *identity.Profile) error {
+	if p.SSHKey == "" {
+		return fmt.Errorf("SSH key path is required: use --sshkey")
+	}
+	if err := identity.ValidateSSHKey(p.SSHKey); err != nil {
+		return err
+	}
+	if p.Email == "" {
+		return fmt.Errorf("email is required: use --email")
+	}
+	if err := p.CheckPrivateEmail(); err != nil {
+		return err
+	}
+	_, err := forge.ParseSpec(p.Forge)
+	return err
+}
+
+var removeCmd = &cobra.Command{
+	Use:               "remove <profile>",
+	Short:             "Delete an identity profile",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: cli.CompleteProfile,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		// Verify profile exists
+		if _, err := identity.Get(name); err != nil {
+			return err
+		}
+
+		if err := identity.Remove(name); err != nil {
+			return err
+		}
+
+		fmt.Printf("Profile '%s' removed.\n", name)
+		return nil
+	},
+}
+
+var setCmd = &cobra.Command{
+	Use:   "set <profile> <key> <value>",
+	Short: "Set a profile field",
+	Long: `Set a single field on an existing profile.
+
+Valid keys: name, sshkey, email, user, ghuser, forge, gluser, btuser,
+privateemail, context, trailer, host.<host>.user, gh.<command>.flags,
+gh.<alias>.alias
+
+trailer, given as "Token: value", is a trailer git-as adds to the commits
+made with the profile. A profile can have several: setting one replaces
+the one with the same token, and "Token:" alone removes it.
+
+gh.<command>.flags are flags gh-as adds to a gh command of the profile,
+with the command's words between dots (gh.pr.list.flags for gh pr list);
+flags given on the command line come after them, and so win.
+gh.<alias>.alias makes 'gh-as <profile> <alias>' run what it stands for.
+
+Examples:
+  git-id set personal email newemail@example.com
+  git-id set work sshkey ~/.ssh/id_work
+  git-id set work forge gitlab:gitlab.example.com
+  git-id set work host.git.example.com.user jdoe
+  git-id set work gh.pr.list.flags "--repo acme/monorepo"
+  git-id set work gh.prs.alias "pr list --author @me"
+  git-id set client-a trailer "Billing-Code: ACME-42"`,
+	Args:              cobra.ExactArgs(3),
+	ValidArgsFunction: completeSet,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		key := args[1]
+		value := args[2]
+
+		// Validate SSH key if setting sshkey
+		if key == "sshkey" {
+			if err := identity.ValidateSSHKey(value); err != nil {
+				return err
+			}
+		}
+		if key == "forge" {
+			if _, err := forge.ParseSpec(value); err != nil {
+				return err
+			}
+		}
+		if key == "trailer" {
+			if _, _, err := identity.ParseTrailer(value); err != nil {
+				return err
+			}
+		}
+		if strings.HasPrefix(key, "gh.") {
+			if _, err := identity.SplitArgs(value); err != nil {
+				return err
+			}
+		}
+		if key == "privateemail" {
+			private, err := identity.ParseBool(value)
+			if err != nil {
+				return err
+			}
+			value = strconv.FormatBool(private)
+		}
+		if err := checkPrivateEmail(name, key, value); err != nil {
+			return err
+		}
+
+		opts := identity.SetOptions{
+			File:     fileFlag,
+			Yes:      yesFlag,
+			Detached: detachedFlag,
+		}
+
+		targetFile, err := identity.SetField(name, key, value, opts)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Set %s.%s = %s in %s\n", name, key, value, targetFile)
+
+		// Show warning if the forge account isn't authenticated, or doesn't
+		// hold the SSH key
+		if key == "ghuser" || key == "forge" || key == "sshkey" {
+			if profile, err := identity.Get(name); err == nil && profile.GHUser != "" {
+				if key != "sshkey" {
+					warnUnauthenticated(cmd.Context(), profile)
+				}
+				warnKeyAccount(cmd.Context(), profile)
+			}
+		}
+
+		return nil
+	},
+}
+
+// checkPrivateEmail checks that setting key to value leaves a profile with
+// privateemail set committing with its GitHub noreply address
+func checkPrivateEmail(name, key, value string) error {
+	if key != "privateemail" && key != "email" && key != "ghuser" {
+		return nil
+	}
+	p, err := identity.Get(name)
+	if err != nil {
+		return nil //nolint:nilerr // SetField reports the missing profile
+	}
+	switch key {
+	case "privateemail":
+		p.PrivateEmail = value == "true"
+	case "email":
+		p.Email = value
+	case "ghuser":
+		p.GHUser = value
+	}
+	return p.CheckPrivateEmail()
+}
+
+// completeSet completes the profile and key arguments of set
+func completeSet(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return cli.CompleteProfile(cmd, args, toComplete)
+	case 1:
+		return identity.Keys(), cobra.ShellCompDirectiveNoFileComp
+	case 2:
+		switch args[1] {
+		case "sshkey":
+			return nil, cobra.ShellCompDirectiveDefault
+		case "forge":
+			return []string{"github", "gitlab", "gitlab:", "gitea:"}, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+		case "privateemail":
+			return []string{"true", "false"}, cobra.ShellCompDirectiveNoFileComp
+		case "context":
+			return completeContext(cmd, args, toComplete)
+		}
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	// Add subcommands
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(addCmd)
+	rootCmd.AddCommand(removeCmd)
+	rootCmd.AddCommand(setCmd)
+
+	// Global flags for write operations
+	for _, cmd := range []*cobra.Command{addCmd, setCmd} {
+		cmd.Flags().StringVar(&fileFlag, "file", "", "Write to specific config file")
+		cmd.Flags().BoolVar(&yesFlag, "yes", false, "Auto-accept multi-file conflict prompt")
+		cmd.Flags().BoolVar(&detachedFlag, "detached", false, "Skip effectiveness check")
+	}
+	showCmd.Flags().BoolVar(&showPublicKey, "public-key", false, "Print only the public SSH key")
+	showCmd.Flags().BoolVar(&showUpload, "upload", false, "Add the public SSH key to the profile's GitHub account")
+	showCmd.Flags().StringVar(&showTitle, "title", "", "Title of the uploaded key (default: git-id <profile> on <host>)")
+	showCmd.MarkFlagsMutuallyExclusive("public-key", "upload")
+
+	addCmd.Flags().StringVar(&addFields.SSHKey, "sshkey", "", "Path to SSH private key")
+	addCmd.Flags().StringVar(&addFields.Email, "email", "", "Git author/committer email")
+	addCmd.Flags().StringVar(&addFields.DisplayName, "name", "", "Display name for commits")
+	addCmd.Flags().StringVar(&addFields.User, "user", "", "Git author/committer name")
+	addCmd.Flags().StringVar(&addFields.GHUser, "ghuser", "", "GitHub username, or the forge account")
+	addCmd.Flags().StringVar(&addFields.Forge, "forge", "", "Where ghuser lives: github, gitlab[:host] or gitea:host")
+	addCmd.Flags().StringVar(&addFields.GLUser, "gluser", "", "Username on gitlab.com")
+	addCmd.Flags().StringVar(&addFields.BTUser, "btuser", "", "Username on bitbucket.org")
+	addCmd.Flags().BoolVar(&addFields.PrivateEmail, "private-email", false, "Require the email to be ghuser's GitHub noreply address")
+	addCmd.Flags().StringVar(&addFields.Context, "context", "", "Group of profiles it belongs to, such as work or a client")
+	_ = addCmd.RegisterFlagCompletionFunc("context", completeContext)
+
+	listCmd.Flags().BoolVar(&listByContext, "by-context", false, "Group profiles by context")
+	listCmd.Flags().StringVar(&listContext, "context", "", "List only the profiles of this context")
+	_ = listCmd.RegisterFlagCompletionFunc("context", completeContext)
+}
+
+// Command returns the git-id command
+func Command() *cobra.Command {
+	return rootCmd
+}
+
+// authStatus checks that the forge account of a profile is logged in. It
+// returns the label to show the account under: "gh" on GitHub, the forge
+// otherwise.
+func authStatus(ctx context.Context, p *identity.Profile) (string, error) {
+	spec, err := forge.ForProfile(p)
+	if err != nil {
+		return "forge", err
+	}
+	label := "gh"
+	if spec.Kind != forge.GitHub {
+		label = spec.String()
+	}
+	f, err := forge.New(spec)
+	if err == nil {
+		err = forge.CheckAuth(ctx, f)
+	}
+	return label, err
+}
+
+// warnUnauthenticated prints a warning when a profile's forge account
+// can't be used
+func warnUnauthenticated(ctx context.Context, p *identity.Profile) {
+	label, err := authStatus(ctx, p)
+	if err == nil {
+		return
+	}
+	if label == "gh" {
+		label = "GitHub"
+	}
+	fmt.Printf("\n⚠ %s user '%s' is not authenticated: %v\n", label, p.GHUser, err)
+}
+
+// isGitHubKey reports whether a profile has an SSH key and a GitHub account
+// to check it against
+func isGitHubKey(p *identity.Profile) bool {
+	if p.SSHKey == "" || p.GHUser == "" {
+		return false
+	}
+	spec, err := forge.ForProfile(p)
+	return err == nil && spec.Kind == forge.GitHub
+}
+
+// checkKeyAccount checks that a profile's public SSH key is registered to
+// its GitHub account rather than to another one, which GitHub would take
+// pushes with it to come from. Profiles on other forges, or without a key,
+// pass.
+func checkKeyAccount(ctx context.Context, p *identity.Profile) error {
+	if !isGitHubKey(p) {
+		return nil
+	}
+	key, err := identity.PublicKey(p.SSHKey)
+	if err != nil {
+		return err
+	}
+	return ghapi.CheckSSHKey(ctx, key, p.GHUser, forge.GitHubAccounts())
+}
+
+// warnKeyAccount prints a warning when a profile's SSH key isn't
+// registered to its GitHub account
+func warnKeyAccount(ctx context.Context, p *identity.Profile) {
+	if err := checkKeyAccount(ctx, p); err != nil {
+		printKeyWarning(p, err)
+	}
+}
+
+// printKeyWarning explains an error from checkKeyAccount, and what to do
+// about it
+func printKeyWarning(p *identity.Profile, err error) {
+	fmt.Printf("\n⚠ %v\n", err)
+	var elsewhere *ghapi.KeyElsewhereError
+	switch {
+	case errors.As(err, &elsewhere):
+		fmt.Printf("  Remove it from the SSH keys of %s, then run: git-id show %s --upload\n", elsewhere.Owner, p.Name)
+	case errors.Is(err, ghapi.ErrKeyNotRegistered):
+		fmt.Printf("  Run: git-id show %s --upload\n", p.Name)
+	}
+}
+
+// profilePublicKey returns the public half of a profile's SSH key
+func profilePublicKey(p *identity.Profile) (string, error) {
+	if p.SSHKey == "" {
+		return "", fmt.Errorf("profile %q has no sshkey. Set one with: git-id set %s sshkey <path>", p.Name, p.Name)
+	}
+	return identity.PublicKey(p.SSHKey)
+}
+
+// uploadPublicKey adds a profile's public SSH key to its GitHub account,
+// unless it is registered there already
+func uploadPublicKey(ctx context.Context, p *identity.Profile) error {
+	if p.GHUser == "" {
+		return fmt.Errorf("profile %q has no ghuser to upload the key to. Set one with: git-id set %s ghuser <login>", p.Name, p.Name)
+	}
+	spec, err := forge.ForProfile(p)
+	if err != nil {
+		return err
+	}
+	if spec.Kind != forge.GitHub {
+		return fmt.Errorf("--upload only supports GitHub, and profile %q is on %s", p.Name, spec)
+	}
+	key, err := profilePublicKey(p)
+	if err != nil {
+		return err
+	}
+
+	client, err := ghapi.New(p.GHUser)
+	if err != nil {
+		return err
+	}
+	keys, err := client.SSHKeys(ctx)
+	if err != nil {
+		return keyScopeHint(p.GHUser, err)
+	}
+	for _, k := range keys {
+		if identity.SameKey(k.Key, key) {
+			fmt.Printf("✓ The key is already registered to %s as %q\n", p.GHUser, k.Title)
+			return nil
+		}
+	}
+
+	title := showTitle
+	if title == "" {
+		title = "git-id " + p.Name
+		if host, err := os.Hostname(); err == nil {
+			title += " on " + host
+		}
+	}
+	if _, err := client.AddSSHKey(ctx, title, key); err != nil {
+		return keyScopeHint(p.GHUser, err)
+	}
+	fmt.Printf("✓ Added %s to the SSH keys of %s as %q\n", identity.ExpandPath(p.SSHKey)+".pub", p.GHUser, title)
+	return nil
+}
+
+// keyScopeHint says how to grant the scope SSH keys need, when that's what
+// err is about
+func keyScopeHint(ghUser string, err error) error {
+	if errors.Is(err, ghapi.ErrNoKeyScope) {
+		return fmt.Errorf("GitHub user %q: %w. Run: gh auth switch -u %s && gh auth refresh -h %s -s admin:public_key",
+			ghUser, err, ghUser, ghapi.Host)
+	}
+	return fmt.Errorf("GitHub user %q: %w", ghUser, err)
+}
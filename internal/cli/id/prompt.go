@@ -0,0 +1,53 @@
+package id
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Print the identity live here, for a shell prompt",
+	Long: `Print a short segment for a shell prompt naming the identity commits
+made here get: the profile of the git-id session of this shell, or else
+the profile whose email is this directory's user.email (the email itself
+when no profile has it).
+
+A warning follows it when the session has expired, or when the directory
+is set up for another profile than the session's:
+
+    personal
+    client-a ⚠ expired
+    client-a ⚠ personal
+
+It prints nothing when there is no identity, and never fails, so the
+prompt stays quiet. It runs two git config commands, fast enough for
+every prompt.`,
+	Example: `  # bash, or zsh with setopt PROMPT_SUBST
+  PS1='[$(git-id prompt)] \w \$ '
+
+  # starship.toml
+  [custom.git_id]
+  command = "git-id prompt"
+  when = true
+  format = "[$output]($style) "`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := identity.ActiveIn(".")
+		if err != nil {
+			return nil //nolint:nilerr // A broken session must not break the prompt
+		}
+		if segment := a.Segment(time.Now()); segment != "" {
+			fmt.Println(segment)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(promptCmd)
+}
@@ -0,0 +1,229 @@
+package id
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/forge"
+	"github.com/jdevera/git-this-bread/internal/ghapi"
+	"github.com/jdevera/git-this-bread/internal/i18n"
+	"github.com/jdevera/git-this-bread/internal/identity"
+	"github.com/jdevera/git-this-bread/internal/paths"
+)
+
+var (
+	revokeDeleteRemote bool
+	revokeNoNewKey     bool
+	revokeNewKey       string
+	revokeKeyType      string
+	revokeNoPassphrase bool
+	revokeUpload       bool
+	revokeYes          bool
+)
+
+var revokeCmd = &cobra.Command{
+	Use:   "revoke <profile>",
+	Short: "Revoke a profile's compromised SSH key and replace it",
+	Long: `Walk through replacing a profile's SSH key once it is compromised:
+
+  1. Mark the key revoked: it goes on a list of revoked public keys, and
+     git-as and git-id session refuse to use it from then on
+  2. With --delete-remote, delete it from the profile's GitHub account
+  3. Move the key files aside, to <key>.revoked-<date>
+  4. Generate a replacement with ssh-keygen, at the same path or at
+     --new-key, which the profile is then set to (--no-new-key skips it)
+  5. With --upload, add the new key to the profile's GitHub account
+  6. Print what is left to do by hand: other forges and servers the key
+     opens, the ssh agent, commit signing
+
+The list of revoked keys is a valid RevokedKeys file for sshd, so it can
+be copied to servers to refuse the key there too.
+
+It asks before starting, unless given --yes.`,
+	Example: `  git-id revoke work --delete-remote --upload
+  git-id revoke work --new-key ~/.ssh/id_work_2026 --yes`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: cli.CompleteProfile,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		profile, err := identity.Get(args[0])
+		if err != nil {
+			return err
+		}
+		key, err := profilePublicKey(profile)
+		if err != nil {
+			return err
+		}
+		fingerprint, err := identity.Fingerprint(key)
+		if err != nil {
+			return err
+		}
+		onGitHub := isGitHubKey(profile)
+		if (revokeDeleteRemote || revokeUpload) && !onGitHub {
+			return fmt.Errorf("--delete-remote and --upload need a profile with a GitHub ghuser, and %q has none", profile.Name)
+		}
+
+		fmt.Printf("Revoking the SSH key of %s: %s\n  %s\n\n", profile.Name, identity.ExpandPath(profile.SSHKey), fingerprint)
+		if !revokeYes {
+			if err := cli.RequireInput("git-id revoke",
+				i18n.Sprintf("Use --yes to revoke the key of %s without asking", profile.Name)); err != nil {
+				return err
+			}
+			if !confirm("Go ahead? [y/N] ") {
+				return fmt.Errorf("nothing revoked")
+			}
+		}
+
+		var todo []string
+		if err := identity.RevokeKey(key); err != nil {
+			return fmt.Errorf("marking the key revoked: %w", err)
+		}
+		revokedFile, _ := identity.RevokedKeysFile()
+		fmt.Printf("✓ Marked revoked in %s\n", revokedFile)
+
+		deleted := false // The key is off GitHub, nothing left to do there
+		if revokeDeleteRemote {
+			deleted, err = deleteRemoteKey(ctx, profile, key)
+			if err != nil {
+				fmt.Printf("⚠ Could not delete it from GitHub: %v\n", err)
+			}
+		}
+		if onGitHub && !deleted {
+			todo = append(todo, fmt.Sprintf("Delete the key %s from the SSH keys of %s: https://%s/settings/keys",
+				fingerprint, profile.GHUser, ghapi.Host))
+		}
+
+		retired, err := identity.RetireKey(profile.SSHKey, time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Moved the key aside to %s\n", retired)
+		todo = append(todo, "Drop it from your ssh agent: ssh-add -d "+paths.ShellQuote(retired))
+
+		if revokeNoNewKey {
+			todo = append(todo, fmt.Sprintf("Create a new key and set it: git-id set %s sshkey <path>", profile.Name))
+		} else {
+			newKey := profile.SSHKey
+			if revokeNewKey != "" {
+				newKey = revokeNewKey
+			}
+			if err := generateKey(newKey, profile.Email); err != nil {
+				return err
+			}
+			fmt.Printf("✓ Generated a new key at %s\n", identity.ExpandPath(newKey))
+			if newKey != profile.SSHKey {
+				file, err := identity.SetField(profile.Name, "sshkey", newKey, identity.SetOptions{Yes: true})
+				if err != nil {
+					return err
+				}
+				fmt.Printf("✓ Set %s.sshkey = %s in %s\n", profile.Name, newKey, file)
+				profile.SSHKey = newKey
+			}
+			uploaded := false
+			if revokeUpload {
+				if err := uploadPublicKey(ctx, profile); err != nil {
+					fmt.Printf("⚠ Could not upload it: %v\n", err)
+				} else {
+					uploaded = true
+				}
+			}
+			if onGitHub && !uploaded {
+				todo = append(todo, fmt.Sprintf("Add the new key to %s: git-id show %s --upload", profile.GHUser, profile.Name))
+			}
+		}
+
+		for _, a := range forge.Accounts(profile) {
+			if !onGitHub || a.Host != ghapi.Host {
+				todo = append(todo, fmt.Sprintf("Replace the key on %s, for user %s", a.Host, a.User))
+			}
+		}
+		todo = append(todo,
+			"Remove it from the authorized_keys of every server it logs in to, or refuse it with sshd's RevokedKeys "+revokedFile,
+			"If it signed commits (gpg.format ssh), point user.signingkey and your allowed signers at the new key",
+		)
+		if onGitHub {
+			todo = append(todo, fmt.Sprintf("Look for activity you don't recognize: https://%s/settings/security-log", ghapi.Host))
+		}
+
+		fmt.Println("\nStill to do:")
+		for i, step := range todo {
+			fmt.Printf("  %d. %s\n", i+1, step)
+		}
+		return nil
+	},
+}
+
+// confirm asks a yes/no question on stdin, no being the default
+func confirm(question string) bool {
+	fmt.Print(question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// deleteRemoteKey deletes a public key from the SSH keys of the profile's
+// GitHub account. It reports whether the key is off the account, which it
+// also is when it wasn't there to begin with, so no step is left to do.
+func deleteRemoteKey(ctx context.Context, p *identity.Profile, key string) (bool, error) {
+	client, err := ghapi.New(p.GHUser)
+	if err != nil {
+		return false, err
+	}
+	keys, err := client.SSHKeys(ctx)
+	if err != nil {
+		return false, keyScopeHint(p.GHUser, err)
+	}
+	for _, k := range keys {
+		if !identity.SameKey(k.Key, key) {
+			continue
+		}
+		if err := client.DeleteSSHKey(ctx, k.ID); err != nil {
+			return false, keyScopeHint(p.GHUser, err)
+		}
+		fmt.Printf("✓ Deleted %q from the SSH keys of %s\n", k.Title, p.GHUser)
+		return true, nil
+	}
+	fmt.Printf("✓ The key is not among the SSH keys of %s\n", p.GHUser)
+	return true, nil
+}
+
+// generateKey runs ssh-keygen to create a key pair at path, letting it ask
+// for a passphrase unless --no-passphrase was given
+func generateKey(path, email string) error {
+	args := []string{"-t", revokeKeyType, "-f", identity.ExpandPath(path)}
+	if email != "" {
+		args = append(args, "-C", email)
+	}
+	if revokeNoPassphrase {
+		args = append(args, "-N", "")
+	}
+	cmd := exec.Command("ssh-keygen", args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := debuglog.Run(cmd); err != nil {
+		return fmt.Errorf("generating the new key: %w. The old one is revoked already; create one with ssh-keygen and run: git-id set <profile> sshkey <path>", err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(revokeCmd)
+
+	revokeCmd.Flags().BoolVar(&revokeDeleteRemote, "delete-remote", false, "Delete the key from the profile's GitHub account (needs the admin:public_key scope)")
+	revokeCmd.Flags().BoolVar(&revokeNoNewKey, "no-new-key", false, "Don't generate a replacement key")
+	revokeCmd.Flags().StringVar(&revokeNewKey, "new-key", "", "Where to generate the replacement key, setting the profile to it (default the old key's path)")
+	revokeCmd.Flags().StringVar(&revokeKeyType, "type", "ed25519", "Type of the replacement key, as ssh-keygen -t takes it")
+	revokeCmd.Flags().BoolVar(&revokeNoPassphrase, "no-passphrase", false, "Generate the replacement key without a passphrase, without asking")
+	revokeCmd.Flags().BoolVar(&revokeUpload, "upload", false, "Add the replacement key to the profile's GitHub account")
+	revokeCmd.Flags().BoolVarP(&revokeYes, "yes", "y", false, "Revoke without asking first")
+	revokeCmd.MarkFlagsMutuallyExclusive("no-new-key", "new-key")
+	revokeCmd.MarkFlagsMutuallyExclusive("no-new-key", "upload")
+}
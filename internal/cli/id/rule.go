@@ -0,0 +1,135 @@
+package id
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+var ruleRemote bool
+
+var ruleCmd = &cobra.Command{
+	Use:   "rule",
+	Short: "Manage the rules that pick a profile by directory or remote",
+	Long: `Manage the rules that pick a profile for a directory, by where it is or
+by the remotes of the repository in it.
+
+A directory rule is a glob of paths, where ~ is your home directory and **
+matches any number of directories. It picks its profile for the
+directories it matches and everything under them; the rule matching the
+closest directory wins. A remote rule, added with --remote, is a
+host[/owner[/repo]] glob, matched against every remote of the repository,
+and wins over directory rules. Among rules as close, the most literal one
+wins.
+
+Rules are stored with their profile in git config, as its dir and remote
+keys. 'git-id current' shows the rule for a repository, and
+'git-as --auto' runs git as the profile it picks.`,
+	Example: `  git-id rule add '~/work/**' work
+  git-id rule add --remote github.com/acme client-a
+  git-id rule list
+  git-id rule remove '~/work/**'`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return ruleListCmd.RunE(cmd, args)
+	},
+}
+
+var ruleAddCmd = &cobra.Command{
+	Use:   "add <pattern> <profile>",
+	Short: "Add a rule picking a profile",
+	Long: `Add a rule picking profile for the directories that pattern matches, or,
+with --remote, the repositories with a remote it matches. A relative
+directory pattern is made absolute.`,
+	Example: `  git-id rule add '~/work/**' work
+  git-id rule add . personal
+  git-id rule add --remote 'gitlab.example.com/team/*' work`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 1 {
+			return cli.CompleteProfile(cmd, args, toComplete)
+		}
+		if len(args) == 0 && !ruleRemote {
+			return nil, cobra.ShellCompDirectiveFilterDirs
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		kind := identity.RuleDir
+		if ruleRemote {
+			kind = identity.RuleRemote
+		}
+		r, err := identity.AddRule(identity.Rule{Profile: args[1], Kind: kind, Pattern: args[0]})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rule '%s' added to profile '%s' in %s\n", r, r.Profile, r.File)
+		return nil
+	},
+}
+
+var ruleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the rules",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rules, err := identity.Rules()
+		if err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			fmt.Println("No rules configured.")
+			fmt.Println("Use 'git-id rule add <pattern> <profile>' to add one.")
+			return nil
+		}
+		for _, r := range rules {
+			fmt.Printf("  %-6s %-40s %s\n", r.Kind, r.Pattern, r.Profile)
+		}
+		return nil
+	},
+}
+
+var ruleRemoveCmd = &cobra.Command{
+	Use:   "remove <pattern> [profile]",
+	Short: "Remove the rules with a pattern",
+	Long: `Remove the rules whose pattern is pattern, exactly as 'git-id rule list'
+shows it. Given a profile, only its rule is removed.`,
+	Example: `  git-id rule remove '~/work/**'
+  git-id rule remove github.com/acme client-a`,
+	Args: cobra.RangeArgs(1, 2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 1 {
+			return cli.CompleteProfile(cmd, args, toComplete)
+		}
+		var patterns []string
+		if rules, err := identity.Rules(); err == nil && len(args) == 0 {
+			for _, r := range rules {
+				patterns = append(patterns, r.Pattern)
+			}
+		}
+		return patterns, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile := ""
+		if len(args) == 2 {
+			profile = args[1]
+		}
+		removed, err := identity.RemoveRule(args[0], profile)
+		for _, r := range removed {
+			fmt.Printf("Rule '%s' removed from profile '%s'\n", r, r.Profile)
+		}
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(ruleCmd)
+	ruleCmd.AddCommand(ruleAddCmd)
+	ruleCmd.AddCommand(ruleListCmd)
+	ruleCmd.AddCommand(ruleRemoveCmd)
+
+	ruleAddCmd.Flags().BoolVar(&ruleRemote, "remote", false, "Match the repository's remotes, as a host[/owner[/repo]] glob")
+}
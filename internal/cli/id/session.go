@@ -0,0 +1,134 @@
+package id
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+var (
+	sessionFor    time.Duration
+	sessionStrict bool
+	sessionShell  string
+)
+
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Act as a profile in the current shell for a limited time",
+	Long: `Export a profile's identity into the current shell until a set time.
+
+'start' prints commands that set the same variables git-as does, plus the
+session's expiry; eval them in your shell. Once the session expires,
+git-as and gh-as warn before running, or refuse with --strict, so time
+spent as one client doesn't spill into another's. 'end' prints the
+commands that remove it all.`,
+	Example: `  eval "$(git-id session start client-a --for 2h)"
+  git-id session status
+  eval "$(git-id session end)"
+  git-id session start client-a --shell fish | source`,
+}
+
+var sessionStartCmd = &cobra.Command{
+	Use:               "start <profile>",
+	Short:             "Print the commands that start a session",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: cli.CompleteProfile,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if sessionFor <= 0 {
+			return fmt.Errorf("--for must be positive, like 2h or 90m")
+		}
+		profile, err := identity.Get(args[0])
+		if err != nil {
+			return err
+		}
+		env, err := profile.GitEnv()
+		if err != nil {
+			return err
+		}
+		s := identity.NewSession(profile.Name, sessionFor, sessionStrict, time.Now())
+		script, err := identity.ShellExports(shellFor(), append(env, s.Env()...))
+		if err != nil {
+			return err
+		}
+		fmt.Print(script)
+		fmt.Fprintf(os.Stderr, "Session of %s until %s\n", profile.Name, s.Expires.Local().Format("15:04 Mon 2 Jan"))
+		return nil
+	},
+}
+
+var sessionEndCmd = &cobra.Command{
+	Use:   "end",
+	Short: "Print the commands that end the session",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		script, err := identity.ShellUnsets(shellFor(), identity.SessionVars())
+		if err != nil {
+			return err
+		}
+		fmt.Print(script)
+		return nil
+	},
+}
+
+var sessionStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the session of this shell and the time left",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := identity.CurrentSession()
+		if err != nil {
+			return err
+		}
+		if s == nil {
+			fmt.Println("No session in this shell.")
+			return nil
+		}
+		now := time.Now()
+		strict := ""
+		if s.Strict {
+			strict = " (strict)"
+		}
+		if s.Expired(now) {
+			fmt.Printf("⚠ Session of %s expired %s ago%s\n", s.Profile, now.Sub(s.Expires).Round(time.Minute), strict)
+			return nil
+		}
+		fmt.Printf("Session of %s, %s left%s\n", s.Profile, s.Expires.Sub(now).Round(time.Minute), strict)
+		return nil
+	},
+}
+
+// shellFor returns the shell to write commands for: --shell, or a guess
+// from SHELL
+func shellFor() string {
+	if sessionShell != "" {
+		return sessionShell
+	}
+	shell := filepath.Base(os.Getenv("SHELL"))
+	switch {
+	case strings.Contains(shell, "fish"):
+		return "fish"
+	case shell == "." && runtime.GOOS == "windows":
+		return "powershell"
+	}
+	return "sh"
+}
+
+func init() {
+	rootCmd.AddCommand(sessionCmd)
+	sessionCmd.AddCommand(sessionStartCmd, sessionEndCmd, sessionStatusCmd)
+
+	sessionStartCmd.Flags().DurationVar(&sessionFor, "for", 8*time.Hour, "How long the session lasts")
+	sessionStartCmd.Flags().BoolVar(&sessionStrict, "strict", false, "Make git-as and gh-as refuse to run once it expires")
+	for _, cmd := range []*cobra.Command{sessionStartCmd, sessionEndCmd} {
+		cmd.Flags().StringVar(&sessionShell, "shell", "", "Shell to write commands for: sh, fish or powershell (default: from SHELL)")
+		_ = cmd.RegisterFlagCompletionFunc("shell", cobra.FixedCompletions([]string{"sh", "fish", "powershell"}, cobra.ShellCompDirectiveNoFileComp))
+	}
+}
@@ -0,0 +1,73 @@
+package id
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+var (
+	useDir    string
+	useRemove bool
+)
+
+var useCmd = &cobra.Command{
+	Use:   "use <profile> --dir <directory>",
+	Short: "Make git act as a profile in every repository under a directory",
+	Long: `Make git itself act as a profile in every repository under a directory,
+without git-as or wrappers: the profile's email, commit name and SSH key
+are written to a git config fragment of its own, under
+~/.config/git-this-bread/identities, and an [includeIf "gitdir:..."]
+stanza for the directory, including the fragment, is added to your
+global git config.
+
+Running it again rewrites the fragment from the profile, so changes to
+the profile reach it, and adds nothing else. The profile must have what
+git-as needs: an SSH key and an email.
+
+--remove takes the stanza for the directory out, or, without --dir, every
+stanza of the profile. The fragment is deleted once nothing includes it.`,
+	Example: `  git-id use client-a --dir ~/clients/acme
+  git-id use client-a --remove --dir ~/clients/acme
+  git-id use client-a --remove`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: cli.CompleteProfile,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if useRemove {
+			removed, err := identity.Unuse(args[0], useDir)
+			for _, inc := range removed {
+				fmt.Printf("Profile '%s' no longer used for %s\n", args[0], inc.Dir)
+			}
+			return err
+		}
+		if useDir == "" {
+			return fmt.Errorf("--dir is required, unless --remove is given")
+		}
+
+		p, err := identity.Get(args[0])
+		if err != nil {
+			return err
+		}
+		inc, added, err := identity.Use(p, useDir)
+		if err != nil {
+			return err
+		}
+		if !added {
+			fmt.Printf("Profile '%s' is already used for %s; updated %s\n", p.Name, inc.Dir, inc.Path)
+			return nil
+		}
+		fmt.Printf("Profile '%s' used for %s, through %s\n", p.Name, inc.Dir, inc.Path)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(useCmd)
+
+	useCmd.Flags().StringVar(&useDir, "dir", "", "Directory whose repositories act as the profile")
+	useCmd.Flags().BoolVar(&useRemove, "remove", false, "Stop using the profile for --dir, or for every directory")
+	_ = useCmd.MarkFlagDirname("dir")
+}
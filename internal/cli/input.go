@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/term"
+
+	"github.com/jdevera/git-this-bread/internal/i18n"
+)
+
+// NoInputEnv turns prompts off, like --no-input, when set to a true value
+const NoInputEnv = "GIT_THIS_BREAD_NO_INPUT"
+
+// ErrNoInput is wrapped by the errors of commands that would have to ask
+// something and can't
+var ErrNoInput error = noInputError{}
+
+type noInputError struct{}
+
+func (noInputError) Error() string { return i18n.T("can't ask for input") }
+
+// noInput is set by the --no-input flag that Execute adds
+var noInput bool
+
+// stdinIsTerminal is replaced in tests
+var stdinIsTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) //nolint:gosec // Fd fits in int on all supported platforms
+}
+
+// Interactive reports whether a command may prompt: stdin is a terminal and
+// neither --no-input, GIT_THIS_BREAD_NO_INPUT nor CI turns prompts off
+func Interactive() bool {
+	return noInputReason() == ""
+}
+
+// RequireInput returns nil when a command may prompt. Otherwise it returns
+// an error, wrapping ErrNoInput, that says why what can't ask and what to do
+// instead, so scripts and CI jobs fail at once rather than hang on a prompt.
+// Callers translate instead.
+func RequireInput(what, instead string) error {
+	reason := noInputReason()
+	if reason == "" {
+		return nil
+	}
+	return fmt.Errorf("%s %w: %s\n%s", what, ErrNoInput, reason, instead)
+}
+
+func noInputReason() string {
+	switch {
+	case noInput:
+		return i18n.T("--no-input is set")
+	case envTrue(NoInputEnv):
+		return i18n.Sprintf("%s is set", NoInputEnv)
+	case envTrue("CI"):
+		return i18n.T("running in CI")
+	case !stdinIsTerminal():
+		return i18n.T("stdin isn't a terminal")
+	}
+	return ""
+}
+
+// envTrue reports whether the variable name holds a true value. CI systems
+// set CI=true, some CI=1 and a few just CI to anything, so a value that
+// isn't a boolean counts as true too.
+func envTrue(name string) bool {
+	v := os.Getenv(name)
+	if v == "" {
+		return false
+	}
+	on, err := strconv.ParseBool(v)
+	return err != nil || on
+}
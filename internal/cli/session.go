@@ -0,0 +1,19 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+// CheckSession warns on stderr when the git-id session of this shell has
+// expired, and fails instead when it was started with --strict
+func CheckSession() error {
+	warning, err := identity.CheckSession(time.Now())
+	if warning != "" {
+		fmt.Fprintf(os.Stderr, "⚠ %s\n", warning)
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/spf13/cobra"
+)
+
+// Build describes the binary. main sets it from -ldflags "-X main.version=...
+// -X main.commit=... -X main.date=..."; what is left empty is filled in from
+// the Go build info, so go install and go build from a checkout report
+// something useful too.
+type Build struct {
+	Version string
+	Commit  string
+	Date    string
+}
+
+// withDefaults fills in the fields the linker didn't set
+func (b Build) withDefaults() Build {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b
+	}
+	if (b.Version == "" || b.Version == "dev") && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		b.Version = info.Main.Version
+	}
+	for _, s := range info.Settings {
+		switch {
+		case s.Key == "vcs.revision" && b.Commit == "":
+			b.Commit = s.Value
+		case s.Key == "vcs.time" && b.Date == "":
+			b.Date = s.Value
+		case s.Key == "vcs.modified" && s.Value == "true" && b.Commit != "":
+			b.Commit += "-dirty"
+		}
+	}
+	if b.Version == "" {
+		b.Version = "dev"
+	}
+	return b
+}
+
+// String formats the build as the version command prints it
+func (b Build) String() string {
+	s := b.Version + "\n"
+	if b.Commit != "" {
+		s += "commit: " + b.Commit + "\n"
+	}
+	if b.Date != "" {
+		s += "built:  " + b.Date + "\n"
+	}
+	return s + fmt.Sprintf("go:     %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
+
+// versionCommand prints what --version prints
+func versionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print the version, commit and build date",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			PrintVersion(cmd)
+		},
+	}
+}
+
+// PrintVersion prints the build of the binary cmd belongs to, for commands
+// that pass their flags through and so must spot --version themselves
+func PrintVersion(cmd *cobra.Command) {
+	root := cmd.Root()
+	_, _ = fmt.Fprint(cmd.OutOrStdout(), root.Name()+" "+root.Version)
+}
@@ -0,0 +1,230 @@
+// Package wip implements the git-wip command, run on its own or as a
+// subcommand of bread
+package wip
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/config"
+	"github.com/jdevera/git-this-bread/internal/forge"
+	"github.com/jdevera/git-this-bread/internal/identity"
+	"github.com/jdevera/git-this-bread/internal/render"
+	"github.com/jdevera/git-this-bread/internal/wip"
+)
+
+var (
+	push      bool
+	remote    string
+	asProfile string
+	dryRun    bool
+	excludes  []string
+	themeName string
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "git-wip [directory...]",
+	Short: "Snapshot uncommitted changes and stashes onto wip branches",
+	Long: `git-wip (a 🍞 git-this-bread tool)
+
+Save the work git-explain keeps warning about before it gets lost.
+
+Uncommitted changes (untracked files included, ignored ones left out) and
+stashes become one commit on a wip/<date> branch: its tree is the working
+tree and its parents are HEAD and every stash. The working tree, the index
+and the stash list are left as they were, and work that is already saved
+isn't saved again.
+
+If DIRECTORY is a git repo, snapshot it directly. Otherwise, snapshot every
+immediate subdirectory that has something to save. Without DIRECTORY,
+outside a git repo, the explain.roots directories from
+~/.config/git-this-bread/config.toml are used, skipping explain.excludes.
+
+With --push, each snapshot is pushed to your remote (the first one
+git-explain counts as yours, or --remote). Use --as to push, and commit, as
+an identity profile managed by git-id.
+
+To get the work back:
+
+  git checkout wip/2026-10-16 -- .     # the uncommitted changes
+  git stash apply wip/2026-10-16^2     # the first stash, ^3 the next...`,
+	Example: `  git-wip
+  git-wip ~/src --dry-run
+  git-wip --push --as personal`,
+	Args: cobra.ArbitraryArgs,
+	RunE: run,
+}
+
+func init() {
+	rootCmd.Flags().BoolVar(&push, "push", false, "Push each snapshot branch to your remote (default wip.push from config.toml)")
+	rootCmd.Flags().StringVar(&remote, "remote", "", "Remote to push to (default wip.remote from config.toml, or the first remote that is yours)")
+	rootCmd.Flags().StringVar(&asProfile, "as", "", "Commit and push as identity profile (managed by git-id; default identity.default from config.toml)")
+	rootCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Show what would be saved without saving or pushing it")
+	rootCmd.Flags().StringArrayVar(&excludes, "exclude", nil, "Skip subdirectories matching this glob (repeatable; default explain.excludes from config.toml)")
+	rootCmd.Flags().StringVar(&themeName, "theme", "", "Color theme: dark, light (default $GIT_THIS_BREAD_THEME, ui.theme from config.toml, or dark)")
+	_ = rootCmd.RegisterFlagCompletionFunc("as", cli.CompleteProfileFlag)
+}
+
+// Command returns the git-wip command
+func Command() *cobra.Command {
+	return rootCmd
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	if err := analyzer.LoadGitConfig(); err != nil {
+		return err
+	}
+	for _, a := range forge.AllAccounts() {
+		analyzer.AddHostUser(a.Host, a.User)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := render.LoadTheme(themeName, cfg.UI.Theme); err != nil {
+		return err
+	}
+	flags := cmd.Flags()
+	if !flags.Changed("as") {
+		asProfile = cfg.Identity.Default
+	}
+	if !flags.Changed("push") {
+		push = cfg.Wip.Push
+	}
+	if !flags.Changed("remote") {
+		remote = cfg.Wip.Remote
+	}
+	if !flags.Changed("exclude") {
+		excludes = cfg.Explain.Excludes
+	}
+
+	var profile *identity.Profile
+	var env []string
+	if asProfile != "" {
+		if profile, err = identity.Get(asProfile); err != nil {
+			return fmt.Errorf("%w\nUse 'git-id list' to see available profiles", err)
+		}
+		if env, err = profile.GitEnv(); err != nil {
+			return err
+		}
+	}
+
+	dirs := []string{"."}
+	if len(args) > 0 {
+		dirs = args
+	} else if len(cfg.Explain.Roots) > 0 && !analyzer.IsGitRepo(".") {
+		dirs = cfg.Explain.Roots
+	}
+
+	opts := analyzer.Options{Timeout: 30 * time.Second, Excludes: excludes}
+	var repos []analyzer.RepoInfo
+	for _, dir := range dirs {
+		target, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf("invalid directory: %w", err)
+		}
+		if info, err := os.Stat(target); err != nil || !info.IsDir() {
+			return fmt.Errorf("not a directory: %s", target)
+		}
+		if analyzer.IsGitRepo(target) {
+			repos = append(repos, analyzer.AnalyzeRepo(target, opts))
+		} else {
+			repos = append(repos, analyzer.AnalyzeDirectory(target, opts, false)...)
+		}
+	}
+
+	// From here on, errors are about repos rather than how git-wip was called
+	cmd.SilenceUsage = true
+	out := cmd.OutOrStdout()
+	var saved, failed int
+	for i := range repos {
+		r := &repos[i]
+		if !r.IsGitRepo || (!r.HasUncommittedChanges && r.StashCount == 0) {
+			continue
+		}
+		snap, err := wip.Save(cmd.Context(), r.Path, wip.Options{Env: env, DryRun: dryRun})
+		if err == nil && snap != nil && push && !dryRun {
+			err = pushSnapshot(cmd, r, profile, snap, env)
+		}
+		switch {
+		case err != nil:
+			failed++
+			report(out, render.RoleError, "✗", r.Name, err.Error())
+		case snap != nil:
+			saved++
+			reportSnapshot(out, r, snap)
+		}
+	}
+
+	if saved == 0 && failed == 0 {
+		_, _ = fmt.Fprintln(out, render.Style(render.RoleMuted).Render("Nothing to save"))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d repo(s) failed", failed)
+	}
+	return nil
+}
+
+// pushSnapshot pushes snap to the remote of r it belongs on
+func pushSnapshot(cmd *cobra.Command, r *analyzer.RepoInfo, profile *identity.Profile, snap *wip.Snapshot, env []string) error {
+	name := pushRemote(r, profile)
+	if name == "" {
+		return fmt.Errorf("saved on %s, but no remote of yours to push to (use --remote)", snap.Branch)
+	}
+	return wip.Push(cmd.Context(), r.Path, name, snap.Branch, env)
+}
+
+// pushRemote picks the remote to push to: --remote, else the remote owned
+// by the profile's GitHub user, else the first remote that is the user's
+func pushRemote(r *analyzer.RepoInfo, profile *identity.Profile) string {
+	if remote != "" {
+		return remote
+	}
+	if profile != nil && profile.GHUser != "" {
+		for _, rem := range r.AllRemotes {
+			if strings.EqualFold(rem.Owner, profile.GHUser) {
+				return rem.Name
+			}
+		}
+	}
+	if len(r.UserRemotes) > 0 {
+		return r.UserRemotes[0]
+	}
+	return ""
+}
+
+func reportSnapshot(w io.Writer, r *analyzer.RepoInfo, snap *wip.Snapshot) {
+	var what []string
+	if snap.Files > 0 {
+		what = append(what, fmt.Sprintf("%d file(s)", snap.Files))
+	}
+	if snap.Stashes > 0 {
+		what = append(what, fmt.Sprintf("%d stash(es)", snap.Stashes))
+	}
+	detail := strings.Join(what, ", ")
+	pushed := ""
+	if push && !dryRun {
+		pushed = ", pushed"
+	}
+	switch {
+	case snap.Exists:
+		report(w, render.RoleMuted, "=", r.Name, "already saved on "+snap.Branch+pushed)
+	case dryRun:
+		report(w, render.RoleInfo, "→", r.Name, "would save "+detail+" on "+snap.Branch)
+	default:
+		report(w, render.RoleSuccess, "✓", r.Name, "saved "+detail+" on "+snap.Branch+pushed)
+	}
+}
+
+func report(w io.Writer, role render.Role, icon, name, msg string) {
+	st := render.Style(role)
+	_, _ = fmt.Fprintf(w, "%s %s  %s\n", st.Render(icon), render.Style(render.RoleAccent).Render(name), msg)
+}
@@ -0,0 +1,256 @@
+// Package wtclone implements the gh-wtclone command, run on its own or as a
+// subcommand of bread
+package wtclone
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/clones"
+	"github.com/jdevera/git-this-bread/internal/config"
+	"github.com/jdevera/git-this-bread/internal/ghapi"
+	"github.com/jdevera/git-this-bread/internal/identity"
+	"github.com/jdevera/git-this-bread/internal/render"
+)
+
+var (
+	asProfile  string
+	archived   bool
+	jsonOutput bool
+	cloneDir   string
+	excludes   []string
+	noPager    bool
+	themeName  string
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "gh-wtclone [directory...]",
+	Short: "Reconcile your local clones with your GitHub repositories",
+	Long: `gh-wtclone (a 🍞 git-this-bread tool)
+
+Compare the repositories cloned under your source directories with the ones
+you own on GitHub, and report:
+
+  • Orphaned     — clones with no remote, or whose remote of yours is gone
+  • Not cloned   — repositories you own with no clone (archived ones only
+                   with --archived)
+  • Mismatched   — remotes that still use a repository's old name or owner
+
+Each finding comes with the command that fixes it.
+
+Every immediate subdirectory of DIRECTORY is checked, or DIRECTORY itself
+when it is a repo. Without DIRECTORY, the explain.roots directories from
+~/.config/git-this-bread/config.toml are used (or the current one),
+skipping explain.excludes. Missing repositories are suggested to be cloned
+into --clone-dir, by default the first directory.
+
+Use --as to run with a specific identity profile managed by git-id;
+identity.default from config.toml is the default.`,
+	Example: `  gh-wtclone
+  gh-wtclone ~/src ~/work --archived
+  gh-wtclone --as work --json`,
+	Args: cobra.ArbitraryArgs,
+	RunE: run,
+}
+
+func init() {
+	rootCmd.Flags().StringVar(&asProfile, "as", "", "Run as identity profile (managed by git-id; default identity.default from config.toml)")
+	rootCmd.Flags().BoolVar(&archived, "archived", false, "Also report archived repositories that aren't cloned")
+	rootCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+	rootCmd.Flags().StringVar(&cloneDir, "clone-dir", "", "Directory to suggest cloning missing repositories into (default the first directory)")
+	rootCmd.Flags().StringArrayVar(&excludes, "exclude", nil, "Skip subdirectories matching this glob (repeatable; default explain.excludes from config.toml)")
+	rootCmd.Flags().BoolVar(&noPager, "no-pager", false, "Don't pipe long output through $PAGER")
+	rootCmd.Flags().StringVar(&themeName, "theme", "", "Color theme: dark, light (default $GIT_THIS_BREAD_THEME, ui.theme from config.toml, or dark)")
+	_ = rootCmd.RegisterFlagCompletionFunc("as", cli.CompleteProfileFlag)
+}
+
+// Command returns the gh-wtclone command
+func Command() *cobra.Command {
+	return rootCmd
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := render.LoadTheme(themeName, cfg.UI.Theme); err != nil {
+		return err
+	}
+	flags := cmd.Flags()
+	if !flags.Changed("as") {
+		asProfile = cfg.Identity.Default
+	}
+	if !flags.Changed("exclude") {
+		excludes = cfg.Explain.Excludes
+	}
+
+	dirs := []string{"."}
+	if len(args) > 0 {
+		dirs = args
+	} else if len(cfg.Explain.Roots) > 0 && !analyzer.IsGitRepo(".") {
+		dirs = cfg.Explain.Roots
+	}
+	for i, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return fmt.Errorf("invalid directory: %w", err)
+		}
+		if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+			return fmt.Errorf("not a directory: %s", abs)
+		}
+		dirs[i] = abs
+	}
+	if cloneDir == "" {
+		cloneDir = dirs[0]
+	}
+
+	ctx := cmd.Context()
+	status("Checking authentication...")
+	client, login, err := connect(ctx, asProfile)
+	if err != nil {
+		status("")
+		return err
+	}
+
+	status("Fetching your repositories...")
+	owned, err := ghapi.GetAll[clones.Repo](ctx, client, "user/repos?affiliation=owner")
+	if err != nil {
+		status("")
+		return err
+	}
+
+	status("Scanning local clones...")
+	opts := analyzer.Options{Timeout: 30 * time.Second, Excludes: excludes}
+	var local []analyzer.RepoInfo
+	for _, dir := range dirs {
+		if analyzer.IsGitRepo(dir) {
+			local = append(local, analyzer.AnalyzeRepo(dir, opts))
+		} else {
+			local = append(local, analyzer.AnalyzeDirectory(dir, opts, false)...)
+		}
+	}
+
+	status("Checking remotes...")
+	lookup := func(owner, name string) (*clones.Repo, error) {
+		var repo clones.Repo
+		// The API follows renames and transfers to the repository's new home
+		err := client.Get(ctx, fmt.Sprintf("repos/%s/%s", owner, name), &repo)
+		if ghapi.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &repo, nil
+	}
+	report, err := clones.Reconcile(local, owned, lookup, clones.Options{Login: login, CloneDir: cloneDir, Archived: archived})
+	status("")
+	if err != nil {
+		return err
+	}
+
+	return render.Page(noPager || jsonOutput, func(w io.Writer) error {
+		if jsonOutput {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		}
+		return writeReport(w, report)
+	})
+}
+
+// connect returns a GitHub client as the GitHub user of profile, or as gh's
+// active account when profile is empty, and the login it acts as
+func connect(ctx context.Context, profile string) (*ghapi.Client, string, error) {
+	user := ""
+	if profile != "" {
+		p, err := identity.Get(profile)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w\nUse 'git-id list' to see available profiles", err)
+		}
+		if p.GHUser == "" {
+			return nil, "", fmt.Errorf("profile '%s' has no GitHub user configured.\nUse: git-id set %s ghuser <username>", profile, profile)
+		}
+		user = p.GHUser
+	}
+	client, err := ghapi.New(user)
+	if err != nil {
+		return nil, "", err
+	}
+	viewer, err := client.Viewer(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, viewer.Login, nil
+}
+
+// status replaces the progress line on stderr; an empty message clears it
+func status(msg string) {
+	fmt.Fprint(os.Stderr, "\r\033[K")
+	if msg != "" {
+		fmt.Fprintf(os.Stderr, "%s %s", render.Style(render.RoleInfo).Render("⠋"), render.Style(render.RoleMuted).Render(msg))
+	}
+}
+
+func writeReport(w io.Writer, report *clones.Report) error {
+	heading := render.Style(render.RoleAccent).Bold(true)
+	dim := render.Style(render.RoleMuted)
+	warn := render.Style(render.RoleWarning)
+	info := render.Style(render.RoleInfo)
+	fix := func(command string) {
+		_, _ = fmt.Fprintf(w, "    %s\n", dim.Render("$ "+command))
+	}
+
+	if len(report.Orphans)+len(report.NotCloned)+len(report.Mismatched) == 0 {
+		_, err := fmt.Fprintln(w, render.Style(render.RoleSuccess).Render("✓ Every clone matches a repository of yours, and every repository is cloned"))
+		return err
+	}
+
+	if len(report.Orphans) > 0 {
+		_, _ = fmt.Fprintln(w, heading.Render(fmt.Sprintf("○ Orphaned clones (%d)", len(report.Orphans))))
+		for _, o := range report.Orphans {
+			if o.Remote == "" {
+				_, _ = fmt.Fprintf(w, "  %s %s  %s\n", warn.Render("✗"), o.Path, dim.Render("no remotes"))
+			} else {
+				_, _ = fmt.Fprintf(w, "  %s %s  %s\n", warn.Render("✗"), o.Path, dim.Render(o.Remote+" → "+o.URL+" no longer exists"))
+			}
+			fix(o.Fix)
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+
+	if len(report.Mismatched) > 0 {
+		_, _ = fmt.Fprintln(w, heading.Render(fmt.Sprintf("○ Mismatched remotes (%d)", len(report.Mismatched))))
+		for _, m := range report.Mismatched {
+			_, _ = fmt.Fprintf(w, "  %s %s  %s\n", warn.Render("≠"), m.Path, dim.Render(m.Remote+" → "+m.URL+" moved to "+m.Want))
+			fix(m.Fix)
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+
+	if len(report.NotCloned) > 0 {
+		_, _ = fmt.Fprintln(w, heading.Render(fmt.Sprintf("○ Not cloned (%d)", len(report.NotCloned))))
+		for _, m := range report.NotCloned {
+			var tags string
+			if m.Fork {
+				tags += " fork"
+			}
+			if m.Archived {
+				tags += " archived"
+			}
+			_, _ = fmt.Fprintf(w, "  %s %s%s\n", info.Render("↓"), m.FullName, dim.Render(tags))
+			fix(m.Fix)
+		}
+	}
+	return nil
+}
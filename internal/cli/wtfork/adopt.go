@@ -0,0 +1,135 @@
+package wtfork
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/config"
+)
+
+// adoptedTopic marks adopted forks on GitHub, for anyone browsing them
+const adoptedTopic = "adopted-fork"
+
+var (
+	adoptTopic bool
+	adoptUndo  bool
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt <owner/name>...",
+	Short: "Mark maintained forks you keep diverged, so syncs stop being suggested",
+	Long: `Mark forks as adopted: your own version, kept diverged from upstream on
+purpose. Adopted forks are pinned in wtfork.adopted of the config file, and
+later runs show them as adopted, leave them out of the sync and delete
+actions of --emit-actions, and tell --llm-advice not to suggest either.
+
+With --topic, also add the adopted-fork topic to them on GitHub. --undo
+takes the mark off again.
+
+A fork stays in its upstream's fork network. To cut it loose for good,
+ask GitHub Support to detach it, or move its history to a new repository;
+adopt prints how.`,
+	Example: `  gh-wtfork adopt me/dotfiles
+  gh-wtfork adopt me/dotfiles me/vim-config --topic
+  gh-wtfork adopt me/dotfiles --undo`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, name := range args {
+			if !validFullName(name) {
+				return fmt.Errorf("give the fork as owner/name, not %q", name)
+			}
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if !cmd.Flags().Changed("as") {
+			asProfile = defaultProfile(&cfg)
+		}
+
+		adopted := markAdopted(cfg.Wtfork.Adopted, args, !adoptUndo)
+		path, err := config.Set("wtfork.adopted", strings.Join(adopted, ","))
+		if err != nil {
+			return err
+		}
+
+		if adoptTopic {
+			if err := setAdoptedTopic(cmd, args, !adoptUndo); err != nil {
+				return err
+			}
+		}
+
+		if adoptUndo {
+			fmt.Printf("No longer adopted: %s (updated %s)\n", strings.Join(args, ", "), path)
+			return nil
+		}
+		fmt.Printf("Adopted %s (pinned in %s). gh-wtfork won't suggest syncing them anymore.\n", strings.Join(args, ", "), path)
+		fmt.Println()
+		fmt.Println("To detach one from its upstream's fork network for good, ask GitHub Support")
+		fmt.Println("(https://support.github.com/contact), or move its history to a new repository:")
+		_, repo, _ := strings.Cut(args[0], "/")
+		fmt.Printf("  git clone --mirror git@github.com:%s.git\n", args[0])
+		fmt.Printf("  cd %s.git\n", repo)
+		fmt.Printf("  gh repo create %s-standalone --private\n", args[0])
+		fmt.Printf("  git push --mirror git@github.com:%s-standalone.git\n", args[0])
+		return nil
+	},
+}
+
+// markAdopted adds forks to the adopted list, or removes them when adopt is
+// false
+func markAdopted(list, forks []string, adopt bool) []string {
+	result := slices.Clone(list)
+	for _, f := range forks {
+		i := slices.Index(result, f)
+		switch {
+		case adopt && i < 0:
+			result = append(result, f)
+		case !adopt && i >= 0:
+			result = slices.Delete(result, i, i+1)
+		}
+	}
+	return result
+}
+
+// validFullName reports whether name looks like owner/name
+func validFullName(name string) bool {
+	owner, repo, ok := strings.Cut(name, "/")
+	return ok && owner != "" && repo != "" && !strings.Contains(repo, "/")
+}
+
+// setAdoptedTopic adds the adopted-fork topic to forks on GitHub, or
+// removes it
+func setAdoptedTopic(cmd *cobra.Command, forks []string, adopt bool) error {
+	client, err := githubClient(asProfile)
+	if err != nil {
+		return err
+	}
+	for _, f := range forks {
+		topics, err := client.Topics(cmd.Context(), f)
+		if err != nil {
+			return fmt.Errorf("reading the topics of %s: %w", f, err)
+		}
+		updated := markAdopted(topics, []string{adoptedTopic}, adopt)
+		if slices.Equal(updated, topics) {
+			continue
+		}
+		if err := client.SetTopics(cmd.Context(), f, updated); err != nil {
+			return fmt.Errorf("setting the topics of %s: %w", f, err)
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(adoptCmd)
+
+	adoptCmd.Flags().BoolVar(&adoptTopic, "topic", false, "Also add the "+adoptedTopic+" topic to the forks on GitHub")
+	adoptCmd.Flags().BoolVar(&adoptUndo, "undo", false, "Take the adopted mark off the forks")
+	adoptCmd.Flags().StringVar(&asProfile, "as", "", "Run as identity profile, for --topic (managed by git-id; default identity.default from config.toml)")
+	_ = adoptCmd.RegisterFlagCompletionFunc("as", cli.CompleteProfileFlag)
+}
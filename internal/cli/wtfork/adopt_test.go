@@ -0,0 +1,30 @@
+package wtfork
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkAdopted(t *testing.T) {
+	tests := []struct {
+		name  string
+		list  []string
+		forks []string
+		adopt bool
+		want  []string
+	}{
+		{"adds", []string{"me/a"}, []string{"me/b"}, true, []string{"me/a", "me/b"}},
+		{"adds to nothing", nil, []string{"me/a"}, true, []string{"me/a"}},
+		{"keeps adopted once", []string{"me/a"}, []string{"me/a", "me/a"}, true, []string{"me/a"}},
+		{"removes", []string{"me/a", "me/b", "me/c"}, []string{"me/b"}, false, []string{"me/a", "me/c"}},
+		{"removes what isn't there", []string{"me/a"}, []string{"me/b"}, false, []string{"me/a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := append([]string(nil), tt.list...)
+			assert.Equal(t, tt.want, markAdopted(list, tt.forks, tt.adopt))
+			assert.Equal(t, tt.list, list, "the list given is left alone")
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package wtfork
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/config"
+	"github.com/jdevera/git-this-bread/internal/ghapi"
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive <owner/name>...",
+	Short: "Archive forks on GitHub instead of deleting them",
+	Long: `Archive forks on GitHub: they become read-only and stay on your profile,
+with their branches and history, instead of being deleted.
+
+To have --emit-actions archive untouched forks rather than delete them,
+use --untouched archive, or set it as the default:
+
+    bread config set wtfork.action_for_untouched archive
+
+Archived forks show an archived mark in later runs and get no actions.
+Undo with: gh repo unarchive <owner/name>`,
+	Example: `  gh-wtfork archive me/old-fork
+  gh-wtfork archive me/a me/b --as work`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, name := range args {
+			if !validFullName(name) {
+				return fmt.Errorf("give the fork as owner/name, not %q", name)
+			}
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if !cmd.Flags().Changed("as") {
+			asProfile = defaultProfile(&cfg)
+		}
+		client, err := githubClient(asProfile)
+		if err != nil {
+			return err
+		}
+
+		failed := 0
+		for _, name := range args {
+			if err := client.Archive(cmd.Context(), name); err != nil {
+				fmt.Printf("%s %s: %v\n", red.Render("✗"), name, err)
+				failed++
+				continue
+			}
+			fmt.Printf("%s archived %s\n", green.Render("✓"), name)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d fork(s) not archived", failed)
+		}
+		return nil
+	},
+}
+
+// githubClient returns a GitHub API client as the GitHub user of profile,
+// or with gh's active account when profile is empty
+func githubClient(profile string) (*ghapi.Client, error) {
+	var user string
+	if profile != "" {
+		p, err := identity.Get(profile)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q not found: %w", profile, err)
+		}
+		user = p.GHUser
+	}
+	return ghapi.New(user)
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+
+	archiveCmd.Flags().StringVar(&asProfile, "as", "", "Run as identity profile (managed by git-id; default identity.default from config.toml)")
+	_ = archiveCmd.RegisterFlagCompletionFunc("as", cli.CompleteProfileFlag)
+}
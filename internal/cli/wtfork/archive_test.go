@@ -0,0 +1,43 @@
+package wtfork
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForkActions_Untouched(t *testing.T) {
+	tests := []struct {
+		name      string
+		fork      Fork
+		untouched string
+		want      []string
+	}{
+		{"delete", Fork{FullName: "me/a", Category: CategoryUntouched}, "delete", []string{"gh", "repo", "delete", "me/a", "--yes"}},
+		{"archive", Fork{FullName: "me/a", Category: CategoryUntouched}, "archive", []string{"gh", "repo", "archive", "me/a", "--yes"}},
+		{"keep", Fork{FullName: "me/a", Category: CategoryUntouched}, "keep", nil},
+		{"archived already", Fork{FullName: "me/a", Category: CategoryUntouched, Archived: true}, "archive", nil},
+		{"adopted", Fork{FullName: "me/a", Category: CategoryUntouched, Adopted: true}, "delete", nil},
+		{"unchanged generated repo", Fork{FullName: "me/a", Category: CategoryGenerated, Unchanged: true}, "archive", []string{"gh", "repo", "archive", "me/a", "--yes"}},
+		{"changed generated repo", Fork{FullName: "me/a", Category: CategoryGenerated}, "archive", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := forkActions([]Fork{tt.fork}, tt.untouched, "")
+			if tt.want == nil {
+				assert.Empty(t, items)
+				return
+			}
+			if assert.Len(t, items, 1) {
+				assert.Equal(t, tt.want, items[0].Args)
+			}
+		})
+	}
+}
+
+func TestValidFullName(t *testing.T) {
+	assert.True(t, validFullName("me/fork"))
+	for _, name := range []string{"fork", "/fork", "me/", "me/fork/extra", ""} {
+		assert.False(t, validFullName(name), name)
+	}
+}
@@ -0,0 +1,202 @@
+package wtfork
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/config"
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/identity"
+	"github.com/jdevera/git-this-bread/internal/paths"
+)
+
+var cloneRoot string
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone <owner/name>...",
+	Short: "Clone forks worth keeping, with their upstream as a remote",
+	Long: `Clone forks from GitHub into --root, ready to work on: each one's
+parent is added as the upstream remote, and fetched.
+
+With a profile (--as, or identity.default), the clone uses its SSH key,
+and so does the clone afterwards: its core.sshCommand, user.email and
+user.name are set to the profile's, so plain git commands there act as it.
+
+Forks already cloned under --root are left alone. To have --emit-actions
+suggest cloning the maintained forks that aren't yet, set the root:
+
+    bread config set wtfork.clone_root ~/src`,
+	Example: `  gh-wtfork clone me/dotfiles --root ~/src
+  gh-wtfork clone me/a me/b --as work`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, name := range args {
+			if !validFullName(name) {
+				return fmt.Errorf("give the fork as owner/name, not %q", name)
+			}
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if !cmd.Flags().Changed("as") {
+			asProfile = defaultProfile(&cfg)
+		}
+		if !cmd.Flags().Changed("root") {
+			cloneRoot = cfg.Wtfork.CloneRoot
+		}
+		if cloneRoot == "" {
+			cloneRoot = "."
+		}
+		root := paths.Expand(cloneRoot)
+
+		var profile *identity.Profile
+		if asProfile != "" {
+			if profile, err = identity.Get(asProfile); err != nil {
+				return fmt.Errorf("profile %q not found: %w", asProfile, err)
+			}
+		}
+		env, err := cloneEnv(profile)
+		if err != nil {
+			return err
+		}
+		client, err := githubClient(asProfile)
+		if err != nil {
+			return err
+		}
+
+		failed := 0
+		for _, name := range args {
+			var repo cloneRepo
+			err := client.Get(cmd.Context(), "repos/"+name, &repo)
+			if err == nil {
+				err = cloneFork(cmd.Context(), &repo, root, profile, env)
+			}
+			if err != nil {
+				fmt.Printf("%s %s: %v\n", red.Render("✗"), name, err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d fork(s) not cloned", failed)
+		}
+		return nil
+	},
+}
+
+// cloneRepo is what cloning a fork needs to know of it
+type cloneRepo struct {
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	SSHURL   string `json:"ssh_url"`
+	Parent   *struct {
+		FullName string `json:"full_name"`
+		SSHURL   string `json:"ssh_url"`
+	} `json:"parent"`
+}
+
+// cloneEnv returns the environment git clones as profile in: with its SSH
+// key, when it has one. A profile with privateemail set must have its
+// noreply address, which the clone will commit with.
+func cloneEnv(profile *identity.Profile) ([]string, error) {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if profile == nil || profile.SSHKey == "" {
+		return env, nil
+	}
+	if err := identity.ValidateSSHKey(profile.SSHKey); err != nil {
+		return nil, err
+	}
+	if err := profile.CheckNotRevoked(); err != nil {
+		return nil, err
+	}
+	if err := profile.CheckPrivateEmail(); err != nil {
+		return nil, err
+	}
+	return append(env, "GIT_SSH_COMMAND="+profile.SSHCommand()), nil
+}
+
+// cloneFork clones repo into root, sets it up to act as profile, when
+// there is one, and adds its parent as the upstream remote
+func cloneFork(ctx context.Context, repo *cloneRepo, root string, profile *identity.Profile, env []string) error {
+	dir := filepath.Join(root, repo.Name)
+	if isCloned(root, repo.Name) {
+		fmt.Printf("%s %s is already at %s\n", dim.Render("○"), repo.FullName, dir)
+		return nil
+	}
+	git := func(dir string, args ...string) error {
+		if out, err := cloneGit(ctx, dir, env, args...); err != nil {
+			return fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(out))
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return err
+	}
+	if err := git(root, "clone", "--quiet", repo.SSHURL, dir); err != nil {
+		return err
+	}
+	if profile != nil {
+		var settings [][2]string
+		if profile.SSHKey != "" {
+			settings = append(settings, [2]string{"core.sshCommand", profile.SSHCommand()})
+		}
+		if profile.Email != "" {
+			settings = append(settings, [2]string{"user.email", profile.Email})
+		}
+		if name := profile.CommitName(); name != "" {
+			settings = append(settings, [2]string{"user.name", name})
+		}
+		for _, s := range settings {
+			if err := git(dir, "config", "--local", s[0], s[1]); err != nil {
+				return err
+			}
+		}
+	}
+	fmt.Printf("%s cloned %s into %s\n", green.Render("✓"), repo.FullName, dir)
+
+	if repo.Parent == nil {
+		fmt.Printf("  %s\n", dim.Render("not a fork: no upstream to add"))
+		return nil
+	}
+	if err := git(dir, "remote", "add", "upstream", repo.Parent.SSHURL); err != nil {
+		return err
+	}
+	if err := git(dir, "fetch", "--quiet", "upstream"); err != nil {
+		return err
+	}
+	fmt.Printf("  upstream: %s\n", repo.Parent.FullName)
+	return nil
+}
+
+// cloneGit runs git with args in dir and env, and returns its output and
+// errors; replaced in tests
+var cloneGit = func(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir, cmd.Env = dir, env
+	out, err := debuglog.CombinedOutput(cmd)
+	return string(out), err
+}
+
+// isCloned reports whether root, which may start with ~, already has a
+// directory for the fork name
+func isCloned(root, name string) bool {
+	_, err := os.Stat(filepath.Join(paths.Expand(root), name))
+	return err == nil
+}
+
+func init() {
+	rootCmd.AddCommand(cloneCmd)
+
+	cloneCmd.Flags().StringVar(&cloneRoot, "root", "", "Directory to clone into (default wtfork.clone_root from config.toml, or the current one)")
+	cloneCmd.Flags().StringVar(&asProfile, "as", "", "Clone as identity profile, with its SSH key (managed by git-id; default identity.default from config.toml)")
+	_ = cloneCmd.MarkFlagDirname("root")
+	_ = cloneCmd.RegisterFlagCompletionFunc("as", cli.CompleteProfileFlag)
+}
@@ -0,0 +1,122 @@
+package wtfork
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/identity"
+	"github.com/jdevera/git-this-bread/testutil"
+)
+
+// fakeCloneGit runs the git of cloneFork through fake, recording the
+// environment of the last command
+func fakeCloneGit(t *testing.T, fake *testutil.FakeGit) *[]string {
+	t.Helper()
+	var env []string
+	orig := cloneGit
+	cloneGit = func(ctx context.Context, dir string, e []string, args ...string) (string, error) {
+		env = e
+		return fake.Run(ctx, dir, args...)
+	}
+	t.Cleanup(func() { cloneGit = orig })
+	return &env
+}
+
+func forkToClone() *cloneRepo {
+	repo := &cloneRepo{Name: "tool", FullName: "me/tool", SSHURL: "git@github.com:me/tool.git"}
+	repo.Parent = &struct {
+		FullName string `json:"full_name"`
+		SSHURL   string `json:"ssh_url"`
+	}{FullName: "org/tool", SSHURL: "git@github.com:org/tool.git"}
+	return repo
+}
+
+func TestCloneFork(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "tool")
+	fake := testutil.NewFakeGit().
+		On("clone --quiet git@github.com:me/tool.git "+dir, "").
+		On("remote add upstream git@github.com:org/tool.git", "").
+		On("fetch --quiet upstream", "")
+	fakeCloneGit(t, fake)
+
+	out := testutil.CaptureStdout(func() {
+		require.NoError(t, cloneFork(context.Background(), forkToClone(), root, nil, nil))
+	})
+	assert.Equal(t, []string{
+		"clone --quiet git@github.com:me/tool.git " + dir,
+		"remote add upstream git@github.com:org/tool.git",
+		"fetch --quiet upstream",
+	}, fake.Calls())
+	assert.Contains(t, out, "cloned me/tool into "+dir)
+	assert.Contains(t, out, "upstream: org/tool")
+}
+
+func TestCloneFork_AsProfile(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "tool")
+	profile := &identity.Profile{Name: "work", SSHKey: "/keys/id_work", Email: "me@work.example", DisplayName: "Me Work"}
+	fake := testutil.NewFakeGit().
+		On("clone --quiet git@github.com:me/tool.git "+dir, "").
+		On("config --local core.sshCommand "+profile.SSHCommand(), "").
+		On("config --local user.email me@work.example", "").
+		On("config --local user.name Me Work", "").
+		On("remote add upstream git@github.com:org/tool.git", "").
+		On("fetch --quiet upstream", "")
+	env := fakeCloneGit(t, fake)
+
+	testutil.CaptureStdout(func() {
+		require.NoError(t, cloneFork(context.Background(), forkToClone(), root, profile, []string{"GIT_SSH_COMMAND=" + profile.SSHCommand()}))
+	})
+	assert.Equal(t, []string{
+		"clone --quiet git@github.com:me/tool.git " + dir,
+		"config --local core.sshCommand " + profile.SSHCommand(),
+		"config --local user.email me@work.example",
+		"config --local user.name Me Work",
+		"remote add upstream git@github.com:org/tool.git",
+		"fetch --quiet upstream",
+	}, fake.Calls())
+	assert.Contains(t, *env, "GIT_SSH_COMMAND="+profile.SSHCommand(), "git runs with the profile's key")
+}
+
+func TestCloneFork_NotAFork(t *testing.T) {
+	root := t.TempDir()
+	repo := forkToClone()
+	repo.Parent = nil
+	fake := testutil.NewFakeGit().On("clone --quiet git@github.com:me/tool.git "+filepath.Join(root, "tool"), "")
+	fakeCloneGit(t, fake)
+
+	out := testutil.CaptureStdout(func() {
+		require.NoError(t, cloneFork(context.Background(), repo, root, nil, nil))
+	})
+	assert.Len(t, fake.Calls(), 1, "no upstream to add")
+	assert.Contains(t, out, "not a fork")
+}
+
+func TestCloneFork_AlreadyCloned(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, "tool"), 0o750))
+	fake := testutil.NewFakeGit()
+	fakeCloneGit(t, fake)
+
+	out := testutil.CaptureStdout(func() {
+		require.NoError(t, cloneFork(context.Background(), forkToClone(), root, nil, nil))
+	})
+	assert.Empty(t, fake.Calls())
+	assert.Contains(t, out, "me/tool is already at")
+}
+
+func TestCloneFork_CloneFails(t *testing.T) {
+	root := t.TempDir()
+	fake := testutil.NewFakeGit().Fail("clone --quiet git@github.com:me/tool.git "+filepath.Join(root, "tool"), 128)
+	fakeCloneGit(t, fake)
+
+	err := cloneFork(context.Background(), forkToClone(), root, nil, nil)
+	assert.ErrorContains(t, err, "git clone: exit status 128")
+	assert.Len(t, fake.Calls(), 1, "nothing runs after a failed clone")
+}
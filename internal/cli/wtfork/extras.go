@@ -0,0 +1,195 @@
+package wtfork
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jdevera/git-this-bread/internal/forge"
+	"github.com/jdevera/git-this-bread/internal/render"
+)
+
+// Categories of what --include adds to the forks
+const (
+	CategoryGenerated = "generated" // Generated from a template
+	CategoryGist      = "gist"
+)
+
+// includeKinds are what --include can add to the forks
+var includeKinds = []string{"gists", "templates"}
+
+// Staleness of what --include adds. A generated repo is stale after a year
+// without a push. Gists are snippets, meant to sit still, so they are only
+// stale after two years without a change.
+const (
+	generatedStaleAfter = 365 * 24 * time.Hour
+	gistStaleAfter      = 2 * 365 * 24 * time.Hour
+)
+
+// generatedWithin is how long after its creation a push still counts as
+// generating the repo: GitHub pushes the template's files as it creates it
+const generatedWithin = 10 * time.Minute
+
+// checkInclude validates the kinds given to --include
+func checkInclude(kinds []string) error {
+	for _, kind := range kinds {
+		if !slices.Contains(includeKinds, kind) {
+			return fmt.Errorf("invalid --include %q: must be %s", kind, strings.Join(includeKinds, ", "))
+		}
+	}
+	return nil
+}
+
+// listExtras returns the generated repos and gists kinds asks for,
+// categorized, leaving out those excluded
+func listExtras(ctx context.Context, fg forge.Forge, kinds []string, now time.Time) ([]Fork, error) {
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+	extras, ok := fg.(forge.Extras)
+	if !ok {
+		return nil, fmt.Errorf("--include only works on GitHub, not %s", fg.Spec())
+	}
+
+	var results []Fork
+	if slices.Contains(kinds, "templates") {
+		repos, err := extras.ListGenerated(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repos generated from templates: %w", err)
+		}
+		for i := range repos {
+			results = append(results, generatedRepo(&repos[i], now))
+		}
+	}
+	if slices.Contains(kinds, "gists") {
+		gists, err := extras.ListGists(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list gists: %w", err)
+		}
+		for i := range gists {
+			results = append(results, gistEntry(&gists[i], now))
+		}
+	}
+
+	kept := results[:0]
+	for i := range results {
+		if !excluded(results[i].Name, results[i].FullName, excludes) {
+			kept = append(kept, results[i])
+		}
+	}
+	return kept, nil
+}
+
+// generatedRepo categorizes a repo generated from a template: unchanged
+// when nothing was pushed after generating it, stale when nothing was for
+// a year
+func generatedRepo(repo *forge.Repo, now time.Time) Fork {
+	f := Fork{
+		Name:           repo.Name,
+		FullName:       repo.FullName,
+		URL:            repo.URL,
+		DefaultBranch:  repo.DefaultBranch,
+		Category:       CategoryGenerated,
+		Archived:       repo.Archived,
+		ForkLastCommit: formatDate(repo.Pushed),
+		ForkLastAgo:    relativeTime(repo.Pushed),
+	}
+	if repo.Template != nil {
+		f.ParentName = repo.Template.Name
+		f.ParentFullName = repo.Template.FullName
+		f.ParentURL = repo.Template.URL
+	}
+	created, errCreated := time.Parse(time.RFC3339, repo.Created)
+	pushed, errPushed := time.Parse(time.RFC3339, repo.Pushed)
+	if errCreated == nil && errPushed == nil {
+		f.Unchanged = pushed.Sub(created) < generatedWithin
+	}
+	if errPushed == nil {
+		f.Stale = now.Sub(pushed) > generatedStaleAfter
+	}
+	return f
+}
+
+// gistEntry categorizes a gist, named after its first file as GitHub does:
+// stale when it hasn't changed for two years
+func gistEntry(g *forge.Gist, now time.Time) Fork {
+	name := g.ID
+	if len(g.Files) > 0 {
+		name = g.Files[0]
+	}
+	f := Fork{
+		Name:           name,
+		FullName:       "gist:" + g.ID,
+		URL:            g.URL,
+		Category:       CategoryGist,
+		Description:    g.Description,
+		Files:          len(g.Files),
+		Secret:         !g.Public,
+		ForkLastCommit: formatDate(g.Updated),
+		ForkLastAgo:    relativeTime(g.Updated),
+	}
+	if updated, err := time.Parse(time.RFC3339, g.Updated); err == nil {
+		f.Stale = now.Sub(updated) > gistStaleAfter
+	}
+	return f
+}
+
+// isExtra reports whether f came from --include rather than being a fork
+func isExtra(f *Fork) bool {
+	return f.Category == CategoryGenerated || f.Category == CategoryGist
+}
+
+// writeExtra writes a generated repo or a gist for printResults
+func writeExtra(buf *strings.Builder, f *Fork) {
+	style, icon, title := cyan, icons["template"], f.FullName
+	if f.Category == CategoryGist {
+		icon, title = icons["gist"], f.Name
+	}
+	if f.Stale || f.Unchanged {
+		style = dim
+	}
+	fmt.Fprintf(buf, "%s %s\n", style.Render(icon), render.Link(style.Render(title), f.URL))
+
+	switch f.Category {
+	case CategoryGenerated:
+		marks := ""
+		if f.Adopted {
+			marks += " · adopted, kept"
+		}
+		if f.Archived {
+			marks += " · " + icons["archived"] + " archived"
+		}
+		if f.ParentFullName != "" {
+			fmt.Fprintf(buf, "    %s %s%s\n", dim.Render(icons["upstream"]),
+				render.Link(dim.Render("template "+f.ParentFullName), f.ParentURL), dim.Render(marks))
+		}
+	case CategoryGist:
+		var parts []string
+		if f.Description != "" {
+			parts = append(parts, render.Truncate(f.Description, 60))
+		}
+		parts = append(parts, fmt.Sprintf("%d file(s)", f.Files))
+		if f.Secret {
+			parts = append(parts, "secret")
+		}
+		fmt.Fprintf(buf, "    %s\n", dim.Render(strings.Join(parts, " · ")))
+	}
+
+	verb := "last push"
+	if f.Category == CategoryGist {
+		verb = "last change"
+	}
+	switch {
+	case f.Unchanged:
+		fmt.Fprintf(buf, "    %s %s\n", yellow.Render(icons["warning"]),
+			yellow.Render(fmt.Sprintf("unchanged since generated %s - can probably delete", f.ForkLastAgo)))
+	case f.Stale:
+		fmt.Fprintf(buf, "    %s %s\n", yellow.Render(icons["warning"]),
+			yellow.Render(fmt.Sprintf("stale, %s %s", verb, f.ForkLastAgo)))
+	case f.ForkLastAgo != "":
+		fmt.Fprintf(buf, "    %s %s\n", green.Render(icons["sync"]), green.Render(verb+" "+f.ForkLastAgo))
+	}
+	fmt.Fprintln(buf)
+}
@@ -0,0 +1,206 @@
+package wtfork
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/forge"
+)
+
+// fakeForge is a forge whose listings are set by the test. Only the
+// listing methods the tests call do anything.
+type fakeForge struct {
+	spec      forge.Spec
+	forks     []forge.Repo
+	generated []forge.Repo
+	gists     []forge.Gist
+	err       error
+}
+
+func (f *fakeForge) Spec() forge.Spec                                { return f.spec }
+func (f *fakeForge) AuthStatus(context.Context) (string, error)      { return f.spec.User, nil }
+func (f *fakeForge) ListForks(context.Context) ([]forge.Repo, error) { return f.forks, f.err }
+func (f *fakeForge) Compare(context.Context, *forge.Repo, string) (int, int, error) {
+	return 0, 0, nil
+}
+func (f *fakeForge) LastCommitDate(context.Context, *forge.Repo, string) (string, error) {
+	return "", nil
+}
+func (f *fakeForge) ListBranches(context.Context, *forge.Repo) ([]forge.Branch, error) {
+	return nil, nil
+}
+func (f *fakeForge) ListPRs(context.Context, *forge.Repo) ([]forge.PR, error)       { return nil, nil }
+func (f *fakeForge) ListIssues(context.Context, *forge.Repo) ([]forge.Issue, error) { return nil, nil }
+
+// fakeGitHub is a fakeForge that also lists generated repos and gists
+type fakeGitHub struct{ fakeForge }
+
+func (f *fakeGitHub) ListGenerated(context.Context) ([]forge.Repo, error) { return f.generated, f.err }
+func (f *fakeGitHub) ListGists(context.Context) ([]forge.Gist, error)     { return f.gists, f.err }
+
+var extrasNow = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// ago returns the RFC 3339 date d before extrasNow
+func ago(d time.Duration) string {
+	return extrasNow.Add(-d).Format(time.RFC3339)
+}
+
+func TestCheckInclude(t *testing.T) {
+	assert.NoError(t, checkInclude(nil))
+	assert.NoError(t, checkInclude([]string{"gists", "templates"}))
+	assert.ErrorContains(t, checkInclude([]string{"gists", "stars"}), `invalid --include "stars": must be gists, templates`)
+}
+
+func TestGeneratedRepo(t *testing.T) {
+	const day = 24 * time.Hour
+	tests := []struct {
+		name          string
+		created       string
+		pushed        string
+		wantUnchanged bool
+		wantStale     bool
+	}{
+		{"pushed only while generated", ago(400 * day), ago(400*day - 2*time.Minute), true, true},
+		{"generated recently, untouched", ago(3 * day), ago(3*day - time.Minute), true, false},
+		{"worked on after generating", ago(400 * day), ago(30 * day), false, false},
+		{"worked on, then left for over a year", ago(800 * day), ago(366 * day), false, true},
+		{"just under a year", ago(800 * day), ago(364 * day), false, false},
+		{"unknown dates", "", "", false, false},
+		{"unknown creation", "", ago(400 * day), false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := generatedRepo(&forge.Repo{Name: "app", FullName: "me/app", Created: tt.created, Pushed: tt.pushed}, extrasNow)
+			assert.Equal(t, CategoryGenerated, f.Category)
+			assert.Equal(t, tt.wantUnchanged, f.Unchanged, "unchanged")
+			assert.Equal(t, tt.wantStale, f.Stale, "stale")
+		})
+	}
+
+	f := generatedRepo(&forge.Repo{
+		Name: "app", FullName: "me/app", Archived: true, Pushed: ago(time.Hour),
+		Template: &forge.Repo{Name: "tpl", FullName: "org/tpl", URL: "https://github.com/org/tpl"},
+	}, extrasNow)
+	assert.Equal(t, "org/tpl", f.ParentFullName, "the template stands in for the parent")
+	assert.Equal(t, "https://github.com/org/tpl", f.ParentURL)
+	assert.True(t, f.Archived)
+	assert.Equal(t, extrasNow.Format("2006-01-02"), f.ForkLastCommit)
+}
+
+func TestGistEntry(t *testing.T) {
+	const day = 24 * time.Hour
+	tests := []struct {
+		name      string
+		gist      forge.Gist
+		wantName  string
+		wantStale bool
+	}{
+		{"named after its first file", forge.Gist{ID: "abc", Files: []string{"a.sh", "b.sh"}, Updated: ago(30 * day)}, "a.sh", false},
+		{"no files", forge.Gist{ID: "abc", Updated: ago(30 * day)}, "abc", false},
+		{"untouched for a year and a half", forge.Gist{ID: "abc", Updated: ago(550 * day)}, "abc", false},
+		{"untouched for over two years", forge.Gist{ID: "abc", Updated: ago(731 * day)}, "abc", true},
+		{"unknown date", forge.Gist{ID: "abc"}, "abc", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := gistEntry(&tt.gist, extrasNow)
+			assert.Equal(t, CategoryGist, f.Category)
+			assert.Equal(t, tt.wantName, f.Name)
+			assert.Equal(t, "gist:abc", f.FullName)
+			assert.Equal(t, len(tt.gist.Files), f.Files)
+			assert.Equal(t, tt.wantStale, f.Stale)
+		})
+	}
+
+	assert.True(t, gistEntry(&forge.Gist{ID: "abc"}, extrasNow).Secret)
+	assert.False(t, gistEntry(&forge.Gist{ID: "abc", Public: true}, extrasNow).Secret)
+}
+
+func TestListExtras(t *testing.T) {
+	gh := &fakeGitHub{fakeForge{
+		generated: []forge.Repo{
+			{Name: "app", FullName: "me/app", Pushed: ago(time.Hour)},
+			{Name: "scratch", FullName: "me/scratch", Pushed: ago(time.Hour)},
+		},
+		gists: []forge.Gist{{ID: "g1", Files: []string{"notes.md"}}},
+	}}
+	names := func(forks []Fork) []string {
+		var names []string
+		for i := range forks {
+			names = append(names, forks[i].FullName)
+		}
+		return names
+	}
+	ctx := context.Background()
+
+	got, err := listExtras(ctx, gh, nil, extrasNow)
+	require.NoError(t, err)
+	assert.Empty(t, got, "nothing to include")
+
+	got, err = listExtras(ctx, gh, []string{"templates"}, extrasNow)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"me/app", "me/scratch"}, names(got))
+
+	got, err = listExtras(ctx, gh, []string{"gists"}, extrasNow)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gist:g1"}, names(got))
+
+	orig := excludes
+	excludes = []string{"scratch", "notes.*"}
+	t.Cleanup(func() { excludes = orig })
+	got, err = listExtras(ctx, gh, []string{"gists", "templates"}, extrasNow)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"me/app"}, names(got), "excludes apply to names and gist files")
+
+	gh.err = errors.New("rate limited")
+	_, err = listExtras(ctx, gh, []string{"templates"}, extrasNow)
+	assert.ErrorContains(t, err, "failed to list repos generated from templates: rate limited")
+
+	gitlab := &fakeForge{spec: forge.Spec{Kind: forge.GitLab, Host: "gitlab.com"}}
+	_, err = listExtras(ctx, gitlab, []string{"gists"}, extrasNow)
+	assert.ErrorContains(t, err, "--include only works on GitHub")
+}
+
+func TestWriteExtra(t *testing.T) {
+	tests := []struct {
+		name string
+		fork Fork
+		want []string
+	}{
+		{
+			name: "unchanged generated repo",
+			fork: Fork{FullName: "me/app", Category: CategoryGenerated, ParentFullName: "org/tpl", Unchanged: true, ForkLastAgo: "3 days ago"},
+			want: []string{"me/app", "template org/tpl", "unchanged since generated 3 days ago - can probably delete"},
+		},
+		{
+			name: "stale generated repo",
+			fork: Fork{FullName: "me/app", Category: CategoryGenerated, Stale: true, Archived: true, ParentFullName: "org/tpl", ForkLastAgo: "2 years ago"},
+			want: []string{"archived", "stale, last push 2 years ago"},
+		},
+		{
+			name: "active gist",
+			fork: Fork{Name: "notes.md", FullName: "gist:g1", Category: CategoryGist, Description: "Notes", Files: 2, Secret: true, ForkLastAgo: "1 week ago"},
+			want: []string{"notes.md", "Notes · 2 file(s) · secret", "last change 1 week ago"},
+		},
+		{
+			name: "stale gist",
+			fork: Fork{Name: "notes.md", FullName: "gist:g1", Category: CategoryGist, Files: 1, Stale: true, ForkLastAgo: "3 years ago"},
+			want: []string{"1 file(s)", "stale, last change 3 years ago"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			writeExtra(&buf, &tt.fork)
+			for _, want := range tt.want {
+				assert.Contains(t, buf.String(), want)
+			}
+		})
+	}
+}
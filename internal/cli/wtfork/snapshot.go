@@ -0,0 +1,207 @@
+package wtfork
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jdevera/git-this-bread/internal/forge"
+	"github.com/jdevera/git-this-bread/internal/paths"
+	"github.com/jdevera/git-this-bread/internal/render"
+)
+
+// Snapshot is the categorized result of one run, saved by --snapshot for
+// --diff-last to compare later runs with
+type Snapshot struct {
+	Time  time.Time `json:"time"`
+	Forge string    `json:"forge"`          // forge.Spec, as ParseSpec reads it
+	User  string    `json:"user,omitempty"` // Account triaged; empty for the default credentials
+	Forks []Fork    `json:"forks"`
+}
+
+// SnapshotDiff is what changed between two snapshots
+type SnapshotDiff struct {
+	Since     time.Time        `json:"since"`
+	New       []string         `json:"new,omitempty"`       // Forks created since
+	Gone      []string         `json:"gone,omitempty"`      // Forks deleted since
+	Merged    []MergedPR       `json:"merged,omitempty"`    // PRs merged since
+	Deletable []string         `json:"deletable,omitempty"` // Forks that became safe to delete
+	Moved     []CategoryChange `json:"moved,omitempty"`     // Forks that changed category
+}
+
+// MergedPR is a PR of a fork that was merged upstream
+type MergedPR struct {
+	Fork string `json:"fork"`
+	PR   PR     `json:"pr"`
+}
+
+// CategoryChange is a fork that moved from one category to another
+type CategoryChange struct {
+	Fork string `json:"fork"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Empty reports whether nothing changed
+func (d *SnapshotDiff) Empty() bool {
+	return len(d.New)+len(d.Gone)+len(d.Merged)+len(d.Deletable)+len(d.Moved) == 0
+}
+
+// snapshotDir returns the directory of the snapshots taken of spec's forks
+func snapshotDir(spec forge.Spec) (string, error) {
+	stateHome, err := paths.StateHome()
+	if err != nil {
+		return "", err
+	}
+	name := strings.ReplaceAll(spec.String(), ":", "_")
+	if spec.User != "" {
+		name += "_" + spec.User
+	}
+	return filepath.Join(stateHome, "git-this-bread", "gh-wtfork", "snapshots", name), nil
+}
+
+// saveSnapshot writes the forks as a new snapshot, one file per run, and
+// returns its path
+func saveSnapshot(spec forge.Spec, forks []Fork, now time.Time) (string, error) {
+	dir, err := snapshotDir(spec)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", err
+	}
+	snap := Snapshot{Time: now.UTC(), Forge: spec.String(), User: spec.User, Forks: forks}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, now.UTC().Format("20060102T150405Z")+".json")
+	return path, os.WriteFile(path, data, 0o600)
+}
+
+// lastSnapshot returns the latest snapshot of spec's forks, or nil when
+// none was taken
+func lastSnapshot(spec forge.Spec) (*Snapshot, error) {
+	dir, err := snapshotDir(spec)
+	if err != nil {
+		return nil, err
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(names) == 0 {
+		return nil, err
+	}
+	slices.Sort(names) // Timestamped names sort by time
+
+	last := names[len(names)-1]
+	data, err := os.ReadFile(last) //nolint:gosec // last is a file of the snapshot directory
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("reading snapshot %s: %w", last, err)
+	}
+	return &snap, nil
+}
+
+// diffSnapshot compares the forks of a run with an earlier snapshot
+func diffSnapshot(prev *Snapshot, forks []Fork) SnapshotDiff {
+	diff := SnapshotDiff{Since: prev.Time}
+	before := make(map[string]*Fork, len(prev.Forks))
+	for i := range prev.Forks {
+		before[prev.Forks[i].FullName] = &prev.Forks[i]
+	}
+
+	seen := make(map[string]bool, len(forks))
+	for i := range forks {
+		f := &forks[i]
+		seen[f.FullName] = true
+		old, ok := before[f.FullName]
+		if !ok {
+			diff.New = append(diff.New, f.FullName)
+		}
+		if safeToDelete(f) && (!ok || !safeToDelete(old)) {
+			diff.Deletable = append(diff.Deletable, f.FullName)
+		}
+		if !ok {
+			continue
+		}
+		if old.Category != f.Category {
+			diff.Moved = append(diff.Moved, CategoryChange{Fork: f.FullName, From: old.Category, To: f.Category})
+		}
+		merged := make(map[int]bool)
+		for _, b := range old.Branches {
+			if b.PR != nil && b.PR.State == PRStateMerged {
+				merged[b.PR.Number] = true
+			}
+		}
+		for _, b := range f.Branches {
+			if b.PR != nil && b.PR.State == PRStateMerged && !merged[b.PR.Number] {
+				diff.Merged = append(diff.Merged, MergedPR{Fork: f.FullName, PR: *b.PR})
+			}
+		}
+	}
+	for i := range prev.Forks {
+		if !seen[prev.Forks[i].FullName] {
+			diff.Gone = append(diff.Gone, prev.Forks[i].FullName)
+		}
+	}
+	slices.Sort(diff.New)
+	slices.Sort(diff.Gone)
+	slices.Sort(diff.Deletable)
+	return diff
+}
+
+// safeToDelete reports whether nothing would be lost deleting the fork:
+// it is untouched, or everything it is ahead by was applied upstream. A
+// generated repo is when it is unchanged since generated.
+func safeToDelete(f *Fork) bool {
+	if f.Adopted || f.Archived {
+		return false
+	}
+	return f.Category == CategoryUntouched || f.Unchanged ||
+		f.Ahead > 0 && f.Upstreamed >= f.Ahead && !hasOpenPR(f)
+}
+
+// printDiff writes what changed since the last snapshot
+func printDiff(w io.Writer, d *SnapshotDiff) error {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%s\n\n", greenBold.Render("Changes since "+d.Since.Local().Format("2006-01-02 15:04")))
+	if d.Empty() {
+		fmt.Fprintf(&buf, "  %s\n", dim.Render("Nothing changed."))
+		return flush(w, &buf)
+	}
+
+	section := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		fmt.Fprintf(&buf, "%s\n", cyan.Render(title))
+		for _, l := range lines {
+			fmt.Fprintf(&buf, "  %s\n", l)
+		}
+		fmt.Fprintln(&buf)
+	}
+
+	section(icons["fork"]+" New forks", d.New)
+	section(icons["closed"]+" Gone", d.Gone)
+
+	var merged []string
+	for _, m := range d.Merged {
+		merged = append(merged, fmt.Sprintf("%s %s %s", m.Fork, render.Link(fmt.Sprintf("#%d", m.PR.Number), m.PR.URL), m.PR.Title))
+	}
+	section(icons["merged"]+" PRs merged", merged)
+	section(icons["check"]+" Now safe to delete", d.Deletable)
+
+	var moved []string
+	for _, c := range d.Moved {
+		moved = append(moved, fmt.Sprintf("%s %s", c.Fork, dim.Render(c.From+" → "+c.To)))
+	}
+	section(icons["sync"]+" Changed category", moved)
+	return flush(w, &buf)
+}
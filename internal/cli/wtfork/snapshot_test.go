@@ -0,0 +1,97 @@
+package wtfork
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSafeToDelete(t *testing.T) {
+	openPR := []Branch{{Name: "fix", PR: &PR{Number: 1, State: PRStateOpen}}}
+	tests := []struct {
+		name string
+		fork Fork
+		want bool
+	}{
+		{"untouched", Fork{Category: CategoryUntouched}, true},
+		{"unchanged generated repo", Fork{Category: CategoryGenerated, Unchanged: true}, true},
+		{"all upstreamed", Fork{Category: CategoryMaintained, Ahead: 3, Upstreamed: 3}, true},
+		{"some upstreamed", Fork{Category: CategoryMaintained, Ahead: 3, Upstreamed: 2}, false},
+		{"upstreamed with an open PR", Fork{Category: CategoryMaintained, Ahead: 1, Upstreamed: 1, Branches: openPR}, false},
+		{"contribution", Fork{Category: CategoryContribution, Branches: openPR}, false},
+		{"adopted", Fork{Category: CategoryUntouched, Adopted: true}, false},
+		{"archived", Fork{Category: CategoryUntouched, Archived: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, safeToDelete(&tt.fork))
+		})
+	}
+}
+
+func TestDiffSnapshot(t *testing.T) {
+	since := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	merged := func(n int) []Branch {
+		return []Branch{{Name: "fix", PR: &PR{Number: n, State: PRStateMerged}}}
+	}
+	tests := []struct {
+		name   string
+		before []Fork
+		after  []Fork
+		want   SnapshotDiff
+	}{
+		{
+			name:   "nothing changed",
+			before: []Fork{{FullName: "me/a", Category: CategoryMaintained, Ahead: 1}},
+			after:  []Fork{{FullName: "me/a", Category: CategoryMaintained, Ahead: 1}},
+			want:   SnapshotDiff{Since: since},
+		},
+		{
+			name:   "new and gone",
+			before: []Fork{{FullName: "me/b", Category: CategoryMaintained}, {FullName: "me/a", Category: CategoryMaintained}},
+			after:  []Fork{{FullName: "me/d", Category: CategoryMaintained}, {FullName: "me/c", Category: CategoryContribution}},
+			want:   SnapshotDiff{Since: since, New: []string{"me/c", "me/d"}, Gone: []string{"me/a", "me/b"}},
+		},
+		{
+			name:  "new and deletable",
+			after: []Fork{{FullName: "me/a", Category: CategoryUntouched}},
+			want:  SnapshotDiff{Since: since, New: []string{"me/a"}, Deletable: []string{"me/a"}},
+		},
+		{
+			name:   "became deletable",
+			before: []Fork{{FullName: "me/a", Category: CategoryMaintained, Ahead: 2, Upstreamed: 1}},
+			after:  []Fork{{FullName: "me/a", Category: CategoryMaintained, Ahead: 2, Upstreamed: 2}},
+			want:   SnapshotDiff{Since: since, Deletable: []string{"me/a"}},
+		},
+		{
+			name:   "deletable already",
+			before: []Fork{{FullName: "me/a", Category: CategoryUntouched}},
+			after:  []Fork{{FullName: "me/a", Category: CategoryUntouched}},
+			want:   SnapshotDiff{Since: since},
+		},
+		{
+			name:   "moved",
+			before: []Fork{{FullName: "me/a", Category: CategoryContribution}},
+			after:  []Fork{{FullName: "me/a", Category: CategoryMaintained, Ahead: 1}},
+			want: SnapshotDiff{Since: since, Moved: []CategoryChange{
+				{Fork: "me/a", From: CategoryContribution, To: CategoryMaintained},
+			}},
+		},
+		{
+			name:   "merged since",
+			before: []Fork{{FullName: "me/a", Category: CategoryContribution, Branches: merged(1)}},
+			after:  []Fork{{FullName: "me/a", Category: CategoryContribution, Branches: append(merged(1), merged(2)...)}},
+			want: SnapshotDiff{Since: since, Merged: []MergedPR{
+				{Fork: "me/a", PR: PR{Number: 2, State: PRStateMerged}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffSnapshot(&Snapshot{Time: since, Forks: tt.before}, tt.after)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want.Empty(), got.Empty())
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package wtfork
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/forge"
+	"github.com/jdevera/git-this-bread/internal/render"
+)
+
+// Upstream sums up your work on one upstream project, across your forks of
+// it, for --by-upstream
+type Upstream struct {
+	FullName   string   `json:"full_name"`
+	URL        string   `json:"html_url,omitempty"`
+	Forks      []string `json:"forks"`    // Full names of your forks of it
+	Categories []string `json:"-"`        // Of each fork, for the text output
+	Branches   int      `json:"branches"` // Non-default branches across the forks
+	Ahead      int      `json:"ahead"`    // Commits the forks' default branches have that it doesn't
+	OpenPRs    int      `json:"open_prs"`
+	MergedPRs  int      `json:"merged_prs"`
+	ClosedPRs  int      `json:"closed_prs"`
+	OpenIssues int      `json:"open_issues"` // Issues and discussions you opened there
+	Issues     int      `json:"issues"`
+}
+
+// byUpstream groups forks by the project they were forked from, most
+// forks first, then by name. Repos generated from templates and gists
+// aren't forks, so they are left out.
+func byUpstream(forks []Fork) []Upstream {
+	index := make(map[string]int)
+	var ups []Upstream
+	for i := range forks {
+		f := &forks[i]
+		if isExtra(f) || f.ParentFullName == "" {
+			continue
+		}
+		at, ok := index[f.ParentFullName]
+		if !ok {
+			at = len(ups)
+			index[f.ParentFullName] = at
+			ups = append(ups, Upstream{FullName: f.ParentFullName, URL: f.ParentURL})
+		}
+		u := &ups[at]
+		u.Forks = append(u.Forks, f.FullName)
+		u.Categories = append(u.Categories, f.Category)
+		u.Ahead += f.Ahead
+		for _, b := range f.Branches {
+			if b.IsDefault {
+				continue
+			}
+			u.Branches++
+			if b.PR == nil {
+				continue
+			}
+			switch b.PR.State {
+			case PRStateOpen:
+				u.OpenPRs++
+			case PRStateMerged:
+				u.MergedPRs++
+			case PRStateClosed:
+				u.ClosedPRs++
+			}
+		}
+		u.Issues += len(f.Issues)
+		for _, issue := range f.Issues {
+			if issue.State == forge.IssueOpen {
+				u.OpenIssues++
+			}
+		}
+	}
+	sort.SliceStable(ups, func(i, j int) bool {
+		if len(ups[i].Forks) != len(ups[j].Forks) {
+			return len(ups[i].Forks) > len(ups[j].Forks)
+		}
+		return ups[i].FullName < ups[j].FullName
+	})
+	return ups
+}
+
+// Summary says what the upstream's numbers are, as in "1 fork, 4 branches,
+// 2 merged PRs, 1 open"
+func (u *Upstream) Summary() string {
+	parts := []string{count(len(u.Forks), "fork")}
+	if u.Ahead > 0 {
+		parts = append(parts, fmt.Sprintf("%d ahead", u.Ahead))
+	}
+	if u.Branches > 0 {
+		parts = append(parts, count(u.Branches, "branch"))
+	}
+	// "2 merged PRs, 1 open": the noun follows the first count only
+	first := true
+	for _, prs := range []struct {
+		n     int
+		state string
+	}{{u.MergedPRs, "merged"}, {u.OpenPRs, "open"}, {u.ClosedPRs, "closed"}} {
+		if prs.n == 0 {
+			continue
+		}
+		part := fmt.Sprintf("%d %s", prs.n, prs.state)
+		if first {
+			part += " " + plural(prs.n, "PR")
+			first = false
+		}
+		parts = append(parts, part)
+	}
+	if u.Issues > 0 {
+		parts = append(parts, fmt.Sprintf("%s (%d open)", count(u.Issues, "issue"), u.OpenIssues))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// count formats n of noun, as "1 fork" or "4 forks"
+func count(n int, noun string) string {
+	return fmt.Sprintf("%d %s", n, plural(n, noun))
+}
+
+// plural returns noun, in the plural unless n is 1
+func plural(n int, noun string) string {
+	switch {
+	case n == 1:
+		return noun
+	case strings.HasSuffix(noun, "ch"):
+		return noun + "es"
+	}
+	return noun + "s"
+}
+
+// printUpstreams prints each upstream with its numbers, and the forks of
+// it under it
+func printUpstreams(w io.Writer, ups []Upstream) error {
+	if len(ups) == 0 {
+		_, err := fmt.Fprintln(w, dim.Render("No active forks found. Use --all to see untouched forks."))
+		return err
+	}
+
+	var buf strings.Builder
+	for i := range ups {
+		u := &ups[i]
+		style := dim
+		switch {
+		case u.MergedPRs > 0 || u.Ahead > 0:
+			style = greenBold
+		case u.Branches > 0 || u.Issues > 0:
+			style = yellow
+		}
+		fmt.Fprintf(&buf, "%s %s: %s\n", style.Render(icons["upstream"]),
+			render.Link(style.Render(u.FullName), u.URL), u.Summary())
+		for j, name := range u.Forks {
+			fmt.Fprintf(&buf, "    %s %s %s\n", dim.Render(icons["fork"]), name, dim.Render("· "+u.Categories[j]))
+		}
+	}
+	return flush(w, &buf)
+}
@@ -0,0 +1,129 @@
+package wtfork
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/forge"
+)
+
+// upstreamForks are forks of two upstreams, and extras that aren't forks
+func upstreamForks() []Fork {
+	return []Fork{
+		{
+			FullName: "me/tool", ParentFullName: "org/tool", ParentURL: "https://github.com/org/tool",
+			Category: CategoryMaintained, Ahead: 3,
+			Branches: []Branch{
+				{Name: "main", IsDefault: true, PR: &PR{State: PRStateMerged}},
+				{Name: "fix-a", PR: &PR{State: PRStateMerged}},
+				{Name: "fix-b", PR: &PR{State: PRStateOpen}},
+				{Name: "wip"},
+			},
+			Issues: []Issue{{State: forge.IssueOpen}, {State: forge.IssueClosed}},
+		},
+		{
+			FullName: "work/tool", ParentFullName: "org/tool",
+			Category: CategoryContribution,
+			Branches: []Branch{{Name: "fix-c", PR: &PR{State: PRStateClosed}}},
+			Issues:   []Issue{{State: forge.IssueClosed}},
+		},
+		{FullName: "me/lib", ParentFullName: "other/lib", Category: CategoryUntouched},
+		{FullName: "me/app", ParentFullName: "org/template", Category: CategoryGenerated},
+		{FullName: "gist:g1", Category: CategoryGist},
+	}
+}
+
+func TestByUpstream(t *testing.T) {
+	ups := byUpstream(upstreamForks())
+	require.Len(t, ups, 2, "generated repos and gists aren't forks")
+
+	tool := ups[0]
+	assert.Equal(t, "org/tool", tool.FullName, "most forks first")
+	assert.Equal(t, "https://github.com/org/tool", tool.URL)
+	assert.Equal(t, []string{"me/tool", "work/tool"}, tool.Forks)
+	assert.Equal(t, []string{CategoryMaintained, CategoryContribution}, tool.Categories)
+	assert.Equal(t, 3, tool.Ahead)
+	assert.Equal(t, 4, tool.Branches, "default branches don't count")
+	assert.Equal(t, 1, tool.MergedPRs, "the default branch's PR doesn't count")
+	assert.Equal(t, 1, tool.OpenPRs)
+	assert.Equal(t, 1, tool.ClosedPRs)
+	assert.Equal(t, 3, tool.Issues)
+	assert.Equal(t, 1, tool.OpenIssues)
+
+	lib := ups[1]
+	assert.Equal(t, "other/lib", lib.FullName)
+	assert.Equal(t, []string{"me/lib"}, lib.Forks)
+	assert.Zero(t, lib.Branches+lib.Ahead+lib.Issues)
+}
+
+func TestByUpstream_Order(t *testing.T) {
+	forks := []Fork{
+		{FullName: "me/b", ParentFullName: "z/b"},
+		{FullName: "me/a", ParentFullName: "y/a"},
+		{FullName: "me/c", ParentFullName: "x/c"},
+		{FullName: "work/c", ParentFullName: "x/c"},
+		{FullName: "me/orphan"},
+	}
+	var names []string
+	for _, u := range byUpstream(forks) {
+		names = append(names, u.FullName)
+	}
+	assert.Equal(t, []string{"x/c", "y/a", "z/b"}, names, "by number of forks, then name; forks without a parent left out")
+}
+
+func TestUpstreamSummary(t *testing.T) {
+	tests := []struct {
+		name string
+		up   Upstream
+		want string
+	}{
+		{"fork alone", Upstream{Forks: []string{"me/a"}}, "1 fork"},
+		{"branches", Upstream{Forks: []string{"me/a", "work/a"}, Branches: 1}, "2 forks, 1 branch"},
+		{"ahead", Upstream{Forks: []string{"me/a"}, Ahead: 5, Branches: 4}, "1 fork, 5 ahead, 4 branches"},
+		{"PRs", Upstream{Forks: []string{"me/a"}, Branches: 4, MergedPRs: 2, OpenPRs: 1}, "1 fork, 4 branches, 2 merged PRs, 1 open"},
+		{"one PR", Upstream{Forks: []string{"me/a"}, Branches: 1, ClosedPRs: 1}, "1 fork, 1 branch, 1 closed PR"},
+		{"issues", Upstream{Forks: []string{"me/a"}, Issues: 3, OpenIssues: 1}, "1 fork, 3 issues (1 open)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.up.Summary())
+		})
+	}
+}
+
+func TestPrintUpstreams(t *testing.T) {
+	var buf strings.Builder
+	require.NoError(t, printUpstreams(&buf, byUpstream(upstreamForks())))
+	out := buf.String()
+	assert.Contains(t, out, "org/tool: 2 forks, 3 ahead, 4 branches, 1 merged PR, 1 open, 1 closed, 3 issues (1 open)")
+	assert.Contains(t, out, "me/tool · maintained")
+	assert.Contains(t, out, "work/tool · contribution")
+	assert.Contains(t, out, "other/lib: 1 fork")
+	assert.Less(t, strings.Index(out, "org/tool"), strings.Index(out, "other/lib"))
+	assert.NotContains(t, out, "me/app")
+
+	buf.Reset()
+	require.NoError(t, printUpstreams(&buf, nil))
+	assert.Contains(t, buf.String(), "No active forks found")
+}
+
+func TestUpstreamJSON(t *testing.T) {
+	data, err := json.Marshal(byUpstream(upstreamForks())[:1])
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{
+		"full_name": "org/tool",
+		"html_url": "https://github.com/org/tool",
+		"forks": ["me/tool", "work/tool"],
+		"branches": 4,
+		"ahead": 3,
+		"open_prs": 1,
+		"merged_prs": 1,
+		"closed_prs": 1,
+		"open_issues": 1,
+		"issues": 3
+	}]`, string(data))
+}
@@ -0,0 +1,106 @@
+package wtfork
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/forge"
+	"github.com/jdevera/git-this-bread/internal/paths"
+)
+
+// --- Upstream Cache ---
+// Caches what rarely changes about an upstream repo, so running again
+// after acting on a few forks doesn't ask about every upstream again.
+
+// DefaultUpstreamTTL is how long upstream metadata is reused when
+// wtfork.upstream_ttl doesn't say
+const DefaultUpstreamTTL = time.Hour
+
+// upstreamTTL is how long upstream metadata is reused, across runs
+var upstreamTTL = DefaultUpstreamTTL
+
+// UpstreamMeta is what the cache keeps of an upstream repo. Whether it is
+// archived isn't kept: the fork listing says so on every run.
+type UpstreamMeta struct {
+	DefaultBranch string    `json:"default_branch"` // The branch LastCommit is of
+	LastCommit    string    `json:"last_commit"`    // ISO 8601 date of the last commit on the default branch
+	FetchedAt     time.Time `json:"fetched_at"`
+}
+
+// fresh reports whether m, fetched for upstream, can still be used: it is
+// younger than upstreamTTL and upstream's default branch hasn't changed
+func (m *UpstreamMeta) fresh(upstream *forge.Repo, now time.Time) bool {
+	return m.DefaultBranch == upstream.DefaultBranch && now.Sub(m.FetchedAt) < upstreamTTL
+}
+
+// upstreamLastCommit returns the date of the last commit on the default
+// branch of upstream, from the cache while it is fresh
+func upstreamLastCommit(ctx context.Context, fg forge.Forge, upstream *forge.Repo) (string, error) {
+	key := prCacheKey(fg.Spec(), upstream)
+	if !noCache {
+		if m, err := loadUpstreamMeta(key); err == nil && m.fresh(upstream, time.Now()) {
+			return m.LastCommit, nil
+		}
+	}
+
+	date, err := fg.LastCommitDate(ctx, upstream, upstream.DefaultBranch)
+	if err != nil {
+		return "", err
+	}
+	_ = saveUpstreamMeta(key, &UpstreamMeta{
+		DefaultBranch: upstream.DefaultBranch,
+		LastCommit:    date,
+		FetchedAt:     time.Now(),
+	})
+	return date, nil
+}
+
+// getUpstreamCacheDir returns the upstream cache directory for gh-wtfork
+func getUpstreamCacheDir() (string, error) {
+	cacheHome, err := paths.CacheHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheHome, "git-this-bread", "gh-wtfork", "upstreams"), nil
+}
+
+// loadUpstreamMeta loads the cached metadata of an upstream repo
+func loadUpstreamMeta(key string) (*UpstreamMeta, error) {
+	cacheDir, err := getUpstreamCacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(cacheDir, cacheFileName(key))) //nolint:gosec // the path is constructed safely from the repo name
+	debuglog.Cache("gh-wtfork-upstreams", key, err == nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var m UpstreamMeta
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// saveUpstreamMeta saves the metadata of an upstream repo to the cache
+func saveUpstreamMeta(key string, m *UpstreamMeta) error {
+	cacheDir, err := getUpstreamCacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cacheDir, 0o750); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(cacheDir, cacheFileName(key)), data, 0o600)
+}
@@ -0,0 +1,97 @@
+package wtfork
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/forge"
+)
+
+func TestUpstreamMetaFresh(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	upstream := &forge.Repo{FullName: "org/tool", DefaultBranch: "main"}
+	tests := []struct {
+		name string
+		meta UpstreamMeta
+		want bool
+	}{
+		{"just fetched", UpstreamMeta{DefaultBranch: "main", FetchedAt: now.Add(-time.Minute)}, true},
+		{"almost expired", UpstreamMeta{DefaultBranch: "main", FetchedAt: now.Add(-upstreamTTL + time.Second)}, true},
+		{"expired", UpstreamMeta{DefaultBranch: "main", FetchedAt: now.Add(-upstreamTTL)}, false},
+		{"default branch renamed", UpstreamMeta{DefaultBranch: "master", FetchedAt: now.Add(-time.Minute)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.meta.fresh(upstream, now))
+		})
+	}
+}
+
+// datedForge counts the LastCommitDate calls, answering with the date of
+// the branch asked for
+type datedForge struct {
+	fakeForge
+	dates map[string]string
+	calls int
+}
+
+func (f *datedForge) LastCommitDate(_ context.Context, _ *forge.Repo, branch string) (string, error) {
+	f.calls++
+	return f.dates[branch], nil
+}
+
+func TestUpstreamLastCommit(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	fg := &datedForge{
+		fakeForge: fakeForge{spec: forge.Spec{Kind: forge.GitHub, Host: "github.com"}},
+		dates:     map[string]string{"main": "2026-09-01T00:00:00Z", "trunk": "2026-09-20T00:00:00Z"},
+	}
+	upstream := &forge.Repo{FullName: "org/tool", DefaultBranch: "main"}
+	ctx := context.Background()
+
+	date, err := upstreamLastCommit(ctx, fg, upstream)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-09-01T00:00:00Z", date)
+	assert.Equal(t, 1, fg.calls)
+
+	date, err = upstreamLastCommit(ctx, fg, upstream)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-09-01T00:00:00Z", date)
+	assert.Equal(t, 1, fg.calls, "reused from the cache")
+
+	upstream.DefaultBranch = "trunk"
+	date, err = upstreamLastCommit(ctx, fg, upstream)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-09-20T00:00:00Z", date, "a new default branch invalidates the cache")
+	assert.Equal(t, 2, fg.calls)
+
+	m, err := loadUpstreamMeta(prCacheKey(fg.Spec(), upstream))
+	require.NoError(t, err)
+	assert.Equal(t, "trunk", m.DefaultBranch)
+
+	orig := noCache
+	noCache = true
+	t.Cleanup(func() { noCache = orig })
+	_, err = upstreamLastCommit(ctx, fg, upstream)
+	require.NoError(t, err)
+	assert.Equal(t, 3, fg.calls, "--no-cache asks again")
+}
+
+func TestUpstreamLastCommit_Expired(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	fg := &datedForge{dates: map[string]string{"main": "2026-09-01T00:00:00Z"}}
+	upstream := &forge.Repo{FullName: "org/tool", DefaultBranch: "main"}
+	key := prCacheKey(fg.Spec(), upstream)
+	require.NoError(t, saveUpstreamMeta(key, &UpstreamMeta{
+		DefaultBranch: "main", LastCommit: "2025-01-01T00:00:00Z", FetchedAt: time.Now().Add(-2 * upstreamTTL),
+	}))
+
+	date, err := upstreamLastCommit(context.Background(), fg, upstream)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-09-01T00:00:00Z", date)
+	assert.Equal(t, 1, fg.calls)
+}
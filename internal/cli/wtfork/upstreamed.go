@@ -0,0 +1,63 @@
+package wtfork
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/forge"
+)
+
+// upstreamedCommits counts the commits of fork's default branch, missing
+// from its parent's, that have an equivalent patch there: applied by
+// someone else. git cherry compares them by patch-id, in a blobless bare
+// clone that is removed afterwards.
+func upstreamedCommits(ctx context.Context, fork *forge.Repo) (int, error) {
+	if fork.Parent == nil {
+		return 0, nil
+	}
+	dir, err := os.MkdirTemp("", "gh-wtfork-*")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	git := func(args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		out, err := debuglog.Output(cmd)
+		if err != nil {
+			return "", fmt.Errorf("git %s: %w", args[0], err)
+		}
+		return string(out), nil
+	}
+
+	if _, err := git("clone", "--quiet", "--bare", "--filter=blob:none", "--single-branch",
+		"--branch", fork.DefaultBranch, cloneURL(fork), "."); err != nil {
+		return 0, err
+	}
+	if _, err := git("fetch", "--quiet", "--filter=blob:none", cloneURL(fork.Parent),
+		"+refs/heads/"+fork.Parent.DefaultBranch+":refs/upstream"); err != nil {
+		return 0, err
+	}
+	out, err := git("cherry", "refs/upstream", "HEAD")
+	if err != nil {
+		return 0, err
+	}
+	return countCherry(out), nil
+}
+
+// countCherry counts the commits git cherry output marks as having an
+// equivalent upstream: "- <sha>" lines, where "+ <sha>" is one without
+func countCherry(out string) int {
+	return strings.Count("\n"+out, "\n- ")
+}
+
+// cloneURL returns the URL git clones repo from
+func cloneURL(repo *forge.Repo) string {
+	return strings.TrimSuffix(repo.URL, "/") + ".git"
+}
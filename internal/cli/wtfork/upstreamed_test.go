@@ -0,0 +1,26 @@
+package wtfork
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountCherry(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want int
+	}{
+		{"nothing ahead", "", 0},
+		{"none upstreamed", "+ 1111111\n+ 2222222\n", 0},
+		{"all upstreamed", "- 1111111\n- 2222222\n", 2},
+		{"some upstreamed", "+ 1111111\n- 2222222\n+ 3333333\n- 4444444\n", 2},
+		{"no trailing newline", "+ 1111111\n- 2222222", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, countCherry(tt.out))
+		})
+	}
+}
@@ -0,0 +1,1402 @@
+// Package wtfork implements the gh-wtfork command, run on its own or as a
+// subcommand of bread
+package wtfork
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/invopop/jsonschema"
+	"github.com/spf13/cobra"
+
+	"github.com/jdevera/git-this-bread/internal/actions"
+	"github.com/jdevera/git-this-bread/internal/cli"
+	"github.com/jdevera/git-this-bread/internal/config"
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/forge"
+	"github.com/jdevera/git-this-bread/internal/ghapi"
+	"github.com/jdevera/git-this-bread/internal/identity"
+	"github.com/jdevera/git-this-bread/internal/llmadvice"
+	"github.com/jdevera/git-this-bread/internal/paths"
+	"github.com/jdevera/git-this-bread/internal/render"
+)
+
+var (
+	asProfile   string
+	forgeName   string
+	emitActions bool
+	showAll     bool
+	jsonOutput  bool
+	useTable    bool
+	showSchema  bool
+	noCache     bool
+	themeName   string
+	noPager     bool
+	excludes    []string
+	upstreamed  bool
+	untouchedDo string
+	snapshot    bool
+	hyperlinks  string
+	diffLast    bool
+	include     []string
+	byUpstreams bool
+
+	llmAdvice       bool
+	llmProvider     string
+	llmModel        string
+	llmInstructions string
+	llmUsage        bool
+)
+
+// Styles, derived from the shared render theme (see applyStyles)
+var (
+	greenBold lipgloss.Style
+	green     lipgloss.Style
+	yellow    lipgloss.Style
+	red       lipgloss.Style
+	cyan      lipgloss.Style
+	dim       lipgloss.Style
+	dimItalic lipgloss.Style
+)
+
+// applyStyles rebuilds the styles from the active render theme
+func applyStyles() {
+	greenBold = render.Style(render.RoleSuccess).Bold(true)
+	green = render.Style(render.RoleSuccess)
+	yellow = render.Style(render.RoleWarning)
+	red = render.Style(render.RoleError)
+	cyan = render.Style(render.RoleAccent)
+	dim = render.Style(render.RoleMuted)
+	dimItalic = render.Style(render.RoleMuted).Italic(true)
+}
+
+// Icons
+var icons = map[string]string{
+	"fork":     "\uf402", // nf-oct-repo_forked
+	"upstream": "\uf062", // nf-fa-arrow_up
+	"branch":   "\ue725", // nf-dev-git_branch
+	"pr":       "\uf407", // nf-oct-git_pull_request
+	"merged":   "\uf419", // nf-oct-git_merge
+	"closed":   "\uf659", // nf-mdi-close_circle
+	"issue":    "\uf41b", // nf-oct-issue_opened
+	"discuss":  "\uf442", // nf-oct-comment_discussion
+	"archived": "\uf187", // nf-fa-archive
+	"sync":     "\uf021", // nf-fa-refresh
+	"ahead":    "\uf176", // nf-fa-long_arrow_up
+	"behind":   "\uf175", // nf-fa-long_arrow_down
+	"check":    "\uf00c", // nf-fa-check
+	"warning":  "\uf071", // nf-fa-warning
+	"spinner":  "\uf110", // nf-fa-spinner
+	"template": "\uf401", // nf-oct-repo
+	"gist":     "\uf40d", // nf-oct-file_code
+}
+
+// PR states
+const (
+	PRStateOpen   = forge.PROpen
+	PRStateMerged = forge.PRMerged
+	PRStateClosed = forge.PRClosed
+)
+
+// Fork categories
+const (
+	CategoryMaintained   = "maintained"   // Ahead on default branch - you're keeping your own version
+	CategoryContribution = "contribution" // Not ahead, but has branches/PRs - just for contributing
+	CategoryUntouched    = "untouched"    // No changes - can be deleted
+)
+
+type Fork struct {
+	Name           string   `json:"name"`
+	FullName       string   `json:"full_name"`
+	URL            string   `json:"html_url"`
+	ParentName     string   `json:"parent_name"` // The template, for generated repos
+	ParentFullName string   `json:"parent_full_name"`
+	ParentURL      string   `json:"parent_html_url,omitempty"`
+	DefaultBranch  string   `json:"default_branch"`
+	Category       string   `json:"category"` // maintained, contribution, untouched; generated or gist with --include
+	Ahead          int      `json:"ahead"`
+	Behind         int      `json:"behind"`
+	ForkLastCommit string   `json:"fork_last_commit,omitempty"`     // Last commit on fork's default branch; last push of generated repos, last change of gists
+	ForkLastAgo    string   `json:"fork_last_ago,omitempty"`        // Relative time
+	UpstreamLast   string   `json:"upstream_last_commit,omitempty"` // Last commit on upstream's default branch
+	UpstreamAgo    string   `json:"upstream_last_ago,omitempty"`    // Relative time
+	Branches       []Branch `json:"branches,omitempty"`
+	Issues         []Issue  `json:"issues,omitempty"`      // Issues and discussions you opened upstream
+	Upstreamed     int      `json:"upstreamed,omitempty"`  // Ahead commits with an equivalent patch upstream; only with --upstreamed
+	Archived       bool     `json:"archived,omitempty"`    // Read-only on the forge
+	Adopted        bool     `json:"adopted,omitempty"`     // Kept diverged on purpose (gh-wtfork adopt): no sync suggested
+	Stale          bool     `json:"stale,omitempty"`       // Generated repo or gist left alone for long
+	Unchanged      bool     `json:"unchanged,omitempty"`   // Generated repo never pushed to after it was generated
+	Description    string   `json:"description,omitempty"` // Of a gist
+	Files          int      `json:"files,omitempty"`       // Files in a gist
+	Secret         bool     `json:"secret,omitempty"`      // Secret gist
+	Untouched      bool     `json:"untouched"`             // Deprecated: use Category == CategoryUntouched
+}
+
+type Branch struct {
+	Name      string `json:"name"`
+	URL       string `json:"html_url,omitempty"`
+	Date      string `json:"date"`     // ISO date
+	DateAgo   string `json:"date_ago"` // Human-readable relative time
+	IsDefault bool   `json:"is_default"`
+	PR        *PR    `json:"pr,omitempty"` // Associated PR if any
+}
+
+// Issue is an issue or discussion you opened in the upstream repo
+type Issue struct {
+	Number     int    `json:"number"`
+	Title      string `json:"title"`
+	State      string `json:"state"` // OPEN, CLOSED
+	URL        string `json:"url"`
+	Discussion bool   `json:"discussion,omitempty"`
+}
+
+type PR struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"` // OPEN, MERGED, CLOSED
+	URL    string `json:"url"`
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "gh-wtfork",
+	Short: "What the fork? Analyze your GitHub forks",
+	Long: `gh-wtfork (a git-this-bread tool)
+
+Triage years of GitHub forks. Categorizes your forks into:
+
+  • Maintained    — ahead on default branch (your own version)
+  • Contribution  — has branches/PRs (contributing upstream)
+  • Untouched     — no changes (can probably delete)
+
+For each fork shows deviation with temporal context, branches
+with age, linked PR status (open/merged/closed), and the issues
+and discussions you opened upstream.
+
+--include adds what piles up the same way, each in its own category:
+repos generated from templates (templates), unchanged since generated
+or stale after a year without a push, and your gists (gists), stale
+after two years without a change. Only on GitHub.
+
+Use --as to run with a specific identity profile managed by git-id.
+Forks on GitLab or Gitea/Forgejo are triaged with --forge, or when the
+profile's forge says so (git-id set <profile> forge gitlab).
+
+In CI, set GH_TOKEN (or GITHUB_TOKEN): every GitHub API call uses it,
+without gh or a login, and identity.default no longer applies.
+
+Defaults come from ~/.config/git-this-bread/config.toml: --as from
+identity.default, --forge from wtfork.forge and --exclude from
+wtfork.excludes, --include from wtfork.include (see 'bread config').
+
+Each upstream's default branch, archived status and last commit date are
+cached for an hour (wtfork.upstream_ttl), separately from PRs, so running
+again after acting on a few forks doesn't ask about every upstream again.
+--no-cache asks anyway.
+
+With --llm-advice, an LLM reads the results and suggests a cleanup plan
+("delete these 12, sync these 3, ..."). It uses the providers, API keys,
+[llm] settings and cache of git explain --llm-advice.
+
+--by-upstream groups forks by the project they were forked from, and
+sums up your work on each, across your forks of it:
+  kubernetes/kubernetes: 1 fork, 4 branches, 2 merged PRs, 1 open
+
+--snapshot saves the results under XDG_STATE_HOME, and --diff-last
+shows only what changed since the last snapshot, for periodic triage:
+  gh-wtfork --diff-last --snapshot`,
+	RunE: run,
+}
+
+func init() {
+	rootCmd.Flags().StringVar(&asProfile, "as", "", "Run as identity profile (managed by git-id; default identity.default from config.toml)")
+	rootCmd.Flags().StringVar(&forgeName, "forge", "", "Forge to triage: github, gitlab[:host] or gitea:host (default the --as profile's forge, wtfork.forge from config.toml, or github)")
+	rootCmd.Flags().StringArrayVar(&excludes, "exclude", nil, "Skip forks whose name or owner/name matches this glob (repeatable; default wtfork.excludes from config.toml)")
+	rootCmd.Flags().StringSliceVar(&include, "include", nil, "Also triage these, besides forks: gists, templates (repos generated from templates; default wtfork.include from config.toml)")
+	_ = rootCmd.RegisterFlagCompletionFunc("include", cobra.FixedCompletions(includeKinds, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.Flags().BoolVarP(&showAll, "all", "a", false, "Show all forks (default: hide untouched)")
+	rootCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+	rootCmd.Flags().BoolVarP(&useTable, "table", "t", false, "Show one row per fork")
+	rootCmd.Flags().BoolVar(&showSchema, "schema", false, "Output JSON schema for the JSON output format and exit")
+	rootCmd.Flags().BoolVar(&noCache, "no-cache", false, "Bypass cache (still refreshes it)")
+	rootCmd.Flags().BoolVar(&noPager, "no-pager", false, "Don't pipe long output through $PAGER")
+	rootCmd.Flags().StringVar(&themeName, "theme", "", "Color theme: dark, light (default $GIT_THIS_BREAD_THEME, ui.theme from config.toml, or dark)")
+	rootCmd.Flags().StringVar(&hyperlinks, "hyperlinks", "", "Link forks, branches, PRs and issues to their pages: auto, always, never (default ui.hyperlinks from config.toml, or auto)")
+	_ = rootCmd.RegisterFlagCompletionFunc("hyperlinks", cobra.FixedCompletions(render.HyperlinkModes, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.Flags().BoolVar(&llmAdvice, "llm-advice", false, "Add an LLM-written triage plan for the forks (requires API key in env)")
+	rootCmd.Flags().StringVar(&llmProvider, "llm-provider", "openai", "LLM provider: openai, anthropic, ollama, static (offline); a comma-separated list falls back in order")
+	rootCmd.Flags().StringVar(&llmModel, "llm-model", "", "LLM model name (default $OPENAI_MODEL/$ANTHROPIC_MODEL/$OLLAMA_MODEL or the provider default)")
+	rootCmd.Flags().StringVar(&llmInstructions, "llm-instructions", "", "Custom instructions for the LLM (e.g., persona or style)")
+	rootCmd.Flags().BoolVar(&llmUsage, "llm-usage", false, "Print LLM token usage and estimated cost for this run and month")
+	rootCmd.Flags().BoolVar(&upstreamed, "upstreamed", false, "Check whether the commits of maintained forks were applied upstream by someone else (clones each one)")
+	rootCmd.Flags().StringVar(&untouchedDo, "untouched", "", "What --emit-actions does with untouched forks: delete, archive or none (default wtfork.action_for_untouched from config.toml, or delete)")
+	_ = rootCmd.RegisterFlagCompletionFunc("untouched", cobra.FixedCompletions(untouchedActions, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.Flags().BoolVar(&emitActions, "emit-actions", false, "Print cleanup commands as JSON actions (for bread apply) instead of the report")
+	rootCmd.Flags().BoolVar(&snapshot, "snapshot", false, "Save the results as a snapshot, for a later --diff-last")
+	rootCmd.Flags().BoolVar(&diffLast, "diff-last", false, "Show only what changed since the last snapshot: new and gone forks, merged PRs, forks now safe to delete")
+	rootCmd.Flags().BoolVar(&byUpstreams, "by-upstream", false, "Group forks by the project they were forked from, summing up your branches, PRs and issues there")
+	rootCmd.MarkFlagsMutuallyExclusive("llm-advice", "json")
+	rootCmd.MarkFlagsMutuallyExclusive("emit-actions", "json", "table", "llm-advice")
+	rootCmd.MarkFlagsMutuallyExclusive("diff-last", "emit-actions", "table", "llm-advice")
+	rootCmd.MarkFlagsMutuallyExclusive("by-upstream", "emit-actions", "table", "diff-last")
+	_ = rootCmd.RegisterFlagCompletionFunc("as", cli.CompleteProfileFlag)
+	applyStyles()
+}
+
+// Command returns the gh-wtfork command
+func Command() *cobra.Command {
+	return rootCmd
+}
+
+// Progress update sent from workers
+type progressUpdate struct {
+	repo   string
+	action string
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	if showSchema {
+		r := jsonschema.Reflector{}
+		schema := r.Reflect(&[]Fork{})
+		if byUpstreams {
+			schema = r.Reflect(&[]Upstream{})
+		}
+		out, _ := json.MarshalIndent(schema, "", "  ")
+		fmt.Println(string(out))
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := render.LoadTheme(themeName, cfg.UI.Theme); err != nil {
+		return err
+	}
+	applyStyles()
+	flags := cmd.Flags()
+	if !flags.Changed("as") {
+		asProfile = defaultProfile(&cfg)
+	}
+	if !flags.Changed("exclude") {
+		excludes = cfg.Wtfork.Excludes
+	}
+	if !flags.Changed("include") {
+		include = cfg.Wtfork.Include
+	}
+	if err := checkInclude(include); err != nil {
+		return err
+	}
+	if !flags.Changed("hyperlinks") {
+		hyperlinks = cfg.UI.Hyperlinks
+	}
+	if err := render.SetHyperlinks(hyperlinks); err != nil {
+		return err
+	}
+	if !flags.Changed("untouched") {
+		untouchedDo = cfg.Wtfork.ActionForUntouched
+	}
+	if cfg.Wtfork.UpstreamTTL > 0 {
+		upstreamTTL = cfg.Wtfork.UpstreamTTL
+	}
+	if untouchedDo == "" {
+		untouchedDo = "delete"
+	}
+	if !slices.Contains(untouchedActions, untouchedDo) {
+		return fmt.Errorf("invalid action for untouched forks %q: must be %s", untouchedDo, strings.Join(untouchedActions, ", "))
+	}
+
+	ctx := cmd.Context()
+
+	// Show immediate feedback
+	fmt.Fprintf(os.Stderr, "%s %s",
+		cyan.Render("⠋"),
+		dim.Render("Checking authentication..."))
+
+	fg, err := connect(ctx, asProfile, forgeName, cfg.Wtfork.Forge)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\r\033[K")
+		return err
+	}
+	if emitActions && fg.Spec().Kind != forge.GitHub {
+		fmt.Fprintf(os.Stderr, "\r\033[K")
+		return fmt.Errorf("--emit-actions writes gh commands, so it only works on GitHub, not %s", fg.Spec())
+	}
+
+	fmt.Fprintf(os.Stderr, "\r\033[K%s %s",
+		cyan.Render("⠙"),
+		dim.Render("Fetching fork list..."))
+
+	forks, err := fg.ListForks(ctx)
+	fmt.Fprintf(os.Stderr, "\r\033[K") // Clear before error or continue
+
+	if err != nil {
+		return fmt.Errorf("failed to list forks: %w", err)
+	}
+	if len(excludes) > 0 {
+		var kept []forge.Repo
+		for i := range forks {
+			if !excluded(forks[i].Name, forks[i].FullName, excludes) {
+				kept = append(kept, forks[i])
+			}
+		}
+		forks = kept
+	}
+	if len(include) > 0 {
+		fmt.Fprintf(os.Stderr, "%s %s", cyan.Render("⠹"), dim.Render("Fetching "+strings.Join(include, " and ")+"..."))
+	}
+	extras, err := listExtras(ctx, fg, include, time.Now())
+	fmt.Fprintf(os.Stderr, "\r\033[K")
+	if err != nil {
+		return err
+	}
+
+	if len(forks) == 0 && len(extras) == 0 {
+		if emitActions {
+			return actions.Write(os.Stdout, nil)
+		}
+		fmt.Println("No forks found.")
+		return nil
+	}
+
+	// Parallel analysis with progress updates
+	total := len(forks)
+	results := make([]Fork, total)
+	errors := make([]error, total)
+
+	// Progress channel for sub-action updates
+	progress := make(chan progressUpdate, 100)
+	var completed atomic.Int32
+
+	// Spinner goroutine - keeps progress on single line
+	done := make(chan struct{})
+	go func() {
+		spinner := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+		tick := 0
+		lastUpdate := progressUpdate{}
+
+		ticker := time.NewTicker(80 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case update := <-progress:
+				lastUpdate = update
+			case <-ticker.C:
+				tick++
+				spinChar := spinner[tick%len(spinner)]
+				comp := completed.Load()
+
+				// Build progress line, truncate to ~70 chars to avoid wrapping
+				var line string
+				if lastUpdate.repo != "" {
+					repoName := render.Truncate(lastUpdate.repo, 20)
+					line = fmt.Sprintf("%s Analyzing [%d/%d] %s · %s",
+						spinChar, comp, total, repoName, lastUpdate.action)
+				} else {
+					line = fmt.Sprintf("%s Analyzing [%d/%d]",
+						spinChar, comp, total)
+				}
+
+				// Truncate if too long (terminal safe)
+				line = render.Truncate(line, 70)
+
+				fmt.Fprintf(os.Stderr, "\r\033[K%s", cyan.Render(line))
+			}
+		}
+	}()
+
+	// Worker pool - 5 concurrent workers to respect GitHub rate limits
+	const maxWorkers = 5
+	sem := make(chan struct{}, maxWorkers)
+	var wg sync.WaitGroup
+
+	for i := range forks {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			sem <- struct{}{}        // Acquire
+			defer func() { <-sem }() // Release
+
+			analyzed, err := analyzeForkWithProgress(ctx, fg, &forks[idx], progress)
+			results[idx] = analyzed
+			errors[idx] = err
+			completed.Add(1)
+		}(i)
+	}
+
+	wg.Wait()
+	close(done)
+	close(progress)
+
+	// Collect results, report errors
+	var finalResults []Fork
+	for i := range results {
+		if errors[i] != nil {
+			fmt.Fprintf(os.Stderr, "\r\033[K  %s failed to analyze %s: %v\n",
+				yellow.Render(icons["warning"]), forks[i].FullName, errors[i])
+			continue
+		}
+		if results[i].FullName != "" {
+			results[i].Adopted = slices.Contains(cfg.Wtfork.Adopted, results[i].FullName)
+			finalResults = append(finalResults, results[i])
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "\r\033[K%s Analyzed %d forks\n\n",
+		green.Render(icons["check"]), len(finalResults))
+
+	for i := range extras {
+		extras[i].Adopted = slices.Contains(cfg.Wtfork.Adopted, extras[i].FullName)
+	}
+	results = append(finalResults, extras...)
+
+	var diff *SnapshotDiff
+	if diffLast {
+		prev, err := lastSnapshot(fg.Spec())
+		if err != nil {
+			return err
+		}
+		if prev == nil {
+			fmt.Fprintf(os.Stderr, "%s No snapshot to compare with yet, showing the full report. Take one with --snapshot\n\n",
+				yellow.Render(icons["warning"]))
+		} else {
+			d := diffSnapshot(prev, results)
+			diff = &d
+		}
+	}
+	if snapshot {
+		path, err := saveSnapshot(fg.Spec(), results, time.Now())
+		if err != nil {
+			return fmt.Errorf("saving the snapshot: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "%s Saved snapshot %s\n\n", green.Render(icons["check"]), dim.Render(path))
+	}
+	if diff != nil {
+		if jsonOutput {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(diff)
+		}
+		return render.Page(noPager, func(w io.Writer) error { return printDiff(w, diff) })
+	}
+
+	// The triage plan covers every fork, untouched ones included
+	var triage []llmadvice.Advice
+	var triageErr error
+	if llmAdvice && len(results) > 0 {
+		triage, triageErr = forkTriage(cmd, &cfg.LLM, results)
+	}
+
+	if emitActions {
+		return actions.Write(os.Stdout, forkActions(results, untouchedDo, cfg.Wtfork.CloneRoot))
+	}
+
+	// Filter untouched if not showing all
+	if !showAll {
+		var filtered []Fork
+		for i := range results {
+			if !results[i].Untouched {
+				filtered = append(filtered, results[i])
+			}
+		}
+		results = filtered
+	}
+
+	// Sort: maintained > contribution > untouched, then by name
+	categoryOrder := map[string]int{
+		CategoryMaintained:   0,
+		CategoryContribution: 1,
+		CategoryUntouched:    2,
+		CategoryGenerated:    3,
+		CategoryGist:         4,
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Category != results[j].Category {
+			return categoryOrder[results[i].Category] < categoryOrder[results[j].Category]
+		}
+		return results[i].Name < results[j].Name
+	})
+
+	var ups []Upstream
+	if byUpstreams {
+		ups = byUpstream(results)
+	}
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if byUpstreams {
+			return enc.Encode(ups)
+		}
+		return enc.Encode(results)
+	}
+
+	return render.Page(noPager, func(w io.Writer) error {
+		var err error
+		switch {
+		case byUpstreams:
+			err = printUpstreams(w, ups)
+		case useTable:
+			err = printTable(w, results)
+		default:
+			err = printResults(w, results)
+		}
+		if err != nil || !llmAdvice {
+			return err
+		}
+		return render.WriteLLMSummary(w, triage, triageErr)
+	})
+}
+
+// untouchedActions are what forkActions can do with untouched forks
+var untouchedActions = []string{"delete", "archive", "none"}
+
+// forkActions suggests gh commands to clean up forks: delete or archive
+// untouched ones (as untouched says), sync the ones only behind upstream,
+// and delete branches whose PR was merged. With a cloneRoot, maintained
+// forks not cloned there yet get a gh-wtfork clone. Adopted forks only get
+// their branches cleaned up and cloned; archived ones, which are
+// read-only, nothing.
+func forkActions(forks []Fork, untouched, cloneRoot string) []actions.Action {
+	var items []actions.Action
+	add := func(f *Fork, severity string, argv []string, format string, args ...any) {
+		items = append(items, actions.Action{
+			Tool:     "gh-wtfork",
+			Repo:     f.FullName,
+			Action:   fmt.Sprintf(format, args...),
+			Command:  actions.Join(argv),
+			Args:     argv,
+			Severity: severity,
+		})
+	}
+	for i := range forks {
+		f := &forks[i]
+		repo := f.FullName
+		if f.Archived || f.Category == CategoryGist {
+			continue
+		}
+		if f.Category == CategoryGenerated {
+			if !f.Unchanged || f.Adopted {
+				continue
+			}
+			switch untouched {
+			case "delete":
+				add(f, actions.SeverityInfo, []string{"gh", "repo", "delete", repo, "--yes"}, "Delete repo generated from %s, unchanged since", f.ParentFullName)
+			case "archive":
+				add(f, actions.SeverityInfo, []string{"gh", "repo", "archive", repo, "--yes"}, "Archive repo generated from %s, unchanged since", f.ParentFullName)
+			}
+			continue
+		}
+		if f.Category == CategoryUntouched && !f.Adopted {
+			switch untouched {
+			case "delete":
+				add(f, actions.SeverityInfo, []string{"gh", "repo", "delete", repo, "--yes"}, "Delete untouched fork of %s", f.ParentFullName)
+			case "archive":
+				add(f, actions.SeverityInfo, []string{"gh", "repo", "archive", repo, "--yes"}, "Archive untouched fork of %s", f.ParentFullName)
+			}
+			continue
+		}
+		if f.Ahead > 0 && f.Upstreamed >= f.Ahead && !f.Adopted && !hasOpenPR(f) {
+			add(f, actions.SeverityInfo, []string{"gh", "repo", "delete", repo, "--yes"}, "Delete fork of %s, its %d commit(s) are upstream", f.ParentFullName, f.Ahead)
+			continue
+		}
+		if f.Ahead == 0 && f.Behind > 0 && !f.Adopted {
+			add(f, actions.SeverityInfo, []string{"gh", "repo", "sync", repo}, "Sync %d commit(s) from %s", f.Behind, f.ParentFullName)
+		}
+		if f.Category == CategoryMaintained && cloneRoot != "" && !isCloned(cloneRoot, f.Name) {
+			add(f, actions.SeverityInfo, []string{"gh-wtfork", "clone", repo, "--root", cloneRoot},
+				"Clone maintained fork into %s, with %s as upstream", cloneRoot, f.ParentFullName)
+		}
+		for _, b := range f.Branches {
+			if b.IsDefault || b.PR == nil || b.PR.State != PRStateMerged {
+				continue
+			}
+			add(f, actions.SeverityInfo,
+				[]string{"gh", "api", "-X", "DELETE", "repos/" + f.FullName + "/git/refs/heads/" + b.Name},
+				"Delete branch %s, merged in #%d", b.Name, b.PR.Number)
+		}
+	}
+	return items
+}
+
+// hasOpenPR reports whether any branch of the fork has an open PR
+func hasOpenPR(f *Fork) bool {
+	for _, b := range f.Branches {
+		if b.PR != nil && b.PR.State == PRStateOpen {
+			return true
+		}
+	}
+	return false
+}
+
+// upstreamedText says how many of the fork's commits are upstream already
+func upstreamedText(f *Fork) string {
+	if f.Upstreamed >= f.Ahead {
+		return fmt.Sprintf("your %d commit(s) appear merged upstream - fork can likely be deleted", f.Ahead)
+	}
+	return fmt.Sprintf("%d of your %d commits appear merged upstream", f.Upstreamed, f.Ahead)
+}
+
+// forkTriage asks the LLM for a cleanup plan across forks
+func forkTriage(cmd *cobra.Command, cfg *llmadvice.Config, forks []Fork) ([]llmadvice.Advice, error) {
+	opts := cfg.Options()
+	opts.NoCache = noCache
+	flags := cmd.Flags()
+	if flags.Changed("llm-provider") {
+		opts.Provider, opts.Fallback = llmadvice.ParseProviders(llmProvider)
+	}
+	if flags.Changed("llm-model") {
+		opts.Model = llmModel
+	}
+	if flags.Changed("llm-instructions") {
+		opts.Instructions = llmInstructions
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	var usage llmadvice.Usage
+	opts.OnUsage = usage.Add
+	defer func() { llmadvice.ReportUsage(os.Stderr, usage, llmUsage) }()
+
+	fmt.Fprintf(os.Stderr, "%s %s", cyan.Render("⠋"), dim.Render("Asking the LLM for a triage plan..."))
+	defer fmt.Fprintf(os.Stderr, "\r\033[K")
+
+	states := make([]llmadvice.ForkState, len(forks))
+	for i := range forks {
+		states[i] = forkState(&forks[i])
+	}
+	return llmadvice.GetForkAdvice(states, opts)
+}
+
+// excluded reports whether a fork's name or owner/name matches any of the
+// glob patterns
+func excluded(name, fullName string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, fullName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// forkState describes a fork for the triage prompt
+func forkState(f *Fork) llmadvice.ForkState {
+	s := llmadvice.ForkState{
+		FullName:      f.FullName,
+		Parent:        f.ParentFullName,
+		Category:      f.Category,
+		Ahead:         f.Ahead,
+		Behind:        f.Behind,
+		LastCommitAgo: f.ForkLastAgo,
+		UpstreamAgo:   f.UpstreamAgo,
+		Upstreamed:    f.Upstreamed,
+		Adopted:       f.Adopted,
+		Archived:      f.Archived,
+	}
+	for _, issue := range f.Issues {
+		if issue.State == forge.IssueOpen {
+			s.OpenIssues++
+		} else {
+			s.ClosedIssues++
+		}
+	}
+	for _, b := range f.Branches {
+		if b.IsDefault {
+			continue
+		}
+		fb := llmadvice.ForkBranch{Name: b.Name, Ago: b.DateAgo}
+		if b.PR != nil {
+			fb.PRNumber = b.PR.Number
+			fb.PRTitle = b.PR.Title
+			fb.PRState = b.PR.State
+		}
+		s.Branches = append(s.Branches, fb)
+	}
+	return s
+}
+
+// flush writes buffered output to w
+func flush(w io.Writer, buf *strings.Builder) error {
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+func printResults(w io.Writer, forks []Fork) error {
+	if len(forks) == 0 {
+		_, err := fmt.Fprintln(w, dim.Render("No active forks found. Use --all to see untouched forks."))
+		return err
+	}
+
+	var buf strings.Builder
+
+	// Group header tracking
+	lastCategory := ""
+
+	for i := range forks {
+		f := &forks[i]
+
+		// Print category header when it changes
+		if f.Category != lastCategory {
+			if lastCategory != "" {
+				fmt.Fprintln(&buf) // Extra space between categories
+			}
+			switch f.Category {
+			case CategoryMaintained:
+				fmt.Fprintf(&buf, "%s %s\n", greenBold.Render("●"), greenBold.Render("Maintained"))
+			case CategoryContribution:
+				fmt.Fprintf(&buf, "%s %s\n", yellow.Render("○"), yellow.Render("Contributions"))
+			case CategoryUntouched:
+				fmt.Fprintf(&buf, "%s %s\n", dim.Render("·"), dim.Render("Untouched"))
+			case CategoryGenerated:
+				fmt.Fprintf(&buf, "%s %s\n", cyan.Render("◇"), cyan.Render("From templates"))
+			case CategoryGist:
+				fmt.Fprintf(&buf, "%s %s\n", cyan.Render("◇"), cyan.Render("Gists"))
+			}
+			lastCategory = f.Category
+		}
+		if isExtra(f) {
+			writeExtra(&buf, f)
+			continue
+		}
+
+		// Fork name with icon
+		forkIcon := icons["fork"]
+		var nameStyled string
+		switch f.Category {
+		case CategoryMaintained:
+			nameStyled = render.Link(greenBold.Render(f.FullName), f.URL)
+			fmt.Fprintf(&buf, "%s %s\n", green.Render(forkIcon), nameStyled)
+		case CategoryContribution:
+			nameStyled = render.Link(yellow.Render(f.FullName), f.URL)
+			fmt.Fprintf(&buf, "%s %s\n", yellow.Render(forkIcon), nameStyled)
+		case CategoryUntouched:
+			nameStyled = render.Link(dim.Render(f.FullName), f.URL)
+			fmt.Fprintf(&buf, "%s %s\n", dim.Render(forkIcon), nameStyled)
+		}
+
+		// Upstream
+		marks := ""
+		if f.Adopted {
+			marks += " · adopted, kept diverged"
+		}
+		if f.Archived {
+			marks += " · " + icons["archived"] + " archived"
+		}
+		fmt.Fprintf(&buf, "    %s %s%s\n", dim.Render(icons["upstream"]),
+			render.Link(dim.Render(f.ParentFullName), f.ParentURL), dim.Render(marks))
+
+		// Deviation with temporal context
+		if f.Ahead > 0 || f.Behind > 0 {
+			var parts []string
+			if f.Ahead > 0 {
+				aheadStr := fmt.Sprintf("%s %d ahead", icons["ahead"], f.Ahead)
+				if f.ForkLastAgo != "" {
+					aheadStr += fmt.Sprintf(" (%s)", f.ForkLastAgo)
+				}
+				parts = append(parts, greenBold.Render(aheadStr))
+			}
+			if f.Behind > 0 {
+				behindStr := fmt.Sprintf("%s %d behind", icons["behind"], f.Behind)
+				if f.UpstreamAgo != "" {
+					behindStr += fmt.Sprintf(" (upstream: %s)", f.UpstreamAgo)
+				}
+				parts = append(parts, red.Render(behindStr))
+			}
+			fmt.Fprintf(&buf, "    %s\n", strings.Join(parts, "  "))
+			if f.Upstreamed > 0 {
+				fmt.Fprintf(&buf, "    %s %s\n", green.Render(icons["merged"]), green.Render(upstreamedText(f)))
+			}
+		} else {
+			syncStr := "in sync"
+			if f.UpstreamAgo != "" {
+				syncStr += fmt.Sprintf(" (upstream: %s)", f.UpstreamAgo)
+			}
+			fmt.Fprintf(&buf, "    %s %s\n", green.Render(icons["sync"]), green.Render(syncStr))
+		}
+
+		// Branches (non-default only)
+		var nonDefaultBranches []Branch
+		for j := range f.Branches {
+			if !f.Branches[j].IsDefault {
+				nonDefaultBranches = append(nonDefaultBranches, f.Branches[j])
+			}
+		}
+
+		if len(nonDefaultBranches) > 0 {
+			for _, b := range nonDefaultBranches {
+				branchLine := fmt.Sprintf("    %s %s", cyan.Render(icons["branch"]), render.Link(cyan.Render(b.Name), b.URL))
+
+				// Date and age
+				if b.Date != "" {
+					branchLine += fmt.Sprintf("  %s", dim.Render(b.Date))
+					if b.DateAgo != "" {
+						branchLine += fmt.Sprintf(" · %s", dimItalic.Render(b.DateAgo))
+					}
+				}
+				fmt.Fprintln(&buf, branchLine)
+
+				// PR info
+				if b.PR != nil {
+					prIcon := icons["pr"]
+					prStyle := yellow // default for open
+					stateLabel := "open"
+
+					switch b.PR.State {
+					case PRStateMerged:
+						prIcon = icons["merged"]
+						prStyle = greenBold
+						stateLabel = "merged"
+					case PRStateClosed:
+						prIcon = icons["closed"]
+						prStyle = red
+						stateLabel = "closed"
+					}
+
+					fmt.Fprintf(&buf, "        %s %s %s %s\n",
+						prStyle.Render(prIcon),
+						prStyle.Render(stateLabel),
+						render.Link(fmt.Sprintf("#%d", b.PR.Number), b.PR.URL),
+						dim.Render(render.Truncate(b.PR.Title, 50)))
+				}
+			}
+		}
+
+		// Issues and discussions opened upstream
+		for _, issue := range f.Issues {
+			icon, kind := icons["issue"], "issue"
+			if issue.Discussion {
+				icon, kind = icons["discuss"], "discussion"
+			}
+			style, state := yellow, "open"
+			if issue.State == forge.IssueClosed {
+				style, state = dim, "closed"
+			}
+			fmt.Fprintf(&buf, "    %s %s %s %s\n",
+				style.Render(icon),
+				style.Render(kind+" "+state),
+				render.Link(fmt.Sprintf("#%d", issue.Number), issue.URL),
+				dim.Render(render.Truncate(issue.Title, 50)))
+		}
+
+		fmt.Fprintln(&buf)
+	}
+	return flush(w, &buf)
+}
+
+// printTable prints one row per fork with its deviation and PR counts
+func printTable(w io.Writer, forks []Fork) error {
+	if len(forks) == 0 {
+		_, err := fmt.Fprintln(w, dim.Render("No active forks found. Use --all to see untouched forks."))
+		return err
+	}
+
+	t := render.NewTable("Fork", "Upstream", "Category", "Ahead", "Behind", "Branches", "PRs", "Issues")
+	for i := range forks {
+		f := &forks[i]
+
+		branches, open, merged := 0, 0, 0
+		for _, b := range f.Branches {
+			if b.IsDefault {
+				continue
+			}
+			branches++
+			if b.PR != nil {
+				switch b.PR.State {
+				case PRStateOpen:
+					open++
+				case PRStateMerged:
+					merged++
+				}
+			}
+		}
+
+		var style lipgloss.Style
+		switch f.Category {
+		case CategoryMaintained:
+			style = greenBold
+		case CategoryContribution:
+			style = yellow
+		case CategoryGenerated, CategoryGist:
+			style = cyan
+			if f.Stale || f.Unchanged {
+				style = dim
+			}
+		default:
+			style = dim
+		}
+
+		prs := "-"
+		if open > 0 || merged > 0 {
+			prs = fmt.Sprintf("%d open, %d merged", open, merged)
+		}
+		issues := "-"
+		if len(f.Issues) > 0 {
+			openIssues := 0
+			for _, issue := range f.Issues {
+				if issue.State == forge.IssueOpen {
+					openIssues++
+				}
+			}
+			issues = fmt.Sprintf("%d open, %d closed", openIssues, len(f.Issues)-openIssues)
+		}
+		category := f.Category
+		if f.Adopted {
+			category += " (adopted)"
+		}
+		if f.Archived {
+			category += " (archived)"
+		}
+		if f.Unchanged {
+			category += " (unchanged)"
+		} else if f.Stale {
+			category += " (stale)"
+		}
+
+		t.AddRow(
+			render.Link(style.Render(f.FullName), f.URL),
+			render.Link(dim.Render(f.ParentFullName), f.ParentURL),
+			style.Render(category),
+			fmt.Sprintf("%d", f.Ahead),
+			fmt.Sprintf("%d", f.Behind),
+			fmt.Sprintf("%d", branches),
+			prs,
+			issues,
+		)
+	}
+	_, err := fmt.Fprintln(w, t)
+	return err
+}
+
+// relativeTime returns a human-readable relative time string
+// If years present: "Xy Xmo"
+// If months present: "Xmo Xd"
+// Otherwise: "Xd"
+func relativeTime(isoDate string) string {
+	if len(isoDate) < 10 {
+		return ""
+	}
+
+	t, err := time.Parse("2006-01-02", isoDate[:10])
+	if err != nil {
+		// Try ISO 8601 format
+		t, err = time.Parse(time.RFC3339, isoDate)
+		if err != nil {
+			return ""
+		}
+	}
+
+	now := time.Now()
+	diff := now.Sub(t)
+
+	days := int(diff.Hours() / 24)
+	months := days / 30
+	years := months / 12
+	months %= 12
+	days %= 30
+
+	if years > 0 {
+		if months > 0 {
+			return fmt.Sprintf("%dy %dmo ago", years, months)
+		}
+		return fmt.Sprintf("%dy ago", years)
+	}
+	if months > 0 {
+		if days > 0 {
+			return fmt.Sprintf("%dmo %dd ago", months, days)
+		}
+		return fmt.Sprintf("%dmo ago", months)
+	}
+	if days > 0 {
+		return fmt.Sprintf("%dd ago", days)
+	}
+	return "today"
+}
+
+// defaultProfile returns the profile to run as when --as is not given:
+// identity.default from the config file, unless a token in the environment
+// (GH_TOKEN, GITHUB_TOKEN) says who to run as, as in CI, where it belongs to
+// whichever account the job was set up with
+func defaultProfile(cfg *config.Config) string {
+	if token, _ := ghapi.EnvToken(); token != "" {
+		return ""
+	}
+	return cfg.Identity.Default
+}
+
+// connect returns the forge to triage, authenticated as the GitHub (or
+// forge) user of profile, or with the default credentials when profile is
+// empty. The forge is forgeName, else the profile's, else defaultForge.
+func connect(ctx context.Context, profile, forgeName, defaultForge string) (forge.Forge, error) {
+	var user string
+	if profile != "" {
+		p, err := identity.Get(profile)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q not found: %w", profile, err)
+		}
+		if p.GHUser == "" {
+			return nil, fmt.Errorf("profile %q has no GitHub user configured", profile)
+		}
+		user = p.GHUser
+		if forgeName == "" {
+			forgeName = p.Forge
+		}
+	}
+	if forgeName == "" {
+		forgeName = defaultForge
+	}
+	spec, err := forge.ParseSpec(forgeName)
+	if err != nil {
+		return nil, err
+	}
+	spec.User = user
+
+	f, err := forge.New(spec)
+	if err == nil {
+		err = forge.CheckAuth(ctx, f)
+	}
+	if err != nil {
+		if profile != "" {
+			return nil, fmt.Errorf("not authenticated as profile %q: %w", profile, err)
+		}
+		return nil, fmt.Errorf("not authenticated: %w", err)
+	}
+	return f, nil
+}
+
+func analyzeForkWithProgress(ctx context.Context, fg forge.Forge, repo *forge.Repo, progress chan<- progressUpdate) (Fork, error) { //nolint:unparam // error kept for future use
+	f := Fork{
+		Name:          repo.Name,
+		FullName:      repo.FullName,
+		URL:           repo.URL,
+		DefaultBranch: repo.DefaultBranch,
+		Archived:      repo.Archived,
+	}
+
+	if repo.Parent != nil {
+		f.ParentName = repo.Parent.Name
+		f.ParentFullName = repo.Parent.FullName
+		f.ParentURL = repo.Parent.URL
+	}
+
+	// Get comparison with upstream and last commit dates
+	if repo.Parent != nil {
+		progress <- progressUpdate{repo: repo.Name, action: "comparing with upstream"}
+		if ahead, behind, err := fg.Compare(ctx, repo, repo.DefaultBranch); err == nil {
+			f.Ahead = ahead
+			f.Behind = behind
+		}
+
+		// Get last commit dates for both fork and upstream default branches
+		progress <- progressUpdate{repo: repo.Name, action: "checking commit dates"}
+		if forkDate, err := fg.LastCommitDate(ctx, repo, repo.DefaultBranch); err == nil {
+			f.ForkLastCommit = formatDate(forkDate)
+			f.ForkLastAgo = relativeTime(forkDate)
+		}
+		if upstreamDate, err := upstreamLastCommit(ctx, fg, repo.Parent); err == nil {
+			f.UpstreamLast = formatDate(upstreamDate)
+			f.UpstreamAgo = relativeTime(upstreamDate)
+		}
+	}
+
+	// Get branches
+	progress <- progressUpdate{repo: repo.Name, action: "fetching branches"}
+	if branches, err := fg.ListBranches(ctx, repo); err == nil {
+		f.Branches = toBranches(branches, repo.DefaultBranch)
+		for i := range f.Branches {
+			f.Branches[i].URL = fg.Spec().BranchURL(repo.URL, f.Branches[i].Name)
+		}
+	}
+
+	// Get PRs and link to branches
+	if repo.Parent != nil {
+		progress <- progressUpdate{repo: repo.Name, action: "fetching PRs"}
+		prs, err := getPRsForFork(ctx, fg, repo)
+		if err == nil {
+			linkPRsToBranches(&f, prs)
+		}
+
+		progress <- progressUpdate{repo: repo.Name, action: "fetching issues"}
+		if issues, err := fg.ListIssues(ctx, repo); err == nil {
+			for _, i := range issues {
+				f.Issues = append(f.Issues, Issue(i))
+			}
+		}
+	}
+
+	// Categorize the fork
+	nonDefaultBranches := 0
+	for i := range f.Branches {
+		if !f.Branches[i].IsDefault {
+			nonDefaultBranches++
+		}
+	}
+
+	// Determine category:
+	// - Maintained: ahead on default branch (you're keeping your own version)
+	// - Contribution: not ahead, but has branches/PRs (just for contributing)
+	// - Untouched: no changes at all
+	switch {
+	case f.Ahead > 0:
+		f.Category = CategoryMaintained
+		if upstreamed {
+			progress <- progressUpdate{repo: repo.Name, action: "looking for your commits upstream"}
+			if n, err := upstreamedCommits(ctx, repo); err == nil {
+				f.Upstreamed = min(n, f.Ahead)
+			}
+		}
+	case nonDefaultBranches > 0 || hasOpenPR(&f):
+		f.Category = CategoryContribution
+	default:
+		f.Category = CategoryUntouched
+	}
+	f.Untouched = f.Category == CategoryUntouched
+
+	return f, nil
+}
+
+// toBranches dates every branch but the default one for display
+func toBranches(branches []forge.Branch, defaultBranch string) []Branch {
+	result := make([]Branch, 0, len(branches))
+	for _, b := range branches {
+		branch := Branch{
+			Name:      b.Name,
+			IsDefault: b.Name == defaultBranch,
+		}
+		if !branch.IsDefault && b.Date != "" {
+			branch.Date = formatDate(b.Date)
+			branch.DateAgo = relativeTime(b.Date)
+		}
+		result = append(result, branch)
+	}
+	return result
+}
+
+// getPRsForFork lists the PRs from a fork to its parent, falling back to
+// the cache when the forge can't be reached and keeping merged and closed
+// PRs the forge no longer returns
+func getPRsForFork(ctx context.Context, fg forge.Forge, repo *forge.Repo) ([]forge.PR, error) {
+	key := prCacheKey(fg.Spec(), repo.Parent)
+
+	// Load cached PRs (unless --no-cache)
+	var cache *PRCache
+	if !noCache {
+		cache, _ = loadPRCache(key)
+	}
+	if cache == nil {
+		cache = &PRCache{PRs: make(map[int]CachedPR)}
+	}
+
+	prs, err := fg.ListPRs(ctx, repo)
+	if err != nil {
+		// API failed - fall back to cache if available
+		if len(cache.PRs) > 0 {
+			return mergeCachedPRs(nil, cache), nil
+		}
+		return nil, err
+	}
+
+	// Merge with cached PRs (adds old merged/closed PRs not in search results)
+	prs = mergeCachedPRs(prs, cache)
+
+	// Save merged/closed PRs to cache for next time
+	_ = savePRCache(key, prs)
+
+	return prs, nil
+}
+
+// prCacheKey names the PR cache of an upstream repo. GitHub repos keep the
+// plain owner/name they were always cached under.
+func prCacheKey(spec forge.Spec, upstream *forge.Repo) string {
+	if spec.Kind == forge.GitHub {
+		return upstream.FullName
+	}
+	return spec.Host + "/" + upstream.FullName
+}
+
+func linkPRsToBranches(fork *Fork, prs []forge.PR) {
+	// Create a map of branch name to PRs (use the most relevant PR)
+	branchPRs := make(map[string]*PR)
+
+	for i := range prs {
+		pr := &prs[i]
+		branchName := pr.Branch
+
+		existing, exists := branchPRs[branchName]
+		// Prefer: Open > Merged > Closed
+		if !exists {
+			branchPRs[branchName] = &PR{
+				Number: pr.Number,
+				Title:  pr.Title,
+				State:  pr.State,
+				URL:    pr.URL,
+			}
+		} else if pr.State == PRStateOpen || (pr.State == PRStateMerged && existing.State == PRStateClosed) {
+			// Update if this PR is more relevant
+			branchPRs[branchName] = &PR{
+				Number: pr.Number,
+				Title:  pr.Title,
+				State:  pr.State,
+				URL:    pr.URL,
+			}
+		}
+	}
+
+	// Link PRs to branches
+	for i := range fork.Branches {
+		if pr, ok := branchPRs[fork.Branches[i].Name]; ok {
+			fork.Branches[i].PR = pr
+		}
+	}
+}
+
+func formatDate(isoDate string) string {
+	if len(isoDate) >= 10 {
+		return isoDate[:10]
+	}
+	return isoDate
+}
+
+// --- PR Cache ---
+// Caches merged/closed PRs to avoid re-fetching data that won't change.
+
+// CachedPR represents a PR stored in the cache
+type CachedPR struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	URL    string `json:"url"`
+	Branch string `json:"branch"`
+}
+
+// PRCache holds cached PRs for an upstream repo
+type PRCache struct {
+	PRs       map[int]CachedPR `json:"prs"` // keyed by PR number
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// getCacheDir returns the cache directory for gh-wtfork
+func getCacheDir() (string, error) {
+	cacheHome, err := paths.CacheHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheHome, "git-this-bread", "gh-wtfork", "prs"), nil
+}
+
+// cacheFileName returns a safe filename for an upstream repo
+func cacheFileName(upstreamFullName string) string {
+	// Replace / with _ for safe filename
+	return strings.ReplaceAll(upstreamFullName, "/", "_") + ".json"
+}
+
+// loadPRCache loads cached PRs for an upstream repo
+func loadPRCache(upstreamFullName string) (*PRCache, error) {
+	cacheDir, err := getCacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	cachePath := filepath.Join(cacheDir, cacheFileName(upstreamFullName))
+	data, err := os.ReadFile(cachePath) //nolint:gosec // cachePath is constructed safely from repo name
+	debuglog.Cache("gh-wtfork-prs", upstreamFullName, err == nil)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &PRCache{PRs: make(map[int]CachedPR)}, nil
+		}
+		return nil, err
+	}
+
+	var cache PRCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		// Corrupted cache, start fresh
+		return &PRCache{PRs: make(map[int]CachedPR)}, nil
+	}
+
+	if cache.PRs == nil {
+		cache.PRs = make(map[int]CachedPR)
+	}
+
+	return &cache, nil
+}
+
+// savePRCache saves PRs to the cache (only merged/closed)
+func savePRCache(upstreamFullName string, prs []forge.PR) error {
+	cacheDir, err := getCacheDir()
+	if err != nil {
+		return err
+	}
+
+	// Ensure cache directory exists
+	if err := os.MkdirAll(cacheDir, 0o750); err != nil {
+		return err
+	}
+
+	// Load existing cache to preserve PRs we didn't fetch this time
+	cache, _ := loadPRCache(upstreamFullName)
+
+	// Add/update merged and closed PRs
+	for _, pr := range prs {
+		if pr.State == PRStateMerged || pr.State == PRStateClosed {
+			cache.PRs[pr.Number] = CachedPR{
+				Number: pr.Number,
+				Title:  pr.Title,
+				State:  pr.State,
+				URL:    pr.URL,
+				Branch: pr.Branch,
+			}
+		}
+	}
+
+	cache.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	cachePath := filepath.Join(cacheDir, cacheFileName(upstreamFullName))
+	return os.WriteFile(cachePath, data, 0o600)
+}
+
+// mergeCachedPRs merges cached PRs with freshly fetched PRs
+// Fresh data takes precedence (a cached "open" PR might now be "merged")
+func mergeCachedPRs(fresh []forge.PR, cached *PRCache) []forge.PR {
+	// Build a set of PR numbers we already have
+	seen := make(map[int]bool)
+	for _, pr := range fresh {
+		seen[pr.Number] = true
+	}
+
+	// Add cached PRs that weren't in fresh results
+	// (This can happen if the search API didn't return old merged PRs)
+	for _, cpr := range cached.PRs {
+		if !seen[cpr.Number] {
+			fresh = append(fresh, forge.PR{
+				Number: cpr.Number,
+				Title:  cpr.Title,
+				State:  cpr.State,
+				URL:    cpr.URL,
+				Branch: cpr.Branch,
+			})
+		}
+	}
+
+	return fresh
+}
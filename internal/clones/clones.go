@@ -0,0 +1,169 @@
+// Package clones reconciles the repositories cloned on this machine with the
+// ones a user owns on GitHub: clones whose remote is gone, repositories
+// never cloned, and remotes that point at an old name.
+package clones
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+)
+
+// Repo is a GitHub repository the user owns, as the REST API lists it
+type Repo struct {
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Fork     bool   `json:"fork"`
+	Archived bool   `json:"archived"`
+	Private  bool   `json:"private"`
+	CloneURL string `json:"clone_url"`
+	SSHURL   string `json:"ssh_url"`
+}
+
+// Orphan is a clone with no counterpart on GitHub: it has no remote at all,
+// or its remote points at a repository of the user's that no longer exists
+type Orphan struct {
+	Path   string `json:"path"`
+	Remote string `json:"remote,omitempty"` // Empty when the clone has no remotes
+	URL    string `json:"url,omitempty"`
+	Fix    string `json:"fix"`
+}
+
+// Missing is a repository the user owns that isn't cloned under any root
+type Missing struct {
+	FullName string `json:"full_name"`
+	Fork     bool   `json:"fork,omitempty"`
+	Archived bool   `json:"archived,omitempty"`
+	Fix      string `json:"fix"`
+}
+
+// Mismatch is a remote that reaches a repository under an old name or owner
+type Mismatch struct {
+	Path   string `json:"path"`
+	Remote string `json:"remote"`
+	URL    string `json:"url"`
+	Want   string `json:"want"` // The URL to use, keeping the remote's protocol and host
+	Fix    string `json:"fix"`
+}
+
+// Report is the result of reconciling clones with GitHub
+type Report struct {
+	Orphans    []Orphan   `json:"orphans"`
+	NotCloned  []Missing  `json:"not_cloned"`
+	Mismatched []Mismatch `json:"mismatched"`
+}
+
+// Lookup finds the repository owner/name resolves to now, following
+// renames and transfers. It returns nil when it doesn't exist.
+type Lookup func(owner, name string) (*Repo, error)
+
+// Options controls what Reconcile reports
+type Options struct {
+	Login    string // GitHub user the repositories belong to
+	CloneDir string // Where to suggest cloning missing repositories
+	Archived bool   // Report archived repositories that aren't cloned
+}
+
+// Reconcile compares the local clones with the user's GitHub repositories.
+// Remotes that point at the user's account but at no repository in owned
+// are resolved with lookup, to tell renamed repositories from deleted ones.
+func Reconcile(clones []analyzer.RepoInfo, owned []Repo, lookup Lookup, opts Options) (*Report, error) {
+	byName := make(map[string]*Repo, len(owned))
+	for i := range owned {
+		byName[strings.ToLower(owned[i].FullName)] = &owned[i]
+	}
+	cloned := make(map[string]bool)
+	report := &Report{}
+
+	for i := range clones {
+		c := &clones[i]
+		if !c.IsGitRepo {
+			continue
+		}
+		if len(c.AllRemotes) == 0 {
+			report.Orphans = append(report.Orphans, Orphan{
+				Path: c.Path,
+				Fix:  fmt.Sprintf("gh repo create %s --private --source %s --push", c.Name, c.Path),
+			})
+			continue
+		}
+		for _, r := range c.AllRemotes {
+			if !isGitHub(r.Host) || r.Owner == "" {
+				continue
+			}
+			key := strings.ToLower(r.Owner + "/" + r.Repo)
+			if byName[key] != nil {
+				cloned[key] = true
+				continue
+			}
+			if !strings.EqualFold(r.Owner, opts.Login) {
+				continue // Someone else's repository
+			}
+
+			moved, err := lookup(r.Owner, r.Repo)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", c.Path, err)
+			}
+			if moved == nil {
+				report.Orphans = append(report.Orphans, Orphan{
+					Path:   c.Path,
+					Remote: r.Name,
+					URL:    r.URL,
+					Fix: fmt.Sprintf("gh repo create %s/%s --private && git -C %s push -u %s --all",
+						r.Owner, r.Repo, c.Path, r.Name),
+				})
+				continue
+			}
+			cloned[strings.ToLower(moved.FullName)] = true
+			want := remoteURL(r.URL, r.Host, moved)
+			report.Mismatched = append(report.Mismatched, Mismatch{
+				Path:   c.Path,
+				Remote: r.Name,
+				URL:    r.URL,
+				Want:   want,
+				Fix:    fmt.Sprintf("git -C %s remote set-url %s %s", c.Path, r.Name, want),
+			})
+		}
+	}
+
+	for i := range owned {
+		repo := &owned[i]
+		if cloned[strings.ToLower(repo.FullName)] || (repo.Archived && !opts.Archived) {
+			continue
+		}
+		report.NotCloned = append(report.NotCloned, Missing{
+			FullName: repo.FullName,
+			Fork:     repo.Fork,
+			Archived: repo.Archived,
+			Fix:      fmt.Sprintf("gh repo clone %s %s", repo.FullName, filepath.Join(opts.CloneDir, repo.Name)),
+		})
+	}
+
+	sort.Slice(report.Orphans, func(i, j int) bool { return report.Orphans[i].Path < report.Orphans[j].Path })
+	sort.Slice(report.Mismatched, func(i, j int) bool { return report.Mismatched[i].Path < report.Mismatched[j].Path })
+	sort.Slice(report.NotCloned, func(i, j int) bool {
+		return strings.ToLower(report.NotCloned[i].FullName) < strings.ToLower(report.NotCloned[j].FullName)
+	})
+	return report, nil
+}
+
+// isGitHub reports whether a remote host is GitHub, including SSH host
+// aliases like github-work that identity setups use
+func isGitHub(host string) bool {
+	return strings.Contains(strings.ToLower(host), "github")
+}
+
+// remoteURL returns the URL of repo in the style of current: HTTPS stays
+// HTTPS, and SSH keeps its host, which may be an alias
+func remoteURL(current, host string, repo *Repo) string {
+	if strings.HasPrefix(current, "https://") || strings.HasPrefix(current, "http://") {
+		return repo.CloneURL
+	}
+	if strings.HasPrefix(current, "ssh://") {
+		return fmt.Sprintf("ssh://git@%s/%s.git", host, repo.FullName)
+	}
+	return fmt.Sprintf("git@%s:%s.git", host, repo.FullName)
+}
@@ -0,0 +1,97 @@
+package clones
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+)
+
+func clone(path string, remotes ...analyzer.RemoteInfo) analyzer.RepoInfo {
+	return analyzer.RepoInfo{Path: path, Name: path[len("/src/"):], IsGitRepo: true, AllRemotes: remotes}
+}
+
+func remote(name, url string) analyzer.RemoteInfo {
+	host, owner, repo := analyzer.ParseRemoteURL(url)
+	return analyzer.RemoteInfo{Name: name, URL: url, Host: host, Owner: owner, Repo: repo}
+}
+
+func TestReconcile(t *testing.T) {
+	owned := []Repo{
+		{Name: "tool", FullName: "me/tool", CloneURL: "https://github.com/me/tool.git"},
+		{Name: "new-name", FullName: "me/new-name", CloneURL: "https://github.com/me/new-name.git"},
+		{Name: "acme.sh", FullName: "me/acme.sh", Fork: true},
+		{Name: "old", FullName: "me/old", Archived: true},
+	}
+	clones := []analyzer.RepoInfo{
+		clone("/src/tool", remote("origin", "git@github-personal:Me/Tool.git")),
+		clone("/src/renamed", remote("origin", "https://github.com/me/old-name.git")),
+		clone("/src/gone", remote("origin", "git@github.com:me/gone.git"), remote("upstream", "https://github.com/else/gone")),
+		clone("/src/scratch"),
+		clone("/src/theirs", remote("origin", "https://github.com/else/theirs")),
+		clone("/src/gitlab", remote("origin", "git@gitlab.com:me/gitlab.git")),
+		{Path: "/src/notes", Name: "notes"},
+	}
+	var looked []string
+	lookup := func(owner, name string) (*Repo, error) {
+		looked = append(looked, owner+"/"+name)
+		if name == "old-name" {
+			return &owned[1], nil
+		}
+		return nil, nil
+	}
+
+	report, err := Reconcile(clones, owned, lookup, Options{Login: "me", CloneDir: "/src"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"me/old-name", "me/gone"}, looked, "only the user's unknown remotes are looked up")
+
+	assert.Equal(t, []Orphan{
+		{Path: "/src/gone", Remote: "origin", URL: "git@github.com:me/gone.git",
+			Fix: "gh repo create me/gone --private && git -C /src/gone push -u origin --all"},
+		{Path: "/src/scratch", Fix: "gh repo create scratch --private --source /src/scratch --push"},
+	}, report.Orphans)
+
+	assert.Equal(t, []Mismatch{{
+		Path: "/src/renamed", Remote: "origin", URL: "https://github.com/me/old-name.git",
+		Want: "https://github.com/me/new-name.git",
+		Fix:  "git -C /src/renamed remote set-url origin https://github.com/me/new-name.git",
+	}}, report.Mismatched)
+
+	assert.Equal(t, []Missing{
+		{FullName: "me/acme.sh", Fork: true, Fix: "gh repo clone me/acme.sh /src/acme.sh"},
+	}, report.NotCloned, "archived repositories are left out")
+}
+
+func TestReconcile_Archived(t *testing.T) {
+	owned := []Repo{{Name: "old", FullName: "me/old", Archived: true}}
+	report, err := Reconcile(nil, owned, nil, Options{Login: "me", CloneDir: "/src", Archived: true})
+	require.NoError(t, err)
+	require.Len(t, report.NotCloned, 1)
+	assert.True(t, report.NotCloned[0].Archived)
+}
+
+func TestReconcile_LookupError(t *testing.T) {
+	clones := []analyzer.RepoInfo{clone("/src/x", remote("origin", "git@github.com:me/x.git"))}
+	_, err := Reconcile(clones, nil, func(_, _ string) (*Repo, error) {
+		return nil, errors.New("rate limited")
+	}, Options{Login: "me"})
+	assert.ErrorContains(t, err, "/src/x: rate limited")
+}
+
+func TestRemoteURL(t *testing.T) {
+	repo := &Repo{FullName: "me/new", CloneURL: "https://github.com/me/new.git"}
+	tests := []struct {
+		current, host, want string
+	}{
+		{"https://github.com/me/old", "github.com", "https://github.com/me/new.git"},
+		{"git@github.com:me/old.git", "github.com", "git@github.com:me/new.git"},
+		{"git@github-work:me/old.git", "github-work", "git@github-work:me/new.git"},
+		{"ssh://git@github.com/me/old.git", "github.com", "ssh://git@github.com/me/new.git"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, remoteURL(tt.current, tt.host, repo), tt.current)
+	}
+}
@@ -0,0 +1,151 @@
+// Package config loads the settings shared by the git-this-bread tools from
+// ~/.config/git-this-bread/config.toml
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/jdevera/git-this-bread/internal/llmadvice"
+	"github.com/jdevera/git-this-bread/internal/notify"
+	"github.com/jdevera/git-this-bread/internal/paths"
+)
+
+// EnvPrefix starts the environment variables that override settings:
+// GIT_THIS_BREAD_EXPLAIN_ROOTS overrides explain.roots, and so on
+const EnvPrefix = "GIT_THIS_BREAD_"
+
+// Config holds user defaults for every tool, one section per tool.
+// Every field is optional; command-line flags override them.
+type Config struct {
+	Explain  Explain          `toml:"explain"`
+	Wtfork   Wtfork           `toml:"wtfork"`
+	Wip      Wip              `toml:"wip"`
+	Identity Identity         `toml:"identity"`
+	UI       UI               `toml:"ui"`
+	Stats    Stats            `toml:"stats"`
+	Notify   notify.Config    `toml:"notify"` // Where git-explain --notify sends its summary
+	LLM      llmadvice.Config `toml:"llm"`    // Read from llm.toml when config.toml has no [llm] section
+}
+
+// Explain holds git-explain defaults
+type Explain struct {
+	Roots           []string          `toml:"roots"`             // Directories to analyze when none is given and "." is not a repo
+	Excludes        []string          `toml:"excludes"`          // Glob patterns of directory names to skip
+	Labels          map[string]string `toml:"labels"`            // Labels, separated by blanks, of the repos whose name matches each glob
+	DefaultBranches []string          `toml:"default_branches"`  // Names to try, in order, for a repo's default branch when its remotes don't say
+	ReadOnlyRemotes []string          `toml:"read_only_remotes"` // Globs (host, host/owner or host/owner/repo) of remotes that can't be pushed to, besides the known mirrors
+}
+
+// Wtfork holds gh-wtfork defaults
+type Wtfork struct {
+	Excludes           []string      `toml:"excludes"`             // Glob patterns of fork names (name or owner/name) to skip
+	Forge              string        `toml:"forge"`                // github, gitlab[:host] or gitea:host, when no profile sets one
+	Adopted            []string      `toml:"adopted"`              // Forks (owner/name) kept diverged on purpose, set by gh-wtfork adopt
+	ActionForUntouched string        `toml:"action_for_untouched"` // What --emit-actions does with untouched forks: delete (default), archive or none
+	Include            []string      `toml:"include"`              // What to triage besides forks: gists, templates
+	CloneRoot          string        `toml:"clone_root"`           // Where gh-wtfork clone clones forks, and --emit-actions suggests cloning maintained ones
+	UpstreamTTL        time.Duration `toml:"upstream_ttl"`         // How long upstream repo metadata is reused across runs, e.g. "6h"; 0 means an hour
+}
+
+// Wip holds git-wip defaults
+type Wip struct {
+	Push   bool   `toml:"push"`   // Push every snapshot, as if --push were given
+	Remote string `toml:"remote"` // Remote to push to, instead of the first one that is yours
+}
+
+// Identity holds defaults for the identity tools
+type Identity struct {
+	Default string `toml:"default"` // Profile to use when a command isn't given one
+}
+
+// UI holds settings for how every tool talks
+type UI struct {
+	Locale     string `toml:"locale"`     // Language of messages: en or es (default from LC_ALL, LC_MESSAGES or LANG)
+	Hyperlinks string `toml:"hyperlinks"` // Terminal hyperlinks on names: auto (default), always or never
+	Theme      string `toml:"theme"`      // Color theme: dark (default) or light, under $GIT_THIS_BREAD_THEME
+}
+
+// Stats holds the settings of the local usage statistics
+type Stats struct {
+	Enabled bool `toml:"enabled"` // Record each command run, for bread stats
+}
+
+// Dir returns the XDG-compliant config directory
+func Dir() (string, error) {
+	configHome, err := paths.ConfigHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configHome, "git-this-bread"), nil
+}
+
+// Path returns the path of the config file
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.toml"), nil
+}
+
+// Load reads the config file and applies environment overrides. A missing
+// file is not an error.
+func Load() (Config, error) {
+	path, err := Path()
+	if err != nil {
+		return Config{}, err
+	}
+	return loadFile(path)
+}
+
+func loadFile(path string) (Config, error) {
+	var cfg Config
+	md, err := toml.DecodeFile(path, &cfg)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return Config{}, fmt.Errorf("reading %s: unknown setting %q", path, undecoded[0].String())
+	}
+
+	// LLM settings predate this file and may still live in llm.toml
+	dir := filepath.Dir(path)
+	if !md.IsDefined("llm") {
+		if cfg.LLM, err = llmadvice.LoadConfigFile(filepath.Join(dir, "llm.toml")); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.LLM.ResolveTemplates(dir); err != nil {
+		return Config{}, fmt.Errorf("reading %s: %w", path, err)
+	}
+	for i, root := range cfg.Explain.Roots {
+		cfg.Explain.Roots[i] = paths.Expand(root)
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides settings with the GIT_THIS_BREAD_<SECTION>_<KEY>
+// variables that are set
+func (c *Config) applyEnv() error {
+	for _, s := range c.Settings() {
+		value, ok := os.LookupEnv(s.envVar())
+		if !ok {
+			continue
+		}
+		if err := s.set(value); err != nil {
+			return fmt.Errorf("%s: %w", s.envVar(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.toml", `
+[explain]
+roots = ["~/src", "/work"]
+excludes = ["vendor"]
+
+[identity]
+default = "personal"
+
+[llm]
+provider = "ollama"
+`)
+	writeFile(t, dir, "llm.toml", `provider = "anthropic"`)
+	home, _ := os.UserHomeDir()
+
+	cfg, err := loadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(home, "src"), "/work"}, cfg.Explain.Roots)
+	assert.Equal(t, []string{"vendor"}, cfg.Explain.Excludes)
+	assert.Equal(t, "personal", cfg.Identity.Default)
+	assert.Equal(t, "ollama", cfg.LLM.Provider, "the [llm] section wins over llm.toml")
+}
+
+func TestLoadFile_LLMFallback(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "llm.toml", "provider = \"anthropic\"\ncache_ttl = \"72h\"\n")
+
+	cfg, err := loadFile(filepath.Join(dir, "config.toml"))
+	require.NoError(t, err)
+	assert.Equal(t, "anthropic", cfg.LLM.Provider)
+	assert.Equal(t, 72*time.Hour, cfg.LLM.CacheTTL)
+}
+
+func TestLoadFile_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	_, err := loadFile(writeFile(t, dir, "config.toml", "[explain]\nroot = [\"~/src\"]\n"))
+	assert.ErrorContains(t, err, `unknown setting "explain.root"`)
+
+	_, err = loadFile(writeFile(t, dir, "config.toml", "[explain\n"))
+	assert.Error(t, err)
+}
+
+func TestLoadFile_Env(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.toml", "[wtfork]\nexcludes = [\"a\"]\n")
+	t.Setenv("GIT_THIS_BREAD_WTFORK_EXCLUDES", "b, c")
+	t.Setenv("GIT_THIS_BREAD_LLM_REDACT", "true")
+
+	cfg, err := loadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, cfg.Wtfork.Excludes)
+	assert.True(t, cfg.LLM.Redact)
+
+	t.Setenv("GIT_THIS_BREAD_LLM_MAX_TOKENS", "lots")
+	_, err = loadFile(path)
+	assert.ErrorContains(t, err, "GIT_THIS_BREAD_LLM_MAX_TOKENS")
+}
+
+func TestPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/custom/config")
+	path, err := Path()
+	require.NoError(t, err)
+	assert.Equal(t, "/custom/config/git-this-bread/config.toml", path)
+}
+
+func TestSettings(t *testing.T) {
+	temperature := 0.5
+	var cfg Config
+	cfg.Explain.Roots = []string{"/a", "/b"}
+	cfg.LLM.CacheTTL = time.Hour
+	cfg.LLM.Temperature = &temperature
+	cfg.LLM.Headers = map[string]string{"B": "2", "A": "1"}
+
+	values := make(map[string]string)
+	for _, s := range cfg.Settings() {
+		values[s.Name()] = s.String()
+	}
+	assert.Equal(t, "/a,/b", values["explain.roots"])
+	assert.Equal(t, "1h0m0s", values["llm.cache_ttl"])
+	assert.Equal(t, "0.5", values["llm.temperature"])
+	assert.Equal(t, "A: 1,B: 2", values["llm.headers"])
+	assert.Empty(t, values["identity.default"])
+	assert.Empty(t, values["llm.max_tokens"])
+
+	_, err := cfg.Lookup("explain.nope")
+	assert.ErrorContains(t, err, `unknown setting "explain.nope"`)
+}
+
+func TestSetInFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	writeFile(t, dir, "llm.toml", `model = "llama3.2"`)
+
+	require.NoError(t, setInFile(path, "explain.roots", "~/src, ~/work"))
+	require.NoError(t, setInFile(path, "identity.default", "work"))
+	require.NoError(t, setInFile(path, "llm.cache_ttl", "24h"))
+
+	cfg, err := loadFile(path)
+	require.NoError(t, err)
+	home, _ := os.UserHomeDir()
+	assert.Equal(t, []string{filepath.Join(home, "src"), filepath.Join(home, "work")}, cfg.Explain.Roots)
+	assert.Equal(t, "work", cfg.Identity.Default)
+	assert.Equal(t, 24*time.Hour, cfg.LLM.CacheTTL)
+	assert.Equal(t, "llama3.2", cfg.LLM.Model, "llm.toml is carried over into [llm]")
+
+	require.NoError(t, setInFile(path, "identity.default", ""))
+	cfg, err = loadFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Identity.Default)
+
+	assert.ErrorContains(t, setInFile(path, "llm.cache_ttl", "soon"), "llm.cache_ttl")
+	assert.ErrorContains(t, setInFile(path, "nope.nope", "x"), "unknown setting")
+}
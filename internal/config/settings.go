@@ -0,0 +1,226 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Setting is one config value, named by its section and key as in
+// "explain.roots"
+type Setting struct {
+	Section string
+	Key     string
+	value   reflect.Value
+}
+
+// Name returns the dotted name of the setting
+func (s Setting) Name() string {
+	return s.Section + "." + s.Key
+}
+
+// String formats the value as Set accepts it: lists and headers are comma
+// separated, unset values are empty
+func (s Setting) String() string {
+	v := s.value
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		if v.Int() == 0 {
+			return ""
+		}
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Pointer:
+		if v.IsNil() {
+			return ""
+		}
+		return fmt.Sprint(v.Elem().Interface())
+	case v.Kind() == reflect.Slice:
+		return strings.Join(v.Interface().([]string), ",")
+	case v.Kind() == reflect.Map:
+		var pairs []string
+		for k, val := range v.Interface().(map[string]string) {
+			pairs = append(pairs, k+": "+val)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	case v.IsZero():
+		return ""
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// envVar returns the environment variable that overrides the setting
+func (s Setting) envVar() string {
+	return EnvPrefix + strings.ToUpper(s.Section+"_"+s.Key)
+}
+
+// set parses value into the setting; an empty value unsets it
+func (s Setting) set(value string) error {
+	v := s.value
+	if value == "" {
+		v.SetZero()
+		return nil
+	}
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.Pointer && v.Type().Elem().Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(&f))
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int, v.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	case v.Kind() == reflect.Map:
+		headers := make(map[string]string)
+		for _, pair := range strings.Split(value, ",") {
+			name, val, ok := strings.Cut(pair, ":")
+			if !ok {
+				return fmt.Errorf(`invalid entry %q: want "Name: value"`, pair)
+			}
+			headers[strings.TrimSpace(name)] = strings.TrimSpace(val)
+		}
+		v.Set(reflect.ValueOf(headers))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// fileValue returns the setting as it is written to config.toml
+func (s Setting) fileValue() any {
+	if s.value.Type() == reflect.TypeOf(time.Duration(0)) {
+		return s.String()
+	}
+	if s.value.Kind() == reflect.Pointer {
+		return s.value.Elem().Interface()
+	}
+	return s.value.Interface()
+}
+
+// Settings lists every setting of c in file order
+func (c *Config) Settings() []Setting {
+	var list []Setting
+	sections := reflect.ValueOf(c).Elem()
+	for i := range sections.NumField() {
+		section := sections.Type().Field(i).Tag.Get("toml")
+		fields := sections.Field(i)
+		for j := range fields.NumField() {
+			key := fields.Type().Field(j).Tag.Get("toml")
+			if key == "" {
+				continue
+			}
+			list = append(list, Setting{Section: section, Key: key, value: fields.Field(j)})
+		}
+	}
+	return list
+}
+
+// Lookup finds a setting by its dotted name
+func (c *Config) Lookup(name string) (Setting, error) {
+	for _, s := range c.Settings() {
+		if s.Name() == name {
+			return s, nil
+		}
+	}
+	return Setting{}, fmt.Errorf("unknown setting %q", name)
+}
+
+// Set writes one setting to the config file; an empty value removes it.
+// The rest of the file is kept, though not its comments. It returns the
+// path of the file written.
+func Set(name, value string) (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	return path, setInFile(path, name, value)
+}
+
+func setInFile(path, name, value string) error {
+	var scratch Config
+	s, err := scratch.Lookup(name)
+	if err != nil {
+		return err
+	}
+	if err := s.set(value); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	file, err := readRaw(path)
+	if err != nil {
+		return err
+	}
+	section, ok := file[s.Section].(map[string]any)
+	if !ok {
+		section = make(map[string]any)
+		// The first LLM setting written here would hide llm.toml
+		if s.Section == "llm" {
+			if section, err = readRaw(filepath.Join(filepath.Dir(path), "llm.toml")); err != nil {
+				return err
+			}
+		}
+		file[s.Section] = section
+	}
+	if value == "" {
+		delete(section, s.Key)
+	} else {
+		section[s.Key] = s.fileValue()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := toml.NewEncoder(f).Encode(file); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// readRaw decodes a TOML file without a schema; a missing file is empty
+func readRaw(path string) (map[string]any, error) {
+	raw := make(map[string]any)
+	if _, err := toml.DecodeFile(path, &raw); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return raw, nil
+}
@@ -0,0 +1,135 @@
+// Package debuglog records what the tools do behind the scenes: the
+// external commands they run, the API requests they make and the caches they
+// consult. It is silent until Enable is called, which --debug and
+// BREAD_DEBUG do.
+package debuglog
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Env turns on debug logging when set to a true value, for commands that
+// pass their flags through (git-as, gh-as) or to debug from a script
+const Env = "BREAD_DEBUG"
+
+var logger = slog.New(slog.DiscardHandler)
+
+// Enable sends debug records to w. Call it before any goroutine logs.
+func Enable(w io.Writer) {
+	logger = slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// EnabledByEnv reports whether Env asks for debug logging
+func EnabledByEnv() bool {
+	on, err := strconv.ParseBool(os.Getenv(Env))
+	return err == nil && on
+}
+
+// Output runs cmd like cmd.Output and logs it
+func Output(cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	out, err := cmd.Output()
+	logCommand(cmd, start, err)
+	return out, err
+}
+
+// CombinedOutput runs cmd like cmd.CombinedOutput and logs it
+func CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	logCommand(cmd, start, err)
+	return out, err
+}
+
+// Run runs cmd like cmd.Run and logs it
+func Run(cmd *exec.Cmd) error {
+	start := time.Now()
+	err := cmd.Run()
+	logCommand(cmd, start, err)
+	return err
+}
+
+func logCommand(cmd *exec.Cmd, start time.Time, err error) {
+	attrs := []any{
+		"cmd", strings.Join(cmd.Args, " "),
+		"duration", time.Since(start).Round(time.Millisecond),
+		"exit", exitCode(err),
+	}
+	if cmd.Dir != "" {
+		attrs = append(attrs, "dir", cmd.Dir)
+	}
+	if err != nil {
+		attrs = append(attrs, "err", err)
+	}
+	logger.Debug("exec", attrs...)
+}
+
+// exitCode returns the exit status of a finished command, or -1 when it
+// didn't run or was killed
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// Handoff logs a command about to replace this process through
+// cli.Handoff, with the environment variables set for it
+func Handoff(argv []string, env ...string) {
+	logger.Debug("handoff", "cmd", strings.Join(argv, " "), "env", env)
+}
+
+// Cache logs a cache lookup
+func Cache(name, key string, hit bool) {
+	logger.Debug("cache", "name", name, "key", key, "hit", hit)
+}
+
+// Transport wraps base to log every request with its status and duration.
+// Query strings are left out, as they may carry API keys.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{base: base}
+}
+
+// HostTransport is Transport for URLs whose path is a secret too, such as
+// webhooks: it logs only their scheme and host
+func HostTransport(base http.RoundTripper) http.RoundTripper {
+	return &transport{base: base, hidePath: true}
+}
+
+type transport struct {
+	base     http.RoundTripper
+	hidePath bool
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	url := req.URL.Scheme + "://" + req.URL.Host
+	if !t.hidePath {
+		url += req.URL.Path
+	}
+	attrs := []any{
+		"method", req.Method,
+		"url", url,
+		"duration", time.Since(start).Round(time.Millisecond),
+	}
+	if err != nil {
+		attrs = append(attrs, "err", err)
+	} else {
+		attrs = append(attrs, "status", resp.StatusCode)
+	}
+	logger.Debug("http", attrs...)
+	return resp, err
+}
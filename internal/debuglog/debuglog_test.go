@@ -0,0 +1,91 @@
+package debuglog
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capture enables logging into a buffer for the rest of the test
+func capture(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	Enable(&buf)
+	t.Cleanup(func() { logger = slog.New(slog.DiscardHandler) })
+	return &buf
+}
+
+func TestOutput(t *testing.T) {
+	buf := capture(t)
+
+	out, err := Output(exec.Command("echo", "hi"))
+	require.NoError(t, err)
+	assert.Equal(t, "hi\n", string(out))
+	assert.Contains(t, buf.String(), `msg=exec cmd="echo hi"`)
+	assert.Contains(t, buf.String(), "exit=0")
+
+	buf.Reset()
+	require.Error(t, Run(exec.Command("sh", "-c", "exit 3")))
+	assert.Contains(t, buf.String(), "exit=3")
+
+	buf.Reset()
+	_, err = CombinedOutput(exec.Command("no-such-command-here"))
+	require.Error(t, err)
+	assert.Contains(t, buf.String(), "exit=-1")
+}
+
+func TestTransport(t *testing.T) {
+	buf := capture(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: Transport(http.DefaultTransport)}
+	resp, err := client.Get(server.URL + "/api/chat?key=secret")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Contains(t, buf.String(), "msg=http method=GET url="+server.URL+"/api/chat ")
+	assert.Contains(t, buf.String(), "status=418")
+	assert.NotContains(t, buf.String(), "secret")
+}
+
+func TestHostTransport(t *testing.T) {
+	buf := capture(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := &http.Client{Transport: HostTransport(http.DefaultTransport)}
+	resp, err := client.Post(server.URL+"/services/T0/B0/token", "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Contains(t, buf.String(), "msg=http method=POST url="+server.URL+" ")
+	assert.NotContains(t, buf.String(), "token")
+}
+
+func TestCache(t *testing.T) {
+	buf := capture(t)
+	Cache("llm-advice", "abc", true)
+	assert.Contains(t, buf.String(), "msg=cache name=llm-advice key=abc hit=true")
+}
+
+func TestSilentByDefault(t *testing.T) {
+	assert.NotPanics(t, func() { Cache("llm-advice", "abc", false) })
+}
+
+func TestEnabledByEnv(t *testing.T) {
+	t.Setenv(Env, "1")
+	assert.True(t, EnabledByEnv())
+	t.Setenv(Env, "no")
+	assert.False(t, EnabledByEnv())
+	t.Setenv(Env, "")
+	assert.False(t, EnabledByEnv())
+}
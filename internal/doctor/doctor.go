@@ -0,0 +1,432 @@
+// Package doctor checks what the git-this-bread tools need from their
+// environment, and says how to fix whatever is missing
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jdevera/git-this-bread/internal/config"
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/forge"
+	"github.com/jdevera/git-this-bread/internal/ghapi"
+	"github.com/jdevera/git-this-bread/internal/identity"
+	"github.com/jdevera/git-this-bread/internal/paths"
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	OK   Status = "ok"   // Nothing to do
+	Warn Status = "warn" // Some tools or features won't work as well
+	Fail Status = "fail" // Some tools won't work at all
+)
+
+// Result is what one check found
+type Result struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	Detail string `json:"detail"`
+	Fix    string `json:"fix,omitempty"` // What to do about it, unless Status is OK
+}
+
+// Check examines one part of the environment. Checks about several things,
+// such as one per identity profile, return a result for each.
+type Check func(ctx context.Context) []Result
+
+// Minimum versions. Older git releases may lack options the tools use;
+// gh auth token --user, which --as relies on, came with gh 2.40.0.
+var (
+	MinGit = [3]int{2, 20, 0}
+	MinGH  = [3]int{2, 40, 0}
+	// sqlite3 -json, which git explain history reads with, came with 3.33.0
+	MinSQLite = [3]int{3, 33, 0}
+)
+
+// APIURL is the endpoint checked for reachability
+var APIURL = "https://api.github.com"
+
+// Checks returns every check, in the order they are reported
+func Checks() []Check {
+	return []Check{CheckGit, CheckGitIdentity, CheckGH, CheckConfig, CheckProfiles, CheckAPI, CheckCache, CheckSQLite, CheckNerdFont}
+}
+
+// Run runs checks one after the other and collects their results
+func Run(ctx context.Context, checks []Check) []Result {
+	var results []Result
+	for _, check := range checks {
+		results = append(results, check(ctx)...)
+	}
+	return results
+}
+
+// CheckGit checks that git is installed and recent enough
+func CheckGit(ctx context.Context) []Result {
+	return []Result{checkTool(ctx, "git", MinGit, Fail, "Install git: https://git-scm.com/downloads")}
+}
+
+// CheckGH checks that gh is installed and recent enough. Only the GitHub
+// tools need it, so it is a warning.
+func CheckGH(ctx context.Context) []Result {
+	r := checkTool(ctx, "gh", MinGH, Warn, "Install gh: https://cli.github.com")
+	if r.Status != OK {
+		r.Detail += " (needed by gh-as, gh-wtfork, gh-wtclone and --as)"
+	}
+	return []Result{r}
+}
+
+// CheckSQLite checks that sqlite3 is installed and recent enough. Only the
+// git-explain history needs it, so it is a warning.
+func CheckSQLite(ctx context.Context) []Result {
+	r := checkTool(ctx, "sqlite3", MinSQLite, Warn, "Install sqlite3: https://sqlite.org/download.html or your package manager")
+	if r.Status != OK {
+		r.Detail += " (needed by git explain --record and history)"
+	}
+	return []Result{r}
+}
+
+// checkTool runs "name --version" and compares its version with min
+func checkTool(ctx context.Context, name string, minVersion [3]int, severity Status, install string) Result {
+	r := Result{Name: name}
+	if _, err := exec.LookPath(name); err != nil {
+		r.Status, r.Detail, r.Fix = severity, "not found in PATH", install
+		return r
+	}
+	out, err := debuglog.Output(exec.CommandContext(ctx, name, "--version"))
+	if err != nil {
+		r.Status, r.Detail, r.Fix = severity, fmt.Sprintf("%s --version failed: %v", name, err), install
+		return r
+	}
+	r.Status, r.Detail = versionStatus(string(out), minVersion, severity)
+	if r.Status != OK {
+		r.Fix = fmt.Sprintf("Upgrade %s to %s or later", name, formatVersion(minVersion))
+	}
+	return r
+}
+
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseVersion finds the first MAJOR.MINOR[.PATCH] in the output of a
+// --version flag, such as "git version 2.39.3 (Apple Git-145)"
+func ParseVersion(s string) ([3]int, bool) {
+	var v [3]int
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return v, false
+	}
+	for i := range v {
+		v[i], _ = strconv.Atoi(m[i+1]) // An absent patch is 0
+	}
+	return v, true
+}
+
+// versionStatus rates the version found in out against minVersion
+func versionStatus(out string, minVersion [3]int, severity Status) (Status, string) {
+	v, ok := ParseVersion(out)
+	if !ok {
+		return severity, fmt.Sprintf("unknown version %q", strings.TrimSpace(out))
+	}
+	for i := range v {
+		if v[i] != minVersion[i] {
+			if v[i] < minVersion[i] {
+				return severity, fmt.Sprintf("%s is older than %s", formatVersion(v), formatVersion(minVersion))
+			}
+			break
+		}
+	}
+	return OK, formatVersion(v)
+}
+
+func formatVersion(v [3]int) string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+// CheckGitIdentity checks the git settings git-explain and the tools built
+// on it use to tell your commits and remotes apart
+func CheckGitIdentity(ctx context.Context) []Result {
+	var results []Result
+	for _, key := range []string{"user.email", "github.user"} {
+		r := Result{Name: "git config " + key, Status: OK}
+		out, _ := debuglog.Output(exec.CommandContext(ctx, "git", "config", "--get", key))
+		if value := strings.TrimSpace(string(out)); value != "" {
+			r.Detail = value
+		} else {
+			example := "you@example.com"
+			if key == "github.user" {
+				example = "yourusername"
+			}
+			r.Status, r.Detail = Fail, "not set (needed by git-explain, git-wip and gh-wtclone)"
+			r.Fix = fmt.Sprintf("git config --global %s %q", key, example)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// CheckConfig checks that config.toml, if there is one, loads
+func CheckConfig(context.Context) []Result {
+	r := Result{Name: "config.toml", Status: OK}
+	path, err := config.Path()
+	if err != nil {
+		r.Status, r.Detail, r.Fix = Fail, err.Error(), "Set HOME or XDG_CONFIG_HOME"
+		return []Result{r}
+	}
+	if _, err := config.Load(); err != nil {
+		r.Status, r.Detail, r.Fix = Fail, err.Error(), "Fix or remove "+path
+		return []Result{r}
+	}
+	r.Detail = path
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		r.Detail = "none, using the defaults"
+	}
+	return []Result{r}
+}
+
+// CheckProfiles checks every git-id profile: its SSH key and email, its
+// forge and, when it has an account there, that the account is logged in
+func CheckProfiles(ctx context.Context) []Result {
+	names, err := identity.List()
+	if err != nil {
+		return []Result{{Name: "profiles", Status: Fail, Detail: err.Error(), Fix: "Check your git config files"}}
+	}
+	if len(names) == 0 {
+		return []Result{{Name: "profiles", Status: OK, Detail: "none (git-id add <name> creates one)"}}
+	}
+
+	var results []Result
+	for _, name := range names {
+		results = append(results, checkProfile(ctx, name))
+	}
+	if cfg, err := config.Load(); err == nil && cfg.Identity.Default != "" {
+		if _, err := identity.Get(cfg.Identity.Default); err != nil {
+			results = append(results, Result{
+				Name: "identity.default", Status: Fail,
+				Detail: fmt.Sprintf("profile %q doesn't exist", cfg.Identity.Default),
+				Fix:    "bread config set identity.default <profile>",
+			})
+		}
+	}
+	return results
+}
+
+// CheckContext returns a check of the git-id profiles in one context, such
+// as work or a client, as CheckProfiles checks them
+func CheckContext(name string) Check {
+	return func(ctx context.Context) []Result {
+		names, err := identity.InContext(name)
+		if err != nil {
+			return []Result{{Name: "context " + name, Status: Fail, Detail: err.Error(), Fix: "Check your git config files"}}
+		}
+		if len(names) == 0 {
+			return []Result{{
+				Name: "context " + name, Status: Fail, Detail: "no profiles",
+				Fix: fmt.Sprintf("git-id set <profile> context %s", name),
+			}}
+		}
+		var results []Result
+		for _, n := range names {
+			results = append(results, checkProfile(ctx, n))
+		}
+		return results
+	}
+}
+
+func checkProfile(ctx context.Context, name string) Result {
+	r := Result{Name: "profile " + name, Status: OK}
+	p, err := identity.Get(name)
+	if err != nil {
+		r.Status, r.Detail, r.Fix = Fail, err.Error(), "git-id show "+name
+		return r
+	}
+	if err := identity.ValidateSSHKey(p.SSHKey); err != nil {
+		r.Status, r.Detail, r.Fix = Fail, err.Error(), fmt.Sprintf("git-id set %s sshkey <path>", name)
+		return r
+	}
+	if p.Email == "" {
+		r.Status, r.Detail, r.Fix = Fail, "no email", fmt.Sprintf("git-id set %s email <email>", name)
+		return r
+	}
+	spec, err := forge.ForProfile(p)
+	if err != nil {
+		r.Status, r.Detail, r.Fix = Fail, err.Error(), fmt.Sprintf("git-id set %s forge github", name)
+		return r
+	}
+	if p.GHUser == "" {
+		r.Detail = p.Email
+		return r
+	}
+	f, err := forge.New(spec)
+	if err == nil {
+		err = forge.CheckAuth(ctx, f)
+	}
+	if err != nil {
+		r.Status, r.Detail, r.Fix = Warn, fmt.Sprintf("%s account %s: %v", spec, p.GHUser, err), loginFix(spec)
+		return r
+	}
+	r.Detail = fmt.Sprintf("%s, %s account %s", p.Email, spec, p.GHUser)
+	if spec.Kind == forge.GitHub {
+		checkKeyAccount(ctx, p, &r)
+	}
+	return r
+}
+
+// checkKeyAccount warns when the SSH key of a GitHub profile isn't
+// registered to its account, or is registered to another one
+func checkKeyAccount(ctx context.Context, p *identity.Profile, r *Result) {
+	key, err := identity.PublicKey(p.SSHKey)
+	if err == nil {
+		err = ghapi.CheckSSHKey(ctx, key, p.GHUser, forge.GitHubAccounts())
+	}
+	var elsewhere *ghapi.KeyElsewhereError
+	switch {
+	case err == nil:
+		r.Detail += ", SSH key registered"
+	case errors.As(err, &elsewhere):
+		r.Status, r.Detail = Warn, err.Error()
+		r.Fix = fmt.Sprintf("Remove the key from the SSH keys of %s, then run: git-id show %s --upload", elsewhere.Owner, p.Name)
+	case errors.Is(err, ghapi.ErrKeyNotRegistered):
+		r.Status, r.Detail, r.Fix = Warn, err.Error(), "git-id show "+p.Name+" --upload"
+	default:
+		r.Status, r.Detail = Warn, "can't check the SSH key on GitHub: "+err.Error()
+		r.Fix = "git-id show " + p.Name
+	}
+}
+
+// loginFix says how to log in to a forge
+func loginFix(spec forge.Spec) string {
+	switch spec.Kind {
+	case forge.GitLab:
+		return "glab auth login --hostname " + spec.Host + ", or set GITLAB_TOKEN"
+	case forge.Gitea:
+		return "Set GITEA_TOKEN (or FORGEJO_TOKEN) to a token for " + spec.Host
+	default:
+		return "gh auth login"
+	}
+}
+
+// CheckAPI checks that the GitHub API answers at all, logged in or not
+func CheckAPI(ctx context.Context) []Result {
+	r := Result{Name: "GitHub API", Status: OK}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, APIURL, http.NoBody)
+	if err != nil {
+		r.Status, r.Detail = Fail, err.Error()
+		return []Result{r}
+	}
+	client := &http.Client{Transport: debuglog.Transport(http.DefaultTransport)}
+	resp, err := client.Do(req)
+	if err != nil {
+		r.Status, r.Detail = Fail, fmt.Sprintf("can't reach %s: %v", APIURL, err)
+		r.Fix = "Check your network connection, and HTTPS_PROXY if you need a proxy"
+		return []Result{r}
+	}
+	_ = resp.Body.Close()
+	r.Detail = fmt.Sprintf("%s answered %s", APIURL, resp.Status)
+	return []Result{r}
+}
+
+// CheckCache checks that the cache directory can be written to
+func CheckCache(context.Context) []Result {
+	r := Result{Name: "cache directory", Status: OK}
+	dir, err := CacheDir()
+	if err == nil {
+		err = writable(dir)
+	}
+	if err != nil {
+		r.Status, r.Detail, r.Fix = Fail, err.Error(), "Make it writable, or point XDG_CACHE_HOME somewhere that is"
+		return []Result{r}
+	}
+	r.Detail = dir
+	return []Result{r}
+}
+
+// CacheDir returns the directory the tools cache under
+func CacheDir() (string, error) {
+	cacheHome, err := paths.CacheHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheHome, "git-this-bread"), nil
+}
+
+// writable creates dir if needed and a file in it, then removes the file
+func writable(dir string) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
+
+// CheckNerdFont guesses whether a Nerd Font, which the icons need, is
+// installed. Which font the terminal uses can't be told, so this is at most
+// a warning.
+func CheckNerdFont(ctx context.Context) []Result {
+	r := Result{Name: "Nerd Font", Status: OK}
+	found, known := nerdFontInstalled(ctx)
+	switch {
+	case found:
+		r.Detail = "installed (make sure your terminal uses it)"
+	case known:
+		r.Status, r.Detail = Warn, "none installed; icons will show as boxes or blanks"
+		r.Fix = "Install a Nerd Font (https://www.nerdfonts.com) and set your terminal to use it, or use git-explain --ascii"
+	default:
+		r.Status, r.Detail = Warn, "can't tell whether one is installed"
+		r.Fix = "If icons show as boxes, install a Nerd Font (https://www.nerdfonts.com) and set your terminal to use it, or use git-explain --ascii"
+	}
+	return []Result{r}
+}
+
+// nerdFontInstalled looks for a Nerd Font with fc-list, or on macOS in the
+// font directories. known is false when there was nowhere to look.
+func nerdFontInstalled(ctx context.Context) (found, known bool) {
+	if _, err := exec.LookPath("fc-list"); err == nil {
+		out, err := debuglog.Output(exec.CommandContext(ctx, "fc-list", ":", "family"))
+		if err == nil {
+			return HasNerdFont(strings.Split(string(out), "\n")), true
+		}
+	}
+	if runtime.GOOS == "darwin" {
+		dirs := []string{"/Library/Fonts"}
+		if home, err := paths.Home(); err == nil {
+			dirs = append(dirs, filepath.Join(home, "Library", "Fonts"))
+		}
+		var names []string
+		for _, dir := range dirs {
+			entries, _ := os.ReadDir(dir)
+			for _, e := range entries {
+				names = append(names, e.Name())
+			}
+		}
+		return HasNerdFont(names), true
+	}
+	return false, false
+}
+
+// HasNerdFont reports whether any of the font family or file names is a
+// Nerd Font, which are named "... Nerd Font" or "...NerdFont..."
+func HasNerdFont(names []string) bool {
+	for _, name := range names {
+		lower := strings.ToLower(name)
+		if strings.Contains(lower, "nerd font") || strings.Contains(lower, "nerdfont") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want [3]int
+		ok   bool
+	}{
+		{"git version 2.43.0", [3]int{2, 43, 0}, true},
+		{"git version 2.39.3 (Apple Git-145)", [3]int{2, 39, 3}, true},
+		{"git version 2.45.1.windows.1", [3]int{2, 45, 1}, true},
+		{"gh version 2.45.0 (2024-03-04)\nhttps://github.com/cli/cli/releases/tag/v2.45.0", [3]int{2, 45, 0}, true},
+		{"tool 3.1", [3]int{3, 1, 0}, true},
+		{"no version here", [3]int{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseVersion(tt.in)
+		assert.Equal(t, tt.ok, ok, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+}
+
+func TestVersionStatus(t *testing.T) {
+	minVersion := [3]int{2, 40, 0}
+	tests := []struct {
+		out, detail string
+		status      Status
+	}{
+		{"gh version 2.40.0", "2.40.0", OK},
+		{"gh version 3.0.0", "3.0.0", OK},
+		{"gh version 2.39.9", "2.39.9 is older than 2.40.0", Warn},
+		{"gh version 1.99.0", "1.99.0 is older than 2.40.0", Warn},
+		{"gh (devel)", `unknown version "gh (devel)"`, Warn},
+	}
+	for _, tt := range tests {
+		status, detail := versionStatus(tt.out, minVersion, Warn)
+		assert.Equal(t, tt.status, status, tt.out)
+		assert.Equal(t, tt.detail, detail, tt.out)
+	}
+}
+
+func TestHasNerdFont(t *testing.T) {
+	assert.True(t, HasNerdFont([]string{"DejaVu Sans", "JetBrainsMono Nerd Font,JetBrainsMono NF"}))
+	assert.True(t, HasNerdFont([]string{"HackNerdFont-Regular.ttf"}))
+	assert.False(t, HasNerdFont([]string{"DejaVu Sans", "Noto Color Emoji", ""}))
+	assert.False(t, HasNerdFont(nil))
+}
+
+func TestCheckCache(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	results := CheckCache(context.Background())
+	require.Len(t, results, 1)
+	assert.Equal(t, OK, results[0].Status)
+	assert.Equal(t, filepath.Join(dir, "git-this-bread"), results[0].Detail)
+
+	entries, err := os.ReadDir(results[0].Detail)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the test file is removed")
+
+	require.NoError(t, os.Chmod(results[0].Detail, 0o500))
+	t.Cleanup(func() { _ = os.Chmod(results[0].Detail, 0o700) })
+	if os.Geteuid() == 0 {
+		t.Skip("root can write anywhere")
+	}
+	results = CheckCache(context.Background())
+	assert.Equal(t, Fail, results[0].Status)
+	assert.NotEmpty(t, results[0].Fix)
+}
+
+func TestCheckConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	results := CheckConfig(context.Background())
+	assert.Equal(t, []Result{{Name: "config.toml", Status: OK, Detail: "none, using the defaults"}}, results)
+
+	path := filepath.Join(dir, "git-this-bread", "config.toml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+	require.NoError(t, os.WriteFile(path, []byte("[explain\n"), 0o600))
+	results = CheckConfig(context.Background())
+	assert.Equal(t, Fail, results[0].Status)
+	assert.Equal(t, "Fix or remove "+path, results[0].Fix)
+}
+
+func TestCheckAPI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	orig := APIURL
+	t.Cleanup(func() { APIURL = orig })
+
+	APIURL = srv.URL
+	results := CheckAPI(context.Background())
+	assert.Equal(t, OK, results[0].Status, "any answer means it can be reached")
+	assert.Contains(t, results[0].Detail, "401")
+
+	srv.Close()
+	results = CheckAPI(context.Background())
+	assert.Equal(t, Fail, results[0].Status)
+	assert.Contains(t, results[0].Fix, "network")
+}
+
+func TestRun(t *testing.T) {
+	one := func(name string) Check {
+		return func(context.Context) []Result { return []Result{{Name: name, Status: OK}} }
+	}
+	results := Run(context.Background(), []Check{one("a"), func(context.Context) []Result { return nil }, one("b")})
+	assert.Equal(t, []Result{{Name: "a", Status: OK}, {Name: "b", Status: OK}}, results)
+}
+
+func TestCheckContext(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	gitconfig := "[identity \"acme\"]\n\temail = me@acme.test\n\tcontext = client-a\n" +
+		"[identity \"acme-ops\"]\n\temail = ops@acme.test\n\tcontext = client-a\n" +
+		"[identity \"personal\"]\n\temail = me@example.com\n"
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".gitconfig"), []byte(gitconfig), 0o600))
+
+	results := CheckContext("client-a")(context.Background())
+	require.Len(t, results, 2)
+	assert.Equal(t, "profile acme", results[0].Name)
+	assert.Equal(t, "profile acme-ops", results[1].Name)
+	assert.Equal(t, Fail, results[0].Status, "no SSH key")
+	assert.Equal(t, "git-id set acme sshkey <path>", results[0].Fix)
+
+	results = CheckContext("client-b")(context.Background())
+	assert.Equal(t, []Result{{
+		Name: "context client-b", Status: Fail, Detail: "no profiles",
+		Fix: "git-id set <profile> context client-b",
+	}}, results)
+}
+
+func TestWrite(t *testing.T) {
+	results := []Result{
+		{Name: "git", Status: OK, Detail: "2.45.0"},
+		{Name: "profile work", Status: Fail, Detail: "no email", Fix: "git-id set work email <email>"},
+	}
+	var buf bytes.Buffer
+	Write(&buf, results)
+	assert.Contains(t, buf.String(), "no email")
+	assert.Contains(t, buf.String(), "To fix:\n  1. git-id set work email <email>\n")
+	assert.Equal(t, 1, Failed(results))
+
+	buf.Reset()
+	Write(&buf, results[:1])
+	assert.Contains(t, buf.String(), "Everything is in place")
+	assert.Zero(t, Failed(results[:1]))
+}
@@ -0,0 +1,59 @@
+package doctor
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jdevera/git-this-bread/internal/render"
+)
+
+var marks = map[Status]struct {
+	icon string
+	role render.Role
+}{
+	OK:   {"✓", render.RoleSuccess},
+	Warn: {"!", render.RoleWarning},
+	Fail: {"✗", render.RoleError},
+}
+
+// Failed counts the results that failed
+func Failed(results []Result) int {
+	failed := 0
+	for i := range results {
+		if results[i].Status == Fail {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Write lists results to w, one per line, and then how to fix the ones
+// that aren't OK
+func Write(w io.Writer, results []Result) {
+	width := 0
+	for i := range results {
+		width = max(width, render.Width(results[i].Name))
+	}
+
+	var fixes []string
+	dim := render.Style(render.RoleMuted)
+	for i := range results {
+		r := &results[i]
+		mark := marks[r.Status]
+		fmt.Fprintf(w, "%s %s  %s\n", render.Style(mark.role).Render(mark.icon), render.PadRight(r.Name, width), dim.Render(r.Detail))
+		if r.Status != OK && r.Fix != "" {
+			fixes = append(fixes, r.Fix)
+		}
+	}
+
+	if len(fixes) == 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, render.Style(render.RoleSuccess).Render("✓ Everything is in place"))
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, render.Style(render.RoleAccent).Bold(true).Render("To fix:"))
+	for i, fix := range fixes {
+		fmt.Fprintf(w, "  %d. %s\n", i+1, fix)
+	}
+}
@@ -0,0 +1,310 @@
+// Package forge hides which code hosting service a repository lives on.
+// GitHub, GitLab and Gitea (or Forgejo) each implement Forge, so gh-wtfork
+// and the identity checks work the same on all of them.
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+// Kind names a forge implementation
+type Kind string
+
+// Supported forges
+const (
+	GitHub Kind = "github"
+	GitLab Kind = "gitlab"
+	Gitea  Kind = "gitea"
+)
+
+// defaultHosts are used when a spec names no host. Gitea has no main
+// instance, so it always needs one.
+var defaultHosts = map[Kind]string{
+	GitHub: "github.com",
+	GitLab: "gitlab.com",
+}
+
+// PR states, the same on every forge
+const (
+	PROpen   = "OPEN"
+	PRMerged = "MERGED"
+	PRClosed = "CLOSED"
+)
+
+// Issue states, the same on every forge
+const (
+	IssueOpen   = "OPEN"
+	IssueClosed = "CLOSED"
+)
+
+// Spec says which forge to talk to, and as whom
+type Spec struct {
+	Kind Kind
+	Host string
+	User string // Account to act as; empty for the default credentials
+}
+
+// ParseSpec reads a forge as written in profiles and flags: "github",
+// "gitlab", "gitlab:gitlab.example.com" or "gitea:codeberg.org". An empty
+// string is GitHub.
+func ParseSpec(s string) (Spec, error) {
+	if s == "" {
+		s = string(GitHub)
+	}
+	kind, host, _ := strings.Cut(s, ":")
+	spec := Spec{Kind: Kind(strings.ToLower(kind)), Host: host}
+	switch spec.Kind {
+	case GitHub:
+		if host != "" && host != defaultHosts[GitHub] {
+			return Spec{}, fmt.Errorf("forge %q: only github.com is supported for GitHub", s)
+		}
+	case GitLab, Gitea:
+	default:
+		return Spec{}, fmt.Errorf("unknown forge %q (use github, gitlab[:host] or gitea:host)", s)
+	}
+	if spec.Host == "" {
+		spec.Host = defaultHosts[spec.Kind]
+	}
+	if spec.Host == "" {
+		return Spec{}, fmt.Errorf("forge %q needs a host, like %s:codeberg.org", s, spec.Kind)
+	}
+	return spec, nil
+}
+
+// String formats the spec as ParseSpec reads it, leaving out default hosts
+func (s Spec) String() string {
+	if s.Host == defaultHosts[s.Kind] {
+		return string(s.Kind)
+	}
+	return string(s.Kind) + ":" + s.Host
+}
+
+// BranchURL returns the web page of a branch of the repository at repoURL
+func (s Spec) BranchURL(repoURL, branch string) string {
+	path := (&url.URL{Path: branch}).EscapedPath()
+	switch s.Kind {
+	case GitLab:
+		return repoURL + "/-/tree/" + path
+	case Gitea:
+		return repoURL + "/src/branch/" + path
+	}
+	return repoURL + "/tree/" + path
+}
+
+// Repo is a repository on a forge
+type Repo struct {
+	ID            int64 // Numeric ID, for forges whose API needs it (GitLab)
+	Name          string
+	FullName      string // owner/name; group/subgroup/name on GitLab
+	URL           string
+	DefaultBranch string
+	Archived      bool   // Read-only, kept for reference
+	Parent        *Repo  // The repository this one is a fork of
+	Template      *Repo  // The template this one was generated from, when listed by ListGenerated
+	Created       string // ISO 8601, or empty when unknown
+	Pushed        string // ISO 8601 date of the last push, or empty when unknown
+}
+
+// Owner returns the user or namespace the repository belongs to
+func (r *Repo) Owner() string {
+	if i := strings.LastIndex(r.FullName, "/"); i >= 0 {
+		return r.FullName[:i]
+	}
+	return r.FullName
+}
+
+// Branch is a branch and the date of its last commit
+type Branch struct {
+	Name string
+	Date string // ISO 8601, or empty when unknown
+}
+
+// PR is a pull request, or a merge request on GitLab
+type PR struct {
+	Number int
+	Title  string
+	State  string // PROpen, PRMerged or PRClosed
+	URL    string
+	Branch string // Head branch, in the fork
+}
+
+// Issue is an issue, or a GitHub discussion, opened in a repository
+type Issue struct {
+	Number     int
+	Title      string
+	State      string // IssueOpen or IssueClosed
+	URL        string
+	Discussion bool // A GitHub discussion rather than an issue
+}
+
+// Gist is a gist of the authenticated user
+type Gist struct {
+	ID          string
+	Description string
+	URL         string
+	Public      bool
+	Files       []string // File names, sorted
+	Created     string   // ISO 8601
+	Updated     string   // ISO 8601 date of the last change
+}
+
+// Forge is the API of a code hosting service, as the tools use it
+type Forge interface {
+	// Spec returns the forge and account the client talks to
+	Spec() Spec
+	// AuthStatus returns the login the credentials belong to, or why
+	// they don't work
+	AuthStatus(ctx context.Context) (string, error)
+	// ListForks returns the authenticated user's forks, with their parents
+	ListForks(ctx context.Context) ([]Repo, error)
+	// Compare counts the commits branch of fork is ahead of and behind the
+	// same branch of its parent
+	Compare(ctx context.Context, fork *Repo, branch string) (ahead, behind int, err error)
+	// LastCommitDate returns the ISO 8601 date of the last commit on branch
+	LastCommitDate(ctx context.Context, repo *Repo, branch string) (string, error)
+	// ListBranches returns every branch of repo. The default branch may be
+	// left undated.
+	ListBranches(ctx context.Context, repo *Repo) ([]Branch, error)
+	// ListPRs returns the pull requests opened from fork's owner against its
+	// parent
+	ListPRs(ctx context.Context, fork *Repo) ([]PR, error)
+	// ListIssues returns the issues, and discussions where the forge has
+	// them, opened by fork's owner in its parent
+	ListIssues(ctx context.Context, fork *Repo) ([]Issue, error)
+}
+
+// Extras is implemented by the forges that can also list what, besides
+// forks, piles up on an account: repositories generated from templates, and
+// gists. Only GitHub has both.
+type Extras interface {
+	// ListGenerated returns the authenticated user's repositories that were
+	// generated from a template, with their Template, Created and Pushed set
+	ListGenerated(ctx context.Context) ([]Repo, error)
+	// ListGists returns the authenticated user's gists, public and secret
+	ListGists(ctx context.Context) ([]Gist, error)
+}
+
+// New returns a client for spec, with credentials for spec.User
+func New(spec Spec) (Forge, error) {
+	var f Forge
+	var err error
+	switch spec.Kind {
+	case GitHub:
+		f, err = newGitHub(spec)
+	case GitLab:
+		f, err = newGitLab(spec)
+	case Gitea:
+		f, err = newGitea(spec)
+	default:
+		err = fmt.Errorf("unknown forge %q", spec.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ForProfile returns the forge of an identity profile, as its GHUser
+func ForProfile(p *identity.Profile) (Spec, error) {
+	spec, err := ParseSpec(p.Forge)
+	if err != nil {
+		return Spec{}, fmt.Errorf("profile %q: %w", p.Name, err)
+	}
+	spec.User = p.GHUser
+	return spec, nil
+}
+
+// Account is a username on a forge host
+type Account struct {
+	Host string
+	User string
+}
+
+// Accounts returns the usernames of a profile on every forge host it
+// names: ghuser on its forge's host, gluser on gitlab.com, btuser on
+// bitbucket.org and each host.<host>.user
+func Accounts(p *identity.Profile) []Account {
+	var accounts []Account
+	add := func(host, user string) {
+		if user != "" {
+			accounts = append(accounts, Account{Host: strings.ToLower(host), User: user})
+		}
+	}
+	if spec, err := ForProfile(p); err == nil {
+		add(spec.Host, p.GHUser)
+	}
+	add(defaultHosts[GitLab], p.GLUser)
+	add("bitbucket.org", p.BTUser)
+	hosts := make([]string, 0, len(p.Hosts))
+	for host := range p.Hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		add(host, p.Hosts[host])
+	}
+	return accounts
+}
+
+// AllAccounts returns the forge accounts of every profile, once each
+func AllAccounts() []Account {
+	names, err := identity.List()
+	if err != nil {
+		return nil
+	}
+	var accounts []Account
+	seen := map[Account]bool{}
+	for _, name := range names {
+		p, err := identity.Get(name)
+		if err != nil {
+			continue
+		}
+		for _, a := range Accounts(p) {
+			if key := (Account{Host: a.Host, User: strings.ToLower(a.User)}); !seen[key] {
+				seen[key] = true
+				accounts = append(accounts, a)
+			}
+		}
+	}
+	return accounts
+}
+
+// GitHubAccounts returns the GitHub users of every profile, once each
+func GitHubAccounts() []string {
+	names, err := identity.List()
+	if err != nil {
+		return nil
+	}
+	var users []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		p, err := identity.Get(name)
+		if err != nil || p.GHUser == "" || seen[strings.ToLower(p.GHUser)] {
+			continue
+		}
+		if spec, err := ForProfile(p); err == nil && spec.Kind == GitHub {
+			seen[strings.ToLower(p.GHUser)] = true
+			users = append(users, p.GHUser)
+		}
+	}
+	return users
+}
+
+// CheckAuth checks that f's credentials work and belong to the spec's user,
+// when one is set
+func CheckAuth(ctx context.Context, f Forge) error {
+	login, err := f.AuthStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if user := f.Spec().User; user != "" && !strings.EqualFold(login, user) {
+		return fmt.Errorf("%s credentials belong to %q, not %q", f.Spec(), login, user)
+	}
+	return nil
+}
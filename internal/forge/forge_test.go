@@ -0,0 +1,203 @@
+package forge
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Spec
+		wantErr string
+	}{
+		{"", Spec{Kind: GitHub, Host: "github.com"}, ""},
+		{"github", Spec{Kind: GitHub, Host: "github.com"}, ""},
+		{"GitLab", Spec{Kind: GitLab, Host: "gitlab.com"}, ""},
+		{"gitlab:gitlab.example.com", Spec{Kind: GitLab, Host: "gitlab.example.com"}, ""},
+		{"gitea:codeberg.org", Spec{Kind: Gitea, Host: "codeberg.org"}, ""},
+		{"gitea", Spec{}, "needs a host"},
+		{"github:ghe.example.com", Spec{}, "only github.com"},
+		{"bitbucket", Spec{}, "unknown forge"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseSpec(tt.in)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSpecString(t *testing.T) {
+	assert.Equal(t, "github", Spec{Kind: GitHub, Host: "github.com"}.String())
+	assert.Equal(t, "gitlab", Spec{Kind: GitLab, Host: "gitlab.com"}.String())
+	assert.Equal(t, "gitlab:git.example.com", Spec{Kind: GitLab, Host: "git.example.com"}.String())
+}
+
+func TestBranchURL(t *testing.T) {
+	assert.Equal(t, "https://github.com/me/app/tree/fix/login", Spec{Kind: GitHub}.BranchURL("https://github.com/me/app", "fix/login"))
+	assert.Equal(t, "https://gitlab.com/me/app/-/tree/wip", Spec{Kind: GitLab}.BranchURL("https://gitlab.com/me/app", "wip"))
+	assert.Equal(t, "https://codeberg.org/me/app/src/branch/a%23b", Spec{Kind: Gitea}.BranchURL("https://codeberg.org/me/app", "a#b"))
+}
+
+func TestRepoOwner(t *testing.T) {
+	assert.Equal(t, "jdevera", (&Repo{FullName: "jdevera/acme.sh"}).Owner())
+	assert.Equal(t, "group/sub", (&Repo{FullName: "group/sub/project"}).Owner())
+}
+
+func TestAccounts(t *testing.T) {
+	p := &identity.Profile{
+		Name:   "work",
+		GHUser: "me-work",
+		Forge:  "gitlab:git.example.com",
+		GLUser: "me",
+		BTUser: "me-bt",
+		Hosts:  map[string]string{"Gitea.Example.com": "jdoe"},
+	}
+	assert.Equal(t, []Account{
+		{Host: "git.example.com", User: "me-work"},
+		{Host: "gitlab.com", User: "me"},
+		{Host: "bitbucket.org", User: "me-bt"},
+		{Host: "gitea.example.com", User: "jdoe"},
+	}, Accounts(p))
+
+	assert.Empty(t, Accounts(&identity.Profile{Name: "bare", Email: "me@example.com"}))
+}
+
+// serve answers each request path (with its query) from responses
+func serve(t *testing.T, responses map[string]string) *restClient {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.RequestURI()]
+		if !ok {
+			t.Errorf("unexpected request %s", r.URL.RequestURI())
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if next, ok := responses["next:"+r.URL.RequestURI()]; ok {
+			w.Header().Set("Link", `<`+srv.URL+next+`>; rel="next"`)
+		}
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return newRESTClient(srv.URL, http.Header{"Private-Token": {"secret"}})
+}
+
+func TestGitLab(t *testing.T) {
+	ctx := context.Background()
+	g := &gitLab{spec: Spec{Kind: GitLab, Host: "gitlab.com", User: "me"}, api: serve(t, map[string]string{
+		"/user": `{"username":"me"}`,
+		"/projects?owned=true&per_page=100": `[
+			{"id":1,"name":"tool","path_with_namespace":"me/tool","web_url":"https://gitlab.com/me/tool","default_branch":"main",
+			 "forked_from_project":{"id":9,"name":"tool","path_with_namespace":"upstream/tool","default_branch":"main"}}]`,
+		"next:/projects?owned=true&per_page=100":                             "/projects?owned=true&per_page=100&page=2",
+		"/projects?owned=true&per_page=100&page=2":                           `[{"id":2,"name":"mine","path_with_namespace":"me/mine"}]`,
+		"/projects/1/repository/compare?from=main&to=main&from_project_id=9": `{"commits":[{},{}]}`,
+		"/projects/9/repository/compare?from=main&to=main&from_project_id=1": `{"commits":[{},{},{},{},{}]}`,
+		"/projects/1/repository/branches?per_page=100": `[
+			{"name":"main","commit":{"committed_date":"2026-01-02T03:04:05Z"}},
+			{"name":"fix","commit":{"committed_date":"2026-02-03T04:05:06Z"}}]`,
+		"/projects/9/merge_requests?state=all&author_username=me&per_page=100": `[
+			{"iid":7,"title":"Fix it","state":"merged","web_url":"https://gitlab.com/upstream/tool/-/merge_requests/7","source_branch":"fix","source_project_id":1},
+			{"iid":8,"title":"From elsewhere","state":"opened","source_branch":"x","source_project_id":3}]`,
+		"/projects/9/issues?scope=all&author_username=me&per_page=100": `[
+			{"iid":3,"title":"Crash on start","state":"opened","web_url":"https://gitlab.com/upstream/tool/-/issues/3"},
+			{"iid":1,"title":"Typo","state":"closed"}]`,
+	})}
+
+	require.NoError(t, CheckAuth(ctx, g))
+
+	forks, err := g.ListForks(ctx)
+	require.NoError(t, err)
+	require.Len(t, forks, 1, "projects that aren't forks are left out")
+	fork := forks[0]
+	assert.Equal(t, "me/tool", fork.FullName)
+	assert.Equal(t, int64(9), fork.Parent.ID)
+
+	ahead, behind, err := g.Compare(ctx, &fork, "main")
+	require.NoError(t, err)
+	assert.Equal(t, 2, ahead)
+	assert.Equal(t, 5, behind)
+
+	branches, err := g.ListBranches(ctx, &fork)
+	require.NoError(t, err)
+	assert.Equal(t, []Branch{{"main", "2026-01-02T03:04:05Z"}, {"fix", "2026-02-03T04:05:06Z"}}, branches)
+
+	prs, err := g.ListPRs(ctx, &fork)
+	require.NoError(t, err)
+	assert.Equal(t, []PR{{Number: 7, Title: "Fix it", State: PRMerged,
+		URL: "https://gitlab.com/upstream/tool/-/merge_requests/7", Branch: "fix"}}, prs)
+
+	issues, err := g.ListIssues(ctx, &fork)
+	require.NoError(t, err)
+	assert.Equal(t, []Issue{
+		{Number: 3, Title: "Crash on start", State: IssueOpen, URL: "https://gitlab.com/upstream/tool/-/issues/3"},
+		{Number: 1, Title: "Typo", State: IssueClosed},
+	}, issues)
+}
+
+func TestGitea(t *testing.T) {
+	ctx := context.Background()
+	g := &gitea{spec: Spec{Kind: Gitea, Host: "codeberg.org"}, api: serve(t, map[string]string{
+		"/user": `{"login":"me"}`,
+		"/user/repos?limit=50": `[
+			{"id":1,"name":"tool","full_name":"me/tool","default_branch":"main","fork":true,"archived":true,"owner":{"login":"me"},
+			 "parent":{"id":9,"name":"tool","full_name":"upstream/tool","default_branch":"dev"}},
+			{"id":2,"name":"team","full_name":"org/team","fork":true,"owner":{"login":"org"},"parent":{"full_name":"x/team"}}]`,
+		"/repos/upstream/tool/compare/main...me:main": `{"total_commits":1}`,
+		"/repos/me/tool/compare/main...upstream:main": `{"total_commits":4}`,
+		"/repos/upstream/tool/branches/dev":           `{"name":"dev","commit":{"timestamp":"2026-03-04T05:06:07Z"}}`,
+		"/repos/upstream/tool/pulls?state=all&limit=50": `[
+			{"number":3,"title":"Add","state":"closed","merged":true,"head":{"ref":"add","repo":{"full_name":"me/tool"}}},
+			{"number":4,"title":"Try","state":"closed","merged":false,"head":{"ref":"try","repo":{"full_name":"me/tool"}}},
+			{"number":5,"title":"Other","state":"open","head":{"ref":"x","repo":{"full_name":"else/tool"}}}]`,
+		"/repos/upstream/tool/issues?type=issues&state=all&created_by=me&limit=50": `[{"number":6,"title":"Docs","state":"open"}]`,
+	})}
+
+	forks, err := g.ListForks(ctx)
+	require.NoError(t, err)
+	require.Len(t, forks, 1, "forks owned by others are left out")
+	fork := forks[0]
+	assert.True(t, fork.Archived)
+
+	ahead, behind, err := g.Compare(ctx, &fork, "main")
+	require.NoError(t, err)
+	assert.Equal(t, 1, ahead)
+	assert.Equal(t, 4, behind)
+
+	date, err := g.LastCommitDate(ctx, fork.Parent, fork.Parent.DefaultBranch)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-04T05:06:07Z", date)
+
+	prs, err := g.ListPRs(ctx, &fork)
+	require.NoError(t, err)
+	require.Len(t, prs, 2)
+	assert.Equal(t, PRMerged, prs[0].State)
+	assert.Equal(t, PRClosed, prs[1].State)
+	assert.Equal(t, "try", prs[1].Branch)
+
+	issues, err := g.ListIssues(ctx, &fork)
+	require.NoError(t, err)
+	assert.Equal(t, []Issue{{Number: 6, Title: "Docs", State: IssueOpen}}, issues)
+}
+
+func TestCheckAuth_WrongUser(t *testing.T) {
+	g := &gitea{spec: Spec{Kind: Gitea, Host: "codeberg.org", User: "work"}, api: serve(t, map[string]string{
+		"/user": `{"login":"personal"}`,
+	})}
+	assert.ErrorContains(t, CheckAuth(context.Background(), g), `belong to "personal", not "work"`)
+}
@@ -0,0 +1,206 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// gitea talks to the Gitea REST API, which Forgejo (Codeberg) shares, with
+// GITEA_TOKEN or FORGEJO_TOKEN
+type gitea struct {
+	spec Spec
+	api  *restClient
+}
+
+func newGitea(spec Spec) (*gitea, error) {
+	token := os.Getenv("GITEA_TOKEN")
+	if token == "" {
+		token = os.Getenv("FORGEJO_TOKEN")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("no token for %s. Create one in its user settings and set GITEA_TOKEN", spec.Host)
+	}
+	header := http.Header{"Authorization": {"token " + token}}
+	return &gitea{spec: spec, api: newRESTClient("https://"+spec.Host+"/api/v1", header)}, nil
+}
+
+func (g *gitea) Spec() Spec {
+	return g.spec
+}
+
+func (g *gitea) AuthStatus(ctx context.Context) (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if _, err := g.api.get(ctx, "user", &user); err != nil {
+		return "", err
+	}
+	return user.Login, nil
+}
+
+type giteaRepo struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	URL           string `json:"html_url"`
+	DefaultBranch string `json:"default_branch"`
+	Archived      bool   `json:"archived"`
+}
+
+func (r *giteaRepo) repo() Repo {
+	return Repo{ID: r.ID, Name: r.Name, FullName: r.FullName, URL: r.URL, DefaultBranch: r.DefaultBranch, Archived: r.Archived}
+}
+
+func (g *gitea) ListForks(ctx context.Context) ([]Repo, error) {
+	login, err := g.AuthStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	type repo struct {
+		giteaRepo
+		Fork   bool       `json:"fork"`
+		Parent *giteaRepo `json:"parent"`
+		Owner  struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	}
+	repos, err := getAll[repo](ctx, g.api, "user/repos?limit=50")
+	if err != nil {
+		return nil, err
+	}
+
+	var forks []Repo
+	for i := range repos {
+		r := &repos[i]
+		// user/repos also lists repositories the user collaborates on
+		if !r.Fork || r.Parent == nil || r.Owner.Login != login {
+			continue
+		}
+		fork := r.repo()
+		parent := r.Parent.repo()
+		fork.Parent = &parent
+		forks = append(forks, fork)
+	}
+	return forks, nil
+}
+
+// Compare uses cross-repository compares, "branch...owner:branch", from
+// each side
+func (g *gitea) Compare(ctx context.Context, fork *Repo, branch string) (ahead, behind int, err error) {
+	if fork.Parent == nil {
+		return 0, 0, fmt.Errorf("%s is not a fork", fork.FullName)
+	}
+	count := func(base *Repo, headOwner string) (int, error) {
+		var c struct {
+			TotalCommits int `json:"total_commits"`
+		}
+		path := fmt.Sprintf("repos/%s/compare/%s...%s:%s",
+			base.FullName, url.PathEscape(branch), url.PathEscape(headOwner), url.PathEscape(branch))
+		_, err := g.api.get(ctx, path, &c)
+		return c.TotalCommits, err
+	}
+	if ahead, err = count(fork.Parent, fork.Owner()); err != nil {
+		return 0, 0, err
+	}
+	if behind, err = count(fork, fork.Parent.Owner()); err != nil {
+		return 0, 0, err
+	}
+	return ahead, behind, nil
+}
+
+type giteaBranch struct {
+	Name   string `json:"name"`
+	Commit struct {
+		Date string `json:"timestamp"`
+	} `json:"commit"`
+}
+
+func (g *gitea) LastCommitDate(ctx context.Context, repo *Repo, branch string) (string, error) {
+	var b giteaBranch
+	if _, err := g.api.get(ctx, fmt.Sprintf("repos/%s/branches/%s", repo.FullName, url.PathEscape(branch)), &b); err != nil {
+		return "", err
+	}
+	return b.Commit.Date, nil
+}
+
+func (g *gitea) ListBranches(ctx context.Context, repo *Repo) ([]Branch, error) {
+	raw, err := getAll[giteaBranch](ctx, g.api, fmt.Sprintf("repos/%s/branches?limit=50", repo.FullName))
+	if err != nil {
+		return nil, err
+	}
+	branches := make([]Branch, 0, len(raw))
+	for _, b := range raw {
+		branches = append(branches, Branch{Name: b.Name, Date: b.Commit.Date})
+	}
+	return branches, nil
+}
+
+func (g *gitea) ListPRs(ctx context.Context, fork *Repo) ([]PR, error) {
+	if fork.Parent == nil {
+		return nil, fmt.Errorf("%s is not a fork", fork.FullName)
+	}
+	type pull struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		State  string `json:"state"`
+		Merged bool   `json:"merged"`
+		URL    string `json:"html_url"`
+		Head   struct {
+			Ref  string `json:"ref"`
+			Repo *struct {
+				FullName string `json:"full_name"`
+			} `json:"repo"`
+		} `json:"head"`
+	}
+	pulls, err := getAll[pull](ctx, g.api, fmt.Sprintf("repos/%s/pulls?state=all&limit=50", fork.Parent.FullName))
+	if err != nil {
+		return nil, err
+	}
+
+	var prs []PR
+	for _, p := range pulls {
+		if p.Head.Repo == nil || p.Head.Repo.FullName != fork.FullName {
+			continue
+		}
+		state := PROpen
+		switch {
+		case p.Merged:
+			state = PRMerged
+		case p.State == "closed":
+			state = PRClosed
+		}
+		prs = append(prs, PR{Number: p.Number, Title: p.Title, State: state, URL: p.URL, Branch: p.Head.Ref})
+	}
+	return prs, nil
+}
+
+func (g *gitea) ListIssues(ctx context.Context, fork *Repo) ([]Issue, error) {
+	if fork.Parent == nil {
+		return nil, fmt.Errorf("%s is not a fork", fork.FullName)
+	}
+	type issue struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		State  string `json:"state"`
+		URL    string `json:"html_url"`
+	}
+	path := fmt.Sprintf("repos/%s/issues?type=issues&state=all&created_by=%s&limit=50",
+		fork.Parent.FullName, url.QueryEscape(fork.Owner()))
+	found, err := getAll[issue](ctx, g.api, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	for _, i := range found {
+		state := IssueOpen
+		if i.State == "closed" {
+			state = IssueClosed
+		}
+		issues = append(issues, Issue{Number: i.Number, Title: i.Title, State: state, URL: i.URL})
+	}
+	return issues, nil
+}
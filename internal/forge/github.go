@@ -0,0 +1,388 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/jdevera/git-this-bread/internal/ghapi"
+)
+
+// gitHub talks to GitHub through internal/ghapi, with gh's credentials
+type gitHub struct {
+	spec Spec
+	api  *ghapi.Client
+}
+
+func newGitHub(spec Spec) (*gitHub, error) {
+	client, err := ghapi.New(spec.User)
+	if err != nil {
+		return nil, err
+	}
+	return &gitHub{spec: spec, api: client}, nil
+}
+
+func (g *gitHub) Spec() Spec {
+	return g.spec
+}
+
+func (g *gitHub) AuthStatus(ctx context.Context) (string, error) {
+	v, err := g.api.Viewer(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%w. %s", err, ghapi.LoginHint())
+	}
+	return v.Login, nil
+}
+
+// forksQuery lists the viewer's forks a page at a time
+const forksQuery = `query($cursor: String) {
+	viewer {
+		repositories(first: 100, after: $cursor, isFork: true, ownerAffiliations: OWNER) {
+			nodes {
+				name
+				nameWithOwner
+				url
+				isArchived
+				defaultBranchRef { name }
+				parent {
+					name
+					nameWithOwner
+					url
+					defaultBranchRef { name }
+				}
+			}
+			pageInfo { hasNextPage endCursor }
+		}
+	}
+}`
+
+type ghRepo struct {
+	Name          string `json:"name"`
+	FullName      string `json:"nameWithOwner"`
+	URL           string `json:"url"`
+	Archived      bool   `json:"isArchived"`
+	DefaultBranch struct {
+		Name string `json:"name"`
+	} `json:"defaultBranchRef"`
+}
+
+func (r *ghRepo) repo() Repo {
+	return Repo{Name: r.Name, FullName: r.FullName, URL: r.URL, DefaultBranch: r.DefaultBranch.Name, Archived: r.Archived}
+}
+
+func (g *gitHub) ListForks(ctx context.Context) ([]Repo, error) {
+	var forks []Repo
+	vars := map[string]any{"cursor": nil}
+	for {
+		var result struct {
+			Viewer struct {
+				Repositories struct {
+					Nodes []struct {
+						ghRepo
+						Parent *ghRepo `json:"parent"`
+					} `json:"nodes"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"repositories"`
+			} `json:"viewer"`
+		}
+		if err := g.api.Query(ctx, forksQuery, vars, &result); err != nil {
+			return nil, err
+		}
+
+		repos := result.Viewer.Repositories
+		for i := range repos.Nodes {
+			node := &repos.Nodes[i]
+			fork := node.repo()
+			if node.Parent != nil {
+				parent := node.Parent.repo()
+				fork.Parent = &parent
+			}
+			forks = append(forks, fork)
+		}
+		if !repos.PageInfo.HasNextPage {
+			return forks, nil
+		}
+		vars["cursor"] = repos.PageInfo.EndCursor
+	}
+}
+
+// generatedQuery lists the viewer's own repositories, with the template
+// each was generated from, a page at a time
+const generatedQuery = `query($cursor: String) {
+	viewer {
+		repositories(first: 100, after: $cursor, isFork: false, ownerAffiliations: OWNER) {
+			nodes {
+				name
+				nameWithOwner
+				url
+				isArchived
+				createdAt
+				pushedAt
+				defaultBranchRef { name }
+				templateRepository {
+					name
+					nameWithOwner
+					url
+					defaultBranchRef { name }
+				}
+			}
+			pageInfo { hasNextPage endCursor }
+		}
+	}
+}`
+
+func (g *gitHub) ListGenerated(ctx context.Context) ([]Repo, error) {
+	var generated []Repo
+	vars := map[string]any{"cursor": nil}
+	for {
+		var result struct {
+			Viewer struct {
+				Repositories struct {
+					Nodes []struct {
+						ghRepo
+						CreatedAt string  `json:"createdAt"`
+						PushedAt  string  `json:"pushedAt"`
+						Template  *ghRepo `json:"templateRepository"`
+					} `json:"nodes"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"repositories"`
+			} `json:"viewer"`
+		}
+		if err := g.api.Query(ctx, generatedQuery, vars, &result); err != nil {
+			return nil, err
+		}
+
+		repos := result.Viewer.Repositories
+		for i := range repos.Nodes {
+			node := &repos.Nodes[i]
+			if node.Template == nil {
+				continue
+			}
+			repo := node.repo()
+			template := node.Template.repo()
+			repo.Template = &template
+			repo.Created = node.CreatedAt
+			repo.Pushed = node.PushedAt
+			generated = append(generated, repo)
+		}
+		if !repos.PageInfo.HasNextPage {
+			return generated, nil
+		}
+		vars["cursor"] = repos.PageInfo.EndCursor
+	}
+}
+
+func (g *gitHub) ListGists(ctx context.Context) ([]Gist, error) {
+	type ghGist struct {
+		ID          string              `json:"id"`
+		Description string              `json:"description"`
+		URL         string              `json:"html_url"`
+		Public      bool                `json:"public"`
+		Files       map[string]struct{} `json:"files"`
+		CreatedAt   string              `json:"created_at"`
+		UpdatedAt   string              `json:"updated_at"`
+	}
+	raw, err := ghapi.GetAll[ghGist](ctx, g.api, "gists")
+	if err != nil {
+		return nil, err
+	}
+
+	gists := make([]Gist, 0, len(raw))
+	for _, r := range raw {
+		files := make([]string, 0, len(r.Files))
+		for name := range r.Files {
+			files = append(files, name)
+		}
+		sort.Strings(files)
+		gists = append(gists, Gist{
+			ID:          r.ID,
+			Description: r.Description,
+			URL:         r.URL,
+			Public:      r.Public,
+			Files:       files,
+			Created:     r.CreatedAt,
+			Updated:     r.UpdatedAt,
+		})
+	}
+	return gists, nil
+}
+
+func (g *gitHub) Compare(ctx context.Context, fork *Repo, branch string) (ahead, behind int, err error) {
+	if fork.Parent == nil {
+		return 0, 0, fmt.Errorf("%s is not a fork", fork.FullName)
+	}
+	endpoint := fmt.Sprintf("repos/%s/compare/%s:%s...%s:%s",
+		fork.Parent.FullName, fork.Parent.Owner(), branch, fork.Owner(), branch)
+
+	var c struct {
+		AheadBy  int `json:"ahead_by"`
+		BehindBy int `json:"behind_by"`
+	}
+	if err := g.api.Get(ctx, endpoint, &c); err != nil {
+		return 0, 0, err
+	}
+	return c.AheadBy, c.BehindBy, nil
+}
+
+// ghCommit is the part of a GitHub commit the tools read
+type ghCommit struct {
+	Commit struct {
+		Committer struct {
+			Date string `json:"date"`
+		} `json:"committer"`
+	} `json:"commit"`
+}
+
+func (g *gitHub) LastCommitDate(ctx context.Context, repo *Repo, branch string) (string, error) {
+	endpoint := fmt.Sprintf("repos/%s/commits?sha=%s&per_page=1", repo.FullName, url.QueryEscape(branch))
+	var commits []ghCommit
+	if err := g.api.Get(ctx, endpoint, &commits); err != nil {
+		return "", err
+	}
+	if len(commits) == 0 {
+		return "", fmt.Errorf("no commits on %s", branch)
+	}
+	return commits[0].Commit.Committer.Date, nil
+}
+
+func (g *gitHub) ListBranches(ctx context.Context, repo *Repo) ([]Branch, error) {
+	type ghBranch struct {
+		Name   string `json:"name"`
+		Commit struct {
+			SHA string `json:"sha"`
+		} `json:"commit"`
+	}
+	raw, err := ghapi.GetAll[ghBranch](ctx, g.api, fmt.Sprintf("repos/%s/branches", repo.FullName))
+	if err != nil {
+		return nil, err
+	}
+
+	branches := make([]Branch, 0, len(raw))
+	for _, b := range raw {
+		branch := Branch{Name: b.Name}
+		// The branch list has no dates; each needs its own request
+		if b.Name != repo.DefaultBranch {
+			var commit ghCommit
+			if err := g.api.Get(ctx, fmt.Sprintf("repos/%s/commits/%s", repo.FullName, b.Commit.SHA), &commit); err == nil {
+				branch.Date = commit.Commit.Committer.Date
+			}
+		}
+		branches = append(branches, branch)
+	}
+	return branches, nil
+}
+
+func (g *gitHub) ListPRs(ctx context.Context, fork *Repo) ([]PR, error) {
+	if fork.Parent == nil {
+		return nil, fmt.Errorf("%s is not a fork", fork.FullName)
+	}
+	query := `query($q: String!) {
+		search(query: $q, type: ISSUE, first: 100) {
+			nodes {
+				... on PullRequest {
+					number
+					title
+					state
+					url
+					headRefName
+				}
+			}
+		}
+	}`
+	search := fmt.Sprintf("is:pr repo:%s author:%s", fork.Parent.FullName, fork.Owner())
+
+	var result struct {
+		Search struct {
+			Nodes []struct {
+				Number      int    `json:"number"`
+				Title       string `json:"title"`
+				State       string `json:"state"`
+				URL         string `json:"url"`
+				HeadRefName string `json:"headRefName"`
+			} `json:"nodes"`
+		} `json:"search"`
+	}
+	if err := g.api.Query(ctx, query, map[string]any{"q": search}, &result); err != nil {
+		return nil, err
+	}
+
+	var prs []PR
+	for _, pr := range result.Search.Nodes {
+		if pr.Number == 0 {
+			continue // Search results that aren't pull requests
+		}
+		prs = append(prs, PR{Number: pr.Number, Title: pr.Title, State: pr.State, URL: pr.URL, Branch: pr.HeadRefName})
+	}
+	return prs, nil
+}
+
+func (g *gitHub) ListIssues(ctx context.Context, fork *Repo) ([]Issue, error) {
+	if fork.Parent == nil {
+		return nil, fmt.Errorf("%s is not a fork", fork.FullName)
+	}
+	query := `query($issues: String!, $discussions: String!) {
+		issues: search(query: $issues, type: ISSUE, first: 100) {
+			nodes {
+				... on Issue {
+					number
+					title
+					state
+					url
+				}
+			}
+		}
+		discussions: search(query: $discussions, type: DISCUSSION, first: 50) {
+			nodes {
+				... on Discussion {
+					number
+					title
+					closed
+					url
+				}
+			}
+		}
+	}`
+	search := fmt.Sprintf("repo:%s author:%s", fork.Parent.FullName, fork.Owner())
+
+	type node struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		State  string `json:"state"`
+		Closed bool   `json:"closed"`
+		URL    string `json:"url"`
+	}
+	var result struct {
+		Issues struct {
+			Nodes []node `json:"nodes"`
+		} `json:"issues"`
+		Discussions struct {
+			Nodes []node `json:"nodes"`
+		} `json:"discussions"`
+	}
+	vars := map[string]any{"issues": "is:issue " + search, "discussions": search}
+	if err := g.api.Query(ctx, query, vars, &result); err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	for _, n := range result.Issues.Nodes {
+		if n.Number != 0 {
+			issues = append(issues, Issue{Number: n.Number, Title: n.Title, State: n.State, URL: n.URL})
+		}
+	}
+	for _, n := range result.Discussions.Nodes {
+		state := IssueOpen
+		if n.Closed {
+			state = IssueClosed
+		}
+		issues = append(issues, Issue{Number: n.Number, Title: n.Title, State: state, URL: n.URL, Discussion: true})
+	}
+	return issues, nil
+}
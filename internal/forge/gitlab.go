@@ -0,0 +1,198 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+)
+
+// gitLab talks to the GitLab REST API, with GITLAB_TOKEN or glab's token
+type gitLab struct {
+	spec Spec
+	api  *restClient
+}
+
+func newGitLab(spec Spec) (*gitLab, error) {
+	token := os.Getenv("GITLAB_TOKEN")
+	if token == "" {
+		out, err := debuglog.Output(exec.Command("glab", "config", "get", "token", "--host", spec.Host))
+		token = strings.TrimSpace(string(out))
+		if err != nil || token == "" {
+			return nil, fmt.Errorf("no GitLab token for %s. Set GITLAB_TOKEN or run: glab auth login --hostname %s", spec.Host, spec.Host)
+		}
+	}
+	header := http.Header{"Private-Token": {token}}
+	return &gitLab{spec: spec, api: newRESTClient("https://"+spec.Host+"/api/v4", header)}, nil
+}
+
+func (g *gitLab) Spec() Spec {
+	return g.spec
+}
+
+func (g *gitLab) AuthStatus(ctx context.Context) (string, error) {
+	var user struct {
+		Username string `json:"username"`
+	}
+	if _, err := g.api.get(ctx, "user", &user); err != nil {
+		return "", err
+	}
+	return user.Username, nil
+}
+
+type glProject struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	FullName      string `json:"path_with_namespace"`
+	URL           string `json:"web_url"`
+	DefaultBranch string `json:"default_branch"`
+	Archived      bool   `json:"archived"`
+}
+
+func (p *glProject) repo() Repo {
+	return Repo{ID: p.ID, Name: p.Name, FullName: p.FullName, URL: p.URL, DefaultBranch: p.DefaultBranch, Archived: p.Archived}
+}
+
+func (g *gitLab) ListForks(ctx context.Context) ([]Repo, error) {
+	type project struct {
+		glProject
+		ForkedFrom *glProject `json:"forked_from_project"`
+	}
+	projects, err := getAll[project](ctx, g.api, "projects?owned=true&per_page=100")
+	if err != nil {
+		return nil, err
+	}
+
+	var forks []Repo
+	for i := range projects {
+		p := &projects[i]
+		if p.ForkedFrom == nil {
+			continue
+		}
+		fork := p.repo()
+		parent := p.ForkedFrom.repo()
+		fork.Parent = &parent
+		forks = append(forks, fork)
+	}
+	return forks, nil
+}
+
+// Compare asks each project for the commits it has that the other lacks
+func (g *gitLab) Compare(ctx context.Context, fork *Repo, branch string) (ahead, behind int, err error) {
+	if fork.Parent == nil {
+		return 0, 0, fmt.Errorf("%s is not a fork", fork.FullName)
+	}
+	count := func(project, fromProject int64) (int, error) {
+		var c struct {
+			Commits []struct{} `json:"commits"`
+		}
+		path := fmt.Sprintf("projects/%d/repository/compare?from=%s&to=%s&from_project_id=%d",
+			project, url.QueryEscape(branch), url.QueryEscape(branch), fromProject)
+		_, err := g.api.get(ctx, path, &c)
+		return len(c.Commits), err
+	}
+	if ahead, err = count(fork.ID, fork.Parent.ID); err != nil {
+		return 0, 0, err
+	}
+	if behind, err = count(fork.Parent.ID, fork.ID); err != nil {
+		return 0, 0, err
+	}
+	return ahead, behind, nil
+}
+
+type glBranch struct {
+	Name   string `json:"name"`
+	Commit struct {
+		Date string `json:"committed_date"`
+	} `json:"commit"`
+}
+
+func (g *gitLab) LastCommitDate(ctx context.Context, repo *Repo, branch string) (string, error) {
+	var b glBranch
+	if _, err := g.api.get(ctx, fmt.Sprintf("projects/%d/repository/branches/%s", repo.ID, url.PathEscape(branch)), &b); err != nil {
+		return "", err
+	}
+	return b.Commit.Date, nil
+}
+
+func (g *gitLab) ListBranches(ctx context.Context, repo *Repo) ([]Branch, error) {
+	raw, err := getAll[glBranch](ctx, g.api, fmt.Sprintf("projects/%d/repository/branches?per_page=100", repo.ID))
+	if err != nil {
+		return nil, err
+	}
+	branches := make([]Branch, 0, len(raw))
+	for _, b := range raw {
+		branches = append(branches, Branch{Name: b.Name, Date: b.Commit.Date})
+	}
+	return branches, nil
+}
+
+// glStates maps merge request states to PR states
+var glStates = map[string]string{
+	"opened": PROpen,
+	"merged": PRMerged,
+	"closed": PRClosed,
+	"locked": PRClosed,
+}
+
+func (g *gitLab) ListPRs(ctx context.Context, fork *Repo) ([]PR, error) {
+	if fork.Parent == nil {
+		return nil, fmt.Errorf("%s is not a fork", fork.FullName)
+	}
+	type mergeRequest struct {
+		IID           int    `json:"iid"`
+		Title         string `json:"title"`
+		State         string `json:"state"`
+		URL           string `json:"web_url"`
+		SourceBranch  string `json:"source_branch"`
+		SourceProject int64  `json:"source_project_id"`
+	}
+	path := fmt.Sprintf("projects/%d/merge_requests?state=all&author_username=%s&per_page=100",
+		fork.Parent.ID, url.QueryEscape(fork.Owner()))
+	mrs, err := getAll[mergeRequest](ctx, g.api, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var prs []PR
+	for _, mr := range mrs {
+		if mr.SourceProject != fork.ID {
+			continue
+		}
+		prs = append(prs, PR{Number: mr.IID, Title: mr.Title, State: glStates[mr.State], URL: mr.URL, Branch: mr.SourceBranch})
+	}
+	return prs, nil
+}
+
+func (g *gitLab) ListIssues(ctx context.Context, fork *Repo) ([]Issue, error) {
+	if fork.Parent == nil {
+		return nil, fmt.Errorf("%s is not a fork", fork.FullName)
+	}
+	type issue struct {
+		IID   int    `json:"iid"`
+		Title string `json:"title"`
+		State string `json:"state"`
+		URL   string `json:"web_url"`
+	}
+	path := fmt.Sprintf("projects/%d/issues?scope=all&author_username=%s&per_page=100",
+		fork.Parent.ID, url.QueryEscape(fork.Owner()))
+	found, err := getAll[issue](ctx, g.api, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	for _, i := range found {
+		state := IssueOpen
+		if i.State == "closed" {
+			state = IssueClosed
+		}
+		issues = append(issues, Issue{Number: i.IID, Title: i.Title, State: state, URL: i.URL})
+	}
+	return issues, nil
+}
@@ -0,0 +1,84 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+)
+
+// restClient makes JSON API requests for the forges go-gh doesn't cover
+type restClient struct {
+	base   string // API root, without a trailing slash
+	header http.Header
+	http   *http.Client
+}
+
+func newRESTClient(base string, header http.Header) *restClient {
+	return &restClient{
+		base:   strings.TrimSuffix(base, "/"),
+		header: header,
+		http:   &http.Client{Transport: debuglog.Transport(http.DefaultTransport)},
+	}
+}
+
+// get fetches path, relative to the API root or a full URL, into v and
+// returns the response headers
+func (c *restClient) get(ctx context.Context, path string, v any) (http.Header, error) {
+	url := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		url = c.base + "/" + strings.TrimPrefix(path, "/")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	for k, vals := range c.header {
+		req.Header[k] = vals
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("GET %s: %s %s", req.URL.Redacted(), resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("GET %s: %w", req.URL.Redacted(), err)
+	}
+	return resp.Header, nil
+}
+
+// getAll fetches every page of a list, following Link headers
+func getAll[T any](ctx context.Context, c *restClient, path string) ([]T, error) {
+	var all []T
+	for path != "" {
+		var page []T
+		header, err := c.get(ctx, path, &page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		path = nextPage(header.Get("Link"))
+	}
+	return all, nil
+}
+
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// nextPage returns the URL of the next page from a Link header, or ""
+func nextPage(link string) string {
+	if m := nextLink.FindStringSubmatch(link); m != nil {
+		return m[1]
+	}
+	return ""
+}
@@ -0,0 +1,382 @@
+// Package ghapi is the GitHub API client shared by the tools. It talks to
+// the REST and GraphQL APIs through go-gh, as gh's active account or as a
+// given GitHub user, follows pagination and waits out short rate limits.
+package ghapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/identity"
+)
+
+// Host is the GitHub host the tools talk to
+const Host = "github.com"
+
+// Client makes GitHub API requests as one user
+type Client struct {
+	rest *api.RESTClient
+	gql  *api.GraphQLClient
+}
+
+// tokenVars are the environment variables a token can come in instead of
+// gh's login, in the order gh itself reads them
+var tokenVars = []string{"GH_TOKEN", "GITHUB_TOKEN"}
+
+// EnvToken returns the token set in GH_TOKEN or GITHUB_TOKEN, as in CI, and
+// the variable it came from. It returns empty strings when neither is set.
+func EnvToken() (token, source string) {
+	for _, name := range tokenVars {
+		if token := os.Getenv(name); token != "" {
+			return token, name
+		}
+	}
+	return "", ""
+}
+
+// LoginHint tells how to fix failed authentication: logging in with gh, or
+// checking the token of the environment when there is one
+func LoginHint() string {
+	if _, source := EnvToken(); source != "" {
+		return "Check the token in " + source
+	}
+	return "Run: gh auth login"
+}
+
+// New returns a client authenticated as user, with the token gh holds for
+// them. An empty user means gh's active account, or the token in the
+// environment (see EnvToken) when there is one, without running gh at all.
+// For a named user gh has no token for, the environment's token is only
+// used once it is shown to be theirs.
+func New(user string) (*Client, error) {
+	return newAs(user, http.DefaultTransport)
+}
+
+func newAs(user string, base http.RoundTripper) (*Client, error) {
+	opts := api.ClientOptions{Host: Host}
+	envToken, source := EnvToken()
+	if user == "" {
+		opts.AuthToken = envToken
+		return newClient(opts, base)
+	}
+	token, err := identity.GHToken(user)
+	if err == nil {
+		opts.AuthToken = token
+		return newClient(opts, base)
+	}
+	if envToken == "" {
+		return nil, err
+	}
+
+	opts.AuthToken = envToken
+	client, cerr := newClient(opts, base)
+	if cerr != nil {
+		return nil, cerr
+	}
+	viewer, verr := client.Viewer(context.Background())
+	if verr != nil {
+		return nil, fmt.Errorf("%w, and checking the token in %s failed: %w", err, source, verr)
+	}
+	if !strings.EqualFold(viewer.Login, user) {
+		return nil, fmt.Errorf("the token in %s is for %s, not %s. Unset it, or: gh auth login --hostname %s (as %s)",
+			source, viewer.Login, user, Host, user)
+	}
+	return client, nil
+}
+
+func newClient(opts api.ClientOptions, base http.RoundTripper) (*Client, error) {
+	opts.Transport = &rateLimitTransport{base: debuglog.Transport(base)}
+	opts.LogIgnoreEnv = true // --debug covers it
+	rest, err := api.NewRESTClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("GitHub API: %w. %s", err, LoginHint())
+	}
+	gql, err := api.NewGraphQLClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("GitHub API: %w. %s", err, LoginHint())
+	}
+	return &Client{rest: rest, gql: gql}, nil
+}
+
+// Get fetches a REST path such as "repos/owner/name" and decodes the JSON
+// response into v
+func (c *Client) Get(ctx context.Context, path string, v any) error {
+	return c.rest.DoWithContext(ctx, http.MethodGet, path, nil, v)
+}
+
+// Post sends body as JSON to a REST path and decodes the JSON response
+// into v
+func (c *Client) Post(ctx context.Context, path string, body, v any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return c.rest.DoWithContext(ctx, http.MethodPost, path, bytes.NewReader(data), v)
+}
+
+// Put sends body as JSON to a REST path with PUT and decodes the JSON
+// response into v
+func (c *Client) Put(ctx context.Context, path string, body, v any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return c.rest.DoWithContext(ctx, http.MethodPut, path, bytes.NewReader(data), v)
+}
+
+// Patch sends body as JSON to a REST path with PATCH and decodes the JSON
+// response into v
+func (c *Client) Patch(ctx context.Context, path string, body, v any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return c.rest.DoWithContext(ctx, http.MethodPatch, path, bytes.NewReader(data), v)
+}
+
+// Archive makes a repository, given as owner/name, read-only
+func (c *Client) Archive(ctx context.Context, repo string) error {
+	return c.Patch(ctx, "repos/"+repo, map[string]bool{"archived": true}, nil)
+}
+
+// Topics returns the topics of a repository, given as owner/name
+func (c *Client) Topics(ctx context.Context, repo string) ([]string, error) {
+	var topics struct {
+		Names []string `json:"names"`
+	}
+	err := c.Get(ctx, "repos/"+repo+"/topics", &topics)
+	return topics.Names, err
+}
+
+// SetTopics replaces the topics of a repository, given as owner/name
+func (c *Client) SetTopics(ctx context.Context, repo string, names []string) error {
+	if names == nil {
+		names = []string{}
+	}
+	return c.Put(ctx, "repos/"+repo+"/topics", map[string][]string{"names": names}, nil)
+}
+
+// IsNotFound reports whether err is a 404 from the API, as for a repository
+// that doesn't exist or that the user can't see
+func IsNotFound(err error) bool {
+	var httpErr *api.HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound
+}
+
+// GetAll fetches every page of a REST path that returns a list
+func GetAll[T any](ctx context.Context, c *Client, path string) ([]T, error) {
+	if !strings.Contains(path, "per_page=") {
+		if strings.Contains(path, "?") {
+			path += "&per_page=100"
+		} else {
+			path += "?per_page=100"
+		}
+	}
+
+	var all []T
+	for path != "" {
+		resp, err := c.rest.RequestWithContext(ctx, http.MethodGet, path, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page []T
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", path, err)
+		}
+		all = append(all, page...)
+		path = nextPage(resp.Header.Get("Link"))
+	}
+	return all, nil
+}
+
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// nextPage returns the URL of the next page from a Link header, or ""
+func nextPage(link string) string {
+	if m := nextLink.FindStringSubmatch(link); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// Query runs a GraphQL query and decodes its "data" into v
+func (c *Client) Query(ctx context.Context, query string, variables map[string]any, v any) error {
+	return c.gql.DoWithContext(ctx, query, variables, v)
+}
+
+// Viewer is the authenticated user
+type Viewer struct {
+	Login  string
+	Scopes []string // OAuth scopes of the token; empty for fine-grained tokens
+}
+
+// HasScope reports whether the token was granted scope
+func (v *Viewer) HasScope(scope string) bool {
+	for _, s := range v.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Viewer returns who the client is authenticated as, which also checks that
+// it is
+func (c *Client) Viewer(ctx context.Context) (Viewer, error) {
+	resp, err := c.rest.RequestWithContext(ctx, http.MethodGet, "user", nil)
+	if err != nil {
+		return Viewer{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return Viewer{}, err
+	}
+	v := Viewer{Login: user.Login}
+	for _, s := range strings.Split(resp.Header.Get("X-OAuth-Scopes"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			v.Scopes = append(v.Scopes, s)
+		}
+	}
+	return v, nil
+}
+
+// ErrNoKeyScope is returned when the token may not read or add SSH keys,
+// which needs a scope gh doesn't ask for by default
+var ErrNoKeyScope = errors.New("the token lacks the admin:public_key scope")
+
+// SSHKey is an SSH public key registered to a GitHub account
+type SSHKey struct {
+	ID    int64  `json:"id"`
+	Key   string `json:"key"` // "<type> <key>", without a comment
+	Title string `json:"title"`
+}
+
+// SSHKeys returns the SSH keys registered to the authenticated user
+func (c *Client) SSHKeys(ctx context.Context) ([]SSHKey, error) {
+	keys, err := GetAll[SSHKey](ctx, c, "user/keys")
+	if IsNotFound(err) {
+		return nil, ErrNoKeyScope
+	}
+	return keys, err
+}
+
+// AddSSHKey registers a public key to the authenticated user
+func (c *Client) AddSSHKey(ctx context.Context, title, key string) (SSHKey, error) {
+	var added SSHKey
+	err := c.Post(ctx, "user/keys", map[string]string{"title": title, "key": key}, &added)
+	if IsNotFound(err) {
+		return SSHKey{}, ErrNoKeyScope
+	}
+	return added, err
+}
+
+// DeleteSSHKey removes a key, by its ID, from the authenticated user
+func (c *Client) DeleteSSHKey(ctx context.Context, id int64) error {
+	err := c.rest.DoWithContext(ctx, http.MethodDelete, fmt.Sprintf("user/keys/%d", id), nil, nil)
+	if IsNotFound(err) {
+		return ErrNoKeyScope
+	}
+	return err
+}
+
+// UserSSHKeys returns the public SSH keys of any GitHub user, a list
+// anyone may read, without titles
+func (c *Client) UserSSHKeys(ctx context.Context, login string) ([]SSHKey, error) {
+	return GetAll[SSHKey](ctx, c, "users/"+login+"/keys")
+}
+
+// KeysOf returns the SSH keys registered to a GitHub user: their own list
+// (user/keys) when gh holds a token for them that may read it, the public
+// one otherwise
+func KeysOf(ctx context.Context, login string) ([]SSHKey, error) {
+	c, err := New(login)
+	if err == nil {
+		keys, err := c.SSHKeys(ctx)
+		if !errors.Is(err, ErrNoKeyScope) {
+			return keys, err
+		}
+	} else if c, err = New(""); err != nil {
+		return nil, err
+	}
+	return c.UserSSHKeys(ctx, login)
+}
+
+// KeyOwner returns the first of logins with a public key registered to
+// their GitHub account, comparing fingerprints, or "" when none has it
+func KeyOwner(ctx context.Context, key string, logins []string) (string, error) {
+	return keyOwner(ctx, key, logins, KeysOf)
+}
+
+// ErrKeyNotRegistered is returned by CheckSSHKey when no account it
+// looked at has the key
+var ErrKeyNotRegistered = errors.New("SSH key is not registered")
+
+// KeyElsewhereError is returned by CheckSSHKey when the key is registered
+// to another account, where GitHub takes every push made with it
+type KeyElsewhereError struct {
+	User  string // Account the key should be on
+	Owner string // Account it is on
+}
+
+func (e *KeyElsewhereError) Error() string {
+	return fmt.Sprintf("SSH key is registered to GitHub user %q, not %q, so pushes with it act as %s", e.Owner, e.User, e.Owner)
+}
+
+// CheckSSHKey checks that a public key is registered to the GitHub account
+// user. When it isn't, it looks for it on others, such as the accounts of
+// the other profiles, to name the one it was added to instead.
+func CheckSSHKey(ctx context.Context, key, user string, others []string) error {
+	logins := []string{user}
+	for _, o := range others {
+		if !strings.EqualFold(o, user) {
+			logins = append(logins, o)
+		}
+	}
+	owner, err := KeyOwner(ctx, key, logins)
+	switch {
+	case err != nil:
+		return err
+	case owner == "":
+		return fmt.Errorf("%w to GitHub user %q", ErrKeyNotRegistered, user)
+	case owner != user:
+		return &KeyElsewhereError{User: user, Owner: owner}
+	}
+	return nil
+}
+
+func keyOwner(ctx context.Context, key string, logins []string,
+	keysOf func(context.Context, string) ([]SSHKey, error),
+) (string, error) {
+	want, err := identity.Fingerprint(key)
+	if err != nil {
+		return "", err
+	}
+	for _, login := range logins {
+		keys, err := keysOf(ctx, login)
+		if err != nil {
+			return "", fmt.Errorf("SSH keys of %s: %w", login, err)
+		}
+		for _, k := range keys {
+			if fp, err := identity.Fingerprint(k.Key); err == nil && fp == want {
+				return login, nil
+			}
+		}
+	}
+	return "", nil
+}
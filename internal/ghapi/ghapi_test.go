@@ -0,0 +1,310 @@
+package ghapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// redirect sends every request to a test server instead of GitHub
+type redirect struct {
+	target *url.URL
+}
+
+func (r redirect) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = r.target.Scheme
+	req.URL.Host = r.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func testClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	target, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	client, err := newClient(api.ClientOptions{Host: Host, AuthToken: "secret"}, redirect{target: target})
+	require.NoError(t, err)
+	return client
+}
+
+func TestGetAll(t *testing.T) {
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "100", r.URL.Query().Get("per_page"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 2 {
+			w.Header().Set("Link", `<https://api.github.com/repos/o/r/branches?per_page=100&page=2>; rel="next", `+
+				`<https://api.github.com/repos/o/r/branches?per_page=100&page=2>; rel="last"`)
+			_, _ = io.WriteString(w, `[{"name":"main"},{"name":"dev"}]`)
+			return
+		}
+		_, _ = io.WriteString(w, `[{"name":"fix"}]`)
+	}))
+
+	type branch struct {
+		Name string `json:"name"`
+	}
+	branches, err := GetAll[branch](context.Background(), client, "repos/o/r/branches")
+	require.NoError(t, err)
+	assert.Equal(t, []branch{{"main"}, {"dev"}, {"fix"}}, branches)
+}
+
+func TestIsNotFound(t *testing.T) {
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/o/gone" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"message":"Not Found"}`)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	var v any
+	assert.True(t, IsNotFound(client.Get(context.Background(), "repos/o/gone", &v)))
+	err := client.Get(context.Background(), "repos/o/broken", &v)
+	require.Error(t, err)
+	assert.False(t, IsNotFound(err))
+	assert.False(t, IsNotFound(nil))
+}
+
+func TestQuery(t *testing.T) {
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/graphql", r.URL.Path)
+		var body struct {
+			Variables map[string]any `json:"variables"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "is:pr", body.Variables["q"])
+		_, _ = io.WriteString(w, `{"data":{"search":{"issueCount":3}}}`)
+	}))
+
+	var result struct {
+		Search struct {
+			IssueCount int `json:"issueCount"`
+		} `json:"search"`
+	}
+	require.NoError(t, client.Query(context.Background(), `query($q: String!) { search(query: $q, type: ISSUE) { issueCount } }`,
+		map[string]any{"q": "is:pr"}, &result))
+	assert.Equal(t, 3, result.Search.IssueCount)
+}
+
+func TestViewer(t *testing.T) {
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/user", r.URL.Path)
+		w.Header().Set("X-OAuth-Scopes", "repo, read:org, gist")
+		_, _ = io.WriteString(w, `{"login":"jdevera"}`)
+	}))
+
+	v, err := client.Viewer(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "jdevera", v.Login)
+	assert.True(t, v.HasScope("read:org"))
+	assert.False(t, v.HasScope("delete_repo"))
+}
+
+func TestRateLimitRetry(t *testing.T) {
+	defer func(d time.Duration) { minRateWait = d }(minRateWait)
+	minRateWait = time.Millisecond
+
+	var calls atomic.Int32
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = io.WriteString(w, `{"message":"API rate limit exceeded"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"default_branch":"main"}`)
+	}))
+
+	var repo struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	require.NoError(t, client.Get(context.Background(), "repos/o/r", &repo))
+	assert.Equal(t, "main", repo.DefaultBranch)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRateLimitTooLong(t *testing.T) {
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+
+	err := client.Get(context.Background(), "repos/o/r", nil)
+	assert.ErrorContains(t, err, "rate limit exceeded; it resets at")
+}
+
+func TestRateLimitWait(t *testing.T) {
+	header := func(kv ...string) http.Header {
+		h := http.Header{}
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+	tests := []struct {
+		name    string
+		status  int
+		header  http.Header
+		limited bool
+	}{
+		{"ok", http.StatusOK, header(), false},
+		{"forbidden", http.StatusForbidden, header("X-RateLimit-Remaining", "12"), false},
+		{"primary", http.StatusForbidden, header("X-RateLimit-Remaining", "0", "X-RateLimit-Reset", "0"), true},
+		{"secondary", http.StatusForbidden, header("Retry-After", "30"), true},
+		{"too many", http.StatusTooManyRequests, header(), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, limited := rateLimitWait(&http.Response{StatusCode: tt.status, Header: tt.header})
+			assert.Equal(t, tt.limited, limited)
+		})
+	}
+}
+
+func TestSSHKeys(t *testing.T) {
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/user/keys", r.URL.Path)
+		_, _ = io.WriteString(w, `[{"id":1,"key":"ssh-ed25519 AAAA","title":"laptop"}]`)
+	}))
+
+	keys, err := client.SSHKeys(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []SSHKey{{ID: 1, Key: "ssh-ed25519 AAAA", Title: "laptop"}}, keys)
+}
+
+func TestAddSSHKey(t *testing.T) {
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/user/keys", r.URL.Path)
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]string{"title": "laptop", "key": "ssh-ed25519 AAAA me@laptop"}, body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"id":2,"key":"ssh-ed25519 AAAA","title":"laptop"}`)
+	}))
+
+	key, err := client.AddSSHKey(context.Background(), "laptop", "ssh-ed25519 AAAA me@laptop")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), key.ID)
+}
+
+func TestDeleteSSHKey(t *testing.T) {
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, "/user/keys/7", r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	require.NoError(t, client.DeleteSSHKey(context.Background(), 7))
+}
+
+func TestSSHKeysWithoutScope(t *testing.T) {
+	// GitHub hides the endpoints from tokens without the scope
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"message":"Not Found"}`)
+	}))
+
+	_, err := client.SSHKeys(context.Background())
+	assert.ErrorIs(t, err, ErrNoKeyScope)
+	_, err = client.AddSSHKey(context.Background(), "laptop", "ssh-ed25519 AAAA")
+	assert.ErrorIs(t, err, ErrNoKeyScope)
+	assert.ErrorIs(t, client.DeleteSSHKey(context.Background(), 7), ErrNoKeyScope)
+}
+
+func TestUserSSHKeys(t *testing.T) {
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/users/octocat/keys", r.URL.Path)
+		_, _ = io.WriteString(w, `[{"id":1,"key":"ssh-ed25519 AAAA"}]`)
+	}))
+
+	keys, err := client.UserSSHKeys(context.Background(), "octocat")
+	require.NoError(t, err)
+	assert.Equal(t, []SSHKey{{ID: 1, Key: "ssh-ed25519 AAAA"}}, keys)
+}
+
+func TestKeyOwner(t *testing.T) {
+	const (
+		mine   = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINoGTS/Z1N/eBYId+B2oyuL9P30GH9QPJPzcIb0icUCv me@laptop"
+		theirs = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIHY9wXJ9LWW4nqyQr3lUbwSTX36gSxgsZT6HWLZ6ahhy"
+	)
+	registered := map[string][]SSHKey{
+		"me-work":     {{Key: theirs}},
+		"me-personal": {{Key: "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINoGTS/Z1N/eBYId+B2oyuL9P30GH9QPJPzcIb0icUCv"}},
+	}
+	keysOf := func(_ context.Context, login string) ([]SSHKey, error) {
+		return registered[login], nil
+	}
+	ctx := context.Background()
+
+	owner, err := keyOwner(ctx, mine, []string{"me-work", "me-personal"}, keysOf)
+	require.NoError(t, err)
+	assert.Equal(t, "me-personal", owner)
+
+	owner, err = keyOwner(ctx, mine, []string{"me-work"}, keysOf)
+	require.NoError(t, err)
+	assert.Empty(t, owner)
+
+	_, err = keyOwner(ctx, "not a key", []string{"me-work"}, keysOf)
+	assert.Error(t, err)
+}
+
+func TestEnvToken(t *testing.T) {
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "")
+	token, source := EnvToken()
+	assert.Empty(t, token)
+	assert.Empty(t, source)
+	assert.Equal(t, "Run: gh auth login", LoginHint())
+
+	t.Setenv("GITHUB_TOKEN", "from-actions")
+	token, source = EnvToken()
+	assert.Equal(t, "from-actions", token)
+	assert.Equal(t, "GITHUB_TOKEN", source)
+
+	t.Setenv("GH_TOKEN", "mine")
+	token, source = EnvToken()
+	assert.Equal(t, "mine", token)
+	assert.Equal(t, "GH_TOKEN", source)
+	assert.Equal(t, "Check the token in GH_TOKEN", LoginHint())
+}
+
+func TestNewWithEnvToken(t *testing.T) {
+	t.Setenv("GH_TOKEN", "mine")
+	t.Setenv("PATH", "") // No gh to ask for the user's token
+	_, err := New("")
+	require.NoError(t, err)
+
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		_, _ = io.WriteString(w, `{"login":"Someone"}`)
+	}))
+	defer srv.Close()
+	target, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	_, err = newAs("someone", redirect{target: target})
+	require.NoError(t, err, "the token is someone's")
+	assert.Equal(t, "token mine", gotAuth)
+
+	_, err = newAs("someone-else", redirect{target: target})
+	assert.ErrorContains(t, err, "the token in GH_TOKEN is for Someone, not someone-else")
+}
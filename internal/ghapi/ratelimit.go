@@ -0,0 +1,80 @@
+package ghapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Rate limit handling. Variables so tests can shorten them.
+var (
+	maxAttempts = 3
+	maxRateWait = time.Minute // Longer waits fail instead, naming the reset time
+	minRateWait = time.Second // Floor for limits that don't say how long to wait
+)
+
+// rateLimitTransport retries requests GitHub turned down for exceeding a
+// rate limit, when the limit resets soon enough to wait for it
+type rateLimitTransport struct {
+	base http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	send := req
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(send)
+		if err != nil {
+			return nil, err
+		}
+		wait, limited := rateLimitWait(resp)
+		if !limited || attempt >= maxAttempts || !replayable(req) {
+			return resp, nil
+		}
+		if wait > maxRateWait {
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("GitHub API rate limit exceeded; it resets at %s",
+				time.Now().Add(wait).Format(time.Kitchen))
+		}
+		_ = resp.Body.Close()
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+		send = req.Clone(req.Context())
+		if req.GetBody != nil {
+			if send.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// rateLimitWait tells whether resp was refused for a rate limit and how
+// long until trying again makes sense
+func rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return max(time.Duration(secs)*time.Second, minRateWait), true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return max(time.Until(time.Unix(reset, 0)), minRateWait), true
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return minRateWait, true
+	}
+	return 0, false // A plain 403 is a permission problem
+}
+
+// replayable reports whether req can be sent again
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
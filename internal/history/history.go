@@ -0,0 +1,219 @@
+// Package history keeps per-repo metrics of git-explain runs in a local
+// SQLite database, to see how they change over time. It runs the sqlite3
+// command line tool, so it needs no cgo or database driver.
+package history
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/paths"
+)
+
+// Entry is the metrics of one repo in one run
+type Entry struct {
+	Time        time.Time `json:"time"`
+	Path        string    `json:"path"`
+	Name        string    `json:"name"`
+	Dirty       int       `json:"dirty"`        // Files with uncommitted changes
+	Ahead       int       `json:"ahead"`        // Unpushed commits on the current branch
+	Behind      int       `json:"behind"`       // Commits to pull into the current branch
+	Stashes     int       `json:"stashes"`      // Stash entries
+	UserCommits int       `json:"user_commits"` // Commits by you
+}
+
+// FromRepo takes the metrics of an analyzed repo at now
+func FromRepo(info *analyzer.RepoInfo, now time.Time) Entry {
+	e := Entry{
+		Time:        now.UTC().Truncate(time.Second),
+		Path:        info.Path,
+		Name:        info.Name,
+		Ahead:       info.Ahead,
+		Behind:      info.Behind,
+		Stashes:     info.StashCount,
+		UserCommits: info.TotalUserCommits,
+	}
+	if info.DirtyDetails != nil {
+		e.Dirty = info.DirtyDetails.TotalFiles()
+	}
+	return e
+}
+
+// Path returns the history database, under XDG_STATE_HOME
+func Path() (string, error) {
+	stateHome, err := paths.StateHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateHome, "git-this-bread", "history.sqlite"), nil
+}
+
+// schema creates the runs table the first time
+const schema = `CREATE TABLE IF NOT EXISTS runs (
+  time TEXT NOT NULL,
+  path TEXT NOT NULL,
+  name TEXT NOT NULL,
+  dirty INTEGER NOT NULL,
+  ahead INTEGER NOT NULL,
+  behind INTEGER NOT NULL,
+  stashes INTEGER NOT NULL,
+  user_commits INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS runs_path_time ON runs (path, time);
+`
+
+// Append adds entries to the database at db in one transaction, creating
+// it when missing
+func Append(ctx context.Context, db string, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(db), 0o750); err != nil {
+		return err
+	}
+	_, err := sqlite(ctx, db, insertSQL(entries), false)
+	return err
+}
+
+// insertSQL returns the script that stores entries
+func insertSQL(entries []Entry) string {
+	var b strings.Builder
+	b.WriteString(schema)
+	b.WriteString("BEGIN;\n")
+	for _, e := range entries {
+		fmt.Fprintf(&b, "INSERT INTO runs VALUES (%s, %s, %s, %d, %d, %d, %d, %d);\n",
+			quote(e.Time.UTC().Format(time.RFC3339)), quote(e.Path), quote(e.Name),
+			e.Dirty, e.Ahead, e.Behind, e.Stashes, e.UserCommits)
+	}
+	b.WriteString("COMMIT;\n")
+	return b.String()
+}
+
+// Load returns the entries of a repo since a time, oldest first. repo is
+// matched against the path of each entry, or its name when no path
+// matches. A missing database has no entries.
+func Load(ctx context.Context, db, repo string, since time.Time) ([]Entry, error) {
+	if _, err := os.Stat(db); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	query := fmt.Sprintf(`SELECT * FROM runs
+WHERE (path = %[1]s OR (name = %[1]s AND NOT EXISTS (SELECT 1 FROM runs WHERE path = %[1]s))) AND time >= %[2]s
+ORDER BY time;`, quote(repo), quote(since.UTC().Format(time.RFC3339)))
+	out, err := sqlite(ctx, db, schema+query, true)
+	if err != nil {
+		return nil, err
+	}
+	return parseRows(out)
+}
+
+// LoadUnder returns the entries since a time of every repo at or under
+// the directory root, grouped by repo, each oldest first. A missing
+// database has no entries.
+func LoadUnder(ctx context.Context, db, root string, since time.Time) ([]Entry, error) {
+	if _, err := os.Stat(db); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	query := fmt.Sprintf("SELECT * FROM runs WHERE time >= %s ORDER BY path, time;",
+		quote(since.UTC().Format(time.RFC3339)))
+	out, err := sqlite(ctx, db, schema+query, true)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseRows(out)
+	if err != nil {
+		return nil, err
+	}
+	under := entries[:0]
+	for _, e := range entries {
+		if rel, err := filepath.Rel(root, e.Path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			under = append(under, e)
+		}
+	}
+	return under, nil
+}
+
+// parseRows reads the rows sqlite3 -json prints, which is nothing at all
+// when there are none
+func parseRows(out []byte) ([]Entry, error) {
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	var rows []struct {
+		Entry
+		Time string `json:"time"`
+	}
+	if err := json.Unmarshal(out, &rows); err != nil {
+		return nil, fmt.Errorf("reading history: %w", err)
+	}
+	entries := make([]Entry, 0, len(rows))
+	for _, r := range rows {
+		e := r.Entry
+		e.Time, _ = time.Parse(time.RFC3339, r.Time)
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// sqlite runs script against the database at db, with JSON output when
+// asked for
+func sqlite(ctx context.Context, db, script string, asJSON bool) ([]byte, error) {
+	args := []string{"-bail"}
+	if asJSON {
+		args = append(args, "-json")
+	}
+	cmd := exec.CommandContext(ctx, "sqlite3", append(args, db)...)
+	cmd.Stdin = strings.NewReader(script)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := debuglog.Output(cmd)
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, errors.New("the history needs the sqlite3 command: install it from https://sqlite.org/download.html or your package manager")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sqlite3 %s: %w: %s", db, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// quote makes s an SQL string literal
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Change is how one metric moved between the first and last entry
+type Change struct {
+	Metric string
+	From   int
+	To     int
+}
+
+// Delta is how much the metric grew, negative when it shrank
+func (c Change) Delta() int {
+	return c.To - c.From
+}
+
+// Trend compares the first and the last of entries, one change per metric
+func Trend(entries []Entry) []Change {
+	if len(entries) == 0 {
+		return nil
+	}
+	first, last := entries[0], entries[len(entries)-1]
+	return []Change{
+		{"uncommitted files", first.Dirty, last.Dirty},
+		{"unpushed commits", first.Ahead, last.Ahead},
+		{"commits behind", first.Behind, last.Behind},
+		{"stashes", first.Stashes, last.Stashes},
+		{"your commits", first.UserCommits, last.UserCommits},
+	}
+}
@@ -0,0 +1,89 @@
+package history
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+)
+
+func TestPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/state")
+	path, err := Path()
+	require.NoError(t, err)
+	assert.Equal(t, "/state/git-this-bread/history.sqlite", path)
+}
+
+func TestFromRepo(t *testing.T) {
+	now := time.Date(2026, 5, 1, 10, 0, 0, 500, time.UTC)
+	info := &analyzer.RepoInfo{
+		Path: "/src/app", Name: "app", Ahead: 2, StashCount: 1, TotalUserCommits: 40,
+		DirtyDetails: &analyzer.DirtyDetails{StagedFiles: 1, Untracked: 2},
+	}
+
+	assert.Equal(t, Entry{
+		Time: now.Truncate(time.Second), Path: "/src/app", Name: "app",
+		Dirty: 3, Ahead: 2, Stashes: 1, UserCommits: 40,
+	}, FromRepo(info, now))
+}
+
+func TestInsertSQL_Quotes(t *testing.T) {
+	script := insertSQL([]Entry{{Time: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), Path: "/src/bob's", Name: "bob's"}})
+	assert.Contains(t, script, "INSERT INTO runs VALUES ('2026-05-01T00:00:00Z', '/src/bob''s', 'bob''s', 0, 0, 0, 0, 0);")
+}
+
+func TestAppendLoad(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+	ctx := context.Background()
+	db := filepath.Join(t.TempDir(), "sub", "history.sqlite")
+	entries, err := Load(ctx, db, "/src/app", time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, entries, "a missing database has no entries")
+
+	day := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, Append(ctx, db, []Entry{
+		{Time: day, Path: "/src/app", Name: "app", Ahead: 5},
+		{Time: day, Path: "/src/lib", Name: "lib", Dirty: 1},
+	}))
+	require.NoError(t, Append(ctx, db, []Entry{{Time: day.AddDate(0, 0, 7), Path: "/src/app", Name: "app", Ahead: 2}}))
+
+	entries, err = Load(ctx, db, "/src/app", time.Time{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, Entry{Time: day, Path: "/src/app", Name: "app", Ahead: 5}, entries[0])
+
+	entries, err = Load(ctx, db, "lib", time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "matched by name")
+
+	entries, err = Load(ctx, db, "/src/app", day.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "only since the given time")
+
+	require.NoError(t, Append(ctx, db, []Entry{{Time: day, Path: "/srcs/other", Name: "other"}}))
+	entries, err = LoadUnder(ctx, db, "/src", time.Time{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, []string{"/src/app", "/src/app", "/src/lib"}, []string{entries[0].Path, entries[1].Path, entries[2].Path})
+
+	entries, err = LoadUnder(ctx, db, "/src/lib", day)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "the root itself")
+}
+
+func TestTrend(t *testing.T) {
+	assert.Nil(t, Trend(nil))
+
+	changes := Trend([]Entry{{Ahead: 5, Stashes: 1}, {Ahead: 7}, {Ahead: 2, Stashes: 3}})
+	assert.Equal(t, Change{"unpushed commits", 5, 2}, changes[1])
+	assert.Equal(t, -3, changes[1].Delta())
+	assert.Equal(t, 2, changes[3].Delta())
+}
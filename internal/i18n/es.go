@@ -0,0 +1,144 @@
+package i18n
+
+// es is the Spanish catalog
+var es = map[string]string{
+	// Advice
+	"Has local changes but no remote - set up your fork or commit upstream":                         "Tiene cambios locales pero ningún remoto - configura tu fork o haz commit en upstream",
+	"No contributions - consider removing if not needed":                                            "Sin contribuciones - considera eliminarlo si no lo necesitas",
+	"Forked but no commits yet - start contributing or remove":                                      "Fork sin commits todavía - empieza a contribuir o elimínalo",
+	"Finish or abort the %s in progress":                                                            "Termina o aborta el %s en curso",
+	"Resolve %d conflicted file(s)":                                                                 "Resuelve %d archivo(s) con conflictos",
+	"%s is read-only - push your %d unpushed commit(s) to a fork instead":                           "%s es de solo lectura: sube tus %d commit(s) sin publicar a un fork",
+	"%d unpushed commit(s) on %d other branch(es) track read-only %s - push them to a fork instead": "%d commit(s) sin publicar en %d rama(s) más siguen a %s, de solo lectura: súbelos a un fork",
+	"Push your %d unpushed commit(s)":                                                               "Sube tus %d commit(s) sin publicar",
+	"Pull %d commit(s) from the remote":                                                             "Trae %d commit(s) del remoto",
+	"Sync with %s - %d commit(s) behind upstream":                                                   "Sincroniza con %s - %d commit(s) por detrás de upstream",
+	"Staged changes ready - commit %d file(s)":                                                      "Cambios preparados - haz commit de %d archivo(s)",
+	"%d untracked files - add to .gitignore or stage":                                               "%d archivos sin seguimiento - añádelos a .gitignore o prepáralos",
+	"Review %d stash(es) - apply or drop":                                                           "Revisa %d stash(es) - aplícalos o descártalos",
+	"Install Git LFS - %d file(s) here are only LFS pointers without it":                            "Instala Git LFS - sin él, %d archivo(s) de aquí son solo punteros de LFS",
+	"Push %d LFS object(s) - without the Git LFS hook, git push leaves them behind":                 "Sube %d objeto(s) de LFS - sin el hook de Git LFS, git push los deja atrás",
+	"%d loose objects in %d packs, %s of garbage files - run git gc":                                "%d objetos sueltos en %d packs, %s de ficheros basura - ejecuta git gc",
+	"%d loose objects in %d packs - run git gc":                                                     "%d objetos sueltos en %d packs - ejecuta git gc",
+	"Automatic gc is off (gc.auto=0) - turn it back on":                                             "El gc automático está desactivado (gc.auto=0) - vuelve a activarlo",
+	"Your uncommitted changes touch %d file(s) from %s - you may be on the wrong branch":            "Tus cambios sin confirmar tocan %d archivo(s) de %s - quizá estás en la rama equivocada",
+	"%s touches %d file(s) from %s - apply it there":                                                "%s toca %d archivo(s) de %s - aplícalo allí",
+	"Changes that touch other branches:":                                                            "Cambios que tocan otras ramas:",
+	"No background maintenance - run git maintenance start":                                         "Sin mantenimiento en segundo plano - ejecuta git maintenance start",
+	"Remote %s is unreachable - update its URL or remove it":                                        "El remoto %s no responde - actualiza su URL o elimínalo",
+	"%d remote-tracking ref(s) deleted upstream - run git fetch --prune":                            "%d ref(s) de seguimiento borradas en el remoto - ejecuta git fetch --prune",
+	"Push %d unpushed commit(s) on %d other branch(es)":                                             "Sube %d commit(s) sin publicar en %d rama(s) más",
+	"%d branch(es) track deleted remotes - delete them":                                             "%d rama(s) siguen remotos borrados - elimínalas",
+	"%d branch(es) on %s not checked out here - fetch them":                                         "%d rama(s) en %s que no están aquí - tráelas con fetch",
+
+	// Legend
+	"Legend":                                                   "Leyenda",
+	"Repository types":                                         "Tipos de repositorio",
+	"Status indicators":                                        "Indicadores de estado",
+	"Repository with your contributions":                       "Repositorio con tus contribuciones",
+	"Fork (has upstream remote)":                               "Fork (tiene remoto upstream)",
+	"Clone without contributions":                              "Clon sin contribuciones",
+	"Current branch name":                                      "Rama actual",
+	"Your remote":                                              "Tu remoto",
+	"Number of your commits":                                   "Número de commits tuyos",
+	"Date of last commit":                                      "Fecha del último commit",
+	"Uncommitted changes":                                      "Cambios sin commit",
+	"Unpushed commits":                                         "Commits sin publicar",
+	"Commits on the remote not pulled yet":                     "Commits del remoto aún sin traer",
+	"Fork ahead of / behind its upstream":                      "Fork por delante / por detrás de su upstream",
+	"Stashed changes":                                          "Cambios guardados en stash",
+	"Operation in progress, conflicts or errors":               "Operación en curso, conflictos o errores",
+	"Disk usage (with --disk-usage)":                           "Uso de disco (con --disk-usage)",
+	"Git LFS not installed, or objects git push leaves behind": "Git LFS sin instalar, u objetos que git push deja atrás",
+	"Labels (explain.labels)":                                  "Etiquetas (explain.labels)",
+	"No contributions":                                         "Sin contribuciones",
+
+	// Repository output
+	"Active":               "Activos",
+	"Needs attention":      "Requieren atención",
+	"Untouched clones":     "Clones sin tocar",
+	"Not git repositories": "No son repositorios git",
+	"No label":             "Sin etiqueta",
+	"not a git repo":       "no es un repositorio git",
+	"timed out":            "tiempo agotado",
+	"no contributions":     "sin contribuciones",
+	"mirror":               "espejo",
+	"bare":                 "bare",
+	"worktree of %s":       "worktree de %s",
+	"Mirror clone: a bare copy of every ref of its remote": "Clon espejo: una copia bare de todas las refs de su remoto",
+	"Bare repository: no working tree":                     "Repositorio bare: sin directorio de trabajo",
+	"Linked worktree of %s":                                "Worktree enlazado de %s",
+	"%d in the last year":                                  "%d en el último año",
+	"All repos":                                            "Todos los repos",
+	"Your activity in the last year":                       "Tu actividad en el último año",
+	"Your commits per week over the last year":             "Tus commits por semana en el último año",
+	"✓ No actions needed":                                  "✓ No hace falta hacer nada",
+	"Remotes:":                                             "Remotos:",
+	" (mine)":                                              " (mío)",
+	" (read-only)":                                         " (solo lectura)",
+	" (unreachable)":                                       " (inaccesible)",
+	"upstream gone":                                        "upstream desaparecido",
+	"analysis timed out, results are partial":              "el análisis agotó el tiempo, los resultados son parciales",
+	"Branches with your commits:":                          "Ramas con commits tuyos:",
+	"Branches only on your remotes:":                       "Ramas solo en tus remotos:",
+	"not fetched":                                          "sin traer",
+	"Advice:":                                              "Consejos:",
+	"Using rule-based advice:":                             "Usando los consejos basados en reglas:",
+	"⚠ LLM unavailable: %s":                                "⚠ LLM no disponible: %s",
+	"⚠ LLM unavailable: %s (using rule-based advice)":      "⚠ LLM no disponible: %s (usando los consejos basados en reglas)",
+	"📊 LLM Summary:":                                       "📊 Resumen del LLM:",
+	"Repository":                                           "Repositorio",
+	"Remote":                                               "Remoto",
+	"Commits":                                              "Commits",
+	"Last":                                                 "Último",
+	"Status":                                               "Estado",
+
+	// GitHub Actions output (--format gh-actions)
+	"Error: %s":                            "Error: %s",
+	"Analysis timed out":                   "El análisis agotó el tiempo",
+	"%s in progress":                       "%s en curso",
+	"%d conflicted file(s)":                "%d archivo(s) en conflicto",
+	"%d uncommitted file(s)":               "%d archivo(s) sin commit",
+	"%d unpushed commit(s) on %d branches": "%d commit(s) sin publicar en %d ramas",
+	"%d unpushed commit(s)":                "%d commit(s) sin publicar",
+	"%d stash(es)":                         "%d stash(es)",
+	"Unreachable remote(s): %s":            "Remoto(s) inaccesible(s): %s",
+	"Path":                                 "Ruta",
+	"Branch":                               "Rama",
+	"Problems":                             "Problemas",
+	"%d of %d repos need attention":        "%d de %d repos necesitan atención",
+	"git-explain on %s: %s":                "git-explain en %s: %s",
+	"All %d repos are clean":               "Los %d repos están limpios",
+	"Git LFS: %d file(s), git lfs not installed":       "Git LFS: %d archivo(s), git lfs no está instalado",
+	"Git LFS: %d object(s) unpushed, no pre-push hook": "Git LFS: %d objeto(s) sin subir, sin hook pre-push",
+	"Git LFS: %d file(s), %d object(s) to push":        "Git LFS: %d archivo(s), %d objeto(s) por subir",
+	"Git LFS: %d file(s)":                              "Git LFS: %d archivo(s)",
+
+	// Errors
+	"can't ask for input":    "no puede preguntar",
+	"--no-input is set":      "--no-input está activado",
+	"%s is set":              "%s está definida",
+	"running in CI":          "se está ejecutando en CI",
+	"stdin isn't a terminal": "stdin no es una terminal",
+	"Give the fields as flags: git-id add %s --sshkey <path> --email <email>": "Da los campos como opciones: git-id add %s --sshkey <ruta> --email <correo>",
+	"Use --yes to run every action without asking":                            "Usa --yes para ejecutar todas las acciones sin preguntar",
+	"Use --yes to run every fix without asking, or --dry-run to print them":   "Usa --yes para aplicar todos los arreglos sin preguntar, o --dry-run para mostrarlos",
+	"Use --llm-advice for the advice alone":                                   "Usa --llm-advice para obtener solo los consejos",
+	"⚠ This repository is bound to profile %s (user.email %s), not %s":        "⚠ Este repositorio está ligado al perfil %s (user.email %s), no a %s",
+	"⚠ Repositories bound to another profile than %s:":                        "⚠ Repositorios ligados a otro perfil que %s:",
+	"Use: git-as --force %s ... to run as %s anyway":                          "Usa: git-as --force %s ... para ejecutarlo como %s de todos modos",
+	"Run git as %s anyway? [y/N] ":                                            "¿Ejecutar git como %s de todos modos? [y/N] ",
+
+	// First-run setup of git-explain
+	"git-explain needs to know which commits and remotes are yours, and git config lacks %s.": "git-explain necesita saber qué commits y remotos son tuyos, y a la configuración de git le falta %s.",
+	"  1) Set them in your global git config":                                                 "  1) Guardarlos en tu configuración global de git",
+	"  2) Save them as an identity profile (git-id) and make it the default":                  "  2) Guardarlos como perfil de identidad (git-id) y hacerlo el predeterminado",
+	"Choose": "Elige",
+	"no choice %q: run git-explain again to set up":               "no hay opción %q: vuelve a ejecutar git-explain para configurarlo",
+	"Email of your commits":                                       "Correo de tus commits",
+	"GitHub user":                                                 "Usuario de GitHub",
+	"both the email and the GitHub user are needed":               "hacen falta el correo y el usuario de GitHub",
+	"Saved user.email and github.user in your global git config.": "Guardados user.email y github.user en tu configuración global de git.",
+	"Profile name":                                                "Nombre del perfil",
+	"Saved profile %q to %s and made it the default. Add its SSH key with: git-id set %s sshkey <path>": "Perfil %q guardado en %s y hecho predeterminado. Añade su clave SSH con: git-id set %s sshkey <ruta>",
+}
@@ -0,0 +1,112 @@
+// Package i18n translates the messages the tools show. Messages are looked
+// up by their English text, so code keeps reading as before and a message
+// with no translation is shown in English.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Default is the locale of the messages in the code
+const Default = "en"
+
+// catalogs maps each locale to its translations, keyed by English text
+var catalogs = map[string]map[string]string{
+	"es": es,
+}
+
+// languages names each locale in English, for prompts to an LLM
+var languages = map[string]string{
+	"en": "English",
+	"es": "Spanish",
+}
+
+var (
+	locale  = Default
+	catalog map[string]string
+)
+
+// Locales lists the supported locales, Default first
+func Locales() []string {
+	names := []string{Default}
+	for name := range catalogs {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// Parse reduces a locale setting such as "es_ES.UTF-8" to its language,
+// "es". C and POSIX, which mean no locale, give "".
+func Parse(s string) string {
+	s, _, _ = strings.Cut(s, ".")
+	s, _, _ = strings.Cut(s, "@")
+	lang, _, _ := strings.Cut(s, "_")
+	lang, _, _ = strings.Cut(lang, "-")
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "c" || lang == "posix" {
+		return ""
+	}
+	return lang
+}
+
+// Detect picks the locale to use: configured (the ui.locale setting) when
+// given, otherwise the first of LC_ALL, LC_MESSAGES and LANG that is set.
+// A locale with no catalog falls back to Default.
+func Detect(configured string) string {
+	for _, s := range []string{configured, os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if lang := Parse(s); lang != "" {
+			if supported(lang) {
+				return lang
+			}
+			return Default
+		}
+	}
+	return Default
+}
+
+// SetLocale makes T translate into locale
+func SetLocale(name string) error {
+	lang := Parse(name)
+	if !supported(lang) {
+		return fmt.Errorf("unsupported locale %q, must be one of: %s", name, strings.Join(Locales(), ", "))
+	}
+	locale, catalog = lang, catalogs[lang]
+	return nil
+}
+
+// Locale returns the locale T translates into
+func Locale() string {
+	return locale
+}
+
+// Language returns the English name of the locale T translates into
+func Language() string {
+	return languages[locale]
+}
+
+// T returns the translation of msg, or msg itself when it has none
+func T(msg string) string {
+	if s, ok := catalog[msg]; ok {
+		return s
+	}
+	return msg
+}
+
+// Sprintf formats the translation of format
+func Sprintf(format string, args ...any) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// Errorf is fmt.Errorf with the translation of format
+func Errorf(format string, args ...any) error {
+	return fmt.Errorf(T(format), args...)
+}
+
+func supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return lang == Default || ok
+}
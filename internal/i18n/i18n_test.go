@@ -0,0 +1,70 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]string{
+		"es_ES.UTF-8":    "es",
+		"es":             "es",
+		"pt-BR":          "pt",
+		"ca_ES@valencia": "ca",
+		"EN_us":          "en",
+		"C":              "",
+		"POSIX":          "",
+		"":               "",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, Parse(in), in)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "es_ES.UTF-8")
+	assert.Equal(t, "es", Detect(""))
+	assert.Equal(t, "en", Detect("en"), "the setting wins over the environment")
+
+	t.Setenv("LC_ALL", "fr_FR.UTF-8")
+	assert.Equal(t, Default, Detect(""), "an unsupported locale falls back to English")
+
+	t.Setenv("LC_ALL", "C")
+	assert.Equal(t, "es", Detect(""), "C means no locale")
+
+	t.Setenv("LANG", "")
+	assert.Equal(t, Default, Detect(""))
+}
+
+func TestSetLocale(t *testing.T) {
+	t.Cleanup(func() { _ = SetLocale(Default) })
+
+	require.NoError(t, SetLocale("es_ES.UTF-8"))
+	assert.Equal(t, "es", Locale())
+	assert.Equal(t, "Spanish", Language())
+	assert.Equal(t, "Sube tus 3 commit(s) sin publicar", Sprintf("Push your %d unpushed commit(s)", 3))
+	assert.Equal(t, "not in the catalog", T("not in the catalog"))
+
+	require.NoError(t, SetLocale("en"))
+	assert.Equal(t, "Push your 3 unpushed commit(s)", Sprintf("Push your %d unpushed commit(s)", 3))
+
+	err := SetLocale("fr")
+	assert.ErrorContains(t, err, `unsupported locale "fr", must be one of: en, es`)
+}
+
+var verbs = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// Translations must take the same arguments as the English text
+func TestCatalogs_KeepVerbs(t *testing.T) {
+	for name, catalog := range catalogs {
+		for en, tr := range catalog {
+			assert.Equal(t, verbs.FindAllString(en, -1), verbs.FindAllString(tr, -1), "%s: %q", name, en)
+			assert.NotEmpty(t, tr, "%s: %q", name, en)
+		}
+	}
+}
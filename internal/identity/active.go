@@ -0,0 +1,108 @@
+package identity
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+)
+
+// Active is the identity live in a directory, as a shell prompt shows it
+type Active struct {
+	Profile    string   // Profile commits are made as: the session's, or else DirProfile
+	Email      string   // user.email of the directory, from git config
+	DirProfile string   // Profile whose email is Email, "" when none is
+	Session    *Session // Session of this shell, nil when none
+}
+
+// ActiveIn works out the identity live in dir, from git config and the
+// session of this shell
+func ActiveIn(dir string) (*Active, error) {
+	s, err := CurrentSession()
+	if err != nil {
+		return nil, err
+	}
+	a := &Active{Session: s}
+
+	cmd := exec.Command("git", "config", "user.email")
+	cmd.Dir = dir
+	if out, err := debuglog.Output(cmd); err == nil {
+		a.Email = strings.TrimSpace(string(out))
+	}
+	if a.Email != "" {
+		a.DirProfile = profileWithEmail(dir, a.Email)
+	}
+
+	a.Profile = a.DirProfile
+	if s != nil {
+		a.Profile = s.Profile
+	}
+	return a, nil
+}
+
+// Binding returns the profile the repository in dir is bound to, and its
+// email: the profile whose email is the repository's user.email, when that
+// comes from its own config, or a file included for it, rather than from
+// the global one. Both are "" when it isn't bound to a profile.
+func Binding(dir string) (profile, email string) {
+	email = configValue(dir, "user.email")
+	if email == "" || strings.EqualFold(email, configValue(dir, "--global", "user.email")) {
+		return "", ""
+	}
+	if profile = profileWithEmail(dir, email); profile == "" {
+		return "", ""
+	}
+	return profile, email
+}
+
+// configValue reads a git config value as dir sees it, "" when unset
+func configValue(dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"config"}, args...)...)
+	cmd.Dir = dir
+	out, err := debuglog.Output(cmd)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// profileWithEmail returns the first profile whose email is email, as
+// dir's git config sees them, or "" when none is
+func profileWithEmail(dir, email string) string {
+	cmd := exec.Command("git", "config", "--get-regexp", `^identity\..+\.email$`)
+	cmd.Dir = dir
+	out, err := debuglog.Output(cmd)
+	if err != nil {
+		return ""
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		key, val, _ := strings.Cut(scanner.Text(), " ")
+		if strings.EqualFold(strings.TrimSpace(val), email) {
+			return strings.TrimSuffix(strings.TrimPrefix(key, "identity."), ".email")
+		}
+	}
+	return ""
+}
+
+// Segment formats a for a prompt: the live profile, or the email when no
+// profile has it, followed by a warning when the session has expired or
+// the directory is set up for another profile than the session's. It is
+// empty when there is no identity at all.
+func (a *Active) Segment(now time.Time) string {
+	name := a.Profile
+	if name == "" {
+		name = a.Email
+	}
+	switch {
+	case name == "":
+		return ""
+	case a.Session != nil && a.Session.Expired(now):
+		return name + " ⚠ expired"
+	case a.Session != nil && a.DirProfile != "" && a.DirProfile != a.Session.Profile:
+		return name + " ⚠ " + a.DirProfile
+	}
+	return name
+}
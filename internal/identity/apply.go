@@ -0,0 +1,158 @@
+package identity
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+)
+
+// Apply writes p into the local config of the repository in dir, so git
+// acts as the profile there without git-as: its email as user.email, its
+// commit name as user.name and its ssh command as core.sshCommand. The
+// profile must have what git-as needs. It fails outside a repository, and,
+// unless force is set, when a user.email or core.sshCommand no profile
+// wrote is set there, which it would lose. Without a commit name, a
+// user.name a profile wrote is removed, and one set otherwise is left.
+func Apply(dir string, p *Profile, force bool) error {
+	if _, err := p.GitEnv(); err != nil {
+		return err
+	}
+	if err := checkRepo(dir); err != nil {
+		return err
+	}
+	if !force {
+		if err := checkHandSet(dir, p); err != nil {
+			return err
+		}
+	}
+	if err := setLocal(dir, "user.email", p.Email); err != nil {
+		return err
+	}
+	if err := setLocal(dir, "core.sshCommand", p.SSHCommand()); err != nil {
+		return err
+	}
+	if name := p.CommitName(); name != "" {
+		return setLocal(dir, "user.name", name)
+	}
+	// A name another profile left would go with this profile's email
+	if isProfileName(dir, configValue(dir, "--local", "user.name")) {
+		unsetLocal(dir, "user.name")
+	}
+	return nil
+}
+
+// Unapply removes the profile Apply wrote into the local config of the
+// repository in dir and returns its name. It fails when the repository's
+// own user.email isn't a profile's, or, given a name, that profile's.
+// user.name and core.sshCommand are only removed when a profile's name or
+// SSH key made them.
+func Unapply(dir, name string) (string, error) {
+	if err := checkRepo(dir); err != nil {
+		return "", err
+	}
+	email := configValue(dir, "--local", "user.email")
+	profile := ""
+	if email != "" {
+		profile = profileWithEmail(dir, email)
+	}
+	if profile == "" {
+		return "", fmt.Errorf("no profile is applied to this repository")
+	}
+	if name != "" && profile != name {
+		return "", fmt.Errorf("profile '%s' is not applied to this repository, '%s' is", name, profile)
+	}
+	unsetLocal(dir, "user.email")
+	if isProfileName(dir, configValue(dir, "--local", "user.name")) {
+		unsetLocal(dir, "user.name")
+	}
+	if profileWithSSHCommand(dir, configValue(dir, "--local", "core.sshCommand")) != "" {
+		unsetLocal(dir, "core.sshCommand")
+	}
+	return profile, nil
+}
+
+// checkHandSet fails when the local config of the repository in dir has a
+// user.email or core.sshCommand that no profile wrote, and that Apply would
+// replace with p's
+func checkHandSet(dir string, p *Profile) error {
+	email := configValue(dir, "--local", "user.email")
+	if email != "" && !strings.EqualFold(email, p.Email) && profileWithEmail(dir, email) == "" {
+		return fmt.Errorf("this repository's user.email is %s, which no profile has.\nUse: git-id apply --force %s to replace it", email, p.Name)
+	}
+	command := configValue(dir, "--local", "core.sshCommand")
+	if command != "" && command != p.SSHCommand() && profileWithSSHCommand(dir, command) == "" {
+		return fmt.Errorf("this repository's core.sshCommand is %q, which no profile's SSH key made.\nUse: git-id apply --force %s to replace it", command, p.Name)
+	}
+	return nil
+}
+
+// profileWithSSHCommand returns the first profile whose SSHCommand is
+// command, as dir's git config sees them, or "" when none is
+func profileWithSSHCommand(dir, command string) string {
+	if command == "" {
+		return ""
+	}
+	cmd := exec.Command("git", "config", "--get-regexp", `^identity\..+\.sshkey$`)
+	cmd.Dir = dir
+	out, err := debuglog.Output(cmd)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		key, val, _ := strings.Cut(line, " ")
+		if val != "" && (&Profile{SSHKey: strings.TrimSpace(val)}).SSHCommand() == command {
+			return strings.TrimSuffix(strings.TrimPrefix(key, "identity."), ".sshkey")
+		}
+	}
+	return ""
+}
+
+// isProfileName reports whether name is one Apply could have written as
+// user.name: the name of a profile, or its user
+func isProfileName(dir, name string) bool {
+	if name == "" {
+		return false
+	}
+	cmd := exec.Command("git", "config", "--get-regexp", `^identity\..+\.(name|user)$`)
+	cmd.Dir = dir
+	out, err := debuglog.Output(cmd)
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if _, val, _ := strings.Cut(line, " "); val == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRepo fails when dir isn't in a git repository
+func checkRepo(dir string) error {
+	cmd := exec.Command("git", "rev-parse", "--git-dir")
+	cmd.Dir = dir
+	if err := debuglog.Run(cmd); err != nil {
+		return fmt.Errorf("not in a git repository")
+	}
+	return nil
+}
+
+// setLocal sets key to value in the local config of the repository in dir
+func setLocal(dir, key, value string) error {
+	cmd := exec.Command("git", "config", "--local", key, value)
+	cmd.Dir = dir
+	if err := debuglog.Run(cmd); err != nil {
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+	return nil
+}
+
+// unsetLocal removes key from the local config of the repository in dir,
+// if it is there
+func unsetLocal(dir, key string) {
+	cmd := exec.Command("git", "config", "--local", "--unset-all", key)
+	cmd.Dir = dir
+	_ = debuglog.Run(cmd)
+}
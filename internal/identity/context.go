@@ -0,0 +1,38 @@
+package identity
+
+import (
+	"slices"
+	"sort"
+)
+
+// Contexts returns the contexts that profiles are in, sorted: groups of
+// profiles, such as work, personal or a client's, set with the context key
+func Contexts() ([]string, error) {
+	names, err := List()
+	if err != nil {
+		return nil, err
+	}
+	var contexts []string
+	for _, name := range names {
+		if p, err := Get(name); err == nil && p.Context != "" && !slices.Contains(contexts, p.Context) {
+			contexts = append(contexts, p.Context)
+		}
+	}
+	sort.Strings(contexts)
+	return contexts, nil
+}
+
+// InContext returns the names of the profiles whose context is name
+func InContext(name string) ([]string, error) {
+	names, err := List()
+	if err != nil {
+		return nil, err
+	}
+	var in []string
+	for _, n := range names {
+		if p, err := Get(n); err == nil && p.Context == name {
+			in = append(in, n)
+		}
+	}
+	return in, nil
+}
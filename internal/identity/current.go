@@ -0,0 +1,80 @@
+package identity
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Effective is what gives git its identity in a repository, and the
+// profile each part belongs to
+type Effective struct {
+	Email        string   // user.email
+	EmailProfile string   // Profile whose email is Email, "" when none is
+	SSHCommand   string   // GIT_SSH_COMMAND, or else core.sshCommand
+	SSHFromEnv   bool     // SSHCommand is GIT_SSH_COMMAND
+	SSHKey       string   // Key SSHCommand authenticates with, "" when it names none
+	SSHProfile   string   // Profile whose sshkey is SSHKey, "" when none is
+	Remotes      []Remote // Remotes of the repository, by name
+}
+
+// Remote is a remote of a repository and its URL
+type Remote struct {
+	Name string
+	URL  string
+}
+
+// EffectiveIn reads the identity settings git uses in dir and finds which
+// of profiles they belong to
+func EffectiveIn(dir string, profiles []*Profile) *Effective {
+	e := &Effective{Email: configValue(dir, "user.email")}
+	e.SSHCommand, e.SSHFromEnv = os.Getenv("GIT_SSH_COMMAND"), true
+	if e.SSHCommand == "" {
+		e.SSHCommand, e.SSHFromEnv = configValue(dir, "core.sshCommand"), false
+	}
+	e.SSHKey = SSHKeyOf(e.SSHCommand)
+
+	for _, p := range profiles {
+		if e.EmailProfile == "" && e.Email != "" && strings.EqualFold(p.Email, e.Email) {
+			e.EmailProfile = p.Name
+		}
+		if e.SSHProfile == "" && e.SSHKey != "" && p.SSHKey != "" &&
+			filepath.Clean(ExpandPath(p.SSHKey)) == filepath.Clean(ExpandPath(e.SSHKey)) {
+			e.SSHProfile = p.Name
+		}
+	}
+
+	e.Remotes = remotesIn(dir)
+	return e
+}
+
+// remotesIn lists the remotes of the repository in dir, by name
+func remotesIn(dir string) []Remote {
+	var remotes []Remote
+	scanner := bufio.NewScanner(strings.NewReader(configValue(dir, "--get-regexp", `^remote\..+\.url$`)))
+	for scanner.Scan() {
+		key, url, _ := strings.Cut(scanner.Text(), " ")
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "remote."), ".url")
+		remotes = append(remotes, Remote{Name: name, URL: strings.TrimSpace(url)})
+	}
+	return remotes
+}
+
+// SSHKeyOf returns the key an ssh command authenticates with, the value
+// of its first -i option, or "" when it has none
+func SSHKeyOf(command string) string {
+	words, err := SplitArgs(command)
+	if err != nil {
+		return ""
+	}
+	for i, w := range words {
+		if w == "-i" && i+1 < len(words) {
+			return words[i+1]
+		}
+		if key, ok := strings.CutPrefix(w, "-i"); ok && key != "" {
+			return key
+		}
+	}
+	return ""
+}
@@ -0,0 +1,170 @@
+package identity
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+)
+
+// GHFlagsKey returns the key of the flags gh-as adds to a gh command, such
+// as "pr list"
+func GHFlagsKey(command string) string {
+	return "gh." + strings.Join(strings.Fields(command), ".") + ".flags"
+}
+
+// GHAliasKey returns the key of a gh-as alias
+func GHAliasKey(name string) string {
+	return "gh." + name + ".alias"
+}
+
+// keyGH returns what a gh.<command>.flags or gh.<alias>.alias key is about,
+// the command ("pr list") or the alias, and which of the two it is
+func keyGH(key string) (subject, kind string, ok bool) {
+	rest, ok := strings.CutPrefix(key, "gh.")
+	if !ok {
+		return "", "", false
+	}
+	if command, ok := strings.CutSuffix(rest, ".flags"); ok && command != "" {
+		return strings.ReplaceAll(command, ".", " "), "flags", true
+	}
+	if alias, ok := strings.CutSuffix(rest, ".alias"); ok && alias != "" && !strings.Contains(alias, ".") {
+		return alias, "alias", true
+	}
+	return "", "", false
+}
+
+// getGHSettings reads the gh.<command>.flags and gh.<alias>.alias keys of a
+// profile, each nil when it has none
+func getGHSettings(profile string) (flags, aliases map[string]string) {
+	prefix := fmt.Sprintf("identity.%s.", profile)
+	cmd := exec.Command("git", "config", "--get-regexp", `^`+regexp.QuoteMeta(prefix)+`gh\..+\.(flags|alias)$`)
+	out, err := debuglog.Output(cmd)
+	if err != nil {
+		return nil, nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		key, val, _ := strings.Cut(scanner.Text(), " ")
+		subject, kind, ok := keyGH(strings.TrimPrefix(key, prefix))
+		if !ok {
+			continue
+		}
+		target := &flags
+		if kind == "alias" {
+			target = &aliases
+		}
+		if *target == nil {
+			*target = make(map[string]string)
+		}
+		(*target)[subject] = strings.TrimSpace(val)
+	}
+	return flags, aliases
+}
+
+// ghKeys returns the gh.<command>.flags and gh.<alias>.alias keys of the
+// profile with their values
+func (p *Profile) ghKeys() map[string]string {
+	keys := make(map[string]string, len(p.GHFlags)+len(p.GHAliases))
+	for command, flags := range p.GHFlags {
+		keys[GHFlagsKey(command)] = flags
+	}
+	for alias, expansion := range p.GHAliases {
+		keys[GHAliasKey(alias)] = expansion
+	}
+	return keys
+}
+
+// ExpandGHArgs applies the profile's gh-as settings to the arguments of a
+// gh command: an alias in first place becomes what it stands for, then the
+// flags of every command the arguments start with ("pr", then "pr list")
+// go after the command words, before the flags given, so that those win.
+func (p *Profile) ExpandGHArgs(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return args, nil
+	}
+	if expansion, ok := p.GHAliases[args[0]]; ok {
+		words, err := SplitArgs(expansion)
+		if err != nil {
+			return nil, fmt.Errorf("gh alias %q of profile %q: %w", args[0], p.Name, err)
+		}
+		args = append(words, args[1:]...)
+	}
+
+	n := 0 // Leading words: the command and its positional arguments
+	for n < len(args) && !strings.HasPrefix(args[n], "-") {
+		n++
+	}
+	commands := make([]string, 0, len(p.GHFlags))
+	for command := range p.GHFlags {
+		words := strings.Fields(command)
+		if len(words) <= n && slices.Equal(words, args[:len(words)]) {
+			commands = append(commands, command)
+		}
+	}
+	if len(commands) == 0 {
+		return args, nil
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return len(strings.Fields(commands[i])) < len(strings.Fields(commands[j]))
+	})
+
+	expanded := append([]string(nil), args[:n]...)
+	for _, command := range commands {
+		words, err := SplitArgs(p.GHFlags[command])
+		if err != nil {
+			return nil, fmt.Errorf("gh flags for %q of profile %q: %w", command, p.Name, err)
+		}
+		expanded = append(expanded, words...)
+	}
+	return append(expanded, args[n:]...), nil
+}
+
+// SplitArgs splits s into words as a shell would, minus expansions: words
+// are separated by blanks, and quotes and backslashes keep them together
+func SplitArgs(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %q", s)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,87 @@
+package identity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// privateEmailKey is the key that keeps a profile's commits to its GitHub
+// noreply address
+const privateEmailKey = "privateemail"
+
+// NoreplyDomain is the domain of the addresses GitHub gives users to
+// commit with without exposing their email
+const NoreplyDomain = "users.noreply.github.com"
+
+// ErrPublicEmail is returned for commits that would expose the email of a
+// profile with privateemail set
+var ErrPublicEmail = errors.New("commits would expose a real email")
+
+// ParseBool reads a boolean as git config writes them: true, yes, on and 1,
+// or false, no, off, 0 and the empty string
+func ParseBool(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q: use true or false", s)
+}
+
+// IsNoreplyEmail reports whether email is the GitHub noreply address of
+// user, as <id>+<user>@users.noreply.github.com or, for older accounts,
+// <user>@users.noreply.github.com. With no user, any noreply address is.
+func IsNoreplyEmail(email, user string) bool {
+	local, domain, ok := strings.Cut(strings.TrimSpace(email), "@")
+	if !ok || !strings.EqualFold(domain, NoreplyDomain) || local == "" {
+		return false
+	}
+	if id, name, ok := strings.Cut(local, "+"); ok {
+		if id == "" || strings.Trim(id, "0123456789") != "" {
+			return false
+		}
+		local = name
+	}
+	return user == "" || strings.EqualFold(local, user)
+}
+
+// CheckPrivateEmail fails with ErrPublicEmail when the profile has
+// privateemail set, yet its email is not its GitHub noreply address
+func (p *Profile) CheckPrivateEmail() error {
+	if !p.PrivateEmail || IsNoreplyEmail(p.Email, p.GHUser) {
+		return nil
+	}
+	user := p.GHUser
+	if user == "" {
+		user = "<user>"
+	}
+	return fmt.Errorf("profile '%s': %w: it has privateemail set, but %s is not the GitHub noreply address of %s.\n"+
+		"Find yours at https://github.com/settings/emails and use: git-id set %s email <id>+%s@%s",
+		p.Name, ErrPublicEmail, p.Email, user, p.Name, user, NoreplyDomain)
+}
+
+// CheckCommitAuthor fails with ErrPublicEmail when the arguments of a git
+// command of a profile with privateemail set give a commit an --author
+// whose email isn't a GitHub noreply address
+func (p *Profile) CheckCommitAuthor(args []string) error {
+	i := gitCommand(args)
+	if !p.PrivateEmail || i < 0 || args[i] != "commit" {
+		return nil
+	}
+	for j := i + 1; j < len(args) && args[j] != "--"; j++ {
+		author, ok := strings.CutPrefix(args[j], "--author=")
+		if args[j] == "--author" && j+1 < len(args) {
+			author, ok = args[j+1], true
+		}
+		if !ok {
+			continue
+		}
+		start, end := strings.Index(author, "<"), strings.LastIndex(author, ">")
+		if start >= 0 && end > start && !IsNoreplyEmail(author[start+1:end], "") {
+			return fmt.Errorf("profile '%s': %w: --author %s. Leave --author out, or give a noreply address", p.Name, ErrPublicEmail, author)
+		}
+	}
+	return nil
+}
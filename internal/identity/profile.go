@@ -4,24 +4,85 @@ package identity
 import (
 	"bufio"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/paths"
 )
 
 // Profile represents a git/GitHub identity profile.
 type Profile struct {
-	Name        string // Profile name (e.g., "personal", "work")
-	DisplayName string // Display name for git commits (optional, overrides User)
-	SSHKey      string // Path to SSH private key (required for git-as)
-	Email       string // Git author/committer email (required for git-as)
-	User        string // Git author/committer name (optional)
-	GHUser      string // GitHub username for gh-as (optional)
+	Name         string            // Profile name (e.g., "personal", "work")
+	DisplayName  string            // Display name for git commits (optional, overrides User)
+	SSHKey       string            // Path to SSH private key (required for git-as)
+	Email        string            // Git author/committer email (required for git-as)
+	User         string            // Git author/committer name (optional)
+	GHUser       string            // GitHub username for gh-as, or the Forge account (optional)
+	Forge        string            // Where GHUser lives: github (default), gitlab[:host] or gitea:host (optional)
+	GLUser       string            // Username on gitlab.com (optional)
+	BTUser       string            // Username on bitbucket.org (optional)
+	Hosts        map[string]string // Usernames on other forge hosts, from host.<host>.user (optional)
+	GHFlags      map[string]string // Flags gh-as adds to gh commands ("pr list"), from gh.<command>.flags (optional)
+	GHAliases    map[string]string // gh-as aliases and what they stand for, from gh.<alias>.alias (optional)
+	Trailers     []string          // Trailers git-as adds to commits, as "Token: value" (optional)
+	PrivateEmail bool              // Email must be the GitHub noreply address of GHUser, from privateemail (optional)
+	Context      string            // Group of profiles it belongs to, such as work or a client (optional)
 }
 
 // profileKeys are the git config keys used for profile fields.
-var profileKeys = []string{"name", "sshkey", "email", "user", "ghuser"}
+var profileKeys = []string{"name", "sshkey", "email", "user", "ghuser", "forge", "gluser", "btuser", privateEmailKey, "context", trailerKey}
+
+// Keys returns the profile fields that can be set, besides the
+// host.<host>.user ones.
+func Keys() []string {
+	return append([]string(nil), profileKeys...)
+}
+
+// HostKey returns the key of the profile's username on a forge host
+func HostKey(host string) string {
+	return "host." + host + ".user"
+}
+
+// keyHost returns the host of a host.<host>.user key
+func keyHost(key string) (string, bool) {
+	host, ok := strings.CutPrefix(key, "host.")
+	if !ok {
+		return "", false
+	}
+	host, ok = strings.CutSuffix(host, ".user")
+	return host, ok && host != ""
+}
+
+// validKey reports whether key is a profile field
+func validKey(key string) bool {
+	for _, k := range profileKeys {
+		if k == key {
+			return true
+		}
+	}
+	if _, ok := keyHost(key); ok {
+		return true
+	}
+	_, _, ok := keyGH(key)
+	return ok
+}
+
+// hostKeys returns the host.<host>.user keys of the profile, sorted
+func (p *Profile) hostKeys() []string {
+	keys := make([]string, 0, len(p.Hosts))
+	for host := range p.Hosts {
+		keys = append(keys, HostKey(host))
+	}
+	sort.Strings(keys)
+	return keys
+}
 
 // CommitName returns the name to use for git commits.
 // Prefers DisplayName, falls back to User.
@@ -32,10 +93,89 @@ func (p *Profile) CommitName() string {
 	return p.User
 }
 
+// GitEnv returns the environment overrides that make git act as the
+// profile: its SSH key for remotes and its email and name for commits.
+// The profile must have an SSH key that exists, and wasn't revoked, and an
+// email, its GitHub noreply address when privateemail is set.
+func (p *Profile) GitEnv() ([]string, error) {
+	if p.SSHKey == "" {
+		return nil, fmt.Errorf("profile '%s' has no SSH key configured.\nUse: git-id set %s sshkey <path>", p.Name, p.Name)
+	}
+	if p.Email == "" {
+		return nil, fmt.Errorf("profile '%s' has no email configured.\nUse: git-id set %s email <email>", p.Name, p.Name)
+	}
+	if err := ValidateSSHKey(p.SSHKey); err != nil {
+		return nil, err
+	}
+	if err := p.CheckNotRevoked(); err != nil {
+		return nil, err
+	}
+	if err := p.CheckPrivateEmail(); err != nil {
+		return nil, err
+	}
+
+	env := []string{
+		"GIT_SSH_COMMAND=" + p.SSHCommand(),
+		fmt.Sprintf("GIT_AUTHOR_EMAIL=%s", p.Email),
+		fmt.Sprintf("GIT_COMMITTER_EMAIL=%s", p.Email),
+	}
+	if name := p.CommitName(); name != "" {
+		env = append(env,
+			fmt.Sprintf("GIT_AUTHOR_NAME=%s", name),
+			fmt.Sprintf("GIT_COMMITTER_NAME=%s", name),
+		)
+	}
+	return env, nil
+}
+
+// SSHCommand returns the ssh command that authenticates with the profile's
+// SSH key, and only with it
+func (p *Profile) SSHCommand() string {
+	return fmt.Sprintf("ssh -i %s -o IdentitiesOnly=yes", paths.ShellQuote(ExpandPath(p.SSHKey)))
+}
+
+// MergeSSHCommand adds the options of ours, a command from SSHCommand, to
+// existing, a GIT_SSH_COMMAND set before, so that its settings, such as a
+// ProxyCommand for a bastion, are kept. Ours go first, so the profile's key
+// is the one offered. A command SSHCommand made, as for another profile,
+// is replaced. It returns false when existing doesn't run ssh itself, so
+// ssh options can't be added to it.
+func MergeSSHCommand(existing, ours string) (string, bool) {
+	existing = strings.TrimSpace(existing)
+	if existing == "" || isProfileSSHCommand(existing) {
+		return ours, true
+	}
+	program, rest := firstWord(existing)
+	name := strings.ToLower(filepath.Base(strings.Trim(strings.ReplaceAll(program, `\`, "/"), `'"`)))
+	if strings.TrimSuffix(name, ".exe") != "ssh" {
+		return "", false
+	}
+	return program + strings.TrimPrefix(ours, "ssh") + rest, true
+}
+
+// isProfileSSHCommand reports whether command is one SSHCommand made
+func isProfileSSHCommand(command string) bool {
+	return strings.HasPrefix(command, "ssh -i ") && strings.HasSuffix(command, " -o IdentitiesOnly=yes")
+}
+
+// firstWord splits a shell command after its first word, which may be
+// quoted
+func firstWord(s string) (word, rest string) {
+	if q := s[0]; q == '\'' || q == '"' {
+		if end := strings.IndexByte(s[1:], q); end >= 0 {
+			return s[:end+2], s[end+2:]
+		}
+	}
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		return s[:i], s[i:]
+	}
+	return s, ""
+}
+
 // List returns all profile names from git config.
 func List() ([]string, error) {
 	cmd := exec.Command("git", "config", "--get-regexp", `^identity\.`)
-	out, err := cmd.Output()
+	out, err := debuglog.Output(cmd)
 	if err != nil {
 		// No matches is not an error - just empty
 		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
@@ -91,20 +231,64 @@ func Get(name string) (*Profile, error) {
 	if val, err := getConfigValue(name, "ghuser"); err == nil {
 		p.GHUser = val
 	}
+	if val, err := getConfigValue(name, "forge"); err == nil {
+		p.Forge = val
+	}
+	if val, err := getConfigValue(name, "gluser"); err == nil {
+		p.GLUser = val
+	}
+	if val, err := getConfigValue(name, "btuser"); err == nil {
+		p.BTUser = val
+	}
+	if val, err := getConfigValue(name, privateEmailKey); err == nil {
+		p.PrivateEmail, _ = ParseBool(val)
+	}
+	if val, err := getConfigValue(name, "context"); err == nil {
+		p.Context = val
+	}
+	p.Hosts = getHostUsers(name)
+	p.GHFlags, p.GHAliases = getGHSettings(name)
+	p.Trailers = getTrailers(name)
 
 	// Check if profile exists (has at least one field)
-	if p.DisplayName == "" && p.SSHKey == "" && p.Email == "" && p.User == "" && p.GHUser == "" {
+	if p.DisplayName == "" && p.SSHKey == "" && p.Email == "" && p.User == "" && p.GHUser == "" && p.Forge == "" &&
+		p.GLUser == "" && p.BTUser == "" && len(p.Hosts) == 0 && len(p.GHFlags) == 0 && len(p.GHAliases) == 0 &&
+		len(p.Trailers) == 0 && !p.PrivateEmail && p.Context == "" {
 		return nil, fmt.Errorf("profile %q not found", name)
 	}
 
 	return p, nil
 }
 
+// getHostUsers reads the host.<host>.user keys of a profile, or nil when
+// it has none
+func getHostUsers(profile string) map[string]string {
+	prefix := fmt.Sprintf("identity.%s.", profile)
+	cmd := exec.Command("git", "config", "--get-regexp", `^`+regexp.QuoteMeta(prefix)+`host\..+\.user$`)
+	out, err := debuglog.Output(cmd)
+	if err != nil {
+		return nil
+	}
+
+	var hosts map[string]string
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		key, val, _ := strings.Cut(scanner.Text(), " ")
+		if host, ok := keyHost(strings.TrimPrefix(key, prefix)); ok {
+			if hosts == nil {
+				hosts = make(map[string]string)
+			}
+			hosts[host] = strings.TrimSpace(val)
+		}
+	}
+	return hosts
+}
+
 // getConfigValue reads a single config value.
 func getConfigValue(profile, key string) (string, error) {
 	configKey := fmt.Sprintf("identity.%s.%s", profile, key)
 	cmd := exec.Command("git", "config", "--get", configKey)
-	out, err := cmd.Output()
+	out, err := debuglog.Output(cmd)
 	if err != nil {
 		return "", err
 	}
@@ -117,7 +301,7 @@ func GetSourceFile(name string) (string, error) {
 	for _, key := range profileKeys {
 		configKey := fmt.Sprintf("identity.%s.%s", name, key)
 		cmd := exec.Command("git", "config", "--show-origin", "--get", configKey)
-		out, err := cmd.Output()
+		out, err := debuglog.Output(cmd)
 		if err != nil {
 			continue
 		}
@@ -141,7 +325,7 @@ func GetAllSourceFiles(name string) ([]string, error) {
 	for _, key := range profileKeys {
 		configKey := fmt.Sprintf("identity.%s.%s", name, key)
 		cmd := exec.Command("git", "config", "--show-origin", "--get-all", configKey)
-		out, err := cmd.Output()
+		out, err := debuglog.Output(cmd)
 		if err != nil {
 			continue
 		}
@@ -199,6 +383,11 @@ func Set(p *Profile, opts SetOptions) (string, error) {
 		}
 	}
 
+	// On a fresh machine, not even the directory of the config file exists
+	if err := os.MkdirAll(filepath.Dir(targetFile), 0o750); err != nil {
+		return targetFile, err
+	}
+
 	// Write each field
 	if p.DisplayName != "" {
 		if err := setConfigValue(targetFile, p.Name, "name", p.DisplayName); err != nil {
@@ -225,6 +414,46 @@ func Set(p *Profile, opts SetOptions) (string, error) {
 			return targetFile, err
 		}
 	}
+	if p.Forge != "" {
+		if err := setConfigValue(targetFile, p.Name, "forge", p.Forge); err != nil {
+			return targetFile, err
+		}
+	}
+	if p.GLUser != "" {
+		if err := setConfigValue(targetFile, p.Name, "gluser", p.GLUser); err != nil {
+			return targetFile, err
+		}
+	}
+	if p.BTUser != "" {
+		if err := setConfigValue(targetFile, p.Name, "btuser", p.BTUser); err != nil {
+			return targetFile, err
+		}
+	}
+	if p.PrivateEmail {
+		if err := setConfigValue(targetFile, p.Name, privateEmailKey, "true"); err != nil {
+			return targetFile, err
+		}
+	}
+	if p.Context != "" {
+		if err := setConfigValue(targetFile, p.Name, "context", p.Context); err != nil {
+			return targetFile, err
+		}
+	}
+	for host, user := range p.Hosts {
+		if err := setConfigValue(targetFile, p.Name, HostKey(host), user); err != nil {
+			return targetFile, err
+		}
+	}
+	for key, value := range p.ghKeys() {
+		if err := setConfigValue(targetFile, p.Name, key, value); err != nil {
+			return targetFile, err
+		}
+	}
+	for _, trailer := range p.Trailers {
+		if err := setTrailer(targetFile, p.Name, trailer); err != nil {
+			return targetFile, err
+		}
+	}
 
 	// Verify write succeeded by reading back from the specific file
 	if err := verifyWrite(targetFile, p); err != nil {
@@ -245,7 +474,7 @@ func Set(p *Profile, opts SetOptions) (string, error) {
 func setConfigValue(file, profile, key, value string) error {
 	configKey := fmt.Sprintf("identity.%s.%s", profile, key)
 	cmd := exec.Command("git", "config", "--file", file, configKey, value)
-	if err := cmd.Run(); err != nil {
+	if err := debuglog.Run(cmd); err != nil {
 		return fmt.Errorf("failed to set %s: %w", configKey, err)
 	}
 	return nil
@@ -259,7 +488,7 @@ func verifyWrite(file string, p *Profile) error {
 		}
 		configKey := fmt.Sprintf("identity.%s.%s", p.Name, key)
 		cmd := exec.Command("git", "config", "--file", file, "--get", configKey)
-		out, err := cmd.Output()
+		out, err := debuglog.Output(cmd)
 		if err != nil {
 			return fmt.Errorf("write failed: %s not found in %s", configKey, file)
 		}
@@ -281,7 +510,39 @@ func verifyWrite(file string, p *Profile) error {
 	if err := check("user", p.User); err != nil {
 		return err
 	}
-	return check("ghuser", p.GHUser)
+	if err := check("ghuser", p.GHUser); err != nil {
+		return err
+	}
+	if err := check("forge", p.Forge); err != nil {
+		return err
+	}
+	if err := check("gluser", p.GLUser); err != nil {
+		return err
+	}
+	if err := check("btuser", p.BTUser); err != nil {
+		return err
+	}
+	if err := check("context", p.Context); err != nil {
+		return err
+	}
+	if p.PrivateEmail {
+		if err := check(privateEmailKey, "true"); err != nil {
+			return err
+		}
+	}
+	for _, key := range p.hostKeys() {
+		host, _ := keyHost(key)
+		if err := check(key, p.Hosts[host]); err != nil {
+			return err
+		}
+	}
+	gh := p.ghKeys()
+	for _, key := range slices.Sorted(maps.Keys(gh)) {
+		if err := check(key, gh[key]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // verifyEffective checks that git's merged config returns our values.
@@ -309,7 +570,39 @@ func verifyEffective(p *Profile) error {
 	if err := check("user", p.User); err != nil {
 		return err
 	}
-	return check("ghuser", p.GHUser)
+	if err := check("ghuser", p.GHUser); err != nil {
+		return err
+	}
+	if err := check("forge", p.Forge); err != nil {
+		return err
+	}
+	if err := check("gluser", p.GLUser); err != nil {
+		return err
+	}
+	if err := check("btuser", p.BTUser); err != nil {
+		return err
+	}
+	if err := check("context", p.Context); err != nil {
+		return err
+	}
+	if p.PrivateEmail {
+		if err := check(privateEmailKey, "true"); err != nil {
+			return err
+		}
+	}
+	for _, key := range p.hostKeys() {
+		host, _ := keyHost(key)
+		if err := check(key, p.Hosts[host]); err != nil {
+			return err
+		}
+	}
+	gh := p.ghKeys()
+	for _, key := range slices.Sorted(maps.Keys(gh)) {
+		if err := check(key, gh[key]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Remove deletes a profile from its source file.
@@ -320,9 +613,25 @@ func Remove(name string) error {
 		return err
 	}
 
+	// host.<host>.user, gh.<command>.flags and gh.<alias>.alias keys live
+	// in sections of their own
+	p := &Profile{Name: name}
+	p.GHFlags, p.GHAliases = getGHSettings(name)
+	sections := make(map[string]bool)
+	for host := range getHostUsers(name) {
+		sections["host."+host] = true
+	}
+	for key := range p.ghKeys() {
+		sections[key[:strings.LastIndex(key, ".")]] = true
+	}
+	for section := range sections {
+		section = fmt.Sprintf("identity.%s.%s", name, section)
+		_ = debuglog.Run(exec.Command("git", "config", "--file", file, "--remove-section", section))
+	}
+
 	section := fmt.Sprintf("identity.%s", name)
 	cmd := exec.Command("git", "config", "--file", file, "--remove-section", section)
-	if err := cmd.Run(); err != nil {
+	if err := debuglog.Run(cmd); err != nil {
 		return fmt.Errorf("failed to remove profile %q: %w", name, err)
 	}
 	return nil
@@ -331,7 +640,7 @@ func Remove(name string) error {
 // DefaultConfigFile returns the default git config file to use.
 // Prefers ~/.gitconfig if it exists, otherwise uses XDG path.
 func DefaultConfigFile() string {
-	home, err := os.UserHomeDir()
+	home, err := paths.Home()
 	if err != nil {
 		return ""
 	}
@@ -342,7 +651,8 @@ func DefaultConfigFile() string {
 		return gitconfig
 	}
 
-	// Use XDG path
+	// Use XDG path, which git looks for under the home directory on every
+	// platform, Windows included
 	xdgConfig := os.Getenv("XDG_CONFIG_HOME")
 	if xdgConfig == "" {
 		xdgConfig = filepath.Join(home, ".config")
@@ -353,9 +663,9 @@ func DefaultConfigFile() string {
 // SetField sets a single field on an existing profile.
 func SetField(name, key, value string, opts SetOptions) (string, error) {
 	// Validate key
-	validKeys := map[string]bool{"name": true, "sshkey": true, "email": true, "user": true, "ghuser": true}
-	if !validKeys[key] {
-		return "", fmt.Errorf("invalid key %q, must be one of: sshkey, email, user, ghuser", key)
+	if !validKey(key) {
+		return "", fmt.Errorf("invalid key %q, must be one of: %s, host.<host>.user, gh.<command>.flags, gh.<alias>.alias",
+			key, strings.Join(profileKeys, ", "))
 	}
 
 	// Determine target file
@@ -368,6 +678,11 @@ func SetField(name, key, value string, opts SetOptions) (string, error) {
 		targetFile = existingFile
 	}
 
+	// A profile has several trailers, each set or removed by its token
+	if key == trailerKey {
+		return targetFile, setTrailer(targetFile, name, value)
+	}
+
 	// Write the value
 	if err := setConfigValue(targetFile, name, key, value); err != nil {
 		return targetFile, err
@@ -376,7 +691,7 @@ func SetField(name, key, value string, opts SetOptions) (string, error) {
 	// Verify write
 	configKey := fmt.Sprintf("identity.%s.%s", name, key)
 	cmd := exec.Command("git", "config", "--file", targetFile, "--get", configKey)
-	out, err := cmd.Output()
+	out, err := debuglog.Output(cmd)
 	if err != nil || strings.TrimSpace(string(out)) != value {
 		return targetFile, fmt.Errorf("write failed")
 	}
@@ -0,0 +1,111 @@
+package identity
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jdevera/git-this-bread/internal/paths"
+)
+
+// ErrKeyRevoked is returned for a profile whose SSH key was revoked with
+// git-id revoke
+var ErrKeyRevoked = errors.New("SSH key was revoked")
+
+// RevokedKeysFile returns the path of the list of revoked public keys, one
+// per line: the format sshd's RevokedKeys reads, so it can be copied to
+// servers as is
+func RevokedKeysFile() (string, error) {
+	stateHome, err := paths.StateHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateHome, "git-this-bread", "revoked_keys"), nil
+}
+
+// RevokeKey adds a public key line to the revoked keys, unless it is there
+// already
+func RevokeKey(key string) error {
+	if revoked, err := IsRevoked(key); err != nil || revoked {
+		return err
+	}
+	path, err := RevokedKeysFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //nolint:gosec // path is under the state directory
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(f, strings.TrimSpace(key))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// IsRevoked reports whether a public key line is among the revoked keys,
+// whatever its comment
+func IsRevoked(key string) (bool, error) {
+	path, err := RevokedKeysFile()
+	if err != nil {
+		return false, err
+	}
+	f, err := os.Open(path) //nolint:gosec // path is under the state directory
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = f.Close() }()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if SameKey(scanner.Text(), key) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// CheckNotRevoked fails with ErrKeyRevoked when the profile's SSH key was
+// revoked. A key without a readable public half passes.
+func (p *Profile) CheckNotRevoked() error {
+	key, err := PublicKey(p.SSHKey)
+	if err != nil {
+		return nil //nolint:nilerr // Nothing to compare with the revoked keys
+	}
+	revoked, err := IsRevoked(key)
+	if err != nil || !revoked {
+		return err
+	}
+	return fmt.Errorf("profile '%s': %w. Set a new one with: git-id set %s sshkey <path>", p.Name, ErrKeyRevoked, p.Name)
+}
+
+// RetireKey moves an SSH key and its public half aside, to
+// <key>.revoked-<date> and <key>.revoked-<date>.pub, so that nothing uses it
+// by its old path. It returns the new path of the private key.
+func RetireKey(sshKey string, now time.Time) (string, error) {
+	path := paths.Expand(sshKey)
+	retired := path + ".revoked-" + now.Format("20060102")
+	for n := 2; ; n++ {
+		if _, err := os.Stat(retired); os.IsNotExist(err) {
+			break
+		}
+		retired = fmt.Sprintf("%s.revoked-%s-%d", path, now.Format("20060102"), n)
+	}
+	if err := os.Rename(path, retired); err != nil {
+		return "", fmt.Errorf("moving the revoked key aside: %w", err)
+	}
+	if err := os.Rename(path+".pub", retired+".pub"); err != nil && !os.IsNotExist(err) {
+		return retired, fmt.Errorf("moving the revoked public key aside: %w", err)
+	}
+	return retired, nil
+}
@@ -0,0 +1,236 @@
+package identity
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+)
+
+// Kinds of rules, each the key of a profile they are stored under, one
+// value per pattern
+const (
+	RuleDir    = "dir"    // A directory glob, matching the directory itself and everything under it
+	RuleRemote = "remote" // A host[/owner[/repo]] glob, matching a remote of the repository
+)
+
+// Rule picks a profile for the directories, or the repositories with a
+// remote, that its pattern matches
+type Rule struct {
+	Profile string
+	Kind    string // RuleDir or RuleRemote
+	Pattern string
+	File    string // Config file the rule is in
+}
+
+func (r Rule) String() string {
+	return r.Kind + " " + r.Pattern
+}
+
+// ErrAmbiguousRules is returned by MatchRule when equally specific rules
+// pick different profiles
+var ErrAmbiguousRules = errors.New("rules for different profiles match equally")
+
+// Rules reads the rules of every profile from git config, in the order
+// git lists them
+func Rules() ([]Rule, error) {
+	cmd := exec.Command("git", "config", "--show-origin", "--get-regexp", `^identity\..+\.(dir|remote)$`)
+	out, err := debuglog.Output(cmd)
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("git config failed: %w", err)
+	}
+
+	// Format: file:<path>\tidentity.<name>.<kind> <pattern>
+	var rules []Rule
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		origin, entry, _ := strings.Cut(scanner.Text(), "\t")
+		key, pattern, _ := strings.Cut(entry, " ")
+		name := strings.TrimPrefix(key, "identity.")
+		i := strings.LastIndex(name, ".")
+		rules = append(rules, Rule{
+			Profile: name[:i],
+			Kind:    name[i+1:],
+			Pattern: strings.TrimSpace(pattern),
+			File:    strings.TrimPrefix(origin, "file:"),
+		})
+	}
+	return rules, nil
+}
+
+// AddRule adds a rule to its profile, in the file the profile is defined
+// in. A relative directory pattern is made absolute first.
+func AddRule(r Rule) (Rule, error) {
+	if r.Kind != RuleDir && r.Kind != RuleRemote {
+		return r, fmt.Errorf("invalid rule kind %q, must be %s or %s", r.Kind, RuleDir, RuleRemote)
+	}
+	if r.Kind == RuleDir && !strings.HasPrefix(r.Pattern, "~") && !strings.HasPrefix(r.Pattern, "**") && !filepath.IsAbs(r.Pattern) {
+		abs, err := filepath.Abs(r.Pattern)
+		if err != nil {
+			return r, err
+		}
+		r.Pattern = abs
+	}
+	if _, err := path.Match(filepath.ToSlash(r.Pattern), ""); err != nil {
+		return r, fmt.Errorf("invalid pattern %q: %w", r.Pattern, err)
+	}
+
+	file, err := GetSourceFile(r.Profile)
+	if err != nil {
+		return r, fmt.Errorf("profile %q not found", r.Profile)
+	}
+	r.File = file
+
+	rules, err := Rules()
+	if err != nil {
+		return r, err
+	}
+	for _, existing := range rules {
+		if existing.Kind == r.Kind && existing.Pattern == r.Pattern {
+			return r, fmt.Errorf("rule %s already picks profile %q", r, existing.Profile)
+		}
+	}
+
+	configKey := fmt.Sprintf("identity.%s.%s", r.Profile, r.Kind)
+	if err := debuglog.Run(exec.Command("git", "config", "--file", file, "--add", configKey, r.Pattern)); err != nil {
+		return r, fmt.Errorf("failed to set %s: %w", configKey, err)
+	}
+	return r, nil
+}
+
+// RemoveRule removes the rules whose pattern is pattern, only profile's
+// when it isn't "", and returns them
+func RemoveRule(pattern, profile string) ([]Rule, error) {
+	rules, err := Rules()
+	if err != nil {
+		return nil, err
+	}
+	var removed []Rule
+	for _, r := range rules {
+		if r.Pattern != pattern || (profile != "" && r.Profile != profile) {
+			continue
+		}
+		configKey := fmt.Sprintf("identity.%s.%s", r.Profile, r.Kind)
+		cmd := exec.Command("git", "config", "--file", r.File, "--unset-all", configKey, "^"+regexp.QuoteMeta(pattern)+"$")
+		if err := debuglog.Run(cmd); err != nil {
+			return removed, fmt.Errorf("failed to remove rule %s from %s: %w", r, r.File, err)
+		}
+		removed = append(removed, r)
+	}
+	if len(removed) == 0 {
+		if profile != "" {
+			return nil, fmt.Errorf("no rule of profile %q has pattern %q", profile, pattern)
+		}
+		return nil, fmt.Errorf("no rule has pattern %q", pattern)
+	}
+	return removed, nil
+}
+
+// RuleFor returns the rule that picks the profile for dir, from the rules
+// in git config and the remotes of the repository there, or nil when none
+// matches
+func RuleFor(dir string) (*Rule, error) {
+	rules, err := Rules()
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var urls []string
+	for _, r := range remotesIn(dir) {
+		urls = append(urls, r.URL)
+	}
+	return MatchRule(rules, abs, urls)
+}
+
+// MatchRule returns the rule that picks the profile for dir, an absolute
+// path, whose repository has remotes at urls, or nil when none matches.
+// Remote rules come before directory rules, which match the directory
+// closest to dir first; among rules as close, the one with the most
+// characters that aren't wildcards wins. Rules that win as much but pick
+// different profiles are an ErrAmbiguousRules.
+func MatchRule(rules []Rule, dir string, urls []string) (*Rule, error) {
+	best, bestRank := -1, [2]int{}
+	ambiguous := false
+	for i, r := range rules {
+		rank, ok := ruleRank(r, dir, urls)
+		if !ok {
+			continue
+		}
+		switch {
+		case best < 0 || rank[0] > bestRank[0] || (rank[0] == bestRank[0] && rank[1] > bestRank[1]):
+			best, bestRank, ambiguous = i, rank, false
+		case rank == bestRank && r.Profile != rules[best].Profile:
+			ambiguous = true
+		}
+	}
+	if best < 0 {
+		return nil, nil
+	}
+	if ambiguous {
+		return nil, fmt.Errorf("%w for %s", ErrAmbiguousRules, dir)
+	}
+	return &rules[best], nil
+}
+
+// ruleRank reports whether r matches dir or urls and how closely, a
+// higher rank being closer: how close the match is, then how literal the
+// pattern is
+func ruleRank(r Rule, dir string, urls []string) ([2]int, bool) {
+	literal := len(r.Pattern) - strings.Count(r.Pattern, "*") - strings.Count(r.Pattern, "?")
+	if r.Kind == RuleRemote {
+		for _, url := range urls {
+			if analyzer.MatchRemote(r.Pattern, url) {
+				return [2]int{0, literal}, true
+			}
+		}
+		return [2]int{}, false
+	}
+
+	pattern := strings.Split(filepath.ToSlash(filepath.Clean(ExpandPath(r.Pattern))), "/")
+	for up, d := 0, dir; ; up++ {
+		if matchSegments(pattern, strings.Split(filepath.ToSlash(d), "/")) {
+			return [2]int{-1 - up, literal}, true
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return [2]int{}, false
+		}
+		d = parent
+	}
+}
+
+// matchSegments matches the segments of a path against those of a glob,
+// where a ** segment matches any number of them
+func matchSegments(globs, segments []string) bool {
+	if len(globs) == 0 {
+		return len(segments) == 0
+	}
+	if globs[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(globs[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(globs[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(globs[1:], segments[1:])
+}
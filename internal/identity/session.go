@@ -0,0 +1,133 @@
+package identity
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Environment variables that hold an identity session, exported into a
+// shell by git-id session start
+const (
+	SessionVar        = "GIT_ID_SESSION"         // Profile name
+	SessionExpiresVar = "GIT_ID_SESSION_EXPIRES" // RFC 3339 time
+	SessionStrictVar  = "GIT_ID_SESSION_STRICT"  // "1" to refuse git-as/gh-as once expired
+)
+
+// Session is a profile exported into a shell until a set time
+type Session struct {
+	Profile string
+	Expires time.Time
+	Strict  bool // git-as and gh-as refuse to run once it expires
+}
+
+// NewSession starts a session of a profile that lasts d
+func NewSession(profile string, d time.Duration, strict bool, now time.Time) *Session {
+	return &Session{Profile: profile, Expires: now.Add(d).Truncate(time.Second), Strict: strict}
+}
+
+// CurrentSession reads the session of this shell from the environment. It
+// returns nil when there is none.
+func CurrentSession() (*Session, error) {
+	return sessionFromEnv(os.Getenv)
+}
+
+func sessionFromEnv(getenv func(string) string) (*Session, error) {
+	name := getenv(SessionVar)
+	if name == "" {
+		return nil, nil
+	}
+	expires, err := time.Parse(time.RFC3339, getenv(SessionExpiresVar))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", SessionExpiresVar, err)
+	}
+	return &Session{Profile: name, Expires: expires, Strict: getenv(SessionStrictVar) == "1"}, nil
+}
+
+// Expired reports whether the session is over at now
+func (s *Session) Expired(now time.Time) bool {
+	return !now.Before(s.Expires)
+}
+
+// Env returns the variables that record the session itself, to export with
+// the profile's GitEnv
+func (s *Session) Env() []string {
+	env := []string{
+		SessionVar + "=" + s.Profile,
+		SessionExpiresVar + "=" + s.Expires.Format(time.RFC3339),
+	}
+	if s.Strict {
+		env = append(env, SessionStrictVar+"=1")
+	}
+	return env
+}
+
+// CheckSession looks at the session of this shell before git-as or gh-as
+// run. Once it has expired it returns a warning to show or, for a strict
+// session, an error.
+func CheckSession(now time.Time) (warning string, err error) {
+	s, err := CurrentSession()
+	if err != nil || s == nil || !s.Expired(now) {
+		return "", err
+	}
+	msg := fmt.Sprintf("the git-id session of %q expired %s ago", s.Profile, now.Sub(s.Expires).Round(time.Minute))
+	if s.Strict {
+		return "", fmt.Errorf("%s. Start a new one with: eval \"$(git-id session start <profile>)\"", msg)
+	}
+	return msg + ". End it with: eval \"$(git-id session end)\"", nil
+}
+
+// SessionVars returns the names of every variable a session exports, for
+// ending it
+func SessionVars() []string {
+	return []string{
+		"GIT_SSH_COMMAND",
+		"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL",
+		"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME",
+		SessionVar, SessionExpiresVar, SessionStrictVar,
+	}
+}
+
+// ShellExports formats variables as commands for shell, which is "sh"
+// (bash, zsh and the like), "fish" or "powershell", to be eval'ed
+func ShellExports(shell string, env []string) (string, error) {
+	var b strings.Builder
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		switch shell {
+		case "sh":
+			fmt.Fprintf(&b, "export %s=%s\n", k, shQuote(v))
+		case "fish":
+			fmt.Fprintf(&b, "set -gx %s %s\n", k, shQuote(v))
+		case "powershell":
+			fmt.Fprintf(&b, "$env:%s = '%s'\n", k, strings.ReplaceAll(v, "'", "''"))
+		default:
+			return "", fmt.Errorf("unknown shell %q (use sh, fish or powershell)", shell)
+		}
+	}
+	return b.String(), nil
+}
+
+// ShellUnsets formats commands that remove variables in shell
+func ShellUnsets(shell string, names []string) (string, error) {
+	var b strings.Builder
+	for _, k := range names {
+		switch shell {
+		case "sh":
+			fmt.Fprintf(&b, "unset %s\n", k)
+		case "fish":
+			fmt.Fprintf(&b, "set -e %s\n", k)
+		case "powershell":
+			fmt.Fprintf(&b, "Remove-Item Env:%s -ErrorAction SilentlyContinue\n", k)
+		default:
+			return "", fmt.Errorf("unknown shell %q (use sh, fish or powershell)", shell)
+		}
+	}
+	return b.String(), nil
+}
+
+// shQuote single-quotes s for sh and fish
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,91 @@
+package identity
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+)
+
+// trailerKey is the key of the commit trailers of a profile, one value per
+// trailer, as "Token: value"
+const trailerKey = "trailer"
+
+// ParseTrailer splits a trailer, "Token: value", into its token and value.
+// An empty value is allowed, to remove the trailer.
+func ParseTrailer(trailer string) (token, value string, err error) {
+	token, value, ok := strings.Cut(trailer, ":")
+	token = strings.TrimSpace(token)
+	if !ok || token == "" || strings.ContainsAny(token, " \t") {
+		return "", "", fmt.Errorf("invalid trailer %q: give it as \"Token: value\", like \"Billing-Code: ACME-42\"", trailer)
+	}
+	return token, strings.TrimSpace(value), nil
+}
+
+// getTrailers reads the trailers of a profile
+func getTrailers(profile string) []string {
+	cmd := exec.Command("git", "config", "--get-all", fmt.Sprintf("identity.%s.%s", profile, trailerKey))
+	out, err := debuglog.Output(cmd)
+	if err != nil {
+		return nil
+	}
+	var trailers []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			trailers = append(trailers, line)
+		}
+	}
+	return trailers
+}
+
+// setTrailer writes a trailer of a profile to file, replacing the one with
+// the same token. A trailer without a value removes it.
+func setTrailer(file, profile, trailer string) error {
+	token, value, err := ParseTrailer(trailer)
+	if err != nil {
+		return err
+	}
+	configKey := fmt.Sprintf("identity.%s.%s", profile, trailerKey)
+	same := "^" + regexp.QuoteMeta(token) + ":"
+	args := []string{"config", "--file", file, "--replace-all", configKey, token + ": " + value, same}
+	if value == "" {
+		args = []string{"config", "--file", file, "--unset-all", configKey, same}
+	}
+	if err := debuglog.Run(exec.Command("git", args...)); err != nil && value != "" {
+		return fmt.Errorf("failed to set %s: %w", configKey, err)
+	}
+	return nil
+}
+
+// AddTrailers adds the profile's trailers to the arguments of a git
+// command, when it is a commit, as --trailer options (git 2.32 or later).
+// git leaves out a trailer the message already ends with.
+func (p *Profile) AddTrailers(args []string) []string {
+	i := gitCommand(args)
+	if len(p.Trailers) == 0 || i < 0 || args[i] != "commit" {
+		return args
+	}
+	result := slices.Clone(args[:i+1])
+	for _, t := range p.Trailers {
+		result = append(result, "--trailer", t)
+	}
+	return append(result, args[i+1:]...)
+}
+
+// gitCommand returns the index of the command in the arguments of git,
+// past git's own options, or -1 when there is none
+func gitCommand(args []string) int {
+	for i := 0; i < len(args); i++ {
+		switch a := args[i]; {
+		case a == "-C" || a == "-c" || a == "--git-dir" || a == "--work-tree" || a == "--namespace" || a == "--config-env":
+			i++ // Their value is the next argument
+		case strings.HasPrefix(a, "-"):
+		default:
+			return i
+		}
+	}
+	return -1
+}
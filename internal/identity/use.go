@@ -0,0 +1,217 @@
+package identity
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/paths"
+)
+
+// Include is an [includeIf "gitdir:<Dir>"] stanza of the global git
+// config, which includes Path for the repositories under Dir
+type Include struct {
+	Dir  string // gitdir pattern, such as ~/clients/acme/
+	Path string
+}
+
+// FragmentFile returns the path of the git config fragment Use writes for
+// the profile called name
+func FragmentFile(name string) (string, error) {
+	configHome, err := paths.ConfigHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configHome, "git-this-bread", "identities", name+".gitconfig"), nil
+}
+
+// Use writes p into its fragment, as user.email, user.name and
+// core.sshCommand, and includes the fragment from the global git config
+// for the repositories under dir, so git acts as the profile there without
+// git-as. It returns the include, and whether it was added: false when the
+// global config had it already, in which case only the fragment is
+// rewritten. The profile must have what git-as needs.
+func Use(p *Profile, dir string) (Include, bool, error) {
+	if _, err := p.GitEnv(); err != nil {
+		return Include{}, false, err
+	}
+	fragment, err := FragmentFile(p.Name)
+	if err != nil {
+		return Include{}, false, err
+	}
+	pattern, err := gitdirPattern(dir)
+	if err != nil {
+		return Include{}, false, err
+	}
+	inc := Include{Dir: pattern, Path: fragment}
+
+	includes, err := Includes()
+	if err != nil {
+		return inc, false, err
+	}
+	added := true
+	for _, existing := range includes {
+		if existing.Dir != pattern {
+			continue
+		}
+		if existing.Path == fragment {
+			added = false
+			continue
+		}
+		if other, ok := fragmentProfile(existing.Path); ok {
+			return inc, false, fmt.Errorf("%s already uses profile '%s'. Use: git-id use %s --remove --dir %s", pattern, other, other, dir)
+		}
+	}
+
+	if err := writeFragment(fragment, p); err != nil {
+		return inc, false, err
+	}
+	if added {
+		cmd := exec.Command("git", "config", "--global", "--add", includeKey(pattern), fragment)
+		if err := debuglog.Run(cmd); err != nil {
+			return inc, false, fmt.Errorf("failed to set %s: %w", includeKey(pattern), err)
+		}
+	}
+	return inc, added, nil
+}
+
+// Unuse removes the includes of the fragment of the profile called name
+// from the global git config, only the one for the repositories under dir
+// when it isn't "", and returns them. The fragment is deleted once nothing
+// includes it.
+func Unuse(name, dir string) ([]Include, error) {
+	fragment, err := FragmentFile(name)
+	if err != nil {
+		return nil, err
+	}
+	pattern := ""
+	if dir != "" {
+		if pattern, err = gitdirPattern(dir); err != nil {
+			return nil, err
+		}
+	}
+	includes, err := Includes()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []Include
+	left := false
+	for _, inc := range includes {
+		if inc.Path != fragment {
+			continue
+		}
+		if pattern != "" && inc.Dir != pattern {
+			left = true
+			continue
+		}
+		key := includeKey(inc.Dir)
+		cmd := exec.Command("git", "config", "--global", "--unset-all", key, "^"+regexp.QuoteMeta(fragment)+"$")
+		if err := debuglog.Run(cmd); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", key, err)
+		}
+		// The stanza is left empty unless something else is in it
+		if configValue(".", "--global", "--get-regexp", `^includeif\.gitdir:`+regexp.QuoteMeta(inc.Dir)+`\.`) == "" {
+			_ = debuglog.Run(exec.Command("git", "config", "--global", "--remove-section", "includeIf.gitdir:"+inc.Dir))
+		}
+		removed = append(removed, inc)
+	}
+	if len(removed) == 0 {
+		if pattern != "" {
+			return nil, fmt.Errorf("profile '%s' is not used for %s", name, pattern)
+		}
+		return nil, fmt.Errorf("profile '%s' is not used for any directory", name)
+	}
+	if !left {
+		if err := os.Remove(fragment); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// Includes lists the [includeIf "gitdir:..."] stanzas of the global git
+// config, in the order git lists them
+func Includes() ([]Include, error) {
+	cmd := exec.Command("git", "config", "--global", "--null", "--get-regexp", `^includeif\.gitdir:.*\.path$`)
+	out, err := debuglog.Output(cmd)
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("git config failed: %w", err)
+	}
+
+	// Format: includeif.gitdir:<dir>.path\n<path>\0
+	var includes []Include
+	for _, entry := range bytes.Split(out, []byte{0}) {
+		key, path, ok := strings.Cut(string(entry), "\n")
+		if !ok {
+			continue
+		}
+		dir := strings.TrimSuffix(strings.TrimPrefix(key, "includeif.gitdir:"), ".path")
+		includes = append(includes, Include{Dir: dir, Path: path})
+	}
+	return includes, nil
+}
+
+// includeKey returns the key of the path an includeIf for the gitdir
+// pattern dir includes
+func includeKey(dir string) string {
+	return "includeIf.gitdir:" + dir + ".path"
+}
+
+// gitdirPattern turns dir into the gitdir pattern of the repositories
+// under it: absolute, under ~/ when in the home directory, and ending in /
+// so git matches everything below
+func gitdirPattern(dir string) (string, error) {
+	abs, err := filepath.Abs(paths.Expand(dir))
+	if err != nil {
+		return "", err
+	}
+	pattern := filepath.ToSlash(abs)
+	if home, err := paths.Home(); err == nil {
+		home = filepath.ToSlash(filepath.Clean(home))
+		if rest, ok := strings.CutPrefix(pattern, home+"/"); ok {
+			pattern = "~/" + rest
+		}
+	}
+	return strings.TrimSuffix(pattern, "/") + "/", nil
+}
+
+// fragmentProfile returns the profile a fragment Use wrote is for, from
+// its file name, and whether path is one
+func fragmentProfile(path string) (string, bool) {
+	dir, err := FragmentFile("")
+	if err != nil || filepath.Dir(path) != filepath.Dir(dir) {
+		return "", false
+	}
+	return strings.TrimSuffix(filepath.Base(path), ".gitconfig"), true
+}
+
+// writeFragment writes the fragment of p to file, replacing what was there
+func writeFragment(file string, p *Profile) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return err
+	}
+	header := fmt.Sprintf("# Written by git-id use for profile %s: change the profile, then run git-id use again\n", p.Name)
+	if err := os.WriteFile(file, []byte(header), 0o600); err != nil {
+		return err
+	}
+	values := [][2]string{{"user.email", p.Email}, {"core.sshCommand", p.SSHCommand()}}
+	if name := p.CommitName(); name != "" {
+		values = append(values, [2]string{"user.name", name})
+	}
+	for _, kv := range values {
+		if err := debuglog.Run(exec.Command("git", "config", "--file", file, kv[0], kv[1])); err != nil {
+			return fmt.Errorf("failed to set %s in %s: %w", kv[0], file, err)
+		}
+	}
+	return nil
+}
@@ -1,22 +1,20 @@
 package identity
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/paths"
 )
 
 // ValidateSSHKey checks that the SSH key file exists and is readable.
 func ValidateSSHKey(path string) error {
-	// Expand ~ to home directory
-	if strings.HasPrefix(path, "~/") {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("cannot expand ~: %w", err)
-		}
-		path = home + path[1:]
-	}
+	path = paths.Expand(path)
 
 	info, err := os.Stat(path)
 	if err != nil {
@@ -42,20 +40,54 @@ func ValidateSSHKey(path string) error {
 
 // ExpandPath expands ~ to the user's home directory.
 func ExpandPath(path string) string {
-	if strings.HasPrefix(path, "~/") {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return path
-		}
-		return home + path[1:]
+	return paths.Expand(path)
+}
+
+// PublicKey returns the public half of an SSH key, read from the .pub file
+// next to it, as one "<type> <key> [comment]" line.
+func PublicKey(sshKey string) (string, error) {
+	path := paths.Expand(sshKey) + ".pub"
+	data, err := os.ReadFile(path) //nolint:gosec // path is the profile's key
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("public key not found: %s. Create it with: ssh-keygen -y -f %s > %s",
+			path, paths.Expand(sshKey), path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot read public key: %w", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if fields := strings.Fields(key); len(fields) < 2 || strings.Contains(key, "\n") || strings.Contains(key, "PRIVATE KEY") {
+		return "", fmt.Errorf("not an SSH public key: %s", path)
+	}
+	return key, nil
+}
+
+// SameKey reports whether two public key lines hold the same key, whatever
+// their comments
+func SameKey(a, b string) bool {
+	fa, fb := strings.Fields(a), strings.Fields(b)
+	return len(fa) >= 2 && len(fb) >= 2 && fa[0] == fb[0] && fa[1] == fb[1]
+}
+
+// Fingerprint returns the SHA256 fingerprint of a public key line, as
+// ssh-keygen -l and GitHub show it: "SHA256:<base64>".
+func Fingerprint(key string) (string, error) {
+	fields := strings.Fields(key)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("not an SSH public key: %q", key)
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", fmt.Errorf("not an SSH public key: %w", err)
 	}
-	return path
+	sum := sha256.Sum256(blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
 }
 
 // ValidateGHUser checks that the GitHub user is authenticated with gh CLI.
 func ValidateGHUser(username string) error {
 	cmd := exec.Command("gh", "auth", "status")
-	out, err := cmd.CombinedOutput()
+	out, err := debuglog.CombinedOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("gh auth failed: %w", err)
 	}
@@ -86,7 +118,7 @@ func GetGHAuthStatus(username string) GHAuthStatus {
 	}
 
 	cmd := exec.Command("gh", "auth", "status")
-	out, _ := cmd.CombinedOutput()
+	out, _ := debuglog.CombinedOutput(cmd)
 	output := string(out)
 
 	if strings.Contains(output, username) {
@@ -101,3 +133,13 @@ func GetGHAuthStatus(username string) GHAuthStatus {
 		Message:       "not authenticated. Run: gh auth login",
 	}
 }
+
+// GHToken returns the token gh CLI holds for an authenticated GitHub user.
+func GHToken(username string) (string, error) {
+	cmd := exec.Command("gh", "auth", "token", "--user", username)
+	out, err := debuglog.Output(cmd)
+	if err != nil {
+		return "", fmt.Errorf("no gh token for GitHub user %q. Run: gh auth login", username)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -2,17 +2,96 @@ package llmadvice
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/jdevera/git-this-bread/internal/analyzer"
 )
 
+// Advice is one suggestion from the model
+type Advice struct {
+	Text     string `json:"text"`
+	Severity string `json:"severity"`          // SeverityInfo, SeverityWarning or SeverityCritical
+	Command  string `json:"command,omitempty"` // Copy-ready command line, if one applies
+}
+
+// Severities the model is asked to use; anything else is read as info
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// UnmarshalJSON also accepts a plain string, which is how advice was cached
+// before it had a severity and command
+func (a *Advice) UnmarshalJSON(data []byte) error {
+	var text string
+	if json.Unmarshal(data, &text) == nil {
+		*a = Advice{Text: text, Severity: SeverityInfo}
+		return nil
+	}
+	type plain Advice // Drops this method to avoid recursion
+	return json.Unmarshal(data, (*plain)(a))
+}
+
+// Texts returns the text of each advice item
+func Texts(advice []Advice) []string {
+	texts := make([]string, len(advice))
+	for i, a := range advice {
+		texts[i] = a.Text
+	}
+	return texts
+}
+
 // Options configures the LLM advice behavior
 type Options struct {
 	Provider     ProviderType
+	Fallback     []ProviderType // Tried in order when Provider is unavailable
 	NoCache      bool
-	PerRepo      bool   // For multi-repo: analyze each repo individually
-	Instructions string // Custom user instructions for the LLM
+	CacheTTL     time.Duration // Ignore cached advice older than this (0 = no expiry)
+	CacheMax     int           // Max cache entries kept (0 = DefaultCacheMaxEntries)
+	CacheMaxSize int64         // Max cache size in bytes (0 = DefaultCacheMaxBytes)
+	PerRepo      bool          // For multi-repo: analyze each repo individually
+	Instructions string        // Custom user instructions for the LLM
+	Redact       bool          // Keep file names, URLs, messages and names out of prompts
+	Templates    *Templates    // Custom prompt text (nil = built-in)
+
+	Model       string   // Empty means the provider's env var or default model
+	Temperature *float64 // Nil means defaultTemperature
+	MaxTokens   int      // 0 means defaultMaxTokens
+
+	// Stream, when set, receives each advice item as the provider produces it.
+	// Cached advice is returned without calling it.
+	Stream func(Advice)
+
+	// OnUsage, when set, receives the token usage of every LLM response,
+	// fallback attempts included. Cached advice costs nothing and isn't reported.
+	OnUsage func(Usage)
+
+	// Per-repo mode runs up to Concurrency calls at once (0 = DefaultConcurrency),
+	// starting no more than RequestsPerMinute of them (0 = provider default,
+	// negative = unlimited). Progress, when set, is told how many repos are done.
+	Concurrency       int
+	RequestsPerMinute int
+	Progress          func(done, total int)
+
+	limiters map[ProviderType]*rateLimiter // One per provider of the chain, shared by the calls of one per-repo run
+
+	// ContextBudget caps the size of multi-repo prompts, in estimated tokens
+	// (0 = provider default, negative = unlimited)
+	ContextBudget int
+
+	BaseURL string            // OpenAI-compatible endpoint or Ollama server (empty = provider default)
+	Headers map[string]string // Extra HTTP headers for OpenAI-compatible endpoints
+
+	// Deployment makes the OpenAI provider talk to Azure OpenAI, at the
+	// resource endpoint in BaseURL, with APIVersion (empty = client default)
+	Deployment string
+	APIVersion string
 }
 
 // DefaultOptions returns the default options
@@ -24,37 +103,44 @@ func DefaultOptions() Options {
 	}
 }
 
+// CacheLimits returns the limits to prune the cache to, with defaults filled in
+func (o Options) CacheLimits() CacheLimits {
+	limits := CacheLimits{TTL: o.CacheTTL, MaxEntries: o.CacheMax, MaxBytes: o.CacheMaxSize}
+	if limits.MaxEntries == 0 {
+		limits.MaxEntries = DefaultCacheMaxEntries
+	}
+	if limits.MaxBytes == 0 {
+		limits.MaxBytes = DefaultCacheMaxBytes
+	}
+	return limits
+}
+
 // GetLLMAdvice returns LLM-powered advice for a single repo
 // basicAdvice is the rule-based advice that the LLM can improve upon
 // Falls back to nil (no advice) on error
-func GetLLMAdvice(info *analyzer.RepoInfo, basicAdvice []string, opts Options) ([]string, error) {
+func GetLLMAdvice(info *analyzer.RepoInfo, basicAdvice []string, opts Options) ([]Advice, error) {
 	// Check cache first
 	if !opts.NoCache {
-		if cached, err := ReadCache(info, opts.Instructions); err == nil {
+		if cached, err := ReadCache(info, opts); err == nil && !cached.Expired(opts.CacheTTL) {
 			return cached.Advice, nil
 		}
 	}
 
-	// Create provider
-	provider, err := NewProvider(opts.Provider)
-	if err != nil {
-		return nil, err
-	}
-
 	// Generate prompt and call LLM
-	prompt := FormatSingleRepoPrompt(info, basicAdvice, opts.Instructions)
+	prompt := FormatSingleRepoPrompt(info, basicAdvice, opts)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	advice, err := provider.GenerateAdvice(ctx, prompt)
+	advice, provider, err := generateWithFallback(ctx, prompt, opts)
 	if err != nil {
 		return nil, err
 	}
 
 	// Cache the result
 	if !opts.NoCache {
-		_ = WriteCache(info, opts.Instructions, provider.Name(), provider.Model(), advice)
+		_ = WriteCache(info, opts, provider, advice)
+		_, _ = PruneCache(opts.CacheLimits())
 	}
 
 	return advice, nil
@@ -66,7 +152,7 @@ type BasicAdviceFunc func(*analyzer.RepoInfo) []string
 // GetMultiRepoLLMAdvice returns LLM-powered advice for multiple repos
 // In default mode, sends all repos together for combined analysis
 // With PerRepo=true, analyzes each repo individually
-func GetMultiRepoLLMAdvice(repos []*analyzer.RepoInfo, getBasicAdvice BasicAdviceFunc, opts Options) (summary []string, perRepo map[string][]string, err error) {
+func GetMultiRepoLLMAdvice(repos []*analyzer.RepoInfo, getBasicAdvice BasicAdviceFunc, opts Options) (summary []Advice, perRepo map[string][]Advice, err error) {
 	// Build basic advice map
 	basicAdvicePerRepo := make(map[string][]string)
 	for _, repo := range repos {
@@ -74,44 +160,119 @@ func GetMultiRepoLLMAdvice(repos []*analyzer.RepoInfo, getBasicAdvice BasicAdvic
 	}
 
 	if opts.PerRepo {
-		// Per-repo mode: analyze each individually
-		perRepoAdvice := make(map[string][]string)
-		for _, repo := range repos {
-			advice, err := GetLLMAdvice(repo, basicAdvicePerRepo[repo.Name], opts)
-			if err != nil {
-				// Continue on error, just skip this repo
-				continue
-			}
-			perRepoAdvice[repo.Name] = advice
-		}
-		return nil, perRepoAdvice, nil
+		perRepoAdvice, err := getPerRepoAdvice(repos, basicAdvicePerRepo, opts)
+		return nil, perRepoAdvice, err
 	}
 
 	// Combined mode: send all repos together
 	if !opts.NoCache {
-		if cached, err := ReadMultiCache(repos, opts.Instructions); err == nil {
+		if cached, err := ReadMultiCache(repos, opts); err == nil && !cached.Expired(opts.CacheTTL) {
 			return cached.Advice, nil, nil
 		}
 	}
 
-	provider, err := NewProvider(opts.Provider)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	prompt := FormatMultiRepoPrompt(repos, basicAdvicePerRepo, opts.Instructions)
+	prompts := multiRepoPrompts(repos, basicAdvicePerRepo, opts)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	// Each chunk and the merge that follows get the time of a single prompt
+	calls := len(prompts)
+	if calls > 1 {
+		calls++
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(calls)*60*time.Second)
 	defer cancel()
 
-	advice, err := provider.GenerateAdvice(ctx, prompt)
+	var advice []Advice
+	var provider Provider
+	if len(prompts) == 1 {
+		advice, provider, err = generateWithFallback(ctx, prompts[0], opts)
+	} else {
+		advice, provider, err = generateChunked(ctx, prompts, opts)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
 
 	if !opts.NoCache {
-		_ = WriteMultiCache(repos, opts.Instructions, provider.Name(), provider.Model(), advice)
+		_ = WriteMultiCache(repos, opts, provider, advice)
+		_, _ = PruneCache(opts.CacheLimits())
 	}
 
 	return advice, nil, nil
 }
+
+// DefaultConcurrency is how many per-repo LLM calls run at once by default
+const DefaultConcurrency = 4
+
+// RepoErrors holds the repos whose advice failed in per-repo mode, by name.
+// The other repos still got their advice.
+type RepoErrors map[string]error
+
+func (e RepoErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("LLM advice failed for %d repo(s): %s", len(e), strings.Join(names, ", "))
+}
+
+// getPerRepoAdvice asks about each repo on its own, a few at a time and no
+// faster than the provider's rate limit. Failures are returned as RepoErrors.
+func getPerRepoAdvice(repos []*analyzer.RepoInfo, basicAdvicePerRepo map[string][]string, opts Options) (map[string][]Advice, error) {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	// Callbacks are serialized so callers don't need to lock
+	var mu sync.Mutex
+	repoOpts := opts
+	repoOpts.Stream = nil // Items from different repos would interleave
+	repoOpts.Progress = nil
+	repoOpts.limiters = newRateLimiters(opts)
+	if onUsage := opts.OnUsage; onUsage != nil {
+		repoOpts.OnUsage = func(u Usage) {
+			mu.Lock()
+			defer mu.Unlock()
+			onUsage(u)
+		}
+	}
+
+	perRepoAdvice := make(map[string][]Advice)
+	failed := RepoErrors{}
+	done := 0
+	if opts.Progress != nil {
+		opts.Progress(0, len(repos))
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, repo := range repos {
+		wg.Add(1)
+		go func(repo *analyzer.RepoInfo) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			advice, err := GetLLMAdvice(repo, basicAdvicePerRepo[repo.Name], repoOpts)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[repo.Name] = err
+			} else {
+				perRepoAdvice[repo.Name] = advice
+			}
+			done++
+			if opts.Progress != nil {
+				opts.Progress(done, len(repos))
+			}
+		}(repo)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return perRepoAdvice, failed
+	}
+	return perRepoAdvice, nil
+}
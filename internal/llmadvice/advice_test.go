@@ -2,12 +2,20 @@ package llmadvice
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
 
 	"github.com/jdevera/git-this-bread/internal/analyzer"
 )
@@ -16,7 +24,7 @@ import (
 type mockProvider struct {
 	name   string
 	model  string
-	advice []string
+	advice []Advice
 	err    error
 	called bool
 	prompt string
@@ -33,7 +41,7 @@ func (m *mockProvider) Model() string {
 	return m.model
 }
 
-func (m *mockProvider) GenerateAdvice(ctx context.Context, prompt string) ([]string, error) {
+func (m *mockProvider) GenerateAdvice(ctx context.Context, prompt string) ([]Advice, error) {
 	m.called = true
 	m.prompt = prompt
 	if m.err != nil {
@@ -65,21 +73,29 @@ func TestComputeStateHash(t *testing.T) {
 	}
 
 	// Same state should produce same hash
-	hash1 := computeStateHash(info1, "")
-	hash2 := computeStateHash(info2, "")
+	hash1 := computeStateHash(info1, Options{})
+	hash2 := computeStateHash(info2, Options{})
 	assert.Equal(t, hash1, hash2, "Same state should produce same hash")
 
 	// Different state should produce different hash
-	hash3 := computeStateHash(info3, "")
+	hash3 := computeStateHash(info3, Options{})
 	assert.NotEqual(t, hash1, hash3, "Different state should produce different hash")
 
 	// Hash should be deterministic
-	hash1Again := computeStateHash(info1, "")
+	hash1Again := computeStateHash(info1, Options{})
 	assert.Equal(t, hash1, hash1Again, "Hash should be deterministic")
 
 	// Different instructions should produce different hash
-	hash1WithInstructions := computeStateHash(info1, "be Eeyore")
+	hash1WithInstructions := computeStateHash(info1, Options{Instructions: "be Eeyore"})
 	assert.NotEqual(t, hash1, hash1WithInstructions, "Different instructions should produce different hash")
+
+	// Different model should produce different hash
+	hash1WithModel := computeStateHash(info1, Options{Model: "gpt-4o"})
+	assert.NotEqual(t, hash1, hash1WithModel, "Different model should produce different hash")
+
+	// Redacted prompts should produce different hash
+	hash1Redacted := computeStateHash(info1, Options{Redact: true})
+	assert.NotEqual(t, hash1, hash1Redacted, "Redaction should produce different hash")
 }
 
 func TestComputeStateHashWithDirtyDetails(t *testing.T) {
@@ -113,9 +129,9 @@ func TestComputeStateHashWithDirtyDetails(t *testing.T) {
 		},
 	}
 
-	hash1 := computeStateHash(info1, "")
-	hash2 := computeStateHash(info2, "")
-	hash3 := computeStateHash(info3, "")
+	hash1 := computeStateHash(info1, Options{})
+	hash2 := computeStateHash(info2, Options{})
+	hash3 := computeStateHash(info3, Options{})
 
 	assert.Equal(t, hash1, hash2)
 	assert.NotEqual(t, hash1, hash3)
@@ -132,15 +148,18 @@ func TestCacheReadWrite(t *testing.T) {
 		Ahead:         1,
 	}
 
-	advice := []string{"Push your changes", "Review stashes"}
-	instructions := ""
+	advice := []Advice{
+		{Text: "Push your changes", Severity: SeverityWarning, Command: "git push"},
+		{Text: "Review stashes", Severity: SeverityInfo},
+	}
+	opts := Options{Model: "gpt-4o-mini"}
 
 	// Write to cache
-	err := WriteCache(info, instructions, "openai", "gpt-4o-mini", advice)
+	err := WriteCache(info, opts, &mockProvider{name: "openai", model: "gpt-4o-mini"}, advice)
 	require.NoError(t, err)
 
 	// Read from cache
-	entry, err := ReadCache(info, instructions)
+	entry, err := ReadCache(info, opts)
 	require.NoError(t, err)
 	assert.Equal(t, "openai", entry.Provider)
 	assert.Equal(t, "gpt-4o-mini", entry.Model)
@@ -148,12 +167,16 @@ func TestCacheReadWrite(t *testing.T) {
 
 	// Change repo state - should not find cache
 	info.Ahead = 2
-	_, err = ReadCache(info, instructions)
+	_, err = ReadCache(info, opts)
 	assert.Error(t, err)
 
 	// Different instructions should not find cache
 	info.Ahead = 1 // Reset
-	_, err = ReadCache(info, "be Eeyore")
+	_, err = ReadCache(info, Options{Model: "gpt-4o-mini", Instructions: "be Eeyore"})
+	assert.Error(t, err)
+
+	// Different model should not find cache
+	_, err = ReadCache(info, Options{Model: "gpt-4o"})
 	assert.Error(t, err)
 }
 
@@ -224,11 +247,79 @@ func TestParseAdviceResponse(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			result := parseAdviceResponse(tt.response)
-			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.expected, Texts(result))
+			for _, a := range result {
+				assert.Equal(t, SeverityInfo, a.Severity, "plain lines are info")
+			}
+		})
+	}
+}
+
+func TestParseAdviceResponse_JSON(t *testing.T) {
+	push := Advice{Text: "Push 2 commits", Severity: SeverityWarning, Command: "git push"}
+	tests := []struct {
+		name     string
+		response string
+		expected []Advice
+	}{
+		{
+			name: "object, one item per line",
+			response: `{"advice": [
+{"text": "Push 2 commits", "severity": "warning", "command": "git push"},
+{"text": "Finish the rebase", "severity": "critical", "command": ""}
+]}`,
+			expected: []Advice{push, {Text: "Finish the rebase", Severity: SeverityCritical}},
+		},
+		{
+			name:     "bare array in a code fence",
+			response: "```json\n[{\"text\": \"Push 2 commits\", \"severity\": \"warning\", \"command\": \"git push\"}]\n```",
+			expected: []Advice{push},
+		},
+		{
+			name:     "prose around the JSON",
+			response: `Here you go: {"advice": [{"text": "Push 2 commits", "severity": "WARNING", "command": " git push "}]} Hope it helps!`,
+			expected: []Advice{push},
+		},
+		{
+			name:     "unknown severity and missing text",
+			response: `{"advice": [{"text": "Tidy up", "severity": "meh"}, {"severity": "warning"}]}`,
+			expected: []Advice{{Text: "Tidy up", Severity: SeverityInfo}},
+		},
+		{
+			name:     "array of strings",
+			response: `["Push 2 commits", "Tidy up"]`,
+			expected: []Advice{{Text: "Push 2 commits", Severity: SeverityInfo}, {Text: "Tidy up", Severity: SeverityInfo}},
+		},
+		{
+			name:     "empty advice",
+			response: `{"advice": []}`,
+			expected: []Advice{},
+		},
+		{
+			name: "broken JSON falls back to lines",
+			response: `{"advice": [
+{"text": "Push 2 commits", "severity": "warning", "command": "git push"},
+{"text": "Tidy up", "sever`,
+			expected: []Advice{push},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseAdviceResponse(tt.response))
 		})
 	}
 }
 
+func TestAdviceUnmarshalJSON(t *testing.T) {
+	var entry CacheEntry
+	require.NoError(t, json.Unmarshal([]byte(`{"advice": ["Push your work", {"text": "Run gc", "severity": "info", "command": "git gc"}]}`), &entry))
+	assert.Equal(t, []Advice{
+		{Text: "Push your work", Severity: SeverityInfo},
+		{Text: "Run gc", Severity: SeverityInfo, Command: "git gc"},
+	}, entry.Advice, "advice cached as plain strings still reads")
+}
+
 func TestFormatSingleRepoPrompt(t *testing.T) {
 	info := &analyzer.RepoInfo{
 		Name:                  "my-project",
@@ -250,7 +341,7 @@ func TestFormatSingleRepoPrompt(t *testing.T) {
 	}
 
 	basicAdvice := []string{"Push your commits", "Review stashes"}
-	prompt := FormatSingleRepoPrompt(info, basicAdvice, "")
+	prompt := FormatSingleRepoPrompt(info, basicAdvice, Options{})
 
 	// Check that key information is included
 	assert.Contains(t, prompt, "my-project")
@@ -265,6 +356,53 @@ func TestFormatSingleRepoPrompt(t *testing.T) {
 	assert.Contains(t, prompt, "Push your commits")
 }
 
+func TestFormatSingleRepoPrompt_Redacted(t *testing.T) {
+	info := &analyzer.RepoInfo{
+		Name:          "acme-secret-project",
+		CurrentBranch: "client/acme-migration",
+		DefaultBranch: "main",
+		IsFork:        true,
+		UpstreamURL:   "git@github.com:acme/secret.git",
+		Ahead:         2,
+		RecentCommits: []analyzer.CommitInfo{
+			{Hash: "abc1234", Message: "Add ACME credentials loader", Date: "2025-02-01"},
+		},
+		HasUncommittedChanges: true,
+		DirtyDetails: &analyzer.DirtyDetails{
+			UnstagedFiles: 1,
+			UnstagedNames: []string{"config/acme.yaml"},
+		},
+		StashCount: 1,
+		Stashes: []analyzer.StashInfo{
+			{Index: 0, Message: "WIP acme hotfix", Branch: "acme-hotfix", Date: "3 days ago"},
+		},
+		BranchesWithCommits: []analyzer.BranchInfo{
+			{Name: "acme-hotfix", CommitCount: 4, LastCommitDate: "2025-01-20"},
+		},
+		Operation: &analyzer.OperationDetails{ConflictFiles: []string{"src/acme.go"}},
+	}
+
+	basic := []string{"Push your 2 unpushed commit(s)", "Remote acme-mirror is unreachable - update its URL or remove it"}
+	prompt := FormatSingleRepoPrompt(info, basic, Options{Redact: true})
+
+	assert.NotContains(t, strings.ToLower(prompt), "acme")
+	assert.NotContains(t, prompt, "github.com")
+	assert.NotContains(t, prompt, "abc1234")
+	assert.Contains(t, prompt, "names, paths, URLs and messages are redacted")
+	assert.Contains(t, prompt, "(feature branch)", "branch kind survives")
+	assert.Contains(t, prompt, "Unpushed Commits: 2")
+	assert.Contains(t, prompt, "Modified (1 files")
+	assert.Contains(t, prompt, "stash@{0}: (3 days ago, 0 files)")
+	assert.Contains(t, prompt, "branch 1: 4 commits, last 2025-01-20")
+	assert.Contains(t, prompt, "Conflicted Files: 1 (names redacted)")
+	assert.Contains(t, prompt, "Basic Advice (from algorithm): 2 item(s), text redacted", "advice names remotes and branches")
+
+	multi := FormatMultiRepoPrompt([]*analyzer.RepoInfo{info}, map[string][]string{info.Name: basic}, Options{Redact: true})
+	assert.NotContains(t, strings.ToLower(multi), "acme")
+	assert.Contains(t, multi, "Basic Advice: 2 item(s), text redacted")
+	assert.Contains(t, multi, "--- Repository 1 ---")
+}
+
 func TestFormatMultiRepoPrompt(t *testing.T) {
 	repos := []*analyzer.RepoInfo{
 		{
@@ -288,7 +426,7 @@ func TestFormatMultiRepoPrompt(t *testing.T) {
 		"repo1": {"Push your commits"},
 		"repo2": {"Review stashes"},
 	}
-	prompt := FormatMultiRepoPrompt(repos, basicAdvice, "")
+	prompt := FormatMultiRepoPrompt(repos, basicAdvice, Options{})
 
 	assert.Contains(t, prompt, "Multiple Repository States")
 	assert.Contains(t, prompt, "Repository 1: repo1")
@@ -310,4 +448,367 @@ func TestDefaultOptions(t *testing.T) {
 func TestProviderType(t *testing.T) {
 	assert.Equal(t, ProviderType("openai"), ProviderOpenAI)
 	assert.Equal(t, ProviderType("anthropic"), ProviderAnthropic)
+	assert.Equal(t, ProviderType("ollama"), ProviderOllama)
+}
+
+func TestNewProvider_Ollama(t *testing.T) {
+	t.Setenv("OLLAMA_HOST", "127.0.0.1:11500")
+	t.Setenv("OLLAMA_MODEL", "")
+
+	assert.Equal(t, "http://127.0.0.1:11500", ollamaHostURL())
+
+	provider, err := NewProvider(Options{Provider: ProviderOllama})
+	require.NoError(t, err, "no API key needed")
+	assert.Equal(t, "ollama", provider.Name())
+	assert.Equal(t, ollamaModel, provider.Model())
+
+	t.Setenv("OLLAMA_HOST", "https://ollama.internal")
+	t.Setenv("OLLAMA_MODEL", "qwen2.5")
+	assert.Equal(t, "https://ollama.internal", ollamaHostURL())
+	provider, err = NewProvider(Options{Provider: ProviderOllama})
+	require.NoError(t, err)
+	assert.Equal(t, "qwen2.5", provider.Model())
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := ParseHeaders([]string{"X-Team: tools", "Authorization:Bearer abc"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"X-Team": "tools", "Authorization": "Bearer abc"}, headers)
+
+	headers, err = ParseHeaders(nil)
+	require.NoError(t, err)
+	assert.Nil(t, headers)
+
+	_, err = ParseHeaders([]string{"no-colon"})
+	assert.Error(t, err)
+	_, err = ParseHeaders([]string{": value"})
+	assert.Error(t, err)
+}
+
+func TestNewProvider_OpenAICompatible(t *testing.T) {
+	var gotHeader, gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Team")
+		gotPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"- Push your work"}}]}`)
+	}))
+	defer server.Close()
+
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_BASE_URL", "")
+	t.Setenv("OPENAI_MODEL", "local-model")
+
+	_, err := NewProvider(Options{Provider: ProviderOpenAI})
+	assert.ErrorIs(t, err, ErrNoAPIKey, "api.openai.com still needs a key")
+
+	provider, err := NewProvider(Options{
+		Provider: ProviderOpenAI,
+		BaseURL:  server.URL + "/v1",
+		Headers:  map[string]string{"X-Team": "tools"},
+	})
+	require.NoError(t, err, "self-hosted endpoints work without a key")
+	assert.Equal(t, "local-model", provider.Model())
+
+	advice, err := provider.GenerateAdvice(context.Background(), "prompt")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Push your work"}, Texts(advice))
+	assert.Equal(t, "tools", gotHeader)
+	assert.Equal(t, "/v1/chat/completions", gotPath)
+}
+
+func TestNewProvider_Azure(t *testing.T) {
+	var gotKey, gotPath, gotVersion string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("api-key")
+		gotPath = r.URL.Path
+		gotVersion = r.URL.Query().Get("api-version")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"- Push your work"}}]}`)
+	}))
+	defer server.Close()
+
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_BASE_URL", "")
+	t.Setenv("OPENAI_MODEL", "")
+	opts := Options{Provider: ProviderOpenAI, BaseURL: server.URL, Deployment: "advice-4o", APIVersion: "2024-10-21"}
+
+	_, err := NewProvider(opts)
+	assert.ErrorIs(t, err, ErrNoAPIKey, "Azure needs a key")
+
+	t.Setenv("OPENAI_API_KEY", "azure-key")
+	_, err = NewProvider(Options{Provider: ProviderOpenAI, Deployment: "advice-4o"})
+	assert.ErrorContains(t, err, "needs base_url")
+
+	provider, err := NewProvider(opts)
+	require.NoError(t, err)
+	advice, err := provider.GenerateAdvice(context.Background(), "prompt")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Push your work"}, Texts(advice))
+	assert.Equal(t, "azure-key", gotKey)
+	assert.Equal(t, "/openai/deployments/advice-4o/chat/completions", gotPath)
+	assert.Equal(t, "2024-10-21", gotVersion)
+}
+
+func TestResolveModel(t *testing.T) {
+	t.Setenv("ANTHROPIC_MODEL", "")
+	assert.Equal(t, anthropicModel, ResolveModel(Options{Provider: ProviderAnthropic}))
+
+	t.Setenv("ANTHROPIC_MODEL", "claude-sonnet-4-5")
+	assert.Equal(t, "claude-sonnet-4-5", ResolveModel(Options{Provider: ProviderAnthropic}))
+	assert.Equal(t, "claude-opus-4-1", ResolveModel(Options{Provider: ProviderAnthropic, Model: "claude-opus-4-1"}))
+}
+
+func TestOptionsValidate(t *testing.T) {
+	temp := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{"defaults", Options{Provider: ProviderOpenAI}, false},
+		{"openai high temperature", Options{Provider: ProviderOpenAI, Temperature: temp(1.5)}, false},
+		{"anthropic high temperature", Options{Provider: ProviderAnthropic, Temperature: temp(1.5)}, true},
+		{"negative temperature", Options{Provider: ProviderOllama, Temperature: temp(-0.1)}, true},
+		{"zero temperature", Options{Provider: ProviderAnthropic, Temperature: temp(0)}, false},
+		{"negative max tokens", Options{Provider: ProviderOpenAI, MaxTokens: -1}, true},
+		{"model with spaces", Options{Provider: ProviderOpenAI, Model: "gpt 4"}, true},
+		{"unknown provider", Options{Provider: "gemini"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewGeneration(t *testing.T) {
+	g := newGeneration(Options{})
+	assert.InDelta(t, defaultTemperature, g.temperature, 0)
+	assert.Equal(t, defaultMaxTokens, g.maxTokens)
+
+	zero := 0.0
+	g = newGeneration(Options{Temperature: &zero, MaxTokens: 1000})
+	assert.InDelta(t, 0, g.temperature, 0)
+	assert.Equal(t, 1000, g.maxTokens)
+}
+
+func TestPruneCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	// Three entries, last used a, b, c from oldest to newest
+	base := time.Now().Add(-time.Hour)
+	for i, name := range []string{"a", "b", "c"} {
+		info := &analyzer.RepoInfo{Path: "/repos/" + name}
+		require.NoError(t, WriteCache(info, Options{Model: "gpt-4o-mini"}, &mockProvider{name: "openai", model: "gpt-4o-mini"}, []Advice{{Text: "advice"}}))
+		path, err := getCacheFilePath(computeStateHash(info, Options{Model: "gpt-4o-mini"}))
+		require.NoError(t, err)
+		used := base.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, os.Chtimes(path, used, used))
+	}
+
+	files, err := ListCache()
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	assert.Equal(t, []string{"/repos/c"}, files[0].Repos, "most recently used first")
+
+	// Reading an entry makes it the most recently used
+	_, err = ReadCache(&analyzer.RepoInfo{Path: "/repos/a"}, Options{Model: "gpt-4o-mini"})
+	require.NoError(t, err)
+
+	removed, err := PruneCache(CacheLimits{MaxEntries: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	files, err = ListCache()
+	require.NoError(t, err)
+	var repos []string
+	for _, f := range files {
+		repos = append(repos, f.Repos...)
+	}
+	assert.Equal(t, []string{"/repos/a", "/repos/c"}, repos, "least recently used evicted")
+
+	removed, err = PruneCache(CacheLimits{MaxBytes: files[0].Size})
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	removed, err = ClearCache()
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+}
+
+func TestPruneCache_TTL(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	require.NoError(t, writeCacheByHash("old", []string{"/repos/old"}, "openai", "m", nil))
+	require.NoError(t, writeCacheByHash("new", []string{"/repos/new"}, "openai", "m", nil))
+
+	// Backdate the old entry's creation time
+	path, err := getCacheFilePath("old")
+	require.NoError(t, err)
+	entry, err := readCacheByHash("old")
+	require.NoError(t, err)
+	entry.CreatedAt = time.Now().Add(-48 * time.Hour)
+	data, err := json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	removed, err := PruneCache(CacheLimits{TTL: 24 * time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	_, err = readCacheByHash("new")
+	assert.NoError(t, err)
+}
+
+func TestListCache_Empty(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	files, err := ListCache()
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestOptionsCacheLimits(t *testing.T) {
+	limits := Options{}.CacheLimits()
+	assert.Equal(t, DefaultCacheMaxEntries, limits.MaxEntries)
+	assert.Equal(t, DefaultCacheMaxBytes, limits.MaxBytes)
+
+	limits = Options{CacheTTL: time.Hour, CacheMax: 5, CacheMaxSize: 100}.CacheLimits()
+	assert.Equal(t, CacheLimits{TTL: time.Hour, MaxEntries: 5, MaxBytes: 100}, limits)
+}
+
+// chunkedModel is an llms.Model that streams a canned response in chunks
+type chunkedModel struct {
+	chunks []string
+}
+
+func (m *chunkedModel) GenerateContent(ctx context.Context, _ []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	var opts llms.CallOptions
+	for _, o := range options {
+		o(&opts)
+	}
+	var full strings.Builder
+	for _, c := range m.chunks {
+		full.WriteString(c)
+		if opts.StreamingFunc != nil {
+			if err := opts.StreamingFunc(ctx, []byte(c)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: full.String()}}}, nil
+}
+
+func (m *chunkedModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func TestGenerationStream(t *testing.T) {
+	model := &chunkedModel{chunks: []string{"1. Push your", " work\n", "\n- Drop the ", "stale stash\n2) Run", " git gc"}}
+	g := newGeneration(Options{})
+
+	var lines []string
+	advice, err := g.stream(context.Background(), model, "prompt", func(a Advice) {
+		lines = append(lines, a.Text)
+	})
+	require.NoError(t, err)
+
+	expected := []string{"Push your work", "Drop the stale stash", "Run git gc"}
+	assert.Equal(t, expected, lines, "each line is emitted once complete")
+	assert.Equal(t, expected, Texts(advice))
+}
+
+func TestGenerationStream_JSON(t *testing.T) {
+	model := &chunkedModel{chunks: []string{
+		`{"advice": [` + "\n",
+		`{"text": "Push 2 commits", "severity": "warn`, `ing", "command": "git push"},` + "\n",
+		`{` + "\n" + `"text": "Spread over lines", "severity": "info"` + "\n" + `},` + "\n",
+		`{"text": "Finish the rebase", "severity": "critical", "command": ""}]}`,
+	}}
+	g := newGeneration(Options{})
+
+	var streamed []Advice
+	advice, err := g.stream(context.Background(), model, "prompt", func(a Advice) {
+		streamed = append(streamed, a)
+	})
+	require.NoError(t, err)
+
+	push := Advice{Text: "Push 2 commits", Severity: SeverityWarning, Command: "git push"}
+	spread := Advice{Text: "Spread over lines", Severity: SeverityInfo}
+	rebase := Advice{Text: "Finish the rebase", Severity: SeverityCritical}
+	assert.Equal(t, []Advice{push, rebase, spread}, streamed,
+		"complete lines stream as they arrive, the rest once the reply is parsed")
+	assert.Equal(t, []Advice{push, spread, rebase}, advice)
+}
+
+func TestGetLLMAdvice_CachedAdviceIsNotStreamed(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("OPENAI_MODEL", "")
+
+	info := &analyzer.RepoInfo{Path: "/repos/a"}
+	require.NoError(t, WriteCache(info, Options{Provider: ProviderOpenAI}, &mockProvider{name: "openai", model: openAIModel}, []Advice{{Text: "cached"}}))
+
+	streamed := 0
+	advice, err := GetLLMAdvice(info, nil, Options{Provider: ProviderOpenAI, Stream: func(Advice) { streamed++ }})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cached"}, Texts(advice))
+	assert.Zero(t, streamed)
+}
+
+func TestGetMultiRepoLLMAdvice_PerRepo(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("OLLAMA_MODEL", "")
+
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "broken") {
+			http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+			return
+		}
+		_, _ = io.WriteString(w, `{"message":{"role":"assistant","content":"- Push your work"},"done":true}`+"\n")
+	}))
+	defer server.Close()
+
+	var repos []*analyzer.RepoInfo
+	for _, name := range []string{"a", "b", "broken", "c", "d", "e"} {
+		repos = append(repos, &analyzer.RepoInfo{Name: name, Path: "/repos/" + name, IsGitRepo: true})
+	}
+
+	var progress []int
+	opts := Options{
+		Provider:    ProviderOllama,
+		BaseURL:     server.URL,
+		PerRepo:     true,
+		Concurrency: 2,
+		Progress:    func(done, _ int) { progress = append(progress, done) },
+	}
+	summary, perRepo, err := GetMultiRepoLLMAdvice(repos, func(*analyzer.RepoInfo) []string { return nil }, opts)
+
+	var repoErrs RepoErrors
+	require.ErrorAs(t, err, &repoErrs)
+	assert.Len(t, repoErrs, 1)
+	assert.Contains(t, repoErrs, "broken")
+	assert.Nil(t, summary)
+	assert.Len(t, perRepo, 5, "the other repos still get advice")
+	assert.Equal(t, []string{"Push your work"}, Texts(perRepo["a"]))
+	assert.LessOrEqual(t, int(maxInFlight.Load()), 2)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6}, progress)
 }
@@ -3,9 +3,12 @@ package llmadvice
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/anthropic"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
 )
 
 const (
@@ -14,22 +17,29 @@ const (
 
 // AnthropicProvider implements the Provider interface for Anthropic
 type AnthropicProvider struct {
+	generation
 	llm   llms.Model
 	model string
 }
 
-// NewAnthropicProvider creates a new Anthropic provider
-func NewAnthropicProvider(apiKey string) (*AnthropicProvider, error) {
+// NewAnthropicProvider creates a new Anthropic provider.
+// An empty model falls back to anthropicModel.
+func NewAnthropicProvider(apiKey, model string) (*AnthropicProvider, error) {
+	if model == "" {
+		model = anthropicModel
+	}
 	llm, err := anthropic.New(
 		anthropic.WithToken(apiKey),
-		anthropic.WithModel(anthropicModel),
+		anthropic.WithModel(model),
+		anthropic.WithHTTPClient(&http.Client{Transport: debuglog.Transport(http.DefaultTransport)}),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Anthropic client: %w", err)
 	}
 	return &AnthropicProvider{
-		llm:   llm,
-		model: anthropicModel,
+		generation: generation{temperature: defaultTemperature, maxTokens: defaultMaxTokens},
+		llm:        llm,
+		model:      model,
 	}, nil
 }
 
@@ -41,14 +51,14 @@ func (p *AnthropicProvider) Model() string {
 	return p.model
 }
 
-func (p *AnthropicProvider) GenerateAdvice(ctx context.Context, prompt string) ([]string, error) {
-	response, err := llms.GenerateFromSinglePrompt(ctx, p.llm, prompt,
-		llms.WithTemperature(0.3),
-		llms.WithMaxTokens(500),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrAPIError, err)
-	}
+func (p *AnthropicProvider) GenerateAdvice(ctx context.Context, prompt string) ([]Advice, error) {
+	return p.generate(ctx, p.llm, prompt)
+}
+
+func (p *AnthropicProvider) StreamAdvice(ctx context.Context, prompt string, onAdvice func(Advice)) ([]Advice, error) {
+	return p.stream(ctx, p.llm, prompt, onAdvice)
+}
 
-	return parseAdviceResponse(response), nil
+func (p *AnthropicProvider) Chat(ctx context.Context, history []Message, onText func(string)) (string, error) {
+	return p.chat(ctx, p.llm, history, onText)
 }
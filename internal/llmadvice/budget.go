@@ -0,0 +1,188 @@
+package llmadvice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+)
+
+// approxCharsPerToken is a rough average for English and code. Estimates
+// only need to be close enough to keep a prompt under its budget.
+const approxCharsPerToken = 4
+
+// estimateTokens guesses how many tokens s takes up in a prompt
+func estimateTokens(s string) int {
+	return (len(s) + approxCharsPerToken - 1) / approxCharsPerToken
+}
+
+// contextBudgets are the prompt sizes, in tokens, used when
+// Options.ContextBudget is unset. They leave room for the reply and for
+// estimates that fall short. Ollama's default context window is small
+// whatever the model supports.
+var contextBudgets = map[ProviderType]int{
+	ProviderOpenAI:    100000,
+	ProviderAnthropic: 150000,
+	ProviderOllama:    3000,
+}
+
+// contextBudget returns the largest prompt, in tokens, that every provider
+// in the chain accepts, or 0 for no limit
+func (o Options) contextBudget() int {
+	if o.ContextBudget != 0 {
+		return max(o.ContextBudget, 0)
+	}
+	budget := 0
+	for _, c := range providerChain(o) {
+		if b := contextBudgets[c.Provider]; b > 0 && (budget == 0 || b < budget) {
+			budget = b
+		}
+	}
+	return budget
+}
+
+// multiRepoPrompts describes repos in prompts that fit the context budget.
+// When everything doesn't fit in one prompt, repos with nothing to report
+// are left out first, then file names and other details, and only then are
+// the repos split into several prompts whose advice must be merged.
+func multiRepoPrompts(repos []*analyzer.RepoInfo, basicAdvicePerRepo map[string][]string, opts Options) []string {
+	budget := opts.contextBudget()
+	header := multiRepoHeader(repos, opts)
+	fits := func(sections []string) bool {
+		return budget == 0 || estimateTokens(header+strings.Join(sections, "")) <= budget
+	}
+
+	sections := make([]string, len(repos))
+	for i, info := range repos {
+		sections[i] = formatRepoSection(i, info, basicAdvicePerRepo[info.Name], opts, false)
+	}
+	if fits(sections) {
+		return []string{header + strings.Join(sections, "")}
+	}
+
+	// Repos without advice or local changes add little to a summary
+	var kept []int
+	var omitted []string
+	for i, info := range repos {
+		if len(basicAdvicePerRepo[info.Name]) == 0 && !info.HasUncommittedChanges {
+			if opts.Redact {
+				omitted = append(omitted, fmt.Sprintf("Repository %d", i+1))
+			} else {
+				omitted = append(omitted, info.Name)
+			}
+			continue
+		}
+		kept = append(kept, i)
+	}
+	if len(omitted) > 0 {
+		header += fmt.Sprintf("Left out %d repositories with nothing to report: %s\n\n", len(omitted), strings.Join(omitted, ", "))
+	}
+
+	sections = sections[:0]
+	for _, i := range kept {
+		sections = append(sections, formatRepoSection(i, repos[i], basicAdvicePerRepo[repos[i].Name], opts, false))
+	}
+	if fits(sections) {
+		return []string{header + strings.Join(sections, "")}
+	}
+
+	for j, i := range kept {
+		sections[j] = formatRepoSection(i, repos[i], basicAdvicePerRepo[repos[i].Name], opts, true)
+	}
+	if fits(sections) {
+		return []string{header + strings.Join(sections, "")}
+	}
+
+	// Split into as few prompts as fit; a repo too big on its own still
+	// gets a prompt of its own
+	var prompts []string
+	var chunk []string
+	for _, section := range sections {
+		if len(chunk) > 0 && !fits(append(chunk, section)) {
+			prompts = append(prompts, header+strings.Join(chunk, ""))
+			chunk = nil
+		}
+		chunk = append(chunk, section)
+	}
+	if len(chunk) > 0 {
+		prompts = append(prompts, header+strings.Join(chunk, ""))
+	}
+	return prompts
+}
+
+// withoutDetails returns a copy of info without the lists a prompt can do
+// without: file names, recent commits and stash messages. Counts stay.
+func withoutDetails(info *analyzer.RepoInfo) *analyzer.RepoInfo {
+	brief := *info
+	if d := info.DirtyDetails; d != nil {
+		dd := *d
+		dd.StagedNames, dd.UnstagedNames, dd.UntrackedNames = nil, nil, nil
+		brief.DirtyDetails = &dd
+	}
+	brief.RecentCommits = nil
+	brief.Stashes = nil
+	return &brief
+}
+
+const mergePrompt = `Git advisor for an experienced developer. Be brief.
+
+There were too many repositories for one request, so groups of them were
+analyzed separately. Merge the advice of every group below into one list.
+
+Rules:
+- MAX 5 suggestions total, most urgent first
+- One short sentence each (under 15 words)
+- Combine similar items across groups ("Push unpushed work in a, b and c")
+- Keep severities and commands from the groups; do not invent new facts
+
+Format: a JSON object and nothing else, one advice item per line:
+{"advice": [
+{"text": "Push the 2 commits on feature-x", "severity": "warning", "command": "git push"}
+]}
+`
+
+// formatMergePrompt asks for the advice of several groups of repos to be
+// merged into one summary
+func formatMergePrompt(groups [][]Advice, opts Options) string {
+	var sb strings.Builder
+
+	sb.WriteString(mergePrompt)
+
+	if opts.Instructions != "" {
+		sb.WriteString("\nAdditional instructions: ")
+		sb.WriteString(opts.Instructions)
+		sb.WriteString("\n")
+	}
+
+	for i, advice := range groups {
+		fmt.Fprintf(&sb, "\n--- Group %d ---\n", i+1)
+		for _, a := range advice {
+			fmt.Fprintf(&sb, "- [%s] %s", a.Severity, a.Text)
+			if a.Command != "" {
+				fmt.Fprintf(&sb, " (command: %s)", a.Command)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// generateChunked asks for advice on each prompt, then for a merged summary
+// of all of it. Only the summary is streamed.
+func generateChunked(ctx context.Context, prompts []string, opts Options) ([]Advice, Provider, error) {
+	chunkOpts := opts
+	chunkOpts.Stream = nil
+
+	groups := make([][]Advice, len(prompts))
+	for i, prompt := range prompts {
+		advice, _, err := generateWithFallback(ctx, prompt, chunkOpts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("group %d of %d: %w", i+1, len(prompts), err)
+		}
+		groups[i] = advice
+	}
+
+	return generateWithFallback(ctx, formatMergePrompt(groups, opts), opts)
+}
@@ -0,0 +1,112 @@
+package llmadvice
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, estimateTokens(""))
+	assert.Equal(t, 1, estimateTokens("abc"))
+	assert.Equal(t, 2, estimateTokens("abcdefgh"))
+}
+
+func TestOptionsContextBudget(t *testing.T) {
+	assert.Equal(t, 3000, Options{Provider: ProviderOllama}.contextBudget())
+	assert.Equal(t, 3000, Options{Provider: ProviderOpenAI, Fallback: []ProviderType{ProviderOllama}}.contextBudget(),
+		"the smallest provider in the chain decides")
+	assert.Equal(t, 0, Options{Provider: ProviderStatic}.contextBudget())
+	assert.Equal(t, 500, Options{Provider: ProviderOllama, ContextBudget: 500}.contextBudget())
+	assert.Equal(t, 0, Options{Provider: ProviderOllama, ContextBudget: -1}.contextBudget())
+}
+
+// budgetRepos returns n dirty repos with advice and n clean ones
+func budgetRepos(n int) ([]*analyzer.RepoInfo, map[string][]string) {
+	var repos []*analyzer.RepoInfo
+	basic := make(map[string][]string)
+	for i := range n {
+		dirty := &analyzer.RepoInfo{
+			Name:                  fmt.Sprintf("dirty-%d", i),
+			HasUncommittedChanges: true,
+			DirtyDetails:          &analyzer.DirtyDetails{UnstagedFiles: 1, UnstagedNames: []string{"some/rather/long/path/to/a/file.go"}},
+		}
+		basic[dirty.Name] = []string{"Commit or stash your changes"}
+		repos = append(repos, dirty, &analyzer.RepoInfo{Name: fmt.Sprintf("clean-%d", i)})
+	}
+	return repos, basic
+}
+
+func TestMultiRepoPrompts(t *testing.T) {
+	repos, basic := budgetRepos(10)
+	full := FormatMultiRepoPrompt(repos, basic, Options{})
+	withoutClean := multiRepoPrompts(repos, basic, Options{ContextBudget: estimateTokens(full) - 10})[0]
+
+	t.Run("fits", func(t *testing.T) {
+		prompts := multiRepoPrompts(repos, basic, Options{Provider: ProviderStatic})
+		assert.Equal(t, []string{full}, prompts)
+	})
+
+	t.Run("drops clean repos", func(t *testing.T) {
+		prompts := multiRepoPrompts(repos, basic, Options{ContextBudget: estimateTokens(full) - 10})
+		require.Len(t, prompts, 1)
+		assert.Contains(t, prompts[0], "Left out 10 repositories with nothing to report: clean-0, clean-1")
+		assert.NotContains(t, prompts[0], "--- Repository 2: clean-0 ---")
+		assert.Contains(t, prompts[0], "--- Repository 3: dirty-1 ---", "numbering is kept")
+		assert.Contains(t, prompts[0], "file.go")
+	})
+
+	t.Run("drops file names", func(t *testing.T) {
+		budget := estimateTokens(withoutClean) - 10
+		prompts := multiRepoPrompts(repos, basic, Options{ContextBudget: budget})
+		require.Len(t, prompts, 1)
+		assert.NotContains(t, prompts[0], "file.go")
+		assert.Contains(t, prompts[0], "(names omitted)")
+		assert.LessOrEqual(t, estimateTokens(prompts[0]), budget)
+	})
+
+	t.Run("chunks", func(t *testing.T) {
+		header := multiRepoHeader(repos, Options{})
+		budget := estimateTokens(header) + 150
+		prompts := multiRepoPrompts(repos, basic, Options{ContextBudget: budget})
+		require.Greater(t, len(prompts), 1)
+		all := strings.Join(prompts, "")
+		for i := range 10 {
+			assert.Equal(t, 1, strings.Count(all, fmt.Sprintf(": dirty-%d ---", i)), "each repo is in exactly one chunk")
+		}
+		for _, p := range prompts {
+			assert.True(t, strings.HasPrefix(p, systemPrompt))
+			assert.LessOrEqual(t, estimateTokens(p), budget+50)
+		}
+	})
+}
+
+func TestGetMultiRepoLLMAdvice_Chunked(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	repos, basic := budgetRepos(10)
+	header := multiRepoHeader(repos, Options{})
+	opts := Options{Provider: ProviderStatic, NoCache: true, ContextBudget: estimateTokens(header) + 150}
+
+	summary, _, err := GetMultiRepoLLMAdvice(repos, func(info *analyzer.RepoInfo) []string { return basic[info.Name] }, opts)
+	require.NoError(t, err)
+	require.Len(t, summary, 5, "the merge keeps the summary short")
+	assert.Equal(t, "dirty-0: Commit or stash your changes", summary[0].Text)
+}
+
+func TestFormatMergePrompt(t *testing.T) {
+	prompt := formatMergePrompt([][]Advice{
+		{{Text: "Push a", Severity: SeverityWarning, Command: "git push"}},
+		{{Text: "Drop stash in b", Severity: SeverityInfo}},
+	}, Options{Instructions: "be terse"})
+
+	assert.True(t, strings.HasPrefix(prompt, mergePrompt))
+	assert.Contains(t, prompt, "Additional instructions: be terse")
+	assert.Contains(t, prompt, "--- Group 1 ---\n- [warning] Push a (command: git push)\n")
+	assert.Contains(t, prompt, "--- Group 2 ---\n- [info] Drop stash in b\n")
+}
@@ -4,21 +4,33 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+	"github.com/jdevera/git-this-bread/internal/paths"
 )
 
 // CacheEntry represents a cached LLM advice response
 type CacheEntry struct {
 	StateHash string    `json:"state_hash"`
 	CreatedAt time.Time `json:"created_at"`
-	Provider  string    `json:"provider"`
-	Model     string    `json:"model"`
-	Advice    []string  `json:"advice"`
+	Provider  string    `json:"provider"` // Provider that answered
+	Model     string    `json:"model"`    // Model that answered
+	Advice    []Advice  `json:"advice"`
+	Message   string    `json:"message,omitempty"` // Suggested commit message, instead of advice
+	Repos     []string  `json:"repos,omitempty"`   // Paths of the repos the advice is about
+}
+
+// Expired reports whether the entry is older than ttl. A zero ttl never expires.
+func (e *CacheEntry) Expired(ttl time.Duration) bool {
+	return ttl > 0 && time.Since(e.CreatedAt) > ttl
 }
 
 // CacheKey represents the fields used to compute the cache hash
@@ -27,6 +39,7 @@ type CacheKey struct {
 	CurrentBranch string
 	Ahead         int
 	Behind        int
+	Unpushed      int // Across all local branches
 	StagedFiles   int
 	UnstagedFiles int
 	Untracked     int
@@ -34,32 +47,35 @@ type CacheKey struct {
 	IsFork        bool
 	TotalCommits  int
 	Instructions  string // Custom LLM instructions affect output
+	Model         string // Switching models must not return another model's advice
+	Redacted      bool   // Redacted prompts give less specific advice
+	Template      string // Hash of custom prompt templates, if any
 }
 
 // getCacheDir returns the XDG-compliant cache directory
 func getCacheDir() (string, error) {
-	cacheHome := os.Getenv("XDG_CACHE_HOME")
-	if cacheHome == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return "", err
-		}
-		cacheHome = filepath.Join(home, ".cache")
+	cacheHome, err := paths.CacheHome()
+	if err != nil {
+		return "", err
 	}
 	return filepath.Join(cacheHome, "git-this-bread", "git-explain", "llm-advice"), nil
 }
 
-// computeStateHash computes a hash of the repo state that affects advice
-func computeStateHash(info *analyzer.RepoInfo, instructions string) string {
+// computeStateHash computes a hash of the repo state and the options that affect advice
+func computeStateHash(info *analyzer.RepoInfo, opts Options) string {
 	key := CacheKey{
 		Path:          info.Path,
 		CurrentBranch: info.CurrentBranch,
 		Ahead:         info.Ahead,
 		Behind:        info.Behind,
+		Unpushed:      info.UnpushedCommits,
 		StashCount:    info.StashCount,
 		IsFork:        info.IsFork,
 		TotalCommits:  info.TotalUserCommits,
-		Instructions:  instructions,
+		Instructions:  opts.Instructions,
+		Model:         ResolveModel(opts),
+		Redacted:      opts.Redact,
+		Template:      opts.Templates.Hash(),
 	}
 
 	if info.DirtyDetails != nil {
@@ -74,10 +90,10 @@ func computeStateHash(info *analyzer.RepoInfo, instructions string) string {
 }
 
 // computeMultiRepoStateHash computes a hash for multiple repos
-func computeMultiRepoStateHash(repos []*analyzer.RepoInfo, instructions string) string {
+func computeMultiRepoStateHash(repos []*analyzer.RepoInfo, opts Options) string {
 	var hashes []string
 	for _, repo := range repos {
-		hashes = append(hashes, computeStateHash(repo, instructions))
+		hashes = append(hashes, computeStateHash(repo, opts))
 	}
 	data, _ := json.Marshal(hashes)
 	hash := sha256.Sum256(data)
@@ -94,18 +110,24 @@ func getCacheFilePath(stateHash string) (string, error) {
 }
 
 // ReadCache attempts to read cached advice for the given repo state
-func ReadCache(info *analyzer.RepoInfo, instructions string) (*CacheEntry, error) {
-	stateHash := computeStateHash(info, instructions)
+func ReadCache(info *analyzer.RepoInfo, opts Options) (*CacheEntry, error) {
+	stateHash := computeStateHash(info, opts)
 	return readCacheByHash(stateHash)
 }
 
 // ReadMultiCache attempts to read cached advice for multiple repos
-func ReadMultiCache(repos []*analyzer.RepoInfo, instructions string) (*CacheEntry, error) {
-	stateHash := computeMultiRepoStateHash(repos, instructions)
+func ReadMultiCache(repos []*analyzer.RepoInfo, opts Options) (*CacheEntry, error) {
+	stateHash := computeMultiRepoStateHash(repos, opts)
 	return readCacheByHash(stateHash)
 }
 
 func readCacheByHash(stateHash string) (*CacheEntry, error) {
+	entry, err := readCacheFile(stateHash)
+	debuglog.Cache("llm-advice", stateHash, err == nil)
+	return entry, err
+}
+
+func readCacheFile(stateHash string) (*CacheEntry, error) {
 	cachePath, err := getCacheFilePath(stateHash)
 	if err != nil {
 		return nil, err
@@ -126,22 +148,42 @@ func readCacheByHash(stateHash string) (*CacheEntry, error) {
 		return nil, fmt.Errorf("cache hash mismatch")
 	}
 
+	// The file's mtime tracks last use for LRU eviction
+	now := time.Now()
+	_ = os.Chtimes(cachePath, now, now)
+
 	return &entry, nil
 }
 
-// WriteCache writes advice to the cache
-func WriteCache(info *analyzer.RepoInfo, instructions, provider, model string, advice []string) error {
-	stateHash := computeStateHash(info, instructions)
-	return writeCacheByHash(stateHash, provider, model, advice)
+// WriteCache writes advice to the cache under the requested options, recording
+// which provider and model answered (a fallback may have stepped in)
+func WriteCache(info *analyzer.RepoInfo, opts Options, answeredBy Provider, advice []Advice) error {
+	stateHash := computeStateHash(info, opts)
+	return writeCacheByHash(stateHash, []string{info.Path}, answeredBy.Name(), answeredBy.Model(), advice)
 }
 
 // WriteMultiCache writes advice for multiple repos to the cache
-func WriteMultiCache(repos []*analyzer.RepoInfo, instructions, provider, model string, advice []string) error {
-	stateHash := computeMultiRepoStateHash(repos, instructions)
-	return writeCacheByHash(stateHash, provider, model, advice)
+func WriteMultiCache(repos []*analyzer.RepoInfo, opts Options, answeredBy Provider, advice []Advice) error {
+	stateHash := computeMultiRepoStateHash(repos, opts)
+	paths := make([]string, len(repos))
+	for i, repo := range repos {
+		paths[i] = repo.Path
+	}
+	return writeCacheByHash(stateHash, paths, answeredBy.Name(), answeredBy.Model(), advice)
+}
+
+func writeCacheByHash(stateHash string, repos []string, provider, model string, advice []Advice) error {
+	return writeCacheEntry(CacheEntry{
+		StateHash: stateHash,
+		CreatedAt: time.Now(),
+		Provider:  provider,
+		Model:     model,
+		Advice:    advice,
+		Repos:     repos,
+	})
 }
 
-func writeCacheByHash(stateHash, provider, model string, advice []string) error {
+func writeCacheEntry(entry CacheEntry) error {
 	cacheDir, err := getCacheDir()
 	if err != nil {
 		return err
@@ -152,23 +194,122 @@ func writeCacheByHash(stateHash, provider, model string, advice []string) error
 		return err
 	}
 
-	entry := CacheEntry{
-		StateHash: stateHash,
-		CreatedAt: time.Now(),
-		Provider:  provider,
-		Model:     model,
-		Advice:    advice,
-	}
-
 	data, err := json.MarshalIndent(entry, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	cachePath, err := getCacheFilePath(stateHash)
+	cachePath, err := getCacheFilePath(entry.StateHash)
 	if err != nil {
 		return err
 	}
 
 	return os.WriteFile(cachePath, data, 0o600)
 }
+
+// Default cache limits, used when Options leaves them unset
+const (
+	DefaultCacheMaxEntries       = 1000
+	DefaultCacheMaxBytes   int64 = 10 << 20
+)
+
+// CacheLimits bounds what PruneCache keeps
+type CacheLimits struct {
+	TTL        time.Duration // Drop entries created longer ago than this (0 = no expiry)
+	MaxEntries int           // Keep at most this many entries (0 = unlimited)
+	MaxBytes   int64         // Keep at most this many bytes (0 = unlimited)
+}
+
+// CacheFile describes one stored cache entry
+type CacheFile struct {
+	CacheEntry
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// ListCache returns every cache entry, most recently used first.
+// Unreadable files are listed with an empty entry so they can still be pruned.
+func ListCache() ([]CacheFile, error) {
+	cacheDir, err := getCacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	dirEntries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []CacheFile
+	for _, de := range dirEntries {
+		if de.IsDir() || filepath.Ext(de.Name()) != ".json" {
+			continue
+		}
+		fi, err := de.Info()
+		if err != nil {
+			continue
+		}
+		f := CacheFile{
+			Path:     filepath.Join(cacheDir, de.Name()),
+			Size:     fi.Size(),
+			LastUsed: fi.ModTime(),
+		}
+		if data, err := os.ReadFile(f.Path); err == nil { //nolint:gosec // path comes from listing our own cache dir
+			_ = json.Unmarshal(data, &f.CacheEntry)
+		}
+		files = append(files, f)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].LastUsed.After(files[j].LastUsed)
+	})
+	return files, nil
+}
+
+// ClearCache removes every cache entry and returns how many were removed
+func ClearCache() (int, error) {
+	files, err := ListCache()
+	if err != nil {
+		return 0, err
+	}
+	return removeCacheFiles(files)
+}
+
+// PruneCache removes expired entries, then evicts the least recently used
+// ones until the cache fits the limits. It returns how many were removed.
+func PruneCache(limits CacheLimits) (int, error) {
+	files, err := ListCache()
+	if err != nil {
+		return 0, err
+	}
+
+	var keep, drop []CacheFile
+	var total int64
+	for _, f := range files {
+		expired := limits.TTL > 0 && f.Expired(limits.TTL)
+		overCount := limits.MaxEntries > 0 && len(keep) >= limits.MaxEntries
+		overSize := limits.MaxBytes > 0 && total+f.Size > limits.MaxBytes
+		if expired || overCount || overSize {
+			drop = append(drop, f)
+			continue
+		}
+		keep = append(keep, f)
+		total += f.Size
+	}
+	return removeCacheFiles(drop)
+}
+
+func removeCacheFiles(files []CacheFile) (int, error) {
+	removed := 0
+	for _, f := range files {
+		if err := os.Remove(f.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
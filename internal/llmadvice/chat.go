@@ -0,0 +1,193 @@
+package llmadvice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+)
+
+// Role says who wrote a conversation message
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message is one turn of a conversation
+type Message struct {
+	Role    Role
+	Content string
+}
+
+// defaultMaxTurns bounds how many question/answer pairs are sent back to
+// the model, so a long chat doesn't grow the prompt without limit
+const defaultMaxTurns = 10
+
+// Conversation is the history of a chat: a fixed context describing the
+// repos, followed by alternating user and assistant messages
+type Conversation struct {
+	Context  string
+	Messages []Message
+	MaxTurns int // Question/answer pairs kept in History (0 = defaultMaxTurns)
+}
+
+// Add appends a message to the conversation
+func (c *Conversation) Add(role Role, content string) {
+	c.Messages = append(c.Messages, Message{Role: role, Content: content})
+}
+
+// History returns the messages to send: the context, then the most recent turns
+func (c *Conversation) History() []Message {
+	maxTurns := c.MaxTurns
+	if maxTurns == 0 {
+		maxTurns = defaultMaxTurns
+	}
+	recent := c.Messages
+	if keep := 2*maxTurns + 1; len(recent) > keep {
+		// Odd so the kept window still starts with a question
+		recent = recent[len(recent)-keep:]
+	}
+	return append([]Message{{Role: RoleSystem, Content: c.Context}}, recent...)
+}
+
+// chatRoles maps conversation roles to langchaingo message types
+var chatRoles = map[Role]llms.ChatMessageType{
+	RoleSystem:    llms.ChatMessageTypeSystem,
+	RoleUser:      llms.ChatMessageTypeHuman,
+	RoleAssistant: llms.ChatMessageTypeAI,
+}
+
+// chat sends history to llm and returns the reply, passing streamed pieces
+// to onText when it is set
+func (g generation) chat(ctx context.Context, llm llms.Model, history []Message, onText func(string)) (string, error) {
+	msgs := make([]llms.MessageContent, len(history))
+	for i, m := range history {
+		msgs[i] = llms.TextParts(chatRoles[m.Role], m.Content)
+	}
+
+	var options []llms.CallOption
+	if onText != nil {
+		options = append(options, llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+			onText(string(chunk))
+			return nil
+		}))
+	}
+	return g.completeMessages(ctx, llm, msgs, options...)
+}
+
+// Chat answers follow-up questions about repos whose advice was just shown
+type Chat struct {
+	provider ChatProvider
+	conv     Conversation
+}
+
+// NewChat starts a conversation about repos with the first provider in the
+// chain that can be set up. The advice already shown is read back from the
+// cache so the model can refer to it without being asked again.
+func NewChat(repos []*analyzer.RepoInfo, getBasicAdvice BasicAdviceFunc, opts Options) (*Chat, error) {
+	var provider ChatProvider
+	var errs []error
+	for _, o := range providerChain(opts) {
+		p, err := NewProvider(o)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", o.Provider, err))
+			continue
+		}
+		cp, ok := p.(ChatProvider)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: provider does not support chat", o.Provider))
+			continue
+		}
+		provider = cp
+		break
+	}
+	if provider == nil {
+		return nil, errors.Join(errs...)
+	}
+
+	basicAdvicePerRepo := make(map[string][]string)
+	for _, repo := range repos {
+		basicAdvicePerRepo[repo.Name] = getBasicAdvice(repo)
+	}
+
+	return &Chat{
+		provider: provider,
+		conv: Conversation{
+			Context: FormatChatContext(repos, basicAdvicePerRepo, givenAdvice(repos, opts), opts),
+		},
+	}, nil
+}
+
+// givenAdvice returns the cached LLM advice for repos as it was shown
+func givenAdvice(repos []*analyzer.RepoInfo, opts Options) []string {
+	if len(repos) == 1 {
+		if cached, err := ReadCache(repos[0], opts); err == nil {
+			return Texts(cached.Advice)
+		}
+		return nil
+	}
+	if !opts.PerRepo {
+		if cached, err := ReadMultiCache(repos, opts); err == nil {
+			return Texts(cached.Advice)
+		}
+		return nil
+	}
+
+	var advice []string
+	for i, repo := range repos {
+		cached, err := ReadCache(repo, opts)
+		if err != nil {
+			continue
+		}
+		name := repo.Name
+		if opts.Redact {
+			name = fmt.Sprintf("Repository %d", i+1)
+		}
+		for _, a := range cached.Advice {
+			advice = append(advice, name+": "+a.Text)
+		}
+	}
+	return advice
+}
+
+// Ask sends a question and returns the answer, which becomes part of the
+// history for later questions. onText, when set, receives the answer as it
+// arrives. A failed question is dropped from the history.
+func (c *Chat) Ask(ctx context.Context, question string, onText func(string)) (string, error) {
+	c.conv.Add(RoleUser, question)
+
+	streamed := false
+	if onText != nil {
+		show := onText
+		onText = func(text string) {
+			streamed = true
+			show(text)
+		}
+	}
+
+	var answer string
+	err := withRetry(ctx, func() (bool, error) {
+		var chatErr error
+		answer, chatErr = c.provider.Chat(ctx, c.conv.History(), onText)
+		// Retrying after text was shown would print it twice
+		return !streamed, chatErr
+	})
+	if err != nil {
+		c.conv.Messages = c.conv.Messages[:len(c.conv.Messages)-1]
+		return "", err
+	}
+
+	c.conv.Add(RoleAssistant, answer)
+	return answer, nil
+}
+
+// Conversation returns the chat so far
+func (c *Chat) Conversation() Conversation {
+	return c.conv
+}
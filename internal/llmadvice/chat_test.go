@@ -0,0 +1,108 @@
+package llmadvice
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+)
+
+func TestConversationHistory(t *testing.T) {
+	c := Conversation{Context: "repo state", MaxTurns: 2}
+	for _, q := range []string{"q1", "a1", "q2", "a2", "q3", "a3"} {
+		role := RoleUser
+		if q[0] == 'a' {
+			role = RoleAssistant
+		}
+		c.Add(role, q)
+	}
+	c.Add(RoleUser, "q4")
+
+	history := c.History()
+	require.Len(t, history, 6)
+	assert.Equal(t, Message{Role: RoleSystem, Content: "repo state"}, history[0], "context is always sent")
+	assert.Equal(t, Message{Role: RoleUser, Content: "q2"}, history[1], "oldest turns are dropped whole")
+	assert.Equal(t, Message{Role: RoleUser, Content: "q4"}, history[5])
+}
+
+func TestFormatChatContext(t *testing.T) {
+	repos := []*analyzer.RepoInfo{
+		{Name: "secret-project", Path: "/repos/secret-project", CurrentBranch: "main", Ahead: 2},
+		{Name: "other", Path: "/repos/other"},
+	}
+	basic := map[string][]string{"secret-project": {"Push 2 commits", "Sync with acme-upstream - 3 commit(s) behind upstream"}}
+	given := []string{"Push the 2 unpushed commits"}
+
+	ctx := FormatChatContext(repos, basic, given, Options{})
+	assert.Contains(t, ctx, "--- Repository 1: secret-project ---")
+	assert.Contains(t, ctx, "Unpushed Commits: 2")
+	assert.Contains(t, ctx, "  - Push 2 commits")
+	assert.Contains(t, ctx, "Advice you already gave:\n- Push the 2 unpushed commits")
+
+	redacted := FormatChatContext(repos, basic, given, Options{Redact: true})
+	assert.NotContains(t, redacted, "secret-project")
+	assert.Contains(t, redacted, "--- Repository 1 ---")
+	assert.NotContains(t, redacted, "acme-upstream", "advice names remotes")
+	assert.Contains(t, redacted, "Basic Advice: 2 item(s), text redacted")
+}
+
+func TestChatAsk(t *testing.T) {
+	fastRetries(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("OLLAMA_MODEL", "")
+
+	type chatMessage struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	var requests [][]chatMessage
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "model not found", http.StatusNotFound)
+			return
+		}
+		var req struct {
+			Messages []chatMessage `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req.Messages)
+		_, _ = io.WriteString(w, `{"message":{"role":"assistant","content":"feature-x"},"done":true}`+"\n")
+	}))
+	defer server.Close()
+
+	info := &analyzer.RepoInfo{Name: "a", Path: "/repos/a", CurrentBranch: "main"}
+	opts := Options{Provider: ProviderOllama, BaseURL: server.URL}
+	require.NoError(t, WriteCache(info, opts, &mockProvider{name: "ollama", model: ollamaModel}, []Advice{{Text: "Delete merged branches"}}))
+
+	chat, err := NewChat([]*analyzer.RepoInfo{info}, func(*analyzer.RepoInfo) []string { return nil }, opts)
+	require.NoError(t, err)
+
+	answer, err := chat.Ask(context.Background(), "which branches are safe to delete?", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "feature-x", answer)
+
+	fail = true
+	_, err = chat.Ask(context.Background(), "this one fails", nil)
+	require.Error(t, err)
+	fail = false
+
+	_, err = chat.Ask(context.Background(), "why?", nil)
+	require.NoError(t, err)
+
+	require.Len(t, requests, 2)
+	second := requests[1]
+	require.Len(t, second, 4, "context, first question and answer, new question")
+	assert.Equal(t, "system", second[0].Role)
+	assert.Contains(t, second[0].Content, "Delete merged branches", "advice already shown is part of the context")
+	assert.Equal(t, chatMessage{"user", "which branches are safe to delete?"}, second[1])
+	assert.Equal(t, chatMessage{"assistant", "feature-x"}, second[2])
+	assert.Equal(t, chatMessage{"user", "why?"}, second[3], "the failed question is dropped")
+}
@@ -0,0 +1,112 @@
+package llmadvice
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/jdevera/git-this-bread/internal/paths"
+)
+
+// Config holds user defaults for LLM advice, read from llm.toml or the
+// [llm] section of config.toml.
+// Every field is optional; command-line flags override them.
+type Config struct {
+	Provider     string            `toml:"provider"` // One provider, or a fallback chain like "openai,anthropic"
+	Model        string            `toml:"model"`
+	BaseURL      string            `toml:"base_url"`
+	Deployment   string            `toml:"azure_deployment"`  // Azure OpenAI deployment; base_url is then the resource endpoint
+	APIVersion   string            `toml:"azure_api_version"` // Azure OpenAI api-version (default 2023-05-15)
+	Headers      map[string]string `toml:"headers"`
+	Instructions string            `toml:"instructions"`
+	Redact       bool              `toml:"redact"`
+	Temperature  *float64          `toml:"temperature"`
+	MaxTokens    int               `toml:"max_tokens"`
+	CacheTTL     time.Duration     `toml:"cache_ttl"` // e.g. "72h"; 0 keeps entries until the repo state changes
+	CacheMax     int               `toml:"cache_max_entries"`
+	CacheMaxSize int64             `toml:"cache_max_bytes"`
+	Prompt       string            `toml:"prompt"`              // System prompt template file
+	RepoTemplate string            `toml:"repo_template"`       // Repo state template file
+	Concurrency  int               `toml:"concurrency"`         // Per-repo calls at once
+	RateLimit    int               `toml:"requests_per_minute"` // Negative disables the provider default
+	Budget       int               `toml:"context_budget"`      // Multi-repo prompt size in tokens; negative disables
+
+	templates *Templates // Parsed from Prompt and RepoTemplate
+}
+
+// LoadConfigFile reads an LLM config file, such as llm.toml. A missing file
+// is not an error.
+func LoadConfigFile(path string) (Config, error) {
+	var cfg Config
+	md, err := toml.DecodeFile(path, &cfg)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Config{}, nil
+		}
+		return Config{}, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return Config{}, fmt.Errorf("reading %s: unknown setting %q", path, undecoded[0].String())
+	}
+
+	if err := cfg.ResolveTemplates(filepath.Dir(path)); err != nil {
+		return Config{}, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ResolveTemplates parses the template files named by Prompt and
+// RepoTemplate, relative to dir. Configs loaded from elsewhere than llm.toml
+// need it before Options can use their templates.
+func (c *Config) ResolveTemplates(dir string) error {
+	var err error
+	c.templates, err = LoadTemplates(resolvePath(c.Prompt, dir), resolvePath(c.RepoTemplate, dir))
+	return err
+}
+
+// resolvePath expands a leading ~/ and makes relative paths relative to dir
+func resolvePath(path, dir string) string {
+	if path == "" {
+		return ""
+	}
+	if expanded := paths.Expand(path); expanded != path {
+		return expanded
+	}
+	if !filepath.IsAbs(path) {
+		return filepath.Join(dir, path)
+	}
+	return path
+}
+
+// Options returns advice options with the config applied over the defaults
+func (c Config) Options() Options {
+	opts := DefaultOptions()
+	if c.Provider != "" {
+		opts.Provider, opts.Fallback = ParseProviders(c.Provider)
+	}
+	if p := os.Getenv(ProviderEnv); p != "" {
+		opts.Provider, opts.Fallback = ParseProviders(p)
+	}
+	opts.Model = c.Model
+	opts.BaseURL = c.BaseURL
+	opts.Deployment = c.Deployment
+	opts.APIVersion = c.APIVersion
+	opts.Headers = c.Headers
+	opts.Instructions = c.Instructions
+	opts.Redact = c.Redact
+	opts.Temperature = c.Temperature
+	opts.MaxTokens = c.MaxTokens
+	opts.CacheTTL = c.CacheTTL
+	opts.CacheMax = c.CacheMax
+	opts.CacheMaxSize = c.CacheMaxSize
+	opts.Templates = c.templates
+	opts.Concurrency = c.Concurrency
+	opts.RequestsPerMinute = c.RateLimit
+	opts.ContextBudget = c.Budget
+	return opts
+}
@@ -0,0 +1,67 @@
+package llmadvice
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "llm.toml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := writeConfig(t, `
+provider = "anthropic, ollama"
+model = "claude-sonnet-4-5"
+instructions = "be brief"
+temperature = 0.0
+cache_ttl = "72h"
+
+[headers]
+X-Team = "tools"
+`)
+
+	cfg, err := LoadConfigFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "claude-sonnet-4-5", cfg.Model)
+	assert.Equal(t, 72*time.Hour, cfg.CacheTTL)
+	require.NotNil(t, cfg.Temperature, "explicit zero is kept")
+	assert.InDelta(t, 0, *cfg.Temperature, 0)
+	assert.Equal(t, map[string]string{"X-Team": "tools"}, cfg.Headers)
+
+	opts := cfg.Options()
+	assert.Equal(t, ProviderAnthropic, opts.Provider)
+	assert.Equal(t, []ProviderType{ProviderOllama}, opts.Fallback)
+	assert.Equal(t, "be brief", opts.Instructions)
+	assert.Equal(t, 72*time.Hour, opts.CacheTTL)
+	assert.Zero(t, opts.MaxTokens, "unset fields keep provider defaults")
+}
+
+func TestLoadConfigFile_Missing(t *testing.T) {
+	cfg, err := LoadConfigFile(filepath.Join(t.TempDir(), "llm.toml"))
+	require.NoError(t, err)
+	assert.Equal(t, ProviderOpenAI, cfg.Options().Provider)
+}
+
+func TestLoadConfigFile_Invalid(t *testing.T) {
+	_, err := LoadConfigFile(writeConfig(t, `modle = "typo"`))
+	assert.ErrorContains(t, err, `unknown setting "modle"`)
+
+	_, err = LoadConfigFile(writeConfig(t, `provider = [`))
+	assert.Error(t, err)
+}
+
+func TestCacheEntryExpired(t *testing.T) {
+	entry := &CacheEntry{CreatedAt: time.Now().Add(-2 * time.Hour)}
+	assert.False(t, entry.Expired(0), "no TTL never expires")
+	assert.False(t, entry.Expired(3*time.Hour))
+	assert.True(t, entry.Expired(time.Hour))
+}
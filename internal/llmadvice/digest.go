@@ -0,0 +1,164 @@
+package llmadvice
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jdevera/git-this-bread/internal/history"
+)
+
+const digestPrompt = `Write a short digest of how a developer's git repositories changed over
+the period below, for a journal entry or a standup note.
+
+You receive: for each repository, its metrics at the first and last recorded
+run of the period. Metrics left out were zero throughout.
+
+Rules:
+- Markdown: one summary sentence, then at most 7 bullets
+- Progress first: work committed, pushed or cleared, e.g. "cleared 2 dirty repos"
+- Then what got worse or is still waiting: growing unpushed work, repos
+  falling further behind, stashes that stayed
+- Give numbers and name the repositories; group ones that moved the same way
+- No advice on git commands
+- Output only the digest: no code fences or commentary
+`
+
+var ErrNoHistory = errors.New("no runs recorded in the period; record them with: git explain --record")
+
+// FormatDigestPrompt describes how the metrics of each repo changed over
+// the period of entries, which are history grouped by repo, oldest first
+func FormatDigestPrompt(entries []history.Entry, opts Options) string {
+	var sb strings.Builder
+
+	sb.WriteString(digestPrompt)
+
+	if opts.Instructions != "" {
+		sb.WriteString("\nAdditional instructions: ")
+		sb.WriteString(opts.Instructions)
+		sb.WriteString("\n")
+	}
+	if opts.Redact {
+		sb.WriteString(redactedNote)
+	}
+
+	repos := byRepo(entries)
+	from, to := entries[0].Time, entries[0].Time
+	for _, e := range entries {
+		from, to = minTime(from, e.Time), maxTime(to, e.Time)
+	}
+	fmt.Fprintf(&sb, "\n\nPeriod: %s to %s\n", from.Format(time.DateOnly), to.Format(time.DateOnly))
+	fmt.Fprintf(&sb, "Repositories (%d):\n", len(repos))
+	for i, runs := range repos {
+		if opts.Redact {
+			fmt.Fprintf(&sb, "--- Repo %d ---\n", i+1)
+		} else {
+			fmt.Fprintf(&sb, "--- %s ---\n", runs[0].Name)
+		}
+		first, last := runs[0], runs[len(runs)-1]
+		fmt.Fprintf(&sb, "Runs: %d, %s to %s\n", len(runs), first.Time.Format(time.DateOnly), last.Time.Format(time.DateOnly))
+		for _, c := range history.Trend(runs) {
+			if c.From != 0 || c.To != 0 {
+				fmt.Fprintf(&sb, "  %s: %d → %d\n", c.Metric, c.From, c.To)
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// byRepo splits entries, grouped by repo, into the runs of each repo
+func byRepo(entries []history.Entry) [][]history.Entry {
+	var repos [][]history.Entry
+	for i, e := range entries {
+		if i == 0 || e.Path != entries[i-1].Path {
+			repos = append(repos, nil)
+		}
+		repos[len(repos)-1] = append(repos[len(repos)-1], e)
+	}
+	return repos
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// Digest writes a markdown digest of how the repos of entries, history
+// grouped by repo as history.LoadUnder returns it, changed over their
+// period. Digests are cached by the entries, so asking again before the
+// next recorded run costs nothing.
+func Digest(entries []history.Entry, opts Options) (string, error) {
+	if len(entries) == 0 {
+		return "", ErrNoHistory
+	}
+
+	stateHash := computeDigestHash(entries, opts)
+	if !opts.NoCache {
+		if cached, err := readCacheByHash(stateHash); err == nil && !cached.Expired(opts.CacheTTL) && cached.Message != "" {
+			return cached.Message, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	msgs := []Message{{Role: RoleUser, Content: FormatDigestPrompt(entries, opts)}}
+	reply, provider, err := chatWithFallback(ctx, msgs, opts)
+	if err != nil {
+		return "", err
+	}
+	digest := stripFences(reply)
+	if digest == "" {
+		return "", fmt.Errorf("%w: empty digest", ErrAPIError)
+	}
+
+	if !opts.NoCache {
+		var paths []string
+		for _, runs := range byRepo(entries) {
+			paths = append(paths, runs[0].Path)
+		}
+		_ = writeCacheEntry(CacheEntry{
+			StateHash: stateHash,
+			CreatedAt: time.Now(),
+			Provider:  provider.Name(),
+			Model:     provider.Model(),
+			Message:   digest,
+			Repos:     paths,
+		})
+		_, _ = PruneCache(opts.CacheLimits())
+	}
+
+	return digest, nil
+}
+
+// computeDigestHash identifies a digest by the entries and the options
+// that affect it
+func computeDigestHash(entries []history.Entry, opts Options) string {
+	key := struct {
+		Kind         string
+		Entries      []history.Entry
+		Instructions string
+		Model        string
+		Redacted     bool
+	}{"digest", entries, opts.Instructions, ResolveModel(opts), opts.Redact}
+
+	data, _ := json.Marshal(key)
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
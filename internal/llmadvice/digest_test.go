@@ -0,0 +1,52 @@
+package llmadvice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/history"
+)
+
+func digestEntries() []history.Entry {
+	day := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	return []history.Entry{
+		{Time: day, Path: "/src/api", Name: "api", Dirty: 3, Ahead: 2, UserCommits: 40},
+		{Time: day.AddDate(0, 0, 6), Path: "/src/api", Name: "api", UserCommits: 45},
+		{Time: day.AddDate(0, 0, 1), Path: "/src/web", Name: "web", Behind: 4, Stashes: 1},
+		{Time: day.AddDate(0, 0, 5), Path: "/src/web", Name: "web", Behind: 9, Stashes: 1},
+	}
+}
+
+func TestFormatDigestPrompt(t *testing.T) {
+	prompt := FormatDigestPrompt(digestEntries(), Options{})
+
+	assert.Contains(t, prompt, "Period: 2026-05-04 to 2026-05-10\n")
+	assert.Contains(t, prompt, "--- api ---\nRuns: 2, 2026-05-04 to 2026-05-10\n"+
+		"  uncommitted files: 3 → 0\n  unpushed commits: 2 → 0\n  your commits: 40 → 45\n")
+	assert.Contains(t, prompt, "--- web ---\nRuns: 2, 2026-05-05 to 2026-05-09\n"+
+		"  commits behind: 4 → 9\n  stashes: 1 → 1\n")
+
+	redacted := FormatDigestPrompt(digestEntries(), Options{Redact: true})
+	assert.Contains(t, redacted, "--- Repo 2 ---")
+	assert.NotContains(t, redacted, "web")
+}
+
+func TestDigest_Static(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	_, err := Digest(nil, Options{Provider: ProviderStatic})
+	require.ErrorIs(t, err, ErrNoHistory)
+
+	digest, err := Digest(digestEntries(), Options{Provider: ProviderStatic})
+	require.NoError(t, err)
+	assert.Equal(t, "## Digest\n\n"+
+		"- api: uncommitted files 3 → 0, unpushed commits 2 → 0, your commits 40 → 45\n"+
+		"- web: commits behind 4 → 9", digest)
+
+	cached, err := readCacheByHash(computeDigestHash(digestEntries(), Options{Provider: ProviderStatic}))
+	require.NoError(t, err)
+	assert.Equal(t, digest, cached.Message)
+}
@@ -0,0 +1,182 @@
+package llmadvice
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const forkPrompt = `Fork triage advisor for a developer with many GitHub forks. Be brief.
+
+You receive: every fork with its category, divergence from upstream, branches and PRs.
+Categories: maintained (own commits on the default branch), contribution (branches or
+PRs only), untouched (no changes).
+
+Your job: a prioritized cleanup plan across all forks.
+
+Rules:
+- MAX 7 suggestions total
+- One short sentence each (under 20 words)
+- Group forks that need the same action: "Delete these 12 untouched forks: a, b, ..."
+- Forks whose PRs are all merged or closed and have no other branches can be deleted
+- Maintained forks far behind upstream need a sync or rebase; say how far and how old
+- Open PRs keep a fork alive; mention stale ones
+- Maintained forks whose ahead commits are all already upstream can likely be deleted
+- Adopted forks are kept diverged on purpose: never suggest syncing or deleting them
+- Archived forks are read-only: never suggest syncing them or deleting their branches
+- severity: warning (own work at risk or rotting), info (cleanup)
+- command: one copy-ready gh command only when it clearly applies, else ""
+
+Format: a JSON object and nothing else, one advice item per line:
+{"advice": [
+{"text": "Delete 12 untouched forks: a, b, c", "severity": "info", "command": ""},
+{"text": "Rebase your maintained fork of X, 400 commits and 2 years behind", "severity": "warning", "command": "gh repo sync you/x"}
+]}
+`
+
+// ForkState is what the fork triage prompt knows about one GitHub fork
+type ForkState struct {
+	FullName      string // owner/name of the fork
+	Parent        string // owner/name of the upstream repo
+	Category      string // maintained, contribution or untouched
+	Ahead         int    // Default branch commits not in upstream
+	Behind        int    // Upstream commits not in the default branch
+	LastCommitAgo string // Age of the fork's last commit, e.g. "2 years ago"
+	UpstreamAgo   string // Age of upstream's last commit
+	Upstreamed    int    // Ahead commits with an equivalent patch upstream
+	Adopted       bool   // Kept diverged on purpose (gh-wtfork adopt)
+	Archived      bool   // Read-only on the forge
+	OpenIssues    int    // Issues and discussions you opened upstream, still open
+	ClosedIssues  int    // ...and closed
+	Branches      []ForkBranch
+}
+
+// ForkBranch is a non-default branch of a fork and the PR opened from it
+type ForkBranch struct {
+	Name     string
+	Ago      string // Age of the branch's last commit
+	PRNumber int    // 0 when the branch has no PR
+	PRTitle  string
+	PRState  string // OPEN, MERGED or CLOSED
+}
+
+// FormatForkPrompt describes forks for a triage summary
+func FormatForkPrompt(forks []ForkState, opts Options) string {
+	var sb strings.Builder
+
+	sb.WriteString(forkPrompt)
+
+	if opts.Instructions != "" {
+		sb.WriteString("\nAdditional instructions: ")
+		sb.WriteString(opts.Instructions)
+		sb.WriteString("\n")
+	}
+	if opts.Redact {
+		sb.WriteString(redactedNote)
+	}
+
+	fmt.Fprintf(&sb, "\n\nForks (%d):\n", len(forks))
+	for i, f := range forks {
+		if opts.Redact {
+			fmt.Fprintf(&sb, "--- Fork %d ---\n", i+1)
+		} else {
+			fmt.Fprintf(&sb, "--- %s (fork of %s) ---\n", f.FullName, f.Parent)
+		}
+		fmt.Fprintf(&sb, "Category: %s\n", f.Category)
+		if f.Adopted {
+			sb.WriteString("Adopted: yes, kept diverged on purpose\n")
+		}
+		if f.Archived {
+			sb.WriteString("Archived: yes, read-only\n")
+		}
+		if f.Ahead > 0 || f.Behind > 0 {
+			fmt.Fprintf(&sb, "Versus Upstream: %d ahead, %d behind\n", f.Ahead, f.Behind)
+		}
+		if f.Upstreamed > 0 {
+			fmt.Fprintf(&sb, "Already Upstream: %d of the %d ahead commits have an equivalent patch upstream\n", f.Upstreamed, f.Ahead)
+		}
+		if f.OpenIssues > 0 || f.ClosedIssues > 0 {
+			fmt.Fprintf(&sb, "Your Issues Upstream: %d open, %d closed\n", f.OpenIssues, f.ClosedIssues)
+		}
+		if f.LastCommitAgo != "" {
+			fmt.Fprintf(&sb, "Last Commit: %s\n", f.LastCommitAgo)
+		}
+		if f.UpstreamAgo != "" {
+			fmt.Fprintf(&sb, "Upstream Last Commit: %s\n", f.UpstreamAgo)
+		}
+		if len(f.Branches) > 0 {
+			sb.WriteString("Branches:\n")
+			for _, b := range f.Branches {
+				name := b.Name
+				if opts.Redact {
+					name = "(redacted)"
+				}
+				fmt.Fprintf(&sb, "  - %s (%s)", name, b.Ago)
+				switch {
+				case b.PRNumber == 0:
+					sb.WriteString(", no PR")
+				case opts.Redact:
+					fmt.Fprintf(&sb, ", PR %s", b.PRState)
+				default:
+					fmt.Fprintf(&sb, ", PR #%d %s: %s", b.PRNumber, b.PRState, b.PRTitle)
+				}
+				sb.WriteString("\n")
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// GetForkAdvice returns a triage plan for forks, sharing the providers,
+// fallback and cache of repo advice
+func GetForkAdvice(forks []ForkState, opts Options) ([]Advice, error) {
+	stateHash := computeForkHash(forks, opts)
+	if !opts.NoCache {
+		if cached, err := readCacheByHash(stateHash); err == nil && !cached.Expired(opts.CacheTTL) {
+			return cached.Advice, nil
+		}
+	}
+
+	prompt := FormatForkPrompt(forks, opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	advice, provider, err := generateWithFallback(ctx, prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.NoCache {
+		names := make([]string, len(forks))
+		for i, f := range forks {
+			names[i] = f.FullName
+		}
+		_ = writeCacheByHash(stateHash, names, provider.Name(), provider.Model(), advice)
+		_, _ = PruneCache(opts.CacheLimits())
+	}
+
+	return advice, nil
+}
+
+// computeForkHash identifies a fork triage by the forks' state and the
+// options that affect advice
+func computeForkHash(forks []ForkState, opts Options) string {
+	key := struct {
+		Kind         string
+		Forks        []ForkState
+		Instructions string
+		Model        string
+		Redacted     bool
+	}{"forks", forks, opts.Instructions, ResolveModel(opts), opts.Redact}
+
+	data, _ := json.Marshal(key)
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
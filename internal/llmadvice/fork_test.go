@@ -0,0 +1,91 @@
+package llmadvice
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testForks = []ForkState{
+	{FullName: "me/tool", Parent: "acme/tool", Category: "maintained", Ahead: 3, Behind: 400, LastCommitAgo: "2 years ago"},
+	{
+		FullName: "me/lib", Parent: "acme/lib", Category: "contribution",
+		Branches: []ForkBranch{
+			{Name: "fix-typo", Ago: "1 year ago", PRNumber: 12, PRTitle: "Fix typo", PRState: "MERGED"},
+			{Name: "wip", Ago: "3 months ago"},
+		},
+	},
+	{FullName: "me/old", Parent: "acme/old", Category: "untouched"},
+}
+
+func TestFormatForkPrompt(t *testing.T) {
+	prompt := FormatForkPrompt(testForks, Options{})
+
+	assert.Contains(t, prompt, "Fork triage advisor")
+	assert.Contains(t, prompt, "Forks (3):")
+	assert.Contains(t, prompt, "--- me/tool (fork of acme/tool) ---")
+	assert.Contains(t, prompt, "Versus Upstream: 3 ahead, 400 behind")
+	assert.Contains(t, prompt, "Last Commit: 2 years ago")
+	assert.Contains(t, prompt, "  - fix-typo (1 year ago), PR #12 MERGED: Fix typo")
+	assert.Contains(t, prompt, "  - wip (3 months ago), no PR")
+	assert.Contains(t, prompt, "Category: untouched")
+	assert.NotContains(t, prompt, "Already Upstream:")
+
+	prompt = FormatForkPrompt([]ForkState{{FullName: "me/tool", Category: "maintained", Ahead: 3, Upstreamed: 3, OpenIssues: 1}}, Options{})
+	assert.Contains(t, prompt, "Already Upstream: 3 of the 3 ahead commits have an equivalent patch upstream")
+	assert.Contains(t, prompt, "Your Issues Upstream: 1 open, 0 closed")
+}
+
+func TestFormatForkPrompt_Adopted(t *testing.T) {
+	forks := []ForkState{{FullName: "me/dotfiles", Parent: "acme/dotfiles", Category: "maintained", Behind: 50, Adopted: true}}
+	prompt := FormatForkPrompt(forks, Options{})
+
+	assert.Contains(t, prompt, "Category: maintained\nAdopted: yes, kept diverged on purpose\n")
+
+	advice, err := NewStaticProvider().GenerateAdvice(context.Background(), prompt)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"All good"}, Texts(advice), "adopted forks aren't synced")
+}
+
+func TestFormatForkPrompt_Redacted(t *testing.T) {
+	prompt := FormatForkPrompt(testForks, Options{Redact: true})
+
+	assert.Contains(t, prompt, "--- Fork 1 ---")
+	assert.Contains(t, prompt, "PR MERGED")
+	for _, secret := range []string{"me/tool", "acme", "fix-typo", "Fix typo", "#12"} {
+		assert.NotContains(t, prompt, secret)
+	}
+}
+
+func TestGetForkAdvice(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("OLLAMA_MODEL", "")
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = io.WriteString(w, `{"message":{"role":"assistant","content":"- Delete me/old"},"done":true}`+"\n")
+	}))
+	defer server.Close()
+
+	opts := Options{Provider: ProviderOllama, BaseURL: server.URL}
+	advice, err := GetForkAdvice(testForks, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Delete me/old"}, Texts(advice))
+
+	_, err = GetForkAdvice(testForks, opts)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load(), "unchanged forks are answered from the cache")
+
+	changed := append([]ForkState{}, testForks...)
+	changed[0].Behind++
+	_, err = GetForkAdvice(changed, opts)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+}
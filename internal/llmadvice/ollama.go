@@ -0,0 +1,81 @@
+package llmadvice
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/ollama"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+)
+
+const (
+	ollamaModel   = "llama3.2"
+	ollamaBaseURL = "http://localhost:11434"
+)
+
+// OllamaProvider implements the Provider interface for a local Ollama server
+type OllamaProvider struct {
+	generation
+	llm   llms.Model
+	model string
+}
+
+// NewOllamaProvider creates a new Ollama provider.
+// Empty baseURL and model fall back to the local server and default model.
+func NewOllamaProvider(baseURL, model string) (*OllamaProvider, error) {
+	if baseURL == "" {
+		baseURL = ollamaBaseURL
+	}
+	if model == "" {
+		model = ollamaModel
+	}
+	llm, err := ollama.New(
+		ollama.WithServerURL(baseURL),
+		ollama.WithModel(model),
+		ollama.WithHTTPClient(&http.Client{Transport: debuglog.Transport(http.DefaultTransport)}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Ollama client: %w", err)
+	}
+	return &OllamaProvider{
+		generation: generation{temperature: defaultTemperature, maxTokens: defaultMaxTokens},
+		llm:        llm,
+		model:      model,
+	}, nil
+}
+
+// ollamaHostURL reads the server address from OLLAMA_HOST, which, like the
+// ollama CLI, may omit the scheme (e.g. "127.0.0.1:11434")
+func ollamaHostURL() string {
+	host := os.Getenv("OLLAMA_HOST")
+	if host != "" && !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	return host
+}
+
+func (p *OllamaProvider) Name() string {
+	return string(ProviderOllama)
+}
+
+func (p *OllamaProvider) Model() string {
+	return p.model
+}
+
+// JSON mode keeps local models to the format the prompt asks for
+func (p *OllamaProvider) GenerateAdvice(ctx context.Context, prompt string) ([]Advice, error) {
+	return p.generate(ctx, p.llm, prompt, llms.WithJSONMode())
+}
+
+func (p *OllamaProvider) StreamAdvice(ctx context.Context, prompt string, onAdvice func(Advice)) ([]Advice, error) {
+	return p.stream(ctx, p.llm, prompt, onAdvice, llms.WithJSONMode())
+}
+
+func (p *OllamaProvider) Chat(ctx context.Context, history []Message, onText func(string)) (string, error) {
+	return p.chat(ctx, p.llm, history, onText)
+}
@@ -3,9 +3,13 @@ package llmadvice
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strings"
 
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/openai"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
 )
 
 const (
@@ -14,25 +18,136 @@ const (
 
 // OpenAIProvider implements the Provider interface for OpenAI
 type OpenAIProvider struct {
-	llm   llms.Model
-	model string
+	generation
+	llm        llms.Model
+	structured llms.Model // llm with the advice schema enforced, for advice only
+	model      string
+}
+
+// OpenAIConfig points the OpenAI provider at an OpenAI-compatible endpoint
+// such as Azure OpenAI, OpenRouter, llama.cpp server, vLLM or a corporate proxy
+type OpenAIConfig struct {
+	BaseURL string            // Empty means api.openai.com
+	Model   string            // Empty means openAIModel
+	Headers map[string]string // Extra HTTP headers sent with every request
+
+	// Azure OpenAI serves models by deployment, at BaseURL, and takes the
+	// key in an api-key header: setting Deployment switches to that
+	Deployment string
+	APIVersion string // Empty means the client's default
 }
 
 // NewOpenAIProvider creates a new OpenAI provider
-func NewOpenAIProvider(apiKey string) (*OpenAIProvider, error) {
-	llm, err := openai.New(
+func NewOpenAIProvider(apiKey string, cfg OpenAIConfig) (*OpenAIProvider, error) {
+	model := cfg.Model
+	if model == "" {
+		model = openAIModel
+	}
+	opts := []openai.Option{
 		openai.WithToken(apiKey),
-		openai.WithModel(openAIModel),
-	)
+		openai.WithModel(model),
+	}
+	if cfg.Deployment != "" {
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("azure_deployment needs base_url, the endpoint of the Azure OpenAI resource")
+		}
+		// The client puts the model in the URL as the deployment
+		opts = append(opts, openai.WithAPIType(openai.APITypeAzure), openai.WithModel(cfg.Deployment))
+		if cfg.APIVersion != "" {
+			opts = append(opts, openai.WithAPIVersion(cfg.APIVersion))
+		}
+	}
+	if cfg.BaseURL != "" {
+		opts = append(opts, openai.WithBaseURL(cfg.BaseURL))
+	}
+	transport := http.DefaultTransport
+	if len(cfg.Headers) > 0 {
+		transport = &headerTransport{headers: cfg.Headers, base: transport}
+	}
+	opts = append(opts, openai.WithHTTPClient(&http.Client{Transport: debuglog.Transport(transport)}))
+
+	llm, err := openai.New(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OpenAI client: %w", err)
 	}
+
+	// Compatible servers vary in structured output support, so only
+	// api.openai.com is held to the schema; the rest rely on the prompt
+	structured := llm
+	if cfg.BaseURL == "" {
+		structured, err = openai.New(append(opts, openai.WithResponseFormat(adviceResponseFormat))...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OpenAI client: %w", err)
+		}
+	}
+
 	return &OpenAIProvider{
-		llm:   llm,
-		model: openAIModel,
+		generation: generation{temperature: defaultTemperature, maxTokens: defaultMaxTokens},
+		llm:        llm,
+		structured: structured,
+		model:      model,
 	}, nil
 }
 
+// adviceResponseFormat is the JSON the advice prompt asks for, as an OpenAI
+// structured output schema
+var adviceResponseFormat = &openai.ResponseFormat{
+	Type: "json_schema",
+	JSONSchema: &openai.ResponseFormatJSONSchema{
+		Name:   "advice",
+		Strict: true,
+		Schema: &openai.ResponseFormatJSONSchemaProperty{
+			Type: "object",
+			Properties: map[string]*openai.ResponseFormatJSONSchemaProperty{
+				"advice": {
+					Type: "array",
+					Items: &openai.ResponseFormatJSONSchemaProperty{
+						Type: "object",
+						Properties: map[string]*openai.ResponseFormatJSONSchemaProperty{
+							"text":     {Type: "string"},
+							"severity": {Type: "string", Enum: []any{SeverityInfo, SeverityWarning, SeverityCritical}},
+							"command":  {Type: "string"},
+						},
+						Required: []string{"text", "severity", "command"},
+					},
+				},
+			},
+			Required: []string{"advice"},
+		},
+	},
+}
+
+// headerTransport adds fixed headers to every request
+type headerTransport struct {
+	headers map[string]string
+	base    http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// ParseHeaders parses "Name: value" strings into a header map
+func ParseHeaders(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	headers := make(map[string]string, len(values))
+	for _, v := range values {
+		name, value, ok := strings.Cut(v, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid header %q: expected \"Name: value\"", v)
+		}
+		headers[name] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
+
 func (p *OpenAIProvider) Name() string {
 	return string(ProviderOpenAI)
 }
@@ -41,14 +156,14 @@ func (p *OpenAIProvider) Model() string {
 	return p.model
 }
 
-func (p *OpenAIProvider) GenerateAdvice(ctx context.Context, prompt string) ([]string, error) {
-	response, err := llms.GenerateFromSinglePrompt(ctx, p.llm, prompt,
-		llms.WithTemperature(0.3),
-		llms.WithMaxTokens(500),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrAPIError, err)
-	}
+func (p *OpenAIProvider) GenerateAdvice(ctx context.Context, prompt string) ([]Advice, error) {
+	return p.generate(ctx, p.structured, prompt)
+}
+
+func (p *OpenAIProvider) StreamAdvice(ctx context.Context, prompt string, onAdvice func(Advice)) ([]Advice, error) {
+	return p.stream(ctx, p.structured, prompt, onAdvice)
+}
 
-	return parseAdviceResponse(response), nil
+func (p *OpenAIProvider) Chat(ctx context.Context, history []Message, onText func(string)) (string, error) {
+	return p.chat(ctx, p.llm, history, onText)
 }
@@ -1,6 +1,7 @@
 package llmadvice
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -18,33 +19,52 @@ Rules:
 - Include important items from basic advice (unpushed commits, uncommitted work)
 - Enhance with context: mention specific files, branches, ages
 - Add insights the algorithm misses: stale branches, old stashes, patterns
-- No git commands - user knows git
-- If all good, just say "All good"
+- severity: critical (repo is blocked), warning (work at risk), info (housekeeping)
+- command: one copy-ready command only when it clearly applies, else ""
+- If all good, a single info item saying "All good"
+
+Format: a JSON object and nothing else, one advice item per line:
+{"advice": [
+{"text": "Push the 2 commits on feature-x", "severity": "warning", "command": "git push"},
+{"text": "Stash from March is likely stale", "severity": "info", "command": ""}
+]}
+`
 
-Format: numbered list, nothing else.
+const chatPrompt = `Git advisor for an experienced developer, answering follow-up questions
+about the repositories below. Be brief and concrete. Git commands are fine when
+they answer the question. If the state below doesn't answer it, say so.
 `
 
+// redactedNote tells the model why names are missing so it doesn't ask for them
+const redactedNote = "\nNote: names, paths, URLs and messages are redacted; refer to items generically.\n"
+
 // FormatSingleRepoPrompt formats a single repo's state for the LLM
-func FormatSingleRepoPrompt(info *analyzer.RepoInfo, basicAdvice []string, customInstructions string) string {
+func FormatSingleRepoPrompt(info *analyzer.RepoInfo, basicAdvice []string, opts Options) string {
 	var sb strings.Builder
 
-	sb.WriteString(systemPrompt)
+	sb.WriteString(opts.Templates.systemText(systemPrompt, []*analyzer.RepoInfo{info}, opts.Redact))
 
-	if customInstructions != "" {
+	if opts.Instructions != "" {
 		sb.WriteString("\nAdditional instructions: ")
-		sb.WriteString(customInstructions)
+		sb.WriteString(opts.Instructions)
 		sb.WriteString("\n")
 	}
+	if opts.Redact {
+		sb.WriteString(redactedNote)
+	}
 
 	sb.WriteString("\n\nRepository State:\n")
-	sb.WriteString(formatRepoState(info))
+	sb.WriteString(opts.Templates.repoText(info, opts.Redact))
 
-	if len(basicAdvice) > 0 {
+	switch {
+	case len(basicAdvice) > 0 && opts.Redact:
+		fmt.Fprintf(&sb, "\nBasic Advice (from algorithm): %s\n", redactedAdvice(basicAdvice))
+	case len(basicAdvice) > 0:
 		sb.WriteString("\nBasic Advice (from algorithm):\n")
 		for _, a := range basicAdvice {
 			fmt.Fprintf(&sb, "- %s\n", a)
 		}
-	} else {
+	default:
 		sb.WriteString("\nBasic Advice: (none - algorithm found nothing to suggest)\n")
 	}
 
@@ -52,39 +72,128 @@ func FormatSingleRepoPrompt(info *analyzer.RepoInfo, basicAdvice []string, custo
 }
 
 // FormatMultiRepoPrompt formats multiple repos for combined analysis
-func FormatMultiRepoPrompt(repos []*analyzer.RepoInfo, basicAdvicePerRepo map[string][]string, customInstructions string) string {
+func FormatMultiRepoPrompt(repos []*analyzer.RepoInfo, basicAdvicePerRepo map[string][]string, opts Options) string {
+	var sb strings.Builder
+	sb.WriteString(multiRepoHeader(repos, opts))
+	for i, info := range repos {
+		sb.WriteString(formatRepoSection(i, info, basicAdvicePerRepo[info.Name], opts, false))
+	}
+	return sb.String()
+}
+
+// multiRepoHeader is the start of a multi-repo prompt, before the repos
+func multiRepoHeader(repos []*analyzer.RepoInfo, opts Options) string {
 	var sb strings.Builder
 
-	sb.WriteString(systemPrompt)
+	sb.WriteString(opts.Templates.systemText(systemPrompt, repos, opts.Redact))
 
-	if customInstructions != "" {
+	if opts.Instructions != "" {
 		sb.WriteString("\nAdditional instructions: ")
-		sb.WriteString(customInstructions)
+		sb.WriteString(opts.Instructions)
 		sb.WriteString("\n")
 	}
+	if opts.Redact {
+		sb.WriteString(redactedNote)
+	}
 
 	sb.WriteString("\n\nMultiple Repository States:\n")
 	sb.WriteString("Provide an overall summary and prioritized actions across all repositories.\n\n")
+	return sb.String()
+}
 
-	for i, info := range repos {
+// formatRepoSection describes the i-th repo of a multi-repo prompt. Brief
+// sections leave out file names, commits and stash messages to save space.
+func formatRepoSection(i int, info *analyzer.RepoInfo, basicAdvice []string, opts Options, brief bool) string {
+	var sb strings.Builder
+	if opts.Redact {
+		fmt.Fprintf(&sb, "--- Repository %d ---\n", i+1)
+	} else {
 		fmt.Fprintf(&sb, "--- Repository %d: %s ---\n", i+1, info.Name)
-		sb.WriteString(formatRepoState(info))
-		if advice, ok := basicAdvicePerRepo[info.Name]; ok && len(advice) > 0 {
-			sb.WriteString("Basic Advice:\n")
-			for _, a := range advice {
-				fmt.Fprintf(&sb, "  - %s\n", a)
-			}
+	}
+	if brief {
+		info = withoutDetails(info)
+	}
+	sb.WriteString(opts.Templates.repoText(info, opts.Redact))
+	writeBasicAdvice(&sb, basicAdvice, opts.Redact)
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// writeBasicAdvice lists the advice of a repo section, or only counts it
+// when redacting: its text names remotes and branches
+func writeBasicAdvice(sb *strings.Builder, advice []string, redact bool) {
+	switch {
+	case len(advice) == 0:
+	case redact:
+		fmt.Fprintf(sb, "Basic Advice: %s\n", redactedAdvice(advice))
+	default:
+		sb.WriteString("Basic Advice:\n")
+		for _, a := range advice {
+			fmt.Fprintf(sb, "  - %s\n", a)
 		}
+	}
+}
+
+// redactedAdvice stands in for the text of advice in redacted prompts
+func redactedAdvice(advice []string) string {
+	return fmt.Sprintf("%d item(s), text redacted", len(advice))
+}
+
+// FormatChatContext describes repos and the advice already given, as the
+// fixed context of a follow-up conversation
+func FormatChatContext(repos []*analyzer.RepoInfo, basicAdvicePerRepo map[string][]string, given []string, opts Options) string {
+	var sb strings.Builder
+
+	sb.WriteString(chatPrompt)
+
+	if opts.Instructions != "" {
+		sb.WriteString("\nAdditional instructions: ")
+		sb.WriteString(opts.Instructions)
 		sb.WriteString("\n")
 	}
+	if opts.Redact {
+		sb.WriteString(redactedNote)
+	}
+
+	for i, info := range repos {
+		switch {
+		case len(repos) == 1:
+			sb.WriteString("\nRepository State:\n")
+		case opts.Redact:
+			fmt.Fprintf(&sb, "\n--- Repository %d ---\n", i+1)
+		default:
+			fmt.Fprintf(&sb, "\n--- Repository %d: %s ---\n", i+1, info.Name)
+		}
+		sb.WriteString(opts.Templates.repoText(info, opts.Redact))
+		writeBasicAdvice(&sb, basicAdvicePerRepo[info.Name], opts.Redact)
+	}
+
+	if len(given) > 0 {
+		sb.WriteString("\nAdvice you already gave:\n")
+		for _, a := range given {
+			fmt.Fprintf(&sb, "- %s\n", a)
+		}
+	}
 
 	return sb.String()
 }
 
-func formatRepoState(info *analyzer.RepoInfo) string {
+// formatRepoState describes the repo for the prompt. With redact set, only
+// counts, dates and states are kept: no names, paths, URLs or messages.
+func formatRepoState(info *analyzer.RepoInfo, redact bool) string {
 	var sb strings.Builder
 
-	fmt.Fprintf(&sb, "Name: %s\n", info.Name)
+	// names lists names, or just counts them when redacting
+	names := func(list []string) string {
+		if redact {
+			return fmt.Sprintf("%d (names redacted)", len(list))
+		}
+		return formatFileList(list, 5)
+	}
+
+	if !redact {
+		fmt.Fprintf(&sb, "Name: %s\n", info.Name)
+	}
 
 	// Branch context
 	if info.CurrentBranch != "" {
@@ -94,20 +203,45 @@ func formatRepoState(info *analyzer.RepoInfo) string {
 		} else if info.DefaultBranch != "" {
 			branchType = " (feature branch)"
 		}
-		fmt.Fprintf(&sb, "Current Branch: %s%s\n", info.CurrentBranch, branchType)
+		branch := info.CurrentBranch
+		if redact {
+			branch = "(redacted)"
+		}
+		fmt.Fprintf(&sb, "Current Branch: %s%s\n", branch, branchType)
 	}
 
 	if info.IsFork {
 		sb.WriteString("Type: Fork\n")
-		if info.UpstreamURL != "" {
+		if info.UpstreamURL != "" && !redact {
 			fmt.Fprintf(&sb, "Upstream: %s\n", info.UpstreamURL)
 		}
+		if info.UpstreamAhead > 0 || info.UpstreamBehind > 0 {
+			fmt.Fprintf(&sb, "Versus Upstream: %d ahead, %d behind\n", info.UpstreamAhead, info.UpstreamBehind)
+		}
+	}
+
+	if op := info.Operation; op != nil {
+		if op.State != analyzer.OpNone {
+			fmt.Fprintf(&sb, "In Progress: %s\n", op.State)
+		}
+		if len(op.ConflictFiles) > 0 {
+			fmt.Fprintf(&sb, "Conflicted Files: %s\n", names(op.ConflictFiles))
+		}
+		if op.DetachedHEAD {
+			sb.WriteString("HEAD: detached\n")
+		}
+		if op.Empty {
+			sb.WriteString("Note: Repository has no commits\n")
+		}
 	}
 
 	// Unpushed commits with details
 	if info.Ahead > 0 {
 		fmt.Fprintf(&sb, "Unpushed Commits: %d\n", info.Ahead)
 	}
+	if others, commits := info.OtherUnpushed(); len(others) > 0 {
+		fmt.Fprintf(&sb, "Unpushed On Other Branches: %d commits on %s\n", commits, names(others))
+	}
 	if info.Behind > 0 {
 		fmt.Fprintf(&sb, "Behind Remote: %d commits\n", info.Behind)
 	}
@@ -116,7 +250,11 @@ func formatRepoState(info *analyzer.RepoInfo) string {
 	if len(info.RecentCommits) > 0 {
 		sb.WriteString("Recent Commits:\n")
 		for _, c := range info.RecentCommits {
-			fmt.Fprintf(&sb, "  - %s: %s (%s)\n", c.Hash, c.Message, c.Date)
+			if redact {
+				fmt.Fprintf(&sb, "  - (%s)\n", c.Date)
+			} else {
+				fmt.Fprintf(&sb, "  - %s: %s (%s)\n", c.Hash, c.Message, c.Date)
+			}
 		}
 	}
 
@@ -124,19 +262,28 @@ func formatRepoState(info *analyzer.RepoInfo) string {
 	if info.HasUncommittedChanges && info.DirtyDetails != nil {
 		d := info.DirtyDetails
 		sb.WriteString("Uncommitted Changes:\n")
+		fileNames := func(list []string) string {
+			switch {
+			case redact:
+				return "(names redacted)"
+			case len(list) == 0:
+				return "(names omitted)"
+			}
+			return formatFileList(list, 5)
+		}
 		if d.StagedFiles > 0 {
 			fmt.Fprintf(&sb, "  - Staged (%d files, +%d/-%d lines): %s\n",
 				d.StagedFiles, d.StagedInsertions, d.StagedDeletions,
-				formatFileList(d.StagedNames, 5))
+				fileNames(d.StagedNames))
 		}
 		if d.UnstagedFiles > 0 {
 			fmt.Fprintf(&sb, "  - Modified (%d files, +%d/-%d lines): %s\n",
 				d.UnstagedFiles, d.UnstagedInsertions, d.UnstagedDeletions,
-				formatFileList(d.UnstagedNames, 5))
+				fileNames(d.UnstagedNames))
 		}
 		if d.Untracked > 0 {
 			fmt.Fprintf(&sb, "  - Untracked (%d files): %s\n",
-				d.Untracked, formatFileList(d.UntrackedNames, 5))
+				d.Untracked, fileNames(d.UntrackedNames))
 		}
 	}
 
@@ -144,20 +291,57 @@ func formatRepoState(info *analyzer.RepoInfo) string {
 	if info.StashCount > 0 {
 		fmt.Fprintf(&sb, "Stashes (%d):\n", info.StashCount)
 		for _, s := range info.Stashes {
-			fmt.Fprintf(&sb, "  - stash@{%d}: %s (%s)\n", s.Index, s.Message, s.Date)
+			if redact {
+				fmt.Fprintf(&sb, "  - stash@{%d}: (%s, %d files)\n", s.Index, s.Date, len(s.Files))
+				continue
+			}
+			from := ""
+			if s.Branch != "" {
+				from = " from " + s.Branch
+			}
+			fmt.Fprintf(&sb, "  - stash@{%d}: %s%s (%s, %d files)\n", s.Index, s.Message, from, s.Date, len(s.Files))
 		}
 	}
 
 	// Branches with user commits
 	if len(info.BranchesWithCommits) > 0 {
 		sb.WriteString("Your Branches:\n")
-		for _, b := range info.BranchesWithCommits {
+		for i, b := range info.BranchesWithCommits {
 			current := ""
 			if b.IsCurrent {
 				current = " (current)"
 			}
-			fmt.Fprintf(&sb, "  - %s: %d commits, last %s%s\n",
-				b.Name, b.CommitCount, b.LastCommitDate, current)
+			tracking := ""
+			if b.UpstreamGone {
+				tracking = ", upstream deleted"
+			}
+			name := b.Name
+			if redact {
+				name = fmt.Sprintf("branch %d", i+1)
+			}
+			fmt.Fprintf(&sb, "  - %s: %d commits, last %s%s%s\n",
+				name, b.CommitCount, b.LastCommitDate, current, tracking)
+		}
+	}
+
+	if gone := info.GoneBranches(); len(gone) > 0 {
+		fmt.Fprintf(&sb, "Branches With Deleted Upstream: %s\n", names(gone))
+	}
+	if ro := info.ReadOnlyRemotes(); len(ro) > 0 {
+		fmt.Fprintf(&sb, "Read-Only Remotes (can't push, suggest a fork): %s\n", names(ro))
+	}
+	if len(info.StaleRemoteRefs) > 0 {
+		fmt.Fprintf(&sb, "Stale Remote-Tracking Refs: %s\n", names(info.StaleRemoteRefs))
+	}
+
+	if len(info.BranchOverlaps) > 0 {
+		sb.WriteString("Uncommitted Work Touching Other Branches' Files:\n")
+		for i, o := range info.BranchOverlaps {
+			branch, files := o.Branch, formatFileList(o.Files, 5)
+			if redact {
+				branch, files = fmt.Sprintf("branch %d", i+1), fmt.Sprintf("%d files", len(o.Files))
+			}
+			fmt.Fprintf(&sb, "  - %s touches %s: %s\n", o.Source, branch, files)
 		}
 	}
 
@@ -180,30 +364,101 @@ func formatFileList(files []string, limit int) string {
 	return strings.Join(files[:limit], ", ") + fmt.Sprintf(" (+%d more)", len(files)-limit)
 }
 
-// parseAdviceResponse parses the LLM response into individual advice strings
-func parseAdviceResponse(response string) []string {
-	var advice []string
-	lines := strings.Split(strings.TrimSpace(response), "\n")
+// parseAdviceResponse parses the LLM response into advice items. The model
+// is asked for JSON, but a reply that isn't is read one item per line.
+func parseAdviceResponse(response string) []Advice {
+	if advice, ok := parseAdviceJSON(response); ok {
+		return advice
+	}
+
+	var advice []Advice
+	for _, line := range strings.Split(strings.TrimSpace(response), "\n") {
+		if a, ok := parseAdviceLine(line); ok {
+			advice = append(advice, a)
+		}
+	}
+	return advice
+}
+
+// parseAdviceJSON reads {"advice": [...]} or a bare array of items,
+// ignoring code fences or prose around it
+func parseAdviceJSON(response string) ([]Advice, bool) {
+	start := strings.IndexAny(response, "[{")
+	end := strings.LastIndexAny(response, "]}")
+	if start < 0 || end < start {
+		return nil, false
+	}
+	data := []byte(response[start : end+1])
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+	var list []Advice
+	if data[0] == '{' {
+		var wrapped struct {
+			Advice []Advice `json:"advice"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil || wrapped.Advice == nil {
+			return nil, false
 		}
+		list = wrapped.Advice
+	} else if err := json.Unmarshal(data, &list); err != nil {
+		return nil, false
+	}
 
-		// Remove numbering if present (e.g., "1. ", "- ")
-		if len(line) > 2 {
-			if (line[0] >= '1' && line[0] <= '9') && (line[1] == '.' || line[1] == ')') {
-				line = strings.TrimSpace(line[2:])
-			} else if line[0] == '-' || line[0] == '*' {
-				line = strings.TrimSpace(line[1:])
-			}
+	advice := make([]Advice, 0, len(list))
+	for _, a := range list {
+		if a, ok := normalizeAdvice(a); ok {
+			advice = append(advice, a)
 		}
+	}
+	return advice, true
+}
 
-		if line != "" {
-			advice = append(advice, line)
+// parseAdviceLine reads one line of the response: either a complete JSON
+// advice object or a plain, possibly numbered, line of text. ok is false
+// for lines that carry no advice, such as JSON brackets and keys.
+func parseAdviceLine(line string) (Advice, bool) {
+	line = strings.TrimSpace(line)
+
+	if strings.HasPrefix(line, "{") {
+		// Decode just the first value so trailing commas and brackets are ignored
+		var a Advice
+		if json.NewDecoder(strings.NewReader(line)).Decode(&a) == nil {
+			return normalizeAdvice(a)
 		}
 	}
+	if isJSONSyntax(line) {
+		return Advice{}, false
+	}
 
-	return advice
+	// Remove numbering if present (e.g., "1. ", "- ")
+	if len(line) > 2 {
+		if (line[0] >= '1' && line[0] <= '9') && (line[1] == '.' || line[1] == ')') {
+			line = strings.TrimSpace(line[2:])
+		} else if line[0] == '-' || line[0] == '*' {
+			line = strings.TrimSpace(line[1:])
+		}
+	}
+
+	return normalizeAdvice(Advice{Text: line})
+}
+
+// isJSONSyntax reports whether line is a piece of JSON structure rather
+// than text: brackets, a code fence, a key, or the start of an object
+func isJSONSyntax(line string) bool {
+	if strings.HasPrefix(line, "```") || strings.HasPrefix(line, `"`) || strings.HasPrefix(line, `{"`) {
+		return true
+	}
+	return line != "" && strings.Trim(line, "[]{},") == ""
+}
+
+// normalizeAdvice tidies an item from the model, defaulting unknown
+// severities to info. ok is false for items without text.
+func normalizeAdvice(a Advice) (Advice, bool) {
+	a.Text = strings.TrimSpace(a.Text)
+	a.Command = strings.TrimSpace(a.Command)
+	switch a.Severity = strings.ToLower(strings.TrimSpace(a.Severity)); a.Severity {
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+	default:
+		a.Severity = SeverityInfo
+	}
+	return a, a.Text != ""
 }
@@ -3,7 +3,11 @@ package llmadvice
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
+	"strings"
+
+	"github.com/tmc/langchaingo/llms"
 )
 
 // Provider defines the interface for LLM providers
@@ -12,8 +16,25 @@ type Provider interface {
 	Name() string
 	// Model returns the model being used
 	Model() string
-	// GenerateAdvice sends a prompt to the LLM and returns advice strings
-	GenerateAdvice(ctx context.Context, prompt string) ([]string, error)
+	// GenerateAdvice sends a prompt to the LLM and returns the advice items
+	GenerateAdvice(ctx context.Context, prompt string) ([]Advice, error)
+}
+
+// StreamingProvider is a Provider that can hand out advice items as the
+// model produces them
+type StreamingProvider interface {
+	Provider
+	// StreamAdvice works like GenerateAdvice but calls onAdvice with each
+	// advice item as soon as it is complete
+	StreamAdvice(ctx context.Context, prompt string, onAdvice func(Advice)) ([]Advice, error)
+}
+
+// ChatProvider is a Provider that can carry on a conversation
+type ChatProvider interface {
+	Provider
+	// Chat sends the conversation so far and returns the model's reply.
+	// When onText is set it receives the reply in pieces as it arrives.
+	Chat(ctx context.Context, history []Message, onText func(string)) (string, error)
 }
 
 // ProviderType represents supported LLM providers
@@ -22,6 +43,42 @@ type ProviderType string
 const (
 	ProviderOpenAI    ProviderType = "openai"
 	ProviderAnthropic ProviderType = "anthropic"
+	ProviderOllama    ProviderType = "ollama"
+	ProviderStatic    ProviderType = "static" // Offline canned advice, see StaticProvider
+)
+
+// ProviderEnv names the environment variable that overrides the provider
+// from llm.toml, e.g. GIT_THIS_BREAD_LLM_PROVIDER=static for offline demos
+const ProviderEnv = "GIT_THIS_BREAD_LLM_PROVIDER"
+
+// Generation defaults used when Options leaves them unset
+const (
+	defaultTemperature = 0.3
+	defaultMaxTokens   = 500
+)
+
+// maxTemperature is the highest temperature each provider's API accepts
+var maxTemperature = map[ProviderType]float64{
+	ProviderOpenAI:    2,
+	ProviderAnthropic: 1,
+	ProviderOllama:    2,
+	ProviderStatic:    2,
+}
+
+// defaultModels and modelEnvs decide the model when Options.Model is empty:
+// the provider's env var wins over the built-in default
+var (
+	defaultModels = map[ProviderType]string{
+		ProviderOpenAI:    openAIModel,
+		ProviderAnthropic: anthropicModel,
+		ProviderOllama:    ollamaModel,
+		ProviderStatic:    staticModel,
+	}
+	modelEnvs = map[ProviderType]string{
+		ProviderOpenAI:    "OPENAI_MODEL",
+		ProviderAnthropic: "ANTHROPIC_MODEL",
+		ProviderOllama:    "OLLAMA_MODEL",
+	}
 )
 
 var (
@@ -30,22 +87,218 @@ var (
 	ErrAPIError      = errors.New("API error")
 )
 
-// NewProvider creates a new LLM provider based on the type
-func NewProvider(providerType ProviderType) (Provider, error) {
-	switch providerType {
+// ResolveModel returns the model opts will use: opts.Model, then the
+// provider's model env var, then the provider default
+func ResolveModel(opts Options) string {
+	if opts.Model != "" {
+		return opts.Model
+	}
+	if m := os.Getenv(modelEnvs[opts.Provider]); m != "" {
+		return m
+	}
+	return defaultModels[opts.Provider]
+}
+
+// Validate checks the generation settings against what the provider accepts
+func (o Options) Validate() error {
+	maxTemp, ok := maxTemperature[o.Provider]
+	if !ok {
+		return errors.New("unknown provider type: " + string(o.Provider))
+	}
+	for _, fb := range o.Fallback {
+		if _, ok := maxTemperature[fb]; !ok {
+			return errors.New("unknown fallback provider type: " + string(fb))
+		}
+	}
+	if strings.ContainsAny(o.Model, " \t\n") {
+		return fmt.Errorf("invalid model name %q", o.Model)
+	}
+	if t := o.Temperature; t != nil && (*t < 0 || *t > maxTemp) {
+		return fmt.Errorf("temperature %g out of range for %s: must be between 0 and %g", *t, o.Provider, maxTemp)
+	}
+	if o.MaxTokens < 0 {
+		return fmt.Errorf("max tokens must not be negative, got %d", o.MaxTokens)
+	}
+	return nil
+}
+
+// generation holds the sampling settings shared by all providers
+type generation struct {
+	temperature float64
+	maxTokens   int
+	onUsage     func(prompt, completion int) // Called with each response's token counts
+}
+
+func newGeneration(opts Options) generation {
+	g := generation{temperature: defaultTemperature, maxTokens: defaultMaxTokens}
+	if opts.Temperature != nil {
+		g.temperature = *opts.Temperature
+	}
+	if opts.MaxTokens > 0 {
+		g.maxTokens = opts.MaxTokens
+	}
+	return g
+}
+
+// complete sends prompt to llm and returns the reply text, reporting the
+// token counts to onUsage
+func (g generation) complete(ctx context.Context, llm llms.Model, prompt string, options ...llms.CallOption) (string, error) {
+	msg := llms.TextParts(llms.ChatMessageTypeHuman, prompt)
+	return g.completeMessages(ctx, llm, []llms.MessageContent{msg}, options...)
+}
+
+// completeMessages is complete for a whole conversation
+func (g generation) completeMessages(ctx context.Context, llm llms.Model, msgs []llms.MessageContent, options ...llms.CallOption) (string, error) {
+	options = append([]llms.CallOption{
+		llms.WithTemperature(g.temperature),
+		llms.WithMaxTokens(g.maxTokens),
+	}, options...)
+
+	resp, err := llm.GenerateContent(ctx, msgs, options...)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrAPIError, err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("%w: empty response from model", ErrAPIError)
+	}
+	choice := resp.Choices[0]
+	if g.onUsage != nil {
+		g.onUsage(tokenCounts(choice.GenerationInfo))
+	}
+	return choice.Content, nil
+}
+
+// generate sends prompt to llm and parses the advice list out of the reply
+func (g generation) generate(ctx context.Context, llm llms.Model, prompt string, options ...llms.CallOption) ([]Advice, error) {
+	response, err := g.complete(ctx, llm, prompt, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseAdviceResponse(response), nil
+}
+
+// stream is like generate but calls onAdvice with each advice item as soon
+// as its line is complete. Items the line parser can't see (e.g. JSON
+// objects spread over several lines) are handed out once the reply is done.
+func (g generation) stream(ctx context.Context, llm llms.Model, prompt string, onAdvice func(Advice), options ...llms.CallOption) ([]Advice, error) {
+	var pending strings.Builder
+	emitted := make(map[string]bool)
+	emit := func(a Advice) {
+		if !emitted[a.Text] {
+			emitted[a.Text] = true
+			onAdvice(a)
+		}
+	}
+	emitLine := func(line string) {
+		if a, ok := parseAdviceLine(line); ok {
+			emit(a)
+		}
+	}
+
+	options = append(options, llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+		pending.Write(chunk)
+		buffered := pending.String()
+		end := strings.LastIndexByte(buffered, '\n')
+		if end < 0 {
+			return nil
+		}
+		for _, line := range strings.Split(buffered[:end], "\n") {
+			emitLine(line)
+		}
+		pending.Reset()
+		pending.WriteString(buffered[end+1:])
+		return nil
+	}))
+	response, err := g.complete(ctx, llm, prompt, options...)
+	if err != nil {
+		return nil, err
+	}
+	emitLine(pending.String())
+
+	advice := parseAdviceResponse(response)
+	for _, a := range advice {
+		emit(a)
+	}
+	return advice, nil
+}
+
+// generateAdvice calls the provider, streaming items to opts.Stream when
+// both sides support it
+func generateAdvice(ctx context.Context, provider Provider, prompt string, opts Options) ([]Advice, error) {
+	if sp, ok := provider.(StreamingProvider); ok && opts.Stream != nil {
+		return sp.StreamAdvice(ctx, prompt, opts.Stream)
+	}
+	return provider.GenerateAdvice(ctx, prompt)
+}
+
+// NewProvider creates a new LLM provider based on opts.Provider
+func NewProvider(opts Options) (Provider, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	model := ResolveModel(opts)
+	gen := newGeneration(opts)
+	if opts.OnUsage != nil {
+		gen.onUsage = func(prompt, completion int) {
+			opts.OnUsage(newUsage(opts.Provider, model, prompt, completion))
+		}
+	}
+
+	switch opts.Provider {
 	case ProviderOpenAI:
+		baseURL := opts.BaseURL
+		if baseURL == "" {
+			baseURL = os.Getenv("OPENAI_BASE_URL")
+		}
 		apiKey := os.Getenv("OPENAI_API_KEY")
 		if apiKey == "" {
-			return nil, ErrNoAPIKey
+			if baseURL == "" || opts.Deployment != "" {
+				return nil, ErrNoAPIKey
+			}
+			// Self-hosted servers (llama.cpp, vLLM) often take no key, but
+			// the client insists on one
+			apiKey = "unused"
 		}
-		return NewOpenAIProvider(apiKey)
+		p, err := NewOpenAIProvider(apiKey, OpenAIConfig{
+			BaseURL:    baseURL,
+			Model:      model,
+			Headers:    opts.Headers,
+			Deployment: opts.Deployment,
+			APIVersion: opts.APIVersion,
+		})
+		if err != nil {
+			return nil, err
+		}
+		p.generation = gen
+		return p, nil
 	case ProviderAnthropic:
 		apiKey := os.Getenv("ANTHROPIC_API_KEY")
 		if apiKey == "" {
 			return nil, ErrNoAPIKey
 		}
-		return NewAnthropicProvider(apiKey)
+		p, err := NewAnthropicProvider(apiKey, model)
+		if err != nil {
+			return nil, err
+		}
+		p.generation = gen
+		return p, nil
+	case ProviderOllama:
+		// Local server, no API key needed
+		baseURL := opts.BaseURL
+		if baseURL == "" {
+			baseURL = ollamaHostURL()
+		}
+		p, err := NewOllamaProvider(baseURL, model)
+		if err != nil {
+			return nil, err
+		}
+		p.generation = gen
+		return p, nil
+	case ProviderStatic:
+		// No model, so no tokens to report
+		return NewStaticProvider(), nil
 	default:
-		return nil, errors.New("unknown provider type: " + string(providerType))
+		return nil, errors.New("unknown provider type: " + string(opts.Provider))
 	}
 }
@@ -0,0 +1,248 @@
+package llmadvice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Retry settings for transient API errors. Variables so tests can shorten them.
+var (
+	maxAttempts    = 3
+	retryBaseDelay = time.Second
+)
+
+// transientStatus matches the HTTP statuses worth retrying in provider errors
+var transientStatus = regexp.MustCompile(`\b(429|500|502|503|504|529)\b`)
+
+// isTransient reports whether err is likely to go away on retry:
+// rate limits, overloaded or failing servers, and network timeouts
+func isTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return transientStatus.MatchString(msg) ||
+		strings.Contains(msg, "rate limit") ||
+		strings.Contains(msg, "overloaded")
+}
+
+// withRetry calls fn until it succeeds, fails permanently, or runs out of
+// attempts, doubling the delay between tries. It gives up early when the
+// context ends or fn reports that retrying is no longer safe.
+func withRetry(ctx context.Context, fn func() (retryable bool, err error)) error {
+	delay := retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		retryable, err := fn()
+		if err == nil || !retryable || !isTransient(err) || attempt >= maxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// providerChain returns the providers to try, in order
+func providerChain(opts Options) []Options {
+	chain := []Options{opts}
+	for _, fb := range opts.Fallback {
+		// Model and endpoint settings belong to the primary provider
+		o := opts
+		o.Provider = fb
+		o.Fallback = nil
+		o.Model = ""
+		o.BaseURL = ""
+		o.Headers = nil
+		o.Deployment = ""
+		o.APIVersion = ""
+		chain = append(chain, o)
+	}
+	return chain
+}
+
+// generateWithFallback asks each provider in the chain until one answers,
+// retrying transient failures, and returns the advice and who gave it
+func generateWithFallback(ctx context.Context, prompt string, opts Options) ([]Advice, Provider, error) {
+	streamed := false
+	if opts.Stream != nil {
+		onAdvice := opts.Stream
+		opts.Stream = func(a Advice) {
+			streamed = true
+			onAdvice(a)
+		}
+	}
+
+	var errs []error
+	for _, o := range providerChain(opts) {
+		provider, err := NewProvider(o)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", o.Provider, err))
+			continue
+		}
+
+		var advice []Advice
+		err = withRetry(ctx, func() (bool, error) {
+			if err := o.limiters[o.Provider].Wait(ctx); err != nil {
+				return false, err
+			}
+			var genErr error
+			advice, genErr = generateAdvice(ctx, provider, prompt, o)
+			// Retrying after items were shown would print them twice
+			return !streamed, genErr
+		})
+		if err == nil {
+			return advice, provider, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", o.Provider, err))
+		if streamed || ctx.Err() != nil {
+			break
+		}
+	}
+
+	if len(errs) == 1 {
+		return nil, nil, errors.Unwrap(errs[0])
+	}
+	return nil, nil, errors.Join(errs...)
+}
+
+// chatWithFallback sends history to each provider in the chain that can
+// chat until one answers, retrying transient failures
+func chatWithFallback(ctx context.Context, history []Message, opts Options) (string, Provider, error) {
+	var errs []error
+	for _, o := range providerChain(opts) {
+		provider, err := NewProvider(o)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", o.Provider, err))
+			continue
+		}
+		cp, ok := provider.(ChatProvider)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: provider does not support chat", o.Provider))
+			continue
+		}
+
+		var reply string
+		err = withRetry(ctx, func() (bool, error) {
+			if err := o.limiters[o.Provider].Wait(ctx); err != nil {
+				return false, err
+			}
+			var chatErr error
+			reply, chatErr = cp.Chat(ctx, history, nil)
+			return true, chatErr
+		})
+		if err == nil {
+			return reply, provider, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", o.Provider, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if len(errs) == 1 {
+		return "", nil, errors.Unwrap(errs[0])
+	}
+	return "", nil, errors.Join(errs...)
+}
+
+// ParseProviders splits a comma-separated provider list such as
+// "openai,anthropic" into the primary provider and its fallbacks
+func ParseProviders(s string) (primary ProviderType, fallback []ProviderType) {
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if primary == "" {
+			primary = ProviderType(p)
+			continue
+		}
+		fallback = append(fallback, ProviderType(p))
+	}
+	return primary, fallback
+}
+
+// requestsPerMinute are conservative rate limits for each provider, low
+// enough for entry-level API accounts. Local servers have none.
+var requestsPerMinute = map[ProviderType]int{
+	ProviderOpenAI:    500,
+	ProviderAnthropic: 50,
+}
+
+// rateLimit returns the requests per minute to stay under, 0 for none
+func (o Options) rateLimit() int {
+	switch {
+	case o.RequestsPerMinute < 0:
+		return 0
+	case o.RequestsPerMinute > 0:
+		return o.RequestsPerMinute
+	default:
+		return requestsPerMinute[o.Provider]
+	}
+}
+
+// newRateLimiters returns a limiter for each provider of opts' chain, at
+// its own rate: requests_per_minute when set, else the provider's default
+func newRateLimiters(opts Options) map[ProviderType]*rateLimiter {
+	limiters := make(map[ProviderType]*rateLimiter)
+	for _, p := range append([]ProviderType{opts.Provider}, opts.Fallback...) {
+		if _, ok := limiters[p]; !ok {
+			limiters[p] = newRateLimiter(Options{Provider: p, RequestsPerMinute: opts.RequestsPerMinute}.rateLimit())
+		}
+	}
+	return limiters
+}
+
+// rateLimiter spaces out the start of requests to stay under a
+// requests-per-minute limit. A nil limiter never waits.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(rpm int) *rateLimiter {
+	if rpm <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Minute / time.Duration(rpm)}
+}
+
+// Wait blocks until the next request may start or ctx ends
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	start := time.Now()
+	if l.next.After(start) {
+		start = l.next
+	}
+	l.next = start.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(start)
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
@@ -0,0 +1,182 @@
+package llmadvice
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+)
+
+// fastRetries shortens the backoff for the duration of the test
+func fastRetries(t *testing.T) {
+	t.Helper()
+	prev := retryBaseDelay
+	retryBaseDelay = time.Millisecond
+	t.Cleanup(func() { retryBaseDelay = prev })
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("API returned unexpected status code: 429"), true},
+		{errors.New("status code 503: Service Unavailable"), true},
+		{errors.New("overloaded_error: Overloaded"), true},
+		{errors.New("Rate limit reached for gpt-4o-mini"), true},
+		{errors.New("status code 401: invalid api key"), false},
+		{context.Canceled, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, isTransient(tt.err), "%v", tt.err)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	fastRetries(t)
+	ctx := context.Background()
+
+	calls := 0
+	err := withRetry(ctx, func() (bool, error) {
+		calls++
+		if calls < 2 {
+			return true, errors.New("status code 429")
+		}
+		return true, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	err = withRetry(ctx, func() (bool, error) {
+		calls++
+		return true, errors.New("status code 500")
+	})
+	require.Error(t, err)
+	assert.Equal(t, maxAttempts, calls, "bounded attempts")
+
+	calls = 0
+	_ = withRetry(ctx, func() (bool, error) {
+		calls++
+		return true, errors.New("status code 401")
+	})
+	assert.Equal(t, 1, calls, "permanent errors are not retried")
+
+	calls = 0
+	_ = withRetry(ctx, func() (bool, error) {
+		calls++
+		return false, errors.New("status code 503")
+	})
+	assert.Equal(t, 1, calls, "not retried once unsafe")
+}
+
+func TestParseProviders(t *testing.T) {
+	primary, fallback := ParseProviders("openai")
+	assert.Equal(t, ProviderOpenAI, primary)
+	assert.Empty(t, fallback)
+
+	primary, fallback = ParseProviders("openai, anthropic,ollama")
+	assert.Equal(t, ProviderOpenAI, primary)
+	assert.Equal(t, []ProviderType{ProviderAnthropic, ProviderOllama}, fallback)
+
+	assert.Error(t, Options{Provider: ProviderOpenAI, Fallback: []ProviderType{"gemini"}}.Validate())
+}
+
+func TestGetLLMAdvice_RetriesThenFallsBack(t *testing.T) {
+	fastRetries(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_BASE_URL", "")
+	t.Setenv("OPENAI_MODEL", "")
+	t.Setenv("OLLAMA_MODEL", "")
+
+	var openAICalls atomic.Int32
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		openAICalls.Add(1)
+		http.Error(w, `{"error":{"message":"overloaded"}}`, http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	ollama := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"message":{"role":"assistant","content":"- Push your work"},"done":true}`+"\n")
+	}))
+	defer ollama.Close()
+	t.Setenv("OLLAMA_HOST", ollama.URL)
+
+	info := &analyzer.RepoInfo{Path: "/repos/a"}
+	opts := Options{Provider: ProviderOpenAI, Fallback: []ProviderType{ProviderOllama}, BaseURL: failing.URL}
+
+	advice, err := GetLLMAdvice(info, nil, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Push your work"}, Texts(advice))
+	assert.GreaterOrEqual(t, int(openAICalls.Load()), maxAttempts, "primary retried before falling back")
+
+	entry, err := ReadCache(info, opts)
+	require.NoError(t, err, "cached under the requested model")
+	assert.Equal(t, "ollama", entry.Provider, "records who answered")
+	assert.Equal(t, ollamaModel, entry.Model)
+}
+
+func TestGetLLMAdvice_AllProvidersFail(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("OPENAI_BASE_URL", "")
+	t.Setenv("ANTHROPIC_API_KEY", "")
+
+	_, err := GetLLMAdvice(&analyzer.RepoInfo{}, nil, Options{
+		Provider: ProviderOpenAI,
+		Fallback: []ProviderType{ProviderAnthropic},
+		NoCache:  true,
+	})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrNoAPIKey)
+	assert.Contains(t, err.Error(), "openai: ")
+	assert.Contains(t, err.Error(), "anthropic: ")
+}
+
+func TestOptionsRateLimit(t *testing.T) {
+	assert.Equal(t, 50, Options{Provider: ProviderAnthropic}.rateLimit())
+	assert.Equal(t, 0, Options{Provider: ProviderOllama}.rateLimit(), "local servers have no limit")
+	assert.Equal(t, 10, Options{Provider: ProviderAnthropic, RequestsPerMinute: 10}.rateLimit())
+	assert.Equal(t, 0, Options{Provider: ProviderOpenAI, RequestsPerMinute: -1}.rateLimit())
+}
+
+func TestNewRateLimiters(t *testing.T) {
+	limiters := newRateLimiters(Options{Provider: ProviderOpenAI, Fallback: []ProviderType{ProviderAnthropic, ProviderOllama}})
+	require.Len(t, limiters, 3)
+	assert.Equal(t, time.Minute/500, limiters[ProviderOpenAI].interval)
+	assert.Equal(t, time.Minute/50, limiters[ProviderAnthropic].interval, "fallbacks keep their own rate")
+	assert.Nil(t, limiters[ProviderOllama])
+
+	limiters = newRateLimiters(Options{Provider: ProviderOpenAI, Fallback: []ProviderType{ProviderAnthropic}, RequestsPerMinute: 10})
+	assert.Equal(t, time.Minute/10, limiters[ProviderOpenAI].interval)
+	assert.Equal(t, time.Minute/10, limiters[ProviderAnthropic].interval)
+}
+
+func TestRateLimiter(t *testing.T) {
+	assert.NoError(t, newRateLimiter(0).Wait(context.Background()), "nil limiter never waits")
+
+	l := newRateLimiter(60 * 50) // One request every 20ms
+	start := time.Now()
+	for range 3 {
+		require.NoError(t, l.Wait(context.Background()))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond, "the first starts at once, the others are spaced out")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l = newRateLimiter(1)
+	require.NoError(t, l.Wait(ctx), "nothing to wait for yet")
+	assert.ErrorIs(t, l.Wait(ctx), context.Canceled)
+}
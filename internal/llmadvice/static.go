@@ -0,0 +1,222 @@
+package llmadvice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const staticModel = "canned"
+
+// StaticProvider answers without a model or network access. Its advice is
+// the rule-based advice already in the prompt, so the same state always gets
+// the same answer. Meant for tests, demos and air-gapped machines.
+type StaticProvider struct{}
+
+// NewStaticProvider creates a new static provider
+func NewStaticProvider() *StaticProvider {
+	return &StaticProvider{}
+}
+
+func (p *StaticProvider) Name() string {
+	return string(ProviderStatic)
+}
+
+func (p *StaticProvider) Model() string {
+	return staticModel
+}
+
+func (p *StaticProvider) GenerateAdvice(ctx context.Context, prompt string) ([]Advice, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	switch {
+	case strings.HasPrefix(prompt, forkPrompt):
+		return staticForkAdvice(prompt), nil
+	case strings.HasPrefix(prompt, mergePrompt):
+		return staticMergedAdvice(prompt), nil
+	}
+	return staticAdvice(prompt), nil
+}
+
+func (p *StaticProvider) Chat(ctx context.Context, history []Message, onText func(string)) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	var reply string
+	switch {
+	case len(history) > 0 && strings.HasPrefix(history[0].Content, commitPrompt):
+		reply = staticCommitMessage(history[0].Content)
+	case len(history) > 0 && strings.HasPrefix(history[0].Content, digestPrompt):
+		reply = staticDigest(history[0].Content)
+	default:
+		reply = "The static provider can't answer questions; pick a real provider with --llm-provider."
+	}
+	if onText != nil {
+		onText(reply)
+	}
+	return reply, nil
+}
+
+// staticAdvice turns the basic advice listed in a repo prompt into advice
+// items, prefixed with the repo name when the prompt covers several repos
+func staticAdvice(prompt string) []Advice {
+	var advice []Advice
+	repo := ""
+	inAdvice := false
+	for _, line := range strings.Split(prompt, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "--- ") && strings.HasSuffix(trimmed, " ---"):
+			header := strings.TrimSuffix(strings.TrimPrefix(trimmed, "--- "), " ---")
+			if _, name, ok := strings.Cut(header, ": "); ok {
+				header = name
+			}
+			repo = header
+			inAdvice = false
+		case strings.HasPrefix(trimmed, "Basic Advice"):
+			inAdvice = true
+			// Redacted prompts only count the advice, on the same line
+			if _, count, _ := strings.Cut(trimmed, ": "); strings.HasSuffix(count, "text redacted") {
+				if repo != "" {
+					count = repo + ": " + count
+				}
+				advice = append(advice, Advice{Text: count, Severity: SeverityInfo})
+			}
+		case inAdvice && strings.HasPrefix(trimmed, "- "):
+			text := strings.TrimPrefix(trimmed, "- ")
+			if repo != "" {
+				text = repo + ": " + text
+			}
+			advice = append(advice, Advice{Text: text, Severity: SeverityInfo})
+		default:
+			inAdvice = false
+		}
+	}
+
+	if len(advice) == 0 {
+		return []Advice{{Text: "All good", Severity: SeverityInfo}}
+	}
+	if len(advice) > 5 {
+		advice = advice[:5]
+	}
+	return advice
+}
+
+// staticMergedAdvice keeps the first items of the groups in a merge prompt
+func staticMergedAdvice(prompt string) []Advice {
+	var advice []Advice
+	for _, line := range strings.Split(prompt, "\n") {
+		rest, ok := strings.CutPrefix(line, "- [")
+		if !ok {
+			continue
+		}
+		severity, text, ok := strings.Cut(rest, "] ")
+		if !ok {
+			continue
+		}
+		text, command, _ := strings.Cut(text, " (command: ")
+		advice = append(advice, Advice{Text: text, Severity: severity, Command: strings.TrimSuffix(command, ")")})
+	}
+	if len(advice) == 0 {
+		return []Advice{{Text: "All good", Severity: SeverityInfo}}
+	}
+	if len(advice) > 5 {
+		advice = advice[:5]
+	}
+	return advice
+}
+
+// staticForkAdvice counts the forks of a fork triage prompt by category,
+// leaving adopted ones alone
+func staticForkAdvice(prompt string) []Advice {
+	byCategory := make(map[string][]string)
+	fork, category := "", ""
+	for _, line := range strings.Split(prompt, "\n") {
+		switch {
+		case strings.HasPrefix(line, "--- ") && strings.HasSuffix(line, " ---"):
+			fork = strings.TrimSuffix(strings.TrimPrefix(line, "--- "), " ---")
+			fork, _, _ = strings.Cut(fork, " (fork of ")
+		case strings.HasPrefix(line, "Category: "):
+			category = strings.TrimPrefix(line, "Category: ")
+			byCategory[category] = append(byCategory[category], fork)
+		case strings.HasPrefix(line, "Adopted: "):
+			byCategory[category] = byCategory[category][:len(byCategory[category])-1]
+		}
+	}
+
+	var advice []Advice
+	if untouched := byCategory["untouched"]; len(untouched) > 0 {
+		advice = append(advice, Advice{
+			Text:     fmt.Sprintf("Delete %d untouched fork(s): %s", len(untouched), strings.Join(untouched, ", ")),
+			Severity: SeverityInfo,
+		})
+	}
+	if maintained := byCategory["maintained"]; len(maintained) > 0 {
+		advice = append(advice, Advice{
+			Text:     fmt.Sprintf("Keep %d maintained fork(s) in sync: %s", len(maintained), strings.Join(maintained, ", ")),
+			Severity: SeverityWarning,
+		})
+	}
+	if len(advice) == 0 {
+		return []Advice{{Text: "All good", Severity: SeverityInfo}}
+	}
+	return advice
+}
+
+// staticDigest lists the metrics that changed in each repo of a digest
+// prompt
+func staticDigest(prompt string) string {
+	var sb strings.Builder
+	sb.WriteString("## Digest\n\n")
+	repo := ""
+	var changes []string
+	flush := func() {
+		if repo == "" {
+			return
+		}
+		if len(changes) == 0 {
+			changes = []string{"no change"}
+		}
+		fmt.Fprintf(&sb, "- %s: %s\n", repo, strings.Join(changes, ", "))
+	}
+	for _, line := range strings.Split(prompt, "\n") {
+		switch {
+		case strings.HasPrefix(line, "--- ") && strings.HasSuffix(line, " ---"):
+			flush()
+			repo, changes = strings.TrimSuffix(strings.TrimPrefix(line, "--- "), " ---"), nil
+		case repo != "" && strings.HasPrefix(line, "  "):
+			metric, values, _ := strings.Cut(strings.TrimSpace(line), ": ")
+			if from, to, _ := strings.Cut(values, " → "); from != to {
+				changes = append(changes, metric+" "+values)
+			}
+		}
+	}
+	flush()
+	return strings.TrimSpace(sb.String())
+}
+
+// staticCommitMessage names the staged files of a commit prompt
+func staticCommitMessage(prompt string) string {
+	var files []string
+	inFiles := false
+	for _, line := range strings.Split(prompt, "\n") {
+		switch {
+		case strings.HasPrefix(line, "Staged: "):
+			inFiles = true
+		case inFiles && strings.HasPrefix(line, "  "):
+			files = append(files, strings.TrimSpace(line))
+		default:
+			inFiles = false
+		}
+	}
+
+	switch {
+	case len(files) == 0:
+		return "Update files"
+	case len(files) <= 3:
+		return "Update " + strings.Join(files, ", ")
+	default:
+		return fmt.Sprintf("Update %d files", len(files))
+	}
+}
@@ -0,0 +1,79 @@
+package llmadvice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+)
+
+func TestGetLLMAdvice_Static(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	info := &analyzer.RepoInfo{Name: "a", Path: "/repos/a", CurrentBranch: "main"}
+	opts := Options{Provider: ProviderStatic, NoCache: true}
+
+	advice, err := GetLLMAdvice(info, []string{"Push 2 commits", "Drop old stash"}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Push 2 commits", "Drop old stash"}, Texts(advice))
+
+	advice, err = GetLLMAdvice(info, nil, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"All good"}, Texts(advice))
+}
+
+func TestGetMultiRepoLLMAdvice_Static(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	repos := []*analyzer.RepoInfo{{Name: "a", Path: "/repos/a"}, {Name: "b", Path: "/repos/b"}}
+	basic := func(info *analyzer.RepoInfo) []string {
+		if info.Name == "a" {
+			return []string{"Push 2 commits"}
+		}
+		return nil
+	}
+
+	summary, _, err := GetMultiRepoLLMAdvice(repos, basic, Options{Provider: ProviderStatic, NoCache: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a: Push 2 commits"}, Texts(summary))
+
+	summary, _, err = GetMultiRepoLLMAdvice(repos, basic, Options{Provider: ProviderStatic, NoCache: true, Redact: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Repository 1: 1 item(s), text redacted"}, Texts(summary))
+}
+
+func TestStaticProvider_Forks(t *testing.T) {
+	advice, err := NewStaticProvider().GenerateAdvice(context.Background(), FormatForkPrompt(testForks, Options{}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"Delete 1 untouched fork(s): me/old",
+		"Keep 1 maintained fork(s) in sync: me/tool",
+	}, Texts(advice))
+}
+
+func TestStaticProvider_CommitMessage(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	info := &analyzer.RepoInfo{DirtyDetails: &analyzer.DirtyDetails{StagedFiles: 2, StagedNames: []string{"a.go", "b.go"}}}
+	message, err := SuggestCommitMessage(info, "diff", Options{Provider: ProviderStatic, NoCache: true})
+	require.NoError(t, err)
+	assert.Equal(t, "Update a.go, b.go", message)
+}
+
+func TestStaticProvider_Chat(t *testing.T) {
+	var streamed string
+	reply, err := NewStaticProvider().Chat(context.Background(), []Message{{Role: RoleUser, Content: "why?"}}, func(s string) { streamed += s })
+	require.NoError(t, err)
+	assert.Contains(t, reply, "--llm-provider")
+	assert.Equal(t, reply, streamed)
+}
+
+func TestConfigOptions_ProviderEnv(t *testing.T) {
+	t.Setenv(ProviderEnv, "static")
+	opts := Config{Provider: "openai,anthropic"}.Options()
+	assert.Equal(t, ProviderStatic, opts.Provider, "the environment overrides llm.toml")
+	assert.Empty(t, opts.Fallback)
+}
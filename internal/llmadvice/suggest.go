@@ -0,0 +1,150 @@
+package llmadvice
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+)
+
+const commitPrompt = `Write a git commit message for the staged changes below.
+
+Rules:
+- Subject: imperative mood, under 60 characters, no trailing period
+- Then a blank line and a short body only if the change needs explaining, wrapped at 72
+- Describe what changed and why, not how the diff looks
+- Output only the message: no quotes, code fences or commentary
+`
+
+// maxDiffBytes bounds how much of the staged diff goes into the prompt.
+// The file list and counts still describe the rest.
+const maxDiffBytes = 12000
+
+var (
+	ErrNothingStaged = errors.New("nothing is staged")
+	ErrDiffRedacted  = errors.New("commit suggestions need the diff, which redaction keeps from the LLM")
+)
+
+// FormatCommitPrompt describes the staged changes of a repo for a commit
+// message suggestion
+func FormatCommitPrompt(info *analyzer.RepoInfo, diff string, opts Options) string {
+	var sb strings.Builder
+
+	sb.WriteString(commitPrompt)
+
+	if opts.Instructions != "" {
+		sb.WriteString("\nAdditional instructions: ")
+		sb.WriteString(opts.Instructions)
+		sb.WriteString("\n")
+	}
+
+	fmt.Fprintf(&sb, "\n\nBranch: %s\n", info.CurrentBranch)
+	if d := info.DirtyDetails; d != nil {
+		fmt.Fprintf(&sb, "Staged: %d file(s), +%d/-%d\n", d.StagedFiles, d.StagedInsertions, d.StagedDeletions)
+		for _, name := range d.StagedNames {
+			fmt.Fprintf(&sb, "  %s\n", name)
+		}
+	}
+
+	if len(diff) > maxDiffBytes {
+		diff = diff[:maxDiffBytes] + "\n[diff truncated]\n"
+	}
+	sb.WriteString("\nDiff:\n")
+	sb.WriteString(diff)
+
+	return sb.String()
+}
+
+// SuggestCommitMessage proposes a commit message for the changes staged in
+// info, given their diff. Suggestions are cached by the diff, so asking again
+// without changing what is staged costs nothing.
+func SuggestCommitMessage(info *analyzer.RepoInfo, diff string, opts Options) (string, error) {
+	if opts.Redact {
+		return "", ErrDiffRedacted
+	}
+	if info.DirtyDetails == nil || info.DirtyDetails.StagedFiles == 0 || strings.TrimSpace(diff) == "" {
+		return "", ErrNothingStaged
+	}
+
+	stateHash := computeCommitHash(info, diff, opts)
+	if !opts.NoCache {
+		if cached, err := readCacheByHash(stateHash); err == nil && !cached.Expired(opts.CacheTTL) && cached.Message != "" {
+			return cached.Message, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	history := []Message{{Role: RoleUser, Content: FormatCommitPrompt(info, diff, opts)}}
+	reply, provider, err := chatWithFallback(ctx, history, opts)
+	if err != nil {
+		return "", err
+	}
+	message := cleanCommitMessage(reply)
+	if message == "" {
+		return "", fmt.Errorf("%w: empty commit message", ErrAPIError)
+	}
+
+	if !opts.NoCache {
+		_ = writeCacheEntry(CacheEntry{
+			StateHash: stateHash,
+			CreatedAt: time.Now(),
+			Provider:  provider.Name(),
+			Model:     provider.Model(),
+			Message:   message,
+			Repos:     []string{info.Path},
+		})
+		_, _ = PruneCache(opts.CacheLimits())
+	}
+
+	return message, nil
+}
+
+// computeCommitHash identifies a commit suggestion by the staged diff and
+// the options that affect the message
+func computeCommitHash(info *analyzer.RepoInfo, diff string, opts Options) string {
+	diffHash := sha256.Sum256([]byte(diff))
+	key := struct {
+		Kind         string
+		Path         string
+		Branch       string
+		Diff         string
+		Instructions string
+		Model        string
+	}{"commit", info.Path, info.CurrentBranch, hex.EncodeToString(diffHash[:]), opts.Instructions, ResolveModel(opts)}
+
+	data, _ := json.Marshal(key)
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// cleanCommitMessage strips the wrapping models add despite being asked
+// not to: code fences, surrounding quotes and blank lines
+func cleanCommitMessage(reply string) string {
+	msg := stripFences(reply)
+	if len(msg) >= 2 && msg[0] == '"' && msg[len(msg)-1] == '"' {
+		msg = msg[1 : len(msg)-1]
+	}
+	return strings.TrimSpace(msg)
+}
+
+// stripFences strips the code fence a reply is wrapped in, if any, and the
+// blank lines around it
+func stripFences(reply string) string {
+	msg := strings.TrimSpace(reply)
+	if strings.HasPrefix(msg, "```") {
+		msg = strings.TrimPrefix(msg, "```")
+		if i := strings.IndexByte(msg, '\n'); i != -1 {
+			msg = msg[i+1:] // Drop the fence's language tag
+		}
+		msg = strings.TrimSuffix(strings.TrimSpace(msg), "```")
+	}
+	return strings.TrimSpace(msg)
+}
@@ -0,0 +1,92 @@
+package llmadvice
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+)
+
+func TestCleanCommitMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    string
+		expected string
+	}{
+		{"plain", "Fix typo in README\n", "Fix typo in README"},
+		{"quoted", `"Fix typo in README"`, "Fix typo in README"},
+		{"fenced", "```text\nAdd retry to fetch\n\nServers drop idle connections.\n```", "Add retry to fetch\n\nServers drop idle connections."},
+		{"bare fence", "```\nAdd retry to fetch\n```", "Add retry to fetch"},
+		{"empty", "  \n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, cleanCommitMessage(tt.reply))
+		})
+	}
+}
+
+func TestFormatCommitPrompt(t *testing.T) {
+	info := &analyzer.RepoInfo{
+		CurrentBranch: "feature-x",
+		DirtyDetails: &analyzer.DirtyDetails{
+			StagedFiles:      1,
+			StagedNames:      []string{"main.go"},
+			StagedInsertions: 3,
+		},
+	}
+	prompt := FormatCommitPrompt(info, strings.Repeat("+", maxDiffBytes+10), Options{Instructions: "use conventional commits"})
+
+	assert.Contains(t, prompt, "Branch: feature-x")
+	assert.Contains(t, prompt, "Staged: 1 file(s), +3/-0")
+	assert.Contains(t, prompt, "  main.go\n")
+	assert.Contains(t, prompt, "Additional instructions: use conventional commits")
+	assert.Contains(t, prompt, "[diff truncated]")
+	assert.NotContains(t, prompt, strings.Repeat("+", maxDiffBytes+1))
+}
+
+func TestSuggestCommitMessage(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("OLLAMA_MODEL", "")
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = io.WriteString(w, `{"message":{"role":"assistant","content":"\"Add retry to fetch\""},"done":true}`+"\n")
+	}))
+	defer server.Close()
+
+	info := &analyzer.RepoInfo{Path: "/repos/a", DirtyDetails: &analyzer.DirtyDetails{StagedFiles: 1}}
+	opts := Options{Provider: ProviderOllama, BaseURL: server.URL}
+	diff := "diff --git a/fetch.go b/fetch.go\n+retry()\n"
+
+	message, err := SuggestCommitMessage(info, diff, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Add retry to fetch", message)
+
+	message, err = SuggestCommitMessage(info, diff, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Add retry to fetch", message)
+	assert.Equal(t, int32(1), calls.Load(), "the same staged diff is answered from the cache")
+
+	_, err = SuggestCommitMessage(info, diff+"+more()\n", opts)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load(), "a different diff asks again")
+}
+
+func TestSuggestCommitMessage_Errors(t *testing.T) {
+	staged := &analyzer.RepoInfo{DirtyDetails: &analyzer.DirtyDetails{StagedFiles: 1}}
+
+	_, err := SuggestCommitMessage(&analyzer.RepoInfo{}, "", Options{})
+	assert.ErrorIs(t, err, ErrNothingStaged)
+
+	_, err = SuggestCommitMessage(staged, "diff", Options{Redact: true})
+	assert.ErrorIs(t, err, ErrDiffRedacted)
+}
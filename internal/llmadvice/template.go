@@ -0,0 +1,129 @@
+package llmadvice
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+)
+
+// Templates replace the built-in prompt text with user-supplied Go
+// templates. A nil template keeps the built-in text.
+type Templates struct {
+	System *template.Template // Instructions at the top of the prompt, executed with PromptData
+	Repo   *template.Template // Description of each repo, executed with its *analyzer.RepoInfo
+
+	hash string // Identifies the template sources in the cache key
+}
+
+// PromptData is what the system prompt template is executed with.
+// Custom instructions are still appended after it.
+type PromptData struct {
+	Repos []*analyzer.RepoInfo // Nil when the prompt is redacted
+}
+
+// templateFuncs are the helpers available to prompt templates
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"files": func(files []string) string { return formatFileList(files, 5) },
+}
+
+// LoadTemplates reads and parses the system and repo template files.
+// Empty paths keep the built-in text; both empty returns nil.
+func LoadTemplates(systemPath, repoPath string) (*Templates, error) {
+	if systemPath == "" && repoPath == "" {
+		return nil, nil
+	}
+
+	t := &Templates{}
+	sum := sha256.New()
+	for _, f := range []struct {
+		path string
+		dst  **template.Template
+		data any
+	}{
+		{systemPath, &t.System, PromptData{Repos: []*analyzer.RepoInfo{sampleRepo()}}},
+		{repoPath, &t.Repo, sampleRepo()},
+	} {
+		if f.path == "" {
+			continue
+		}
+		src, err := os.ReadFile(f.path) //nolint:gosec // the user points us at their own template
+		if err != nil {
+			return nil, fmt.Errorf("reading prompt template: %w", err)
+		}
+		tmpl, err := template.New(filepath.Base(f.path)).Funcs(templateFuncs).Parse(string(src))
+		if err != nil {
+			return nil, fmt.Errorf("parsing prompt template: %w", err)
+		}
+		// Catch misspelled fields now rather than on the first LLM call
+		if err := tmpl.Execute(&strings.Builder{}, f.data); err != nil {
+			return nil, fmt.Errorf("checking prompt template: %w", err)
+		}
+		*f.dst = tmpl
+		sum.Write(src)
+	}
+	t.hash = hex.EncodeToString(sum.Sum(nil))
+	return t, nil
+}
+
+// sampleRepo has every optional section filled in, so checking a template
+// against it exercises the fields a real repo may have
+func sampleRepo() *analyzer.RepoInfo {
+	return &analyzer.RepoInfo{
+		Name:         "sample",
+		IsGitRepo:    true,
+		Commits:      &analyzer.CommitStats{},
+		DirtyDetails: &analyzer.DirtyDetails{},
+		Operation:    &analyzer.OperationDetails{},
+		DiskUsage:    &analyzer.DiskUsage{},
+		Fingerprint:  &analyzer.Fingerprint{},
+	}
+}
+
+// Hash identifies the template sources, so changing a template doesn't
+// serve advice produced with the old one
+func (t *Templates) Hash() string {
+	if t == nil {
+		return ""
+	}
+	return t.hash
+}
+
+// systemText returns the instructions that open a prompt: the system
+// template if there is one, else def
+func (t *Templates) systemText(def string, repos []*analyzer.RepoInfo, redact bool) string {
+	if t == nil || t.System == nil {
+		return def
+	}
+	data := PromptData{Repos: repos}
+	if redact {
+		data.Repos = nil
+	}
+	var sb strings.Builder
+	if err := t.System.Execute(&sb, data); err != nil {
+		// Templates are checked when loaded; a repo that still trips one up
+		// gets the built-in prompt rather than no advice
+		return def
+	}
+	return sb.String()
+}
+
+// repoText describes one repo with the repo template if there is one.
+// Redacted prompts always use the built-in description, which is the one
+// known to leave names out.
+func (t *Templates) repoText(info *analyzer.RepoInfo, redact bool) string {
+	if t == nil || t.Repo == nil || redact {
+		return formatRepoState(info, redact)
+	}
+	var sb strings.Builder
+	if err := t.Repo.Execute(&sb, info); err != nil {
+		return formatRepoState(info, redact)
+	}
+	return sb.String()
+}
@@ -0,0 +1,107 @@
+package llmadvice
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+)
+
+func writeTemplate(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadTemplates(t *testing.T) {
+	dir := t.TempDir()
+
+	tmpl, err := LoadTemplates("", "")
+	require.NoError(t, err)
+	assert.Nil(t, tmpl, "no files keeps the built-in prompt")
+
+	_, err = LoadTemplates(filepath.Join(dir, "missing.tmpl"), "")
+	assert.ErrorContains(t, err, "reading prompt template")
+
+	_, err = LoadTemplates(writeTemplate(t, dir, "bad.tmpl", "{{.Name"), "")
+	assert.ErrorContains(t, err, "parsing prompt template")
+
+	_, err = LoadTemplates("", writeTemplate(t, dir, "typo.tmpl", "{{.Nmae}}"))
+	assert.ErrorContains(t, err, "checking prompt template", "misspelled fields fail at load time")
+
+	a, err := LoadTemplates(writeTemplate(t, dir, "a.tmpl", "Be terse."), "")
+	require.NoError(t, err)
+	b, err := LoadTemplates(writeTemplate(t, dir, "b.tmpl", "Be chatty."), "")
+	require.NoError(t, err)
+	assert.NotEqual(t, a.Hash(), b.Hash())
+}
+
+func TestFormatPrompt_Templates(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, err := LoadTemplates(
+		writeTemplate(t, dir, "system.tmpl", "Advise on {{len .Repos}} repo(s).{{range .Repos}} {{.Name}}{{end}}"),
+		writeTemplate(t, dir, "repo.tmpl", "{{.Name}} on {{.CurrentBranch}}, {{.Ahead}} ahead{{with .DirtyDetails}}, dirty: {{files .UnstagedNames}}{{end}}\n"),
+	)
+	require.NoError(t, err)
+
+	info := &analyzer.RepoInfo{
+		Name:          "my-project",
+		CurrentBranch: "main",
+		Ahead:         2,
+		DirtyDetails:  &analyzer.DirtyDetails{UnstagedNames: []string{"a.go", "b.go"}},
+	}
+	opts := Options{Templates: tmpl, Instructions: "be brief"}
+
+	prompt := FormatSingleRepoPrompt(info, nil, opts)
+	assert.Contains(t, prompt, "Advise on 1 repo(s). my-project")
+	assert.Contains(t, prompt, "my-project on main, 2 ahead, dirty: a.go, b.go")
+	assert.Contains(t, prompt, "Additional instructions: be brief", "instructions are still appended")
+	assert.NotContains(t, prompt, "Git advisor", "the built-in system prompt is replaced")
+
+	multi := FormatMultiRepoPrompt([]*analyzer.RepoInfo{info, {Name: "other"}}, nil, opts)
+	assert.Contains(t, multi, "Advise on 2 repo(s). my-project other")
+
+	opts.Redact = true
+	redacted := FormatSingleRepoPrompt(info, nil, opts)
+	assert.Contains(t, redacted, "Advise on 0 repo(s).", "templates see no repos when redacting")
+	assert.NotContains(t, redacted, "my-project")
+	assert.Contains(t, redacted, "Unpushed Commits: 2", "the built-in redacted description is used")
+}
+
+func TestComputeStateHash_Templates(t *testing.T) {
+	tmpl, err := LoadTemplates(writeTemplate(t, t.TempDir(), "system.tmpl", "Be terse."), "")
+	require.NoError(t, err)
+
+	info := &analyzer.RepoInfo{Path: "/repos/a"}
+	assert.NotEqual(t, computeStateHash(info, Options{}), computeStateHash(info, Options{Templates: tmpl}))
+}
+
+func TestLoadConfigFile_Templates(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "explain.tmpl", "Custom prompt")
+	path := writeTemplate(t, dir, "llm.toml", `prompt = "explain.tmpl"`)
+
+	cfg, err := LoadConfigFile(path)
+	require.NoError(t, err)
+	opts := cfg.Options()
+	require.NotNil(t, opts.Templates, "relative paths are found next to llm.toml")
+	assert.Contains(t, FormatSingleRepoPrompt(&analyzer.RepoInfo{}, nil, opts), "Custom prompt")
+
+	_, err = LoadConfigFile(writeTemplate(t, dir, "broken.toml", `repo_template = "missing.tmpl"`))
+	assert.ErrorContains(t, err, "reading prompt template")
+}
+
+func TestResolvePath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	assert.Empty(t, resolvePath("", "/etc"))
+	assert.Equal(t, filepath.Join(home, "prompts/x.tmpl"), resolvePath("~/prompts/x.tmpl", "/etc"))
+	assert.Equal(t, "/etc/prompts/x.tmpl", resolvePath("prompts/x.tmpl", "/etc"))
+	assert.Equal(t, "/abs/x.tmpl", resolvePath("/abs/x.tmpl", "/etc"))
+}
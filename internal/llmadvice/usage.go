@@ -0,0 +1,212 @@
+package llmadvice
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Usage counts the tokens spent on LLM calls and what they cost
+type Usage struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost_usd"`       // Estimated, for calls with known pricing
+	Unpriced         int     `json:"unpriced_calls"` // Calls to models missing from the price table
+}
+
+// Add accumulates other into u
+func (u *Usage) Add(other Usage) {
+	u.Calls += other.Calls
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.Cost += other.Cost
+	u.Unpriced += other.Unpriced
+}
+
+// String summarizes the usage on one line
+func (u Usage) String() string {
+	calls := "calls"
+	if u.Calls == 1 {
+		calls = "call"
+	}
+	s := fmt.Sprintf("%d %s, %d prompt + %d completion tokens, ~$%.4f",
+		u.Calls, calls, u.PromptTokens, u.CompletionTokens, u.Cost)
+	if u.Unpriced > 0 {
+		s += fmt.Sprintf(" (%d without known pricing)", u.Unpriced)
+	}
+	return s
+}
+
+// price is the USD cost per million tokens
+type price struct {
+	prompt, completion float64
+}
+
+// modelPrices covers the default models and their common siblings. Model
+// names are matched by prefix so dated snapshots share their family's price.
+var modelPrices = map[ProviderType]map[string]price{
+	ProviderOpenAI: {
+		"gpt-4o-mini":  {0.15, 0.60},
+		"gpt-4o":       {2.50, 10},
+		"gpt-4.1-nano": {0.10, 0.40},
+		"gpt-4.1-mini": {0.40, 1.60},
+		"gpt-4.1":      {2, 8},
+	},
+	ProviderAnthropic: {
+		"claude-3-5-haiku":  {0.80, 4},
+		"claude-3-5-sonnet": {3, 15},
+		"claude-3-7-sonnet": {3, 15},
+	},
+}
+
+// lookupPrice finds the price of model, preferring the longest matching
+// prefix. Ollama runs locally and is always free.
+func lookupPrice(provider ProviderType, model string) (price, bool) {
+	if provider == ProviderOllama || provider == ProviderStatic {
+		return price{}, true
+	}
+	var best string
+	for name := range modelPrices[provider] {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return price{}, false
+	}
+	return modelPrices[provider][best], true
+}
+
+// newUsage prices one call's token counts
+func newUsage(provider ProviderType, model string, promptTokens, completionTokens int) Usage {
+	u := Usage{Calls: 1, PromptTokens: promptTokens, CompletionTokens: completionTokens}
+	p, ok := lookupPrice(provider, model)
+	if !ok {
+		u.Unpriced = 1
+		return u
+	}
+	u.Cost = (float64(promptTokens)*p.prompt + float64(completionTokens)*p.completion) / 1e6
+	return u
+}
+
+// tokenCounts reads the token counts out of a response's generation info.
+// OpenAI and Ollama report prompt/completion tokens, Anthropic input/output.
+func tokenCounts(info map[string]any) (prompt, completion int) {
+	prompt = intField(info, "PromptTokens", "InputTokens")
+	completion = intField(info, "CompletionTokens", "OutputTokens")
+	return prompt, completion
+}
+
+// intField returns the first of keys present in info as an int
+func intField(info map[string]any, keys ...string) int {
+	for _, k := range keys {
+		switch v := info[k].(type) {
+		case int:
+			return v
+		case int32:
+			return int(v)
+		case int64:
+			return int(v)
+		case float64:
+			return int(v)
+		}
+	}
+	return 0
+}
+
+// usageMonth is the key monthly totals are stored under
+func usageMonth(t time.Time) string {
+	return t.Format("2006-01")
+}
+
+// getUsagePath returns the file monthly totals are kept in. It sits next to
+// the advice cache rather than in it so clearing the cache keeps the totals.
+func getUsagePath() (string, error) {
+	cacheDir, err := getCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(cacheDir), "llm-usage.json"), nil
+}
+
+// LoadUsageHistory returns the usage totals by month ("2006-01")
+func LoadUsageHistory() (map[string]Usage, error) {
+	path, err := getUsagePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // path is built from the cache dir, not user input
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return map[string]Usage{}, nil
+		}
+		return nil, err
+	}
+	history := map[string]Usage{}
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return history, nil
+}
+
+// RecordUsage adds u to the total for now's month and returns the new total
+func RecordUsage(u Usage, now time.Time) (Usage, error) {
+	history, err := LoadUsageHistory()
+	if err != nil {
+		return Usage{}, err
+	}
+	month := usageMonth(now)
+	total := history[month]
+	total.Add(u)
+	history[month] = total
+
+	path, err := getUsagePath()
+	if err != nil {
+		return Usage{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return Usage{}, err
+	}
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return Usage{}, err
+	}
+	return total, os.WriteFile(path, data, 0o600)
+}
+
+// MonthlyUsage returns the usage total for t's month
+func MonthlyUsage(t time.Time) (Usage, error) {
+	history, err := LoadUsageHistory()
+	if err != nil {
+		return Usage{}, err
+	}
+	return history[usageMonth(t)], nil
+}
+
+// ReportUsage adds run to the monthly total and, when show is set, prints
+// both to w
+func ReportUsage(w io.Writer, run Usage, show bool) {
+	var month Usage
+	var err error
+	if run.Calls > 0 {
+		month, err = RecordUsage(run, time.Now())
+	} else {
+		month, err = MonthlyUsage(time.Now())
+	}
+	if !show {
+		return
+	}
+	fmt.Fprintf(w, "\nLLM usage: %s\n", run)
+	if err != nil {
+		fmt.Fprintf(w, "This month: unavailable (%v)\n", err)
+		return
+	}
+	fmt.Fprintf(w, "This month: %s\n", month)
+}
@@ -0,0 +1,118 @@
+package llmadvice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestNewUsage(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider ProviderType
+		model    string
+		cost     float64
+		unpriced int
+	}{
+		{"default openai model", ProviderOpenAI, "gpt-4o-mini", 0.00045, 0},
+		{"longest prefix wins", ProviderOpenAI, "gpt-4o-2024-08-06", 0.0075, 0},
+		{"dated anthropic snapshot", ProviderAnthropic, "claude-3-5-haiku-20241022", 0.0028, 0},
+		{"ollama is free", ProviderOllama, "llama3.2", 0, 0},
+		{"unknown model", ProviderOpenAI, "my-finetune", 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newUsage(tt.provider, tt.model, 1000, 500)
+			assert.Equal(t, 1, u.Calls)
+			assert.Equal(t, 1000, u.PromptTokens)
+			assert.Equal(t, 500, u.CompletionTokens)
+			assert.InDelta(t, tt.cost, u.Cost, 1e-9)
+			assert.Equal(t, tt.unpriced, u.Unpriced)
+		})
+	}
+}
+
+func TestTokenCounts(t *testing.T) {
+	prompt, completion := tokenCounts(map[string]any{"PromptTokens": 12, "CompletionTokens": int64(34)})
+	assert.Equal(t, 12, prompt)
+	assert.Equal(t, 34, completion)
+
+	prompt, completion = tokenCounts(map[string]any{"InputTokens": 56, "OutputTokens": 78})
+	assert.Equal(t, 56, prompt, "anthropic reports input tokens")
+	assert.Equal(t, 78, completion, "anthropic reports output tokens")
+
+	prompt, completion = tokenCounts(nil)
+	assert.Zero(t, prompt)
+	assert.Zero(t, completion)
+}
+
+func TestUsageString(t *testing.T) {
+	u := Usage{Calls: 1, PromptTokens: 100, CompletionTokens: 20, Cost: 0.00012}
+	assert.Equal(t, "1 call, 100 prompt + 20 completion tokens, ~$0.0001", u.String())
+
+	u.Add(Usage{Calls: 1, Unpriced: 1})
+	assert.Equal(t, "2 calls, 100 prompt + 20 completion tokens, ~$0.0001 (1 without known pricing)", u.String())
+}
+
+func TestRecordUsage(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	oct := time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC)
+	nov := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := RecordUsage(Usage{Calls: 1, PromptTokens: 10, Cost: 0.5}, oct)
+	require.NoError(t, err)
+	total, err := RecordUsage(Usage{Calls: 2, PromptTokens: 5, Cost: 0.25}, oct)
+	require.NoError(t, err)
+	assert.Equal(t, Usage{Calls: 3, PromptTokens: 15, Cost: 0.75}, total)
+
+	_, err = RecordUsage(Usage{Calls: 1}, nov)
+	require.NoError(t, err)
+
+	month, err := MonthlyUsage(oct)
+	require.NoError(t, err)
+	assert.Equal(t, total, month, "a new month starts its own total")
+
+	history, err := LoadUsageHistory()
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+
+	_, err = ClearCache()
+	require.NoError(t, err)
+	month, err = MonthlyUsage(oct)
+	require.NoError(t, err)
+	assert.Equal(t, 3, month.Calls, "clearing the advice cache keeps usage totals")
+}
+
+// usageModel is an llms.Model that answers with fixed token counts
+type usageModel struct {
+	info map[string]any
+}
+
+func (m *usageModel) GenerateContent(context.Context, []llms.MessageContent, ...llms.CallOption) (*llms.ContentResponse, error) {
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "1. All good", GenerationInfo: m.info}}}, nil
+}
+
+func (m *usageModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func TestGenerationReportsUsage(t *testing.T) {
+	model := &usageModel{info: map[string]any{"PromptTokens": 300, "CompletionTokens": 40}}
+
+	var got []int
+	g := newGeneration(Options{})
+	g.onUsage = func(prompt, completion int) { got = append(got, prompt, completion) }
+
+	_, err := g.generate(context.Background(), model, "prompt")
+	require.NoError(t, err)
+	_, err = g.stream(context.Background(), model, "prompt", func(Advice) {})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{300, 40, 300, 40}, got)
+}
@@ -0,0 +1,178 @@
+// Package notify sends summaries out of the terminal, to a webhook or by
+// email, for tools run by cron rather than by someone watching
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+)
+
+// Webhook and Email are the sinks a message can be sent to
+const (
+	Webhook = "webhook"
+	Email   = "email"
+)
+
+// Sinks lists every sink, as --notify takes them
+var Sinks = []string{Webhook, Email}
+
+// Config holds where notifications go, from the [notify] section of
+// config.toml. Every field is optional; only the sinks asked for need
+// theirs.
+type Config struct {
+	Webhook      string   `toml:"webhook"`       // URL to POST a JSON summary to; Slack incoming webhooks take it as is
+	SMTPServer   string   `toml:"smtp_server"`   // host:port of the mail server, with STARTTLS when it offers it
+	SMTPUser     string   `toml:"smtp_user"`     // Login on the mail server; none sends without one
+	SMTPPassword string   `toml:"smtp_password"` // Better set in GIT_THIS_BREAD_NOTIFY_SMTP_PASSWORD
+	From         string   `toml:"from"`          // Sender address (default smtp_user)
+	To           []string `toml:"to"`            // Recipient addresses
+}
+
+// Message is a notification: a subject line, a plain text body, and
+// details that webhook receivers other than chat rooms can use
+type Message struct {
+	Subject string `json:"subject"`
+	Text    string `json:"body"`
+	Details any    `json:"details,omitempty"`
+}
+
+// Check reports sinks that aren't known, or lack their settings
+func (c *Config) Check(sinks []string) error {
+	for _, sink := range sinks {
+		switch sink {
+		case Webhook:
+			if c.Webhook == "" {
+				return fmt.Errorf("--notify webhook needs a URL. Use: bread config set notify.webhook <url>")
+			}
+		case Email:
+			if c.SMTPServer == "" || len(c.To) == 0 {
+				return fmt.Errorf("--notify email needs a mail server and recipients. Use: bread config set notify.smtp_server <host:port>, and notify.to")
+			}
+			if c.From == "" && c.SMTPUser == "" {
+				return fmt.Errorf("--notify email needs a sender. Use: bread config set notify.from <address>")
+			}
+		default:
+			return fmt.Errorf("invalid --notify value %q: must be %s", sink, strings.Join(Sinks, ", "))
+		}
+	}
+	return nil
+}
+
+// Send sends msg to each of sinks, and returns the first failure after
+// trying every one
+func (c *Config) Send(ctx context.Context, sinks []string, msg Message) error {
+	var first error
+	for _, sink := range sinks {
+		var err error
+		switch sink {
+		case Webhook:
+			err = c.postWebhook(ctx, msg)
+		case Email:
+			err = c.sendEmail(msg)
+		}
+		if err != nil && first == nil {
+			first = fmt.Errorf("notifying by %s: %w", sink, err)
+		}
+	}
+	return first
+}
+
+// webhookTimeout bounds the webhook request, so a cron job never hangs on it
+const webhookTimeout = 30 * time.Second
+
+// postWebhook posts msg as JSON: its text field, subject and body
+// together, is what Slack and compatible chats show
+func (c *Config) postWebhook(ctx context.Context, msg Message) error {
+	payload := struct {
+		Text string `json:"text"`
+		Message
+	}{Text: msg.Subject + "\n\n" + msg.Text, Message: msg}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Webhook URLs carry their token in the path, so only the host is shown
+	client := &http.Client{Transport: debuglog.HostTransport(http.DefaultTransport)}
+	origin := req.URL.Scheme + "://" + req.URL.Host
+	resp, err := client.Do(req)
+	if err != nil {
+		// A *url.Error would show the whole URL
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("POST %s: %w", origin, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("POST %s: %s %s", origin, resp.Status, strings.TrimSpace(string(reply)))
+	}
+	return nil
+}
+
+// sendMail is smtp.SendMail, replaced in tests
+var sendMail = smtp.SendMail
+
+// sendEmail mails msg as plain text to every recipient
+func (c *Config) sendEmail(msg Message) error {
+	from := c.From
+	if from == "" {
+		from = c.SMTPUser
+	}
+	var auth smtp.Auth
+	if c.SMTPUser != "" {
+		host, _, err := net.SplitHostPort(c.SMTPServer)
+		if err != nil {
+			return fmt.Errorf("notify.smtp_server %q: %w", c.SMTPServer, err)
+		}
+		auth = smtp.PlainAuth("", c.SMTPUser, c.SMTPPassword, host)
+	}
+	return sendMail(c.SMTPServer, auth, from, c.To, mailBody(from, c.To, msg, time.Now()))
+}
+
+// mailBody formats msg as an RFC 5322 message
+func mailBody(from string, to []string, msg Message, now time.Time) []byte {
+	var b bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&b, "%s: %s\r\n", name, oneLine(value))
+	}
+	header("From", from)
+	header("To", strings.Join(to, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", oneLine(msg.Subject)))
+	header("Date", now.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Text, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+// oneLine joins the lines of a header value, since a line break in it
+// would start another header
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	var c Config
+	assert.NoError(t, c.Check(nil))
+	assert.ErrorContains(t, c.Check([]string{"pager"}), `invalid --notify value "pager"`)
+	assert.ErrorContains(t, c.Check([]string{Webhook}), "notify.webhook")
+	assert.ErrorContains(t, c.Check([]string{Email}), "notify.smtp_server")
+
+	c = Config{Webhook: "https://example.com/hook", SMTPServer: "smtp.example.com:587", To: []string{"me@example.com"}}
+	assert.ErrorContains(t, c.Check([]string{Email}), "notify.from")
+	c.From = "cron@example.com"
+	assert.NoError(t, c.Check(Sinks))
+}
+
+func TestSendWebhook(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer srv.Close()
+
+	c := Config{Webhook: srv.URL}
+	msg := Message{Subject: "2 repos need attention", Text: "api\nweb", Details: []string{"api", "web"}}
+	require.NoError(t, c.Send(context.Background(), []string{Webhook}, msg))
+	assert.Equal(t, "2 repos need attention\n\napi\nweb", got["text"])
+	assert.Equal(t, "2 repos need attention", got["subject"])
+	assert.Equal(t, "api\nweb", got["body"])
+	assert.Equal(t, []any{"api", "web"}, got["details"])
+}
+
+func TestSendWebhookFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "no_such_channel", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	c := Config{Webhook: srv.URL + "/services/T0/B0/token"}
+	err := c.Send(context.Background(), []string{Webhook}, Message{Subject: "s"})
+	assert.ErrorContains(t, err, "notifying by webhook")
+	assert.ErrorContains(t, err, "no_such_channel")
+	assert.ErrorContains(t, err, "POST "+srv.URL+":")
+	assert.NotContains(t, err.Error(), "token")
+}
+
+func TestSendWebhookUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	addr := srv.URL
+	srv.Close() // Nothing listens there anymore
+
+	c := Config{Webhook: addr + "/services/T0/B0/token"}
+	err := c.Send(context.Background(), []string{Webhook}, Message{Subject: "s"})
+	assert.ErrorContains(t, err, "POST "+addr+": ")
+	assert.NotContains(t, err.Error(), "token")
+}
+
+func TestSendEmail(t *testing.T) {
+	var addr, from string
+	var to []string
+	var body []byte
+	var auth smtp.Auth
+	orig := sendMail
+	sendMail = func(a string, au smtp.Auth, f string, t []string, msg []byte) error {
+		addr, auth, from, to, body = a, au, f, t, msg
+		return nil
+	}
+	t.Cleanup(func() { sendMail = orig })
+
+	c := Config{SMTPServer: "smtp.example.com:587", SMTPUser: "cron@example.com", SMTPPassword: "secret", To: []string{"me@example.com"}}
+	require.NoError(t, c.Send(context.Background(), []string{Email}, Message{Subject: "2 repos\nneed attention", Text: "api\nweb"}))
+	assert.Equal(t, "smtp.example.com:587", addr)
+	assert.NotNil(t, auth)
+	assert.Equal(t, "cron@example.com", from, "smtp_user sends when from isn't set")
+	assert.Equal(t, []string{"me@example.com"}, to)
+	assert.Contains(t, string(body), "Subject: 2 repos need attention\r\n")
+	assert.True(t, strings.HasSuffix(string(body), "\r\n\r\napi\r\nweb\r\n"), string(body))
+}
+
+func TestMailBody(t *testing.T) {
+	now := time.Date(2026, 10, 17, 18, 0, 0, 0, time.UTC)
+	body := string(mailBody("cron@example.com", []string{"a@example.com", "b@example.com"},
+		Message{Subject: "3 repos necesitan atención", Text: "api"}, now))
+	assert.Equal(t, "From: cron@example.com\r\n"+
+		"To: a@example.com, b@example.com\r\n"+
+		"Subject: =?utf-8?q?3_repos_necesitan_atenci=C3=B3n?=\r\n"+
+		"Date: Sat, 17 Oct 2026 18:00:00 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"\r\n"+
+		"api\r\n", body)
+}
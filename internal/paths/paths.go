@@ -0,0 +1,110 @@
+// Package paths finds the home directory, expands ~ and locates the
+// config, cache and state directories, the same way on every platform the
+// tools run on.
+//
+// The XDG variables win everywhere. Without them, Unix and macOS use
+// ~/.config, ~/.cache and ~/.local/state, and Windows uses %APPDATA% for
+// config and %LOCALAPPDATA% for cache and state.
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// system is what the functions look at, so tests can pretend to be
+// another platform
+type system struct {
+	goos     string
+	getenv   func(string) string
+	userHome func() (string, error)
+}
+
+var host = system{goos: runtime.GOOS, getenv: os.Getenv, userHome: os.UserHomeDir}
+
+// Home returns the home directory. On Windows, HOME wins over the user
+// profile when set, as it does for Git for Windows, so ~ means the same
+// to the tools as to git.
+func Home() (string, error) {
+	return host.home()
+}
+
+// Expand expands a leading ~/ to the home directory, and ~\ too on
+// Windows. Anything else, ~ alone included, or a path when there is no
+// home directory, is returned as is.
+func Expand(path string) string {
+	return host.expand(path)
+}
+
+// ConfigHome returns the directory user configuration goes under
+func ConfigHome() (string, error) {
+	return host.dir("XDG_CONFIG_HOME", "APPDATA", ".config")
+}
+
+// CacheHome returns the directory caches go under
+func CacheHome() (string, error) {
+	return host.dir("XDG_CACHE_HOME", "LOCALAPPDATA", ".cache")
+}
+
+// StateHome returns the directory state that should survive, such as
+// history and statistics, goes under
+func StateHome() (string, error) {
+	return host.dir("XDG_STATE_HOME", "LOCALAPPDATA", filepath.Join(".local", "state"))
+}
+
+// ShellQuote quotes a path for the sh that git runs GIT_SSH_COMMAND and
+// aliases with, Git for Windows included. Backslashes become slashes on
+// Windows first, which sh would otherwise treat as escapes.
+func ShellQuote(path string) string {
+	return host.shellQuote(path)
+}
+
+func (s system) home() (string, error) {
+	if s.goos == "windows" {
+		if home := s.getenv("HOME"); home != "" {
+			return home, nil
+		}
+	}
+	return s.userHome()
+}
+
+func (s system) expand(path string) string {
+	if !strings.HasPrefix(path, "~/") && (s.goos != "windows" || !strings.HasPrefix(path, `~\`)) {
+		return path
+	}
+	home, err := s.home()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// dir returns the XDG variable xdg when set, the Windows variable win on
+// Windows, or fallback under the home directory
+func (s system) dir(xdg, win, fallback string) (string, error) {
+	if dir := s.getenv(xdg); dir != "" {
+		return dir, nil
+	}
+	if s.goos == "windows" {
+		if dir := s.getenv(win); dir != "" {
+			return dir, nil
+		}
+	}
+	home, err := s.home()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, fallback), nil
+}
+
+func (s system) shellQuote(path string) string {
+	if s.goos == "windows" {
+		path = strings.ReplaceAll(path, `\`, "/")
+	}
+	if path != "" && strings.Trim(path, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:@+=,") == "" {
+		return path
+	}
+	return "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+}
@@ -0,0 +1,160 @@
+package paths
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fake returns a system for goos with the given environment and home
+func fake(goos string, env map[string]string, home string) system {
+	return system{
+		goos:   goos,
+		getenv: func(key string) string { return env[key] },
+		userHome: func() (string, error) {
+			if home == "" {
+				return "", errors.New("no home")
+			}
+			return home, nil
+		},
+	}
+}
+
+func TestHome(t *testing.T) {
+	tests := []struct {
+		name string
+		goos string
+		env  map[string]string
+		want string
+	}{
+		{"linux uses the user home", "linux", map[string]string{"HOME": "/ignored"}, "/home/user"},
+		{"windows prefers HOME", "windows", map[string]string{"HOME": "/c/Users/me"}, "/c/Users/me"},
+		{"windows without HOME", "windows", nil, "/home/user"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home, err := fake(tt.goos, tt.env, "/home/user").home()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, home)
+		})
+	}
+
+	_, err := fake("linux", nil, "").home()
+	assert.Error(t, err)
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name string
+		goos string
+		path string
+		want string
+	}{
+		{"tilde slash", "linux", "~/.ssh/id", filepath.Join("/home/user", ".ssh", "id")},
+		{"tilde slash on windows", "windows", "~/.ssh/id", filepath.Join("/home/user", ".ssh", "id")},
+		{"tilde backslash on windows", "windows", `~\.ssh\id`, filepath.Join("/home/user", `\.ssh\id`)},
+		{"tilde backslash elsewhere", "linux", `~\.ssh\id`, `~\.ssh\id`},
+		{"tilde alone", "linux", "~", "~"},
+		{"other user", "linux", "~bob/x", "~bob/x"},
+		{"absolute", "linux", "/etc/x", "/etc/x"},
+		{"relative", "linux", "x/~/y", "x/~/y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, fake(tt.goos, nil, "/home/user").expand(tt.path))
+		})
+	}
+
+	t.Run("no home", func(t *testing.T) {
+		assert.Equal(t, "~/x", fake("linux", nil, "").expand("~/x"))
+	})
+}
+
+func TestDirs(t *testing.T) {
+	windowsEnv := map[string]string{`APPDATA`: `C:\Users\me\AppData\Roaming`, `LOCALAPPDATA`: `C:\Users\me\AppData\Local`}
+	tests := []struct {
+		name   string
+		goos   string
+		env    map[string]string
+		config string
+		cache  string
+		state  string
+	}{
+		{
+			name:   "linux defaults",
+			goos:   "linux",
+			env:    windowsEnv, // ignored off Windows
+			config: filepath.Join("/home/user", ".config"),
+			cache:  filepath.Join("/home/user", ".cache"),
+			state:  filepath.Join("/home/user", ".local", "state"),
+		},
+		{
+			name:   "xdg wins",
+			goos:   "windows",
+			env:    map[string]string{"XDG_CONFIG_HOME": "/x/config", "XDG_CACHE_HOME": "/x/cache", "XDG_STATE_HOME": "/x/state", "APPDATA": "a", "LOCALAPPDATA": "l"},
+			config: "/x/config",
+			cache:  "/x/cache",
+			state:  "/x/state",
+		},
+		{
+			name:   "windows app data",
+			goos:   "windows",
+			env:    windowsEnv,
+			config: `C:\Users\me\AppData\Roaming`,
+			cache:  `C:\Users\me\AppData\Local`,
+			state:  `C:\Users\me\AppData\Local`,
+		},
+		{
+			name:   "windows without app data",
+			goos:   "windows",
+			config: filepath.Join("/home/user", ".config"),
+			cache:  filepath.Join("/home/user", ".cache"),
+			state:  filepath.Join("/home/user", ".local", "state"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := fake(tt.goos, tt.env, "/home/user")
+			for _, d := range []struct{ xdg, win, fallback, want string }{
+				{"XDG_CONFIG_HOME", "APPDATA", ".config", tt.config},
+				{"XDG_CACHE_HOME", "LOCALAPPDATA", ".cache", tt.cache},
+				{"XDG_STATE_HOME", "LOCALAPPDATA", filepath.Join(".local", "state"), tt.state},
+			} {
+				dir, err := s.dir(d.xdg, d.win, d.fallback)
+				require.NoError(t, err)
+				assert.Equal(t, d.want, dir, d.xdg)
+			}
+		})
+	}
+
+	t.Run("no home", func(t *testing.T) {
+		_, err := fake("linux", nil, "").dir("XDG_CONFIG_HOME", "APPDATA", ".config")
+		assert.Error(t, err)
+	})
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		name string
+		goos string
+		path string
+		want string
+	}{
+		{"plain", "linux", "/home/user/.ssh/id_ed25519", "/home/user/.ssh/id_ed25519"},
+		{"space", "linux", "/home/my user/.ssh/id", "'/home/my user/.ssh/id'"},
+		{"quote", "linux", "/home/o'neil/id", `'/home/o'\''neil/id'`},
+		{"dollar", "linux", "/tmp/$HOME/id", "'/tmp/$HOME/id'"},
+		{"empty", "linux", "", "''"},
+		{"backslashes elsewhere", "linux", `a\b`, `'a\b'`},
+		{"windows drive", "windows", `C:\Users\me\.ssh\id`, "C:/Users/me/.ssh/id"},
+		{"windows space", "windows", `C:\Users\Jane Doe\.ssh\id`, "'C:/Users/Jane Doe/.ssh/id'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, system{goos: tt.goos}.shellQuote(tt.path))
+		})
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package paths
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpand_Unix(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+
+	assert.Equal(t, filepath.Join("/home/me", ".ssh", "id"), Expand("~/.ssh/id"))
+	// A backslash is an ordinary file name character here
+	assert.Equal(t, `~\.ssh\id`, Expand(`~\.ssh\id`))
+}
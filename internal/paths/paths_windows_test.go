@@ -0,0 +1,18 @@
+//go:build windows
+
+package paths
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpand_Windows(t *testing.T) {
+	t.Setenv("HOME", `C:\Users\me`)
+
+	assert.Equal(t, filepath.Join(`C:\Users\me`, ".ssh", "id"), Expand(`~\.ssh\id`))
+	assert.Equal(t, filepath.Join(`C:\Users\me`, ".ssh", "id"), Expand("~/.ssh/id"))
+	assert.Equal(t, "C:/Users/me/.ssh/id", ShellQuote(Expand(`~\.ssh\id`)))
+}
@@ -0,0 +1,87 @@
+package render
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/i18n"
+)
+
+// sparkBlocks are the bars of a sparkline, from the fewest to the most:
+// unicodeSparkBlocks, or asciiSparkBlocks after SetASCII
+var (
+	unicodeSparkBlocks = []rune("▁▂▃▄▅▆▇█")
+	asciiSparkBlocks   = []rune("_.:-=+*#")
+	sparkBlocks        = unicodeSparkBlocks
+)
+
+// Sparkline draws one bar per value, scaled to the largest. Zero gets the
+// lowest bar, and any other value at least the second.
+func Sparkline(values []int) string {
+	top := slices.Max(append([]int{0}, values...))
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if v > 0 {
+			level = (v*(len(sparkBlocks)-1) + top - 1) / top // Rounded up
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
+
+// activityBars renders weekly commits as a sparkline, with the weeks
+// without any dimmed
+func activityBars(weekly []int) string {
+	var b strings.Builder
+	for _, c := range Sparkline(weekly) {
+		if c == sparkBlocks[0] {
+			b.WriteString(dim.Render(string(c)))
+		} else {
+			b.WriteString(green.Render(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// writeActivity sums up the user's commits of the last year across repos:
+// a sparkline per repo, busiest first, and one of them all
+func writeActivity(out *errWriter, repos []analyzer.RepoInfo) {
+	var active []*analyzer.RepoInfo
+	total := make([]int, analyzer.ActivityWeeks)
+	for i := range repos {
+		c := repos[i].Commits
+		if c == nil || c.LastYear() == 0 {
+			continue
+		}
+		active = append(active, &repos[i])
+		for w, n := range c.Weekly {
+			total[w] += n
+		}
+	}
+	if len(active) == 0 {
+		return
+	}
+	slices.SortStableFunc(active, func(a, b *analyzer.RepoInfo) int {
+		return cmp.Compare(b.Commits.LastYear(), a.Commits.LastYear())
+	})
+
+	all := i18n.T("All repos")
+	width := Width(all)
+	for _, r := range active {
+		width = max(width, Width(r.Name))
+	}
+
+	out.println()
+	out.printf("%s %s\n", blueBold.Render(Icons["activity"]), blueBold.Render(i18n.T("Your activity in the last year")))
+	for _, r := range active {
+		out.printf("  %s  %s  %d\n", PadRight(r.Name, width), activityBars(r.Commits.Weekly), r.Commits.LastYear())
+	}
+	sum := 0
+	for _, n := range total {
+		sum += n
+	}
+	out.printf("  %s  %s  %d\n", PadRight(whiteBold.Render(all), width), activityBars(total), sum)
+}
@@ -0,0 +1,48 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+)
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "▁▂▅█", Sparkline([]int{0, 1, 4, 7}))
+	assert.Equal(t, "▂█", Sparkline([]int{1, 100}), "any commit shows above an empty week")
+	assert.Equal(t, "▁▁", Sparkline([]int{0, 0}))
+	assert.Empty(t, Sparkline(nil))
+}
+
+func TestWriteRepos_Activity(t *testing.T) {
+	weekly := func(last ...int) []int {
+		w := make([]int, analyzer.ActivityWeeks)
+		copy(w[len(w)-len(last):], last)
+		return w
+	}
+	repos := []analyzer.RepoInfo{
+		{Name: "quiet", IsGitRepo: true, TotalUserCommits: 1, Commits: &analyzer.CommitStats{Weekly: weekly(1)}},
+		{Name: "busy", IsGitRepo: true, TotalUserCommits: 9, Commits: &analyzer.CommitStats{Weekly: weekly(4, 5)}},
+		{Name: "old", IsGitRepo: true, TotalUserCommits: 3, Commits: &analyzer.CommitStats{}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteRepos(&buf, repos, Options{}))
+	assert.NotContains(t, buf.String(), "Your activity")
+
+	buf.Reset()
+	require.NoError(t, WriteRepos(&buf, repos, Options{Activity: true}))
+	summary := buf.String()[strings.Index(buf.String(), "Your activity in the last year"):]
+	lines := strings.Split(strings.TrimSpace(summary), "\n")
+	require.Len(t, lines, 4, summary)
+	assert.Contains(t, lines[1], "busy")
+	assert.True(t, strings.HasSuffix(lines[1], " 9"), lines[1])
+	assert.Contains(t, lines[2], "quiet")
+	assert.Contains(t, lines[3], "All repos")
+	assert.True(t, strings.HasSuffix(lines[3], " 10"), lines[3])
+	assert.NotContains(t, summary, "old", "no commits in the last year")
+}
@@ -0,0 +1,333 @@
+package render
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/jdevera/git-this-bread/internal/actions"
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/i18n"
+	"github.com/jdevera/git-this-bread/internal/llmadvice"
+)
+
+// Severity ranks how urgently an advice item needs attention
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"     // Housekeeping, safe to ignore for a while
+	SeverityWarning  Severity = "warning"  // Work at risk of being lost or forgotten
+	SeverityCritical Severity = "critical" // Repository is blocked until resolved
+)
+
+// Advice is one actionable suggestion for a repository
+type Advice struct {
+	Text     string   `json:"text"`
+	Severity Severity `json:"severity"`
+	Command  string   `json:"command,omitempty"` // Copy-ready command line, if one applies
+	Args     []string `json:"-"`                 // The command's program and arguments, which --fix runs without a shell
+}
+
+// severityIcons and severityRoles control how each severity is rendered
+var (
+	severityIcons = map[Severity]string{
+		SeverityInfo:     "→",
+		SeverityWarning:  "!",
+		SeverityCritical: "✗",
+	}
+	severityRoles = map[Severity]Role{
+		SeverityWarning:  RoleWarning,
+		SeverityCritical: RoleError,
+	}
+)
+
+// Style returns the style for advice of this severity in the active theme
+func (s Severity) Style() lipgloss.Style {
+	st := Style(severityRoles[s])
+	if s == SeverityCritical {
+		st = st.Bold(true)
+	}
+	return st
+}
+
+// GetAdvice returns the text of each rule-based advice item
+func GetAdvice(info *analyzer.RepoInfo) []string {
+	items := AdviceFor(info)
+	if items == nil {
+		return nil
+	}
+	texts := make([]string, len(items))
+	for i, a := range items {
+		texts[i] = a.Text
+	}
+	return texts
+}
+
+// AdviceFor returns rule-based advice for a repository, in display order
+func AdviceFor(info *analyzer.RepoInfo) []Advice {
+	var advice []Advice
+	add := func(sev Severity, argv []string, format string, args ...any) {
+		advice = append(advice, Advice{Text: i18n.Sprintf(format, args...), Severity: sev, Command: actions.Join(argv), Args: argv})
+	}
+	hasContributions := info.HasUserRemote || info.TotalUserCommits > 0
+
+	if !hasContributions {
+		if info.HasUncommittedChanges || info.StashCount > 0 {
+			add(SeverityWarning, nil, "Has local changes but no remote - set up your fork or commit upstream")
+		} else {
+			add(SeverityInfo, nil, "No contributions - consider removing if not needed")
+		}
+	}
+
+	if info.HasUserRemote && info.TotalUserCommits == 0 {
+		add(SeverityInfo, nil, "Forked but no commits yet - start contributing or remove")
+	}
+
+	if op := info.Operation; op != nil {
+		if op.State != analyzer.OpNone {
+			add(SeverityCritical, operationCommand(op.State), "Finish or abort the %s in progress", op.State)
+		}
+		if len(op.ConflictFiles) > 0 {
+			add(SeverityCritical, git("status"), "Resolve %d conflicted file(s)", len(op.ConflictFiles))
+		}
+	}
+
+	if r := info.Remote(info.TrackedRemote); info.Ahead > 0 && r != nil && r.ReadOnly {
+		add(SeverityWarning, forkCommand(r),
+			"%s is read-only - push your %d unpushed commit(s) to a fork instead", r.Name, info.Ahead)
+	} else if info.Ahead > 0 {
+		add(SeverityWarning, git("push"), "Push your %d unpushed commit(s)", info.Ahead)
+	}
+
+	if others, commits := info.OtherUnpushed(); len(others) > 0 {
+		if r := readOnlyUpstream(info, others); r != nil {
+			add(SeverityWarning, forkCommand(r),
+				"%d unpushed commit(s) on %d other branch(es) track read-only %s - push them to a fork instead", commits, len(others), r.Name)
+		} else {
+			add(SeverityWarning, pushCommand(info, others),
+				"Push %d unpushed commit(s) on %d other branch(es)", commits, len(others))
+		}
+	}
+
+	if info.Behind > 0 {
+		add(SeverityInfo, git("pull", "--ff-only"), "Pull %d commit(s) from the remote", info.Behind)
+	}
+
+	if info.UpstreamBehind > 0 {
+		var command []string
+		if info.UpstreamRemote != "" && info.DefaultBranch != "" {
+			command = git("pull", info.UpstreamRemote, info.DefaultBranch)
+		}
+		add(SeverityInfo, command, "Sync with %s - %d commit(s) behind upstream", info.UpstreamRemote, info.UpstreamBehind)
+	}
+
+	if info.HasUncommittedChanges && info.DirtyDetails != nil {
+		d := info.DirtyDetails
+		if d.StagedFiles > 0 && d.UnstagedFiles == 0 && d.Untracked == 0 {
+			add(SeverityWarning, git("commit"), "Staged changes ready - commit %d file(s)", d.StagedFiles)
+		}
+		if d.Untracked > 5 {
+			add(SeverityInfo, nil, "%d untracked files - add to .gitignore or stage", d.Untracked)
+		}
+	}
+
+	if info.StashCount > 0 {
+		add(SeverityWarning, git("stash", "list"), "Review %d stash(es) - apply or drop", info.StashCount)
+	}
+
+	if lfs := info.LFS; lfs != nil {
+		switch {
+		case !lfs.Installed:
+			add(SeverityWarning, git("lfs", "install"), "Install Git LFS - %d file(s) here are only LFS pointers without it", lfs.Files)
+		case lfs.Unpushed > 0 && !lfs.Hooked:
+			var command []string
+			if info.TrackedRemote != "" && info.CurrentBranch != "" {
+				command = git("lfs", "push", info.TrackedRemote, info.CurrentBranch)
+			}
+			add(SeverityWarning, command, "Push %d LFS object(s) - without the Git LFS hook, git push leaves them behind", lfs.Unpushed)
+		}
+	}
+
+	for _, o := range info.BranchOverlaps {
+		if o.Source == analyzer.WorkingTreeSource {
+			add(SeverityWarning, nil, "Your uncommitted changes touch %d file(s) from %s - you may be on the wrong branch", len(o.Files), o.Branch)
+		} else {
+			add(SeverityInfo, nil, "%s touches %d file(s) from %s - apply it there", o.Source, len(o.Files), o.Branch)
+		}
+	}
+
+	if d := info.DiskUsage; d != nil && d.NeedsGC() {
+		if d.GarbageBytes > 0 {
+			add(SeverityInfo, git("gc"), "%d loose objects in %d packs, %s of garbage files - run git gc", d.LooseObjects, d.Packs, formatBytes(d.GarbageBytes))
+		} else {
+			add(SeverityInfo, git("gc"), "%d loose objects in %d packs - run git gc", d.LooseObjects, d.Packs)
+		}
+	}
+
+	if m := info.Maintenance; m != nil {
+		if m.AutoGCDisabled {
+			add(SeverityInfo, git("config", "--unset", "gc.auto"), "Automatic gc is off (gc.auto=0) - turn it back on")
+		}
+		if m.Unmaintained() {
+			add(SeverityInfo, git("maintenance", "start"), "No background maintenance - run git maintenance start")
+		}
+	}
+
+	for _, name := range info.UnreachableRemotes() {
+		add(SeverityWarning, nil, "Remote %s is unreachable - update its URL or remove it", name)
+	}
+
+	if n := len(info.StaleRemoteRefs); n > 0 {
+		add(SeverityInfo, git("fetch", "--prune"), "%d remote-tracking ref(s) deleted upstream - run git fetch --prune", n)
+	}
+
+	for _, remote := range info.RemoteOnlyRemotes() {
+		n := 0
+		for _, b := range info.RemoteOnlyBranches {
+			if b.Remote == remote {
+				n++
+			}
+		}
+		add(SeverityInfo, git("fetch", remote), "%d branch(es) on %s not checked out here - fetch them", n, remote)
+	}
+
+	if gone := info.GoneBranches(); len(gone) > 0 {
+		add(SeverityInfo, git(append([]string{"branch", "-d"}, gone...)...),
+			"%d branch(es) track deleted remotes - delete them", len(gone))
+	}
+
+	return advice
+}
+
+// git returns the arguments of a git command line
+func git(args ...string) []string {
+	return append([]string{"git"}, args...)
+}
+
+// pushCommand returns the git push for the local branches names, when
+// they all track branches of one remote, or nil when they don't
+func pushCommand(info *analyzer.RepoInfo, names []string) []string {
+	remote := ""
+	refs := make([]string, 0, len(names))
+	for _, b := range info.LocalBranches {
+		if !slices.Contains(names, b.Name) {
+			continue
+		}
+		r, branch, ok := strings.Cut(b.Upstream, "/")
+		if !ok || (remote != "" && r != remote) {
+			return nil
+		}
+		remote = r
+		if branch == b.Name {
+			refs = append(refs, b.Name)
+		} else {
+			refs = append(refs, b.Name+":"+branch)
+		}
+	}
+	if remote == "" {
+		return nil
+	}
+	return git(append([]string{"push", remote}, refs...)...)
+}
+
+// readOnlyUpstream returns the read-only remote that all the branches
+// called names track, or nil when they don't all track the same one
+func readOnlyUpstream(info *analyzer.RepoInfo, names []string) *analyzer.RemoteInfo {
+	var remote *analyzer.RemoteInfo
+	for _, b := range info.LocalBranches {
+		if !slices.Contains(names, b.Name) {
+			continue
+		}
+		name, _, _ := strings.Cut(b.Upstream, "/")
+		r := info.Remote(name)
+		if r == nil || !r.ReadOnly || (remote != nil && r != remote) {
+			return nil
+		}
+		remote = r
+	}
+	return remote
+}
+
+// forkCommand returns the command that forks the repository of a
+// read-only remote and adds the fork as a remote, nil when none does
+func forkCommand(r *analyzer.RemoteInfo) []string {
+	if r.Host == "github.com" {
+		return []string{"gh", "repo", "fork", "--remote"}
+	}
+	return nil
+}
+
+// maintenanceCommands are the advice commands --maintenance runs
+var maintenanceCommands = []string{"git gc", "git config --unset gc.auto", "git maintenance start"}
+
+// inspectCommands are advice commands that only show something, which
+// ActionsFor leaves out: they fix nothing
+var inspectCommands = []string{"git status", "git stash list"}
+
+// MaintenanceActionsFor returns the actions of ActionsFor that keep the
+// repository in shape: gc and background maintenance
+func MaintenanceActionsFor(info *analyzer.RepoInfo) []actions.Action {
+	var items []actions.Action
+	for _, a := range ActionsFor(info) {
+		if slices.Contains(maintenanceCommands, a.Command) {
+			items = append(items, a)
+		}
+	}
+	return items
+}
+
+// ActionsFor returns the rule-based advice for a repository that comes with
+// a command that changes something, as actions that run it in the
+// repository without a shell
+func ActionsFor(info *analyzer.RepoInfo) []actions.Action {
+	var items []actions.Action
+	for _, a := range AdviceFor(info) {
+		if len(a.Args) == 0 || slices.Contains(inspectCommands, a.Command) {
+			continue
+		}
+		items = append(items, actions.Action{
+			Tool:     "git-explain",
+			Repo:     info.Path,
+			Action:   a.Text,
+			Command:  a.Command,
+			Args:     a.Args,
+			Severity: string(a.Severity),
+			Dir:      info.Path,
+		})
+	}
+	return items
+}
+
+// operationCommand suggests how to move an in-progress operation forward
+func operationCommand(state analyzer.OperationState) []string {
+	if state == analyzer.OpBisect {
+		return git("bisect", "reset")
+	}
+	return git(string(state), "--continue")
+}
+
+// fromLLM converts advice from the LLM, whose severities are already
+// limited to the ones rendered here
+func fromLLM(advice []llmadvice.Advice) []Advice {
+	if advice == nil {
+		return nil
+	}
+	items := make([]Advice, len(advice))
+	for i, a := range advice {
+		items[i] = Advice{Text: a.Text, Severity: Severity(a.Severity), Command: a.Command}
+	}
+	return items
+}
+
+// writeAdvice prints advice items at the given indent, each with its
+// severity icon and color and, when present, the command on the next line
+func writeAdvice(out *errWriter, items []Advice, indent string) {
+	for _, a := range items {
+		st := a.Severity.Style()
+		out.printf("%s%s %s\n", indent, st.Render(severityIcons[a.Severity]), st.Render(a.Text))
+		if a.Command != "" {
+			out.printf("%s  %s\n", indent, dim.Render("$ "+a.Command))
+		}
+	}
+}
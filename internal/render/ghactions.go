@@ -0,0 +1,166 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/i18n"
+)
+
+// Formats lists the values of git-explain --format
+var Formats = []string{"text", "gh-actions"}
+
+// ghEscaper escapes the message of a GitHub Actions workflow command, and
+// ghPropertyEscaper its properties, which also end at : and ,
+var (
+	ghEscaper         = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	ghPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// repoProblems lists in words what makes a repository need attention, empty
+// when nothing does
+func repoProblems(info *analyzer.RepoInfo) []string {
+	if !info.IsGitRepo {
+		return nil
+	}
+	var problems []string
+	if info.Error != "" {
+		problems = append(problems, i18n.Sprintf("Error: %s", info.Error))
+	}
+	if info.TimedOut {
+		problems = append(problems, i18n.T("Analysis timed out"))
+	}
+	if op := info.Operation; op != nil {
+		if op.State != analyzer.OpNone {
+			problems = append(problems, i18n.Sprintf("%s in progress", op.State))
+		}
+		if len(op.ConflictFiles) > 0 {
+			problems = append(problems, i18n.Sprintf("%d conflicted file(s)", len(op.ConflictFiles)))
+		}
+	}
+	if info.HasUncommittedChanges {
+		if d := info.DirtyDetails; d != nil && d.TotalFiles() > 0 {
+			problems = append(problems, i18n.Sprintf("%d uncommitted file(s)", d.TotalFiles()))
+		} else {
+			problems = append(problems, i18n.T("Uncommitted changes"))
+		}
+	}
+	if branches, commits := info.Unpushed(); branches > 1 {
+		problems = append(problems, i18n.Sprintf("%d unpushed commit(s) on %d branches", commits, branches))
+	} else if commits > 0 {
+		problems = append(problems, i18n.Sprintf("%d unpushed commit(s)", commits))
+	}
+	if info.StashCount > 0 {
+		problems = append(problems, i18n.Sprintf("%d stash(es)", info.StashCount))
+	}
+	if lfs := info.LFS; lfs != nil && lfs.AtRisk() {
+		problems = append(problems, lfsSummary(lfs))
+	}
+	if names := info.UnreachableRemotes(); len(names) > 0 {
+		problems = append(problems, i18n.Sprintf("Unreachable remote(s): %s", strings.Join(names, ", ")))
+	}
+	return problems
+}
+
+// blocked reports whether a repository can't be worked on until its
+// problems are solved, which makes them errors rather than warnings
+func blocked(info *analyzer.RepoInfo) bool {
+	if info.Error != "" {
+		return true
+	}
+	op := info.Operation
+	return op != nil && (op.State != analyzer.OpNone || len(op.ConflictFiles) > 0)
+}
+
+// WriteGHActions writes repos as GitHub Actions workflow commands, for a
+// workflow looking after the checkouts of a build server: an annotation
+// per repository with problems, a warning or an error when it is blocked,
+// then a collapsible log group with its advice, and a closing notice
+func WriteGHActions(w io.Writer, repos []analyzer.RepoInfo) error {
+	out := &errWriter{w: w}
+	checked, flagged := 0, 0
+	for i := range repos {
+		info := &repos[i]
+		if !info.IsGitRepo {
+			continue
+		}
+		checked++
+		problems := repoProblems(info)
+		if len(problems) == 0 {
+			continue
+		}
+		flagged++
+
+		level := "warning"
+		if blocked(info) {
+			level = "error"
+		}
+		out.printf("::%s file=%s,title=%s::%s\n", level,
+			ghPropertyEscaper.Replace(info.Path), ghPropertyEscaper.Replace(info.Name),
+			ghEscaper.Replace(strings.Join(problems, "; ")))
+
+		out.printf("::group::%s\n", ghEscaper.Replace(info.Name+": "+strings.Join(problems, ", ")))
+		out.printf("%s: %s\n", i18n.T("Path"), info.Path)
+		if info.CurrentBranch != "" {
+			out.printf("%s: %s\n", i18n.T("Branch"), info.CurrentBranch)
+		}
+		for _, a := range AdviceFor(info) {
+			out.printf("- %s\n", a.Text)
+			if a.Command != "" {
+				out.printf("    $ %s\n", a.Command)
+			}
+		}
+		out.println("::endgroup::")
+	}
+
+	notice := i18n.Sprintf("%d of %d repos need attention", flagged, checked)
+	if flagged == 0 {
+		notice = i18n.Sprintf("All %d repos are clean", checked)
+	}
+	out.printf("::notice title=git-explain::%s\n", ghEscaper.Replace(notice))
+	return out.err
+}
+
+// WriteGHSummary writes a Markdown job summary of repos, for the file in
+// $GITHUB_STEP_SUMMARY: a table of those with problems, and what they are
+func WriteGHSummary(w io.Writer, repos []analyzer.RepoInfo) error {
+	out := &errWriter{w: w}
+	var rows []string
+	checked := 0
+	for i := range repos {
+		info := &repos[i]
+		if !info.IsGitRepo {
+			continue
+		}
+		checked++
+		problems := repoProblems(info)
+		if len(problems) == 0 {
+			continue
+		}
+		icon := "⚠️"
+		if blocked(info) {
+			icon = "❌"
+		}
+		rows = append(rows, fmt.Sprintf("| %s `%s` | %s | %s |", icon, markdownCell(info.Name),
+			markdownCell(info.CurrentBranch), markdownCell(strings.Join(problems, ", "))))
+	}
+
+	if len(rows) == 0 {
+		out.printf("## git-explain: %s\n\n", i18n.Sprintf("All %d repos are clean", checked))
+		return out.err
+	}
+	out.printf("## git-explain: %s\n\n", i18n.Sprintf("%d of %d repos need attention", len(rows), checked))
+	out.printf("| %s | %s | %s |\n|---|---|---|\n", i18n.T("Repository"), i18n.T("Branch"), i18n.T("Problems"))
+	for _, row := range rows {
+		out.println(row)
+	}
+	out.println()
+	return out.err
+}
+
+// markdownCell keeps text from breaking out of a Markdown table cell
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ", "`", "'").Replace(s)
+}
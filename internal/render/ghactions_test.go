@@ -0,0 +1,66 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+)
+
+func ghActionsRepos() []analyzer.RepoInfo {
+	return []analyzer.RepoInfo{
+		{
+			Name: "api", Path: "/srv/api", IsGitRepo: true, CurrentBranch: "main", HasUserRemote: true, TotalUserCommits: 3,
+			HasUncommittedChanges: true, DirtyDetails: &analyzer.DirtyDetails{UnstagedFiles: 2, Untracked: 1},
+			Ahead: 4,
+		},
+		{
+			Name: "web", Path: "/srv/web", IsGitRepo: true, CurrentBranch: "feat/x", HasUserRemote: true, TotalUserCommits: 1,
+			Operation: &analyzer.OperationDetails{State: analyzer.OpRebase, ConflictFiles: []string{"a.go"}},
+		},
+		{Name: "docs", Path: "/srv/docs", IsGitRepo: true, HasUserRemote: true, TotalUserCommits: 1},
+		{Name: "notes", Path: "/srv/notes"},
+	}
+}
+
+func TestWriteGHActions(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteGHActions(&buf, ghActionsRepos()))
+	output := buf.String()
+
+	assert.Contains(t, output, "::warning file=/srv/api,title=api::3 uncommitted file(s); 4 unpushed commit(s)\n")
+	assert.Contains(t, output, "::error file=/srv/web,title=web::rebase in progress; 1 conflicted file(s)\n")
+	assert.Contains(t, output, "::group::api: 3 uncommitted file(s), 4 unpushed commit(s)\n")
+	assert.Contains(t, output, "    $ git push\n")
+	assert.Equal(t, 2, strings.Count(output, "::endgroup::"))
+	assert.NotContains(t, output, "docs", "clean repos stay out of the log")
+	assert.True(t, strings.HasSuffix(output, "::notice title=git-explain::2 of 3 repos need attention\n"), output)
+
+	buf.Reset()
+	require.NoError(t, WriteGHActions(&buf, ghActionsRepos()[2:]))
+	assert.Equal(t, "::notice title=git-explain::All 1 repos are clean\n", buf.String())
+}
+
+func TestWriteGHActions_Escapes(t *testing.T) {
+	repos := []analyzer.RepoInfo{{Name: "a,b", Path: "C:/src/a,b", IsGitRepo: true, Error: "bad: 100%\nreally"}}
+	var buf bytes.Buffer
+	require.NoError(t, WriteGHActions(&buf, repos))
+	assert.Contains(t, buf.String(), "::error file=C%3A/src/a%2Cb,title=a%2Cb::Error: bad: 100%25%0Areally\n")
+}
+
+func TestWriteGHSummary(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteGHSummary(&buf, ghActionsRepos()))
+	assert.Equal(t, "## git-explain: 2 of 3 repos need attention\n\n"+
+		"| Repository | Branch | Problems |\n|---|---|---|\n"+
+		"| ⚠️ `api` | main | 3 uncommitted file(s), 4 unpushed commit(s) |\n"+
+		"| ❌ `web` | feat/x | rebase in progress, 1 conflicted file(s) |\n\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteGHSummary(&buf, nil))
+	assert.Equal(t, "## git-explain: All 0 repos are clean\n\n", buf.String())
+}
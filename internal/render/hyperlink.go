@@ -0,0 +1,87 @@
+package render
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// HyperlinksEnv forces hyperlinks on (1) or off (0), as in other tools
+const HyperlinksEnv = "FORCE_HYPERLINK"
+
+// HyperlinkModes are the values of --hyperlinks and ui.hyperlinks
+var HyperlinkModes = []string{"auto", "always", "never"}
+
+// hyperlinks says whether Link emits OSC 8 hyperlinks
+var hyperlinks bool
+
+// SetHyperlinks turns hyperlinks on or off: "always", "never", or "auto"
+// (or empty) for when stdout is a terminal known to support them
+func SetHyperlinks(mode string) error {
+	switch mode {
+	case "", "auto":
+		hyperlinks = IsTerminal() && supportsHyperlinks(os.Getenv)
+	case "always":
+		hyperlinks = true
+	case "never":
+		hyperlinks = false
+	default:
+		return fmt.Errorf("invalid hyperlinks mode %q: must be %s", mode, strings.Join(HyperlinkModes, ", "))
+	}
+	return nil
+}
+
+// Link makes text a terminal hyperlink to target (OSC 8), when hyperlinks
+// are on and there is a target. Terminals without support show text.
+func Link(text, target string) string {
+	if !hyperlinks || target == "" {
+		return text
+	}
+	return ansi.SetHyperlink(target) + text + ansi.ResetHyperlink()
+}
+
+// FileURL returns the file:// URL of a local path
+func FileURL(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // C:/src becomes /C:/src
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
+
+// supportsHyperlinks guesses from the environment whether the terminal
+// understands OSC 8. Terminals that don't may print the escape codes, so
+// it only says yes for the ones known to.
+func supportsHyperlinks(getenv func(string) string) bool {
+	if force := getenv(HyperlinksEnv); force != "" {
+		return force != "0"
+	}
+	if getenv("CI") != "" || getenv("TERM") == "dumb" {
+		return false
+	}
+	switch getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper", "WarpTerminal":
+		return true
+	}
+	if getenv("WT_SESSION") != "" || getenv("KITTY_WINDOW_ID") != "" || getenv("DOMTERM") != "" || getenv("KONSOLE_VERSION") != "" {
+		return true
+	}
+	if vte, err := strconv.Atoi(getenv("VTE_VERSION")); err == nil && vte >= 5000 {
+		return true // GNOME Terminal, Tilix and other VTE terminals since 0.50
+	}
+	term := getenv("TERM")
+	for _, t := range []string{"kitty", "alacritty", "foot", "wezterm", "ghostty"} {
+		if strings.Contains(term, t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package render
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLink(t *testing.T) {
+	t.Cleanup(func() { hyperlinks = false })
+
+	require.NoError(t, SetHyperlinks("never"))
+	assert.Equal(t, "repo", Link("repo", "https://example.com/repo"))
+
+	require.NoError(t, SetHyperlinks("always"))
+	link := Link("repo", "https://example.com/repo")
+	assert.Equal(t, "\x1b]8;;https://example.com/repo\x07repo\x1b]8;;\x07", link)
+	assert.Equal(t, 4, Width(link), "the escape codes take no cells")
+	assert.Equal(t, "repo", Link("repo", ""), "no target, no link")
+
+	assert.ErrorContains(t, SetHyperlinks("sometimes"), "invalid hyperlinks mode")
+}
+
+func TestFileURL(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix paths")
+	}
+	assert.Equal(t, "file:///home/me/src/my%20app", FileURL("/home/me/src/my app"))
+}
+
+func TestSupportsHyperlinks(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want bool
+	}{
+		{"unknown terminal", map[string]string{"TERM": "xterm-256color"}, false},
+		{"iTerm2", map[string]string{"TERM_PROGRAM": "iTerm.app"}, true},
+		{"kitty", map[string]string{"TERM": "xterm-kitty"}, true},
+		{"Windows Terminal", map[string]string{"WT_SESSION": "abc"}, true},
+		{"new VTE", map[string]string{"VTE_VERSION": "7600"}, true},
+		{"old VTE", map[string]string{"VTE_VERSION": "4205"}, false},
+		{"forced on", map[string]string{"FORCE_HYPERLINK": "1"}, true},
+		{"forced off", map[string]string{"FORCE_HYPERLINK": "0", "TERM_PROGRAM": "iTerm.app"}, false},
+		{"CI", map[string]string{"CI": "true", "TERM_PROGRAM": "vscode"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			assert.Equal(t, tt.want, supportsHyperlinks(getenv))
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package render
+
+import (
+	"io"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/jdevera/git-this-bread/internal/i18n"
+)
+
+// LegendEntry describes one indicator: which icon it uses, how it is styled,
+// and what it means. Renderers style indicators through the same entries, so
+// the legend always matches the output.
+type LegendEntry struct {
+	Icon        string // Key in Icons
+	Sample      string // Placeholder shown next to the icon in the legend
+	ASCIISample string // Sample after SetASCII, when Sample isn't ASCII
+	Meaning     string
+	Role        Role // Color role; empty for the terminal's default color
+	Bold        bool
+}
+
+// Style returns the entry's style in the active theme
+func (e LegendEntry) Style() lipgloss.Style {
+	st := Style(e.Role)
+	if e.Bold {
+		st = st.Bold(true)
+	}
+	return st
+}
+
+// LegendSection groups legend entries under a heading
+type LegendSection struct {
+	Title   string
+	Entries []LegendEntry
+}
+
+// Legend is the registry of every indicator the renderers use
+var Legend = []LegendSection{
+	{
+		Title: "Repository types",
+		Entries: []LegendEntry{
+			{Icon: "repo", Sample: "name", Meaning: "Repository with your contributions", Role: RoleSuccess, Bold: true},
+			{Icon: "fork", Sample: "name", Meaning: "Fork (has upstream remote)", Role: RoleFork, Bold: true},
+			{Icon: "clone", Sample: "name", Meaning: "Clone without contributions", Bold: true},
+		},
+	},
+	{
+		Title: "Status indicators",
+		Entries: []LegendEntry{
+			{Icon: "branch", Sample: "branch", Meaning: "Current branch name", Role: RoleBranch},
+			{Icon: "remote", Sample: "origin", Meaning: "Your remote", Role: RoleSuccess, Bold: true},
+			{Icon: "commit", Sample: "N", Meaning: "Number of your commits", Role: RoleInfo, Bold: true},
+			{Icon: "calendar", Sample: "date", Meaning: "Date of last commit", Role: RoleMuted},
+			{Icon: "activity", Sample: "▁▃█", ASCIISample: "_:#", Meaning: "Your commits per week over the last year", Role: RoleMuted},
+			{Icon: "dirty", Sample: "dirty", Meaning: "Uncommitted changes", Role: RoleWarning},
+			{Icon: "unpushed", Sample: "N", Meaning: "Unpushed commits", Role: RoleError, Bold: true},
+			{Icon: "behind", Sample: "N", Meaning: "Commits on the remote not pulled yet", Role: RoleWarning},
+			{Icon: "upstream", Sample: "+A/-B", Meaning: "Fork ahead of / behind its upstream", Role: RoleBranch},
+			{Icon: "stash", Sample: "N", Meaning: "Stashed changes", Role: RoleBranch},
+			{Icon: "error", Sample: "op", Meaning: "Operation in progress, conflicts or errors", Role: RoleError, Bold: true},
+			{Icon: "disk", Sample: "size", Meaning: "Disk usage (with --disk-usage)", Role: RoleMuted},
+			{Icon: "lfs", Sample: "lfs", Meaning: "Git LFS not installed, or objects git push leaves behind", Role: RoleWarning},
+			{Icon: "label", Sample: "label", Meaning: "Labels (explain.labels)", Role: RoleAccent},
+			{Icon: "no_contrib", Sample: "", Meaning: "No contributions", Role: RoleMuted},
+		},
+	},
+}
+
+// legendEntry looks up the registry entry for an icon key
+func legendEntry(icon string) LegendEntry {
+	for _, section := range Legend {
+		for _, e := range section.Entries {
+			if e.Icon == icon {
+				return e
+			}
+		}
+	}
+	return LegendEntry{Icon: icon}
+}
+
+// sample returns the placeholder shown next to the entry's icon
+func (e LegendEntry) sample() string {
+	if ascii && e.ASCIISample != "" {
+		return e.ASCIISample
+	}
+	return e.Sample
+}
+
+// indicator renders an icon and its text in the icon's registered style
+func indicator(icon, text string) string {
+	return legendEntry(icon).Style().Render(Icons[icon] + " " + text)
+}
+
+// PrintLegend prints the legend to stdout
+func PrintLegend() error {
+	return WriteLegend(os.Stdout)
+}
+
+// WriteLegend writes an explanation of every registered icon to w, using the
+// active theme and icon set
+func WriteLegend(w io.Writer) error {
+	out := &errWriter{w: w}
+	out.println()
+	out.println(i18n.T("Legend"))
+	for _, section := range Legend {
+		out.println()
+		out.println(i18n.T(section.Title) + ":")
+		for _, e := range section.Entries {
+			out.printf("  %s %s  %s\n", e.Style().Render(Icons[e.Icon]), PadRight(e.sample(), 7), i18n.T(e.Meaning))
+		}
+	}
+	out.println()
+	return out.err
+}
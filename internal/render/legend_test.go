@@ -0,0 +1,79 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/i18n"
+)
+
+func TestLegend_IconsExist(t *testing.T) {
+	for _, section := range Legend {
+		for _, e := range section.Entries {
+			assert.Contains(t, Icons, e.Icon, "legend entry %q has no icon", e.Meaning)
+		}
+	}
+}
+
+func TestWriteLegend_ListsEveryEntry(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteLegend(&buf))
+
+	for _, section := range Legend {
+		assert.Contains(t, buf.String(), section.Title+":")
+		for _, e := range section.Entries {
+			assert.Contains(t, buf.String(), Icons[e.Icon]+" ")
+			assert.Contains(t, buf.String(), e.Meaning)
+		}
+	}
+}
+
+func TestIndicator_UsesRegistryStyle(t *testing.T) {
+	entry := legendEntry("stash")
+	assert.Equal(t, entry.Style().Render(Icons["stash"]+" 2 stash"), indicator("stash", "2 stash"))
+
+	unknown := legendEntry("nope")
+	assert.Equal(t, "nope", unknown.Icon)
+}
+
+func TestWriteLegend_Translated(t *testing.T) {
+	require.NoError(t, i18n.SetLocale("es"))
+	t.Cleanup(func() { _ = i18n.SetLocale(i18n.Default) })
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteLegend(&buf))
+	assert.Contains(t, buf.String(), "Leyenda")
+	assert.Contains(t, buf.String(), "Tipos de repositorio:")
+	for _, section := range Legend {
+		for _, e := range section.Entries {
+			assert.NotContains(t, buf.String(), e.Meaning, "untranslated legend entry")
+		}
+	}
+}
+
+func TestASCIIIcons(t *testing.T) {
+	for name := range NerdIcons {
+		icon, ok := ASCIIIcons[name]
+		if assert.True(t, ok, "icon %q has no ASCII variant", name) {
+			assert.Regexp(t, `^[\x21-\x7e]+$`, icon, name)
+		}
+	}
+	assert.Len(t, ASCIIIcons, len(NerdIcons))
+}
+
+func TestWriteLegend_ASCII(t *testing.T) {
+	SetASCII(true)
+	t.Cleanup(func() { SetASCII(false) })
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteLegend(&buf))
+	assert.Regexp(t, `^[\x00-\x7f]*$`, buf.String(), "no Nerd Font icons or Unicode bars")
+	assert.Contains(t, buf.String(), "$ N")
+	assert.Contains(t, buf.String(), "~ _:#")
+
+	assert.Equal(t, "_#", Sparkline([]int{0, 5}))
+	assert.Equal(t, ">=3", atLeast(3, true))
+}
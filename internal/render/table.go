@@ -0,0 +1,180 @@
+package render
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"golang.org/x/term"
+)
+
+// minColumnWidth is the narrowest a column gets squeezed to when a table
+// must fit a maximum width
+const minColumnWidth = 4
+
+// Table renders rows in columns sized to their content. Cells may contain
+// ANSI styles and wide runes. When the table is wider than MaxWidth, the
+// widest columns are narrowed and their cells wrapped onto extra lines.
+type Table struct {
+	Border      bool           // Draw a rounded box around and between cells
+	MaxWidth    int            // 0 = terminal width when stdout is a TTY, otherwise unlimited; <0 = unlimited
+	HeaderStyle lipgloss.Style // Applied to header cells
+	BorderStyle lipgloss.Style // Applied to border runes
+
+	headers []string
+	rows    [][]string
+}
+
+// NewTable creates a bordered table with the given column headers
+func NewTable(headers ...string) *Table {
+	return &Table{
+		Border:      true,
+		HeaderStyle: blueBold,
+		BorderStyle: dim,
+		headers:     headers,
+	}
+}
+
+// AddRow appends a row. Missing cells render empty.
+func (t *Table) AddRow(cells ...string) *Table {
+	t.rows = append(t.rows, cells)
+	return t
+}
+
+// TerminalWidth returns the width of the terminal attached to stdout, falling
+// back to $COLUMNS, or 0 when unknown
+func TerminalWidth() int {
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 { //nolint:gosec // Fd fits in int on all supported platforms
+		return w
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return 0
+}
+
+// String renders the table
+func (t *Table) String() string {
+	ncols := len(t.headers)
+	for _, row := range t.rows {
+		ncols = max(ncols, len(row))
+	}
+	if ncols == 0 {
+		return ""
+	}
+
+	widths := make([]int, ncols)
+	for _, row := range t.allRows() {
+		for i, cell := range row {
+			widths[i] = max(widths[i], Width(cell))
+		}
+	}
+
+	maxWidth := t.MaxWidth
+	if maxWidth == 0 {
+		maxWidth = TerminalWidth()
+	}
+	if maxWidth > 0 {
+		fitWidths(widths, maxWidth-t.overhead(ncols))
+	}
+
+	var sb strings.Builder
+	if t.Border {
+		sb.WriteString(t.rule(widths, "╭", "┬", "╮"))
+	}
+	if len(t.headers) > 0 {
+		headers := make([]string, len(t.headers))
+		for i, h := range t.headers {
+			headers[i] = t.HeaderStyle.Render(h)
+		}
+		t.writeRow(&sb, headers, widths)
+		if t.Border && len(t.rows) > 0 {
+			sb.WriteString(t.rule(widths, "├", "┼", "┤"))
+		}
+	}
+	for _, row := range t.rows {
+		t.writeRow(&sb, row, widths)
+	}
+	if t.Border {
+		sb.WriteString(t.rule(widths, "╰", "┴", "╯"))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func (t *Table) allRows() [][]string {
+	if len(t.headers) == 0 {
+		return t.rows
+	}
+	return append([][]string{t.headers}, t.rows...)
+}
+
+// overhead is the number of cells used by borders and padding
+func (t *Table) overhead(ncols int) int {
+	if t.Border {
+		return 3*ncols + 1 // "│ " before each cell, " " after, closing "│"
+	}
+	return 2 * (ncols - 1) // two spaces between columns
+}
+
+// fitWidths narrows the widest columns until they sum to at most total
+func fitWidths(widths []int, total int) {
+	for {
+		sum, widest := 0, 0
+		for i, w := range widths {
+			sum += w
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if sum <= total || widths[widest] <= minColumnWidth {
+			return
+		}
+		widths[widest]--
+	}
+}
+
+// writeRow writes one logical row, wrapping cells that exceed their column
+func (t *Table) writeRow(sb *strings.Builder, row []string, widths []int) {
+	cells := make([][]string, len(widths))
+	height := 1
+	for i := range widths {
+		cell := ""
+		if i < len(row) {
+			cell = row[i]
+		}
+		if Width(cell) > widths[i] {
+			cell = ansi.Wrap(cell, widths[i], " /-")
+		}
+		cells[i] = strings.Split(cell, "\n")
+		height = max(height, len(cells[i]))
+	}
+
+	sep := t.BorderStyle.Render("│")
+	for line := 0; line < height; line++ {
+		var parts []string
+		for i, w := range widths {
+			text := ""
+			if line < len(cells[i]) {
+				text = cells[i][line]
+			}
+			parts = append(parts, PadRight(text, w))
+		}
+		if t.Border {
+			sb.WriteString(sep + " " + strings.Join(parts, " "+sep+" ") + " " + sep)
+		} else {
+			sb.WriteString(strings.TrimRight(strings.Join(parts, "  "), " "))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// rule draws a horizontal border line
+func (t *Table) rule(widths []int, left, mid, right string) string {
+	segments := make([]string, len(widths))
+	for i, w := range widths {
+		segments[i] = strings.Repeat("─", w+2)
+	}
+	return t.BorderStyle.Render(left+strings.Join(segments, mid)+right) + "\n"
+}
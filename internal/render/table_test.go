@@ -0,0 +1,57 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTable_AutoWidth(t *testing.T) {
+	tbl := NewTable("Name", "N")
+	tbl.MaxWidth = -1
+	tbl.AddRow("日本語", "1").AddRow("a-much-longer-name", "22")
+
+	expected := strings.Join([]string{
+		"╭────────────────────┬────╮",
+		"│ Name               │ N  │",
+		"├────────────────────┼────┤",
+		"│ 日本語             │ 1  │",
+		"│ a-much-longer-name │ 22 │",
+		"╰────────────────────┴────╯",
+	}, "\n")
+	assert.Equal(t, expected, tbl.String())
+}
+
+func TestTable_Borderless(t *testing.T) {
+	tbl := NewTable("Name", "Status")
+	tbl.Border = false
+	tbl.MaxWidth = -1
+	tbl.AddRow("\x1b[32mrepo\x1b[0m", "clean").AddRow("other", "")
+
+	assert.Equal(t, "Name   Status\n\x1b[32mrepo\x1b[0m   clean\nother", tbl.String())
+}
+
+func TestTable_WrapsToMaxWidth(t *testing.T) {
+	tbl := NewTable("Repo", "Description")
+	tbl.Border = false
+	tbl.MaxWidth = 20
+	tbl.AddRow("bread", "a fairly long description here")
+
+	out := tbl.String()
+	for _, line := range strings.Split(out, "\n") {
+		assert.LessOrEqual(t, Width(line), 20, "line %q", line)
+	}
+	assert.Contains(t, out, "fairly")
+	assert.Greater(t, strings.Count(out, "\n"), 1, "long cell wraps onto extra lines")
+}
+
+func TestFitWidths(t *testing.T) {
+	widths := []int{10, 30, 5}
+	fitWidths(widths, 30)
+	assert.Equal(t, []int{10, 15, 5}, widths)
+
+	widths = []int{10, 10}
+	fitWidths(widths, 2)
+	assert.Equal(t, []int{minColumnWidth, minColumnWidth}, widths, "never narrower than the minimum")
+}
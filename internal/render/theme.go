@@ -0,0 +1,168 @@
+package render
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Role is a semantic color slot. Renderers ask for a role and the active
+// theme decides the actual color.
+type Role string
+
+const (
+	RoleSuccess Role = "success" // Your remotes, clean state, merged PRs
+	RoleBranch  Role = "branch"  // Branch names, stashes
+	RoleFork    Role = "fork"    // Fork repository names
+	RoleInfo    Role = "info"    // Commit counts, headings
+	RoleWarning Role = "warning" // Uncommitted or unpushed work, open PRs
+	RoleError   Role = "error"   // Errors, conflicts, closed PRs
+	RoleAccent  Role = "accent"  // Spinners and secondary highlights
+	RoleMuted   Role = "muted"   // Dates and secondary text (faint when unset)
+)
+
+// Roles lists every role in display order
+var Roles = []Role{RoleSuccess, RoleBranch, RoleFork, RoleInfo, RoleWarning, RoleError, RoleAccent, RoleMuted}
+
+// ThemeEnv selects the theme when no --theme flag is given
+const ThemeEnv = "GIT_THIS_BREAD_THEME"
+
+// ColorsEnv overrides individual roles, e.g. "warning=136,error=#cc0000"
+const ColorsEnv = "GIT_THIS_BREAD_COLORS"
+
+// Theme maps roles to colors. Values are ANSI numbers ("2"), 256-color
+// numbers ("136") or hex ("#cc0000").
+type Theme map[Role]string
+
+// Themes are the built-in themes. "dark" uses the terminal's own palette.
+var Themes = map[string]Theme{
+	"dark": {
+		RoleSuccess: "2",
+		RoleBranch:  "5",
+		RoleFork:    "13",
+		RoleInfo:    "4",
+		RoleWarning: "3",
+		RoleError:   "1",
+		RoleAccent:  "6",
+	},
+	"light": {
+		RoleSuccess: "28",
+		RoleBranch:  "90",
+		RoleFork:    "127",
+		RoleInfo:    "25",
+		RoleWarning: "130",
+		RoleError:   "124",
+		RoleAccent:  "30",
+		RoleMuted:   "244",
+	},
+}
+
+var activeTheme = Themes["dark"]
+
+// ThemeNames returns the built-in theme names, sorted
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadTheme activates the named theme, falling back to $GIT_THIS_BREAD_THEME,
+// then configured, the ui.theme setting, and then "dark" when name is
+// empty. Per-role overrides from $GIT_THIS_BREAD_COLORS are applied on top.
+func LoadTheme(name, configured string) error {
+	if name == "" {
+		name = os.Getenv(ThemeEnv)
+	}
+	fromConfig := name == "" && configured != ""
+	if fromConfig {
+		name = configured
+	}
+	if name == "" {
+		name = "dark"
+	}
+	base, ok := Themes[name]
+	if !ok {
+		err := fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(ThemeNames(), ", "))
+		if fromConfig {
+			return fmt.Errorf("ui.theme: %w", err)
+		}
+		return err
+	}
+
+	theme := make(Theme, len(base))
+	for role, color := range base {
+		theme[role] = color
+	}
+	overrides, err := parseColorOverrides(os.Getenv(ColorsEnv))
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", ColorsEnv, err)
+	}
+	for role, color := range overrides {
+		theme[role] = color
+	}
+
+	activeTheme = theme
+	applyTheme()
+	return nil
+}
+
+// parseColorOverrides parses "role=color,role=color"
+func parseColorOverrides(spec string) (Theme, error) {
+	overrides := Theme{}
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		role, color, ok := strings.Cut(pair, "=")
+		if !ok || color == "" {
+			return nil, fmt.Errorf("expected role=color, got %q", pair)
+		}
+		if !isRole(Role(role)) {
+			return nil, fmt.Errorf("unknown color role %q", role)
+		}
+		overrides[Role(role)] = color
+	}
+	return overrides, nil
+}
+
+func isRole(r Role) bool {
+	for _, known := range Roles {
+		if r == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Style returns the base style for a role in the active theme
+func Style(r Role) lipgloss.Style {
+	color, ok := activeTheme[r]
+	if !ok || color == "" {
+		if r == RoleMuted {
+			return lipgloss.NewStyle().Faint(true)
+		}
+		return lipgloss.NewStyle()
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+}
+
+// applyTheme rebuilds the package styles from the active theme
+func applyTheme() {
+	green = Style(RoleSuccess)
+	greenBold = Style(RoleSuccess).Bold(true)
+	magenta = Style(RoleBranch)
+	magentaBold = Style(RoleFork).Bold(true)
+	blueBold = Style(RoleInfo).Bold(true)
+	yellow = Style(RoleWarning)
+	red = Style(RoleError)
+	redBold = Style(RoleError).Bold(true)
+	dim = Style(RoleMuted)
+	dimItalic = Style(RoleMuted).Italic(true)
+}
@@ -0,0 +1,59 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseColorOverrides(t *testing.T) {
+	overrides, err := parseColorOverrides("warning=136, error=#cc0000,")
+	require.NoError(t, err)
+	assert.Equal(t, Theme{RoleWarning: "136", RoleError: "#cc0000"}, overrides)
+
+	_, err = parseColorOverrides("bogus=1")
+	assert.ErrorContains(t, err, "unknown color role")
+
+	_, err = parseColorOverrides("warning")
+	assert.ErrorContains(t, err, "expected role=color")
+}
+
+func TestLoadTheme(t *testing.T) {
+	defer func() { _ = LoadTheme("dark", "") }()
+
+	t.Run("env selects theme and overrides roles", func(t *testing.T) {
+		t.Setenv(ThemeEnv, "light")
+		t.Setenv(ColorsEnv, "error=9")
+		require.NoError(t, LoadTheme("", ""))
+		assert.Equal(t, "9", activeTheme[RoleError])
+		assert.Equal(t, Themes["light"][RoleWarning], activeTheme[RoleWarning])
+		assert.Equal(t, "1", Themes["dark"][RoleError], "built-in theme must not be modified")
+	})
+
+	t.Run("flag wins over env", func(t *testing.T) {
+		t.Setenv(ThemeEnv, "light")
+		t.Setenv(ColorsEnv, "")
+		require.NoError(t, LoadTheme("dark", ""))
+		assert.Equal(t, Themes["dark"][RoleWarning], activeTheme[RoleWarning])
+	})
+
+	t.Run("env wins over config", func(t *testing.T) {
+		t.Setenv(ThemeEnv, "dark")
+		t.Setenv(ColorsEnv, "")
+		require.NoError(t, LoadTheme("", "light"))
+		assert.Equal(t, Themes["dark"][RoleWarning], activeTheme[RoleWarning])
+	})
+
+	t.Run("config without flag or env", func(t *testing.T) {
+		t.Setenv(ThemeEnv, "")
+		t.Setenv(ColorsEnv, "")
+		require.NoError(t, LoadTheme("", "light"))
+		assert.Equal(t, Themes["light"][RoleWarning], activeTheme[RoleWarning])
+		assert.ErrorContains(t, LoadTheme("", "neon"), `ui.theme: unknown theme "neon"`)
+	})
+
+	t.Run("unknown theme", func(t *testing.T) {
+		assert.ErrorContains(t, LoadTheme("neon", ""), "available: dark, light")
+	})
+}
@@ -0,0 +1,41 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/mattn/go-runewidth"
+)
+
+// truncateTail marks text cut short by Truncate
+const truncateTail = "..."
+
+// Width returns the number of terminal cells s occupies, ignoring ANSI
+// escape sequences and counting wide (CJK, emoji) runes as two cells.
+func Width(s string) int {
+	return runewidth.StringWidth(ansi.Strip(s))
+}
+
+// Truncate shortens s to at most width terminal cells, ending with "..."
+// when anything was cut. Styled input keeps its escape sequences intact.
+func Truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if Width(s) <= width {
+		return s
+	}
+	if ansi.Strip(s) != s {
+		return ansi.Truncate(s, width, truncateTail)
+	}
+	return runewidth.Truncate(s, width, truncateTail)
+}
+
+// PadRight pads s with spaces to width terminal cells. Unlike %-*s it is not
+// fooled by escape sequences or wide runes. Longer strings are returned as is.
+func PadRight(s string, width int) string {
+	if w := Width(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		width int
+	}{
+		{"ascii", "hello", 5},
+		{"cjk", "日本語", 6},
+		{"emoji", "🍞 bread", 8},
+		{"ansi styled", "\x1b[31mred\x1b[0m", 3},
+		{"empty", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.width, Width(tt.input))
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		width    int
+		expected string
+	}{
+		{"fits", "short", 10, "short"},
+		{"ascii", "a very long title", 10, "a very ..."},
+		{"cjk does not split runes", "日本語のタイトル", 9, "日本語..."},
+		{"emoji", "🍞🥖🥯🥨🥞", 7, "🍞🥖..."},
+		{"zero width", "anything", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Truncate(tt.input, tt.width)
+			assert.Equal(t, tt.expected, got)
+			assert.LessOrEqual(t, Width(got), tt.width)
+		})
+	}
+}
+
+func TestTruncate_Styled(t *testing.T) {
+	defer lipgloss.SetColorProfile(lipgloss.ColorProfile())
+	lipgloss.SetColorProfile(termenv.ANSI)
+
+	styled := lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render("a very long title")
+	got := Truncate(styled, 10)
+
+	assert.Equal(t, "a very ...", ansi.Strip(got))
+	assert.Contains(t, got, "\x1b[", "styling is preserved")
+	assert.Equal(t, 10, Width(got))
+}
+
+func TestPadRight(t *testing.T) {
+	assert.Equal(t, "ab   ", PadRight("ab", 5))
+	assert.Equal(t, "日本 ", PadRight("日本", 5))
+	assert.Equal(t, "\x1b[31mab\x1b[0m   ", PadRight("\x1b[31mab\x1b[0m", 5))
+	assert.Equal(t, "toolong", PadRight("toolong", 3))
+}
@@ -0,0 +1,27 @@
+package render
+
+import (
+	"fmt"
+	"io"
+)
+
+// errWriter wraps an io.Writer and remembers the first write error, so
+// renderers can print freely and check once at the end
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) printf(format string, args ...any) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = fmt.Fprintf(e.w, format, args...)
+}
+
+func (e *errWriter) println(args ...any) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = fmt.Fprintln(e.w, args...)
+}
@@ -0,0 +1,242 @@
+// Package selfupdate finds the latest git-this-bread release on GitHub and
+// replaces a running binary with the one from that release's archive
+package selfupdate
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+)
+
+// Repo is the GitHub repository releases come from
+const Repo = "jdevera/git-this-bread"
+
+// maxArchiveSize caps how much of a release asset is read into memory
+const maxArchiveSize = 100 << 20
+
+// Release is a published GitHub release
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	URL    string  `json:"html_url"`
+	Assets []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Version returns the release version without the leading "v"
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+// asset finds an asset by file name
+func (r *Release) asset(name string) (Asset, error) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, nil
+		}
+	}
+	return Asset{}, fmt.Errorf("release %s has no %s", r.Tag, name)
+}
+
+// Client talks to the GitHub API
+type Client struct {
+	HTTP    *http.Client
+	BaseURL string // API root, https://api.github.com unless testing
+	Token   string // Optional; sent with API requests to act as a GitHub user
+}
+
+// NewClient returns a client for api.github.com. token may be empty.
+func NewClient(token string) *Client {
+	return &Client{
+		HTTP:    &http.Client{Transport: debuglog.Transport(http.DefaultTransport)},
+		BaseURL: "https://api.github.com",
+		Token:   token,
+	}
+}
+
+// Latest returns the newest published release
+func (c *Client) Latest(ctx context.Context) (*Release, error) {
+	body, err := c.get(ctx, c.BaseURL+"/repos/"+Repo+"/releases/latest", true)
+	if err != nil {
+		return nil, fmt.Errorf("checking the latest release: %w", err)
+	}
+	var rel Release
+	if err := json.Unmarshal(body, &rel); err != nil {
+		return nil, fmt.Errorf("checking the latest release: %w", err)
+	}
+	return &rel, nil
+}
+
+// get fetches url; the token only goes to the API, never to download hosts
+func (c *Client) get(ctx context.Context, url string, api bool) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	if api {
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if c.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.Token)
+		}
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxArchiveSize))
+}
+
+// Newer reports whether latest is a later version than current. Both are
+// MAJOR.MINOR.PATCH, with or without a leading "v"; current is an error when
+// it isn't one, as development builds have no release to compare with.
+func Newer(current, latest string) (bool, error) {
+	cur, err := parseVersion(current)
+	if err != nil {
+		return false, fmt.Errorf("%q is not a release version", current)
+	}
+	lat, err := parseVersion(latest)
+	if err != nil {
+		return false, fmt.Errorf("latest release %q is not a release version", latest)
+	}
+	for i := range cur {
+		if lat[i] != cur[i] {
+			return lat[i] > cur[i], nil
+		}
+	}
+	return false, nil
+}
+
+func parseVersion(v string) ([3]int, error) {
+	var parts [3]int
+	fields := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(fields) != len(parts) {
+		return parts, fmt.Errorf("invalid version %q", v)
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return parts, fmt.Errorf("invalid version %q", v)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
+
+// ArchiveName returns the name of the release archive that holds every tool
+// for one platform, as GoReleaser names it
+func ArchiveName(version, goos, goarch string) string {
+	return fmt.Sprintf("git-this-bread_%s_%s_%s.tar.gz", version, goos, goarch)
+}
+
+// Fetch downloads the archive for goos/goarch from rel, checks it against
+// the release checksums and returns the named binary from it
+func (c *Client) Fetch(ctx context.Context, rel *Release, goos, goarch, binary string) ([]byte, error) {
+	name := ArchiveName(rel.Version(), goos, goarch)
+	archiveAsset, err := rel.asset(name)
+	if err != nil {
+		return nil, err
+	}
+	sumsAsset, err := rel.asset("checksums.txt")
+	if err != nil {
+		return nil, err
+	}
+	sums, err := c.get(ctx, sumsAsset.URL, false)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := c.get(ctx, archiveAsset.URL, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(archive, name, sums); err != nil {
+		return nil, err
+	}
+	return extract(archive, binary)
+}
+
+// verify checks data against the entry for name in a sha256sum-style
+// checksums file
+func verify(data []byte, name string, sums []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[1] != name {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != fields[0] {
+			return fmt.Errorf("checksum mismatch for %s", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("no checksum for %s", name)
+}
+
+// extract returns the file called name from the top of a .tar.gz archive
+func extract(archive []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s is not in the release archive", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag == tar.TypeReg && hdr.Name == name {
+			return io.ReadAll(io.LimitReader(tr, maxArchiveSize))
+		}
+	}
+}
+
+// Replace swaps the executable at path for data. The new file is written
+// next to it and renamed over it, so a failure leaves the old one in place.
+func Replace(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".new-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,135 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		want            bool
+	}{
+		{"1.2.3", "1.2.4", true},
+		{"v1.2.3", "1.10.0", true},
+		{"1.2.3", "2.0.0", true},
+		{"1.2.3", "1.2.3", false},
+		{"1.3.0", "1.2.9", false},
+	}
+	for _, tt := range tests {
+		got, err := Newer(tt.current, tt.latest)
+		require.NoError(t, err, tt.current)
+		assert.Equal(t, tt.want, got, "%s -> %s", tt.current, tt.latest)
+	}
+
+	_, err := Newer("dev", "1.0.0")
+	assert.ErrorContains(t, err, "not a release version")
+	_, err = Newer("v0.0.0-20261016201613-d0ddbc368175+dirty", "1.0.0")
+	assert.Error(t, err)
+}
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+// releaseServer serves a latest release with one archive and its checksums
+func releaseServer(t *testing.T, archive []byte, sum string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	name := ArchiveName("1.4.0", "linux", "amd64")
+
+	mux.HandleFunc("/repos/"+Repo+"/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		_ = json.NewEncoder(w).Encode(Release{
+			Tag: "v1.4.0",
+			Assets: []Asset{
+				{Name: name, URL: srv.URL + "/dl/" + name},
+				{Name: "checksums.txt", URL: srv.URL + "/dl/checksums.txt"},
+			},
+		})
+	})
+	mux.HandleFunc("/dl/", func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"), "the token only goes to the API")
+		switch filepath.Base(r.URL.Path) {
+		case name:
+			_, _ = w.Write(archive)
+		case "checksums.txt":
+			_, _ = w.Write([]byte(sum + "  " + name + "\n"))
+		}
+	})
+	return srv
+}
+
+func TestFetch(t *testing.T) {
+	archive := tarGz(t, map[string]string{"bread": "new bread", "git-as": "new git-as", "README.md": "docs"})
+	sum := sha256.Sum256(archive)
+	srv := releaseServer(t, archive, hex.EncodeToString(sum[:]))
+
+	client := &Client{HTTP: srv.Client(), BaseURL: srv.URL, Token: "secret"}
+	rel, err := client.Latest(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "1.4.0", rel.Version())
+
+	data, err := client.Fetch(context.Background(), rel, "linux", "amd64", "bread")
+	require.NoError(t, err)
+	assert.Equal(t, "new bread", string(data))
+
+	_, err = client.Fetch(context.Background(), rel, "linux", "amd64", "gh-nope")
+	assert.ErrorContains(t, err, "not in the release archive")
+	_, err = client.Fetch(context.Background(), rel, "plan9", "amd64", "bread")
+	assert.ErrorContains(t, err, "has no git-this-bread_1.4.0_plan9_amd64.tar.gz")
+}
+
+func TestFetch_ChecksumMismatch(t *testing.T) {
+	archive := tarGz(t, map[string]string{"bread": "tampered"})
+	srv := releaseServer(t, archive, hex.EncodeToString(make([]byte, sha256.Size)))
+
+	client := &Client{HTTP: srv.Client(), BaseURL: srv.URL, Token: "secret"}
+	rel, err := client.Latest(context.Background())
+	require.NoError(t, err)
+	_, err = client.Fetch(context.Background(), rel, "linux", "amd64", "bread")
+	assert.ErrorContains(t, err, "checksum mismatch")
+}
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bread")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0o755)) // #nosec G306 -- an executable
+
+	require.NoError(t, Replace(path, []byte("new")))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temp file is left behind")
+}
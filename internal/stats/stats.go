@@ -0,0 +1,146 @@
+// Package stats keeps local usage statistics: which commands and flags run,
+// and how long they take. Recording is opt-in (stats.enabled in
+// config.toml) and the file never leaves the machine.
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/jdevera/git-this-bread/internal/paths"
+)
+
+// Record is one command run. Flag names are kept, never their values or
+// the arguments, which can hold paths and names.
+type Record struct {
+	Time     time.Time     `json:"time"`
+	Command  string        `json:"command"`         // Command path, like "git-id add" or "bread explain"
+	Flags    []string      `json:"flags,omitempty"` // Names of the flags given
+	Duration time.Duration `json:"duration"`
+	Failed   bool          `json:"failed,omitempty"`
+}
+
+// Path returns the stats file, under XDG_STATE_HOME
+func Path() (string, error) {
+	stateHome, err := paths.StateHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateHome, "git-this-bread", "stats.jsonl"), nil
+}
+
+// Append adds r to the stats file at path, one JSON object per line
+func Append(path string, r *Record) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //nolint:gosec // path is the stats file
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads every record in the stats file at path. A missing file has
+// none; lines that don't parse, such as one cut short, are skipped.
+func Load(path string) ([]Record, error) {
+	f, err := os.Open(path) //nolint:gosec // path is the stats file
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		if json.Unmarshal(scanner.Bytes(), &r) == nil {
+			records = append(records, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// Summary aggregates the runs of one command
+type Summary struct {
+	Command string         `json:"command"`
+	Runs    int            `json:"runs"`
+	Failed  int            `json:"failed"`
+	Total   time.Duration  `json:"total"`
+	Max     time.Duration  `json:"max"`
+	Last    time.Time      `json:"last"`
+	Flags   map[string]int `json:"flags,omitempty"` // Runs each flag was given in
+}
+
+// Average returns the mean duration of a run
+func (s *Summary) Average() time.Duration {
+	if s.Runs == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Runs)
+}
+
+// Summarize groups records by command, most run first, keeping only the
+// ones since the given time (all when zero)
+func Summarize(records []Record, since time.Time) []Summary {
+	byCommand := make(map[string]*Summary)
+	for i := range records {
+		r := &records[i]
+		if r.Time.Before(since) {
+			continue
+		}
+		s := byCommand[r.Command]
+		if s == nil {
+			s = &Summary{Command: r.Command}
+			byCommand[r.Command] = s
+		}
+		s.Runs++
+		if r.Failed {
+			s.Failed++
+		}
+		s.Total += r.Duration
+		s.Max = max(s.Max, r.Duration)
+		if r.Time.After(s.Last) {
+			s.Last = r.Time
+		}
+		for _, flag := range r.Flags {
+			if s.Flags == nil {
+				s.Flags = make(map[string]int)
+			}
+			s.Flags[flag]++
+		}
+	}
+
+	summaries := make([]Summary, 0, len(byCommand))
+	for _, s := range byCommand {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Runs != summaries[j].Runs {
+			return summaries[i].Runs > summaries[j].Runs
+		}
+		return summaries[i].Command < summaries[j].Command
+	})
+	return summaries
+}
@@ -0,0 +1,72 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/state")
+	path, err := Path()
+	require.NoError(t, err)
+	assert.Equal(t, "/state/git-this-bread/stats.jsonl", path)
+}
+
+func TestAppendLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "stats.jsonl")
+	records, err := Load(path)
+	require.NoError(t, err)
+	assert.Empty(t, records, "a missing file has no records")
+
+	now := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	in := []Record{
+		{Time: now, Command: "git-explain", Flags: []string{"advice"}, Duration: time.Second},
+		{Time: now.Add(time.Minute), Command: "bread wip", Duration: 2 * time.Second, Failed: true},
+	}
+	for i := range in {
+		require.NoError(t, Append(path, &in[i]))
+	}
+
+	// A line cut short by a crash is skipped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"time":"2026-`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	records, err = Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, in, records)
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Time: now.Add(-48 * time.Hour), Command: "gh-wtfork", Duration: 9 * time.Second},
+		{Time: now, Command: "git-explain", Flags: []string{"advice", "json"}, Duration: time.Second},
+		{Time: now.Add(time.Hour), Command: "git-explain", Flags: []string{"advice"}, Duration: 3 * time.Second, Failed: true},
+		{Time: now, Command: "git-wip", Duration: time.Second},
+	}
+
+	summaries := Summarize(records, time.Time{})
+	require.Len(t, summaries, 3)
+	assert.Equal(t, Summary{
+		Command: "git-explain", Runs: 2, Failed: 1,
+		Total: 4 * time.Second, Max: 3 * time.Second, Last: now.Add(time.Hour),
+		Flags: map[string]int{"advice": 2, "json": 1},
+	}, summaries[0])
+	assert.Equal(t, 2*time.Second, summaries[0].Average())
+	assert.Equal(t, []string{"gh-wtfork", "git-wip"}, []string{summaries[1].Command, summaries[2].Command}, "ties by name")
+
+	recent := Summarize(records, now.Add(-time.Hour))
+	require.Len(t, recent, 2)
+	assert.Equal(t, "git-explain", recent[0].Command)
+	assert.Equal(t, "git-wip", recent[1].Command)
+
+	assert.Zero(t, (&Summary{}).Average())
+}
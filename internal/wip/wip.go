@@ -0,0 +1,254 @@
+// Package wip saves work that only lives in a working tree or the stash
+// list as a commit on a wip/<date> branch, without touching the working
+// tree, the index or the stashes themselves.
+package wip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jdevera/git-this-bread/internal/debuglog"
+)
+
+// Prefix is the branch namespace snapshots are saved under
+const Prefix = "wip/"
+
+// Snapshot describes the work saved from a repository
+type Snapshot struct {
+	Branch  string // wip/<date> branch holding the snapshot
+	Commit  string // Snapshot commit; empty on a dry run
+	Files   int    // Uncommitted files saved
+	Stashes int    // Stash entries saved
+	Exists  bool   // The same work was already saved on Branch
+}
+
+// Options controls how snapshots are made
+type Options struct {
+	Env    []string  // Extra environment for git, such as an identity's author
+	Now    time.Time // Date the branch is named after (default now)
+	DryRun bool      // Work out the snapshot without creating it
+}
+
+// Save snapshots the uncommitted changes (untracked files included, ignored
+// ones left out) and stashes of the repository at dir. The snapshot commit
+// has the working tree as its tree and HEAD and every stash as parents, so
+// checking out the branch shows the dangling changes and the stashes stay
+// reachable after they are dropped. It returns nil when there is nothing to
+// save.
+func Save(ctx context.Context, dir string, opts Options) (*Snapshot, error) {
+	g := &git{ctx: ctx, dir: dir, env: opts.Env}
+
+	head, _ := g.run("rev-parse", "-q", "--verify", "HEAD^{commit}")
+	tree, err := g.worktreeTree()
+	if err != nil {
+		return nil, err
+	}
+	stashes, err := g.lines("stash", "list", "--format=%H")
+	if err != nil {
+		return nil, err
+	}
+
+	files := 0
+	if head != "" {
+		changed, err := g.lines("diff-tree", "-r", "--name-only", head, tree)
+		if err != nil {
+			return nil, err
+		}
+		files = len(changed)
+	} else {
+		all, err := g.lines("ls-tree", "-r", "--name-only", tree)
+		if err != nil {
+			return nil, err
+		}
+		files = len(all)
+	}
+	if files == 0 && len(stashes) == 0 {
+		return nil, nil
+	}
+
+	var parents []string
+	if head != "" {
+		parents = append(parents, head)
+	}
+	parents = append(parents, stashes...)
+	snap := &Snapshot{Files: files, Stashes: len(stashes)}
+
+	if branch, commit, err := g.findExisting(tree, parents); err != nil {
+		return nil, err
+	} else if branch != "" {
+		snap.Branch, snap.Commit, snap.Exists = branch, commit, true
+		return snap, nil
+	}
+
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if snap.Branch, err = g.freeBranch(Prefix + now.Format("2006-01-02")); err != nil {
+		return nil, err
+	}
+	if opts.DryRun {
+		return snap, nil
+	}
+
+	args := []string{"commit-tree", tree, "-m", g.message(files, len(stashes))}
+	for _, p := range parents {
+		args = append(args, "-p", p)
+	}
+	if snap.Commit, err = g.run(args...); err != nil {
+		return nil, err
+	}
+	// An empty old value makes update-ref refuse to overwrite a branch
+	// created since freeBranch looked
+	if _, err := g.run("update-ref", "-m", "git-wip", "refs/heads/"+snap.Branch, snap.Commit, ""); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// Push sends a snapshot branch to remote
+func Push(ctx context.Context, dir, remote, branch string, env []string) error {
+	ref := "refs/heads/" + branch
+	cmd := exec.CommandContext(ctx, "git", "-C", dir, "push", "--quiet", remote, ref+":"+ref)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Env = append(cmd.Env, env...)
+	if out, err := debuglog.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("push %s to %s: %s", branch, remote, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// git runs git commands in one repository
+type git struct {
+	ctx context.Context
+	dir string
+	env []string
+}
+
+// run runs git and returns its trimmed stdout
+func (g *git) run(args ...string) (string, error) {
+	return g.runEnv(nil, args...)
+}
+
+func (g *git) runEnv(env []string, args ...string) (string, error) {
+	cmd := exec.CommandContext(g.ctx, "git", append([]string{"-C", g.dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Env = append(cmd.Env, g.env...)
+	cmd.Env = append(cmd.Env, env...)
+	out, err := debuglog.Output(cmd)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// lines runs git and splits its output into lines
+func (g *git) lines(args ...string) ([]string, error) {
+	out, err := g.run(args...)
+	if err != nil || out == "" {
+		return nil, err
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// worktreeTree writes the working tree as git add -A would stage it, into
+// a copy of the index so the real one is left alone
+func (g *git) worktreeTree() (string, error) {
+	index, err := g.run("rev-parse", "--path-format=absolute", "--git-path", "index")
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(index), "wip-index-*")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	// Starting from the real index keeps its stat data, so unchanged files
+	// aren't hashed again. Without one, git wants no file rather than an
+	// empty one.
+	src, err := os.Open(index) //nolint:gosec // path comes from git
+	if err == nil {
+		_, err = io.Copy(tmp, src)
+		_ = src.Close()
+	} else if errors.Is(err, os.ErrNotExist) {
+		err = os.Remove(tmp.Name())
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	env := []string{"GIT_INDEX_FILE=" + tmp.Name()}
+	if _, err := g.runEnv(env, "add", "-A"); err != nil {
+		return "", err
+	}
+	return g.runEnv(env, "write-tree")
+}
+
+// findExisting returns a wip branch that already holds tree with these
+// parents, so running twice doesn't save the same work twice
+func (g *git) findExisting(tree string, parents []string) (branch, commit string, err error) {
+	refs, err := g.lines("for-each-ref", "--format=%(refname:short) %(objectname) %(tree) %(parent)", "refs/heads/"+Prefix)
+	if err != nil {
+		return "", "", err
+	}
+	want := strings.Join(parents, " ")
+	for _, ref := range refs {
+		fields := strings.SplitN(ref, " ", 4)
+		if len(fields) < 3 {
+			continue
+		}
+		got := ""
+		if len(fields) == 4 {
+			got = fields[3]
+		}
+		if fields[2] == tree && got == want {
+			return fields[0], fields[1], nil
+		}
+	}
+	return "", "", nil
+}
+
+// freeBranch returns name, or name-2, name-3, ... when it is taken
+func (g *git) freeBranch(name string) (string, error) {
+	for i := 1; ; i++ {
+		candidate := name
+		if i > 1 {
+			candidate = name + "-" + strconv.Itoa(i)
+		}
+		if _, err := g.run("rev-parse", "-q", "--verify", "refs/heads/"+candidate); err != nil {
+			return candidate, nil
+		}
+	}
+}
+
+// message describes the snapshot, naming the branch it was taken on
+func (g *git) message(files, stashes int) string {
+	branch, err := g.run("symbolic-ref", "-q", "--short", "HEAD")
+	if err != nil || branch == "" {
+		branch = "detached HEAD"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "WIP on %s\n\n", branch)
+	if files > 0 {
+		fmt.Fprintf(&b, "Uncommitted changes: %d file(s), in this commit's tree.\n", files)
+	}
+	if stashes > 0 {
+		fmt.Fprintf(&b, "Stashes: %d, as the parents after the first.\n", stashes)
+	}
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package wip
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jdevera/git-this-bread/testutil"
+)
+
+var day = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+func TestSave_Clean(t *testing.T) {
+	repo := testutil.NewTestRepo(t)
+	repo.WriteFile("a.txt", "a")
+	repo.Commit("init")
+
+	snap, err := Save(context.Background(), repo.Path, Options{Now: day})
+	require.NoError(t, err)
+	assert.Nil(t, snap)
+}
+
+func TestSave_ChangesAndStashes(t *testing.T) {
+	ctx := context.Background()
+	repo := testutil.NewTestRepo(t)
+	repo.WriteFile("a.txt", "a")
+	repo.Commit("init")
+	repo.WriteFile("a.txt", "stashed")
+	repo.Stash()
+	repo.WriteFile("a.txt", "changed")
+	repo.WriteFile("new.txt", "untracked")
+	repo.WriteFile(".gitignore", "*.log\n")
+	repo.WriteFile("debug.log", "ignored")
+	repo.Stage(".gitignore")
+	status := repo.Git("status", "--porcelain")
+
+	snap, err := Save(ctx, repo.Path, Options{Now: day})
+	require.NoError(t, err)
+	require.NotNil(t, snap)
+	assert.Equal(t, "wip/2026-10-16", snap.Branch)
+	assert.Equal(t, 3, snap.Files)
+	assert.Equal(t, 1, snap.Stashes)
+	assert.False(t, snap.Exists)
+
+	// The snapshot holds the working tree, minus ignored files
+	assert.Equal(t, "changed", repo.Git("show", snap.Branch+":a.txt"))
+	assert.Equal(t, "untracked", repo.Git("show", snap.Branch+":new.txt"))
+	_, err = repo.GitMayFail("show", snap.Branch+":debug.log")
+	assert.Error(t, err)
+
+	// HEAD and the stash are its parents
+	parents := strings.Fields(repo.Git("rev-list", "--parents", "-n1", snap.Branch))[1:]
+	assert.Equal(t, []string{
+		strings.TrimSpace(repo.Git("rev-parse", "HEAD")),
+		strings.TrimSpace(repo.Git("rev-parse", "stash@{0}")),
+	}, parents)
+
+	// Nothing in the repository changed
+	assert.Equal(t, status, repo.Git("status", "--porcelain"))
+	assert.Equal(t, "1\n", repo.Git("rev-list", "--walk-reflogs", "--count", "refs/stash"))
+
+	// Saving the same work again finds the first snapshot
+	again, err := Save(ctx, repo.Path, Options{Now: day.Add(24 * time.Hour)})
+	require.NoError(t, err)
+	assert.True(t, again.Exists)
+	assert.Equal(t, snap.Branch, again.Branch)
+	assert.Equal(t, snap.Commit, again.Commit)
+
+	// New work the same day gets its own branch
+	repo.WriteFile("new.txt", "more")
+	third, err := Save(ctx, repo.Path, Options{Now: day})
+	require.NoError(t, err)
+	assert.Equal(t, "wip/2026-10-16-2", third.Branch)
+}
+
+func TestSave_DryRun(t *testing.T) {
+	repo := testutil.NewTestRepo(t)
+	repo.WriteFile("a.txt", "a")
+	repo.Commit("init")
+	repo.WriteFile("a.txt", "b")
+
+	snap, err := Save(context.Background(), repo.Path, Options{Now: day, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, "wip/2026-10-16", snap.Branch)
+	assert.Empty(t, snap.Commit)
+	assert.Empty(t, repo.Git("branch", "--list", "wip/*"))
+}
+
+func TestSave_NoCommits(t *testing.T) {
+	repo := testutil.NewTestRepo(t)
+	repo.WriteFile("a.txt", "a")
+
+	snap, err := Save(context.Background(), repo.Path, Options{Now: day})
+	require.NoError(t, err)
+	assert.Equal(t, 1, snap.Files)
+	assert.Equal(t, "a", repo.Git("show", snap.Branch+":a.txt"))
+}
+
+func TestPush(t *testing.T) {
+	ctx := context.Background()
+	remote := testutil.NewTestRepo(t)
+	remote.Git("config", "receive.denyCurrentBranch", "ignore")
+
+	repo := testutil.NewTestRepo(t)
+	repo.WriteFile("a.txt", "a")
+	repo.Commit("init")
+	repo.AddRemote("fork", remote.Path)
+	repo.WriteFile("a.txt", "b")
+
+	snap, err := Save(ctx, repo.Path, Options{Now: day})
+	require.NoError(t, err)
+	require.NoError(t, Push(ctx, repo.Path, "fork", snap.Branch, nil))
+	assert.Equal(t, snap.Commit, strings.TrimSpace(remote.Git("rev-parse", snap.Branch)))
+
+	err = Push(ctx, repo.Path, "nowhere", snap.Branch, nil)
+	assert.ErrorContains(t, err, "push wip/2026-10-16 to nowhere")
+}
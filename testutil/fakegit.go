@@ -0,0 +1,81 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// FakeGit is an in-memory git runner for unit tests. It satisfies
+// analyzer.GitRunner: responses are keyed on the space-joined arguments
+// (without -C), and unregistered commands fail with exit code 1.
+type FakeGit struct {
+	mu        sync.Mutex
+	responses map[string]fakeResult
+	calls     []string
+}
+
+type fakeResult struct {
+	out  string
+	code int
+}
+
+// ExitError is returned by FakeGit for commands that exit non-zero
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string { return fmt.Sprintf("exit status %d", e.Code) }
+
+// ExitCode returns the simulated exit code
+func (e *ExitError) ExitCode() int { return e.Code }
+
+// NewFakeGit creates an empty fake runner.
+func NewFakeGit() *FakeGit {
+	return &FakeGit{responses: map[string]fakeResult{}}
+}
+
+// On registers stdout for a successful command, e.g. On("status --porcelain", " M a.go\n").
+func (f *FakeGit) On(args, out string) *FakeGit {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[args] = fakeResult{out: out}
+	return f
+}
+
+// Fail registers a command that exits with the given code.
+func (f *FakeGit) Fail(args string, code int) *FakeGit {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[args] = fakeResult{code: code}
+	return f
+}
+
+// Run returns the registered response for args and records the call.
+func (f *FakeGit) Run(ctx context.Context, _ string, args ...string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	key := strings.Join(args, " ")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, key)
+
+	res, ok := f.responses[key]
+	if !ok {
+		return "", &ExitError{Code: 1}
+	}
+	if res.code != 0 {
+		return "", &ExitError{Code: res.code}
+	}
+	return res.out, nil
+}
+
+// Calls returns the commands run so far, in order.
+func (f *FakeGit) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}